@@ -9,8 +9,13 @@ import (
 	"os"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/cli"
+	_ "github.com/mahendrapaipuri/ceems/pkg/api/resource/file"
+	_ "github.com/mahendrapaipuri/ceems/pkg/api/resource/htcondor"
+	_ "github.com/mahendrapaipuri/ceems/pkg/api/resource/k8s"
 	_ "github.com/mahendrapaipuri/ceems/pkg/api/resource/openstack"
+	_ "github.com/mahendrapaipuri/ceems/pkg/api/resource/pbs"
 	_ "github.com/mahendrapaipuri/ceems/pkg/api/resource/slurm"
+	_ "github.com/mahendrapaipuri/ceems/pkg/api/updater/exec"
 	_ "github.com/mahendrapaipuri/ceems/pkg/api/updater/tsdb"
 )
 
@@ -0,0 +1,135 @@
+//go:build cgo
+// +build cgo
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	ceems_db "github.com/mahendrapaipuri/ceems/pkg/api/db"
+	ceems_http "github.com/mahendrapaipuri/ceems/pkg/api/http"
+)
+
+// dbLogger returns a logger for DB commands. Only warnings and errors are logged
+// so that they do not get mixed with the output of commands.
+func dbLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+}
+
+// DBInfo prints schema version, size and row counts of tables of DB.
+func DBInfo(ctx context.Context, dbPath string) error {
+	info, err := ceems_db.Inspect(ctx, dbPath, dbLogger())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Path:\t%s\n", info.Path)
+	fmt.Fprintf(w, "Size:\t%d bytes\n", info.SizeBytes)
+	fmt.Fprintf(w, "Schema version:\t%d (latest: %d, dirty: %t)\n", info.SchemaVersion, info.LatestVersion, info.Dirty)
+	fmt.Fprintln(w, "Row counts:")
+
+	tables := make([]string, 0, len(info.RowCounts))
+	for table := range info.RowCounts {
+		tables = append(tables, table)
+	}
+
+	slices.Sort(tables)
+
+	for _, table := range tables {
+		fmt.Fprintf(w, "  %s\t%d\n", table, info.RowCounts[table])
+	}
+
+	return w.Flush()
+}
+
+// DBQuery queries units or usage from DB and prints the response in JSON format.
+func DBQuery(ctx context.Context, dbPath string, resource string, args dbQueryArgs) error {
+	params, err := args.values()
+	if err != nil {
+		return err
+	}
+
+	return dbQuery(ctx, dbPath, resource, args.mode, params, os.Stdout)
+}
+
+// DBExport exports units or usage from DB in CSV or JSON format. JSON exports
+// contain one object per line.
+func DBExport(ctx context.Context, dbPath string, resource string, format string, output string, args dbQueryArgs) error {
+	params, err := args.values()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "csv":
+		params.Set("format", "csv")
+	case "json":
+		params.Set("format", "ndjson")
+	default:
+		return fmt.Errorf("invalid export format %s. Supported formats are csv and json", format)
+	}
+
+	// Write to stdout when output file is not set
+	if output == "" || output == "-" {
+		return dbQuery(ctx, dbPath, resource, args.mode, params, os.Stdout)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer f.Close()
+
+	if err := dbQuery(ctx, dbPath, resource, args.mode, params, f); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Exported", resource, "to", output)
+
+	return nil
+}
+
+// DBRestore restores DB from a backup.
+func DBRestore(ctx context.Context, dbPath string, backupPath string) error {
+	if err := ceems_db.Restore(ctx, backupPath, dbPath, dbLogger()); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "DB", dbPath, "restored from", backupPath)
+
+	return nil
+}
+
+// DBVacuum vacuums DB.
+func DBVacuum(ctx context.Context, dbPath string) error {
+	if err := ceems_db.Vacuum(ctx, dbPath); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "DB", dbPath, "vacuumed")
+
+	return nil
+}
+
+// dbQuery queries resource using the handlers of API server and writes
+// response to w.
+// Errors are returned by querier and hence, logs of handlers are discarded.
+func dbQuery(ctx context.Context, dbPath string, resource string, mode string, params url.Values, w io.Writer) error {
+	querier, err := ceems_http.NewOfflineQuerier(dbPath, time.Local, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		return fmt.Errorf("failed to open DB: %w", err)
+	}
+	defer querier.Close()
+
+	return querier.Query(ctx, resource, mode, params, w)
+}
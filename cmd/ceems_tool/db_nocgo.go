@@ -0,0 +1,37 @@
+//go:build !cgo
+// +build !cgo
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// errDBUnsupported is returned by DB commands when ceems_tool is built without cgo.
+var errDBUnsupported = errors.New("db commands are not supported as ceems_tool is built without cgo")
+
+// DBInfo is a stub when cgo is not available.
+func DBInfo(_ context.Context, _ string) error {
+	return errDBUnsupported
+}
+
+// DBQuery is a stub when cgo is not available.
+func DBQuery(_ context.Context, _ string, _ string, _ dbQueryArgs) error {
+	return errDBUnsupported
+}
+
+// DBExport is a stub when cgo is not available.
+func DBExport(_ context.Context, _ string, _ string, _ string, _ string, _ dbQueryArgs) error {
+	return errDBUnsupported
+}
+
+// DBRestore is a stub when cgo is not available.
+func DBRestore(_ context.Context, _ string, _ string) error {
+	return errDBUnsupported
+}
+
+// DBVacuum is a stub when cgo is not available.
+func DBVacuum(_ context.Context, _ string) error {
+	return errDBUnsupported
+}
@@ -53,6 +53,13 @@ func main() {
 		webConfigTLS         bool
 		webConfigTLSHosts    []string
 		webConfigTLSValidity time.Duration
+
+		dbPath         string
+		dbResource     string
+		dbQueryFilters dbQueryArgs
+		dbExportFormat string
+		dbExportOutput string
+		dbBackupPath   string
 	)
 
 	app := kingpin.New(filepath.Base(os.Args[0]), "Tooling for the CEEMS.").UsageWriter(os.Stdout)
@@ -164,6 +171,38 @@ func main() {
 		"end", "The time to end querying for metrics. Must be a RFC3339 formatted date or Unix timestamp. Default is current time.",
 	).StringVar(&end)
 
+	dbCmd := app.Command("db", "CEEMS API server DB related commands. These commands work on SQLite DB file directly.")
+	dbCmd.Flag(
+		"db.path", "Path to CEEMS API server SQLite DB file.",
+	).Default("data/ceems.db").StringVar(&dbPath)
+
+	dbInfoCmd := dbCmd.Command("info", "Show schema version, size and row counts of DB.")
+
+	dbQueryCmd := dbCmd.Command("query", "Query units or usage from DB. Response is printed in JSON format.")
+	dbQueryCmd.Arg(
+		"resource", "Resource to query.",
+	).Required().EnumVar(&dbResource, "units", "usage")
+	dbQueryFlags(dbQueryCmd, &dbQueryFilters)
+
+	dbExportCmd := dbCmd.Command("export", "Export units or usage from DB.")
+	dbExportCmd.Arg(
+		"resource", "Resource to export.",
+	).Required().EnumVar(&dbResource, "units", "usage")
+	dbExportCmd.Flag(
+		"format", "Export format. JSON exports contain one object per line.",
+	).Default("csv").EnumVar(&dbExportFormat, "csv", "json")
+	dbExportCmd.Flag(
+		"output", "Output file. If not set, export is written to stdout.",
+	).Short('o').StringVar(&dbExportOutput)
+	dbQueryFlags(dbExportCmd, &dbQueryFilters)
+
+	dbRestoreCmd := dbCmd.Command("restore", "Restore DB from a backup. CEEMS API server must be stopped during restore.")
+	dbRestoreCmd.Flag(
+		"from", "Path to backup DB file.",
+	).Required().PlaceHolder("<backup>").ExistingFileVar(&dbBackupPath)
+
+	dbVacuumCmd := dbCmd.Command("vacuum", "Vacuum DB to free up unused space.")
+
 	parsedCmd := kingpin.MustParse(app.Parse(os.Args[1:]))
 
 	if httpConfigFilePath != "" {
@@ -220,7 +259,103 @@ func main() {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 		defer cancel()
 		os.Exit(checkErr(CreatePromRelabelConfig(ctx, promServerURL, start, end, httpRoundTripper)))
+
+	case dbInfoCmd.FullCommand():
+		os.Exit(checkErr(DBInfo(context.Background(), dbPath)))
+
+	case dbQueryCmd.FullCommand():
+		os.Exit(checkErr(DBQuery(context.Background(), dbPath, dbResource, dbQueryFilters)))
+
+	case dbExportCmd.FullCommand():
+		os.Exit(checkErr(DBExport(context.Background(), dbPath, dbResource, dbExportFormat, dbExportOutput, dbQueryFilters)))
+
+	case dbRestoreCmd.FullCommand():
+		os.Exit(checkErr(DBRestore(context.Background(), dbPath, dbBackupPath)))
+
+	case dbVacuumCmd.FullCommand():
+		os.Exit(checkErr(DBVacuum(context.Background(), dbPath)))
+	}
+}
+
+// dbQueryArgs contains the filters of DB query and export commands. They map
+// to the query parameters of admin endpoints of CEEMS API server.
+type dbQueryArgs struct {
+	clusterIDs []string
+	projects   []string
+	users      []string
+	uuids      []string
+	fields     []string
+	params     map[string]string
+	from       string
+	to         string
+	running    bool
+	mode       string
+}
+
+// dbQueryFlags adds filter flags of DB query commands to cmd.
+func dbQueryFlags(cmd *kingpin.CmdClause, args *dbQueryArgs) {
+	if args.params == nil {
+		args.params = make(map[string]string)
+	}
+
+	cmd.Flag("cluster-id", "Cluster ID. Can be repeated.").StringsVar(&args.clusterIDs)
+	cmd.Flag("project", "Project. Can be repeated.").StringsVar(&args.projects)
+	cmd.Flag("user", "Username. Can be repeated.").StringsVar(&args.users)
+	cmd.Flag("uuid", "Unit UUID. Only for units. Can be repeated.").StringsVar(&args.uuids)
+	cmd.Flag("field", "Fields to return. Can be repeated.").StringsVar(&args.fields)
+	cmd.Flag(
+		"from", "Start of query window. Must be a RFC3339 formatted date or Unix timestamp. Default is 24 hours ago.",
+	).StringVar(&args.from)
+	cmd.Flag(
+		"to", "End of query window. Must be a RFC3339 formatted date or Unix timestamp. Default is current time.",
+	).StringVar(&args.to)
+	cmd.Flag("running", "Include running units. Only for units.").BoolVar(&args.running)
+	cmd.Flag(
+		"mode", "Usage mode. Only for usage.",
+	).Default("current").EnumVar(&args.mode, "current", "global")
+	cmd.Flag(
+		"param", "Any other query parameter supported by CEEMS API server. Can be repeated.",
+	).PlaceHolder("key=value").StringMapVar(&args.params)
+}
+
+// values returns the query parameters of args.
+func (a dbQueryArgs) values() (url.Values, error) {
+	params := url.Values{}
+
+	for key, values := range map[string][]string{
+		"cluster_id": a.clusterIDs,
+		"project":    a.projects,
+		"user":       a.users,
+		"uuid":       a.uuids,
+		"field":      a.fields,
+	} {
+		for _, value := range values {
+			params.Add(key, value)
+		}
 	}
+
+	for name, ts := range map[string]string{"from": a.from, "to": a.to} {
+		if ts == "" {
+			continue
+		}
+
+		t, err := parseTime(ts)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s time: %w", name, err)
+		}
+
+		params.Set(name, strconv.FormatInt(t.Unix(), 10))
+	}
+
+	if a.running {
+		params.Set("running", "true")
+	}
+
+	for key, value := range a.params {
+		params.Add(key, value)
+	}
+
+	return params, nil
 }
 
 // CheckServerStatus checks the server status by making a request to check endpoint.
@@ -0,0 +1,325 @@
+// Package audit implements the audit log of access decisions made by CEEMS API
+// server and load balancer. Records are written asynchronously to a rotating
+// file or to a DB table.
+package audit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/config"
+)
+
+// Audit log backends.
+const (
+	BackendFile = "file"
+	BackendDB   = "db"
+)
+
+// Access decisions.
+const (
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+	DecisionError = "error"
+)
+
+const (
+	// Number of records that can be buffered before requests start to wait
+	// for the writer.
+	bufferSize = 1024
+
+	// Maximum number of records written in one go.
+	maxBatchSize = 256
+)
+
+// Custom errors.
+var (
+	ErrInvalidBackend = errors.New("invalid audit backend. Supported backends are file and db")
+	ErrMissingFile    = errors.New("file must be set for file audit backend")
+	ErrInvalidMaxSize = errors.New("max_size_mb must be positive for file audit backend")
+	ErrDisabled       = errors.New("audit log is not enabled")
+)
+
+// Config contains the configuration of audit log.
+type Config struct {
+	Backend    string `yaml:"backend"`
+	File       string `yaml:"file"`
+	MaxSize    int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = Config{
+		MaxSize:    100,
+		MaxBackups: 5,
+	}
+
+	type plain Config
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Enabled returns true if audit log is configured.
+func (c *Config) Enabled() bool {
+	return c.Backend != ""
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	switch c.Backend {
+	case "", BackendDB:
+		return nil
+	case BackendFile:
+		if c.File == "" {
+			return ErrMissingFile
+		}
+
+		if c.MaxSize <= 0 {
+			return ErrInvalidMaxSize
+		}
+
+		return nil
+	default:
+		return ErrInvalidBackend
+	}
+}
+
+// SetDirectory joins any relative file paths with dir.
+func (c *Config) SetDirectory(dir string) {
+	c.File = config.JoinDir(dir, c.File)
+}
+
+// Filter selects the audit records returned by queries. Time window is
+// in epoch milliseconds.
+type Filter struct {
+	Users     []string
+	Sources   []string
+	Decisions []string
+	From      int64
+	To        int64
+	Limit     int
+	Offset    int
+}
+
+// Match returns true if record is selected by filter. Limit and offset are
+// not taken into account.
+func (f Filter) Match(record models.AuditRecord) bool {
+	if len(f.Users) > 0 && !slices.Contains(f.Users, record.User) {
+		return false
+	}
+
+	if len(f.Sources) > 0 && !slices.Contains(f.Sources, record.Source) {
+		return false
+	}
+
+	if len(f.Decisions) > 0 && !slices.Contains(f.Decisions, record.Decision) {
+		return false
+	}
+
+	return record.Timestamp >= f.From && record.Timestamp <= f.To
+}
+
+// Sink stores audit records.
+type Sink interface {
+	// Write stores records.
+	Write(ctx context.Context, records []models.AuditRecord) error
+	// Query returns the records selected by filter with most recent first.
+	Query(ctx context.Context, filter Filter) ([]models.AuditRecord, error)
+	// Close releases the resources held by sink.
+	Close() error
+}
+
+// Logger writes audit records to a sink in the background so that requests
+// are not slowed down by the writes. Records are never dropped: when the
+// buffer is full, requests wait for the writer to catch up.
+//
+// A nil Logger is valid and discards all records.
+type Logger struct {
+	logger  *slog.Logger
+	source  string
+	sink    Sink
+	records chan models.AuditRecord
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewLogger returns a new Logger that writes records of source to sink.
+func NewLogger(source string, sink Sink, logger *slog.Logger) *Logger {
+	l := &Logger{
+		logger:  logger,
+		source:  source,
+		sink:    sink,
+		records: make(chan models.AuditRecord, bufferSize),
+		done:    make(chan struct{}),
+	}
+
+	go l.run()
+
+	return l
+}
+
+// Log adds record to audit log.
+func (l *Logger) Log(record models.AuditRecord) {
+	if l == nil {
+		return
+	}
+
+	record.Source = l.source
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		l.logger.Error("Audit log closed. Record discarded", "user", record.User, "endpoint", record.Endpoint)
+
+		return
+	}
+
+	l.records <- record
+}
+
+// Query returns the records in sink selected by filter.
+func (l *Logger) Query(ctx context.Context, filter Filter) ([]models.AuditRecord, error) {
+	if l == nil {
+		return nil, ErrDisabled
+	}
+
+	return l.sink.Query(ctx, filter)
+}
+
+// Close writes pending records and closes the sink.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+
+	if l.closed {
+		l.mu.Unlock()
+
+		return nil
+	}
+
+	l.closed = true
+	close(l.records)
+	l.mu.Unlock()
+
+	// Wait for pending records to be written
+	<-l.done
+
+	return l.sink.Close()
+}
+
+// run writes records in batches until records channel is closed.
+func (l *Logger) run() {
+	defer close(l.done)
+
+	for record := range l.records {
+		batch := []models.AuditRecord{record}
+
+		// Write all the buffered records in one go
+	drain:
+		for len(batch) < maxBatchSize {
+			select {
+			case r, ok := <-l.records:
+				if !ok {
+					break drain
+				}
+
+				batch = append(batch, r)
+			default:
+				break drain
+			}
+		}
+
+		if err := l.sink.Write(context.Background(), batch); err != nil {
+			l.logger.Error("Failed to write audit records", "num_records", len(batch), "err", err)
+		}
+	}
+}
+
+// Decision returns the access decision based on status code of response.
+func Decision(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return DecisionDeny
+	case status >= http.StatusBadRequest:
+		return DecisionError
+	default:
+		return DecisionAllow
+	}
+}
+
+// NewRecord returns a record of request that started at start and served with
+// status code. Details like user and queried UUIDs must be set by the caller.
+func NewRecord(r *http.Request, start time.Time, status int) models.AuditRecord {
+	return models.AuditRecord{
+		Timestamp:  start.UnixMilli(),
+		Method:     r.Method,
+		Endpoint:   r.URL.Path,
+		Decision:   Decision(status),
+		StatusCode: status,
+		Latency:    float64(time.Since(start).Microseconds()) / 1e3,
+		RemoteAddr: r.RemoteAddr,
+	}
+}
+
+// ToList converts values to models.List.
+func ToList(values []string) models.List {
+	list := make(models.List, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+
+	return list
+}
+
+// ResponseWriter records the status code written by the wrapped
+// http.ResponseWriter.
+type ResponseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+}
+
+// NewResponseWriter returns a new ResponseWriter that wraps w.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (w *ResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Status returns the status code of response.
+func (w *ResponseWriter) Status() int {
+	return w.status
+}
+
+// Unwrap returns the wrapped http.ResponseWriter so that http.ResponseController
+// can access its methods.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig(t *testing.T) {
+	var c Config
+
+	require.NoError(t, yaml.Unmarshal([]byte("backend: file\nfile: audit.log"), &c))
+	assert.True(t, c.Enabled())
+	assert.Equal(t, 100, c.MaxSize)
+	assert.Equal(t, 5, c.MaxBackups)
+	require.NoError(t, c.Validate())
+
+	c.SetDirectory("/var/log")
+	assert.Equal(t, "/var/log/audit.log", c.File)
+
+	c.MaxSize = 0
+	require.ErrorIs(t, c.Validate(), ErrInvalidMaxSize)
+
+	require.ErrorIs(t, (&Config{Backend: BackendFile}).Validate(), ErrMissingFile)
+	require.ErrorIs(t, (&Config{Backend: "syslog"}).Validate(), ErrInvalidBackend)
+	require.NoError(t, (&Config{Backend: BackendDB}).Validate())
+	assert.False(t, (&Config{}).Enabled())
+}
+
+func TestLoggerWithFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.log")
+
+	sink, err := NewFileSink(path, 1, 2)
+	require.NoError(t, err)
+
+	l := NewLogger("ceems_lb", sink, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for i := range 10 {
+		l.Log(models.AuditRecord{
+			Timestamp: int64(i),
+			User:      fmt.Sprintf("usr%d", i%2),
+			Decision:  []string{DecisionAllow, DecisionDeny}[i%2],
+			UUIDs:     models.List{fmt.Sprintf("%d", i)},
+		})
+	}
+
+	require.NoError(t, l.Close())
+
+	// Records must not be accepted after closing
+	l.Log(models.AuditRecord{Timestamp: 10})
+
+	// Reopen sink to query records
+	sink, err = NewFileSink(path, 1, 2)
+	require.NoError(t, err)
+
+	defer sink.Close()
+
+	records, err := sink.Query(context.Background(), Filter{To: 100})
+	require.NoError(t, err)
+	require.Len(t, records, 10)
+
+	// Most recent first
+	assert.Equal(t, int64(9), records[0].Timestamp)
+	assert.Equal(t, "ceems_lb", records[0].Source)
+	assert.Equal(t, models.List{"9"}, records[0].UUIDs)
+
+	records, err = sink.Query(context.Background(), Filter{Users: []string{"usr1"}, Decisions: []string{DecisionDeny}, From: 2, To: 7})
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	records, err = sink.Query(context.Background(), Filter{To: 100, Limit: 4, Offset: 8})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, int64(1), records[0].Timestamp)
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	s, err := NewFileSink(path, 1, 2)
+	require.NoError(t, err)
+
+	defer s.Close()
+
+	// Use a small size to trigger rotation
+	s.(*fileSink).maxSize = 200
+
+	for i := range 10 {
+		require.NoError(t, s.Write(context.Background(), []models.AuditRecord{{Timestamp: int64(i), User: "usr1"}}))
+	}
+
+	// Only two backups must be kept
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		assert.FileExists(t, p)
+	}
+
+	assert.NoFileExists(t, path+".3")
+
+	// Most recent records are in the current file
+	records, err := s.Query(context.Background(), Filter{To: 100})
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+	assert.Less(t, len(records), 10)
+	assert.Equal(t, int64(9), records[0].Timestamp)
+
+	for i := 1; i < len(records); i++ {
+		assert.Greater(t, records[i-1].Timestamp, records[i].Timestamp)
+	}
+
+	// Files must not be readable by others
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestNewRecord(t *testing.T) {
+	tests := []struct {
+		status   int
+		decision string
+	}{
+		{http.StatusOK, DecisionAllow},
+		{http.StatusUnauthorized, DecisionDeny},
+		{http.StatusForbidden, DecisionDeny},
+		{http.StatusBadRequest, DecisionError},
+		{http.StatusInternalServerError, DecisionError},
+	}
+
+	for _, test := range tests {
+		start := time.Now()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/units?uuid=1", nil)
+		rec := httptest.NewRecorder()
+
+		w := NewResponseWriter(rec)
+		w.WriteHeader(test.status)
+		w.WriteHeader(http.StatusOK)
+
+		assert.Equal(t, test.status, w.Status())
+		assert.Equal(t, rec, w.Unwrap())
+
+		record := NewRecord(req, start, w.Status())
+		assert.Equal(t, test.decision, record.Decision, test.status)
+		assert.Equal(t, "/api/v1/units", record.Endpoint)
+		assert.Equal(t, http.MethodGet, record.Method)
+		assert.Equal(t, start.UnixMilli(), record.Timestamp)
+	}
+
+	// Status must default to 200 when header is not written explicitly
+	w := NewResponseWriter(httptest.NewRecorder())
+	w.Write([]byte("OK"))
+	assert.Equal(t, http.StatusOK, w.Status())
+
+	// Streaming handlers must be able to flush wrapped writer
+	require.NoError(t, http.NewResponseController(w).Flush())
+}
@@ -0,0 +1,200 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// fileSink writes records as JSON lines to a file. The file is rotated when
+// its size exceeds maxSize and at most maxBackups rotated files are kept
+// as path.1, path.2, etc. with path.1 being the most recent one.
+type fileSink struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileSink returns a Sink that writes records to a rotating file at path.
+// maxSize is in MiB.
+func NewFileSink(path string, maxSize int, maxBackups int) (Sink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory of audit log: %w", err)
+	}
+
+	s := &fileSink{
+		path:       path,
+		maxSize:    int64(maxSize) << 20,
+		maxBackups: max(maxBackups, 0),
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// open opens the current file in append mode.
+func (s *fileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+
+	return nil
+}
+
+// backup returns path of nth rotated file.
+func (s *fileSink) backup(n int) string {
+	return fmt.Sprintf("%s.%d", s.path, n)
+}
+
+// rotate shifts rotated files by one, removing the oldest one, and starts a
+// new file.
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	if s.maxBackups == 0 {
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	} else {
+		for n := s.maxBackups - 1; n > 0; n-- {
+			if err := os.Rename(s.backup(n), s.backup(n+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+
+		if err := os.Rename(s.path, s.backup(1)); err != nil {
+			return err
+		}
+	}
+
+	return s.open()
+}
+
+// Write implements Sink interface.
+func (s *fileSink) Write(_ context.Context, records []models.AuditRecord) error {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size > 0 && s.size+int64(buf.Len()) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+
+	n, err := s.file.Write(buf.Bytes())
+	s.size += int64(n)
+
+	return err
+}
+
+// Query implements Sink interface. All the files are scanned and hence, queries
+// must be restricted to a reasonable time window.
+func (s *fileSink) Query(ctx context.Context, filter Filter) ([]models.AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []models.AuditRecord
+
+	// Read files from the oldest to most recent one
+	paths := []string{s.path}
+	for n := 1; n <= s.maxBackups; n++ {
+		paths = append(paths, s.backup(n))
+	}
+
+	slices.Reverse(paths)
+
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+
+		for scanner.Scan() {
+			var record models.AuditRecord
+
+			// Skip partially written lines
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				continue
+			}
+
+			if filter.Match(record) {
+				records = append(records, record)
+			}
+		}
+
+		file.Close()
+
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+		}
+	}
+
+	// Most recent first
+	slices.Reverse(records)
+
+	// Apply pagination
+	records = records[min(filter.Offset, len(records)):]
+	if filter.Limit > 0 {
+		records = records[:min(filter.Limit, len(records))]
+	}
+
+	return records, nil
+}
+
+// Close implements Sink interface.
+func (s *fileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
@@ -0,0 +1,99 @@
+// Package email implements composition and delivery of emails over SMTP
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+)
+
+// Strips CR and LF from header values to avoid header injection.
+var headerReplacer = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// SMTP contains the details of SMTP server used to send emails.
+type SMTP struct {
+	SmartHost    string
+	AuthUsername string
+	AuthPassword string
+}
+
+// Message is an email message. Body must be encoded as described by
+// ContentType.
+type Message struct {
+	From        string
+	To          []string
+	Subject     string
+	ContentType string
+	Body        []byte
+}
+
+// parseAddress returns the address in s after stripping CR and LF.
+func parseAddress(s string) (*mail.Address, error) {
+	addr, err := mail.ParseAddress(headerReplacer.Replace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address %q: %w", s, err)
+	}
+
+	return addr, nil
+}
+
+// Bytes returns the message with its headers. It returns the envelope
+// sender and recipients as well.
+func (m Message) Bytes() ([]byte, string, []string, error) {
+	from, err := parseAddress(m.From)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	to := make([]string, len(m.To))
+	recipients := make([]string, len(m.To))
+
+	for i, s := range m.To {
+		addr, err := parseAddress(s)
+		if err != nil {
+			return nil, "", nil, err
+		}
+
+		to[i] = addr.String()
+		recipients[i] = addr.Address
+	}
+
+	subject := strings.TrimSpace(headerReplacer.Replace(m.Subject))
+
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s\r\n\r\n", headerReplacer.Replace(m.ContentType))
+	msg.Write(m.Body)
+
+	return msg.Bytes(), from.Address, recipients, nil
+}
+
+// Send sends message using SMTP server. PLAIN authentication is used when
+// username is configured.
+func Send(s SMTP, m Message) error {
+	var auth smtp.Auth
+
+	if s.AuthUsername != "" {
+		host, _, err := net.SplitHostPort(s.SmartHost)
+		if err != nil {
+			return err
+		}
+
+		auth = smtp.PlainAuth("", s.AuthUsername, s.AuthPassword, host)
+	}
+
+	msg, from, to, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(s.SmartHost, auth, from, to, msg)
+}
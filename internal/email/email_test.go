@@ -0,0 +1,46 @@
+package email
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBytes(t *testing.T) {
+	msg := Message{
+		From:        "CEEMS <ceems@example.com>",
+		To:          []string{"usr1@example.com\r\n", "Üser Two <usr2@example.com>"},
+		Subject:     "Usage digest für prj1\r\nBcc: victim@example.com",
+		ContentType: "text/plain; charset=UTF-8",
+		Body:        []byte("Hello"),
+	}
+
+	data, from, to, err := msg.Bytes()
+	require.NoError(t, err)
+
+	assert.Equal(t, "ceems@example.com", from)
+	assert.Equal(t, []string{"usr1@example.com", "usr2@example.com"}, to)
+
+	headers, body, ok := strings.Cut(string(data), "\r\n\r\n")
+	require.True(t, ok)
+	assert.Equal(t, "Hello", body)
+
+	// No injected headers must be present
+	lines := strings.Split(headers, "\r\n")
+	assert.Equal(t, []string{
+		`From: "CEEMS" <ceems@example.com>`,
+		"To: <usr1@example.com>, =?utf-8?q?=C3=9Cser_Two?= <usr2@example.com>",
+		"Subject: =?utf-8?q?Usage_digest_f=C3=BCr_prj1_Bcc:_victim@example.com?=",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}, lines)
+
+	// Invalid addresses and addresses with injected headers must be rejected
+	for _, addr := range []string{"not an address", "usr1@example.com\r\nBcc: victim@example.com"} {
+		msg.To = []string{addr}
+		_, _, _, err = msg.Bytes()
+		require.Error(t, err, addr)
+	}
+}
@@ -0,0 +1,392 @@
+// Package oidc implements verification of OIDC bearer tokens that are used to
+// authenticate requests to CEEMS API server and load balancer.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// Minimum interval between two JWKS refreshes triggered by unknown key IDs.
+	minRefreshInterval = time.Minute
+)
+
+// Signature algorithms that are accepted in tokens. Symmetric algorithms are
+// not accepted as JWKS only contain public keys.
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// Custom errors.
+var (
+	ErrMissingIssuer   = errors.New("issuer must be set to enable OIDC authentication")
+	ErrJWKSSource      = errors.New("only one of jwks_url and jwks_file can be set")
+	ErrMissingAudience = errors.New("at least one audience must be set to enable OIDC authentication")
+	ErrNoBearerToken   = errors.New("no bearer token found")
+	ErrUnknownKey      = errors.New("no key found in JWKS to verify token")
+	ErrMissingExpiry   = errors.New("token does not have expiry claim")
+	ErrMissingUsername = errors.New("token does not have username claim")
+)
+
+// Config contains the configuration of OIDC authentication.
+type Config struct {
+	Issuer           string                  `yaml:"issuer"`
+	JWKSURL          string                  `yaml:"jwks_url"`
+	JWKSFile         string                  `yaml:"jwks_file"`
+	JWKSCacheTTL     model.Duration          `yaml:"jwks_cache_ttl"`
+	Audiences        []string                `yaml:"audiences"`
+	UsernameClaim    string                  `yaml:"username_claim"`
+	GroupsClaim      string                  `yaml:"groups_claim"`
+	AdminGroups      []string                `yaml:"admin_groups"`
+	HeaderAuth       bool                    `yaml:"header_auth_fallback"`
+	HTTPClientConfig config.HTTPClientConfig `yaml:"http_client_config"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = Config{
+		JWKSCacheTTL:     model.Duration(time.Hour),
+		UsernameClaim:    "preferred_username",
+		GroupsClaim:      "groups",
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+	}
+
+	type plain Config
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Enabled returns true if OIDC authentication is configured.
+func (c *Config) Enabled() bool {
+	return c.Issuer != ""
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	if !c.Enabled() {
+		if c.JWKSURL != "" || c.JWKSFile != "" {
+			return ErrMissingIssuer
+		}
+
+		return nil
+	}
+
+	if c.JWKSURL != "" && c.JWKSFile != "" {
+		return ErrJWKSSource
+	}
+
+	// Without audiences, tokens issued to any client of the issuer would be accepted
+	if len(c.Audiences) == 0 {
+		return ErrMissingAudience
+	}
+
+	// The UnmarshalYAML method of HTTPClientConfig is not being called because it's not a pointer.
+	// We cannot make it a pointer as the parser panics for inlined pointer structs.
+	// Thus we just do its validation here.
+	return c.HTTPClientConfig.Validate()
+}
+
+// SetDirectory joins any relative file paths with dir.
+func (c *Config) SetDirectory(dir string) {
+	c.JWKSFile = config.JoinDir(dir, c.JWKSFile)
+	c.HTTPClientConfig.SetDirectory(dir)
+}
+
+// Identity is the identity of the user found in a verified token.
+type Identity struct {
+	Username string
+	Groups   []string
+	Admin    bool
+}
+
+// Verifier verifies bearer tokens against the keys published by the issuer.
+type Verifier struct {
+	logger *slog.Logger
+	config Config
+	client *http.Client
+
+	mu          sync.Mutex
+	keys        jose.JSONWebKeySet
+	jwksURL     string
+	expiresAt   time.Time
+	refreshedAt time.Time
+
+	now func() time.Time
+}
+
+// NewVerifier returns a new instance of Verifier. When JWKS file is configured,
+// it is read immediately so that errors in key file are reported early.
+func NewVerifier(c Config, logger *slog.Logger) (*Verifier, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	client, err := config.NewClientFromConfig(c.HTTPClientConfig, "oidc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OIDC HTTP client: %w", err)
+	}
+
+	v := &Verifier{
+		logger:  logger,
+		config:  c,
+		client:  client,
+		jwksURL: c.JWKSURL,
+		now:     time.Now,
+	}
+
+	if c.JWKSFile != "" {
+		if err := v.refresh(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// HeaderAuth returns true if header based authentication must be used when
+// request does not have a bearer token.
+func (v *Verifier) HeaderAuth() bool {
+	return v.config.HeaderAuth
+}
+
+// Verify verifies the signature and claims of token and returns the identity
+// of the user.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Identity, error) {
+	tok, err := jwt.ParseSigned(token, signatureAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	var kid string
+	if len(tok.Headers) > 0 {
+		kid = tok.Headers[0].KeyID
+	}
+
+	keys, err := v.signingKeys(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwt.Claims
+
+	var rawClaims map[string]interface{}
+
+	// Try all candidate keys. Tokens without key ID can be signed by any key in JWKS
+	for _, key := range keys {
+		if err = tok.Claims(key, &claims, &rawClaims); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify token: %w", err)
+	}
+
+	if claims.Expiry == nil {
+		return nil, ErrMissingExpiry
+	}
+
+	if err := claims.Validate(jwt.Expected{
+		Issuer:      v.config.Issuer,
+		AnyAudience: v.config.Audiences,
+		Time:        v.now(),
+	}); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	username, ok := rawClaims[v.config.UsernameClaim].(string)
+	if !ok || username == "" {
+		return nil, fmt.Errorf("%w: %s", ErrMissingUsername, v.config.UsernameClaim)
+	}
+
+	identity := &Identity{
+		Username: username,
+		Groups:   stringsClaim(rawClaims[v.config.GroupsClaim]),
+	}
+
+	for _, group := range identity.Groups {
+		if slices.Contains(v.config.AdminGroups, group) {
+			identity.Admin = true
+
+			break
+		}
+	}
+
+	return identity, nil
+}
+
+// signingKeys returns keys that can be used to verify a token signed by key with
+// ID kid. If no key is found in cached JWKS, JWKS is refreshed as keys might
+// have been rotated.
+func (v *Verifier) signingKeys(ctx context.Context, kid string) ([]jose.JSONWebKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := v.now()
+
+	// Refresh keys when cache is expired
+	if now.After(v.expiresAt) {
+		if err := v.refresh(ctx); err != nil {
+			// Continue with stale keys if we have any
+			if len(v.keys.Keys) == 0 {
+				return nil, err
+			}
+
+			v.logger.Error("Failed to refresh JWKS. Using cached keys", "err", err)
+		}
+	}
+
+	keys := v.lookup(kid)
+	if len(keys) == 0 && now.Sub(v.refreshedAt) > minRefreshInterval {
+		if err := v.refresh(ctx); err != nil {
+			return nil, err
+		}
+
+		keys = v.lookup(kid)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: kid=%s", ErrUnknownKey, kid)
+	}
+
+	return keys, nil
+}
+
+// lookup returns the keys of JWKS with ID kid. When kid is empty, all keys
+// are returned.
+func (v *Verifier) lookup(kid string) []jose.JSONWebKey {
+	if kid == "" {
+		return v.keys.Keys
+	}
+
+	return v.keys.Key(kid)
+}
+
+// refresh reads JWKS from file or fetches it from issuer. Caller must hold
+// the lock.
+func (v *Verifier) refresh(ctx context.Context) error {
+	var keys jose.JSONWebKeySet
+
+	if v.config.JWKSFile != "" {
+		data, err := os.ReadFile(v.config.JWKSFile)
+		if err != nil {
+			return fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return fmt.Errorf("failed to parse JWKS file: %w", err)
+		}
+	} else {
+		// Discover JWKS URL from issuer
+		if v.jwksURL == "" {
+			var discovery struct {
+				JWKSURI string `json:"jwks_uri"`
+			}
+
+			if err := v.fetch(ctx, strings.TrimSuffix(v.config.Issuer, "/")+discoveryPath, &discovery); err != nil {
+				return fmt.Errorf("failed to discover JWKS URL: %w", err)
+			}
+
+			if discovery.JWKSURI == "" {
+				return errors.New("jwks_uri not found in OIDC discovery document")
+			}
+
+			v.jwksURL = discovery.JWKSURI
+		}
+
+		if err := v.fetch(ctx, v.jwksURL, &keys); err != nil {
+			return fmt.Errorf("failed to fetch JWKS: %w", err)
+		}
+	}
+
+	now := v.now()
+	v.keys = keys
+	v.refreshedAt = now
+	v.expiresAt = now.Add(time.Duration(v.config.JWKSCacheTTL))
+
+	v.logger.Debug("JWKS refreshed", "num_keys", len(keys.Keys))
+
+	return nil
+}
+
+// fetch makes a GET request to url and decodes the JSON response into dest.
+func (v *Verifier) fetch(ctx context.Context, url string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed with status %d", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, dest)
+}
+
+// BearerToken returns the bearer token in Authorization header of request.
+func BearerToken(r *http.Request) (string, error) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrNoBearerToken
+	}
+
+	return strings.TrimSpace(token), nil
+}
+
+// stringsClaim returns the value of a claim that can be either a string or
+// a list of strings.
+func stringsClaim(v interface{}) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
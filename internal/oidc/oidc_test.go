@@ -0,0 +1,278 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const testIssuer = "https://idp.example.com"
+
+func signToken(t *testing.T, key interface{}, alg jose.SignatureAlgorithm, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: key},
+		(&jose.SignerOptions{}).WithHeader(jose.HeaderKey("kid"), kid).WithType("JWT"),
+	)
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func testClaims(overrides map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":                testIssuer,
+		"aud":                []string{"ceems"},
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"preferred_username": "usr1",
+		"groups":             []string{"users"},
+	}
+
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+
+	return claims
+}
+
+func writeJWKS(t *testing.T, keys ...jose.JSONWebKey) string {
+	t.Helper()
+
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func testConfig() Config {
+	return Config{
+		Issuer:           testIssuer,
+		JWKSCacheTTL:     model.Duration(time.Hour),
+		Audiences:        []string{"ceems"},
+		UsernameClaim:    "preferred_username",
+		GroupsClaim:      "groups",
+		AdminGroups:      []string{"admins"},
+		HeaderAuth:       true,
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	var c Config
+
+	require.NoError(t, yaml.Unmarshal([]byte("issuer: https://idp.example.com"), &c))
+	assert.True(t, c.Enabled())
+	assert.False(t, c.HeaderAuth)
+	assert.Equal(t, "preferred_username", c.UsernameClaim)
+	assert.Equal(t, "groups", c.GroupsClaim)
+	require.ErrorIs(t, c.Validate(), ErrMissingAudience)
+
+	c.Audiences = []string{"ceems"}
+	require.NoError(t, c.Validate())
+
+	c.JWKSURL = "https://idp.example.com/keys"
+	c.JWKSFile = "keys.json"
+	require.ErrorIs(t, c.Validate(), ErrJWKSSource)
+
+	require.ErrorIs(t, (&Config{JWKSFile: "keys.json"}).Validate(), ErrMissingIssuer)
+}
+
+func TestVerifierWithJWKSFile(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	c := testConfig()
+	c.JWKSFile = writeJWKS(
+		t,
+		jose.JSONWebKey{Key: rsaKey.Public(), KeyID: "rsa", Algorithm: string(jose.RS256), Use: "sig"},
+		jose.JSONWebKey{Key: ecKey.Public(), KeyID: "ec", Algorithm: string(jose.ES256), Use: "sig"},
+	)
+
+	verifier, err := NewVerifier(c, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		token    string
+		expected *Identity
+		err      bool
+	}{
+		{
+			name:     "valid RSA token",
+			token:    signToken(t, rsaKey, jose.RS256, "rsa", testClaims(nil)),
+			expected: &Identity{Username: "usr1", Groups: []string{"users"}},
+		},
+		{
+			name:     "valid EC token of admin",
+			token:    signToken(t, ecKey, jose.ES256, "ec", testClaims(map[string]interface{}{"groups": []string{"users", "admins"}})),
+			expected: &Identity{Username: "usr1", Groups: []string{"users", "admins"}, Admin: true},
+		},
+		{
+			name:     "groups claim as string",
+			token:    signToken(t, rsaKey, jose.RS256, "rsa", testClaims(map[string]interface{}{"groups": "admins"})),
+			expected: &Identity{Username: "usr1", Groups: []string{"admins"}, Admin: true},
+		},
+		{
+			name:     "token without kid",
+			token:    signToken(t, rsaKey, jose.RS256, "", testClaims(nil)),
+			expected: &Identity{Username: "usr1", Groups: []string{"users"}},
+		},
+		{
+			name:  "token signed by unknown key",
+			token: signToken(t, otherKey, jose.RS256, "rsa", testClaims(nil)),
+			err:   true,
+		},
+		{
+			name:  "token with unknown kid",
+			token: signToken(t, otherKey, jose.RS256, "other", testClaims(nil)),
+			err:   true,
+		},
+		{
+			name:  "expired token",
+			token: signToken(t, rsaKey, jose.RS256, "rsa", testClaims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+			err:   true,
+		},
+		{
+			name:  "token without expiry",
+			token: signToken(t, rsaKey, jose.RS256, "rsa", testClaims(map[string]interface{}{"exp": nil})),
+			err:   true,
+		},
+		{
+			name:  "token from other issuer",
+			token: signToken(t, rsaKey, jose.RS256, "rsa", testClaims(map[string]interface{}{"iss": "https://other.example.com"})),
+			err:   true,
+		},
+		{
+			name:  "token for other audience",
+			token: signToken(t, rsaKey, jose.RS256, "rsa", testClaims(map[string]interface{}{"aud": "grafana"})),
+			err:   true,
+		},
+		{
+			name:  "token without username",
+			token: signToken(t, rsaKey, jose.RS256, "rsa", testClaims(map[string]interface{}{"preferred_username": nil})),
+			err:   true,
+		},
+		{
+			name:  "malformed token",
+			token: "foo.bar.baz",
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		identity, err := verifier.Verify(context.Background(), test.token)
+		if test.err {
+			require.Error(t, err, test.name)
+
+			continue
+		}
+
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, identity, test.name)
+	}
+}
+
+func TestVerifierWithDiscovery(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// Start with old key and rotate it later
+	keys := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: oldKey.Public(), KeyID: "old", Use: "sig"}}}
+
+	var jwksRequests int
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	defer server.Close()
+
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		jwksRequests++
+
+		json.NewEncoder(w).Encode(keys)
+	})
+
+	c := testConfig()
+	c.Issuer = server.URL
+
+	verifier, err := NewVerifier(c, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// Keys must be fetched and cached
+	for range 2 {
+		identity, err := verifier.Verify(context.Background(), signToken(t, oldKey, jose.RS256, "old", testClaims(map[string]interface{}{"iss": server.URL})))
+		require.NoError(t, err)
+		assert.Equal(t, "usr1", identity.Username)
+	}
+
+	assert.Equal(t, 1, jwksRequests)
+
+	// Rotate keys. Unknown kid must trigger a refresh
+	keys = jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: newKey.Public(), KeyID: "new", Use: "sig"}}}
+	verifier.now = func() time.Time { return time.Now().Add(2 * minRefreshInterval) }
+
+	_, err = verifier.Verify(context.Background(), signToken(t, newKey, jose.RS256, "new", testClaims(map[string]interface{}{"iss": server.URL})))
+	require.NoError(t, err)
+	assert.Equal(t, 2, jwksRequests)
+}
+
+func TestBearerToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	_, err := BearerToken(req)
+	require.ErrorIs(t, err, ErrNoBearerToken)
+
+	req.Header.Set("Authorization", "Basic dXNyOnB3ZA==")
+
+	_, err = BearerToken(req)
+	require.ErrorIs(t, err, ErrNoBearerToken)
+
+	req.Header.Set("Authorization", "bearer abc.def.ghi")
+
+	token, err := BearerToken(req)
+	require.NoError(t, err)
+	assert.Equal(t, "abc.def.ghi", token)
+}
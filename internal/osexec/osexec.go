@@ -2,6 +2,7 @@
 package osexec
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"math"
@@ -159,6 +160,62 @@ func ExecuteAsContext(
 	return execCmd.CombinedOutput()
 }
 
+// ExecuteContextWithStdin executes a command with context after writing stdin
+// to the command and returns stdout. Stderr of the command is available in the
+// returned error when command exits with non zero code. Environment of the
+// current process is not inherited and command is executed only with env.
+func ExecuteContextWithStdin(ctx context.Context, cmd string, args []string, stdin []byte, env []string) ([]byte, error) {
+	execCmd := exec.CommandContext(ctx, cmd, args...)
+
+	// Do not leak environment of current process into subprocess cmd. A non
+	// nil empty slice is needed as nil env inherits the environment
+	execCmd.Env = append([]string{}, env...)
+
+	// Start child process in its own process group so that interrupt signal will
+	// not stop the command
+	execCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	return outputWithStdin(execCmd, stdin)
+}
+
+// ExecuteAsContextWithStdin executes a command as a given UID and GID with context
+// after writing stdin to the command and returns stdout. Environment of the
+// current process is not inherited and command is executed only with env.
+func ExecuteAsContextWithStdin(
+	ctx context.Context,
+	cmd string,
+	args []string,
+	uid int,
+	gid int,
+	stdin []byte,
+	env []string,
+) ([]byte, error) {
+	execCmd := exec.CommandContext(ctx, cmd, args...)
+
+	// Check bounds on uid and gid before converting into int32
+	uidInt32, err := convertToUint(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	gidInt32, err := convertToUint(gid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start child process in its own process group and set uid and gid for process
+	execCmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid:    true,
+		Credential: &syscall.Credential{Uid: uidInt32, Gid: gidInt32},
+	}
+
+	// Do not leak environment of current process into subprocess cmd. A non
+	// nil empty slice is needed as nil env inherits the environment
+	execCmd.Env = append([]string{}, env...)
+
+	return outputWithStdin(execCmd, stdin)
+}
+
 // ExecuteWithTimeout exwecutes a command with timeout and return stdout/stderr.
 func ExecuteWithTimeout(cmd string, args []string, timeout int, env []string) ([]byte, error) {
 	ctx := context.Background()
@@ -251,6 +308,19 @@ func ExecuteAsWithTimeout(
 	return execCmd.CombinedOutput()
 }
 
+// outputWithStdin writes stdin to the command and returns its stdout. When
+// context is cancelled, the entire process group of the command is killed so
+// that children of the command do not hold the pipes open.
+func outputWithStdin(execCmd *exec.Cmd, stdin []byte) ([]byte, error) {
+	execCmd.Stdin = bytes.NewReader(stdin)
+	execCmd.Cancel = func() error {
+		return syscall.Kill(-execCmd.Process.Pid, syscall.SIGKILL)
+	}
+	execCmd.WaitDelay = time.Second
+
+	return execCmd.Output()
+}
+
 // convertToUint converts int to uint32 after checking bounds.
 func convertToUint(i int) (uint32, error) {
 	if i >= 0 && i <= math.MaxInt32 {
@@ -58,6 +58,59 @@ func TestExecuteContext(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestExecuteContextWithStdin(t *testing.T) {
+	// Test stdin is piped to command and stderr is not part of stdout
+	out, err := ExecuteContextWithStdin(
+		context.Background(),
+		"bash",
+		[]string{"-c", "echo ${VAR1} >&2; tr a-z A-Z"},
+		[]byte("units"),
+		[]string{"VAR1=1", "PATH=/usr/bin:/bin"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "UNITS", string(out))
+
+	// Test environment of current process is not inherited
+	t.Setenv("CEEMS_SECRET", "secret")
+
+	out, err = ExecuteContextWithStdin(
+		context.Background(),
+		"bash",
+		[]string{"-c", "echo -n ${CEEMS_SECRET}${VAR1}"},
+		nil,
+		[]string{"VAR1=1"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(out))
+
+	// Test children of command are killed on timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = ExecuteContextWithStdin(ctx, "bash", []string{"-c", "sleep 300 | cat"}, nil, nil)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestExecuteAsContextWithStdin(t *testing.T) {
+	// Test invalid uid/gid
+	_, err := ExecuteAsContextWithStdin(context.Background(), "cat", nil, -65534, 65534, nil, nil)
+	require.Error(t, err, "expected error due to invalid uid")
+
+	// Get current user
+	currentUser, err := user.Current()
+	require.NoError(t, err)
+
+	out, err := ExecuteAsContextWithStdin(context.Background(), "cat", nil, 65534, 65534, []byte("units"), nil)
+	if currentUser.Uid == "0" {
+		require.NoError(t, err)
+		assert.Equal(t, "units", string(out))
+	} else {
+		require.Error(t, err, "expected error executing as nobody user")
+	}
+}
+
 func TestExecuteWithTimeout(t *testing.T) {
 	// Test successful command execution
 	_, err := ExecuteWithTimeout("sleep", []string{"5"}, 2, nil)
@@ -142,6 +142,7 @@ type ExecSecurityCtxData struct {
 	Environ []string
 	UID     int
 	GID     int
+	StdIn   []byte
 	StdOut  []byte
 	Logger  *slog.Logger
 }
@@ -171,7 +172,17 @@ func ExecAsUser(data interface{}) error {
 	var err error
 
 	cmd := ctxData.Cmd
-	if len(cmd) > 1 {
+	if ctxData.StdIn != nil {
+		stdOut, err = osexec.ExecuteAsContextWithStdin(
+			ctx,
+			cmd[0],
+			cmd[1:],
+			ctxData.UID,
+			ctxData.GID,
+			ctxData.StdIn,
+			ctxData.Environ,
+		)
+	} else if len(cmd) > 1 {
 		stdOut, err = osexec.ExecuteAsContext(
 			ctx,
 			cmd[0],
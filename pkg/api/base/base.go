@@ -25,12 +25,18 @@ var CEEMSServerApp = *kingpin.New(
 
 // DB table names.
 var (
-	UnitsDBTableName      = models.Unit{}.TableName()
-	UsageDBTableName      = models.Usage{}.TableName()
-	DailyUsageDBTableName = models.DailyUsage{}.TableName()
-	ProjectsDBTableName   = models.Project{}.TableName()
-	UsersDBTableName      = models.User{}.TableName()
-	AdminUsersDBTableName = models.AdminUsers{}.TableName()
+	UnitsDBTableName        = models.Unit{}.TableName()
+	UsageDBTableName        = models.Usage{}.TableName()
+	DailyUsageDBTableName   = models.DailyUsage{}.TableName()
+	MonthlyUsageDBTableName = models.MonthlyUsage{}.TableName()
+	ProjectsDBTableName     = models.Project{}.TableName()
+	UsersDBTableName        = models.User{}.TableName()
+	AdminUsersDBTableName   = models.AdminUsers{}.TableName()
+	BudgetsDBTableName      = models.Budget{}.TableName()
+	APITokensDBTableName    = models.APIToken{}.TableName()
+	AuditLogDBTableName     = models.AuditRecord{}.TableName()
+	UnitEventsDBTableName   = models.UnitEvent{}.TableName()
+	ReportsDBTableName      = models.ReportSchedule{}.TableName()
 )
 
 // Slice of field names of all tables
@@ -41,6 +47,7 @@ var (
 	ProjectsDBTableColNames   = models.Project{}.TagNames("json")
 	UsersDBTableColNames      = models.User{}.TagNames("json")
 	AdminUsersDBTableColNames = models.AdminUsers{}.TagNames("json")
+	BudgetsDBTableColNames    = models.Budget{}.TagNames("json")
 )
 
 // Map of struct field name to DB column name.
@@ -50,6 +57,7 @@ var (
 	ProjectsDBTableStructFieldColNameMap   = models.Project{}.TagMap("", "sql")
 	UsersDBTableStructFieldColNameMap      = models.User{}.TagMap("", "sql")
 	AdminUsersDBTableStructFieldColNameMap = models.AdminUsers{}.TagMap("", "sql")
+	BudgetsDBTableStructFieldColNameMap    = models.Budget{}.TagMap("", "sql")
 )
 
 // DatetimeLayout to be used in the package.
@@ -0,0 +1,65 @@
+package base
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SQL dialects of supported DBs.
+const (
+	SQLite   = "sqlite3"
+	Postgres = "postgres"
+)
+
+// Dialect returns the SQL dialect of the DB. Any DB that is not opened with
+// one of SQLite drivers is assumed to be a PostgreSQL DB.
+func Dialect(db *sql.DB) string {
+	typ := reflect.TypeOf(db.Driver())
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	if strings.HasSuffix(typ.PkgPath(), "sqlite3") {
+		return SQLite
+	}
+
+	return Postgres
+}
+
+// Rebind replaces `?` placeholders in query with the placeholders of the
+// dialect. Placeholders inside string literals and quoted identifiers are
+// left untouched.
+func Rebind(dialect string, query string) string {
+	if dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+
+	var quote rune
+
+	n := 0
+
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+
+			b.WriteString("$" + strconv.Itoa(n))
+
+			continue
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
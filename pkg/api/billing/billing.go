@@ -0,0 +1,224 @@
+// Package billing estimates the cost of compute units based on the price
+// tables defined for each cluster.
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Custom errors.
+var (
+	ErrMissingClusterID = errors.New("cluster_id is missing in billing price table")
+	ErrDuplClusterID    = errors.New("duplicate cluster_id found in billing price tables")
+	ErrNegativePrice    = errors.New("prices in billing price table must be non negative")
+	ErrInvalidPeriod    = errors.New("valid_to must be after valid_from in billing price table")
+)
+
+// Cost keys in total_cost map.
+const (
+	CPUCost    = "cpu"
+	GPUCost    = "gpu"
+	EnergyCost = "energy"
+	TotalCost  = "total"
+)
+
+// Price is a single rate in the price table. Match contains regular expressions
+// that are matched against unit's tags and allocation. For example, to match
+// a SLURM partition `partition: gpu.*` and to match an Openstack flavor
+// `name: m1.large` can be used. A price is valid between ValidFrom and ValidTo
+// and zero values mean an open interval.
+type Price struct {
+	Match     map[string]string `yaml:"match"`
+	ValidFrom time.Time         `yaml:"valid_from"`
+	ValidTo   time.Time         `yaml:"valid_to"`
+	CPUHour   float64           `yaml:"cpu_hour"`
+	GPUHour   float64           `yaml:"gpu_hour"`
+	EnergyKWh float64           `yaml:"energy_kwh"`
+
+	matchers map[string]*regexp.Regexp
+}
+
+// PriceTable contains the prices of a given cluster. Prices are evaluated
+// in the order they are defined and the first matching price is used.
+type PriceTable struct {
+	ClusterID string  `yaml:"cluster_id"`
+	Prices    []Price `yaml:"prices"`
+}
+
+// Config is the container for billing related config.
+type Config struct {
+	Currency     string       `yaml:"currency"`
+	EnergyMetric string       `yaml:"energy_metric"`
+	PriceTables  []PriceTable `yaml:"price_tables"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = Config{
+		EnergyMetric: "total",
+	}
+
+	type plain Config
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	clusterIDs := make(map[string]bool)
+
+	for _, table := range c.PriceTables {
+		if table.ClusterID == "" {
+			return ErrMissingClusterID
+		}
+
+		if clusterIDs[table.ClusterID] {
+			return fmt.Errorf("%w: %s", ErrDuplClusterID, table.ClusterID)
+		}
+
+		clusterIDs[table.ClusterID] = true
+
+		for _, price := range table.Prices {
+			if price.CPUHour < 0 || price.GPUHour < 0 || price.EnergyKWh < 0 {
+				return fmt.Errorf("%w: cluster_id %s", ErrNegativePrice, table.ClusterID)
+			}
+
+			if !price.ValidFrom.IsZero() && !price.ValidTo.IsZero() && !price.ValidTo.After(price.ValidFrom) {
+				return fmt.Errorf("%w: cluster_id %s", ErrInvalidPeriod, table.ClusterID)
+			}
+
+			for key, expr := range price.Match {
+				if _, err := regexp.Compile(expr); err != nil {
+					return fmt.Errorf("invalid regex for %s in billing price table of cluster_id %s: %w", key, table.ClusterID, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Biller estimates cost of units.
+type Biller struct {
+	logger       *slog.Logger
+	energyMetric string
+	prices       map[string][]Price
+}
+
+// New returns a new instance of Biller.
+func New(c *Config, logger *slog.Logger) (*Biller, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	energyMetric := c.EnergyMetric
+	if energyMetric == "" {
+		energyMetric = "total"
+	}
+
+	prices := make(map[string][]Price)
+
+	for _, table := range c.PriceTables {
+		for _, price := range table.Prices {
+			price.matchers = make(map[string]*regexp.Regexp)
+
+			// Anchor the expressions to avoid partial matches
+			for key, expr := range price.Match {
+				price.matchers[key] = regexp.MustCompile(fmt.Sprintf("^(?:%s)$", expr))
+			}
+
+			prices[table.ClusterID] = append(prices[table.ClusterID], price)
+		}
+	}
+
+	return &Biller{
+		logger:       logger,
+		energyMetric: energyMetric,
+		prices:       prices,
+	}, nil
+}
+
+// Bill sets total cost on units based on the prices that are valid at
+// the given time.
+func (b *Biller) Bill(clusterUnits []models.ClusterUnits, at time.Time) []models.ClusterUnits {
+	for i, cluster := range clusterUnits {
+		prices, ok := b.prices[cluster.Cluster.ID]
+		if !ok {
+			continue
+		}
+
+		var numBilled int
+
+		for j, unit := range cluster.Units {
+			for _, price := range prices {
+				if !price.matches(unit, at) {
+					continue
+				}
+
+				clusterUnits[i].Units[j].TotalCost = price.cost(unit, b.energyMetric)
+				numBilled++
+
+				break
+			}
+		}
+
+		b.logger.Debug(
+			"Billing units", "cluster_id", cluster.Cluster.ID,
+			"num_units", len(cluster.Units), "num_billed_units", numBilled,
+		)
+	}
+
+	return clusterUnits
+}
+
+// matches returns true if the price is applicable for unit at the given time.
+func (p *Price) matches(unit models.Unit, at time.Time) bool {
+	if !p.ValidFrom.IsZero() && at.Before(p.ValidFrom) {
+		return false
+	}
+
+	if !p.ValidTo.IsZero() && !at.Before(p.ValidTo) {
+		return false
+	}
+
+	for key, matcher := range p.matchers {
+		// Tags take precedence over allocation
+		value, ok := unit.Tags[key]
+		if !ok {
+			if value, ok = unit.Allocation[key]; !ok {
+				return false
+			}
+		}
+
+		if !matcher.MatchString(fmt.Sprint(value)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cost returns the cost of unit by resource type.
+func (p *Price) cost(unit models.Unit, energyMetric string) models.MetricMap {
+	cpuCost := float64(unit.TotalTime["alloc_cputime"]) / 3600 * p.CPUHour
+	gpuCost := float64(unit.TotalTime["alloc_gputime"]) / 3600 * p.GPUHour
+	energyCost := float64(unit.TotalCPUEnergyUsage[energyMetric]+unit.TotalGPUEnergyUsage[energyMetric]) * p.EnergyKWh
+
+	return models.MetricMap{
+		CPUCost:    models.JSONFloat(cpuCost),
+		GPUCost:    models.JSONFloat(gpuCost),
+		EnergyCost: models.JSONFloat(energyCost),
+		TotalCost:  models.JSONFloat(cpuCost + gpuCost + energyCost),
+	}
+}
@@ -0,0 +1,177 @@
+package billing
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigUnmarshal(t *testing.T) {
+	cfg := `
+currency: EUR
+price_tables:
+  - cluster_id: slurm-0
+    prices:
+      - match:
+          partition: gpu.*
+        valid_from: 2024-01-01
+        valid_to: 2025-01-01
+        cpu_hour: 0.01
+        gpu_hour: 1.2
+        energy_kwh: 0.2`
+
+	var c Config
+
+	err := yaml.Unmarshal([]byte(cfg), &c)
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+	assert.Equal(t, "EUR", c.Currency)
+	assert.Equal(t, "total", c.EnergyMetric)
+	require.Len(t, c.PriceTables, 1)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), c.PriceTables[0].Prices[0].ValidFrom)
+	assert.InEpsilon(t, 1.2, c.PriceTables[0].Prices[0].GPUHour, 0)
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		err  error
+	}{
+		{
+			name: "missing cluster id",
+			cfg:  Config{PriceTables: []PriceTable{{}}},
+			err:  ErrMissingClusterID,
+		},
+		{
+			name: "duplicate cluster id",
+			cfg:  Config{PriceTables: []PriceTable{{ClusterID: "slurm-0"}, {ClusterID: "slurm-0"}}},
+			err:  ErrDuplClusterID,
+		},
+		{
+			name: "negative price",
+			cfg:  Config{PriceTables: []PriceTable{{ClusterID: "slurm-0", Prices: []Price{{CPUHour: -1}}}}},
+			err:  ErrNegativePrice,
+		},
+		{
+			name: "invalid period",
+			cfg: Config{PriceTables: []PriceTable{{ClusterID: "slurm-0", Prices: []Price{
+				{ValidFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ValidTo: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			}}}},
+			err: ErrInvalidPeriod,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.cfg.Validate()
+		require.ErrorIs(t, err, test.err, test.name)
+	}
+
+	// Invalid regex
+	c := Config{PriceTables: []PriceTable{{ClusterID: "slurm-0", Prices: []Price{{Match: map[string]string{"partition": "gpu["}}}}}}
+	require.Error(t, c.Validate())
+}
+
+func TestBill(t *testing.T) {
+	c := Config{
+		PriceTables: []PriceTable{
+			{
+				ClusterID: "slurm-0",
+				Prices: []Price{
+					{
+						Match:   map[string]string{"partition": "gpu.*"},
+						CPUHour: 0.01,
+						GPUHour: 1,
+					},
+					{
+						Match:     map[string]string{"partition": "cpu"},
+						ValidTo:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+						CPUHour:   0.02,
+						EnergyKWh: 0.5,
+					},
+					{
+						Match:     map[string]string{"partition": "cpu"},
+						ValidFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+						CPUHour:   0.04,
+						EnergyKWh: 0.5,
+					},
+				},
+			},
+			{
+				ClusterID: "os-0",
+				Prices: []Price{
+					{
+						Match:   map[string]string{"name": "m1.large"},
+						CPUHour: 0.1,
+					},
+				},
+			},
+		},
+	}
+
+	biller, err := New(&c, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	clusterUnits := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units: []models.Unit{
+				{
+					UUID:                "1",
+					Tags:                models.Tag{"partition": "gpu-a100"},
+					TotalTime:           models.MetricMap{"alloc_cputime": 7200, "alloc_gputime": 3600},
+					TotalCPUEnergyUsage: models.MetricMap{"total": 1},
+				},
+				{
+					UUID:                "2",
+					Tags:                models.Tag{"partition": "cpu"},
+					TotalTime:           models.MetricMap{"alloc_cputime": 3600},
+					TotalCPUEnergyUsage: models.MetricMap{"total": 1},
+					TotalGPUEnergyUsage: models.MetricMap{"total": 1},
+				},
+				{
+					UUID:      "3",
+					Tags:      models.Tag{"partition": "debug-gpu"},
+					TotalTime: models.MetricMap{"alloc_cputime": 3600},
+				},
+			},
+		},
+		{
+			Cluster: models.Cluster{ID: "os-0"},
+			Units: []models.Unit{
+				{
+					UUID:       "4",
+					Allocation: models.Allocation{"name": "m1.large"},
+					TotalTime:  models.MetricMap{"alloc_cputime": 36000},
+				},
+			},
+		},
+		{
+			Cluster: models.Cluster{ID: "k8s-0"},
+			Units: []models.Unit{
+				{
+					UUID:      "5",
+					TotalTime: models.MetricMap{"alloc_cputime": 3600},
+				},
+			},
+		},
+	}
+
+	billed := biller.Bill(clusterUnits, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, models.MetricMap{"cpu": 0.02, "gpu": 1, "energy": 0, "total": 1.02}, billed[0].Units[0].TotalCost)
+	assert.Equal(t, models.MetricMap{"cpu": 0.04, "gpu": 0, "energy": 1, "total": 1.04}, billed[0].Units[1].TotalCost)
+	assert.Empty(t, billed[0].Units[2].TotalCost)
+	assert.Equal(t, models.MetricMap{"cpu": 1, "gpu": 0, "energy": 0, "total": 1}, billed[1].Units[0].TotalCost)
+	assert.Empty(t, billed[2].Units[0].TotalCost)
+
+	// Older price must be used for units billed before its expiry
+	billed = biller.Bill(clusterUnits, time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, models.MetricMap{"cpu": 0.02, "gpu": 0, "energy": 1, "total": 1.02}, billed[0].Units[1].TotalCost)
+}
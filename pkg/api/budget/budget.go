@@ -0,0 +1,193 @@
+// Package budget implements the configuration of project budgets and
+// notifications sent when budgets are consumed.
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/config"
+)
+
+// Custom errors.
+var (
+	ErrMissingProject   = errors.New("cluster_id and project are required for each budget")
+	ErrDuplBudget       = errors.New("duplicate budget found for project")
+	ErrInvalidBudget    = errors.New("budget limits must be non negative and at least one limit must be set")
+	ErrInvalidPeriod    = errors.New("invalid budget period. Allowed periods are month, year and global")
+	ErrInvalidThreshold = errors.New("budget thresholds must be in (0, 100]")
+	ErrInvalidEmail     = errors.New("smarthost, from and to are required for email notifications")
+)
+
+// Budget periods.
+const (
+	Monthly = "month"
+	Yearly  = "year"
+	Global  = "global"
+)
+
+// Resources that can be budgeted. These are used as keys in limits
+// and consumed metric maps.
+const (
+	CPUHours     = "cpu_hours"
+	GPUHours     = "gpu_hours"
+	EnergyKWh    = "energy_kwh"
+	EmissionsGms = "emissions_gms"
+)
+
+// Resources is the list of all resources that can be budgeted.
+var Resources = []string{CPUHours, GPUHours, EnergyKWh, EmissionsGms}
+
+// Budget defines the limits of a project in a given period.
+type Budget struct {
+	ClusterID    string  `yaml:"cluster_id"`
+	Project      string  `yaml:"project"`
+	Period       string  `yaml:"period"`
+	CPUHours     float64 `yaml:"cpu_hours"`
+	GPUHours     float64 `yaml:"gpu_hours"`
+	EnergyKWh    float64 `yaml:"energy_kwh"`
+	EmissionsGms float64 `yaml:"emissions_gms"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (b *Budget) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*b = Budget{
+		Period: Monthly,
+	}
+
+	type plain Budget
+
+	if err := unmarshal((*plain)(b)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Limits returns the non zero limits of budget.
+func (b *Budget) Limits() models.MetricMap {
+	limits := make(models.MetricMap)
+
+	for resource, limit := range map[string]float64{
+		CPUHours:     b.CPUHours,
+		GPUHours:     b.GPUHours,
+		EnergyKWh:    b.EnergyKWh,
+		EmissionsGms: b.EmissionsGms,
+	} {
+		if limit > 0 {
+			limits[resource] = models.JSONFloat(limit)
+		}
+	}
+
+	return limits
+}
+
+// PeriodStart returns the start of the budget period that contains t. A zero
+// time is returned for global budgets.
+func (b *Budget) PeriodStart(t time.Time) time.Time {
+	switch b.Period {
+	case Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case Yearly:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// EmailConfig contains the SMTP configuration for email notifications.
+type EmailConfig struct {
+	SmartHost    string        `yaml:"smarthost"`
+	From         string        `yaml:"from"`
+	To           []string      `yaml:"to"`
+	AuthUsername string        `yaml:"auth_username"`
+	AuthPassword config.Secret `yaml:"auth_password"`
+}
+
+// Config is the container for budgets related config.
+type Config struct {
+	Thresholds      []float64          `yaml:"thresholds"`
+	EnergyMetric    string             `yaml:"energy_metric"`
+	EmissionsMetric string             `yaml:"emissions_metric"`
+	Budgets         []Budget           `yaml:"budgets"`
+	Webhooks        []models.WebConfig `yaml:"webhooks"`
+	Email           *EmailConfig       `yaml:"email"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = Config{
+		Thresholds:      []float64{80, 100},
+		EnergyMetric:    "total",
+		EmissionsMetric: "owid_total",
+	}
+
+	type plain Config
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	// Sort thresholds so that the highest crossed threshold can be found easily
+	slices.Sort(c.Thresholds)
+	c.Thresholds = slices.Compact(c.Thresholds)
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	for _, threshold := range c.Thresholds {
+		if threshold <= 0 || threshold > 100 {
+			return ErrInvalidThreshold
+		}
+	}
+
+	budgets := make(map[string]bool)
+
+	for _, budget := range c.Budgets {
+		if budget.ClusterID == "" || budget.Project == "" {
+			return ErrMissingProject
+		}
+
+		key := budget.ClusterID + "/" + budget.Project
+		if budgets[key] {
+			return fmt.Errorf("%w: %s", ErrDuplBudget, key)
+		}
+
+		budgets[key] = true
+
+		if !slices.Contains([]string{Monthly, Yearly, Global}, budget.Period) {
+			return fmt.Errorf("%w: %s", ErrInvalidPeriod, budget.Period)
+		}
+
+		if budget.CPUHours < 0 || budget.GPUHours < 0 || budget.EnergyKWh < 0 || budget.EmissionsGms < 0 ||
+			len(budget.Limits()) == 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidBudget, key)
+		}
+	}
+
+	for _, webhook := range c.Webhooks {
+		if err := webhook.HTTPClientConfig.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Email != nil && (c.Email.SmartHost == "" || c.Email.From == "" || len(c.Email.To) == 0) {
+		return ErrInvalidEmail
+	}
+
+	return nil
+}
+
+// SetDirectory joins any relative file paths with dir.
+func (c *Config) SetDirectory(dir string) {
+	for i := range c.Webhooks {
+		c.Webhooks[i].SetDirectory(dir)
+	}
+}
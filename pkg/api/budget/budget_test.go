@@ -0,0 +1,154 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigUnmarshal(t *testing.T) {
+	cfg := `
+thresholds: [100, 50, 80, 100]
+budgets:
+  - cluster_id: slurm-0
+    project: foo
+    cpu_hours: 1000
+  - cluster_id: slurm-0
+    project: bar
+    period: global
+    energy_kwh: 20`
+
+	var c Config
+
+	err := yaml.Unmarshal([]byte(cfg), &c)
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+	assert.Equal(t, []float64{50, 80, 100}, c.Thresholds)
+	assert.Equal(t, "total", c.EnergyMetric)
+	assert.Equal(t, "owid_total", c.EmissionsMetric)
+	require.Len(t, c.Budgets, 2)
+	assert.Equal(t, Monthly, c.Budgets[0].Period)
+	assert.Equal(t, models.MetricMap{CPUHours: 1000}, c.Budgets[0].Limits())
+	assert.Equal(t, models.MetricMap{EnergyKWh: 20}, c.Budgets[1].Limits())
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		err  error
+	}{
+		{
+			name: "invalid threshold",
+			cfg:  Config{Thresholds: []float64{80, 120}},
+			err:  ErrInvalidThreshold,
+		},
+		{
+			name: "missing project",
+			cfg:  Config{Budgets: []Budget{{ClusterID: "slurm-0", CPUHours: 1}}},
+			err:  ErrMissingProject,
+		},
+		{
+			name: "duplicate budget",
+			cfg: Config{Budgets: []Budget{
+				{ClusterID: "slurm-0", Project: "foo", Period: Monthly, CPUHours: 1},
+				{ClusterID: "slurm-0", Project: "foo", Period: Yearly, CPUHours: 1},
+			}},
+			err: ErrDuplBudget,
+		},
+		{
+			name: "invalid period",
+			cfg:  Config{Budgets: []Budget{{ClusterID: "slurm-0", Project: "foo", Period: "week", CPUHours: 1}}},
+			err:  ErrInvalidPeriod,
+		},
+		{
+			name: "no limits",
+			cfg:  Config{Budgets: []Budget{{ClusterID: "slurm-0", Project: "foo", Period: Monthly}}},
+			err:  ErrInvalidBudget,
+		},
+		{
+			name: "incomplete email",
+			cfg:  Config{Email: &EmailConfig{SmartHost: "localhost:25"}},
+			err:  ErrInvalidEmail,
+		},
+	}
+
+	for _, test := range tests {
+		require.ErrorIs(t, test.cfg.Validate(), test.err, test.name)
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	now := time.Date(2024, 10, 15, 12, 30, 0, 0, time.UTC)
+
+	b := Budget{Period: Monthly}
+	assert.Equal(t, time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC), b.PeriodStart(now))
+
+	b = Budget{Period: Yearly}
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), b.PeriodStart(now))
+
+	b = Budget{Period: Global}
+	assert.True(t, b.PeriodStart(now).IsZero())
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	var texts []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		texts = append(texts, payload["text"])
+	}))
+	defer server.Close()
+
+	c := &Config{Webhooks: []models.WebConfig{{URL: server.URL}}}
+
+	n, err := NewNotifier(c, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// No request must be made when there are no alerts
+	require.NoError(t, n.Notify(context.Background(), nil))
+	assert.Empty(t, texts)
+
+	alerts := []Alert{
+		{
+			ClusterID:   "slurm-0",
+			Project:     "foo",
+			Period:      Monthly,
+			PeriodStart: "2024-10-01T00:00:00",
+			Threshold:   80,
+			Limits:      models.MetricMap{CPUHours: 100},
+			Consumed:    models.MetricMap{CPUHours: 85, GPUHours: 0},
+		},
+	}
+
+	require.NoError(t, n.Notify(context.Background(), alerts))
+	require.Len(t, texts, 1)
+	assert.Equal(
+		t,
+		"Project foo on cluster slurm-0 has reached 80% of its budget for the month period starting from "+
+			"2024-10-01T00:00:00. cpu_hours: 85.00/100.00 (85.0%)",
+		texts[0],
+	)
+
+	// Errors from webhook must be returned
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	require.Error(t, n.Notify(context.Background(), alerts))
+}
@@ -0,0 +1,170 @@
+package budget
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/internal/email"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	config_util "github.com/prometheus/common/config"
+)
+
+// Alert represents a budget threshold crossed by a project.
+type Alert struct {
+	ClusterID   string
+	Project     string
+	Period      string
+	PeriodStart string
+	Threshold   float64
+	Limits      models.MetricMap
+	Consumed    models.MetricMap
+}
+
+// String returns a human readable message of alert.
+func (a Alert) String() string {
+	resources := make([]string, 0, len(a.Limits))
+
+	for _, resource := range Resources {
+		limit, ok := a.Limits[resource]
+		if !ok {
+			continue
+		}
+
+		resources = append(
+			resources,
+			fmt.Sprintf(
+				"%s: %.2f/%.2f (%.1f%%)",
+				resource, a.Consumed[resource], limit, 100*a.Consumed[resource]/limit,
+			),
+		)
+	}
+
+	period := a.Period + " period"
+	if a.PeriodStart != "" {
+		period += " starting from " + a.PeriodStart
+	}
+
+	return fmt.Sprintf(
+		"Project %s on cluster %s has reached %.0f%% of its budget for the %s. %s",
+		a.Project, a.ClusterID, a.Threshold, period, strings.Join(resources, ", "),
+	)
+}
+
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+// Notifier sends budget alerts to webhooks and/or email.
+type Notifier struct {
+	logger   *slog.Logger
+	webhooks []webhook
+	email    *EmailConfig
+}
+
+// NewNotifier returns a new instance of Notifier.
+func NewNotifier(c *Config, logger *slog.Logger) (*Notifier, error) {
+	notifier := &Notifier{
+		logger: logger,
+		email:  c.Email,
+	}
+
+	for _, w := range c.Webhooks {
+		client, err := config_util.NewClientFromConfig(w.HTTPClientConfig, "budget_webhook")
+		if err != nil {
+			return nil, err
+		}
+
+		notifier.webhooks = append(notifier.webhooks, webhook{url: w.URL, client: client})
+	}
+
+	return notifier, nil
+}
+
+// Notify sends alerts to all the configured receivers.
+func (n *Notifier) Notify(ctx context.Context, alerts []Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(alerts))
+	for i, alert := range alerts {
+		messages[i] = alert.String()
+	}
+
+	text := strings.Join(messages, "\n")
+
+	var errs error
+
+	for _, w := range n.webhooks {
+		if err := n.sendWebhook(ctx, w, text); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	if n.email != nil {
+		if err := n.sendEmail(text); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	n.logger.Info("Budget alerts sent", "num_alerts", len(alerts), "errors", errs)
+
+	return errs
+}
+
+// sendWebhook posts the message to a Slack/Mattermost compatible webhook.
+func (n *Notifier) sendWebhook(ctx context.Context, w webhook, text string) error {
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send budget alert to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to send budget alert to webhook: unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// sendEmail sends the message to the configured recipients.
+func (n *Notifier) sendEmail(text string) error {
+	server := email.SMTP{
+		SmartHost:    n.email.SmartHost,
+		AuthUsername: n.email.AuthUsername,
+		AuthPassword: string(n.email.AuthPassword),
+	}
+
+	msg := email.Message{
+		From:        n.email.From,
+		To:          n.email.To,
+		Subject:     "CEEMS budget alert",
+		ContentType: "text/plain; charset=UTF-8",
+		Body:        []byte(text + "\r\n"),
+	}
+
+	if err := email.Send(server, msg); err != nil {
+		return fmt.Errorf("failed to send budget alert email: %w", err)
+	}
+
+	return nil
+}
@@ -17,12 +17,17 @@ import (
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/mahendrapaipuri/ceems/internal/audit"
 	"github.com/mahendrapaipuri/ceems/internal/common"
 	internal_runtime "github.com/mahendrapaipuri/ceems/internal/runtime"
 	"github.com/mahendrapaipuri/ceems/internal/security"
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/billing"
+	"github.com/mahendrapaipuri/ceems/pkg/api/budget"
 	ceems_db "github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/api/efficiency"
 	ceems_http "github.com/mahendrapaipuri/ceems/pkg/api/http"
+	"github.com/mahendrapaipuri/ceems/pkg/api/report"
 	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
 	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
 	"github.com/prometheus/common/promslog"
@@ -39,6 +44,10 @@ type CEEMSAPIAppConfig struct {
 // SetDirectory joins any relative file paths with dir.
 func (c *CEEMSAPIAppConfig) SetDirectory(dir string) {
 	c.Server.Admin.SetDirectory(dir)
+	c.Server.Budgets.SetDirectory(dir)
+	c.Server.Reports.SetDirectory(dir)
+	c.Server.Web.OIDC.SetDirectory(dir)
+	c.Server.Web.Audit.SetDirectory(dir)
 }
 
 // Validate validates the config.
@@ -53,14 +62,48 @@ func (c *CEEMSAPIAppConfig) Validate() error {
 		return err
 	}
 
+	// Validate Billing config
+	if err := c.Server.Billing.Validate(); err != nil {
+		return err
+	}
+
+	// Validate Efficiency config
+	if err := c.Server.Efficiency.Validate(); err != nil {
+		return err
+	}
+
+	// Validate Budgets config
+	if err := c.Server.Budgets.Validate(); err != nil {
+		return err
+	}
+
+	// Validate Reports config
+	if err := c.Server.Reports.Validate(); err != nil {
+		return err
+	}
+
+	// Validate OIDC config
+	if err := c.Server.Web.OIDC.Validate(); err != nil {
+		return err
+	}
+
+	// Validate audit config
+	if err := c.Server.Web.Audit.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // CEEMSAPIServerConfig contains the configuration of CEEMS API server.
 type CEEMSAPIServerConfig struct {
-	Data  ceems_db.DataConfig  `yaml:"data"`
-	Admin ceems_db.AdminConfig `yaml:"admin"`
-	Web   ceems_http.WebConfig `yaml:"web"`
+	Data       ceems_db.DataConfig  `yaml:"data"`
+	Admin      ceems_db.AdminConfig `yaml:"admin"`
+	Billing    billing.Config       `yaml:"billing"`
+	Efficiency efficiency.Config    `yaml:"efficiency"`
+	Budgets    budget.Config        `yaml:"budgets"`
+	Reports    report.Config        `yaml:"reports"`
+	Web        ceems_http.WebConfig `yaml:"web"`
 }
 
 // CEEMSServer represents the `ceems_server` cli.
@@ -225,6 +268,16 @@ func (b *CEEMSServer) Main() error {
 			ReadWritePaths: []string{config.Server.Data.Path, config.Server.Data.BackupPath},
 		}
 
+		// Audit log files are rotated and hence, their directory must be writable
+		if config.Server.Web.Audit.Backend == audit.BackendFile {
+			auditDir := filepath.Dir(config.Server.Web.Audit.File)
+			if err := os.MkdirAll(auditDir, 0o700); err != nil {
+				return fmt.Errorf("failed to create directory of audit log: %w", err)
+			}
+
+			securityCfg.ReadWritePaths = append(securityCfg.ReadWritePaths, auditDir)
+		}
+
 		// Drop all unnecessary privileges
 		if err := security.DropPrivileges(securityCfg); err != nil {
 			return err
@@ -240,10 +293,23 @@ func (b *CEEMSServer) Main() error {
 		Logger:          logger,
 		Data:            config.Server.Data,
 		Admin:           config.Server.Admin,
+		Billing:         config.Server.Billing,
+		Efficiency:      config.Server.Efficiency,
+		Budgets:         config.Server.Budgets,
+		Reports:         config.Server.Reports,
 		ResourceManager: resource.New,
 		Updater:         updater.New,
 	}
 
+	// Create DB instance. It is created before server as units pushed to
+	// server are ingested by it.
+	collector, err := ceems_db.New(dbConfig)
+	if err != nil {
+		logger.Error("Failed to create ceems_server DB", "err", err)
+
+		return err
+	}
+
 	// Make server config.
 	serverConfig := &ceems_http.Config{
 		Logger: logger,
@@ -255,8 +321,11 @@ func (b *CEEMSServer) Main() error {
 			RoutePrefix:       config.Server.Web.RoutePrefix,
 			RequestsLimit:     config.Server.Web.RequestsLimit,
 			MaxQueryPeriod:    config.Server.Web.MaxQueryPeriod,
+			OIDC:              config.Server.Web.OIDC,
+			Audit:             config.Server.Web.Audit,
 		},
-		DB: *dbConfig,
+		DB:       *dbConfig,
+		Ingester: collector,
 	}
 
 	// Create server instance.
@@ -269,14 +338,6 @@ func (b *CEEMSServer) Main() error {
 		return err
 	}
 
-	// Create DB instance.
-	collector, err := ceems_db.New(dbConfig)
-	if err != nil {
-		logger.Error("Failed to create ceems_server DB", "err", err)
-
-		return err
-	}
-
 	// Declare wait group and tickers.
 	var wg sync.WaitGroup
 
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/budget"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// checkBudgets updates the consumption of project budgets and sends alerts
+// when a threshold is crossed for the first time in the current budget period.
+func (s *stats) checkBudgets(ctx context.Context, currentTime time.Time) error {
+	if len(s.budgets.Budgets) == 0 {
+		return nil
+	}
+
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "Budgets check", s.logger)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin SQL transcation: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	stmt, err := prepareNamed(ctx, tx, s.storage.driver, prepareStatements[s.storage.driver][base.BudgetsDBTableName])
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement for table %s: %w", base.BudgetsDBTableName, err)
+	}
+	defer stmt.Close()
+
+	var alerts []budget.Alert
+
+	for _, b := range s.budgets.Budgets {
+		var periodStart string
+		if start := b.PeriodStart(currentTime.In(s.storage.timeLocation)); !start.IsZero() {
+			periodStart = start.Format(base.DatetimeLayout)
+		}
+
+		consumed, err := s.budgetConsumption(ctx, tx, b, periodStart)
+		if err != nil {
+			s.logger.Error(
+				"Failed to estimate budget consumption", "cluster_id", b.ClusterID,
+				"project", b.Project, "err", err,
+			)
+
+			continue
+		}
+
+		// Get percentage of the budget consumed for each resource
+		limits := b.Limits()
+		usedPercent := make(models.MetricMap, len(limits))
+
+		var maxPercent float64
+
+		for resource, limit := range limits {
+			usedPercent[resource] = 100 * consumed[resource] / limit
+			maxPercent = max(maxPercent, float64(usedPercent[resource]))
+		}
+
+		// Get the threshold that has been notified in the current period. When
+		// a new period starts, notified threshold is reset
+		var lastPeriodStart string
+
+		var notifiedThreshold float64
+
+		if err := tx.QueryRowContext(
+			ctx,
+			base.Rebind(
+				s.storage.driver,
+				fmt.Sprintf("SELECT period_start,notified_threshold FROM %s WHERE cluster_id = ? AND project = ?", base.BudgetsDBTableName),
+			),
+			b.ClusterID, b.Project,
+		).Scan(&lastPeriodStart, &notifiedThreshold); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error(
+				"Failed to fetch budget state", "cluster_id", b.ClusterID,
+				"project", b.Project, "err", err,
+			)
+		}
+
+		if lastPeriodStart != periodStart {
+			notifiedThreshold = 0
+		}
+
+		// Thresholds are sorted in ascending order
+		var crossedThreshold float64
+
+		for _, threshold := range s.budgets.Thresholds {
+			if maxPercent >= threshold {
+				crossedThreshold = threshold
+			}
+		}
+
+		if crossedThreshold > notifiedThreshold {
+			alerts = append(alerts, budget.Alert{
+				ClusterID:   b.ClusterID,
+				Project:     b.Project,
+				Period:      b.Period,
+				PeriodStart: periodStart,
+				Threshold:   crossedThreshold,
+				Limits:      limits,
+				Consumed:    consumed,
+			})
+			notifiedThreshold = crossedThreshold
+		}
+
+		if _, err = stmt.ExecContext(
+			ctx,
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["ClusterID"], b.ClusterID),
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["Project"], b.Project),
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["Period"], b.Period),
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["PeriodStart"], periodStart),
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["Limits"], limits),
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["Consumed"], consumed),
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["UsedPercent"], usedPercent),
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["NotifiedThreshold"], notifiedThreshold),
+			sql.Named(base.BudgetsDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
+		); err != nil {
+			s.logger.Error(
+				"Failed to update budgets table in DB", "cluster_id", b.ClusterID,
+				"project", b.Project, "err", err,
+			)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit SQL transcation: %w", err)
+	}
+
+	// Send alerts only after budgets state has been persisted to avoid
+	// sending duplicate alerts
+	return s.notifier.Notify(ctx, alerts)
+}
+
+// budgetConsumption returns the resources consumed by the project of budget
+// since periodStart. Global budgets are estimated from usage table and periodic
+// ones from daily_usage table.
+func (s *stats) budgetConsumption(
+	ctx context.Context,
+	tx *sql.Tx,
+	b budget.Budget,
+	periodStart string,
+) (models.MetricMap, error) {
+	energyPath := "$." + s.budgets.EnergyMetric
+	emissionsPath := "$." + s.budgets.EmissionsMetric
+
+	table := base.UsageDBTableName
+	args := []interface{}{energyPath, energyPath, emissionsPath, emissionsPath, b.ClusterID, b.Project}
+
+	if periodStart != "" {
+		table = base.DailyUsageDBTableName
+		args = append(args, periodStart)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT TOTAL(json_extract(total_time_seconds,'$.alloc_cputime'))/3600,"+
+			"TOTAL(json_extract(total_time_seconds,'$.alloc_gputime'))/3600,"+
+			"TOTAL(json_extract(total_cpu_energy_usage_kwh,?))+TOTAL(json_extract(total_gpu_energy_usage_kwh,?)),"+
+			"TOTAL(json_extract(total_cpu_emissions_gms,?))+TOTAL(json_extract(total_gpu_emissions_gms,?)) "+
+			"FROM %s WHERE cluster_id = ? AND project = ?", table,
+	) // #nosec
+	if periodStart != "" {
+		query += " AND last_updated_at >= ?"
+	}
+
+	var cpuHours, gpuHours, energy, emissions float64
+	if err := tx.QueryRowContext(ctx, base.Rebind(s.storage.driver, query), args...).Scan(&cpuHours, &gpuHours, &energy, &emissions); err != nil {
+		return nil, err
+	}
+
+	return models.MetricMap{
+		budget.CPUHours:     models.JSONFloat(cpuHours),
+		budget.GPUHours:     models.JSONFloat(gpuHours),
+		budget.EnergyKWh:    models.JSONFloat(energy),
+		budget.EmissionsGms: models.JSONFloat(emissions),
+	}, nil
+}
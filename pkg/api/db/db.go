@@ -14,13 +14,20 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mahendrapaipuri/ceems/internal/common"
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/billing"
+	"github.com/mahendrapaipuri/ceems/pkg/api/budget"
 	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/mahendrapaipuri/ceems/pkg/api/efficiency"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/report"
 	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
 	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
 	"github.com/mahendrapaipuri/ceems/pkg/grafana"
@@ -32,8 +39,9 @@ import (
 
 // Directory containing DB related files.
 const (
-	migrationsDir = "migrations"
-	statementsDir = "statements"
+	migrationsDir         = "migrations"
+	postgresMigrationsDir = "migrations/postgres"
+	statementsDir         = "statements"
 )
 
 //go:embed migrations/*.sql
@@ -42,12 +50,35 @@ var MigrationsFS embed.FS
 //go:embed statements/*.sql
 var StatementsFS embed.FS
 
+//go:embed migrations/postgres/*.sql
+var PostgresMigrationsFS embed.FS
+
+//go:embed statements/postgres/*.sql
+var PostgresStatementsFS embed.FS
+
+// Retention policies of expired units.
+const (
+	// RetentionPolicyPurge deletes expired units.
+	RetentionPolicyPurge = "purge"
+	// RetentionPolicyRollup aggregates expired units into monthly usage
+	// before deleting them.
+	RetentionPolicyRollup = "rollup"
+)
+
 // Custom errors.
 var (
-	ErrBackupInt = errors.New("backup_interval of less than 1 day is not supported")
-	ErrUpdateInt = errors.New("update_interval and/or max_update_interval must be more than 0s")
+	ErrBackupInt       = errors.New("backup_interval of less than 1 day is not supported")
+	ErrUpdateInt       = errors.New("update_interval and/or max_update_interval must be more than 0s")
+	ErrRetentionPolicy = errors.New("invalid retention_policy. Supported policies are purge and rollup")
+	ErrCoordProject    = errors.New("project must be set for coordinators")
+	ErrUsageDimension  = errors.New("invalid usage_dimensions. Supported dimensions are allocation.<key> and tags.<key>")
+	ErrPushCluster     = errors.New("cluster not found or its manager is not push")
 )
 
+// Usage dimensions are attributes of allocation or tags of units, eg,
+// allocation.partition.
+var usageDimensionRegexp = regexp.MustCompile(`^(allocation|tags)\.([A-Za-z0-9_]+)$`)
+
 type Timezone struct {
 	*time.Location
 }
@@ -104,10 +135,19 @@ func (t *DateTime) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+// CoordinatorsConfig contains the static coordinators of a project. When
+// cluster ID is empty, coordinators apply to the project in all clusters.
+type CoordinatorsConfig struct {
+	ClusterID string   `yaml:"cluster_id"`
+	Project   string   `yaml:"project"`
+	Users     []string `yaml:"users"`
+}
+
 // AdminConfig is the container for the admin users related config.
 type AdminConfig struct {
-	Users   []string                `yaml:"users"`
-	Grafana common.GrafanaWebConfig `yaml:"grafana"`
+	Users        []string                `yaml:"users"`
+	Coordinators []CoordinatorsConfig    `yaml:"coordinators"`
+	Grafana      common.GrafanaWebConfig `yaml:"grafana"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -130,6 +170,12 @@ func (c *AdminConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Validate validates the config.
 func (c *AdminConfig) Validate() error {
+	for _, coord := range c.Coordinators {
+		if coord.Project == "" {
+			return ErrCoordProject
+		}
+	}
+
 	// The UnmarshalYAML method of HTTPClientConfig is not being called because it's not a pointer.
 	// We cannot make it a pointer as the parser panics for inlined pointer structs.
 	// Thus we just do its validation here.
@@ -143,15 +189,20 @@ func (c *AdminConfig) SetDirectory(dir string) {
 
 // DataConfig is the container for the data related config.
 type DataConfig struct {
-	Path               string         `yaml:"path"`
-	BackupPath         string         `yaml:"backup_path"`
-	RetentionPeriod    model.Duration `yaml:"retention_period"`
-	UpdateInterval     model.Duration `yaml:"update_interval"`
-	MaxUpdateInterval  model.Duration `yaml:"max_update_interval"`
-	BackupInterval     model.Duration `yaml:"backup_interval"`
-	LastUpdate         DateTime       `yaml:"update_from"`
-	Timezone           Timezone       `yaml:"time_zone"`
-	SkipDeleteOldUnits bool
+	Driver                string         `yaml:"driver"`
+	DSN                   config.Secret  `yaml:"dsn"`
+	Path                  string         `yaml:"path"`
+	BackupPath            string         `yaml:"backup_path"`
+	RetentionPeriod       model.Duration `yaml:"retention_period"`
+	RetentionPolicy       string         `yaml:"retention_policy"`
+	EventsRetentionPeriod model.Duration `yaml:"events_retention_period"`
+	UpdateInterval        model.Duration `yaml:"update_interval"`
+	MaxUpdateInterval     model.Duration `yaml:"max_update_interval"`
+	BackupInterval        model.Duration `yaml:"backup_interval"`
+	LastUpdate            DateTime       `yaml:"update_from"`
+	Timezone              Timezone       `yaml:"time_zone"`
+	UsageDimensions       []string       `yaml:"usage_dimensions"`
+	SkipDeleteOldUnits    bool
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -159,13 +210,16 @@ func (c *DataConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	// Set a default config
 	todayMidnight, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
 	*c = DataConfig{
-		Path:              "data",
-		RetentionPeriod:   model.Duration(30 * 24 * time.Hour),
-		UpdateInterval:    model.Duration(15 * time.Minute),
-		MaxUpdateInterval: model.Duration(time.Hour),
-		BackupInterval:    model.Duration(24 * time.Hour),
-		Timezone:          Timezone{Location: time.Local},
-		LastUpdate:        DateTime{todayMidnight},
+		Driver:                SQLite,
+		Path:                  "data",
+		RetentionPeriod:       model.Duration(30 * 24 * time.Hour),
+		RetentionPolicy:       RetentionPolicyPurge,
+		EventsRetentionPeriod: model.Duration(24 * time.Hour),
+		UpdateInterval:        model.Duration(15 * time.Minute),
+		MaxUpdateInterval:     model.Duration(time.Hour),
+		BackupInterval:        model.Duration(24 * time.Hour),
+		Timezone:              Timezone{Location: time.Local},
+		LastUpdate:            DateTime{todayMidnight},
 	}
 
 	type plain DataConfig
@@ -179,6 +233,36 @@ func (c *DataConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Validate validates the config.
 func (c *DataConfig) Validate() error {
+	// Ensure driver is supported and DSN is set for postgres
+	switch c.Driver {
+	case "", SQLite:
+	case Postgres:
+		if c.DSN == "" {
+			return ErrPostgresDSN
+		}
+
+		// Backups use SQLite backup API
+		if c.BackupPath != "" {
+			return ErrPostgresBackup
+		}
+	default:
+		return ErrInvalidDriver
+	}
+
+	// Ensure retention policy is supported
+	switch c.RetentionPolicy {
+	case "", RetentionPolicyPurge, RetentionPolicyRollup:
+	default:
+		return ErrRetentionPolicy
+	}
+
+	// Ensure usage dimensions are attributes of allocation or tags
+	for _, dimension := range c.UsageDimensions {
+		if !usageDimensionRegexp.MatchString(dimension) {
+			return fmt.Errorf("%w: %s", ErrUsageDimension, dimension)
+		}
+	}
+
 	// Ensure update interval is more than 0
 	if time.Duration(c.UpdateInterval).Seconds() == 0 || time.Duration(c.MaxUpdateInterval).Seconds() == 0 {
 		return ErrUpdateInt
@@ -200,45 +284,61 @@ type Config struct {
 	Logger          *slog.Logger
 	Data            DataConfig
 	Admin           AdminConfig
+	Billing         billing.Config
+	Efficiency      efficiency.Config
+	Budgets         budget.Config
+	Reports         report.Config
 	ResourceManager func(*slog.Logger) (*resource.Manager, error)
 	Updater         func(*slog.Logger) (*updater.UnitUpdater, error)
 }
 
 // storageConfig is the container for storage related config.
 type storageConfig struct {
-	dbPath             string
-	dbBackupPath       string
-	retentionPeriod    time.Duration
-	maxUpdateInterval  time.Duration
-	lastUpdateTime     time.Time
-	timeLocation       *time.Location
-	skipDeleteOldUnits bool
+	driver                string
+	dbPath                string
+	dbBackupPath          string
+	retentionPeriod       time.Duration
+	retentionPolicy       string
+	eventsRetentionPeriod time.Duration
+	maxUpdateInterval     time.Duration
+	lastUpdateTime        time.Time
+	timeLocation          *time.Location
+	usageDimensions       []string
+	skipDeleteOldUnits    bool
 }
 
 // String implements Stringer interface for storageConfig.
 func (s *storageConfig) String() string {
 	return fmt.Sprintf(
-		"DB File Path: %s; Retention Period: %s; Location: %s; Last Updated At: %s; Max Update Interval: %s",
-		s.dbPath, s.retentionPeriod, s.timeLocation, s.lastUpdateTime, s.maxUpdateInterval,
+		"DB Driver: %s; DB File Path: %s; Retention Period: %s; Retention Policy: %s; Location: %s; Last Updated At: %s; Max Update Interval: %s",
+		s.driver, s.dbPath, s.retentionPeriod, s.retentionPolicy, s.timeLocation, s.lastUpdateTime, s.maxUpdateInterval,
 	)
 }
 
 type adminConfig struct {
 	users                map[string]models.List // Map of admin users from different sources
+	coordinators         []CoordinatorsConfig   // Static coordinators of projects
 	grafana              *grafana.Grafana
 	grafanaAdminTeamsIDs []string
 }
 
 // stats struct implements fetching compute units, users and project data.
 type stats struct {
-	logger  *slog.Logger
-	db      *sql.DB
-	dbConn  *ceems_sqlite3.Conn
-	emptyDB bool
-	manager *resource.Manager
-	updater *updater.UnitUpdater
-	storage *storageConfig
-	admin   *adminConfig
+	logger     *slog.Logger
+	db         *sql.DB
+	dbConn     *ceems_sqlite3.Conn
+	emptyDB    bool
+	manager    *resource.Manager
+	updater    *updater.UnitUpdater
+	billing    *billing.Biller
+	efficiency *efficiency.Scorer
+	budgets    *budget.Config
+	notifier   *budget.Notifier
+	reporter   *report.Reporter
+	storage    *storageConfig
+	admin      *adminConfig
+	pushTimes  map[string]time.Time // Last ingest times of clusters with push manager
+	mu         sync.Mutex           // Serializes DB updates from collector and ingest API
 }
 
 // SQLite DB related constant vars.
@@ -249,7 +349,13 @@ const (
 )
 
 var (
-	prepareStatements = make(map[string]string)
+	prepareStatements = map[string]map[string]string{
+		SQLite:   make(map[string]string),
+		Postgres: make(map[string]string),
+	}
+
+	// Statements that aggregate expired units into monthly usage for each driver.
+	rollupStatements = make(map[string]string)
 
 	// For estimating average values, we do weighted average method using following
 	// values as weight for each DB column
@@ -269,14 +375,37 @@ var (
 
 // Init func to set prepareStatements.
 func init() {
-	for _, tableName := range []string{base.UnitsDBTableName, base.UsageDBTableName, base.DailyUsageDBTableName, base.AdminUsersDBTableName, base.UsersDBTableName, base.ProjectsDBTableName} {
+	for _, tableName := range []string{base.UnitsDBTableName, base.UsageDBTableName, base.DailyUsageDBTableName, base.AdminUsersDBTableName, base.UsersDBTableName, base.ProjectsDBTableName, base.BudgetsDBTableName} {
 		statements, err := StatementsFS.ReadFile(fmt.Sprintf("statements/%s.sql", tableName))
 		if err != nil {
 			panic(fmt.Sprintf("failed to read SQL statements file for table %s: %s", tableName, err))
 		}
 
-		prepareStatements[tableName] = string(statements)
+		prepareStatements[SQLite][tableName] = string(statements)
+
+		statements, err = PostgresStatementsFS.ReadFile(fmt.Sprintf("statements/postgres/%s.sql", tableName))
+		if err != nil {
+			panic(fmt.Sprintf("failed to read postgres SQL statements file for table %s: %s", tableName, err))
+		}
+
+		prepareStatements[Postgres][tableName] = string(statements)
+	}
+
+	// Monthly usage is only updated from expired units and hence, its statement
+	// is not prepared for every DB update
+	statements, err := StatementsFS.ReadFile(fmt.Sprintf("statements/%s.sql", base.MonthlyUsageDBTableName))
+	if err != nil {
+		panic(fmt.Sprintf("failed to read SQL statements file for table %s: %s", base.MonthlyUsageDBTableName, err))
 	}
+
+	rollupStatements[SQLite] = string(statements)
+
+	statements, err = PostgresStatementsFS.ReadFile(fmt.Sprintf("statements/postgres/%s.sql", base.MonthlyUsageDBTableName))
+	if err != nil {
+		panic(fmt.Sprintf("failed to read postgres SQL statements file for table %s: %s", base.MonthlyUsageDBTableName, err))
+	}
+
+	rollupStatements[Postgres] = string(statements)
 }
 
 // New returns a new instance of stats struct.
@@ -286,23 +415,42 @@ func New(c *Config) (*stats, error) {
 	// Get file paths
 	dbPath := filepath.Join(c.Data.Path, base.CEEMSDBName)
 
-	// Setup DB
-	db, dbConn, err := setupDB(dbPath, c.Logger)
-	if err != nil {
-		c.Logger.Error("DB setup failed", "err", err)
+	var db *sql.DB
 
-		return nil, err
-	}
+	var dbConn *ceems_sqlite3.Conn
 
-	// Setup Migrator
-	migrator, err := db_migrator.New(MigrationsFS, migrationsDir, c.Logger)
-	if err != nil {
-		return nil, err
-	}
+	var migrator *db_migrator.Migrator
 
-	// Perform DB migrations
-	if err = migrator.ApplyMigrations(db); err != nil {
-		return nil, err
+	// Setup DB and apply migrations
+	switch c.Data.Driver {
+	case Postgres:
+		if db, err = OpenPostgres(string(c.Data.DSN)); err != nil {
+			c.Logger.Error("DB setup failed", "err", err)
+
+			return nil, err
+		}
+
+		if migrator, err = db_migrator.New(PostgresMigrationsFS, postgresMigrationsDir, c.Logger); err != nil {
+			return nil, err
+		}
+
+		if err = migrator.ApplyPostgresMigrations(db); err != nil {
+			return nil, err
+		}
+	default:
+		if db, dbConn, err = setupDB(dbPath, c.Logger); err != nil {
+			c.Logger.Error("DB setup failed", "err", err)
+
+			return nil, err
+		}
+
+		if migrator, err = db_migrator.New(MigrationsFS, migrationsDir, c.Logger); err != nil {
+			return nil, err
+		}
+
+		if err = migrator.ApplyMigrations(db); err != nil {
+			return nil, err
+		}
 	}
 
 	// Get last_updated_at time from DB and overwrite the one provided from config.
@@ -350,19 +498,24 @@ func New(c *Config) (*stats, error) {
 	// Admin config
 	adminConfig := &adminConfig{
 		users:                adminUsers,
+		coordinators:         c.Admin.Coordinators,
 		grafana:              grafanaClient,
 		grafanaAdminTeamsIDs: c.Admin.Grafana.TeamsIDs,
 	}
 
 	// Storage config
 	storageConfig := &storageConfig{
-		dbPath:             dbPath,
-		dbBackupPath:       c.Data.BackupPath,
-		retentionPeriod:    time.Duration(c.Data.RetentionPeriod),
-		maxUpdateInterval:  time.Duration(c.Data.MaxUpdateInterval),
-		lastUpdateTime:     c.Data.LastUpdate.Time,
-		timeLocation:       c.Data.Timezone.Location,
-		skipDeleteOldUnits: c.Data.SkipDeleteOldUnits,
+		driver:                base.Dialect(db),
+		dbPath:                dbPath,
+		dbBackupPath:          c.Data.BackupPath,
+		retentionPeriod:       time.Duration(c.Data.RetentionPeriod),
+		retentionPolicy:       c.Data.RetentionPolicy,
+		eventsRetentionPeriod: time.Duration(c.Data.EventsRetentionPeriod),
+		maxUpdateInterval:     time.Duration(c.Data.MaxUpdateInterval),
+		lastUpdateTime:        c.Data.LastUpdate.Time,
+		timeLocation:          c.Data.Timezone.Location,
+		usageDimensions:       c.Data.UsageDimensions,
+		skipDeleteOldUnits:    c.Data.SkipDeleteOldUnits,
 	}
 
 	// Setup manager struct that retrieves unit data
@@ -381,18 +534,56 @@ func New(c *Config) (*stats, error) {
 		return nil, err
 	}
 
+	// Setup biller that estimates cost of units
+	biller, err := billing.New(&c.Billing, c.Logger)
+	if err != nil {
+		c.Logger.Error("Billing setup failed", "err", err)
+
+		return nil, err
+	}
+
+	// Setup scorer that estimates efficiency of units
+	scorer, err := efficiency.New(&c.Efficiency, c.Logger)
+	if err != nil {
+		c.Logger.Error("Efficiency scorer setup failed", "err", err)
+
+		return nil, err
+	}
+
+	// Setup notifier that sends budget alerts
+	notifier, err := budget.NewNotifier(&c.Budgets, c.Logger)
+	if err != nil {
+		c.Logger.Error("Budget notifier setup failed", "err", err)
+
+		return nil, err
+	}
+
+	// Setup reporter that sends periodic usage digests
+	reporter, err := report.New(&c.Reports, c.Logger)
+	if err != nil {
+		c.Logger.Error("Usage reports setup failed", "err", err)
+
+		return nil, err
+	}
+
 	// Emit debug logs
 	c.Logger.Debug("Storage config", "cfg", storageConfig)
 
 	return &stats{
-		logger:  c.Logger,
-		db:      db,
-		dbConn:  dbConn,
-		emptyDB: emptyDB,
-		manager: manager,
-		updater: updater,
-		storage: storageConfig,
-		admin:   adminConfig,
+		logger:     c.Logger,
+		db:         db,
+		dbConn:     dbConn,
+		emptyDB:    emptyDB,
+		manager:    manager,
+		updater:    updater,
+		billing:    biller,
+		efficiency: scorer,
+		budgets:    &c.Budgets,
+		notifier:   notifier,
+		reporter:   reporter,
+		storage:    storageConfig,
+		admin:      adminConfig,
+		pushTimes:  make(map[string]time.Time),
 	}, nil
 }
 
@@ -437,6 +628,10 @@ func (s *stats) Collect(ctx context.Context) error {
 
 // Backup DB.
 func (s *stats) Backup(ctx context.Context) error {
+	if s.storage.driver == Postgres {
+		return ErrPostgresBackup
+	}
+
 	return s.createBackup(ctx)
 }
 
@@ -445,6 +640,35 @@ func (s *stats) Stop() error {
 	return s.db.Close()
 }
 
+// projectCoordinators returns the coordinators of project fetched from resource
+// manager merged with the static coordinators of the project.
+func (a *adminConfig) projectCoordinators(clusterID string, project models.Project) models.List {
+	var users []string
+
+	for _, c := range project.Coordinators {
+		if user, ok := c.(string); ok {
+			users = append(users, user)
+		}
+	}
+
+	if a != nil {
+		for _, coord := range a.coordinators {
+			if coord.Project == project.Name && (coord.ClusterID == "" || coord.ClusterID == clusterID) {
+				users = append(users, coord.Users...)
+			}
+		}
+	}
+
+	slices.Sort(users)
+
+	coordinators := make(models.List, 0, len(users))
+	for _, user := range slices.Compact(users) {
+		coordinators = append(coordinators, user)
+	}
+
+	return coordinators
+}
+
 // updateAdminUsers updates the static list of admin users with the ones fetched
 // from Grafana teams.
 func (s *stats) updateAdminUsers(ctx context.Context) error {
@@ -495,14 +719,26 @@ func (s *stats) collect(ctx context.Context, startTime, endTime time.Time) error
 	// Update units struct with unit level metrics from TSDB
 	units = s.updater.Update(ctx, startTime, endTime, units)
 
+	// Estimate cost of units using prices valid at the end of current interval
+	units = s.billing.Bill(units, endTime)
+
+	// Estimate efficiency scores of units from the updated metrics
+	units = s.efficiency.Score(units)
+
 	// Update admin users list from Grafana
 	if err := s.updateAdminUsers(ctx); err != nil {
 		s.logger.Error("Failed to update admin users from Grafana", "err", err)
 	}
 
+	// Units can be ingested concurrently by API server. Hold the lock until
+	// the transaction is committed
+	s.mu.Lock()
+
 	// Begin transcation
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
+		s.mu.Unlock()
+
 		return fmt.Errorf("failed to begin SQL transcation: %w", err)
 	}
 
@@ -518,27 +754,72 @@ func (s *stats) collect(ctx context.Context, startTime, endTime time.Time) error
 		}
 	}
 
+	// Compare units with the ones in DB to find lifecycle events. This must be
+	// done before units are updated in DB
+	events := s.unitEvents(ctx, tx, units, endTime)
+
+	// Split units of resource managers that return units of past periods
+	units, ackUnits := s.splitAckUnits(units)
+
 	// Insert data into DB
 	s.logger.Debug("Executing SQL statements")
 
 	if err := s.execStatements(ctx, tx, startTime, endTime, units, users, projects); err != nil {
 		s.logger.Debug("Failed to execute SQL statements", "err", err)
+		s.mu.Unlock()
 
 		return fmt.Errorf("failed to execute SQL statements: %w", err)
 	} else {
 		s.logger.Debug("Finished executing SQL statements")
 	}
 
+	// Units of past periods, like the ones read from accounting files, are
+	// inserted like pushed units so that they are counted in num_units
+	if len(ackUnits) > 0 {
+		if err := s.execAckStatements(ctx, tx, endTime, ackUnits); err != nil {
+			s.logger.Debug("Failed to execute SQL statements", "err", err)
+			s.mu.Unlock()
+
+			return fmt.Errorf("failed to execute SQL statements: %w", err)
+		}
+	}
+
+	// Record lifecycle events of units in the same transaction so that they
+	// are only visible once units are updated
+	if err := s.recordUnitEvents(ctx, tx, events, endTime); err != nil {
+		s.logger.Error("Failed to record unit events", "err", err)
+	}
+
 	// Commit changes
 	if err = tx.Commit(); err != nil {
+		s.mu.Unlock()
+
 		return fmt.Errorf("failed to commit SQL transcation: %w", err)
 	}
 
 	s.logger.Info("DB updated for period", "from", startTime, "to", endTime)
 
+	// Acknowledge units to resource managers only after they are committed so
+	// that they are fetched again when DB update fails
+	if err := s.manager.Ack(endTime); err != nil {
+		s.logger.Error("Failed to acknowledge units to resource managers", "err", err)
+	}
+
 	// Keep track of last updated time upon successful DB ops
 	s.storage.lastUpdateTime = endTime
 
+	s.mu.Unlock()
+
+	// Check budgets of projects against the updated usage
+	if err := s.checkBudgets(ctx, endTime); err != nil {
+		s.logger.Error("Failed to check budgets", "err", err)
+	}
+
+	// Send usage digests of the last complete periods
+	if err := s.sendReports(ctx, endTime); err != nil {
+		s.logger.Error("Failed to send usage reports", "err", err)
+	}
+
 	return nil
 }
 
@@ -547,38 +828,72 @@ func (s *stats) purgeExpiredUnits(ctx context.Context, tx *sql.Tx) error {
 	// Measure elapsed time
 	defer common.TimeTrack(time.Now(), "DB cleanup", s.logger)
 
+	// Date before which entries are purged
+	cutoffTime := time.Now().UTC().AddDate(0, 0, -int(s.storage.retentionPeriod.Hours()/24))
+	cutoff := cutoffTime.Format(time.DateOnly)
+
+	// Aggregate expired units into monthly usage before purging them
+	if s.storage.retentionPolicy == RetentionPolicyRollup {
+		stmt, err := prepareNamed(ctx, tx, s.storage.driver, rollupStatements[s.storage.driver])
+		if err != nil {
+			return fmt.Errorf("failed to prepare rollup statement: %w", err)
+		}
+		defer stmt.Close()
+
+		res, err := stmt.ExecContext(ctx, sql.Named("cutoff", cutoff))
+		if err != nil {
+			return fmt.Errorf("failed to rollup expired units: %w", err)
+		}
+
+		// Get changes
+		if rollupsUpdated, err := res.RowsAffected(); err == nil {
+			s.logger.Debug("DB update", "rollups_updated", rollupsUpdated)
+		}
+	}
+
 	// Purge expired units
-	deleteUnitsQuery := fmt.Sprintf(
-		"DELETE FROM %s WHERE started_at <= date('now', '-%d day')",
-		base.UnitsDBTableName,
-		int(s.storage.retentionPeriod.Hours()/24),
+	deleteUnitsQuery := base.Rebind(
+		s.storage.driver, fmt.Sprintf("DELETE FROM %s WHERE started_at <= ?", base.UnitsDBTableName),
 	) // #nosec
-	if _, err := tx.ExecContext(ctx, deleteUnitsQuery); err != nil {
+
+	res, err := tx.ExecContext(ctx, deleteUnitsQuery, cutoff)
+	if err != nil {
 		return err
 	}
 
 	// Get changes
-	var unitsDeleted int
-	if err := tx.QueryRowContext(ctx, "SELECT changes()").Scan(&unitsDeleted); err == nil {
+	if unitsDeleted, err := res.RowsAffected(); err == nil {
 		s.logger.Debug("DB update", "units_deleted", unitsDeleted)
 	}
 
 	// Purge stale usage data
-	deleteUsageQuery := fmt.Sprintf(
-		"DELETE FROM %s WHERE last_updated_at <= date('now', '-%d day')",
-		base.UsageDBTableName,
-		int(s.storage.retentionPeriod.Hours()/24),
+	deleteUsageQuery := base.Rebind(
+		s.storage.driver, fmt.Sprintf("DELETE FROM %s WHERE last_updated_at <= ?", base.UsageDBTableName),
 	) // #nosec
-	if _, err := tx.ExecContext(ctx, deleteUsageQuery); err != nil {
+
+	if res, err = tx.ExecContext(ctx, deleteUsageQuery, cutoff); err != nil {
 		return err
 	}
 
 	// Get changes
-	var usageDeleted int
-	if err := tx.QueryRowContext(ctx, "SELECT changes()").Scan(&usageDeleted); err == nil {
+	if usageDeleted, err := res.RowsAffected(); err == nil {
 		s.logger.Debug("DB update", "usage_deleted", usageDeleted)
 	}
 
+	// Purge audit records. Timestamps of audit records are in epoch milliseconds
+	deleteAuditQuery := base.Rebind(
+		s.storage.driver, fmt.Sprintf("DELETE FROM %s WHERE timestamp <= ?", base.AuditLogDBTableName),
+	) // #nosec
+
+	if res, err = tx.ExecContext(ctx, deleteAuditQuery, cutoffTime.UnixMilli()); err != nil {
+		return err
+	}
+
+	// Get changes
+	if auditDeleted, err := res.RowsAffected(); err == nil {
+		s.logger.Debug("DB update", "audit_records_deleted", auditDeleted)
+	}
+
 	return nil
 }
 
@@ -596,12 +911,12 @@ func (s *stats) execStatements(
 	defer common.TimeTrack(time.Now(), "DB insertion", s.logger)
 
 	// Prepare statements
-	stmts := make(map[string]*sql.Stmt, len(prepareStatements))
+	stmts := make(map[string]*namedStmt, len(prepareStatements[s.storage.driver]))
 
 	var err error
 
-	for table, stmt := range prepareStatements {
-		stmts[table], err = tx.PrepareContext(ctx, stmt) //nolint:sqlclosecheck
+	for table, stmt := range prepareStatements[s.storage.driver] {
+		stmts[table], err = prepareNamed(ctx, tx, s.storage.driver, stmt) //nolint:sqlclosecheck
 		if err != nil {
 			return fmt.Errorf("failed to prepare statement for table %s: %w", table, err)
 		}
@@ -654,7 +969,10 @@ func (s *stats) execStatements(
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["TotalIOReadStats"], unit.TotalIOReadStats),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["TotalIngressStats"], unit.TotalIngressStats),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["TotalOutgressStats"], unit.TotalOutgressStats),
+				sql.Named(base.UnitsDBTableStructFieldColNameMap["TotalCost"], unit.TotalCost),
+				sql.Named(base.UnitsDBTableStructFieldColNameMap["Efficiency"], unit.Efficiency),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["Tags"], unit.Tags),
+				sql.Named(base.UnitsDBTableStructFieldColNameMap["ParentUUID"], unit.ParentUUID),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["Ignore"], unit.Ignore),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["NumUpdates"], 1),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
@@ -662,6 +980,12 @@ func (s *stats) execStatements(
 				s.logger.Error("Failed to insert unit in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
 			}
 
+			// Sub units like job steps are already accounted in their parent
+			// units. Skip them for usage tables to avoid double counting
+			if unit.ParentUUID != "" {
+				continue
+			}
+
 			// If the unit has started in this update period, increment num units
 			// Or if we start with empty DB, we need to increment for num units for all discovered units
 			unitIncr = 0
@@ -693,6 +1017,7 @@ func (s *stats) execStatements(
 				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIOReadStats"], unit.TotalIOReadStats),
 				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIngressStats"], unit.TotalIngressStats),
 				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalOutgressStats"], unit.TotalOutgressStats),
+				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalCost"], unit.TotalCost),
 				sql.Named(base.UsageDBTableStructFieldColNameMap["NumUpdates"], 1),
 			); err != nil {
 				s.logger.Error("Failed to update usage table in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
@@ -722,6 +1047,8 @@ func (s *stats) execStatements(
 				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIOReadStats"], unit.TotalIOReadStats),
 				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIngressStats"], unit.TotalIngressStats),
 				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalOutgressStats"], unit.TotalOutgressStats),
+				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalCost"], unit.TotalCost),
+				sql.Named(base.UsageDBTableStructFieldColNameMap["Dimensions"], usageDimensions(unit, s.storage.usageDimensions)),
 				sql.Named(base.UsageDBTableStructFieldColNameMap["NumUpdates"], 1),
 			); err != nil {
 				s.logger.Error("Failed to update daily_usage table in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
@@ -757,6 +1084,7 @@ func (s *stats) execStatements(
 				sql.Named(base.ProjectsDBTableStructFieldColNameMap["UID"], project.UID),
 				sql.Named(base.ProjectsDBTableStructFieldColNameMap["Name"], project.Name),
 				sql.Named(base.ProjectsDBTableStructFieldColNameMap["Users"], project.Users),
+				sql.Named(base.ProjectsDBTableStructFieldColNameMap["Coordinators"], s.admin.projectCoordinators(cluster.Cluster.ID, project)),
 				sql.Named(base.ProjectsDBTableStructFieldColNameMap["Tags"], project.Tags),
 				sql.Named(base.ProjectsDBTableStructFieldColNameMap["LastUpdatedAt"], project.LastUpdatedAt),
 			); err != nil {
@@ -785,22 +1113,49 @@ func (s *stats) execStatements(
 	return nil
 }
 
+// usageDimensions returns the values of dimensions of unit. Units that do not
+// have a dimension are grouped together in daily usage.
+func usageDimensions(unit models.Unit, dimensions []string) models.Tag {
+	values := make(models.Tag, len(dimensions))
+
+	for _, dimension := range dimensions {
+		source, key, _ := strings.Cut(dimension, ".")
+
+		attrs := unit.Allocation
+		if source == "tags" {
+			attrs = unit.Tags
+		}
+
+		if value, ok := attrs[key]; ok {
+			values[dimension] = value
+		}
+	}
+
+	return values
+}
+
 // backup executes the sqlite3 backup strategy
 // Based on https://gist.github.com/bbengfort/452a9d5e74a63d88e5a34a580d6cb6d3
 // Ref: https://github.com/rotationalio/ensign/pull/529/files
 func (s *stats) backup(ctx context.Context, backupDBPath string) error {
+	return copyDB(ctx, s.dbConn, backupDBPath, s.logger)
+}
+
+// copyDB copies the DB of srcConn into a new DB file at destDBPath using SQLite
+// backup API.
+func copyDB(ctx context.Context, srcConn *ceems_sqlite3.Conn, destDBPath string, logger *slog.Logger) error {
 	var backupDBFile *os.File
 
 	var err error
 	// Create a backup DB file
-	if backupDBFile, err = os.Create(backupDBPath); err != nil {
+	if backupDBFile, err = os.Create(destDBPath); err != nil {
 		return err
 	}
 
 	backupDBFile.Close()
 
 	// Open a second sqlite3 database at the backup location
-	destDB, destConn, err := openDBConnection(backupDBPath)
+	destDB, destConn, err := openDBConnection(destDBPath)
 	if err != nil {
 		return err
 	}
@@ -813,7 +1168,7 @@ func (s *stats) backup(ctx context.Context, backupDBPath string) error {
 	// NOTE: backup.Finish() MUST be called to prevent panics.
 	var backup *sqlite3.SQLiteBackup
 
-	if backup, err = destConn.Backup(sqlite3Main, s.dbConn, sqlite3Main); err != nil {
+	if backup, err = destConn.Backup(sqlite3Main, srcConn, sqlite3Main); err != nil {
 		return err
 	}
 
@@ -825,7 +1180,7 @@ func (s *stats) backup(ctx context.Context, backupDBPath string) error {
 	for !isDone {
 		select {
 		case <-ctx.Done():
-			s.logger.Debug("DB backup aborted due to cancelled context", "err", ctx.Err())
+			logger.Debug("DB backup aborted due to cancelled context", "err", ctx.Err())
 
 			return backup.Finish()
 		default:
@@ -839,7 +1194,7 @@ func (s *stats) backup(ctx context.Context, backupDBPath string) error {
 				return err
 			}
 
-			s.logger.Debug("DB backup step", "remaining", backup.Remaining(), "page_count", backup.PageCount())
+			logger.Debug("DB backup step", "remaining", backup.Remaining(), "page_count", backup.PageCount())
 
 			// This sleep allows other transactions to write during backups.
 			time.Sleep(stepSleep)
@@ -853,7 +1208,12 @@ func (s *stats) backup(ctx context.Context, backupDBPath string) error {
 func (s *stats) vacuum(ctx context.Context) error {
 	s.logger.Debug("Starting to vacuum DB")
 
-	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+	return vacuumDB(ctx, s.db)
+}
+
+// vacuumDB rebuilds the DB to free up unused pages.
+func vacuumDB(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, "VACUUM"); err != nil {
 		return err
 	}
 
@@ -18,14 +18,19 @@ import (
 	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/billing"
+	"github.com/mahendrapaipuri/ceems/pkg/api/budget"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/report"
 	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource/file"
 	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
 	"github.com/mahendrapaipuri/ceems/pkg/grafana"
 	config_util "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 type mockFetcherOne struct {
@@ -978,6 +983,75 @@ func TestAdminUsersDBUpdate(t *testing.T) {
 	assert.ElementsMatch(t, s.admin.users["grafana"], models.List{"foo", "bar"})
 }
 
+func TestProjectCoordinatorsDBUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Static coordinators of fooprj in all clusters and barprj only in slurm-1
+	c.Admin.Coordinators = []CoordinatorsConfig{
+		{Project: "fooprj", Users: []string{"pi2", "pi1"}},
+		{ClusterID: "slurm-1", Project: "barprj", Users: []string{"pi3"}},
+	}
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	// Coordinators fetched from resource manager must be merged with static ones
+	projects := []models.ClusterProjects{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Projects: []models.Project{
+				{Name: "fooprj", Users: models.List{"foo1"}, Coordinators: models.List{"pi1"}},
+				{Name: "barprj", Users: models.List{"bar1"}},
+			},
+		},
+		{
+			Cluster: models.Cluster{ID: "slurm-1"},
+			Projects: []models.Project{
+				{Name: "fooprj", Users: models.List{"foo1"}},
+				{Name: "barprj", Users: models.List{"bar1"}},
+			},
+		},
+	}
+
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+
+	s.execStatements(context.Background(), tx, time.Now().Add(-time.Minute), time.Now(), nil, nil, projects)
+	require.NoError(t, tx.Commit())
+
+	expected := map[string]string{
+		"slurm-0/fooprj": `["pi1","pi2"]`,
+		"slurm-0/barprj": `[]`,
+		"slurm-1/fooprj": `["pi1","pi2"]`,
+		"slurm-1/barprj": `["pi3"]`,
+	}
+
+	rows, err := s.db.Query("SELECT cluster_id, name, coordinators FROM " + base.ProjectsDBTableName)
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	got := make(map[string]string)
+
+	for rows.Next() {
+		var clusterID, name, coordinators string
+
+		require.NoError(t, rows.Scan(&clusterID, &name, &coordinators))
+
+		got[clusterID+"/"+name] = coordinators
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, expected, got)
+
+	// Static coordinators must have a project
+	require.ErrorIs(t, (&AdminConfig{Coordinators: []CoordinatorsConfig{{Users: []string{"pi1"}}}}).Validate(), ErrCoordProject)
+}
+
 func TestStatsDBBackup(t *testing.T) {
 	tmpDir := t.TempDir()
 	c, err := prepareMockConfig(tmpDir)
@@ -1050,3 +1124,865 @@ func TestUnitStatsDeleteOldUnits(t *testing.T) {
 	require.NoError(t, err, "failed to query DB")
 	assert.Equal(t, 0, numRows, "expected 0 rows after deletion")
 }
+
+func TestUnitStatsRollupOldUnits(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.RetentionPolicy = RetentionPolicyRollup
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	newUnit := func(uuid, parent, startedAt string, walltime, cpuUsage float64) models.Unit {
+		return models.Unit{
+			UUID:       uuid,
+			ParentUUID: parent,
+			User:       "foo1",
+			Project:    "fooprj",
+			StartedAt:  startedAt,
+			TotalTime: models.MetricMap{
+				"walltime":      models.JSONFloat(walltime),
+				"alloc_cputime": models.JSONFloat(2 * walltime),
+			},
+			AveCPUUsage:         models.MetricMap{"usage": models.JSONFloat(cpuUsage)},
+			TotalCPUEnergyUsage: models.MetricMap{"total": models.JSONFloat(walltime / 100)},
+		}
+	}
+
+	// Two expired units in January with a step, one expired unit in February
+	// and one unit within retention period
+	recent := time.Now().Format(base.DatetimezoneLayout)
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{
+				ID: "slurm-0",
+			},
+			Units: []models.Unit{
+				newUnit("1000", "", "2024-01-10T10:00:00+0100", 100, 10),
+				newUnit("1000.0", "1000", "2024-01-10T10:00:00+0100", 50, 10),
+				newUnit("1001", "", "2024-01-20T10:00:00+0100", 300, 50),
+				newUnit("1002", "", "2024-02-01T00:30:00+0100", 200, 20),
+				newUnit("1003", "", recent, 400, 80),
+			},
+		},
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+	err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil)
+	require.NoError(t, err)
+
+	// Now roll up and clean up DB for old units
+	err = s.purgeExpiredUnits(ctx, tx)
+	require.NoError(t, err, "failed to roll up old entries in DB")
+	tx.Commit()
+
+	// Another unit of January that expires later must be added to existing rollup
+	units[0].Units = []models.Unit{newUnit("1004", "", "2024-01-25T10:00:00+0100", 100, 90)}
+	tx, err = s.db.Begin()
+	require.NoError(t, err)
+	err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil)
+	require.NoError(t, err)
+	err = s.purgeExpiredUnits(ctx, tx)
+	require.NoError(t, err, "failed to roll up old entries in DB")
+	tx.Commit()
+
+	// Only recent unit must be left in units table
+	var uuids []string
+
+	rows, err := s.db.Query(fmt.Sprintf("SELECT uuid FROM %s", base.UnitsDBTableName))
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var uuid string
+		require.NoError(t, rows.Scan(&uuid))
+
+		uuids = append(uuids, uuid)
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"1003"}, uuids)
+
+	// Check rollups
+	type rollup struct {
+		numUnits   int
+		totalTime  models.MetricMap
+		avgCPU     models.MetricMap
+		totalPower models.MetricMap
+	}
+
+	rollups := make(map[string]rollup)
+
+	rows, err = s.db.Query(
+		fmt.Sprintf(
+			"SELECT last_updated_at,num_units,total_time_seconds,avg_cpu_usage,total_cpu_energy_usage_kwh FROM %s WHERE username = 'foo1'",
+			base.MonthlyUsageDBTableName,
+		),
+	)
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var month string
+
+		var r rollup
+		require.NoError(t, rows.Scan(&month, &r.numUnits, &r.totalTime, &r.avgCPU, &r.totalPower))
+
+		rollups[month] = r
+	}
+
+	require.NoError(t, rows.Err())
+	require.Len(t, rollups, 2)
+
+	// Steps must not be accounted and averages must be weighed by alloc_cputime
+	jan := rollups["2024-01-01T00:00:00"]
+	assert.Equal(t, 3, jan.numUnits)
+	assert.InEpsilon(t, 500, float64(jan.totalTime["walltime"]), 0)
+	assert.InEpsilon(t, 5, float64(jan.totalPower["total"]), 1e-9)
+	assert.InEpsilon(t, 50, float64(jan.avgCPU["usage"]), 1e-9)
+
+	feb := rollups["2024-02-01T00:00:00"]
+	assert.Equal(t, 1, feb.numUnits)
+	assert.InEpsilon(t, 20, float64(feb.avgCPU["usage"]), 1e-9)
+}
+
+func TestUnitStatsDBSubUnits(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	// Job with one step
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{
+				ID: "slurm-0",
+			},
+			Units: []models.Unit{
+				{
+					UUID:    "1000",
+					User:    "foo1",
+					Project: "fooprj",
+					TotalTime: models.MetricMap{
+						"walltime":      models.JSONFloat(900),
+						"alloc_cputime": models.JSONFloat(1800),
+					},
+				},
+				{
+					UUID:       "1000.0",
+					ParentUUID: "1000",
+					User:       "foo1",
+					Project:    "fooprj",
+					TotalTime: models.MetricMap{
+						"walltime":      models.JSONFloat(600),
+						"alloc_cputime": models.JSONFloat(1200),
+					},
+				},
+			},
+		},
+	}
+	ctx := context.Background()
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+	err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil)
+	require.NoError(t, err)
+	tx.Commit()
+
+	// Step must be stored with its parent UUID
+	var parentUUID string
+	err = s.db.QueryRow(
+		fmt.Sprintf("SELECT parent_uuid FROM %s WHERE uuid = '1000.0';", base.UnitsDBTableName),
+	).Scan(&parentUUID)
+	require.NoError(t, err, "failed to query DB")
+	assert.Equal(t, "1000", parentUUID)
+
+	// Usage must be accounted only from the parent unit
+	var numUnits int
+
+	var totalTime models.MetricMap
+	err = s.db.QueryRow(
+		fmt.Sprintf("SELECT num_units,total_time_seconds FROM %s WHERE username = 'foo1';", base.UsageDBTableName),
+	).Scan(&numUnits, &totalTime)
+	require.NoError(t, err, "failed to query DB")
+	assert.Equal(t, 1, numUnits)
+	assert.InEpsilon(t, 900, float64(totalTime["walltime"]), 0)
+}
+
+func TestUnitStatsDBEvents(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.EventsRetentionPeriod = model.Duration(time.Hour)
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	unitsAt := func(units ...models.Unit) []models.ClusterUnits {
+		return []models.ClusterUnits{{Cluster: models.Cluster{ID: "slurm-0"}, Units: units}}
+	}
+
+	update := func(currentTime time.Time, units []models.ClusterUnits) {
+		ctx := context.Background()
+		tx, err := s.db.Begin()
+		require.NoError(t, err)
+
+		events := s.unitEvents(ctx, tx, units, currentTime)
+		require.NoError(t, s.execStatements(ctx, tx, currentTime.Add(-time.Minute), currentTime, units, nil, nil))
+		require.NoError(t, s.recordUnitEvents(ctx, tx, events, currentTime))
+		require.NoError(t, tx.Commit())
+	}
+
+	// Returns events in DB and checks that their IDs are increasing
+	events := func() [][]string {
+		rows, err := s.db.Query(fmt.Sprintf("SELECT id,type,uuid,state,previous_state FROM %s ORDER BY id", base.UnitEventsDBTableName))
+		require.NoError(t, err)
+
+		defer rows.Close()
+
+		var got [][]string
+
+		var lastID int64
+
+		for rows.Next() {
+			var id int64
+
+			var typ, uuid, state, prevState string
+
+			require.NoError(t, rows.Scan(&id, &typ, &uuid, &state, &prevState))
+			assert.Greater(t, id, lastID)
+
+			lastID = id
+
+			got = append(got, []string{typ, uuid, state, prevState})
+		}
+
+		return got
+	}
+
+	now := time.Now()
+
+	// Events must not be generated when DB is primed
+	update(now.Add(-2*time.Hour), unitsAt(models.Unit{UUID: "999", User: "foo1", State: "RUNNING"}))
+
+	// First update after priming
+	update(now.Add(-90*time.Minute), unitsAt(
+		models.Unit{UUID: "1000", User: "foo1", Project: "fooprj", State: "PENDING"},
+		models.Unit{UUID: "1001", User: "foo2", Project: "fooprj", State: "COMPLETED", EndedAtTS: 10},
+		models.Unit{UUID: "1000.0", ParentUUID: "1000", User: "foo1", State: "RUNNING"},
+	))
+
+	expected := [][]string{
+		{models.UnitEventCreated, "1000", "PENDING", ""},
+		{models.UnitEventCreated, "1001", "COMPLETED", ""},
+		{models.UnitEventEnded, "1001", "COMPLETED", ""},
+	}
+	assert.Equal(t, expected, events())
+
+	// Second update
+	update(now, unitsAt(
+		models.Unit{UUID: "999", User: "foo1", State: "RUNNING", AveCPUUsage: models.MetricMap{"usage": 10}},
+		models.Unit{UUID: "1000", User: "foo1", Project: "fooprj", State: "RUNNING"},
+		models.Unit{UUID: "1001", User: "foo2", Project: "fooprj", State: "COMPLETED", EndedAtTS: 10},
+		models.Unit{UUID: "1002", User: "foo2", Project: "fooprj", State: "RUNNING", Ignore: 1},
+	))
+
+	// Events of first update must be purged as they are older than retention period
+	expected = [][]string{
+		{models.UnitEventMetricsUpdated, "999", "RUNNING", ""},
+		{models.UnitEventStateChanged, "1000", "RUNNING", "PENDING"},
+	}
+	assert.Equal(t, expected, events())
+}
+
+func TestUnitStatsDBBilling(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Billing = billing.Config{
+		PriceTables: []billing.PriceTable{
+			{
+				ClusterID: "slurm-0",
+				Prices:    []billing.Price{{CPUHour: 0.5, EnergyKWh: 0.2}},
+			},
+		},
+	}
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	ctx := context.Background()
+
+	// Insert same unit in two consecutive intervals
+	for range 2 {
+		units := []models.ClusterUnits{
+			{
+				Cluster: models.Cluster{
+					ID: "slurm-0",
+				},
+				Units: []models.Unit{
+					{
+						UUID:    "1000",
+						User:    "foo1",
+						Project: "fooprj",
+						TotalTime: models.MetricMap{
+							"walltime":         models.JSONFloat(1800),
+							"alloc_cputime":    models.JSONFloat(3600),
+							"alloc_cpumemtime": models.JSONFloat(3600),
+							"alloc_gputime":    models.JSONFloat(0),
+							"alloc_gpumemtime": models.JSONFloat(0),
+						},
+						TotalCPUEnergyUsage: models.MetricMap{"total": models.JSONFloat(1)},
+					},
+				},
+			},
+		}
+		units = s.billing.Bill(units, time.Now())
+
+		tx, err := s.db.Begin()
+		require.NoError(t, err)
+		err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+	}
+
+	// Cost must be accumulated in units and usage tables
+	for _, table := range []string{base.UnitsDBTableName, base.UsageDBTableName, base.DailyUsageDBTableName} {
+		var totalCost models.MetricMap
+		err = s.db.QueryRow(
+			fmt.Sprintf("SELECT total_cost FROM %s WHERE username = 'foo1';", table),
+		).Scan(&totalCost)
+		require.NoError(t, err, "failed to query DB")
+		assert.InEpsilon(t, 1, float64(totalCost["cpu"]), 1e-6, table)
+		assert.InEpsilon(t, 0.4, float64(totalCost["energy"]), 1e-6, table)
+		assert.InEpsilon(t, 1.4, float64(totalCost["total"]), 1e-6, table)
+	}
+}
+
+func TestUnitStatsDBEfficiency(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	ctx := context.Background()
+
+	// Insert same unit in two consecutive intervals with different usages
+	for _, usage := range []float64{80, 40} {
+		units := []models.ClusterUnits{
+			{
+				Cluster: models.Cluster{
+					ID: "slurm-0",
+				},
+				Units: []models.Unit{
+					{
+						UUID:    "1000",
+						User:    "foo1",
+						Project: "fooprj",
+						TotalTime: models.MetricMap{
+							"walltime":         models.JSONFloat(1800),
+							"alloc_cputime":    models.JSONFloat(3600),
+							"alloc_cpumemtime": models.JSONFloat(3600),
+							"alloc_gputime":    models.JSONFloat(0),
+							"alloc_gpumemtime": models.JSONFloat(0),
+						},
+						AveCPUUsage:         models.MetricMap{"global": models.JSONFloat(usage)},
+						TotalCPUEnergyUsage: models.MetricMap{"total": models.JSONFloat(1)},
+					},
+				},
+			},
+		}
+		units = s.efficiency.Score(units)
+
+		tx, err := s.db.Begin()
+		require.NoError(t, err)
+		err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+	}
+
+	// Scores must be averaged and wasted energy accumulated
+	var scores models.MetricMap
+	err = s.db.QueryRow(
+		fmt.Sprintf("SELECT efficiency FROM %s WHERE username = 'foo1';", base.UnitsDBTableName),
+	).Scan(&scores)
+	require.NoError(t, err, "failed to query DB")
+	assert.InEpsilon(t, 0.6, float64(scores["cpu"]), 1e-6)
+	assert.InEpsilon(t, 0.6, float64(scores["score"]), 1e-6)
+	assert.InEpsilon(t, 0.8, float64(scores["wasted_energy_kwh"]), 1e-6)
+}
+
+func TestUnitStatsDBUsageDimensions(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.UsageDimensions = []string{"allocation.partition", "tags.qos"}
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	ctx := context.Background()
+
+	unit := func(uuid, partition string) models.Unit {
+		return models.Unit{
+			UUID:        uuid,
+			User:        "foo1",
+			Project:     "fooprj",
+			StartedAtTS: time.Now().UnixMilli(),
+			Allocation:  models.Allocation{"partition": partition, "nodes": 1},
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(1800),
+				"alloc_cputime":    models.JSONFloat(3600),
+				"alloc_cpumemtime": models.JSONFloat(3600),
+				"alloc_gputime":    models.JSONFloat(0),
+				"alloc_gpumemtime": models.JSONFloat(0),
+			},
+			TotalCPUEnergyUsage: models.MetricMap{"total": models.JSONFloat(1)},
+		}
+	}
+
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units:   []models.Unit{unit("1000", "cpu"), unit("1001", "gpu"), unit("1002", "gpu")},
+		},
+	}
+
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+	err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	// Daily usage must be split by configured dimensions
+	rows, err := s.db.Query(
+		fmt.Sprintf("SELECT dimensions, num_units FROM %s WHERE username = 'foo1' ORDER BY dimensions;", base.DailyUsageDBTableName),
+	)
+	require.NoError(t, err, "failed to query DB")
+
+	defer rows.Close()
+
+	var dimensions []models.Tag
+
+	var numUnits []int64
+
+	for rows.Next() {
+		var d models.Tag
+
+		var n int64
+
+		require.NoError(t, rows.Scan(&d, &n))
+
+		dimensions = append(dimensions, d)
+		numUnits = append(numUnits, n)
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []models.Tag{{"allocation.partition": "cpu"}, {"allocation.partition": "gpu"}}, dimensions)
+	assert.Equal(t, []int64{1, 2}, numUnits)
+
+	// Only allocation and tags are valid dimensions
+	require.ErrorIs(t, (&DataConfig{UsageDimensions: []string{"project"}}).Validate(), ErrUsageDimension)
+}
+
+func TestUnitStatsDBBudgets(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Webhook server that records alerts
+	var alerts []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&payload); err == nil {
+			alerts = append(alerts, payload["text"])
+		}
+	}))
+	defer server.Close()
+
+	c.Budgets = budget.Config{
+		Thresholds:      []float64{80, 100},
+		EnergyMetric:    "total",
+		EmissionsMetric: "owid_total",
+		Budgets: []budget.Budget{
+			{ClusterID: "slurm-0", Project: "fooprj", Period: budget.Global, CPUHours: 2},
+		},
+		Webhooks: []models.WebConfig{{URL: server.URL}},
+	}
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	ctx := context.Background()
+
+	// Each interval consumes one CPU hour of a 2 CPU hours budget
+	for i := range 3 {
+		units := []models.ClusterUnits{
+			{
+				Cluster: models.Cluster{
+					ID: "slurm-0",
+				},
+				Units: []models.Unit{
+					{
+						UUID:    "1000",
+						User:    "foo1",
+						Project: "fooprj",
+						TotalTime: models.MetricMap{
+							"walltime":         models.JSONFloat(1800),
+							"alloc_cputime":    models.JSONFloat(3600),
+							"alloc_cpumemtime": models.JSONFloat(3600),
+							"alloc_gputime":    models.JSONFloat(0),
+							"alloc_gpumemtime": models.JSONFloat(0),
+						},
+						TotalCPUEnergyUsage: models.MetricMap{"total": models.JSONFloat(1)},
+					},
+				},
+			},
+		}
+
+		tx, err := s.db.Begin()
+		require.NoError(t, err)
+		err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		require.NoError(t, s.checkBudgets(ctx, time.Now()))
+
+		// Alerts must be sent only once when each threshold is crossed
+		switch i {
+		case 0:
+			assert.Empty(t, alerts)
+		case 1, 2:
+			assert.Len(t, alerts, 1)
+		}
+	}
+
+	var b models.Budget
+	err = s.db.QueryRow(
+		fmt.Sprintf("SELECT consumed,used_percent,notified_threshold FROM %s WHERE project = 'fooprj';", base.BudgetsDBTableName),
+	).Scan(&b.Consumed, &b.UsedPercent, &b.NotifiedThreshold)
+	require.NoError(t, err, "failed to query DB")
+	assert.InEpsilon(t, 3, float64(b.Consumed[budget.CPUHours]), 1e-6)
+	assert.InEpsilon(t, 3, float64(b.Consumed[budget.EnergyKWh]), 1e-6)
+	assert.InEpsilon(t, 150, float64(b.UsedPercent[budget.CPUHours]), 1e-6)
+	assert.InEpsilon(t, 100, b.NotifiedThreshold, 1e-6)
+}
+
+func TestUnitStatsDBReports(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Webhook server that records digests
+	var digests []report.Digest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Digest report.Digest `json:"digest"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err == nil {
+			digests = append(digests, payload.Digest)
+		}
+	}))
+	defer server.Close()
+
+	c.Reports = report.Config{
+		Periods:         []string{report.Weekly},
+		TopUnits:        1,
+		EnergyMetric:    "total",
+		EmissionsMetric: "owid_total",
+		Recipients:      report.RecipientsConfig{Tag: "email"},
+		Webhooks:        []models.WebConfig{{URL: server.URL}},
+		Directory:       filepath.Join(tmpDir, "reports"),
+	}
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	ctx := context.Background()
+
+	weekStart := report.PeriodStart(report.Weekly, time.Now().UTC())
+
+	// Units in the week before last and in the last week
+	for i, at := range []time.Time{weekStart.AddDate(0, 0, -10), weekStart.AddDate(0, 0, -3), weekStart.AddDate(0, 0, -3)} {
+		units := []models.ClusterUnits{
+			{
+				Cluster: models.Cluster{
+					ID: "slurm-0",
+				},
+				Units: []models.Unit{
+					{
+						UUID:        fmt.Sprintf("100%d", i),
+						Name:        "job",
+						State:       "COMPLETED",
+						User:        "foo1",
+						Project:     "fooprj",
+						StartedAtTS: at.UnixMilli(),
+						EndedAtTS:   at.Add(time.Hour).UnixMilli(),
+						TotalTime: models.MetricMap{
+							"walltime":         models.JSONFloat(3600),
+							"alloc_cputime":    models.JSONFloat(3600),
+							"alloc_cpumemtime": models.JSONFloat(3600),
+							"alloc_gputime":    models.JSONFloat(0),
+							"alloc_gpumemtime": models.JSONFloat(0),
+						},
+						TotalCPUEnergyUsage: models.MetricMap{"total": models.JSONFloat(i + 1)},
+						Efficiency:          models.MetricMap{"cpu": models.JSONFloat(0.5), "score": models.JSONFloat(0.5)},
+					},
+				},
+			},
+		}
+
+		users := []models.ClusterUsers{
+			{
+				Cluster: models.Cluster{ID: "slurm-0"},
+				Users: []models.User{
+					{Name: "foo1", Projects: models.List{"fooprj"}, Tags: models.List{"email=foo1@example.com"}},
+				},
+			},
+		}
+
+		tx, err := s.db.Begin()
+		require.NoError(t, err)
+		err = s.execStatements(ctx, tx, at.Add(-time.Minute), at, units, users, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+	}
+
+	// Recipients must be resolved from user tags
+	got, err := s.digests(ctx, report.Weekly, weekStart.AddDate(0, 0, -7), weekStart)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, report.ProjectDigest, got[0].Kind)
+	assert.Empty(t, got[0].Recipients)
+	assert.Equal(t, report.UserDigest, got[1].Kind)
+	assert.Equal(t, []string{"foo1@example.com"}, got[1].Recipients)
+
+	// Digests must be sent only once per period
+	for range 2 {
+		require.NoError(t, s.sendReports(ctx, weekStart.Add(time.Hour)))
+	}
+
+	require.Len(t, digests, 2)
+
+	for _, d := range digests {
+		if d.Kind == report.UserDigest {
+			assert.Equal(t, "foo1", d.Name)
+		} else {
+			assert.Equal(t, "fooprj", d.Name)
+		}
+
+		assert.Equal(t, int64(2), d.Current.NumUnits)
+		assert.Equal(t, int64(1), d.Previous.NumUnits)
+		assert.InEpsilon(t, 2, d.Current.CPUHours, 1e-6)
+		assert.InEpsilon(t, 5, d.Current.EnergyKWh, 1e-6)
+		assert.InEpsilon(t, 0.5, float64(d.Current.Efficiency["score"]), 1e-6)
+		require.Len(t, d.TopUnits, 1)
+		assert.Equal(t, "1002", d.TopUnits[0].UUID)
+	}
+
+	label := report.PeriodLabel(report.Weekly, weekStart.AddDate(0, 0, -7))
+	for _, name := range []string{"user-slurm-0-foo1.txt", "project-slurm-0-fooprj.html"} {
+		_, err := os.Stat(filepath.Join(tmpDir, "reports", report.Weekly, label, name))
+		require.NoError(t, err)
+	}
+}
+
+func TestUnitStatsDBIngest(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.ResourceManager = func(logger *slog.Logger) (*resource.Manager, error) {
+		pusher, err := resource.NewPushResourceManager(models.Cluster{ID: "push-0", Manager: "push"}, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		return &resource.Manager{Logger: logger, Fetchers: []resource.Fetcher{pusher}}, nil
+	}
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	ctx := context.Background()
+
+	unit := func(uuid string) models.Unit {
+		return models.Unit{
+			UUID:        uuid,
+			User:        "foo1",
+			Project:     "fooprj",
+			StartedAtTS: 1,
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(1800),
+				"alloc_cputime":    models.JSONFloat(3600),
+				"alloc_cpumemtime": models.JSONFloat(3600),
+				"alloc_gputime":    models.JSONFloat(0),
+				"alloc_gpumemtime": models.JSONFloat(0),
+			},
+			TotalCPUEnergyUsage: models.MetricMap{"total": models.JSONFloat(1)},
+		}
+	}
+
+	// Units pushed for first time must be counted irrespective of start time
+	err = s.Ingest(
+		ctx, "push-0", []models.Unit{unit("1000")},
+		[]models.User{{Name: "foo1", Projects: models.List{"fooprj"}}},
+		[]models.Project{{Name: "fooprj", Users: models.List{"foo1"}}},
+	)
+	require.NoError(t, err)
+
+	err = s.Ingest(ctx, "push-0", []models.Unit{unit("1000"), unit("1001")}, nil, nil)
+	require.NoError(t, err)
+
+	var numUnits int64
+
+	var energy models.MetricMap
+
+	err = s.db.QueryRow(
+		fmt.Sprintf("SELECT num_units, total_cpu_energy_usage_kwh FROM %s WHERE cluster_id = 'push-0' AND username = 'foo1';", base.UsageDBTableName),
+	).Scan(&numUnits, &energy)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), numUnits)
+	assert.Equal(t, models.MetricMap{"total": models.JSONFloat(3)}, energy)
+
+	var manager string
+
+	err = s.db.QueryRow(
+		fmt.Sprintf("SELECT resource_manager FROM %s WHERE cluster_id = 'push-0' AND name = 'foo1';", base.UsersDBTableName),
+	).Scan(&manager)
+	require.NoError(t, err)
+	assert.Equal(t, "push", manager)
+
+	// Only clusters with push manager accept units
+	err = s.Ingest(ctx, "slurm-0", []models.Unit{unit("1002")}, nil, nil)
+	require.ErrorIs(t, err, ErrPushCluster)
+}
+
+func TestUnitStatsDBFileCluster(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	acctDir := filepath.Join(tmpDir, "acct")
+	require.NoError(t, os.Mkdir(acctDir, 0o750))
+
+	var extra yaml.Node
+
+	err = yaml.Unmarshal([]byte(fmt.Sprintf(`
+path: %s
+delimiter: "|"
+columns:
+  uuid: JobID
+  username: User
+  project: Account
+  started_at: Start
+`, acctDir)), &extra)
+	require.NoError(t, err)
+
+	c.ResourceManager = func(logger *slog.Logger) (*resource.Manager, error) {
+		fetcher, err := file.New(models.Cluster{ID: "file-0", Manager: "file", Extra: extra}, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		return &resource.Manager{Logger: logger, Fetchers: []resource.Fetcher{fetcher}}, nil
+	}
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	// Units in accounting files are from past and must not be purged
+	s.storage.skipDeleteOldUnits = true
+
+	ctx := context.Background()
+
+	writeFile := func(name string, uuids ...string) {
+		content := "JobID|User|Account|Start\n"
+		for _, uuid := range uuids {
+			content += uuid + "|usr1|acc1|2024-04-18T10:00:00\n"
+		}
+
+		path := filepath.Join(acctDir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		require.NoError(t, os.Chtimes(path, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+	}
+
+	numUnits := func() int64 {
+		var numUnits, dailyNumUnits int64
+
+		err := s.db.QueryRow(
+			fmt.Sprintf("SELECT num_units FROM %s WHERE cluster_id = 'file-0' AND username = 'usr1';", base.UsageDBTableName),
+		).Scan(&numUnits)
+		require.NoError(t, err)
+
+		err = s.db.QueryRow(
+			fmt.Sprintf("SELECT SUM(num_units) FROM %s WHERE cluster_id = 'file-0' AND username = 'usr1';", base.DailyUsageDBTableName),
+		).Scan(&dailyNumUnits)
+		require.NoError(t, err)
+		assert.Equal(t, numUnits, dailyNumUnits)
+
+		return numUnits
+	}
+
+	writeFile("2024-04-18.csv", "1")
+	require.NoError(t, s.collect(ctx, time.Now().Add(-time.Minute), time.Now()))
+	assert.Equal(t, int64(1), numUnits())
+
+	// Units of past periods must be counted after first update as well and
+	// units that are already in DB must not be counted again
+	writeFile("2024-04-19.csv", "1", "2")
+	require.NoError(t, s.collect(ctx, time.Now().Add(-time.Minute), time.Now()))
+	assert.Equal(t, int64(2), numUnits())
+
+	// When DB update fails, files must be fetched again during next update
+	writeFile("2024-04-20.csv", "3")
+
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %[1]s RENAME TO %[1]s_tmp;", base.UsageDBTableName))
+	require.NoError(t, err)
+	require.Error(t, s.collect(ctx, time.Now().Add(-time.Minute), time.Now()))
+
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %[1]s_tmp RENAME TO %[1]s;", base.UsageDBTableName))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), numUnits())
+
+	require.NoError(t, s.collect(ctx, time.Now().Add(-time.Minute), time.Now()))
+	assert.Equal(t, int64(3), numUnits())
+
+	// Files must not be fetched again once they are committed
+	require.NoError(t, s.collect(ctx, time.Now().Add(-time.Minute), time.Now()))
+	assert.Equal(t, int64(3), numUnits())
+}
@@ -0,0 +1,158 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+)
+
+// Supported DB drivers.
+const (
+	SQLite   = base.SQLite
+	Postgres = base.Postgres
+)
+
+// PostgresDriverName is the name of the database/sql driver used for PostgreSQL.
+const PostgresDriverName = "pgx"
+
+// Custom errors.
+var (
+	ErrInvalidDriver  = errors.New("invalid DB driver. Supported drivers are sqlite3 and postgres")
+	ErrPostgresDSN    = errors.New("dsn must be provided for postgres DB driver")
+	ErrPostgresBackup = errors.New("backups are not supported for postgres DB driver. Use pg_dump instead")
+)
+
+// OpenPostgres opens a connection to PostgreSQL DB at dsn.
+func OpenPostgres(dsn string) (*sql.DB, error) {
+	db, err := sql.Open(PostgresDriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// namedToPositional replaces `:name` placeholders in query with positional
+// `$n` placeholders and returns the names of placeholders in their positional
+// order. Repeated names share the same position.
+func namedToPositional(query string) (string, []string) {
+	var b strings.Builder
+
+	var names []string
+
+	var quote byte
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			// Type casts like ::jsonb
+			b.WriteString("::")
+			i++
+
+			continue
+		case c == ':' && i+1 < len(query) && isIdentChar(query[i+1]):
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+
+			name := query[i+1 : j]
+
+			pos := slices.Index(names, name)
+			if pos == -1 {
+				names = append(names, name)
+				pos = len(names) - 1
+			}
+
+			b.WriteString("$" + strconv.Itoa(pos+1))
+
+			i = j - 1
+
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String(), names
+}
+
+// isIdentChar returns true if c can be part of a placeholder name.
+func isIdentChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// namedStmt is a prepared statement that accepts named arguments on all
+// dialects. PostgreSQL drivers do not support named arguments and hence,
+// they are converted into positional arguments.
+type namedStmt struct {
+	*sql.Stmt
+	names []string
+}
+
+// prepareNamed prepares query with named placeholders in the transaction.
+func prepareNamed(ctx context.Context, tx *sql.Tx, dialect string, query string) (*namedStmt, error) {
+	var names []string
+
+	if dialect == Postgres {
+		query, names = namedToPositional(query)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &namedStmt{Stmt: stmt, names: names}, nil
+}
+
+// ExecContext executes the statement with named arguments.
+func (s *namedStmt) ExecContext(ctx context.Context, args ...any) (sql.Result, error) {
+	if s.names == nil {
+		return s.Stmt.ExecContext(ctx, args...)
+	}
+
+	values := make(map[string]any, len(args))
+
+	for _, arg := range args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			values[named.Name] = named.Value
+		}
+	}
+
+	positional := make([]any, len(s.names))
+
+	for i, name := range s.names {
+		value, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("missing value for named argument %s", name)
+		}
+
+		positional[i] = value
+	}
+
+	return s.Stmt.ExecContext(ctx, positional...)
+}
@@ -0,0 +1,73 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	ceems_sqlite3 "github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedToPositional(t *testing.T) {
+	query, names := namedToPositional(
+		"INSERT INTO usage (num_units,total_time_seconds) VALUES (:num_units,:total_time_seconds::jsonb) " +
+			"ON CONFLICT(cluster_id) DO UPDATE SET num_units = usage.num_units + :num_units, " +
+			"avg_cpu_usage = json_extract(:total_time_seconds, '$.alloc_cputime:x')",
+	)
+	assert.Equal(
+		t,
+		"INSERT INTO usage (num_units,total_time_seconds) VALUES ($1,$2::jsonb) "+
+			"ON CONFLICT(cluster_id) DO UPDATE SET num_units = usage.num_units + $1, "+
+			"avg_cpu_usage = json_extract($2, '$.alloc_cputime:x')",
+		query,
+	)
+	assert.Equal(t, []string{"num_units", "total_time_seconds"}, names)
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT * FROM units WHERE project IN (?,?) AND name = '?' AND uuid = ?"
+
+	assert.Equal(t, query, base.Rebind(SQLite, query))
+	assert.Equal(t, "SELECT * FROM units WHERE project IN ($1,$2) AND name = '?' AND uuid = $3", base.Rebind(Postgres, query))
+}
+
+func TestDialect(t *testing.T) {
+	db, err := sql.Open(ceems_sqlite3.DriverName, ":memory:")
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	assert.Equal(t, SQLite, base.Dialect(db))
+}
+
+func TestDataConfigDriver(t *testing.T) {
+	tests := []struct {
+		config DataConfig
+		err    error
+	}{
+		{config: DataConfig{Driver: SQLite}},
+		{config: DataConfig{Driver: Postgres}, err: ErrPostgresDSN},
+		{config: DataConfig{Driver: Postgres, DSN: "postgres://localhost/ceems", BackupPath: "backup"}, err: ErrPostgresBackup},
+		{config: DataConfig{Driver: "mysql"}, err: ErrInvalidDriver},
+	}
+
+	for _, test := range tests {
+		test.config.UpdateInterval = 1
+		test.config.MaxUpdateInterval = 1
+
+		assert.ErrorIs(t, test.config.Validate(), test.err, test.config.Driver)
+	}
+}
+
+func TestOpenPostgresUnreachable(t *testing.T) {
+	assert.Contains(t, sql.Drivers(), PostgresDriverName)
+
+	// Nothing listens on port 1
+	_, err := OpenPostgres("postgres://ceems@127.0.0.1:1/ceems?sslmode=disable&connect_timeout=1")
+	assert.Error(t, err)
+}
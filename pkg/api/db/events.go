@@ -0,0 +1,215 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Maximum number of units looked up in DB in a single query.
+const unitEventsChunkSize = 500
+
+// unitState is the state of a unit as found in DB.
+type unitState struct {
+	UUID      string
+	State     string
+	EndedAtTS int64
+}
+
+// unitEvents returns the lifecycle events of units by comparing them with
+// their current state in DB. Sub units and ignored units do not generate
+// any events.
+func (s *stats) unitEvents(
+	ctx context.Context,
+	tx *sql.Tx,
+	clusterUnits []models.ClusterUnits,
+	currentTime time.Time,
+) []models.UnitEvent {
+	// Events are disabled or DB is being primed with existing units
+	if s.storage.eventsRetentionPeriod <= 0 || s.emptyDB {
+		return nil
+	}
+
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "Unit events", s.logger)
+
+	var events []models.UnitEvent
+
+	for _, cluster := range clusterUnits {
+		states, err := s.unitStates(ctx, tx, cluster)
+		if err != nil {
+			s.logger.Error("Failed to fetch states of units", "cluster_id", cluster.Cluster.ID, "err", err)
+
+			continue
+		}
+
+		for _, unit := range cluster.Units {
+			if unit.UUID == "" || unit.ParentUUID != "" || unit.Ignore != 0 {
+				continue
+			}
+
+			event := models.UnitEvent{
+				ClusterID:       cluster.Cluster.ID,
+				ResourceManager: unit.ResourceManager,
+				UUID:            unit.UUID,
+				Name:            unit.Name,
+				Project:         unit.Project,
+				User:            unit.User,
+				State:           unit.State,
+				Timestamp:       currentTime.UnixMilli(),
+			}
+
+			prev, ok := states[unit.UUID]
+
+			switch {
+			case !ok:
+				event.Type = models.UnitEventCreated
+				events = append(events, event)
+
+				// Unit might have ended within the update interval
+				if unit.EndedAtTS > 0 {
+					event.Type = models.UnitEventEnded
+					events = append(events, event)
+				}
+			case unit.EndedAtTS > 0 && prev.EndedAtTS == 0:
+				event.Type = models.UnitEventEnded
+				event.PreviousState = prev.State
+				events = append(events, event)
+			case unit.State != prev.State:
+				event.Type = models.UnitEventStateChanged
+				event.PreviousState = prev.State
+				events = append(events, event)
+			case hasMetrics(unit):
+				event.Type = models.UnitEventMetricsUpdated
+				events = append(events, event)
+			}
+		}
+	}
+
+	return events
+}
+
+// unitStates returns the current states of units of cluster found in DB
+// keyed by their UUIDs.
+func (s *stats) unitStates(ctx context.Context, tx *sql.Tx, cluster models.ClusterUnits) (map[string]unitState, error) {
+	uuids := make([]any, 0, len(cluster.Units))
+
+	for _, unit := range cluster.Units {
+		if unit.UUID != "" && unit.ParentUUID == "" {
+			uuids = append(uuids, unit.UUID)
+		}
+	}
+
+	states := make(map[string]unitState, len(uuids))
+
+	for start := 0; start < len(uuids); start += unitEventsChunkSize {
+		chunk := uuids[start:min(start+unitEventsChunkSize, len(uuids))]
+
+		// When a unit is found more than once, rows are ordered so that the
+		// most recent one wins
+		query := base.Rebind(s.storage.driver, fmt.Sprintf(
+			"SELECT uuid,COALESCE(state,''),COALESCE(ended_at_ts,0) FROM %s WHERE cluster_id = ? AND parent_uuid = '' AND uuid IN (%s) ORDER BY id",
+			base.UnitsDBTableName, strings.TrimSuffix(strings.Repeat("?,", len(chunk)), ","),
+		)) // #nosec
+
+		rows, err := tx.QueryContext(ctx, query, append([]any{cluster.Cluster.ID}, chunk...)...)
+		if err != nil {
+			return nil, err
+		}
+
+		for rows.Next() {
+			var state unitState
+			if err := rows.Scan(&state.UUID, &state.State, &state.EndedAtTS); err != nil {
+				rows.Close()
+
+				return nil, err
+			}
+
+			states[state.UUID] = state
+		}
+
+		err = rows.Err()
+		rows.Close()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return states, nil
+}
+
+// hasMetrics returns true if updaters have estimated any metrics of unit.
+func hasMetrics(unit models.Unit) bool {
+	for _, m := range []models.MetricMap{
+		unit.AveCPUUsage, unit.AveCPUMemUsage, unit.TotalCPUEnergyUsage,
+		unit.AveGPUUsage, unit.AveGPUMemUsage, unit.TotalGPUEnergyUsage,
+		unit.TotalIOWriteStats, unit.TotalIOReadStats, unit.TotalIngressStats, unit.TotalOutgressStats,
+	} {
+		if len(m) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recordUnitEvents inserts events into DB and purges the events that are older
+// than events retention period.
+func (s *stats) recordUnitEvents(ctx context.Context, tx *sql.Tx, events []models.UnitEvent, currentTime time.Time) error {
+	if s.storage.eventsRetentionPeriod <= 0 {
+		return nil
+	}
+
+	// Purge expired events. Timestamps of events are in epoch milliseconds
+	deleteEventsQuery := base.Rebind(
+		s.storage.driver, fmt.Sprintf("DELETE FROM %s WHERE timestamp <= ?", base.UnitEventsDBTableName),
+	) // #nosec
+
+	res, err := tx.ExecContext(ctx, deleteEventsQuery, currentTime.Add(-s.storage.eventsRetentionPeriod).UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to purge expired events: %w", err)
+	}
+
+	// Get changes
+	if eventsDeleted, err := res.RowsAffected(); err == nil {
+		s.logger.Debug("DB update", "unit_events_deleted", eventsDeleted)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, base.Rebind(
+		s.storage.driver,
+		"INSERT INTO "+base.UnitEventsDBTableName+
+			" (type,cluster_id,resource_manager,uuid,name,project,username,state,previous_state,timestamp)"+
+			" VALUES (?,?,?,?,?,?,?,?,?,?)",
+	))
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement for table %s: %w", base.UnitEventsDBTableName, err)
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		if _, err := stmt.ExecContext(
+			ctx, e.Type, e.ClusterID, e.ResourceManager, e.UUID, e.Name,
+			e.Project, e.User, e.State, e.PreviousState, e.Timestamp,
+		); err != nil {
+			return fmt.Errorf("failed to insert event of unit %s: %w", e.UUID, err)
+		}
+	}
+
+	s.logger.Debug("DB update", "unit_events_inserted", len(events))
+
+	return nil
+}
@@ -0,0 +1,192 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Ingest inserts compute units, users and projects pushed by clients of a
+// cluster that is configured with push manager. Units go through the same
+// updaters, billing and efficiency scoring as the fetched ones and are
+// inserted with the same statements so that usage tables are aggregated.
+//
+// Metrics of pushed units must be the ones accumulated since the last push
+// as they are added to the existing values in DB.
+func (s *stats) Ingest(
+	ctx context.Context,
+	clusterID string,
+	units []models.Unit,
+	users []models.User,
+	projects []models.Project,
+) error {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "Units ingestion", s.logger)
+
+	cluster, ok := s.manager.PushCluster(clusterID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrPushCluster, clusterID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endTime := time.Now().In(s.storage.timeLocation)
+
+	// Metrics of units are updated since the last push of cluster. When there
+	// is no push yet, use last update time of DB
+	startTime, ok := s.pushTimes[clusterID]
+	if !ok {
+		startTime = s.storage.lastUpdateTime
+	}
+
+	for i := range units {
+		units[i].ClusterID = cluster.ID
+
+		if units[i].ResourceManager == "" {
+			units[i].ResourceManager = cluster.Manager
+		}
+	}
+
+	// Users and projects are marked as updated at the time of ingestion
+	lastUpdatedAt := endTime.Format(base.DatetimeLayout)
+
+	for i := range users {
+		users[i].ClusterID = cluster.ID
+		users[i].ResourceManager = cluster.Manager
+		users[i].LastUpdatedAt = lastUpdatedAt
+	}
+
+	for i := range projects {
+		projects[i].ClusterID = cluster.ID
+		projects[i].ResourceManager = cluster.Manager
+		projects[i].LastUpdatedAt = lastUpdatedAt
+	}
+
+	clusterUnits := []models.ClusterUnits{{Cluster: cluster, Units: units}}
+
+	// Update units with metrics from TSDB, estimate costs and efficiency
+	clusterUnits = s.updater.Update(ctx, startTime, endTime, clusterUnits)
+	clusterUnits = s.billing.Bill(clusterUnits, endTime)
+	clusterUnits = s.efficiency.Score(clusterUnits)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin SQL transcation: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// Find units that are already in DB. Events must be found before units
+	// are updated in DB
+	events := s.unitEvents(ctx, tx, clusterUnits, endTime)
+
+	newUnits, knownUnits, err := s.splitNewUnits(ctx, tx, clusterUnits)
+	if err != nil {
+		return fmt.Errorf("failed to find existing units: %w", err)
+	}
+
+	// Units pushed for the first time are counted in num_units of usage
+	// irrespective of their start time. Hence, use zero start time for them
+	if err := s.execStatements(ctx, tx, time.Time{}, endTime, newUnits, nil, nil); err != nil {
+		return fmt.Errorf("failed to execute SQL statements: %w", err)
+	}
+
+	if err := s.execStatements(
+		ctx, tx, endTime, endTime, knownUnits,
+		[]models.ClusterUsers{{Cluster: cluster, Users: users}},
+		[]models.ClusterProjects{{Cluster: cluster, Projects: projects}},
+	); err != nil {
+		return fmt.Errorf("failed to execute SQL statements: %w", err)
+	}
+
+	if err := s.recordUnitEvents(ctx, tx, events, endTime); err != nil {
+		s.logger.Error("Failed to record unit events", "err", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit SQL transcation: %w", err)
+	}
+
+	s.pushTimes[clusterID] = endTime
+
+	s.logger.Info("Units ingested", "cluster_id", clusterID, "units", len(units), "users", len(users), "projects", len(projects))
+
+	return nil
+}
+
+// splitAckUnits splits units into the ones of resource managers that need
+// acknowledgement and the rest.
+func (s *stats) splitAckUnits(clusterUnits []models.ClusterUnits) ([]models.ClusterUnits, []models.ClusterUnits) {
+	var units, ackUnits []models.ClusterUnits
+
+	for _, cluster := range clusterUnits {
+		if s.manager.AckCluster(cluster.Cluster.ID) {
+			ackUnits = append(ackUnits, cluster)
+		} else {
+			units = append(units, cluster)
+		}
+	}
+
+	return units, ackUnits
+}
+
+// execAckStatements inserts units of resource managers that return units of
+// past periods only once. As their start times are not in the current update
+// period, units that are not yet in DB are counted in num_units of usage
+// irrespective of their start times like pushed units.
+func (s *stats) execAckStatements(
+	ctx context.Context,
+	tx *sql.Tx,
+	endTime time.Time,
+	clusterUnits []models.ClusterUnits,
+) error {
+	newUnits, knownUnits, err := s.splitNewUnits(ctx, tx, clusterUnits)
+	if err != nil {
+		return fmt.Errorf("failed to find existing units: %w", err)
+	}
+
+	if err := s.execStatements(ctx, tx, time.Time{}, endTime, newUnits, nil, nil); err != nil {
+		return err
+	}
+
+	return s.execStatements(ctx, tx, endTime, endTime, knownUnits, nil, nil)
+}
+
+// splitNewUnits splits units into the ones that are not yet in DB and the
+// ones that are already in DB.
+func (s *stats) splitNewUnits(
+	ctx context.Context,
+	tx *sql.Tx,
+	clusterUnits []models.ClusterUnits,
+) ([]models.ClusterUnits, []models.ClusterUnits, error) {
+	newUnits := make([]models.ClusterUnits, len(clusterUnits))
+	knownUnits := make([]models.ClusterUnits, len(clusterUnits))
+
+	for i, cluster := range clusterUnits {
+		states, err := s.unitStates(ctx, tx, cluster)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		newUnits[i].Cluster = cluster.Cluster
+		knownUnits[i].Cluster = cluster.Cluster
+
+		for _, unit := range cluster.Units {
+			if _, ok := states[unit.UUID]; ok || unit.ParentUUID != "" {
+				knownUnits[i].Units = append(knownUnits[i].Units, unit)
+			} else {
+				newUnits[i].Units = append(newUnits[i].Units, unit)
+			}
+		}
+	}
+
+	return newUnits, knownUnits, nil
+}
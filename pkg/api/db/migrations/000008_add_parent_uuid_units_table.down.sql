@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_cluster_id_parent_uuid;
+ALTER TABLE units DROP COLUMN parent_uuid;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS budgets (
+ "id" integer not null primary key,
+ "cluster_id" text,
+ "project" text,
+ "period" text,
+ "period_start" text default "",
+ "limits" text default '{}',
+ "consumed" text default '{}',
+ "used_percent" text default '{}',
+ "notified_threshold" real default 0,
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cluster_id_project_budget ON budgets (cluster_id,project);
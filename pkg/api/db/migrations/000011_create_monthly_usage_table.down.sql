@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_cluster_id_project_usr_month;
+DROP TABLE IF EXISTS monthly_usage;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_audit_log_username;
+DROP INDEX IF EXISTS idx_audit_log_timestamp;
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+ "id" integer not null primary key,
+ "timestamp" integer,
+ "source" text,
+ "username" text,
+ "queried_users" text default '[]',
+ "method" text,
+ "endpoint" text,
+ "cluster_ids" text default '[]',
+ "uuids" text default '[]',
+ "decision" text,
+ "status_code" integer,
+ "latency_ms" real,
+ "remote_addr" text default ''
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log (timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_username ON audit_log (username, timestamp);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_unit_events_username;
+DROP INDEX IF EXISTS idx_unit_events_timestamp;
+DROP TABLE IF EXISTS unit_events;
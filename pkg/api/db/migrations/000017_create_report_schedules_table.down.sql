@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_period_report_schedules;
+DROP TABLE IF EXISTS report_schedules;
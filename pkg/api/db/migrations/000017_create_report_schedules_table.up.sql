@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS report_schedules (
+ "id" integer not null primary key,
+ "period" text,
+ "period_start" text default '',
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_period_report_schedules ON report_schedules (period);
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS uq_cluster_id_uuid_start;
+DROP INDEX IF EXISTS idx_cluster_id_usr_uuid;
+DROP TABLE IF EXISTS units;
+
+DROP AGGREGATE IF EXISTS total(double precision);
+DROP FUNCTION IF EXISTS json_each(jsonb, text);
+DROP FUNCTION IF EXISTS json_each(jsonb);
+DROP FUNCTION IF EXISTS json_extract(jsonb, text);
+DROP AGGREGATE IF EXISTS avg_metric_map_agg(jsonb, double precision);
+DROP FUNCTION IF EXISTS avg_metric_map_agg_final(jsonb);
+DROP FUNCTION IF EXISTS avg_metric_map_agg_step(jsonb, jsonb, double precision);
+DROP AGGREGATE IF EXISTS sum_metric_map_agg(jsonb);
+DROP FUNCTION IF EXISTS avg_metric_map(jsonb, jsonb, double precision, double precision);
+DROP FUNCTION IF EXISTS add_metric_map(jsonb, jsonb);
@@ -0,0 +1,121 @@
+-- Functions to update and aggregate metric maps. They are equivalents of the
+-- functions registered in ceems_sqlite3 driver.
+CREATE OR REPLACE FUNCTION add_metric_map(existing jsonb, current jsonb) RETURNS jsonb AS $$
+ SELECT COALESCE(
+  jsonb_object_agg(k, COALESCE((existing->>k)::double precision, 0) + COALESCE((current->>k)::double precision, 0)),
+  '{}'::jsonb
+ )
+ FROM (SELECT jsonb_object_keys(COALESCE(existing, '{}')) AS k UNION SELECT jsonb_object_keys(COALESCE(current, '{}'))) AS keys;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION avg_metric_map(
+ existing jsonb, current jsonb, existing_weight double precision, current_weight double precision
+) RETURNS jsonb AS $$
+ SELECT COALESCE(
+  jsonb_object_agg(k, CASE WHEN w > 0 THEN s / w ELSE s END),
+  '{}'::jsonb
+ )
+ FROM (
+  SELECT k,
+   COALESCE((existing->>k)::double precision * COALESCE(existing_weight, 0), 0) + COALESCE((current->>k)::double precision * COALESCE(current_weight, 0), 0) AS s,
+   CASE WHEN existing ? k THEN COALESCE(existing_weight, 0) ELSE 0 END + CASE WHEN current ? k THEN COALESCE(current_weight, 0) ELSE 0 END AS w
+  FROM (SELECT jsonb_object_keys(COALESCE(existing, '{}')) AS k UNION SELECT jsonb_object_keys(COALESCE(current, '{}'))) AS keys
+ ) AS sums;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE AGGREGATE sum_metric_map_agg(jsonb) (
+ SFUNC = add_metric_map,
+ STYPE = jsonb,
+ INITCOND = '{}'
+);
+
+-- State of avg_metric_map_agg keeps weighted sums and total weights of each metric
+CREATE OR REPLACE FUNCTION avg_metric_map_agg_step(state jsonb, current jsonb, weight double precision) RETURNS jsonb AS $$
+ SELECT CASE
+  WHEN current IS NULL OR current = '{}'::jsonb OR COALESCE(weight, 0) = 0 THEN state
+  ELSE jsonb_build_object(
+   'sums', add_metric_map(state->'sums', (SELECT jsonb_object_agg(key, value::double precision * weight) FROM jsonb_each_text(current))),
+   'weights', add_metric_map(state->'weights', (SELECT jsonb_object_agg(key, weight) FROM jsonb_each_text(current)))
+  )
+ END;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION avg_metric_map_agg_final(state jsonb) RETURNS jsonb AS $$
+ SELECT COALESCE(
+  jsonb_object_agg(
+   key,
+   CASE WHEN (state->'weights'->>key)::double precision > 0 THEN value::double precision / (state->'weights'->>key)::double precision ELSE value::double precision END
+  ),
+  '{}'::jsonb
+ )
+ FROM jsonb_each_text(state->'sums');
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE AGGREGATE avg_metric_map_agg(jsonb, double precision) (
+ SFUNC = avg_metric_map_agg_step,
+ STYPE = jsonb,
+ FINALFUNC = avg_metric_map_agg_final,
+ INITCOND = '{"sums": {}, "weights": {}}'
+);
+
+-- SQLite compatible JSON functions so that queries of API server can be used on
+-- both SQLite and PostgreSQL. json_extract and json_each with path are only
+-- used on numeric values.
+CREATE OR REPLACE FUNCTION json_extract(doc jsonb, path text) RETURNS double precision AS $$
+ SELECT (doc #>> string_to_array(substr(path, 3), '.'))::double precision;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION json_each(doc jsonb) RETURNS TABLE (key text, value text) AS $$
+ SELECT * FROM jsonb_each_text(CASE WHEN jsonb_typeof(doc) = 'object' THEN doc ELSE '{}'::jsonb END)
+ UNION ALL
+ SELECT (ordinality - 1)::text, elem FROM jsonb_array_elements_text(CASE WHEN jsonb_typeof(doc) = 'array' THEN doc ELSE '[]'::jsonb END) WITH ORDINALITY AS e(elem, ordinality);
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION json_each(doc jsonb, path text) RETURNS TABLE (key text, value double precision) AS $$
+ SELECT regexp_replace(path, '^.*\.', ''), json_extract(doc, path) WHERE json_extract(doc, path) IS NOT NULL;
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE AGGREGATE total(double precision) (
+ SFUNC = float8pl,
+ STYPE = double precision,
+ INITCOND = '0'
+);
+
+CREATE TABLE IF NOT EXISTS units (
+ "id" bigint generated by default as identity primary key,
+ "resource_manager" text default '', 
+ "cluster_id" text,
+ "uuid" text,
+ "name" text,
+ "project" text,
+ "groupname" text,
+ "username" text,
+ "created_at" text,
+ "started_at" text,
+ "ended_at" text,
+ "created_at_ts" bigint,
+ "started_at_ts" bigint,
+ "ended_at_ts" bigint,
+ "elapsed" text,
+ "state" text,
+ "allocation" jsonb default '{}',
+ "tags" jsonb default '{}',
+ "total_time_seconds" jsonb default '{}', 
+ "avg_cpu_usage" jsonb default '{}', 
+ "avg_cpu_mem_usage" jsonb default '{}',
+ "total_cpu_energy_usage_kwh" jsonb default '{}', 
+ "total_cpu_emissions_gms" jsonb default '{}', 
+ "avg_gpu_usage" jsonb default '{}', 
+ "avg_gpu_mem_usage" jsonb default '{}', 
+ "total_gpu_energy_usage_kwh" jsonb default '{}', 
+ "total_gpu_emissions_gms" jsonb default '{}',
+ "total_io_write_stats" jsonb default '{}', 
+ "total_io_read_stats" jsonb default '{}', 
+ "total_ingress_stats" jsonb default '{}',
+ "total_outgress_stats" jsonb default '{}',
+ "ignore" integer, 
+ "num_updates" integer default 0, 
+ "last_updated_at" text
+);
+CREATE INDEX idx_cluster_id_usr_uuid ON units (cluster_id,username,uuid);
+CREATE UNIQUE INDEX uq_cluster_id_uuid_start ON units (cluster_id,uuid,started_at);
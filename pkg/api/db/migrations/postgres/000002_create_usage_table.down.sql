@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_cluster_id_project_usr;
+DROP TABLE IF EXISTS usage;
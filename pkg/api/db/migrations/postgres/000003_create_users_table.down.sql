@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_cluster_id_user;
+DROP TABLE IF EXISTS users;
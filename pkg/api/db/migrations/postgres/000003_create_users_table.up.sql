@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS users (
+ "id" bigint generated by default as identity primary key,
+ "uid" text,
+ "cluster_id" text,
+ "resource_manager" text,
+ "name" text,
+ "projects" jsonb default '[]',
+ "tags" jsonb default '[]',
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cluster_id_user ON users (cluster_id,name);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_cluster_id_project;
+DROP TABLE IF EXISTS projects;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS projects (
+ "id" bigint generated by default as identity primary key,
+ "uid" text,
+ "cluster_id" text,
+ "resource_manager" text,
+ "name" text,
+ "users" jsonb default '[]',
+ "tags" jsonb default '[]',
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cluster_id_project ON projects (cluster_id,name);
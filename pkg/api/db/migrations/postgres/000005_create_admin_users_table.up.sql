@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS admin_users (
+ "id" bigint generated by default as identity primary key,
+ "source" text,
+ "users" jsonb default '[]',
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_source ON admin_users (source);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_cluster_id_project_user_ended;
@@ -0,0 +1 @@
+CREATE INDEX idx_cluster_id_project_user_ended ON units (cluster_id,project,username,ended_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_cluster_id_project_usr_lastupdated;
+DROP TABLE IF EXISTS daily_usage;
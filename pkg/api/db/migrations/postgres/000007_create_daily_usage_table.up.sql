@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS daily_usage (
+ "id" bigint generated by default as identity primary key,
+ "resource_manager" text default '',
+ "cluster_id" text, 
+ "num_units" integer,
+ "project" text,
+ "groupname" text,
+ "username" text,
+ "total_time_seconds" jsonb default '{}', 
+ "avg_cpu_usage" jsonb default '{}', 
+ "avg_cpu_mem_usage" jsonb default '{}',
+ "total_cpu_energy_usage_kwh" jsonb default '{}', 
+ "total_cpu_emissions_gms" jsonb default '{}',
+ "avg_gpu_usage" jsonb default '{}', 
+ "avg_gpu_mem_usage" jsonb default '{}',
+ "total_gpu_energy_usage_kwh" jsonb default '{}', 
+ "total_gpu_emissions_gms" jsonb default '{}',
+ "total_io_write_stats" jsonb default '{}', 
+ "total_io_read_stats" jsonb default '{}',
+ "total_ingress_stats" jsonb default '{}', 
+ "total_outgress_stats" jsonb default '{}',
+ "num_updates" integer default 0,  
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX uq_cluster_id_project_usr_lastupdated ON daily_usage (cluster_id,username,project,last_updated_at);
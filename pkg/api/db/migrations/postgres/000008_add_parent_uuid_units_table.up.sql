@@ -0,0 +1,2 @@
+ALTER TABLE units ADD COLUMN parent_uuid text default '';
+CREATE INDEX idx_cluster_id_parent_uuid ON units (cluster_id,parent_uuid);
@@ -0,0 +1,3 @@
+ALTER TABLE daily_usage DROP COLUMN total_cost;
+ALTER TABLE usage DROP COLUMN total_cost;
+ALTER TABLE units DROP COLUMN total_cost;
@@ -0,0 +1,3 @@
+ALTER TABLE units ADD COLUMN total_cost jsonb default '{}';
+ALTER TABLE usage ADD COLUMN total_cost jsonb default '{}';
+ALTER TABLE daily_usage ADD COLUMN total_cost jsonb default '{}';
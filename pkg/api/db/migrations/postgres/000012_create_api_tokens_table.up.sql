@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS api_tokens (
+ "id" bigint generated by default as identity primary key,
+ "token_id" text,
+ "username" text,
+ "name" text default '',
+ "prefix" text,
+ "token_hash" text,
+ "scopes" jsonb default '[]',
+ "created_at" text,
+ "expires_at" text,
+ "expires_at_ts" bigint,
+ "last_used_at" text default '',
+ "revoked_at" text default '',
+ "revoked_by" text default ''
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_api_token_id ON api_tokens (token_id);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_api_token_hash ON api_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_api_tokens_username ON api_tokens (username);
@@ -0,0 +1 @@
+ALTER TABLE projects ADD COLUMN coordinators jsonb default '[]';
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+ "id" bigint generated by default as identity primary key,
+ "timestamp" bigint,
+ "source" text,
+ "username" text,
+ "queried_users" jsonb default '[]',
+ "method" text,
+ "endpoint" text,
+ "cluster_ids" jsonb default '[]',
+ "uuids" jsonb default '[]',
+ "decision" text,
+ "status_code" integer,
+ "latency_ms" double precision,
+ "remote_addr" text default ''
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log (timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_username ON audit_log (username, timestamp);
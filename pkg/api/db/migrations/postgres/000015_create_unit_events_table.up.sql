@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS unit_events (
+ "id" bigint generated by default as identity primary key,
+ "type" text,
+ "cluster_id" text,
+ "resource_manager" text,
+ "uuid" text,
+ "name" text default '',
+ "project" text default '',
+ "username" text default '',
+ "state" text default '',
+ "previous_state" text default '',
+ "timestamp" bigint
+);
+CREATE INDEX IF NOT EXISTS idx_unit_events_timestamp ON unit_events (timestamp);
+CREATE INDEX IF NOT EXISTS idx_unit_events_username ON unit_events (username, id);
@@ -0,0 +1 @@
+ALTER TABLE units ADD COLUMN efficiency jsonb default '{}';
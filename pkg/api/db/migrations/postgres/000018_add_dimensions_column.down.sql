@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS uq_cluster_id_project_usr_lastupdated_dims;
+CREATE UNIQUE INDEX uq_cluster_id_project_usr_lastupdated ON daily_usage (cluster_id,username,project,last_updated_at);
+ALTER TABLE monthly_usage DROP COLUMN dimensions;
+ALTER TABLE daily_usage DROP COLUMN dimensions;
+ALTER TABLE usage DROP COLUMN dimensions;
@@ -0,0 +1,5 @@
+ALTER TABLE usage ADD COLUMN dimensions jsonb default '{}';
+ALTER TABLE daily_usage ADD COLUMN dimensions jsonb default '{}';
+ALTER TABLE monthly_usage ADD COLUMN dimensions jsonb default '{}';
+DROP INDEX IF EXISTS uq_cluster_id_project_usr_lastupdated;
+CREATE UNIQUE INDEX uq_cluster_id_project_usr_lastupdated_dims ON daily_usage (cluster_id,username,project,last_updated_at,dimensions);
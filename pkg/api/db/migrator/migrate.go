@@ -6,9 +6,11 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
@@ -33,14 +35,59 @@ func New(sqlFiles embed.FS, dirName string, logger *slog.Logger) (*Migrator, err
 	}, nil
 }
 
-// ApplyMigrations applies DB migrations.
+// ApplyMigrations applies DB migrations on SQLite DB.
 func (m *Migrator) ApplyMigrations(db *sql.DB) error {
 	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
 	if err != nil {
 		return fmt.Errorf("unable to create db instance: %w", err)
 	}
 
-	migrator, err := migrate.NewWithInstance("iofs", m.srcDriver, "sqlite3", driver)
+	return m.apply("sqlite3", driver)
+}
+
+// ApplyPostgresMigrations applies DB migrations on PostgreSQL DB.
+func (m *Migrator) ApplyPostgresMigrations(db *sql.DB) error {
+	driver, err := postgresDriver(db)
+	if err != nil {
+		return fmt.Errorf("unable to create db instance: %w", err)
+	}
+
+	return m.apply("postgres", driver)
+}
+
+// Version returns the current migration version of SQLite DB and whether the
+// last migration has failed. A version of -1 means no migrations are applied.
+func (m *Migrator) Version(db *sql.DB) (int, bool, error) {
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to create db instance: %w", err)
+	}
+
+	return driver.Version()
+}
+
+// LatestVersion returns the version of latest available migration.
+func (m *Migrator) LatestVersion() (uint, error) {
+	version, err := m.srcDriver.First()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := m.srcDriver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		version = next
+	}
+}
+
+// apply applies migrations using DB driver.
+func (m *Migrator) apply(name string, driver database.Driver) error {
+	migrator, err := migrate.NewWithInstance("iofs", m.srcDriver, name, driver)
 	if err != nil {
 		return fmt.Errorf("unable to create migration: %w", err)
 	}
@@ -30,3 +30,30 @@ func TestMigratorError(t *testing.T) {
 	err = migrator.ApplyMigrations(db)
 	assert.Error(t, err, "expected DB migrations error")
 }
+
+func TestMigratorVersion(t *testing.T) {
+	// Setup Migrator
+	migrator, err := New(testMigrationsFS, testMigrationsDir, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err, "failed to create migrator")
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err, "failed to open DB")
+
+	// No migrations applied yet
+	version, dirty, err := migrator.Version(db)
+	require.NoError(t, err)
+	assert.Equal(t, -1, version)
+	assert.False(t, dirty)
+
+	// Failed migration must leave DB in dirty state
+	require.Error(t, migrator.ApplyMigrations(db))
+
+	version, dirty, err = migrator.Version(db)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.True(t, dirty)
+
+	latest, err := migrator.LatestVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), latest)
+}
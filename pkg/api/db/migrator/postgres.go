@@ -0,0 +1,13 @@
+package migrator
+
+import (
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/pgx/v5"
+)
+
+// postgresDriver returns migration driver of PostgreSQL DB.
+func postgresDriver(db *sql.DB) (database.Driver, error) {
+	return pgx.WithInstance(db, &pgx.Config{})
+}
@@ -0,0 +1,239 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	ceems_sqlite3 "github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+)
+
+// Custom errors.
+var (
+	ErrInvalidBackup = errors.New("backup file is not a valid CEEMS DB")
+)
+
+// Info contains the summary of a CEEMS DB.
+type Info struct {
+	Path          string           `json:"path"`
+	SizeBytes     int64            `json:"size_bytes"`
+	SchemaVersion int              `json:"schema_version"`
+	LatestVersion uint             `json:"latest_schema_version"`
+	Dirty         bool             `json:"dirty"`
+	RowCounts     map[string]int64 `json:"row_counts"`
+}
+
+// openReadOnly opens SQLite DB at dbPath in read only mode.
+func openReadOnly(dbPath string) (*sql.DB, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(ceems_sqlite3.DriverName, makeDSN(dbPath, map[string]string{"mode": "ro", "_busy_timeout": "5000"}))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Inspect returns the summary of SQLite DB at dbPath without modifying it.
+func Inspect(ctx context.Context, dbPath string, logger *slog.Logger) (*Info, error) {
+	db, err := openReadOnly(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DB: %w", err)
+	}
+	defer db.Close()
+
+	info := &Info{
+		Path:      dbPath,
+		RowCounts: make(map[string]int64),
+	}
+
+	// Size of DB is estimated from its pages as WAL file might contain pages that are
+	// not checkpointed yet
+	if err := db.QueryRowContext(
+		ctx, "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()",
+	).Scan(&info.SizeBytes); err != nil {
+		return nil, fmt.Errorf("failed to get DB size: %w", err)
+	}
+
+	// Get schema version
+	migrator, err := db_migrator.New(MigrationsFS, migrationsDir, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	if info.SchemaVersion, info.Dirty, err = migrator.Version(db); err != nil {
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	if info.LatestVersion, err = migrator.LatestVersion(); err != nil {
+		return nil, fmt.Errorf("failed to get latest schema version: %w", err)
+	}
+
+	// Count rows of all tables
+	rows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, table)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, table := range tables {
+		var count int64
+		if err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %q", table)).Scan(&count); err != nil { // #nosec
+			return nil, fmt.Errorf("failed to count rows of table %s: %w", table, err)
+		}
+
+		info.RowCounts[table] = count
+	}
+
+	return info, nil
+}
+
+// Vacuum rebuilds SQLite DB at dbPath to free up unused pages.
+func Vacuum(ctx context.Context, dbPath string) error {
+	if _, err := os.Stat(dbPath); err != nil {
+		return err
+	}
+
+	db, _, err := openDBConnection(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open DB: %w", err)
+	}
+	defer db.Close()
+
+	return vacuumDB(ctx, db)
+}
+
+// Restore replaces SQLite DB at dbPath with the DB in backupPath. The backup
+// is copied next to dbPath first and then moved in place so that dbPath is
+// never left in a partially restored state. Backups made with a newer schema
+// version than the latest supported one are rejected. API server must not be
+// running during restore.
+func Restore(ctx context.Context, backupPath string, dbPath string, logger *slog.Logger) error {
+	// Ensure that backup is a valid CEEMS DB
+	srcDB, err := openReadOnly(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer srcDB.Close()
+
+	// Get raw connection of backup for SQLite backup API
+	srcConn, ok := ceems_sqlite3.GetLastConn()
+	if !ok {
+		return errors.New("failed to get connection of backup DB")
+	}
+
+	var integrity string
+	if err := srcDB.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&integrity); err != nil || integrity != "ok" {
+		return fmt.Errorf("%w: integrity check failed: %s", ErrInvalidBackup, integrity)
+	}
+
+	migrator, err := db_migrator.New(MigrationsFS, migrationsDir, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	version, dirty, err := migrator.Version(srcDB)
+	if err != nil {
+		return fmt.Errorf("%w: failed to get schema version: %w", ErrInvalidBackup, err)
+	}
+
+	if version < 0 || dirty {
+		return fmt.Errorf("%w: invalid schema version %d (dirty: %t)", ErrInvalidBackup, version, dirty)
+	}
+
+	// Backups made by newer versions cannot be migrated down by the current version
+	latestVersion, err := migrator.LatestVersion()
+	if err != nil {
+		return fmt.Errorf("failed to get latest schema version: %w", err)
+	}
+
+	if uint(version) > latestVersion {
+		return fmt.Errorf(
+			"%w: schema version %d is newer than latest supported version %d", ErrInvalidBackup, version, latestVersion,
+		)
+	}
+
+	tmpPath := filepath.Join(filepath.Dir(dbPath), "."+filepath.Base(dbPath)+".restore")
+	if err := copyDB(ctx, srcConn, tmpPath, logger); err != nil {
+		os.Remove(tmpPath)
+
+		return fmt.Errorf("failed to copy backup: %w", err)
+	}
+
+	// Move WAL files of current DB aside as they belong to the DB that is being
+	// replaced. They are moved back if the restored DB cannot be moved in place.
+	var movedFiles []string
+
+	rollback := func() {
+		for _, path := range movedFiles {
+			if err := os.Rename(path+".bak", path); err != nil {
+				logger.Error("Failed to move back WAL file of DB", "path", path, "err", err)
+			}
+		}
+
+		os.Remove(tmpPath)
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		path := dbPath + suffix
+		if err := os.Rename(path, path+".bak"); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			rollback()
+
+			return fmt.Errorf("failed to move %s file of DB: %w", suffix, err)
+		}
+
+		movedFiles = append(movedFiles, path)
+	}
+
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		rollback()
+
+		return fmt.Errorf("failed to move restored DB: %w", err)
+	}
+
+	for _, path := range movedFiles {
+		if err := os.Remove(path + ".bak"); err != nil {
+			logger.Warn("Failed to remove WAL file of replaced DB", "path", path+".bak", "err", err)
+		}
+	}
+
+	logger.Info("DB restored", "backup", backupPath, "db", dbPath)
+
+	return nil
+}
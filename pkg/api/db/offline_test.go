@@ -0,0 +1,89 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfflineInspectRestoreVacuum(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+
+	// Make new stats DB with mock data and back it up
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+	require.NoError(t, populateDBWithMockData(s))
+
+	backupPath := filepath.Join(tmpDir, "backup.db")
+	require.NoError(t, s.backup(ctx, backupPath))
+
+	// Delete units from DB after backup
+	_, err = s.db.Exec("DELETE FROM " + base.UnitsDBTableName)
+	require.NoError(t, err)
+	require.NoError(t, s.Stop())
+
+	dbPath := filepath.Join(c.Data.Path, base.CEEMSDBName)
+
+	info, err := Inspect(ctx, dbPath, logger)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), info.RowCounts[base.UnitsDBTableName])
+	assert.Positive(t, info.RowCounts[base.UsageDBTableName])
+	assert.Positive(t, info.SizeBytes)
+	assert.Equal(t, int(info.LatestVersion), info.SchemaVersion)
+	assert.False(t, info.Dirty)
+
+	// Restore DB from backup
+	require.NoError(t, Restore(ctx, backupPath, dbPath, logger))
+
+	info, err = Inspect(ctx, dbPath, logger)
+	require.NoError(t, err)
+	assert.Positive(t, info.RowCounts[base.UnitsDBTableName])
+
+	require.NoError(t, Vacuum(ctx, dbPath))
+
+	// Invalid backups must be rejected
+	invalidPath := filepath.Join(tmpDir, "invalid.db")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("not a DB"), 0o600))
+	require.Error(t, Restore(ctx, invalidPath, dbPath, logger))
+
+	// Backups with a newer schema version must be rejected
+	newerPath := filepath.Join(tmpDir, "newer.db")
+	backup, err := os.ReadFile(backupPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(newerPath, backup, 0o600))
+	newerDB, err := sql.Open("sqlite3", newerPath)
+	require.NoError(t, err)
+	_, err = newerDB.Exec("UPDATE schema_migrations SET version = version + 1")
+	require.NoError(t, err)
+	require.NoError(t, newerDB.Close())
+	require.ErrorIs(t, Restore(ctx, newerPath, dbPath, logger), ErrInvalidBackup)
+
+	// DB must not be modified by failed restore
+	info, err = Inspect(ctx, dbPath, logger)
+	require.NoError(t, err)
+	assert.Positive(t, info.RowCounts[base.UnitsDBTableName])
+
+	// WAL files of current DB must be kept when restored DB cannot be moved in place
+	busyPath := filepath.Join(tmpDir, "busy")
+	require.NoError(t, os.MkdirAll(filepath.Join(busyPath, "data"), 0o700))
+	require.NoError(t, os.WriteFile(busyPath+"-wal", []byte("wal"), 0o600))
+	require.Error(t, Restore(ctx, backupPath, busyPath, logger))
+	assert.FileExists(t, busyPath+"-wal")
+	assert.NoFileExists(t, filepath.Join(tmpDir, ".busy.restore"))
+}
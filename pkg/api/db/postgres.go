@@ -0,0 +1,7 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+// Register pgx driver for PostgreSQL DB.
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,131 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPostgres returns DSN of an empty PostgreSQL DB used in tests. Tests are
+// skipped when CEEMS_TEST_POSTGRES_DSN is not set.
+func setupPostgres(t *testing.T) string {
+	t.Helper()
+
+	dsn := os.Getenv("CEEMS_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CEEMS_TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := OpenPostgres(dsn)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	_, err = db.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public;")
+	require.NoError(t, err)
+
+	return dsn
+}
+
+func TestPostgresMetricMapFunctions(t *testing.T) {
+	dsn := setupPostgres(t)
+
+	c, err := prepareMockConfig(t.TempDir())
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.Driver = Postgres
+	c.Data.DSN = config_util.Secret(dsn)
+	c.Data.BackupPath = ""
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	tests := []struct {
+		query    string
+		expected models.MetricMap
+	}{
+		{
+			query:    `SELECT add_metric_map('{"a": 1, "b": 2}', '{"a": 2, "c": 3}')`,
+			expected: models.MetricMap{"a": 3, "b": 2, "c": 3},
+		},
+		{
+			query:    `SELECT avg_metric_map('{"a": 1, "b": 2}', '{"a": 4}', 2, 1)`,
+			expected: models.MetricMap{"a": 2, "b": 2},
+		},
+		{
+			query:    `SELECT sum_metric_map_agg(m) FROM (VALUES ('{"a": 1}'::jsonb), ('{}'::jsonb), ('{"a": 2, "b": 1}'::jsonb)) AS t(m)`,
+			expected: models.MetricMap{"a": 3, "b": 1},
+		},
+		{
+			query:    `SELECT avg_metric_map_agg(m, w) FROM (VALUES ('{"a": 1}'::jsonb, 1), ('{"a": 4}'::jsonb, 2), ('{"a": 10}'::jsonb, 0)) AS t(m, w)`,
+			expected: models.MetricMap{"a": 3},
+		},
+	}
+
+	for _, test := range tests {
+		var got models.MetricMap
+
+		require.NoError(t, s.db.QueryRow(test.query).Scan(&got), test.query)
+		assert.Equal(t, test.expected, got, test.query)
+	}
+}
+
+func TestPostgresUnitStatsDBEntries(t *testing.T) {
+	dsn := setupPostgres(t)
+
+	c, err := prepareMockConfig(t.TempDir())
+	require.NoError(t, err, "failed to create mock config")
+
+	// Use same mock data on SQLite and PostgreSQL DBs and compare usage
+	sqliteStats, err := New(c)
+	require.NoError(t, err, "failed to create new SQLite stats")
+
+	defer sqliteStats.Stop()
+
+	c.Data.Driver = Postgres
+	c.Data.DSN = config_util.Secret(dsn)
+	c.Data.BackupPath = ""
+
+	postgresStats, err := New(c)
+	require.NoError(t, err, "failed to create new postgres stats")
+
+	defer postgresStats.Stop()
+
+	var usages [2][]models.Usage
+
+	for i, s := range []*stats{sqliteStats, postgresStats} {
+		require.NoError(t, populateDBWithMockData(s))
+
+		rows, err := s.db.QueryContext(
+			context.Background(),
+			"SELECT username,project,num_units,total_time_seconds,avg_cpu_usage,avg_cpu_mem_usage FROM usage ORDER BY cluster_id,username,project",
+		)
+		require.NoError(t, err)
+
+		for rows.Next() {
+			var usage models.Usage
+
+			require.NoError(
+				t, rows.Scan(&usage.User, &usage.Project, &usage.NumUnits, &usage.TotalTime, &usage.AveCPUUsage, &usage.AveCPUMemUsage),
+			)
+
+			usages[i] = append(usages[i], usage)
+		}
+
+		require.NoError(t, rows.Err())
+		rows.Close()
+	}
+
+	assert.NotEmpty(t, usages[0])
+	assert.Equal(t, usages[0], usages[1])
+}
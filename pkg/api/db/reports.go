@@ -0,0 +1,390 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/efficiency"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/report"
+)
+
+// digestKey identifies a digest of a user or project.
+type digestKey struct {
+	kind      string
+	clusterID string
+	name      string
+}
+
+// sendReports sends the digests of the last complete report period when they
+// have not been sent yet.
+func (s *stats) sendReports(ctx context.Context, currentTime time.Time) error {
+	if s.reporter == nil {
+		return nil
+	}
+
+	var errs error
+
+	for _, period := range s.reporter.Config().Periods {
+		// Digests are always sent for the last complete period
+		end := report.PeriodStart(period, currentTime.In(s.storage.timeLocation))
+		start := report.PreviousPeriodStart(period, end)
+
+		var lastPeriodStart string
+		if err := s.db.QueryRowContext(
+			ctx,
+			base.Rebind(
+				s.storage.driver,
+				fmt.Sprintf("SELECT period_start FROM %s WHERE period = ?", base.ReportsDBTableName),
+			),
+			period,
+		).Scan(&lastPeriodStart); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			errs = errors.Join(errs, fmt.Errorf("failed to fetch report state: %w", err))
+
+			continue
+		}
+
+		if lastPeriodStart == start.Format(base.DatetimeLayout) {
+			continue
+		}
+
+		if err := s.sendPeriodReports(ctx, period, start, end, currentTime); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// sendPeriodReports builds the digests of period [start, end), records the
+// period as reported and sends the digests.
+func (s *stats) sendPeriodReports(ctx context.Context, period string, start, end, currentTime time.Time) error {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "Usage reports", s.logger)
+
+	digests, err := s.digests(ctx, period, start, end)
+	if err != nil {
+		return fmt.Errorf("failed to build %s digests: %w", period, err)
+	}
+
+	// Persist the state before sending digests to avoid sending duplicate
+	// digests when delivery fails partially
+	if _, err := s.db.ExecContext(
+		ctx,
+		base.Rebind(
+			s.storage.driver,
+			fmt.Sprintf(
+				"INSERT INTO %s (period,period_start,last_updated_at) VALUES (?,?,?) "+
+					"ON CONFLICT(period) DO UPDATE SET period_start = excluded.period_start, last_updated_at = excluded.last_updated_at",
+				base.ReportsDBTableName,
+			),
+		),
+		period, start.Format(base.DatetimeLayout), currentTime.Format(base.DatetimeLayout),
+	); err != nil {
+		return fmt.Errorf("failed to update report state: %w", err)
+	}
+
+	s.logger.Debug("Sending usage reports", "period", period, "from", start, "to", end, "num_digests", len(digests))
+
+	return s.reporter.Send(ctx, digests)
+}
+
+// digests returns the digests of all users and projects that have consumed
+// resources in period [start, end) compared with the previous period.
+func (s *stats) digests(ctx context.Context, period string, start, end time.Time) ([]report.Digest, error) {
+	config := s.reporter.Config()
+
+	current, err := s.periodSummaries(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.periodSummaries(ctx, report.PreviousPeriodStart(period, start), start)
+	if err != nil {
+		return nil, err
+	}
+
+	scores, topUnits, err := s.periodUnits(ctx, start, end, config.TopUnits)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := s.reporter.Recipients()
+	if err != nil {
+		return nil, err
+	}
+
+	userTags, coordinators, err := s.reportRecipients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([]report.Digest, 0, len(current))
+
+	for key, summary := range current {
+		summary.Efficiency = scores[key]
+
+		digest := report.Digest{
+			Kind:      key.kind,
+			Name:      key.name,
+			ClusterID: key.clusterID,
+			Period:    period,
+			Label:     report.PeriodLabel(period, start),
+			From:      start,
+			To:        end,
+			Current:   summary,
+			Previous:  previous[key],
+			TopUnits:  topUnits[key],
+		}
+
+		switch key.kind {
+		case report.UserDigest:
+			if addr := recipients.User(key.name, userTags[key.clusterID+"/"+key.name]); addr != "" {
+				digest.Recipients = []string{addr}
+			}
+		case report.ProjectDigest:
+			digest.Recipients = append(digest.Recipients, recipients.Project(key.name)...)
+
+			for _, coord := range coordinators[key.clusterID+"/"+key.name] {
+				name, ok := coord.(string)
+				if !ok {
+					continue
+				}
+
+				if addr := recipients.User(name, userTags[key.clusterID+"/"+name]); addr != "" {
+					digest.Recipients = append(digest.Recipients, addr)
+				}
+			}
+
+			slices.Sort(digest.Recipients)
+			digest.Recipients = slices.Compact(digest.Recipients)
+		}
+
+		digests = append(digests, digest)
+	}
+
+	// Sort digests to deliver them in a deterministic order
+	slices.SortFunc(digests, func(a, b report.Digest) int {
+		if c := cmp.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+
+		if c := cmp.Compare(a.ClusterID, b.ClusterID); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return digests, nil
+}
+
+// periodSummaries returns the usage of users and projects in period
+// [start, end) estimated from daily usage.
+func (s *stats) periodSummaries(ctx context.Context, start, end time.Time) (map[digestKey]report.Summary, error) {
+	config := s.reporter.Config()
+	energyPath := "$." + config.EnergyMetric
+	emissionsPath := "$." + config.EmissionsMetric
+
+	query := fmt.Sprintf(
+		"SELECT cluster_id,project,username,TOTAL(num_units),"+
+			"TOTAL(json_extract(total_time_seconds,'$.alloc_cputime'))/3600,"+
+			"TOTAL(json_extract(total_time_seconds,'$.alloc_gputime'))/3600,"+
+			"TOTAL(json_extract(total_cpu_energy_usage_kwh,?))+TOTAL(json_extract(total_gpu_energy_usage_kwh,?)),"+
+			"TOTAL(json_extract(total_cpu_emissions_gms,?))+TOTAL(json_extract(total_gpu_emissions_gms,?)) "+
+			"FROM %s WHERE last_updated_at >= ? AND last_updated_at < ? GROUP BY cluster_id,project,username",
+		base.DailyUsageDBTableName,
+	) // #nosec
+
+	rows, err := s.db.QueryContext(
+		ctx, base.Rebind(s.storage.driver, query),
+		energyPath, energyPath, emissionsPath, emissionsPath,
+		start.Format(base.DatetimeLayout), end.Format(base.DatetimeLayout),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make(map[digestKey]report.Summary)
+
+	for rows.Next() {
+		var clusterID, project, user string
+
+		var numUnits float64
+
+		var usage report.Summary
+
+		if err := rows.Scan(
+			&clusterID, &project, &user, &numUnits,
+			&usage.CPUHours, &usage.GPUHours, &usage.EnergyKWh, &usage.EmissionsGms,
+		); err != nil {
+			return nil, err
+		}
+
+		usage.NumUnits = int64(numUnits)
+
+		for _, key := range []digestKey{
+			{report.UserDigest, clusterID, user},
+			{report.ProjectDigest, clusterID, project},
+		} {
+			summary := summaries[key]
+			summary.NumUnits += usage.NumUnits
+			summary.CPUHours += usage.CPUHours
+			summary.GPUHours += usage.GPUHours
+			summary.EnergyKWh += usage.EnergyKWh
+			summary.EmissionsGms += usage.EmissionsGms
+			summaries[key] = summary
+		}
+	}
+
+	return summaries, rows.Err()
+}
+
+// periodUnits returns the aggregate efficiency scores and top units by energy
+// of users and projects for units that were active in period [start, end).
+// Usage of units running across periods is accounted entirely in top units.
+func (s *stats) periodUnits(
+	ctx context.Context,
+	start, end time.Time,
+	numTopUnits int,
+) (map[digestKey]models.MetricMap, map[digestKey][]report.UnitSummary, error) {
+	energyMetric := s.reporter.Config().EnergyMetric
+
+	query := fmt.Sprintf(
+		"SELECT cluster_id,uuid,name,project,username,total_time_seconds,"+
+			"total_cpu_energy_usage_kwh,total_gpu_energy_usage_kwh,efficiency "+
+			"FROM %s WHERE started_at_ts < ? AND (ended_at_ts = 0 OR ended_at_ts >= ?) "+
+			"AND ignore = 0 AND parent_uuid = ''",
+		base.UnitsDBTableName,
+	) // #nosec
+
+	rows, err := s.db.QueryContext(ctx, base.Rebind(s.storage.driver, query), end.UnixMilli(), start.UnixMilli())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	aggregators := make(map[digestKey]*efficiency.Aggregator)
+	topUnits := make(map[digestKey][]report.UnitSummary)
+
+	for rows.Next() {
+		var unit report.UnitSummary
+
+		var totalTime, cpuEnergy, gpuEnergy, scores models.MetricMap
+
+		if err := rows.Scan(
+			&unit.ClusterID, &unit.UUID, &unit.Name, &unit.Project, &unit.User,
+			&totalTime, &cpuEnergy, &gpuEnergy, &scores,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		unit.CPUHours = float64(totalTime["alloc_cputime"]) / 3600
+		unit.GPUHours = float64(totalTime["alloc_gputime"]) / 3600
+		unit.EnergyKWh = float64(cpuEnergy[energyMetric] + gpuEnergy[energyMetric])
+		unit.Efficiency = scores
+
+		for _, key := range []digestKey{
+			{report.UserDigest, unit.ClusterID, unit.User},
+			{report.ProjectDigest, unit.ClusterID, unit.Project},
+		} {
+			if len(scores) > 0 {
+				if _, ok := aggregators[key]; !ok {
+					aggregators[key] = efficiency.NewAggregator()
+				}
+
+				aggregators[key].Add(scores, totalTime)
+			}
+
+			if numTopUnits > 0 {
+				topUnits[key] = insertTopUnit(topUnits[key], unit, numTopUnits)
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	scores := make(map[digestKey]models.MetricMap, len(aggregators))
+	for key, aggregator := range aggregators {
+		scores[key] = aggregator.Scores()
+	}
+
+	return scores, topUnits, nil
+}
+
+// reportRecipients returns the tags of users and coordinators of projects
+// keyed by <cluster_id>/<name>.
+func (s *stats) reportRecipients(ctx context.Context) (map[string]models.List, map[string]models.List, error) {
+	userTags, err := s.namedLists(ctx, "tags", base.UsersDBTableName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	coordinators, err := s.namedLists(ctx, "coordinators", base.ProjectsDBTableName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return userTags, coordinators, nil
+}
+
+// namedLists returns the list column of table keyed by <cluster_id>/<name>.
+func (s *stats) namedLists(ctx context.Context, column, table string) (map[string]models.List, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT cluster_id,name,%s FROM %s", column, table)) // #nosec
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make(map[string]models.List)
+
+	for rows.Next() {
+		var clusterID, name string
+
+		var list models.List
+
+		if err := rows.Scan(&clusterID, &name, &list); err != nil {
+			return nil, err
+		}
+
+		lists[clusterID+"/"+name] = list
+	}
+
+	return lists, rows.Err()
+}
+
+// insertTopUnit inserts unit into units sorted by energy and CPU hours in
+// descending order keeping at most n units.
+func insertTopUnit(units []report.UnitSummary, unit report.UnitSummary, n int) []report.UnitSummary {
+	idx, _ := slices.BinarySearchFunc(units, unit, func(a, b report.UnitSummary) int {
+		if a.EnergyKWh != b.EnergyKWh {
+			return cmp.Compare(b.EnergyKWh, a.EnergyKWh)
+		}
+
+		return cmp.Compare(b.CPUHours, a.CPUHours)
+	})
+
+	if idx >= n {
+		return units
+	}
+
+	units = slices.Insert(units, idx, unit)
+	if len(units) > n {
+		units = units[:n]
+	}
+
+	return units
+}
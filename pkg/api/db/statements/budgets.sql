@@ -0,0 +1,8 @@
+INSERT INTO budgets (cluster_id,project,period,period_start,limits,consumed,used_percent,notified_threshold,last_updated_at) VALUES (:cluster_id,:project,:period,:period_start,:limits,:consumed,:used_percent,:notified_threshold,:last_updated_at) ON CONFLICT(cluster_id,project) DO UPDATE SET
+  period = :period,
+  period_start = :period_start,
+  limits = :limits,
+  consumed = :consumed,
+  used_percent = :used_percent,
+  notified_threshold = :notified_threshold,
+  last_updated_at = :last_updated_at
@@ -0,0 +1,17 @@
+INSERT INTO monthly_usage (cluster_id,resource_manager,num_units,project,groupname,username,last_updated_at,total_time_seconds,avg_cpu_usage,avg_cpu_mem_usage,total_cpu_energy_usage_kwh,total_cpu_emissions_gms,avg_gpu_usage,avg_gpu_mem_usage,total_gpu_energy_usage_kwh,total_gpu_emissions_gms,total_io_write_stats,total_io_read_stats,total_ingress_stats,total_outgress_stats,total_cost,num_updates) SELECT cluster_id,MAX(resource_manager),COUNT(id),project,MAX(groupname),username,substr(started_at, 1, 7) || '-01T00:00:00' AS month,sum_metric_map_agg(COALESCE(total_time_seconds,'{}')),avg_metric_map_agg(COALESCE(avg_cpu_usage,'{}'),COALESCE(CAST(json_extract(total_time_seconds,'$.alloc_cputime') AS REAL),0.0)),avg_metric_map_agg(COALESCE(avg_cpu_mem_usage,'{}'),COALESCE(CAST(json_extract(total_time_seconds,'$.alloc_cpumemtime') AS REAL),0.0)),sum_metric_map_agg(COALESCE(total_cpu_energy_usage_kwh,'{}')),sum_metric_map_agg(COALESCE(total_cpu_emissions_gms,'{}')),avg_metric_map_agg(COALESCE(avg_gpu_usage,'{}'),COALESCE(CAST(json_extract(total_time_seconds,'$.alloc_gputime') AS REAL),0.0)),avg_metric_map_agg(COALESCE(avg_gpu_mem_usage,'{}'),COALESCE(CAST(json_extract(total_time_seconds,'$.alloc_gpumemtime') AS REAL),0.0)),sum_metric_map_agg(COALESCE(total_gpu_energy_usage_kwh,'{}')),sum_metric_map_agg(COALESCE(total_gpu_emissions_gms,'{}')),sum_metric_map_agg(COALESCE(total_io_write_stats,'{}')),sum_metric_map_agg(COALESCE(total_io_read_stats,'{}')),sum_metric_map_agg(COALESCE(total_ingress_stats,'{}')),sum_metric_map_agg(COALESCE(total_outgress_stats,'{}')),sum_metric_map_agg(COALESCE(total_cost,'{}')),SUM(num_updates) FROM units WHERE started_at <= :cutoff AND parent_uuid = '' GROUP BY cluster_id,username,project,month ON CONFLICT(cluster_id,username,project,last_updated_at) DO UPDATE SET
+  num_units = num_units + excluded.num_units,
+  total_time_seconds = add_metric_map(total_time_seconds, excluded.total_time_seconds),
+  avg_cpu_usage = avg_metric_map(avg_cpu_usage, excluded.avg_cpu_usage, COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_cputime') AS REAL), 0.0), COALESCE(CAST(json_extract(excluded.total_time_seconds, '$.alloc_cputime') AS REAL), 0.0)),
+  avg_cpu_mem_usage = avg_metric_map(avg_cpu_mem_usage, excluded.avg_cpu_mem_usage, COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_cpumemtime') AS REAL), 0.0), COALESCE(CAST(json_extract(excluded.total_time_seconds, '$.alloc_cpumemtime') AS REAL), 0.0)),
+  total_cpu_energy_usage_kwh = add_metric_map(total_cpu_energy_usage_kwh, excluded.total_cpu_energy_usage_kwh),
+  total_cpu_emissions_gms = add_metric_map(total_cpu_emissions_gms, excluded.total_cpu_emissions_gms),
+  avg_gpu_usage = avg_metric_map(avg_gpu_usage, excluded.avg_gpu_usage, COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_gputime') AS REAL), 0.0), COALESCE(CAST(json_extract(excluded.total_time_seconds, '$.alloc_gputime') AS REAL), 0.0)),
+  avg_gpu_mem_usage = avg_metric_map(avg_gpu_mem_usage, excluded.avg_gpu_mem_usage, COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_gpumemtime') AS REAL), 0.0), COALESCE(CAST(json_extract(excluded.total_time_seconds, '$.alloc_gpumemtime') AS REAL), 0.0)),
+  total_gpu_energy_usage_kwh = add_metric_map(total_gpu_energy_usage_kwh, excluded.total_gpu_energy_usage_kwh),
+  total_gpu_emissions_gms = add_metric_map(total_gpu_emissions_gms, excluded.total_gpu_emissions_gms),
+  total_io_write_stats = add_metric_map(total_io_write_stats, excluded.total_io_write_stats),
+  total_io_read_stats = add_metric_map(total_io_read_stats, excluded.total_io_read_stats),
+  total_ingress_stats = add_metric_map(total_ingress_stats, excluded.total_ingress_stats),
+  total_outgress_stats = add_metric_map(total_outgress_stats, excluded.total_outgress_stats),
+  total_cost = add_metric_map(total_cost, excluded.total_cost),
+  num_updates = num_updates + excluded.num_updates
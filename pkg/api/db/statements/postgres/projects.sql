@@ -0,0 +1,9 @@
+INSERT INTO projects (uid,cluster_id,resource_manager,name,users,coordinators,tags,last_updated_at) VALUES (:uid,:cluster_id,:resource_manager,:name,:users,:coordinators,:tags,:last_updated_at) ON CONFLICT(cluster_id,name) DO UPDATE SET
+  uid = :uid,
+  cluster_id = :cluster_id,
+  resource_manager = :resource_manager,
+  name = :name,
+  users = :users,
+  coordinators = :coordinators,
+  tags = :tags,
+  last_updated_at = :last_updated_at  
@@ -0,0 +1,290 @@
+// Package efficiency estimates the efficiency scores of compute units from the
+// usage metrics estimated by updaters.
+package efficiency
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Custom errors.
+var (
+	ErrMissingMetric = errors.New("usage_metric and energy_metric must not be empty in efficiency config")
+)
+
+// Score keys in efficiency map. All the scores except wasted energy are
+// fractions between 0 and 1. Over request ratio of memory is the ratio of
+// requested memory to used memory and hence, it is greater than or equal to 1.
+const (
+	CPUEfficiency    = "cpu"
+	CPUMemEfficiency = "cpu_mem"
+	MemOverRequest   = "mem_over_request"
+	GPUEfficiency    = "gpu"
+	GPUMemEfficiency = "gpu_mem"
+	GPUIdle          = "gpu_idle"
+	WastedEnergy     = "wasted_energy_kwh"
+	Score            = "score"
+)
+
+// Keys of total_time_seconds map used as weights of scores.
+const (
+	walltime      = "walltime"
+	allocCPUTime  = "alloc_cputime"
+	allocMemTime  = "alloc_cpumemtime"
+	allocGPUTime  = "alloc_gputime"
+	allocGMemTime = "alloc_gpumemtime"
+)
+
+// Usage metrics are estimated as percentages by updaters.
+const percentToRatio = 100
+
+// Keys returns the keys of efficiency map that can be used to sort units.
+func Keys() []string {
+	return []string{
+		CPUEfficiency, CPUMemEfficiency, MemOverRequest, GPUEfficiency,
+		GPUMemEfficiency, GPUIdle, WastedEnergy, Score,
+	}
+}
+
+// Config is the container for efficiency related config.
+type Config struct {
+	UsageMetric  string `yaml:"usage_metric"`
+	EnergyMetric string `yaml:"energy_metric"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = Config{
+		UsageMetric:  "global",
+		EnergyMetric: "total",
+	}
+
+	type plain Config
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	// Zero config is valid and defaults will be used
+	if *c == (Config{}) {
+		return nil
+	}
+
+	if c.UsageMetric == "" || c.EnergyMetric == "" {
+		return ErrMissingMetric
+	}
+
+	return nil
+}
+
+// Scorer estimates efficiency scores of units.
+type Scorer struct {
+	logger       *slog.Logger
+	usageMetric  string
+	energyMetric string
+}
+
+// New returns a new instance of Scorer.
+func New(c *Config, logger *slog.Logger) (*Scorer, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	usageMetric := c.UsageMetric
+	if usageMetric == "" {
+		usageMetric = "global"
+	}
+
+	energyMetric := c.EnergyMetric
+	if energyMetric == "" {
+		energyMetric = "total"
+	}
+
+	return &Scorer{
+		logger:       logger,
+		usageMetric:  usageMetric,
+		energyMetric: energyMetric,
+	}, nil
+}
+
+// Score sets efficiency scores on units. Units without any usage metrics are
+// left untouched.
+func (s *Scorer) Score(clusterUnits []models.ClusterUnits) []models.ClusterUnits {
+	for i, cluster := range clusterUnits {
+		var numScored int
+
+		for j, unit := range cluster.Units {
+			if scores := s.score(unit); len(scores) > 0 {
+				clusterUnits[i].Units[j].Efficiency = scores
+				numScored++
+			}
+		}
+
+		s.logger.Debug(
+			"Scoring units", "cluster_id", cluster.Cluster.ID,
+			"num_units", len(cluster.Units), "num_scored_units", numScored,
+		)
+	}
+
+	return clusterUnits
+}
+
+// score returns the efficiency scores of unit.
+func (s *Scorer) score(unit models.Unit) models.MetricMap {
+	scores := make(models.MetricMap)
+
+	var wasted float64
+
+	var hasEnergy bool
+
+	cpu, hasCPU := ratio(unit.AveCPUUsage, s.usageMetric)
+	if hasCPU {
+		scores[CPUEfficiency] = models.JSONFloat(cpu)
+
+		if energy, ok := unit.TotalCPUEnergyUsage[s.energyMetric]; ok {
+			wasted += float64(energy) * (1 - cpu)
+			hasEnergy = true
+		}
+	}
+
+	if mem, ok := ratio(unit.AveCPUMemUsage, s.usageMetric); ok {
+		scores[CPUMemEfficiency] = models.JSONFloat(mem)
+
+		if mem > 0 {
+			scores[MemOverRequest] = models.JSONFloat(1 / mem)
+		}
+	}
+
+	gpu, hasGPU := ratio(unit.AveGPUUsage, s.usageMetric)
+	if hasGPU {
+		scores[GPUEfficiency] = models.JSONFloat(gpu)
+		scores[GPUIdle] = models.JSONFloat(1 - gpu)
+
+		if energy, ok := unit.TotalGPUEnergyUsage[s.energyMetric]; ok {
+			wasted += float64(energy) * (1 - gpu)
+			hasEnergy = true
+		}
+	}
+
+	if gmem, ok := ratio(unit.AveGPUMemUsage, s.usageMetric); ok {
+		scores[GPUMemEfficiency] = models.JSONFloat(gmem)
+	}
+
+	if hasEnergy {
+		scores[WastedEnergy] = models.JSONFloat(wasted)
+	}
+
+	// Overall score is the average of CPU and GPU efficiencies weighted by
+	// their allocated times
+	switch {
+	case hasCPU && hasGPU:
+		cpuTime := float64(unit.TotalTime[allocCPUTime])
+		gpuTime := float64(unit.TotalTime[allocGPUTime])
+
+		if cpuTime+gpuTime > 0 {
+			scores[Score] = models.JSONFloat((cpu*cpuTime + gpu*gpuTime) / (cpuTime + gpuTime))
+		} else {
+			scores[Score] = models.JSONFloat((cpu + gpu) / 2)
+		}
+	case hasCPU:
+		scores[Score] = models.JSONFloat(cpu)
+	case hasGPU:
+		scores[Score] = models.JSONFloat(gpu)
+	}
+
+	return scores
+}
+
+// ratio returns the usage percent of metric in m as a fraction between 0 and 1.
+func ratio(m models.MetricMap, metric string) (float64, bool) {
+	v, ok := m[metric]
+	if !ok || math.IsNaN(float64(v)) {
+		return 0, false
+	}
+
+	return math.Min(math.Max(float64(v)/percentToRatio, 0), 1), true
+}
+
+// Aggregator aggregates efficiency scores of several units. Scores are averaged
+// weighting each unit by its allocated times and wasted energy is summed.
+type Aggregator struct {
+	sums    models.MetricMap
+	weights models.MetricMap
+}
+
+// NewAggregator returns a new instance of Aggregator.
+func NewAggregator() *Aggregator {
+	return &Aggregator{
+		sums:    make(models.MetricMap),
+		weights: make(models.MetricMap),
+	}
+}
+
+// Add adds scores of unit that consumed totalTime to aggregate.
+func (a *Aggregator) Add(scores models.MetricMap, totalTime models.MetricMap) {
+	for key, value := range scores {
+		if key == WastedEnergy {
+			a.sums[key] += value
+
+			continue
+		}
+
+		w := weight(key, totalTime)
+		a.sums[key] += value * w
+		a.weights[key] += w
+	}
+}
+
+// Scores returns the aggregated scores.
+func (a *Aggregator) Scores() models.MetricMap {
+	scores := make(models.MetricMap, len(a.sums))
+
+	for key, sum := range a.sums {
+		if key == WastedEnergy {
+			scores[key] = sum
+		} else {
+			scores[key] = sum / a.weights[key]
+		}
+	}
+
+	return scores
+}
+
+// weight returns the weight of score key based on total times of unit.
+func weight(key string, totalTime models.MetricMap) models.JSONFloat {
+	var w models.JSONFloat
+
+	switch key {
+	case CPUEfficiency:
+		w = totalTime[allocCPUTime]
+	case CPUMemEfficiency, MemOverRequest:
+		w = totalTime[allocMemTime]
+	case GPUEfficiency, GPUIdle:
+		w = totalTime[allocGPUTime]
+	case GPUMemEfficiency:
+		w = totalTime[allocGMemTime]
+	case Score:
+		w = totalTime[allocCPUTime] + totalTime[allocGPUTime]
+	}
+
+	// Fallback to walltime when allocated times are unknown and to equal
+	// weights when even walltime is unknown
+	if w <= 0 {
+		w = totalTime[walltime]
+	}
+
+	if w <= 0 {
+		w = 1
+	}
+
+	return w
+}
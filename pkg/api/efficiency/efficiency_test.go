@@ -0,0 +1,82 @@
+package efficiency
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig(t *testing.T) {
+	var c Config
+
+	require.NoError(t, yaml.Unmarshal([]byte("energy_metric: rapl"), &c))
+	require.NoError(t, c.Validate())
+	assert.Equal(t, "global", c.UsageMetric)
+	assert.Equal(t, "rapl", c.EnergyMetric)
+
+	require.NoError(t, (&Config{}).Validate())
+	require.ErrorIs(t, (&Config{UsageMetric: "global"}).Validate(), ErrMissingMetric)
+}
+
+func TestScore(t *testing.T) {
+	s, err := New(&Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units: []models.Unit{
+				{
+					UUID:                "1",
+					TotalTime:           models.MetricMap{"alloc_cputime": 300, "alloc_gputime": 100},
+					AveCPUUsage:         models.MetricMap{"global": 80},
+					AveCPUMemUsage:      models.MetricMap{"global": 25},
+					TotalCPUEnergyUsage: models.MetricMap{"total": 10},
+					AveGPUUsage:         models.MetricMap{"global": 40},
+					AveGPUMemUsage:      models.MetricMap{"global": 120},
+					TotalGPUEnergyUsage: models.MetricMap{"total": 20},
+				},
+				{
+					UUID:        "2",
+					AveCPUUsage: models.MetricMap{"global": 50},
+				},
+				{
+					UUID: "3",
+				},
+			},
+		},
+	}
+
+	units = s.Score(units)
+
+	got := units[0].Units[0].Efficiency
+	assert.InEpsilon(t, 0.8, float64(got[CPUEfficiency]), 1e-9)
+	assert.InEpsilon(t, 0.25, float64(got[CPUMemEfficiency]), 1e-9)
+	assert.InEpsilon(t, 4, float64(got[MemOverRequest]), 1e-9)
+	assert.InEpsilon(t, 0.4, float64(got[GPUEfficiency]), 1e-9)
+	assert.InEpsilon(t, 0.6, float64(got[GPUIdle]), 1e-9)
+	assert.InEpsilon(t, 1, float64(got[GPUMemEfficiency]), 1e-9)
+	assert.InEpsilon(t, 14, float64(got[WastedEnergy]), 1e-9)
+	assert.InEpsilon(t, 0.7, float64(got[Score]), 1e-9)
+
+	assert.Equal(t, models.MetricMap{CPUEfficiency: 0.5, Score: 0.5}, units[0].Units[1].Efficiency)
+	assert.Nil(t, units[0].Units[2].Efficiency)
+}
+
+func TestAggregator(t *testing.T) {
+	a := NewAggregator()
+
+	a.Add(models.MetricMap{CPUEfficiency: 0.2, WastedEnergy: 2}, models.MetricMap{"alloc_cputime": 300})
+	a.Add(models.MetricMap{CPUEfficiency: 0.6, WastedEnergy: 1}, models.MetricMap{"alloc_cputime": 100})
+	a.Add(models.MetricMap{GPUEfficiency: 0.5}, models.MetricMap{})
+
+	got := a.Scores()
+	assert.InEpsilon(t, 0.3, float64(got[CPUEfficiency]), 1e-9)
+	assert.InEpsilon(t, 3, float64(got[WastedEnergy]), 1e-9)
+	assert.InEpsilon(t, 0.5, float64(got[GPUEfficiency]), 1e-9)
+}
@@ -2,6 +2,7 @@
 package helper
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
@@ -115,6 +116,21 @@ func TimeToTimestamp(layout string, date string) int64 {
 	return 0
 }
 
+// FormatElapsed formats duration as [D-]HH:MM:SS like elapsed time of SLURM.
+// Negative durations are formatted as zero.
+func FormatElapsed(d time.Duration) string {
+	seconds := max(int64(d.Seconds()), 0)
+	days := seconds / 86400
+	seconds %= 86400
+
+	elapsed := fmt.Sprintf("%02d:%02d:%02d", seconds/3600, (seconds%3600)/60, seconds%60)
+	if days > 0 {
+		return fmt.Sprintf("%d-%s", days, elapsed)
+	}
+
+	return elapsed
+}
+
 // ChunkBy splits the slice into chunks of given size.
 func ChunkBy[T any](items []T, chunkSize int) [][]T {
 	if chunkSize == 0 {
@@ -2,6 +2,7 @@ package helper
 
 import (
 	"testing"
+	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/stretchr/testify/assert"
@@ -196,3 +197,22 @@ func TestChunkBy(t *testing.T) {
 		assert.Equal(t, test.expected, got, test.name)
 	}
 }
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "00:00:00"},
+		{6562 * time.Second, "01:49:22"},
+		{24*time.Hour - time.Second, "23:59:59"},
+		{24 * time.Hour, "1-00:00:00"},
+		{49 * time.Hour, "2-01:00:00"},
+		{400*24*time.Hour + 90*time.Second, "400-00:01:30"},
+		{-time.Hour, "00:00:00"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, FormatElapsed(test.duration), test.duration.String())
+	}
+}
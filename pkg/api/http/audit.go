@@ -0,0 +1,278 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/audit"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// auditDB stores audit records in CEEMS DB.
+type auditDB struct {
+	logger *slog.Logger
+	db     *sql.DB
+}
+
+// NewAuditDBSink returns an audit.Sink that stores records in audit log table
+// of CEEMS DB. The DB connection must not be read only and it is not closed
+// by the sink.
+func NewAuditDBSink(db *sql.DB, logger *slog.Logger) audit.Sink {
+	return &auditDB{logger: logger, db: db}
+}
+
+// Write implements audit.Sink interface.
+func (a *auditDB) Write(ctx context.Context, records []models.AuditRecord) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, base.Rebind(
+		base.Dialect(a.db),
+		"INSERT INTO "+base.AuditLogDBTableName+
+			" (timestamp,source,username,queried_users,method,endpoint,cluster_ids,uuids,decision,status_code,latency_ms,remote_addr)"+
+			" VALUES (?,?,?,?,?,?,?,?,?,?,?,?)",
+	))
+	if err != nil {
+		tx.Rollback()
+
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if _, err := stmt.ExecContext(
+			ctx, r.Timestamp, r.Source, r.User, r.QueriedUsers, r.Method, r.Endpoint,
+			r.ClusterIDs, r.UUIDs, r.Decision, r.StatusCode, r.Latency, r.RemoteAddr,
+		); err != nil {
+			tx.Rollback()
+
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Query implements audit.Sink interface.
+func (a *auditDB) Query(ctx context.Context, filter audit.Filter) ([]models.AuditRecord, error) {
+	q := Query{}
+	q.query("SELECT * FROM " + base.AuditLogDBTableName + " WHERE timestamp BETWEEN ")
+	q.param([]string{strconv.FormatInt(filter.From, 10)})
+	q.query(" AND ")
+	q.param([]string{strconv.FormatInt(filter.To, 10)})
+
+	if len(filter.Users) > 0 {
+		q.query(" AND username IN ")
+		q.param(filter.Users)
+	}
+
+	if len(filter.Sources) > 0 {
+		q.query(" AND source IN ")
+		q.param(filter.Sources)
+	}
+
+	if len(filter.Decisions) > 0 {
+		q.query(" AND decision IN ")
+		q.param(filter.Decisions)
+	}
+
+	q.query(" ORDER BY timestamp DESC, id DESC")
+	q.page(filter.Limit, filter.Offset)
+
+	return Querier[models.AuditRecord](ctx, a.db, q, a.logger)
+}
+
+// Close implements audit.Sink interface.
+func (a *auditDB) Close() error {
+	return nil
+}
+
+// newAuditLogger returns audit logger based on config. DB connection must
+// be a read-write connection.
+func newAuditLogger(c audit.Config, db *sql.DB, logger *slog.Logger) (*audit.Logger, error) {
+	var sink audit.Sink
+
+	var err error
+
+	switch c.Backend {
+	case audit.BackendDB:
+		sink = NewAuditDBSink(db, logger)
+	case audit.BackendFile:
+		if sink, err = audit.NewFileSink(c.File, c.MaxSize, c.MaxBackups); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, audit.ErrInvalidBackend
+	}
+
+	return audit.NewLogger(base.CEEMSServerAppName, sink, logger), nil
+}
+
+// record adds the request served with status to audit log.
+func (amw *authenticationMiddleware) record(w *audit.ResponseWriter, r *http.Request, start time.Time) {
+	record := audit.NewRecord(r, start, w.Status())
+
+	// User will not be set when authentication fails. Use the user sent in the
+	// request in that case
+	for _, header := range []string{loggedUserHeader, grafanaUserHeader, ceemsUserHeader} {
+		if record.User = r.Header.Get(header); record.User != "" {
+			break
+		}
+	}
+
+	q := r.URL.Query()
+
+	// Only admin users can query data of other users
+	if r.Header.Get(adminUserHeader) != "" {
+		queriedUsers := q["user"]
+		if dashboardUser := r.Header.Get(dashboardUserHeader); dashboardUser != "" && dashboardUser != record.User {
+			queriedUsers = append(queriedUsers, dashboardUser)
+		}
+
+		record.QueriedUsers = audit.ToList(queriedUsers)
+	}
+
+	record.ClusterIDs = audit.ToList(q["cluster_id"])
+	record.UUIDs = audit.ToList(q["uuid"])
+
+	amw.audit.Log(record)
+}
+
+// auditAdmin         godoc
+//
+//	@Summary		Admin endpoint to fetch audit log
+//	@Description	This admin endpoint will show the audit records of access decisions
+//	@Description	made by CEEMS API server and load balancer. The current user is always
+//	@Description	identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	Records can be filtered by the user making the request using `user`
+//	@Description	query parameter, by the component that recorded them using `source`
+//	@Description	query parameter and by the access decision using `decision` query
+//	@Description	parameter. Allowed decisions are `allow`, `deny` and `error`.
+//	@Description
+//	@Description	If `to` query parameter is not provided, current time will be used. If `from`
+//	@Description	query parameter is not used, a default query window of 24 hours will be used.
+//	@Description	Records are sorted with the most recent first and they can be paginated
+//	@Description	using `limit` and `offset` query parameters.
+//	@Description
+//	@Description	Records are available only when audit log is enabled. When the audit log
+//	@Description	is stored in a file, only the records of CEEMS API server are returned.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		audit
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string		true	"Current user name"
+//	@Param		user			query		[]string	false	"Username"	collectionFormat(multi)
+//	@Param		source			query		[]string	false	"Source"	collectionFormat(multi)
+//	@Param		decision		query		[]string	false	"Decision"	collectionFormat(multi)
+//	@Param		from			query		string		false	"From timestamp"
+//	@Param		to				query		string		false	"To timestamp"
+//	@Param		limit			query		integer		false	"Maximum number of results to return"
+//	@Param		offset			query		integer		false	"Number of results to skip"
+//	@Success	200				{object}	Response[models.AuditRecord]
+//	@Failure	400				{object}	Response[any]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	403				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Failure	503				{object}	Response[any]
+//	@Router		/audit/admin [get]
+//
+// GET /audit/admin
+// Get audit records.
+func (s *CEEMSServer) auditAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "audit admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	if s.audit == nil {
+		errorResponse[any](w, &apiError{errorUnavailable, audit.ErrDisabled}, s.logger, nil)
+
+		return
+	}
+
+	q := r.URL.Query()
+
+	filter := audit.Filter{
+		Users:     q["user"],
+		Sources:   q["source"],
+		Decisions: q["decision"],
+		From:      time.Now().Add(-defaultQueryWindow).UnixMilli(),
+		To:        time.Now().UnixMilli(),
+	}
+
+	for _, param := range []struct {
+		name  string
+		value *int64
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if v := q.Get(param.name); v != "" {
+			ts, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				errorResponse[any](
+					w, &apiError{errorBadData, fmt.Errorf("query parameter '%s': %w", param.name, ErrMalformedTimeStamp)}, s.logger, nil,
+				)
+
+				return
+			}
+
+			*param.value = time.Unix(ts, 0).UnixMilli()
+		}
+	}
+
+	// Records are always sorted by time
+	pageQ, err := getPageQuery(q)
+	if err == nil && (pageQ.sort != "" || pageQ.cursor != nil) {
+		err = errAuditSort
+	}
+
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	filter.Limit, filter.Offset = pageQ.limit, pageQ.offset
+
+	// Make query
+	records, err := s.audit.Query(r.Context(), filter)
+	if records == nil && err != nil {
+		s.logger.Error("Failed to fetch audit records", "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	response := Response[models.AuditRecord]{
+		Status:     "success",
+		Data:       records,
+		Pagination: nextPage(pageQ, records),
+	}
+	if err != nil {
+		response.Warnings = append(response.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
@@ -0,0 +1,346 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/billing"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Billing modes.
+const (
+	userBilling    = "user"
+	projectBilling = "project"
+)
+
+var (
+	// Expressions that format last_updated_at into each billing period in
+	// each SQL dialect.
+	invoicePeriods = map[string]map[string]string{
+		"day": {
+			base.SQLite:   "strftime('%Y-%m-%d', last_updated_at)",
+			base.Postgres: "to_char(CAST(last_updated_at AS timestamp), 'YYYY-MM-DD')",
+		},
+		"month": {
+			base.SQLite:   "strftime('%Y-%m', last_updated_at)",
+			base.Postgres: "to_char(CAST(last_updated_at AS timestamp), 'YYYY-MM')",
+		},
+		"year": {
+			base.SQLite:   "strftime('%Y', last_updated_at)",
+			base.Postgres: "to_char(CAST(last_updated_at AS timestamp), 'YYYY')",
+		},
+	}
+
+	// Keys of total_time_seconds and total_cost maps that are included in invoices.
+	invoiceTimeKeys = []string{"walltime", "alloc_cputime", "alloc_gputime"}
+	invoiceCostKeys = []string{billing.CPUCost, billing.GPUCost, billing.EnergyCost, billing.TotalCost}
+
+	errInvalidPeriod = errors.New("invalid billing period")
+)
+
+// invoiceMetricMap returns a SQL expression that sums keys of a metric map column.
+func invoiceMetricMap(dialect string, col string, keys []string) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("'%[1]s',TOTAL(json_extract(%[2]s,'$.%[1]s'))", key, col)
+	}
+
+	return fmt.Sprintf("%s(%s) AS %s", jsonObjectFuncs[dialect], strings.Join(parts, ","), col)
+}
+
+// billingQuerier queries daily usage and writes invoices of users/projects
+// in each billing period.
+func (s *CEEMSServer) billingQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Get path parameter mode
+	var mode string
+
+	var exists bool
+	if mode, exists = mux.Vars(r)["mode"]; !exists {
+		s.setHeaders(w)
+		errorResponse[any](w, &apiError{errorBadData, errInvalidRequest}, s.logger, nil)
+
+		return
+	}
+
+	// Get billing period and output format
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "month"
+	}
+
+	periodExprs, ok := invoicePeriods[period]
+	if !ok {
+		s.setHeaders(w)
+		errorResponse[any](w, &apiError{errorBadData, fmt.Errorf("%w: %s", errInvalidPeriod, period)}, s.logger, nil)
+
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = jsonFormat
+	}
+
+	if format != jsonFormat && format != csvFormat {
+		s.setHeaders(w)
+		errorResponse[any](w, &apiError{errorBadData, fmt.Errorf("%w: %s", errInvalidFormat, format)}, s.logger, nil)
+
+		return
+	}
+
+	// Get query window time stamps
+	timeQuery, err := s.getQueryWindow(r, "last_updated_at", false, false)
+	if err != nil {
+		s.setHeaders(w)
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Invoices of projects are not split by users
+	userCol := "'' AS username"
+	groupby := []string{"cluster_id", "period", "project"}
+
+	if mode == userBilling {
+		userCol = "username"
+		groupby = append(groupby, "username")
+	}
+
+	// Make query
+	dialect := base.Dialect(s.db)
+
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT cluster_id,MIN(resource_manager) AS resource_manager,%s AS period,project,%s,SUM(num_units) AS num_units,%s,%s FROM %s",
+			periodExprs[dialect], userCol, invoiceMetricMap(dialect, "total_time_seconds", invoiceTimeKeys),
+			invoiceMetricMap(dialect, "total_cost", invoiceCostKeys), base.DailyUsageDBTableName,
+		),
+	)
+	q.query(" WHERE ")
+	q.subQuery(timeQuery)
+
+	// Users get invoices of their own usage in user mode and invoices of
+	// their projects in project mode
+	if len(users) > 0 {
+		if mode == userBilling {
+			q.query(" AND username IN ")
+			q.param(users)
+		} else {
+			q.query(" AND (cluster_id, project) IN ")
+			q.subQuery(projectsSubQuery(users))
+		}
+	}
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	q.query(" GROUP BY " + strings.Join(groupby, ","))
+	q.query(" ORDER BY cluster_id ASC, period ASC, project ASC, username ASC")
+
+	// Make query and check for returned number of rows
+	invoices, err := s.queriers.invoice(r.Context(), s.db, q, s.logger)
+	if invoices == nil && err != nil {
+		s.setHeaders(w)
+		s.logger.Error("Failed to fetch invoices", "users", strings.Join(users, ","), "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	for i := range invoices {
+		invoices[i].Currency = s.dbConfig.Billing.Currency
+	}
+
+	if format == csvFormat {
+		s.writeInvoicesCSV(invoices, w)
+
+		return
+	}
+
+	// Write response
+	s.setHeaders(w)
+	w.WriteHeader(http.StatusOK)
+
+	invoicesResponse := Response[models.Invoice]{
+		Status: "success",
+		Data:   invoices,
+	}
+	if err != nil {
+		invoicesResponse.Warnings = append(invoicesResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&invoicesResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// writeInvoicesCSV writes invoices in CSV format.
+func (s *CEEMSServer) writeInvoicesCSV(invoices []models.Invoice, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="invoices.csv"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+
+	header := []string{"cluster_id", "resource_manager", "period", "project", "username", "num_units"}
+	for _, key := range invoiceTimeKeys {
+		header = append(header, key+"_seconds")
+	}
+
+	for _, key := range invoiceCostKeys {
+		header = append(header, key+"_cost")
+	}
+
+	header = append(header, "currency")
+
+	records := [][]string{header}
+
+	for _, invoice := range invoices {
+		record := []string{
+			invoice.ClusterID, invoice.ResourceManager, invoice.Period, invoice.Project,
+			invoice.User, strconv.FormatInt(invoice.NumUnits, 10),
+		}
+		for _, key := range invoiceTimeKeys {
+			record = append(record, strconv.FormatFloat(float64(invoice.TotalTime[key]), 'f', -1, 64))
+		}
+
+		for _, key := range invoiceCostKeys {
+			record = append(record, strconv.FormatFloat(float64(invoice.TotalCost[key]), 'f', 2, 64))
+		}
+
+		records = append(records, append(record, invoice.Currency))
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		s.logger.Error("Failed to write CSV response", "err", err)
+	}
+}
+
+// billing         godoc
+//
+//	@Summary		Invoices
+//	@Description	This endpoint will return the invoices of the current user. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	Costs are estimated during each update of the DB using the price tables
+//	@Description	configured in `billing` section of the server config. Invoices are
+//	@Description	aggregated from the daily usage of each project.
+//	@Description
+//	@Description	A path parameter `mode` is required to return the kind of invoices.
+//	@Description	Currently, two modes are supported:
+//	@Description	- `user`: In this mode the invoices of the current user in each
+//	@Description	project are returned.
+//	@Description	- `project`: In this mode the invoices of all the projects that the
+//	@Description	current user is part of are returned.
+//	@Description
+//	@Description	The query parameter `period` can be used to split invoices per `day`,
+//	@Description	`month` or `year`. Default is `month`.
+//	@Description
+//	@Description	The query parameter `format` can be used to get the invoices in `json`
+//	@Description	or `csv` formats. Default is `json`.
+//	@Description
+//	@Description	If `to` query parameter is not provided, current time will be used. If `from`
+//	@Description	query parameter is not used, a default query window of 24 hours will be used.
+//	@Description	It means if `to` is provided, `from` will be calculated as `to` - 24hrs.
+//	@Security		BasicAuth
+//	@Tags			billing
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			mode			path		string		true	"Whether to get invoices of user or projects"	Enums(user, project)
+//	@Param			cluster_id		query		[]string	false	"cluster ID"									collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"										collectionFormat(multi)
+//	@Param			period			query		string		false	"Billing period"								Enums(day, month, year)
+//	@Param			format			query		string		false	"Output format"									Enums(json, csv)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Invoice]
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/billing/{mode} [get]
+//
+// GET /billing/{mode}
+// Get invoices of current user or their projects.
+func (s *CEEMSServer) billing(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "billing endpoint", s.logger)
+
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	// Query for invoices and write response
+	s.billingQuerier([]string{dashboardUser}, w, r)
+}
+
+// billingAdmin         godoc
+//
+//	@Summary		Admin Invoices
+//	@Description	This admin endpoint will return the invoices of _queried_ users or
+//	@Description	projects. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	A path parameter `mode` is required to return the kind of invoices.
+//	@Description	Currently, two modes are supported:
+//	@Description	- `user`: In this mode the invoices of the users in each project are
+//	@Description	returned.
+//	@Description	- `project`: In this mode the invoices of projects are returned.
+//	@Description
+//	@Description	If query parameter `user` is provided, only invoices of these users
+//	@Description	(in `user` mode) or projects of these users (in `project` mode) are
+//	@Description	returned. If not, invoices of all users or projects are returned.
+//	@Description
+//	@Description	The query parameter `period` can be used to split invoices per `day`,
+//	@Description	`month` or `year`. Default is `month`.
+//	@Description
+//	@Description	The query parameter `format` can be used to get the invoices in `json`
+//	@Description	or `csv` formats. Default is `json`.
+//	@Security		BasicAuth
+//	@Tags			billing
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			mode			path		string		true	"Whether to get invoices of users or projects"	Enums(user, project)
+//	@Param			cluster_id		query		[]string	false	"cluster ID"									collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"										collectionFormat(multi)
+//	@Param			user			query		[]string	false	"Username"										collectionFormat(multi)
+//	@Param			period			query		string		false	"Billing period"								Enums(day, month, year)
+//	@Param			format			query		string		false	"Output format"									Enums(json, csv)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Invoice]
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		403				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/billing/{mode}/admin [get]
+//
+// GET /billing/{mode}/admin
+// Get invoices of any user or project.
+func (s *CEEMSServer) billingAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "billing admin endpoint", s.logger)
+
+	// Query for invoices and write response
+	s.billingQuerier(r.URL.Query()["user"], w, r)
+}
@@ -0,0 +1,133 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// budgetsQuerier queries budgets of the projects of users and writes response.
+func (s *CEEMSServer) budgetsQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	// Make query
+	q := Query{}
+	q.query("SELECT * FROM " + base.BudgetsDBTableName)
+
+	// First select all projects that user is part of using subquery
+	q.query(" WHERE (cluster_id, project) IN ")
+	q.subQuery(projectsSubQuery(users))
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	// Sort by cluster_id and project
+	q.query(" ORDER BY cluster_id ASC, project ASC ")
+
+	// Make query
+	budgets, err := s.queriers.budget(r.Context(), s.db, q, s.logger)
+	if budgets == nil && err != nil {
+		s.logger.Error("Failed to fetch budgets", "users", strings.Join(users, ","), "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	budgetsResponse := Response[models.Budget]{
+		Status: "success",
+		Data:   budgets,
+	}
+	if err != nil {
+		budgetsResponse.Warnings = append(budgetsResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&budgetsResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// budgets         godoc
+//
+//	@Summary		Show budgets
+//	@Description	This endpoint will show the budgets of the projects of current user. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	Budgets are configured per project in `budgets` section of the server config
+//	@Description	and their consumption is updated after each update of the DB. The response
+//	@Description	includes the limits, consumption and percentage of the budget used for each
+//	@Description	resource in the current budget period.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		budgets
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string		true	"Current user name"
+//	@Param		project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Success	200				{object}	Response[models.Budget]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/budgets [get]
+//
+// GET /budgets
+// Get budgets of projects of current user.
+func (s *CEEMSServer) budgets(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "budgets endpoint", s.logger)
+
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	// Make query and write response
+	s.budgetsQuerier([]string{dashboardUser}, w, r)
+}
+
+// budgetsAdmin         godoc
+//
+//	@Summary		Admin endpoint to fetch budgets
+//	@Description	This admin endpoint will show the budgets of projects. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	If query parameter `user` is provided, only budgets of the projects of
+//	@Description	these users will be returned. If not, budgets of all projects will be
+//	@Description	returned.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		budgets
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string		true	"Current user name"
+//	@Param		user			query		[]string	false	"Username"		collectionFormat(multi)
+//	@Param		project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Success	200				{object}	Response[models.Budget]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	403				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/budgets/admin [get]
+//
+// GET /budgets/admin
+// Get budgets of any project.
+func (s *CEEMSServer) budgetsAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "budgets admin endpoint", s.logger)
+
+	// Make query and write response
+	s.budgetsQuerier(r.URL.Query()["user"], w, r)
+}
@@ -23,21 +23,21 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/clusters/admin": {
+        "/audit/admin": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This endpoint will list all the cluster IDs in the CEEMS DB. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThis will list all the cluster IDs in the DB. This is primarily\nused to verify the CEEMS load balancer's backend IDs that should match\nwith cluster IDs.\n",
+                "description": "This admin endpoint will show the audit records of access decisions\nmade by CEEMS API server and load balancer. The current user is always\nidentified by the header ` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nRecords can be filtered by the user making the request using ` + "`" + `user` + "`" + `\nquery parameter, by the component that recorded them using ` + "`" + `source` + "`" + `\nquery parameter and by the access decision using ` + "`" + `decision` + "`" + ` query\nparameter. Allowed decisions are ` + "`" + `allow` + "`" + `, ` + "`" + `deny` + "`" + ` and ` + "`" + `error` + "`" + `.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nRecords are sorted with the most recent first and they can be paginated\nusing ` + "`" + `limit` + "`" + ` and ` + "`" + `offset` + "`" + ` query parameters.\n\nRecords are available only when audit log is enabled. When the audit log\nis stored in a file, only the records of CEEMS API server are returned.\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "clusters"
+                    "audit"
                 ],
-                "summary": "List clusters",
+                "summary": "Admin endpoint to fetch audit log",
                 "parameters": [
                     {
                         "type": "string",
@@ -45,13 +45,73 @@ const docTemplate = `{
                         "name": "X-Grafana-User",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Username",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Source",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Decision",
+                        "name": "decision",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results to skip",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Cluster"
+                            "$ref": "#/definitions/http.Response-models_AuditRecord"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
                         }
                     },
                     "401": {
@@ -60,95 +120,160 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.Response-any"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/http.Response-any"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
                     }
                 }
             }
         },
-        "/demo/{resource}": {
+        "/billing/{mode}": {
             "get": {
-                "description": "This endpoint returns sample response for units and usage models. This\nendpoint do not require the setting of ` + "`" + `X-Grafana-User` + "`" + ` header as it\nonly returns mock data for each request. This can be used to introspect\nthe response models for different resources.\n\nThe endpoint requires a path parameter ` + "`" + `resource` + "`" + ` which takes either:\n- ` + "`" + `units` + "`" + ` which returns a mock units response\n- ` + "`" + `usage` + "`" + ` which returns a mock usage response.\n\nThe mock data is generated randomly for each request and there is\nno guarantee that the data has logical sense.",
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This endpoint will return the invoices of the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nCosts are estimated during each update of the DB using the price tables\nconfigured in ` + "`" + `billing` + "`" + ` section of the server config. Invoices are\naggregated from the daily usage of each project.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of invoices.\nCurrently, two modes are supported:\n- ` + "`" + `user` + "`" + `: In this mode the invoices of the current user in each\nproject are returned.\n- ` + "`" + `project` + "`" + `: In this mode the invoices of all the projects that the\ncurrent user is part of are returned.\n\nThe query parameter ` + "`" + `period` + "`" + ` can be used to split invoices per ` + "`" + `day` + "`" + `,\n` + "`" + `month` + "`" + ` or ` + "`" + `year` + "`" + `. Default is ` + "`" + `month` + "`" + `.\n\nThe query parameter ` + "`" + `format` + "`" + ` can be used to get the invoices in ` + "`" + `json` + "`" + `\nor ` + "`" + `csv` + "`" + ` formats. Default is ` + "`" + `json` + "`" + `.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "demo"
+                    "billing"
                 ],
-                "summary": "Demo Units/Usage endpoints",
+                "summary": "Invoices",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
                     {
                         "enum": [
-                            "units",
-                            "usage"
+                            "user",
+                            "project"
                         ],
                         "type": "string",
-                        "description": "Whether to return mock units or usage data",
-                        "name": "resource",
+                        "description": "Whether to get invoices of user or projects",
+                        "name": "mode",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "month",
+                            "year"
+                        ],
+                        "type": "string",
+                        "description": "Billing period",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Output format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Usage"
+                            "$ref": "#/definitions/http.Response-models_Invoice"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/http.Response-any"
                         }
-                    }
-                }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "This endpoint returns the health status of the server.\n\nA healthy server returns 200 response code and any other\nresponses should be treated as unhealthy server.",
-                "produces": [
-                    "text/plain"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health status",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/http.Response-any"
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/http.Response-any"
                         }
                     }
                 }
             }
         },
-        "/projects": {
+        "/billing/{mode}/admin": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This endpoint will show details of the queried project of current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe details include list of users in that project. If current user\nattempts to query a project that they are not part of, empty response\nwill be returned\n",
+                "description": "This admin endpoint will return the invoices of _queried_ users or\nprojects. The current user is always identified by the header\n` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of invoices.\nCurrently, two modes are supported:\n- ` + "`" + `user` + "`" + `: In this mode the invoices of the users in each project are\nreturned.\n- ` + "`" + `project` + "`" + `: In this mode the invoices of projects are returned.\n\nIf query parameter ` + "`" + `user` + "`" + ` is provided, only invoices of these users\n(in ` + "`" + `user` + "`" + ` mode) or projects of these users (in ` + "`" + `project` + "`" + ` mode) are\nreturned. If not, invoices of all users or projects are returned.\n\nThe query parameter ` + "`" + `period` + "`" + ` can be used to split invoices per ` + "`" + `day` + "`" + `,\n` + "`" + `month` + "`" + ` or ` + "`" + `year` + "`" + `. Default is ` + "`" + `month` + "`" + `.\n\nThe query parameter ` + "`" + `format` + "`" + ` can be used to get the invoices in ` + "`" + `json` + "`" + `\nor ` + "`" + `csv` + "`" + ` formats. Default is ` + "`" + `json` + "`" + `.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "projects"
+                    "billing"
                 ],
-                "summary": "Show project details",
+                "summary": "Admin Invoices",
                 "parameters": [
                     {
                         "type": "string",
@@ -157,6 +282,27 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
+                    {
+                        "enum": [
+                            "user",
+                            "project"
+                        ],
+                        "type": "string",
+                        "description": "Whether to get invoices of users or projects",
+                        "name": "mode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
                     {
                         "type": "array",
                         "items": {
@@ -173,8 +319,41 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "multi",
-                        "description": "Cluster ID",
-                        "name": "cluster_id",
+                        "description": "Username",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "month",
+                            "year"
+                        ],
+                        "type": "string",
+                        "description": "Billing period",
+                        "name": "period",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Output format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
@@ -182,7 +361,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Project"
+                            "$ref": "#/definitions/http.Response-models_Invoice"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
                         }
                     },
                     "401": {
@@ -191,6 +376,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.Response-any"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -200,21 +391,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/projects/admin": {
+        "/budgets": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This endpoint will show details of the queried project. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nThe details include list of users in that project. If current user\nattempts to query a project that they are not part of, empty response\nwill be returned\n",
+                "description": "This endpoint will show the budgets of the projects of current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nBudgets are configured per project in ` + "`" + `budgets` + "`" + ` section of the server config\nand their consumption is updated after each update of the DB. The response\nincludes the limits, consumption and percentage of the budget used for each\nresource in the current budget period.\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "budgets"
                 ],
-                "summary": "Admin endpoint to fetch project details",
+                "summary": "Show budgets",
                 "parameters": [
                     {
                         "type": "string",
@@ -248,7 +439,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Project"
+                            "$ref": "#/definitions/http.Response-models_Budget"
                         }
                     },
                     "401": {
@@ -266,21 +457,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/stats/{mode}/admin": {
+        "/budgets/admin": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This admin endpoint will return the quick stats of _queried_ cluster. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of usage statistics.\nCurrently, two modes of statistics are supported:\n- ` + "`" + `current` + "`" + `: In this mode the usage between two time periods is returned\nbased on ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` query parameters.\n- ` + "`" + `global` + "`" + `: In this mode the _total_ usage statistics are returned. For\ninstance, if the retention period of the DB is set to 2 years, usage\nstatistics of last 2 years will be returned.\n\nThe statistics include current number of active users, projects, jobs, _etc_.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs.\n",
+                "description": "This admin endpoint will show the budgets of projects. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nIf query parameter ` + "`" + `user` + "`" + ` is provided, only budgets of the projects of\nthese users will be returned. If not, budgets of all projects will be\nreturned.\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "stats"
+                    "budgets"
                 ],
-                "summary": "Admin Stats",
+                "summary": "Admin endpoint to fetch budgets",
                 "parameters": [
                     {
                         "type": "string",
@@ -290,15 +481,14 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "enum": [
-                            "current",
-                            "global"
-                        ],
-                        "type": "string",
-                        "description": "Whether to get quick stats within a period or global",
-                        "name": "mode",
-                        "in": "path",
-                        "required": true
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Username",
+                        "name": "user",
+                        "in": "query"
                     },
                     {
                         "type": "array",
@@ -306,20 +496,18 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "multi",
-                        "description": "cluster ID",
-                        "name": "cluster_id",
+                        "description": "Project",
+                        "name": "project",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "From timestamp",
-                        "name": "from",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "To timestamp",
-                        "name": "to",
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
                         "in": "query"
                     }
                 ],
@@ -327,7 +515,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Stat"
+                            "$ref": "#/definitions/http.Response-models_Budget"
                         }
                     },
                     "401": {
@@ -351,21 +539,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/units": {
+        "/clusters/admin": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This user endpoint will fetch compute units of the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nIf multiple query parameters are passed, for instance, ` + "`" + `?uuid=\u003cuuid\u003e\u0026project=\u003cproject\u003e` + "`" + `,\nthe intersection of query parameters are used to fetch compute units rather than\nthe union. That means if the compute unit's ` + "`" + `uuid` + "`" + ` does not belong to the queried\nproject, null response will be returned.\n\nIn order to return the running compute units as well, use the query parameter ` + "`" + `running` + "`" + `.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs. If query\nparameter ` + "`" + `timezone` + "`" + ` is provided, the unit's created, start and end time strings\nwill be presented in that time zone.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.",
+                "description": "This endpoint will list all the cluster IDs in the CEEMS DB. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThis will list all the cluster IDs in the DB. This is primarily\nused to verify the CEEMS load balancer's backend IDs that should match\nwith cluster IDs.\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "units"
+                    "clusters"
                 ],
-                "summary": "User endpoint for fetching compute units",
+                "summary": "List clusters",
                 "parameters": [
                     {
                         "type": "string",
@@ -373,77 +561,13 @@ const docTemplate = `{
                         "name": "X-Grafana-User",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Cluster ID",
-                        "name": "cluster_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Unit UUID",
-                        "name": "uuid",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Project",
-                        "name": "project",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Whether to fetch running units",
-                        "name": "running",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "From timestamp",
-                        "name": "from",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "To timestamp",
-                        "name": "to",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Time zone in IANA format",
-                        "name": "timezone",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Fields to return in response",
-                        "name": "field",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Unit"
+                            "$ref": "#/definitions/http.Response-models_Cluster"
                         }
                     },
                     "401": {
@@ -452,11 +576,44 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.Response-any"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/http.Response-any"
                         }
+                    }
+                }
+            }
+        },
+        "/demo/{resource}": {
+            "get": {
+                "description": "This endpoint returns sample response for units and usage models. This\nendpoint do not require the setting of ` + "`" + `X-Grafana-User` + "`" + ` header as it\nonly returns mock data for each request. This can be used to introspect\nthe response models for different resources.\n\nThe endpoint requires a path parameter ` + "`" + `resource` + "`" + ` which takes either:\n- ` + "`" + `units` + "`" + ` which returns a mock units response\n- ` + "`" + `usage` + "`" + ` which returns a mock usage response.\n\nThe mock data is generated randomly for each request and there is\nno guarantee that the data has logical sense.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "demo"
+                ],
+                "summary": "Demo Units/Usage endpoints",
+                "parameters": [
+                    {
+                        "enum": [
+                            "units",
+                            "usage"
+                        ],
+                        "type": "string",
+                        "description": "Whether to return mock units or usage data",
+                        "name": "resource",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Usage"
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -467,21 +624,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/units/admin": {
+        "/health": {
+            "get": {
+                "description": "This endpoint returns the health status of the server.\n\nA healthy server returns 200 response code and any other\nresponses should be treated as unhealthy server.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health status",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This admin endpoint will fetch compute units of _any_ user, compute unit and/or project. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nIf multiple query parameters are passed, for instance, ` + "`" + `?uuid=\u003cuuid\u003e\u0026user=\u003cuser\u003e` + "`" + `,\nthe intersection of query parameters are used to fetch compute units rather than\nthe union. That means if the compute unit's ` + "`" + `uuid` + "`" + ` does not belong to the queried\nuser, null response will be returned.\n\nIn order to return the running compute units as well, use the query parameter ` + "`" + `running` + "`" + `.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs. If query\nparameter ` + "`" + `timezone` + "`" + ` is provided, the unit's created, start and end time strings\nwill be presented in that time zone.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.",
+                "description": "This endpoint will show details of the queried project of current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe details include list of users in that project. If current user\nattempts to query a project that they are not part of, empty response\nwill be returned\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "units"
+                    "projects"
                 ],
-                "summary": "Admin endpoint for fetching compute units.",
+                "summary": "Show project details",
                 "parameters": [
                     {
                         "type": "string",
@@ -490,26 +673,6 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Cluster ID",
-                        "name": "cluster_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Unit UUID",
-                        "name": "uuid",
-                        "in": "query"
-                    },
                     {
                         "type": "array",
                         "items": {
@@ -526,42 +689,42 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "multi",
-                        "description": "User name",
-                        "name": "user",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
                         "in": "query"
                     },
                     {
-                        "type": "boolean",
-                        "description": "Whether to fetch running units",
-                        "name": "running",
+                        "type": "integer",
+                        "description": "Maximum number of results to return",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "From timestamp",
-                        "name": "from",
+                        "type": "integer",
+                        "description": "Number of results to skip",
+                        "name": "offset",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "To timestamp",
-                        "name": "to",
+                        "description": "Cursor returned in pagination of previous page",
+                        "name": "cursor",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Time zone in IANA format",
-                        "name": "timezone",
+                        "description": "Field to sort results",
+                        "name": "sort",
                         "in": "query"
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Fields to return in response",
-                        "name": "field",
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
                         "in": "query"
                     }
                 ],
@@ -569,7 +732,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Unit"
+                            "$ref": "#/definitions/http.Response-models_Project"
                         }
                     },
                     "401": {
@@ -578,12 +741,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.Response-any"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/http.Response-any"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -593,21 +750,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/units/verify": {
+        "/projects/admin": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This endpoint will check if the current user is the owner of the\nqueried UUIDs. The current user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nA response of 200 means that the current user is the owner of the queried UUIDs.\nAny other response code should be treated as the current user not being the owner\nof the queried units.\n\nThe ownership check passes if any of the following conditions are ` + "`" + `true` + "`" + `:\n- If the current user is the _direct_ owner of the compute unit.\n- If the current user belongs to the same account/project/namespace as\nthe compute unit. This means the users belonging to the same project can\naccess each others compute units.\n\nThe above checks must pass for **all** the queried units.\nIf the check does not pass for at least one queried unit, a response 403 will be\nreturned.\n\nAny 500 response codes should be treated as failed check as well.",
+                "description": "This endpoint will show details of the queried project. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nThe details include list of users in that project. If current user\nattempts to query a project that they are not part of, empty response\nwill be returned\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "units"
+                    "projects"
                 ],
-                "summary": "Verify unit ownership",
+                "summary": "Admin endpoint to fetch project details",
                 "parameters": [
                     {
                         "type": "string",
@@ -622,8 +779,8 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "multi",
-                        "description": "Unit UUID",
-                        "name": "uuid",
+                        "description": "Project",
+                        "name": "project",
                         "in": "query"
                     },
                     {
@@ -637,13 +794,37 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Timestamps",
-                        "name": "time",
+                        "type": "integer",
+                        "description": "Maximum number of results to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned in pagination of previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort results",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
                         "in": "query"
                     }
                 ],
@@ -651,7 +832,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-any"
+                            "$ref": "#/definitions/http.Response-models_Project"
                         }
                     },
                     "401": {
@@ -660,12 +841,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.Response-any"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/http.Response-any"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -675,21 +850,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/usage/{mode}": {
+        "/stats/{mode}/admin": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This endpoint will return the usage statistics current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of usage statistics.\nCurrently, two modes of statistics are supported:\n- ` + "`" + `current` + "`" + `: In this mode the usage between two time periods is returned\nbased on ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` query parameters.\n- ` + "`" + `global` + "`" + `: In this mode the _total_ usage statistics are returned. For\ninstance, if the retention period of the DB is set to 2 years, usage\nstatistics of last 2 years will be returned.\n\nThe statistics can be limited to certain projects by passing ` + "`" + `project` + "`" + ` query,\nparameter.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.\n\nThe ` + "`" + `current` + "`" + ` usage mode can be slow query depending the requested\nwindow interval. This is mostly due to the fact that the CEEMS DB\nuses custom JSON types to store metric data and usage statistics\nneeds to aggregate metrics over these JSON types using custom aggregate\nfunctions which can be slow.\n\nTherefore the query results are cached for 15 min to avoid load on server.\nURL string is used as the cache key. Thus, the query parameters\n` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` are rounded to the nearest timestamp that are\nmultiple of 900 sec (15 min). The first query will make a DB query and\ncache results and subsequent queries, for a given user and same URL\nquery parameters, will return the same cached result until the cache\nis invalidated after 15 min.",
+                "description": "This admin endpoint will return the quick stats of _queried_ cluster. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of usage statistics.\nCurrently, two modes of statistics are supported:\n- ` + "`" + `current` + "`" + `: In this mode the usage between two time periods is returned\nbased on ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` query parameters.\n- ` + "`" + `global` + "`" + `: In this mode the _total_ usage statistics are returned. For\ninstance, if the retention period of the DB is set to 2 years, usage\nstatistics of last 2 years will be returned.\n\nThe statistics include current number of active users, projects, jobs, _etc_.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs.\n\nThe response can be exported in CSV or newline delimited JSON (NDJSON) formats\nusing the query parameter ` + "`" + `format` + "`" + ` or ` + "`" + `Accept` + "`" + ` header (` + "`" + `text/csv` + "`" + ` or\n` + "`" + `application/x-ndjson` + "`" + `). In CSV format, map fields are flattened into one column\nper key, for instance, ` + "`" + `total_cpu_energy_usage_kwh.total` + "`" + `.\n",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "usage"
+                    "stats"
                 ],
-                "summary": "Usage statistics",
+                "summary": "Admin Stats",
                 "parameters": [
                     {
                         "type": "string",
@@ -704,7 +881,7 @@ const docTemplate = `{
                             "global"
                         ],
                         "type": "string",
-                        "description": "Whether to get usage stats within a period or global",
+                        "description": "Whether to get quick stats within a period or global",
                         "name": "mode",
                         "in": "path",
                         "required": true
@@ -719,16 +896,6 @@ const docTemplate = `{
                         "name": "cluster_id",
                         "in": "query"
                     },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Project",
-                        "name": "project",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
                         "description": "From timestamp",
@@ -742,13 +909,14 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Fields to return in response",
-                        "name": "field",
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
                         "in": "query"
                     }
                 ],
@@ -756,7 +924,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Usage"
+                            "$ref": "#/definitions/http.Response-models_Stat"
                         }
                     },
                     "401": {
@@ -765,6 +933,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.Response-any"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -774,21 +948,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/usage/{mode}/admin": {
+        "/tokens": {
             "get": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This admin endpoint will return the usage statistics of _queried_ user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of usage statistics.\nCurrently, two modes of statistics are supported:\n- ` + "`" + `current` + "`" + `: In this mode the usage between two time periods is returned\nbased on ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` query parameters.\n- ` + "`" + `global` + "`" + `: In this mode the _total_ usage statistics are returned. For\ninstance, if the retention period of the DB is set to 2 years, usage\nstatistics of last 2 years will be returned.\n\nThe statistics can be limited to certain projects by passing ` + "`" + `project` + "`" + ` query,\nparameter.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.\n\nThe ` + "`" + `current` + "`" + ` usage mode can be slow query depending the requested\nwindow interval. This is mostly due to the fact that the CEEMS DB\nuses custom JSON types to store metric data and usage statistics\nneeds to aggregate metrics over these JSON types using custom aggregate\nfunctions which can be slow.\n\nTherefore the query results are cached for 15 min to avoid load on server.\nURL string is used as the cache key. Thus, the query parameters\n` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` are rounded to the nearest timestamp that are\nmultiple of 900 sec (15 min). The first query will make a DB query and\ncache results and subsequent queries, for a given user and same URL\nquery parameters, will return the same cached result until the cache\nis invalidated after 15 min.",
+                "description": "This endpoint will show the API tokens of the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nTokens themselves are never returned. Expired and revoked tokens are\nincluded in the response.\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "usage"
+                    "tokens"
                 ],
-                "summary": "Admin Usage statistics",
+                "summary": "Show API tokens",
                 "parameters": [
                     {
                         "type": "string",
@@ -796,59 +970,114 @@ const docTemplate = `{
                         "name": "X-Grafana-User",
                         "in": "header",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_APIToken"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This endpoint creates a personal API token for the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe token is returned only once in the response and it cannot be\nretrieved later. Requests made with ` + "`" + `Authorization: Bearer \u003ctoken\u003e` + "`" + `\nheader will be authenticated as the owner of the token with the same\nvisibility rules as ` + "`" + `X-Grafana-User` + "`" + ` header.\n\nScopes limit the resources that can be accessed with the token. Allowed\nscopes are names of resources like ` + "`" + `units` + "`" + `, ` + "`" + `usage` + "`" + `, _etc_ and ` + "`" + `admin` + "`" + `\nwhich is needed to access admin endpoints. Only admin users can create\ntokens with ` + "`" + `admin` + "`" + ` scope. API tokens cannot be used to manage tokens.\n",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Create API token",
+                "parameters": [
                     {
-                        "enum": [
-                            "current",
-                            "global"
-                        ],
                         "type": "string",
-                        "description": "Whether to get usage stats within a period or global",
-                        "name": "mode",
-                        "in": "path",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "cluster ID",
-                        "name": "cluster_id",
-                        "in": "query"
+                        "description": "Token request",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_APIToken"
+                        }
                     },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Project",
-                        "name": "project",
-                        "in": "query"
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
                     },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Username",
-                        "name": "user",
-                        "in": "query"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/admin": {
+            "get": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "From timestamp",
-                        "name": "from",
-                        "in": "query"
-                    },
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint will show the API tokens of users. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nIf query parameter ` + "`" + `user` + "`" + ` is provided, only tokens of these users will\nbe returned. If not, tokens of all users will be returned.\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tokens"
+                ],
+                "summary": "Admin endpoint to fetch API tokens",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "To timestamp",
-                        "name": "to",
-                        "in": "query"
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
                     },
                     {
                         "type": "array",
@@ -856,8 +1085,8 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "multi",
-                        "description": "Fields to return in response",
-                        "name": "field",
+                        "description": "Username",
+                        "name": "user",
                         "in": "query"
                     }
                 ],
@@ -865,7 +1094,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_Usage"
+                            "$ref": "#/definitions/http.Response-models_APIToken"
                         }
                     },
                     "401": {
@@ -889,21 +1118,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
-            "get": {
+        "/tokens/{id}": {
+            "delete": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This endpoint will show details of the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe details include list of projects that user is currently a part of.\n",
+                "description": "This endpoint revokes an API token of the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "tokens"
                 ],
-                "summary": "Show user details",
+                "summary": "Revoke API token",
                 "parameters": [
                     {
                         "type": "string",
@@ -913,21 +1142,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Cluster ID",
-                        "name": "cluster_id",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_User"
+                            "$ref": "#/definitions/http.Response-any"
                         }
                     },
                     "401": {
@@ -936,6 +1162,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.Response-any"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -945,21 +1177,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/admin": {
-            "get": {
+        "/tokens/{id}/admin": {
+            "delete": {
                 "security": [
                     {
                         "BasicAuth": []
                     }
                 ],
-                "description": "This endpoint will show details of the queried user(s). The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nWhen the query parameter ` + "`" + `user` + "`" + ` is empty, all users will be returned\nin the response.\n\nThe details include list of projects that user is currently a part of.\n",
+                "description": "This admin endpoint revokes an API token of any user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "tokens"
                 ],
-                "summary": "Admin endpoint for fetching user details of _any_ user.",
+                "summary": "Admin endpoint to revoke API tokens",
                 "parameters": [
                     {
                         "type": "string",
@@ -969,31 +1201,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "User name",
-                        "name": "user",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "Cluster ID",
-                        "name": "cluster_id",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.Response-models_User"
+                            "$ref": "#/definitions/http.Response-any"
                         }
                     },
                     "401": {
@@ -1002,6 +1221,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.Response-any"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1010,40 +1241,2442 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "http.Response-any": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "type": "array",
-                    "items": {}
-                },
-                "error": {
-                    "type": "string"
-                },
-                "errorType": {
-                    "$ref": "#/definitions/http.errorType"
-                },
-                "status": {
-                    "type": "string"
-                },
-                "warnings": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                }
-            }
         },
-        "http.Response-models_Cluster": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Cluster"
+        "/units": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This user endpoint will fetch compute units of the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nIf multiple query parameters are passed, for instance, ` + "`" + `?uuid=\u003cuuid\u003e\u0026project=\u003cproject\u003e` + "`" + `,\nthe intersection of query parameters are used to fetch compute units rather than\nthe union. That means if the compute unit's ` + "`" + `uuid` + "`" + ` does not belong to the queried\nproject, null response will be returned.\n\nIn order to return the running compute units as well, use the query parameter ` + "`" + `running` + "`" + `.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs. If query\nparameter ` + "`" + `timezone` + "`" + ` is provided, the unit's created, start and end time strings\nwill be presented in that time zone.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.\n\nResults can be paginated using ` + "`" + `limit` + "`" + ` and ` + "`" + `offset` + "`" + ` query parameters and sorted\non any field using ` + "`" + `sort` + "`" + ` and ` + "`" + `order` + "`" + ` query parameters. When results are sorted by\n` + "`" + `id` + "`" + ` or ` + "`" + `ended_at_ts` + "`" + `, ` + "`" + `cursor` + "`" + ` query parameter can be used instead of ` + "`" + `offset` + "`" + `.\nThe ` + "`" + `pagination` + "`" + ` object in the response contains ` + "`" + `next_offset` + "`" + ` and/or ` + "`" + `next_cursor` + "`" + `\nto fetch the next page.\nThe response can be exported in CSV or newline delimited JSON (NDJSON) formats\nusing the query parameter ` + "`" + `format` + "`" + ` or ` + "`" + `Accept` + "`" + ` header (` + "`" + `text/csv` + "`" + ` or\n` + "`" + `application/x-ndjson` + "`" + `). In CSV format, map fields are flattened into one column\nper key, for instance, ` + "`" + `total_cpu_energy_usage_kwh.total` + "`" + `.\n\nUnits can be filtered on the values of map fields using ` + "`" + `filter` + "`" + ` query parameter\nof form ` + "`" + `\u003cfield\u003e.\u003ckey\u003e\u003cop\u003e\u003cvalue\u003e` + "`" + ` where ` + "`" + `op` + "`" + ` is one of ` + "`" + `==` + "`" + `, ` + "`" + `!=` + "`" + `, ` + "`" + `\u003c` + "`" + `, ` + "`" + `\u003c=` + "`" + `, ` + "`" + `\u003e` + "`" + `\nand ` + "`" + `\u003e=` + "`" + `. For instance, ` + "`" + `?filter=avg_gpu_usage.global\u003c10\u0026filter=tags.partition==gpu` + "`" + `.\nMultiple filters are combined with AND and only existing keys can be used.\n",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "User endpoint for fetching compute units",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Unit UUID",
+                        "name": "uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to fetch running units",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone in IANA format",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to return in response",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filters on values of map fields",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of units to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of units to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned in pagination of previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort units",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Unit"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/admin": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint will fetch compute units of _any_ user, compute unit and/or project. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nIf multiple query parameters are passed, for instance, ` + "`" + `?uuid=\u003cuuid\u003e\u0026user=\u003cuser\u003e` + "`" + `,\nthe intersection of query parameters are used to fetch compute units rather than\nthe union. That means if the compute unit's ` + "`" + `uuid` + "`" + ` does not belong to the queried\nuser, null response will be returned.\n\nIn order to return the running compute units as well, use the query parameter ` + "`" + `running` + "`" + `.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs. If query\nparameter ` + "`" + `timezone` + "`" + ` is provided, the unit's created, start and end time strings\nwill be presented in that time zone.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.\n\nResults can be paginated using ` + "`" + `limit` + "`" + ` and ` + "`" + `offset` + "`" + ` query parameters and sorted\non any field using ` + "`" + `sort` + "`" + ` and ` + "`" + `order` + "`" + ` query parameters. When results are sorted by\n` + "`" + `id` + "`" + ` or ` + "`" + `ended_at_ts` + "`" + `, ` + "`" + `cursor` + "`" + ` query parameter can be used instead of ` + "`" + `offset` + "`" + `.\nThe ` + "`" + `pagination` + "`" + ` object in the response contains ` + "`" + `next_offset` + "`" + ` and/or ` + "`" + `next_cursor` + "`" + `\nto fetch the next page.\nThe response can be exported in CSV or newline delimited JSON (NDJSON) formats\nusing the query parameter ` + "`" + `format` + "`" + ` or ` + "`" + `Accept` + "`" + ` header (` + "`" + `text/csv` + "`" + ` or\n` + "`" + `application/x-ndjson` + "`" + `). In CSV format, map fields are flattened into one column\nper key, for instance, ` + "`" + `total_cpu_energy_usage_kwh.total` + "`" + `.\n\nUnits can be filtered on the values of map fields using ` + "`" + `filter` + "`" + ` query parameter\nof form ` + "`" + `\u003cfield\u003e.\u003ckey\u003e\u003cop\u003e\u003cvalue\u003e` + "`" + ` where ` + "`" + `op` + "`" + ` is one of ` + "`" + `==` + "`" + `, ` + "`" + `!=` + "`" + `, ` + "`" + `\u003c` + "`" + `, ` + "`" + `\u003c=` + "`" + `, ` + "`" + `\u003e` + "`" + `\nand ` + "`" + `\u003e=` + "`" + `. For instance, ` + "`" + `?filter=avg_gpu_usage.global\u003c10\u0026filter=tags.partition==gpu` + "`" + `.\nMultiple filters are combined with AND and only existing keys can be used.\n",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "Admin endpoint for fetching compute units.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Unit UUID",
+                        "name": "uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "User name",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to fetch running units",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone in IANA format",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to return in response",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Filters on values of map fields",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of units to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of units to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned in pagination of previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort units",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Unit"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/efficiency": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This user endpoint will return the efficiency scores of compute units of\nthe current user and of the projects coordinated by the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in the\nrequest.\n\nScores are estimated during each update of the DB from the usage metrics\nof units. All scores except ` + "`" + `wasted_energy_kwh` + "`" + ` and ` + "`" + `mem_over_request` + "`" + ` are\nfractions between 0 and 1:\n- ` + "`" + `cpu` + "`" + `: Average CPU usage relative to allocated CPUs.\n- ` + "`" + `cpu_mem` + "`" + `: Average CPU memory usage relative to allocated memory.\n- ` + "`" + `mem_over_request` + "`" + `: Ratio of allocated memory to used memory.\n- ` + "`" + `gpu` + "`" + `: Average GPU usage relative to allocated GPUs.\n- ` + "`" + `gpu_mem` + "`" + `: Average GPU memory usage relative to allocated GPU memory.\n- ` + "`" + `gpu_idle` + "`" + `: Fraction of time allocated GPUs were idle.\n- ` + "`" + `wasted_energy_kwh` + "`" + `: Energy consumed by idle allocations in kWh.\n- ` + "`" + `score` + "`" + `: Overall efficiency of the unit.\n\nOnly units that have been scored are returned. Units are sorted by\nthe score given in ` + "`" + `sort` + "`" + ` query parameter which defaults to ` + "`" + `score` + "`" + `.\nThe default ` + "`" + `asc` + "`" + ` order returns the least efficient units first. Use\n` + "`" + `limit` + "`" + ` and ` + "`" + `offset` + "`" + ` query parameters to paginate the units.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "User endpoint for fetching efficiency scores of units",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to fetch running units",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cpu",
+                            "cpu_mem",
+                            "mem_over_request",
+                            "gpu",
+                            "gpu_mem",
+                            "gpu_idle",
+                            "wasted_energy_kwh",
+                            "score"
+                        ],
+                        "type": "string",
+                        "description": "Score to sort units",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of units",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of units to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Unit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/efficiency/admin": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint will return the efficiency scores of compute units of\n_any_ user. The current user is always identified by the header\n` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nIf ` + "`" + `user` + "`" + ` query parameter is provided, only units of these users are\nreturned. See the user endpoint for the sorting and pagination of units.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "Admin endpoint for fetching efficiency scores of units",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "User name",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to fetch running units",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cpu",
+                            "cpu_mem",
+                            "mem_over_request",
+                            "gpu",
+                            "gpu_mem",
+                            "gpu_idle",
+                            "wasted_energy_kwh",
+                            "score"
+                        ],
+                        "type": "string",
+                        "description": "Score to sort units",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of units",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of units to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Unit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/events": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This user endpoint streams the lifecycle events of compute units of the\ncurrent user and of the projects coordinated by the current user as\nServer-Sent Events. The current user is always identified by the header\n` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nEvents are generated when the DB is updated. The type of each event is\none of ` + "`" + `created` + "`" + `, ` + "`" + `state_changed` + "`" + `, ` + "`" + `ended` + "`" + ` and ` + "`" + `metrics_updated` + "`" + `, and its\ndata is a JSON object with the unit identifiers and its current and previous\nstates. Events can be restricted to certain types using ` + "`" + `type` + "`" + ` query parameter.\n\nEvery event has an increasing ID. A client that reconnects with the\n` + "`" + `Last-Event-ID` + "`" + ` header (or ` + "`" + `last_event_id` + "`" + ` query parameter) receives all\nthe events after that ID that are still retained in the DB. Without it,\nonly new events are streamed.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "User endpoint to stream unit lifecycle events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Event type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of last received event",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UnitEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/events/admin": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint streams the lifecycle events of compute units of _any_\nuser as Server-Sent Events. The current user is always identified by the\nheader ` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nSee the user endpoint for the format of events. Events can be restricted\nto units of certain users using ` + "`" + `user` + "`" + ` query parameter.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "Admin endpoint to stream unit lifecycle events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "User name",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Event type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of last received event",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UnitEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/ingest": {
+            "post": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint will insert compute units, users and projects\nof a cluster that is configured with ` + "`" + `push` + "`" + ` manager. The current user is\nalways retrieved from the header ` + "`" + `X-Grafana-User` + "`" + ` or bearer token and\nAPI tokens must have ` + "`" + `units` + "`" + ` and ` + "`" + `admin` + "`" + ` scopes.\n\nUnits, users and projects must be in the same format as the ones\nreturned by units, users and projects endpoints. Fields are validated\nagainst the types of DB columns and unknown fields are rejected.\n` + "`" + `uuid` + "`" + ` of units and ` + "`" + `name` + "`" + ` of users and projects are required.\n\nUnits are updated with metrics from TSDB, billed and scored like the\nunits fetched from other resource managers. Aggregate metrics of units\nmust be the ones since the last push as they are added to the existing\nvalues of units and usage.\n",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "Admin endpoint to push compute units",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Units, users and projects of cluster",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.IngestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_IngestStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/verify": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This endpoint will check if the current user is the owner of the\nqueried UUIDs. The current user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nA response of 200 means that the current user is the owner of the queried UUIDs.\nAny other response code should be treated as the current user not being the owner\nof the queried units.\n\nThe ownership check passes if any of the following conditions are ` + "`" + `true` + "`" + `:\n- If the current user is the _direct_ owner of the compute unit.\n- If the current user belongs to the same account/project/namespace as\nthe compute unit. This means the users belonging to the same project can\naccess each others compute units.\n\nThe above checks must pass for **all** the queried units.\nIf the check does not pass for at least one queried unit, a response 403 will be\nreturned.\n\nAny 500 response codes should be treated as failed check as well.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "Verify unit ownership",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Unit UUID",
+                        "name": "uuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Timestamps",
+                        "name": "time",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/{uuid}/steps": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This user endpoint will fetch sub units of a compute unit of the current user.\nSub units are job steps and components of heterogeneous jobs of batch schedulers.\nThey are stored only when the resource manager is configured to fetch them.\nThe current user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nIf query parameter ` + "`" + `timezone` + "`" + ` is provided, the unit's created, start and end time strings\nwill be presented in that time zone.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "User endpoint for fetching steps of a compute unit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone in IANA format",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to return in response",
+                        "name": "field",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Unit"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/units/{uuid}/steps/admin": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint will fetch sub units of a compute unit of _any_ user. Sub units\nare job steps and components of heterogeneous jobs of batch schedulers. They are\nstored only when the resource manager is configured to fetch them.\nThe current user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nIf query parameter ` + "`" + `timezone` + "`" + ` is provided, the unit's created, start and end time strings\nwill be presented in that time zone.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "units"
+                ],
+                "summary": "Admin endpoint for fetching steps of a compute unit.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unit UUID",
+                        "name": "uuid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "User name",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone in IANA format",
+                        "name": "timezone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to return in response",
+                        "name": "field",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Unit"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage/timeseries": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This endpoint will return the usage statistics of the projects of current user\naggregated in time buckets. The current user is always identified by the header\n` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nThe usage statistics are estimated from daily usage of projects and hence, they\nare available beyond the retention period of TSDB. The query parameter ` + "`" + `step` + "`" + `\nsets the size of time bucket which can be one day (` + "`" + `1d` + "`" + `), one week (` + "`" + `1w` + "`" + `) or one\ncalendar month (` + "`" + `1M` + "`" + `). Weeks start on Monday. The ` + "`" + `timestamp` + "`" + ` of each bucket is the\nstart of the bucket in epoch milliseconds.\n\nBy default, usage statistics of all projects are aggregated in each bucket. The query\nparameter ` + "`" + `groupby` + "`" + ` can be used to split the statistics by ` + "`" + `project` + "`" + `, ` + "`" + `user` + "`" + ` and/or\n` + "`" + `cluster_id` + "`" + `. Average metrics are weighed by the allocated time of each resource.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Usage time series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "1d",
+                            "1w",
+                            "1M"
+                        ],
+                        "type": "string",
+                        "description": "Size of time bucket",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Group by",
+                        "name": "groupby",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to return in response",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_UsageTimeSeries"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage/timeseries/admin": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint will return the usage statistics of projects aggregated\nin time buckets. The current user is always identified by the header\n` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nIf query parameter ` + "`" + `user` + "`" + ` is provided, only usage of the projects of these\nusers will be returned. If not, usage of all projects will be returned.\nRest of the query parameters are same as ` + "`" + `/usage/timeseries` + "`" + ` endpoint.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Admin endpoint for usage time series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Username",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "1d",
+                            "1w",
+                            "1M"
+                        ],
+                        "type": "string",
+                        "description": "Size of time bucket",
+                        "name": "step",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Group by",
+                        "name": "groupby",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to return in response",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_UsageTimeSeries"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage/{mode}": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This endpoint will return the usage statistics current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of usage statistics.\nCurrently, two modes of statistics are supported:\n- ` + "`" + `current` + "`" + `: In this mode the usage between two time periods is returned\nbased on ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` query parameters.\n- ` + "`" + `global` + "`" + `: In this mode the _total_ usage statistics are returned. For\ninstance, if the retention period of the DB is set to 2 years, usage\nstatistics of last 2 years will be returned.\n\nThe statistics can be limited to certain projects by passing ` + "`" + `project` + "`" + ` query,\nparameter.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.\n\nThe ` + "`" + `current` + "`" + ` usage mode can be slow query depending the requested\nwindow interval. This is mostly due to the fact that the CEEMS DB\nuses custom JSON types to store metric data and usage statistics\nneeds to aggregate metrics over these JSON types using custom aggregate\nfunctions which can be slow.\n\nTherefore the query results are cached for 15 min to avoid load on server.\nURL string is used as the cache key. Thus, the query parameters\n` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` are rounded to the nearest timestamp that are\nmultiple of 900 sec (15 min). The first query will make a DB query and\ncache results and subsequent queries, for a given user and same URL\nquery parameters, will return the same cached result until the cache\nis invalidated after 15 min.\n\nWhen the retention policy of DB is ` + "`" + `rollup` + "`" + ` and ` + "`" + `from` + "`" + ` is older than the retention\nperiod, usage is estimated from monthly aggregates of expired units along with\nthe units that are still in the DB. In this case, ` + "`" + `from` + "`" + ` is truncated to the start\nof its month.\nThe response can be exported in CSV or newline delimited JSON (NDJSON) formats\nusing the query parameter ` + "`" + `format` + "`" + ` or ` + "`" + `Accept` + "`" + ` header (` + "`" + `text/csv` + "`" + ` or\n` + "`" + `application/x-ndjson` + "`" + `). In CSV format, map fields are flattened into one column\nper key, for instance, ` + "`" + `total_cpu_energy_usage_kwh.total` + "`" + `.\n\nUsage is always grouped by user and project. It can be split further using\n` + "`" + `groupby` + "`" + ` query parameter by ` + "`" + `cluster_id` + "`" + `, ` + "`" + `groupname` + "`" + ` or attributes of allocation\nand tags of units like ` + "`" + `allocation.partition` + "`" + ` and ` + "`" + `tags.flavor` + "`" + `. Values of the\nattributes of each group are returned in ` + "`" + `dimensions` + "`" + ` field. In ` + "`" + `global` + "`" + ` mode,\nonly the attributes persisted in daily usage can be used.\n",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Usage statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "current",
+                            "global"
+                        ],
+                        "type": "string",
+                        "description": "Whether to get usage stats within a period or global",
+                        "name": "mode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to return in response",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Group usage by column or attribute",
+                        "name": "groupby",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Usage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage/{mode}/admin": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint will return the usage statistics of _queried_ user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of usage statistics.\nCurrently, two modes of statistics are supported:\n- ` + "`" + `current` + "`" + `: In this mode the usage between two time periods is returned\nbased on ` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` query parameters.\n- ` + "`" + `global` + "`" + `: In this mode the _total_ usage statistics are returned. For\ninstance, if the retention period of the DB is set to 2 years, usage\nstatistics of last 2 years will be returned.\n\nThe statistics can be limited to certain projects by passing ` + "`" + `project` + "`" + ` query,\nparameter.\n\nIf ` + "`" + `to` + "`" + ` query parameter is not provided, current time will be used. If ` + "`" + `from` + "`" + `\nquery parameter is not used, a default query window of 24 hours will be used.\nIt means if ` + "`" + `to` + "`" + ` is provided, ` + "`" + `from` + "`" + ` will be calculated as ` + "`" + `to` + "`" + ` - 24hrs.\n\nTo limit the number of fields in the response, use ` + "`" + `field` + "`" + ` query parameter. By default, all\nfields will be included in the response if they are _non-empty_.\n\nThe ` + "`" + `current` + "`" + ` usage mode can be slow query depending the requested\nwindow interval. This is mostly due to the fact that the CEEMS DB\nuses custom JSON types to store metric data and usage statistics\nneeds to aggregate metrics over these JSON types using custom aggregate\nfunctions which can be slow.\n\nTherefore the query results are cached for 15 min to avoid load on server.\nURL string is used as the cache key. Thus, the query parameters\n` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` are rounded to the nearest timestamp that are\nmultiple of 900 sec (15 min). The first query will make a DB query and\ncache results and subsequent queries, for a given user and same URL\nquery parameters, will return the same cached result until the cache\nis invalidated after 15 min.\n\nWhen the retention policy of DB is ` + "`" + `rollup` + "`" + ` and ` + "`" + `from` + "`" + ` is older than the retention\nperiod, usage is estimated from monthly aggregates of expired units along with\nthe units that are still in the DB. In this case, ` + "`" + `from` + "`" + ` is truncated to the start\nof its month.\nThe response can be exported in CSV or newline delimited JSON (NDJSON) formats\nusing the query parameter ` + "`" + `format` + "`" + ` or ` + "`" + `Accept` + "`" + ` header (` + "`" + `text/csv` + "`" + ` or\n` + "`" + `application/x-ndjson` + "`" + `). In CSV format, map fields are flattened into one column\nper key, for instance, ` + "`" + `total_cpu_energy_usage_kwh.total` + "`" + `.\n\nUsage is always grouped by user and project. It can be split further using\n` + "`" + `groupby` + "`" + ` query parameter by ` + "`" + `cluster_id` + "`" + `, ` + "`" + `groupname` + "`" + ` or attributes of allocation\nand tags of units like ` + "`" + `allocation.partition` + "`" + ` and ` + "`" + `tags.flavor` + "`" + `. Values of the\nattributes of each group are returned in ` + "`" + `dimensions` + "`" + ` field. In ` + "`" + `global` + "`" + ` mode,\nonly the attributes persisted in daily usage can be used.\n",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Admin Usage statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "current",
+                            "global"
+                        ],
+                        "type": "string",
+                        "description": "Whether to get usage stats within a period or global",
+                        "name": "mode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Username",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Fields to return in response",
+                        "name": "field",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Group usage by column or attribute",
+                        "name": "groupby",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Usage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage/{mode}/efficiency": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This user endpoint will return the efficiency scores of all the projects\nthat the current user is part of aggregated over their units. The current\nuser is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nA path parameter ` + "`" + `mode` + "`" + ` is required to return the kind of efficiency.\nCurrently, two modes are supported:\n- ` + "`" + `current` + "`" + `: In this mode the efficiency of units in the window given by\n` + "`" + `from` + "`" + ` and ` + "`" + `to` + "`" + ` query parameters is returned. Running units are included.\n- ` + "`" + `global` + "`" + `: In this mode the efficiency of all the units in DB is returned.\n\nScores of units are averaged weighting each unit by its allocated time\nand the wasted energy of units is summed. Efficiency of projects can be\nsplit by users using ` + "`" + `groupby=username` + "`" + ` query parameter to find the least\nefficient users in each project.\n\nResults are sorted by the score given in ` + "`" + `sort` + "`" + ` query parameter which\ndefaults to ` + "`" + `score` + "`" + `. The default ` + "`" + `asc` + "`" + ` order returns the least efficient\nprojects first. Use ` + "`" + `limit` + "`" + ` query parameter to return only the worst\noffenders.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "User endpoint for fetching efficiency of projects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "current",
+                            "global"
+                        ],
+                        "type": "string",
+                        "description": "Whether to get efficiency in a window or since the start",
+                        "name": "mode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "username"
+                        ],
+                        "type": "string",
+                        "description": "Split efficiency of projects by users",
+                        "name": "groupby",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cpu",
+                            "cpu_mem",
+                            "mem_over_request",
+                            "gpu",
+                            "gpu_mem",
+                            "gpu_idle",
+                            "wasted_energy_kwh",
+                            "score"
+                        ],
+                        "type": "string",
+                        "description": "Score to sort projects",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of projects",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of projects to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Efficiency"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage/{mode}/efficiency/admin": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This admin endpoint will return the efficiency scores of _any_ project\naggregated over its units. The current user is always identified by the\nheader ` + "`" + `X-Grafana-User` + "`" + ` in the request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nIf ` + "`" + `user` + "`" + ` query parameter is provided, only projects of these users are\nreturned. See the user endpoint for the aggregation of scores.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usage"
+                ],
+                "summary": "Admin endpoint for fetching efficiency of projects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "current",
+                            "global"
+                        ],
+                        "type": "string",
+                        "description": "Whether to get efficiency in a window or since the start",
+                        "name": "mode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "User name",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "username"
+                        ],
+                        "type": "string",
+                        "description": "Split efficiency of projects by users",
+                        "name": "groupby",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cpu",
+                            "cpu_mem",
+                            "mem_over_request",
+                            "gpu",
+                            "gpu_mem",
+                            "gpu_idle",
+                            "wasted_energy_kwh",
+                            "score"
+                        ],
+                        "type": "string",
+                        "description": "Score to sort projects",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of projects",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of projects to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From timestamp",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To timestamp",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_Efficiency"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This endpoint will show details of the current user. The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe details include list of projects that user is currently a part of.\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Show user details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned in pagination of previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort results",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/admin": {
+            "get": {
+                "security": [
+                    {
+                        "BasicAuth": []
+                    }
+                ],
+                "description": "This endpoint will show details of the queried user(s). The\ncurrent user is always identified by the header ` + "`" + `X-Grafana-User` + "`" + ` in\nthe request.\n\nThe user who is making the request must be in the list of admin users\nconfigured for the server.\n\nWhen the query parameter ` + "`" + `user` + "`" + ` is empty, all users will be returned\nin the response.\n\nThe details include list of projects that user is currently a part of.\n",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Admin endpoint for fetching user details of _any_ user.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Current user name",
+                        "name": "X-Grafana-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "User name",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Cluster ID",
+                        "name": "cluster_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned in pagination of previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort results",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-models_User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Response-any"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "http.IngestRequest": {
+            "type": "object",
+            "properties": {
+                "cluster_id": {
+                    "description": "ID of cluster that is configured with push manager",
+                    "type": "string"
+                },
+                "projects": {
+                    "description": "Projects in the format of projects endpoint",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "units": {
+                    "description": "Compute units in the format of units endpoint",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "users": {
+                    "description": "Users in the format of users endpoint",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                }
+            }
+        },
+        "http.Pagination": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "next_offset": {
+                    "type": "integer"
+                },
+                "offset": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.Response-any": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {}
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorType": {
+                    "$ref": "#/definitions/http.errorType"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.Response-models_APIToken": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.APIToken"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorType": {
+                    "$ref": "#/definitions/http.errorType"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.Response-models_AuditRecord": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditRecord"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorType": {
+                    "$ref": "#/definitions/http.errorType"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.Response-models_Budget": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Budget"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorType": {
+                    "$ref": "#/definitions/http.errorType"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.Response-models_Cluster": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Cluster"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorType": {
+                    "$ref": "#/definitions/http.errorType"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.Response-models_Efficiency": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Efficiency"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorType": {
+                    "$ref": "#/definitions/http.errorType"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.Response-models_IngestStats": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IngestStats"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorType": {
+                    "$ref": "#/definitions/http.errorType"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.Response-models_Invoice": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Invoice"
                     }
                 },
                 "error": {
@@ -1052,6 +3685,9 @@ const docTemplate = `{
                 "errorType": {
                     "$ref": "#/definitions/http.errorType"
                 },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -1078,6 +3714,9 @@ const docTemplate = `{
                 "errorType": {
                     "$ref": "#/definitions/http.errorType"
                 },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -1104,6 +3743,9 @@ const docTemplate = `{
                 "errorType": {
                     "$ref": "#/definitions/http.errorType"
                 },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -1130,6 +3772,9 @@ const docTemplate = `{
                 "errorType": {
                     "$ref": "#/definitions/http.errorType"
                 },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -1156,6 +3801,38 @@ const docTemplate = `{
                 "errorType": {
                     "$ref": "#/definitions/http.errorType"
                 },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.Response-models_UsageTimeSeries": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageTimeSeries"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorType": {
+                    "$ref": "#/definitions/http.errorType"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -1182,57 +3859,346 @@ const docTemplate = `{
                 "errorType": {
                     "$ref": "#/definitions/http.errorType"
                 },
+                "pagination": {
+                    "$ref": "#/definitions/http.Pagination"
+                },
                 "status": {
                     "type": "string"
                 },
-                "warnings": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.TokenRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "Lifetime of the token. Defaults to 30d",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of the token",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Resources that can be accessed with token. Defaults to all non admin resources",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.errorType": {
+            "type": "string",
+            "enum": [
+                "",
+                "unauthorized",
+                "forbidden",
+                "timeout",
+                "canceled",
+                "execution",
+                "bad_data",
+                "internal",
+                "unavailable",
+                "not_found",
+                "not_acceptable"
+            ],
+            "x-enum-varnames": [
+                "errorNone",
+                "errorUnauthorized",
+                "errorForbidden",
+                "errorTimeout",
+                "errorCanceled",
+                "errorExec",
+                "errorBadData",
+                "errorInternal",
+                "errorUnavailable",
+                "errorNotFound",
+                "errorNotAcceptable"
+            ]
+        },
+        "models.APIToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Creation time",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "Expiry time",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Public identifier of token used to manage it",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "description": "Time at which token was last used",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of the token given by user",
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "First characters of token to help users to identify it",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "description": "Revocation time",
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "description": "User who revoked the token",
+                    "type": "string"
+                },
+                "scopes": {
+                    "description": "Resources that can be accessed with token",
+                    "type": "array",
+                    "items": {}
+                },
+                "token": {
+                    "description": "Token. Only set in the response of token creation",
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Owner of the token",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Allocation": {
+            "type": "object",
+            "additionalProperties": true
+        },
+        "models.AuditRecord": {
+            "type": "object",
+            "properties": {
+                "cluster_ids": {
+                    "description": "Queried cluster IDs",
+                    "type": "array",
+                    "items": {}
+                },
+                "decision": {
+                    "description": "Access decision: allow, deny or error",
+                    "type": "string"
+                },
+                "endpoint": {
+                    "description": "Path of request",
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "description": "Time taken to serve the request in milliseconds",
+                    "type": "number"
+                },
+                "method": {
+                    "description": "HTTP method of request",
+                    "type": "string"
+                },
+                "queried_users": {
+                    "description": "Users whose data are queried by admin users",
+                    "type": "array",
+                    "items": {}
+                },
+                "remote_addr": {
+                    "description": "Address of the client",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "CEEMS component that served the request",
+                    "type": "string"
+                },
+                "status_code": {
+                    "description": "HTTP status code of response",
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "description": "Time of request in epoch milliseconds",
+                    "type": "integer"
+                },
+                "user": {
+                    "description": "User making the request",
+                    "type": "string"
+                },
+                "uuids": {
+                    "description": "Queried unit UUIDs",
+                    "type": "array",
+                    "items": {}
+                }
+            }
+        },
+        "models.Budget": {
+            "type": "object",
+            "properties": {
+                "cluster_id": {
+                    "description": "Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.",
+                    "type": "string"
+                },
+                "consumed": {
+                    "description": "Consumption of each resource in the current period",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "last_updated_at": {
+                    "description": "Last Updated time",
+                    "type": "string"
+                },
+                "limits": {
+                    "description": "Limits of the budget for each resource",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "notified_threshold": {
+                    "description": "Highest threshold that has been notified in the current period",
+                    "type": "number"
+                },
+                "period": {
+                    "description": "Budget period. One of month, year or global",
+                    "type": "string"
+                },
+                "period_start": {
+                    "description": "Start of the current budget period. Empty for global budgets",
+                    "type": "string"
+                },
+                "project": {
+                    "description": "Account in batch systems, Tenant in Openstack, Namespace in k8s",
+                    "type": "string"
+                },
+                "used_percent": {
+                    "description": "Percentage of the budget consumed for each resource",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.Cluster": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "manager": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Efficiency": {
+            "type": "object",
+            "properties": {
+                "cluster_id": {
+                    "description": "Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.",
+                    "type": "string"
+                },
+                "efficiency": {
+                    "description": "Efficiency scores averaged over units and total wasted energy in kWh",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "num_units": {
+                    "description": "Number of scored units",
+                    "type": "integer"
+                },
+                "project": {
+                    "description": "Account in batch systems, Tenant in Openstack, Namespace in k8s",
+                    "type": "string"
+                },
+                "resource_manager": {
+                    "description": "Name of the resource manager that owns project. Eg slurm, openstack, kubernetes, etc",
+                    "type": "string"
+                },
+                "total_time_seconds": {
+                    "description": "Different times in seconds consumed by scored units",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "username": {
+                    "description": "Username. It is set only when scores are grouped by users",
+                    "type": "string"
+                }
+            }
+        },
+        "models.IngestStats": {
+            "type": "object",
+            "properties": {
+                "projects": {
+                    "description": "Number of projects",
+                    "type": "integer"
+                },
+                "units": {
+                    "description": "Number of compute units",
+                    "type": "integer"
+                },
+                "users": {
+                    "description": "Number of users",
+                    "type": "integer"
                 }
             }
         },
-        "http.errorType": {
-            "type": "string",
-            "enum": [
-                "",
-                "unauthorized",
-                "forbidden",
-                "timeout",
-                "canceled",
-                "execution",
-                "bad_data",
-                "internal",
-                "unavailable",
-                "not_found",
-                "not_acceptable"
-            ],
-            "x-enum-varnames": [
-                "errorNone",
-                "errorUnauthorized",
-                "errorForbidden",
-                "errorTimeout",
-                "errorCanceled",
-                "errorExec",
-                "errorBadData",
-                "errorInternal",
-                "errorUnavailable",
-                "errorNotFound",
-                "errorNotAcceptable"
-            ]
-        },
-        "models.Allocation": {
-            "type": "object",
-            "additionalProperties": true
-        },
-        "models.Cluster": {
+        "models.Invoice": {
             "type": "object",
             "properties": {
-                "id": {
+                "cluster_id": {
+                    "description": "Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.",
                     "type": "string"
                 },
-                "manager": {
+                "currency": {
+                    "description": "Currency of the cost",
+                    "type": "string"
+                },
+                "num_units": {
+                    "description": "Number of units billed in the period",
+                    "type": "integer"
+                },
+                "period": {
+                    "description": "Billing period. Eg 2024-10 for monthly invoices",
+                    "type": "string"
+                },
+                "project": {
+                    "description": "Account in batch systems, Tenant in Openstack, Namespace in k8s",
+                    "type": "string"
+                },
+                "resource_manager": {
+                    "description": "Name of the resource manager that owns project. Eg slurm, openstack, kubernetes, etc",
+                    "type": "string"
+                },
+                "total_cost": {
+                    "description": "Total cost in the period split by resource type",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_time_seconds": {
+                    "description": "Different times in seconds consumed in the period",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "username": {
+                    "description": "Username. It is set only for invoices of users",
                     "type": "string"
                 }
             }
@@ -1250,6 +4216,11 @@ const docTemplate = `{
                     "description": "Identifier of the resource manager that owns project. It is used to differentiate multiple clusters of same resource manager.",
                     "type": "string"
                 },
+                "coordinators": {
+                    "description": "List of coordinators of the project",
+                    "type": "array",
+                    "items": {}
+                },
                 "name": {
                     "description": "Name of the project",
                     "type": "string"
@@ -1366,6 +4337,14 @@ const docTemplate = `{
                     "description": "Creation timestamp",
                     "type": "integer"
                 },
+                "efficiency": {
+                    "description": "Efficiency scores of unit like CPU efficiency, GPU idle fraction and wasted energy in kWh",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
                 "elapsed": {
                     "description": "Human readable total elapsed time string",
                     "type": "string"
@@ -1386,6 +4365,10 @@ const docTemplate = `{
                     "description": "Name of compute unit",
                     "type": "string"
                 },
+                "parent_uuid": {
+                    "description": "UUID of parent unit. It is set only for sub units like job steps and components of heterogeneous jobs",
+                    "type": "string"
+                },
                 "project": {
                     "description": "Account in batch systems, Tenant in Openstack, Namespace in k8s",
                     "type": "string"
@@ -1414,6 +4397,14 @@ const docTemplate = `{
                         }
                     ]
                 },
+                "total_cost": {
+                    "description": "Total cost of unit split by resource type. It is estimated using price tables in billing config",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
                 "total_cpu_emissions_gms": {
                     "description": "Total CPU emissions from source(s) in grams during lifetime of unit",
                     "allOf": [
@@ -1496,6 +4487,55 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UnitEvent": {
+            "type": "object",
+            "properties": {
+                "cluster_id": {
+                    "description": "Identifier of the resource manager that owns compute unit",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Monotonically increasing ID of event",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name of compute unit",
+                    "type": "string"
+                },
+                "previous_state": {
+                    "description": "State of unit in previous DB update",
+                    "type": "string"
+                },
+                "project": {
+                    "description": "Project of compute unit",
+                    "type": "string"
+                },
+                "resource_manager": {
+                    "description": "Name of the resource manager that owns compute unit",
+                    "type": "string"
+                },
+                "state": {
+                    "description": "Current state of unit",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "description": "Time of DB update in epoch milliseconds",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "Type of event: created, state_changed, ended or metrics_updated",
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Owner of compute unit",
+                    "type": "string"
+                },
+                "uuid": {
+                    "description": "Unique identifier of unit",
+                    "type": "string"
+                }
+            }
+        },
         "models.Usage": {
             "type": "object",
             "properties": {
@@ -1535,6 +4575,14 @@ const docTemplate = `{
                     "description": "Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.",
                     "type": "string"
                 },
+                "dimensions": {
+                    "description": "Values of allocation and tag attributes, eg, ` + "`" + `allocation.partition` + "`" + `, that usage is grouped by",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Tag"
+                        }
+                    ]
+                },
                 "groupname": {
                     "description": "User group",
                     "type": "string"
@@ -1551,6 +4599,14 @@ const docTemplate = `{
                     "description": "Name of the resource manager that owns project. Eg slurm, openstack, kubernetes, etc",
                     "type": "string"
                 },
+                "total_cost": {
+                    "description": "Total cost of project split by resource type",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
                 "total_cpu_emissions_gms": {
                     "description": "Total CPU emissions from source(s) in grams during lifetime of project",
                     "allOf": [
@@ -1629,6 +4685,143 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UsageTimeSeries": {
+            "type": "object",
+            "properties": {
+                "avg_cpu_mem_usage": {
+                    "description": "Average CPU memory usage(s) in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "avg_cpu_usage": {
+                    "description": "Average CPU usage(s) in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "avg_gpu_mem_usage": {
+                    "description": "Average GPU memory usage(s) in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "avg_gpu_usage": {
+                    "description": "Average GPU usage(s) in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "cluster_id": {
+                    "description": "Identifier of the resource manager. It is set only when usage is grouped by cluster_id",
+                    "type": "string"
+                },
+                "num_units": {
+                    "description": "Number of consumed units in the bucket",
+                    "type": "integer"
+                },
+                "project": {
+                    "description": "Account in batch systems, Tenant in Openstack, Namespace in k8s. It is set only when usage is grouped by project",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "description": "Start of time bucket in epoch milliseconds",
+                    "type": "integer"
+                },
+                "total_cost": {
+                    "description": "Total cost in the bucket split by resource type",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_cpu_emissions_gms": {
+                    "description": "Total CPU emissions from source(s) in grams in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_cpu_energy_usage_kwh": {
+                    "description": "Total CPU energy usage(s) in kWh in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_gpu_emissions_gms": {
+                    "description": "Total GPU emissions from source(s) in grams in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_gpu_energy_usage_kwh": {
+                    "description": "Total GPU energy usage(s) in kWh in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_ingress_stats": {
+                    "description": "Total Ingress statistics in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_io_read_stats": {
+                    "description": "Total IO read statistics in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_io_write_stats": {
+                    "description": "Total IO write statistics in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_outgress_stats": {
+                    "description": "Total Outgress statistics in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "total_time_seconds": {
+                    "description": "Different times in seconds consumed in the bucket",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
+                "username": {
+                    "description": "Username. It is set only when usage is grouped by user",
+                    "type": "string"
+                }
+            }
+        },
         "models.User": {
             "type": "object",
             "properties": {
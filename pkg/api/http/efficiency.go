@@ -0,0 +1,482 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/efficiency"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Columns of units returned by efficiency endpoints.
+var efficiencyUnitFields = []string{
+	"cluster_id", "resource_manager", "uuid", "name", "project", "username",
+	"state", "started_at", "ended_at", "total_time_seconds", "efficiency",
+}
+
+// efficiencySort returns the score key used to sort results. Scores are sorted
+// in ascending order by default so that the least efficient units come first.
+func efficiencySort(r *http.Request) (pageQuery, error) {
+	page, err := getPageQuery(r.URL.Query())
+	if err != nil {
+		return pageQuery{}, err
+	}
+
+	if page.cursor != nil {
+		return pageQuery{}, errEfficiencyCursor
+	}
+
+	if page.sort == "" {
+		page.sort = efficiency.Score
+	}
+
+	if !slices.Contains(efficiency.Keys(), page.sort) {
+		return pageQuery{}, fmt.Errorf("%w: %s", errInvalidSortField, page.sort)
+	}
+
+	if !slices.Contains([]string{"asc", "desc"}, page.order) {
+		return pageQuery{}, errInvalidSortOrder
+	}
+
+	return page, nil
+}
+
+// unitsEfficiencyAdmin         godoc
+//
+//	@Summary		Admin endpoint for fetching efficiency scores of units
+//	@Description	This admin endpoint will return the efficiency scores of compute units of
+//	@Description	_any_ user. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	If `user` query parameter is provided, only units of these users are
+//	@Description	returned. See the user endpoint for the sorting and pagination of units.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			user			query		[]string	false	"User name"		collectionFormat(multi)
+//	@Param			running			query		bool		false	"Whether to fetch running units"
+//	@Param			sort			query		string		false	"Score to sort units"	Enums(cpu, cpu_mem, mem_over_request, gpu, gpu_mem, gpu_idle, wasted_energy_kwh, score)
+//	@Param			order			query		string		false	"Sort order"			Enums(asc, desc)
+//	@Param			limit			query		integer		false	"Maximum number of units"
+//	@Param			offset			query		integer		false	"Number of units to skip"
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Unit]
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		403				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/units/efficiency/admin [get]
+//
+// GET /units/efficiency/admin
+// Get efficiency scores of units of any user.
+func (s *CEEMSServer) unitsEfficiencyAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "units efficiency admin endpoint", s.logger)
+
+	s.unitsEfficiencyQuerier(r.URL.Query()["user"], nil, w, r)
+}
+
+// unitsEfficiency         godoc
+//
+//	@Summary		User endpoint for fetching efficiency scores of units
+//	@Description	This user endpoint will return the efficiency scores of compute units of
+//	@Description	the current user and of the projects coordinated by the current user. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in the
+//	@Description	request.
+//	@Description
+//	@Description	Scores are estimated during each update of the DB from the usage metrics
+//	@Description	of units. All scores except `wasted_energy_kwh` and `mem_over_request` are
+//	@Description	fractions between 0 and 1:
+//	@Description	- `cpu`: Average CPU usage relative to allocated CPUs.
+//	@Description	- `cpu_mem`: Average CPU memory usage relative to allocated memory.
+//	@Description	- `mem_over_request`: Ratio of allocated memory to used memory.
+//	@Description	- `gpu`: Average GPU usage relative to allocated GPUs.
+//	@Description	- `gpu_mem`: Average GPU memory usage relative to allocated GPU memory.
+//	@Description	- `gpu_idle`: Fraction of time allocated GPUs were idle.
+//	@Description	- `wasted_energy_kwh`: Energy consumed by idle allocations in kWh.
+//	@Description	- `score`: Overall efficiency of the unit.
+//	@Description
+//	@Description	Only units that have been scored are returned. Units are sorted by
+//	@Description	the score given in `sort` query parameter which defaults to `score`.
+//	@Description	The default `asc` order returns the least efficient units first. Use
+//	@Description	`limit` and `offset` query parameters to paginate the units.
+//	@Description
+//	@Description	If `to` query parameter is not provided, current time will be used. If `from`
+//	@Description	query parameter is not used, a default query window of 24 hours will be used.
+//	@Description	It means if `to` is provided, `from` will be calculated as `to` - 24hrs.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			running			query		bool		false	"Whether to fetch running units"
+//	@Param			sort			query		string		false	"Score to sort units"	Enums(cpu, cpu_mem, mem_over_request, gpu, gpu_mem, gpu_idle, wasted_energy_kwh, score)
+//	@Param			order			query		string		false	"Sort order"			Enums(asc, desc)
+//	@Param			limit			query		integer		false	"Maximum number of units"
+//	@Param			offset			query		integer		false	"Number of units to skip"
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Unit]
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/units/efficiency [get]
+//
+// GET /units/efficiency
+// Get efficiency scores of units of dashboard user.
+func (s *CEEMSServer) unitsEfficiency(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "units efficiency endpoint", s.logger)
+
+	// Get current logged user and dashboard user from headers
+	_, dashboardUser := s.getUser(r)
+
+	s.unitsEfficiencyQuerier([]string{dashboardUser}, []string{dashboardUser}, w, r)
+}
+
+// unitsEfficiencyQuerier returns efficiency scores of units of queried users and of
+// all users in the projects coordinated by coordinators.
+func (s *CEEMSServer) unitsEfficiencyQuerier(
+	queriedUsers []string,
+	coordinators []string,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	// Set headers
+	s.setHeaders(w)
+
+	// Get current logged user and dashboard user from headers
+	loggedUser, _ := s.getUser(r)
+
+	page, err := efficiencySort(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Check if running query param is included
+	_, running := r.URL.Query()["running"]
+
+	// Get query window time stamps
+	timeQuery, err := s.getQueryWindow(r, "ended_at", running, false)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Only scored units that are not ignored. Sub units are accounted in parents
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(efficiencyUnitFields, ","), base.UnitsDBTableName))
+	q.query(fmt.Sprintf(" WHERE ignore = 0 AND parent_uuid = '' AND json_extract(efficiency,'$.%s') IS NOT NULL", page.sort))
+
+	// Add condition to query only for current dashboardUser
+	if len(queriedUsers) > 0 {
+		q.query(" AND ")
+		q.subQuery(unitsOwnersQuery(queriedUsers, coordinators))
+	}
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	// Add time query as sub query to main query
+	q.query(" AND ")
+	q.subQuery(timeQuery)
+
+	// Sort field has been validated already and can be safely formatted into query
+	q.query(fmt.Sprintf(" ORDER BY json_extract(efficiency,'$.%s') %s, id ASC", page.sort, strings.ToUpper(page.order)))
+	q.page(page.limit, page.offset)
+
+	units, err := s.queriers.unit(r.Context(), s.db, q, s.logger)
+	if units == nil && err != nil {
+		s.logger.Error("Failed to fetch efficiency of units", "loggedUser", loggedUser, "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	response := Response[models.Unit]{
+		Status:     "success",
+		Data:       units,
+		Pagination: nextPage(page, units),
+	}
+	if err != nil {
+		response.Warnings = append(response.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// usageEfficiencyAdmin         godoc
+//
+//	@Summary		Admin endpoint for fetching efficiency of projects
+//	@Description	This admin endpoint will return the efficiency scores of _any_ project
+//	@Description	aggregated over its units. The current user is always identified by the
+//	@Description	header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	If `user` query parameter is provided, only projects of these users are
+//	@Description	returned. See the user endpoint for the aggregation of scores.
+//	@Security		BasicAuth
+//	@Tags			usage
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			mode			path		string		true	"Whether to get efficiency in a window or since the start"	Enums(current, global)
+//	@Param			cluster_id		query		[]string	false	"cluster ID"												collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"													collectionFormat(multi)
+//	@Param			user			query		[]string	false	"User name"													collectionFormat(multi)
+//	@Param			groupby			query		string		false	"Split efficiency of projects by users"						Enums(username)
+//	@Param			sort			query		string		false	"Score to sort projects"									Enums(cpu, cpu_mem, mem_over_request, gpu, gpu_mem, gpu_idle, wasted_energy_kwh, score)
+//	@Param			order			query		string		false	"Sort order"												Enums(asc, desc)
+//	@Param			limit			query		integer		false	"Maximum number of projects"
+//	@Param			offset			query		integer		false	"Number of projects to skip"
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Efficiency]
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		403				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/usage/{mode}/efficiency/admin [get]
+//
+// GET /usage/{mode}/efficiency/admin
+// Get efficiency of projects of any user.
+func (s *CEEMSServer) usageEfficiencyAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "usage efficiency admin endpoint", s.logger)
+
+	s.usageEfficiencyQuerier(r.URL.Query()["user"], w, r)
+}
+
+// usageEfficiency         godoc
+//
+//	@Summary		User endpoint for fetching efficiency of projects
+//	@Description	This user endpoint will return the efficiency scores of all the projects
+//	@Description	that the current user is part of aggregated over their units. The current
+//	@Description	user is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	A path parameter `mode` is required to return the kind of efficiency.
+//	@Description	Currently, two modes are supported:
+//	@Description	- `current`: In this mode the efficiency of units in the window given by
+//	@Description	`from` and `to` query parameters is returned. Running units are included.
+//	@Description	- `global`: In this mode the efficiency of all the units in DB is returned.
+//	@Description
+//	@Description	Scores of units are averaged weighting each unit by its allocated time
+//	@Description	and the wasted energy of units is summed. Efficiency of projects can be
+//	@Description	split by users using `groupby=username` query parameter to find the least
+//	@Description	efficient users in each project.
+//	@Description
+//	@Description	Results are sorted by the score given in `sort` query parameter which
+//	@Description	defaults to `score`. The default `asc` order returns the least efficient
+//	@Description	projects first. Use `limit` query parameter to return only the worst
+//	@Description	offenders.
+//	@Security		BasicAuth
+//	@Tags			usage
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			mode			path		string		true	"Whether to get efficiency in a window or since the start"	Enums(current, global)
+//	@Param			cluster_id		query		[]string	false	"cluster ID"												collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"													collectionFormat(multi)
+//	@Param			groupby			query		string		false	"Split efficiency of projects by users"						Enums(username)
+//	@Param			sort			query		string		false	"Score to sort projects"									Enums(cpu, cpu_mem, mem_over_request, gpu, gpu_mem, gpu_idle, wasted_energy_kwh, score)
+//	@Param			order			query		string		false	"Sort order"												Enums(asc, desc)
+//	@Param			limit			query		integer		false	"Maximum number of projects"
+//	@Param			offset			query		integer		false	"Number of projects to skip"
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Efficiency]
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/usage/{mode}/efficiency [get]
+//
+// GET /usage/{mode}/efficiency
+// Get efficiency of projects of dashboard user.
+func (s *CEEMSServer) usageEfficiency(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "usage efficiency endpoint", s.logger)
+
+	// Get current logged user and dashboard user from headers
+	_, dashboardUser := s.getUser(r)
+
+	s.usageEfficiencyQuerier([]string{dashboardUser}, w, r)
+}
+
+// usageEfficiencyQuerier aggregates efficiency scores of units of projects of users.
+func (s *CEEMSServer) usageEfficiencyQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	// Get path parameter mode
+	mode, exists := mux.Vars(r)["mode"]
+	if !exists {
+		errorResponse[any](w, &apiError{errorBadData, errInvalidRequest}, s.logger, nil)
+
+		return
+	}
+
+	page, err := efficiencySort(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	byUser := slices.Contains(r.URL.Query()["groupby"], "username")
+
+	// Only scored units that are not ignored. Sub units are accounted in parents
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(efficiencyUnitFields, ","), base.UnitsDBTableName))
+	q.query(" WHERE ignore = 0 AND parent_uuid = '' AND json_extract(efficiency,'$.score') IS NOT NULL")
+
+	// Users get efficiency of all the projects they are part of
+	if len(users) > 0 {
+		q.query(" AND (cluster_id, project) IN ")
+		q.subQuery(projectsSubQuery(users))
+	}
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	// Current efficiency includes running units as well
+	if mode == currentUsage {
+		timeQuery, err := s.getQueryWindow(r, "ended_at", true, false)
+		if err != nil {
+			errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+			return
+		}
+
+		q.query(" AND ")
+		q.subQuery(timeQuery)
+	}
+
+	// Aggregate scores of units in each group
+	type group struct {
+		efficiency models.Efficiency
+		agg        *efficiency.Aggregator
+	}
+
+	groups := make(map[string]*group)
+
+	if err := s.queriers.unitStream(r.Context(), s.db, q, s.logger, func(unit models.Unit) error {
+		key := unit.ClusterID + "/" + unit.Project
+
+		var user string
+		if byUser {
+			user = unit.User
+			key += "/" + user
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &group{
+				efficiency: models.Efficiency{
+					ClusterID:       unit.ClusterID,
+					ResourceManager: unit.ResourceManager,
+					Project:         unit.Project,
+					User:            user,
+					TotalTime:       make(models.MetricMap),
+				},
+				agg: efficiency.NewAggregator(),
+			}
+			groups[key] = g
+		}
+
+		g.efficiency.NumUnits++
+		g.agg.Add(unit.Efficiency, unit.TotalTime)
+
+		for k, v := range unit.TotalTime {
+			g.efficiency.TotalTime[k] += v
+		}
+
+		return nil
+	}); err != nil {
+		s.logger.Error("Failed to fetch efficiency of units", "users", strings.Join(users, ","), "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	rollups := make([]models.Efficiency, 0, len(groups))
+
+	for _, g := range groups {
+		g.efficiency.Efficiency = g.agg.Scores()
+		rollups = append(rollups, g.efficiency)
+	}
+
+	// Sort by requested score. Groups without the score are always at the end
+	slices.SortFunc(rollups, func(a, b models.Efficiency) int {
+		va, oka := a.Efficiency[page.sort]
+		vb, okb := b.Efficiency[page.sort]
+
+		switch {
+		case oka && !okb:
+			return -1
+		case !oka && okb:
+			return 1
+		}
+
+		c := cmp.Compare(va, vb)
+		if page.order == "desc" {
+			c = -c
+		}
+
+		return cmp.Or(
+			c,
+			cmp.Compare(a.ClusterID, b.ClusterID),
+			cmp.Compare(a.Project, b.Project),
+			cmp.Compare(a.User, b.User),
+		)
+	})
+
+	if page.offset > 0 {
+		rollups = rollups[min(page.offset, len(rollups)):]
+	}
+
+	if page.limit > 0 && len(rollups) > page.limit {
+		rollups = rollups[:page.limit]
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	response := Response[models.Efficiency]{
+		Status:     "success",
+		Data:       rollups,
+		Pagination: nextPage(page, rollups),
+	}
+
+	if err = json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
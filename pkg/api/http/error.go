@@ -54,11 +54,30 @@ const (
 // Custom errors.
 var (
 	errNoUser            = errors.New("no user identified")
+	errInvalidToken      = errors.New("invalid bearer token")
 	errNoPrivs           = errors.New("current user does not have admin privileges")
 	errInvalidRequest    = errors.New("invalid request")
 	errInvalidQueryField = errors.New("invalid query fields")
 	errMissingUUIDs      = errors.New("uuids missing in the request")
 	errNoAuth            = errors.New("user do not have permissions on uuids")
+	errInvalidPageParam  = errors.New("must be a non negative integer")
+	errInvalidCursor     = errors.New("invalid cursor")
+	errCursorOffset      = errors.New("cursor and offset query parameters are mutually exclusive")
+	errOffsetNoLimit     = errors.New("offset query parameter requires limit query parameter")
+	errCursorSortField   = errors.New("cursor pagination is only supported when sorting by id or ended_at_ts")
+	errInvalidFormat     = errors.New("invalid response format")
+	errTokenNotFound     = errors.New("token not found")
+	errTokenRevoked      = errors.New("token has been revoked")
+	errTokenExpired      = errors.New("token has expired")
+	errTokenLifetime     = errors.New("token lifetime must not exceed 365d")
+	errInvalidScope      = errors.New("invalid token scope")
+	errTokenScope        = errors.New("token does not have the scope to access the resource")
+	errAuditSort         = errors.New("audit records are always sorted by time and cursors are not supported")
+	errInvalidEventID    = errors.New("last event ID must be a non negative integer")
+	errEfficiencyCursor  = errors.New("cursors are not supported by efficiency endpoints")
+	errInvalidFilter     = errors.New("invalid filter")
+	errNoIngester        = errors.New("units ingestion is not enabled")
+	errInvalidUsageGroup = errors.New("invalid groupby. Allowed values are cluster_id, project, username, groupname, allocation.<key> and tags.<key>")
 )
 
 // Return error response for by setting errorString and errorType in response.
@@ -0,0 +1,266 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Last-Event-ID header sent by SSE clients when they reconnect.
+const lastEventIDHeader = "Last-Event-ID"
+
+// Maximum number of events fetched from DB in one poll.
+const eventsBatchSize = 500
+
+var (
+	// Interval at which DB is polled for new unit events. Events are only
+	// generated during DB updates and hence, there is no point in polling
+	// frequently.
+	eventsPollInterval = 5 * time.Second
+
+	// Interval at which comments are sent to keep idle connections alive
+	// through proxies.
+	eventsKeepAliveInterval = 30 * time.Second
+)
+
+// unitEventsAdmin   godoc
+//
+//	@Summary		Admin endpoint to stream unit lifecycle events
+//	@Description	This admin endpoint streams the lifecycle events of compute units of _any_
+//	@Description	user as Server-Sent Events. The current user is always identified by the
+//	@Description	header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	See the user endpoint for the format of events. Events can be restricted
+//	@Description	to units of certain users using `user` query parameter.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		text/event-stream
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			Last-Event-ID	header		integer		false	"ID of last received event"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			user			query		[]string	false	"User name"		collectionFormat(multi)
+//	@Param			type			query		[]string	false	"Event type"	collectionFormat(multi)
+//	@Param			last_event_id	query		integer		false	"ID of last received event"
+//	@Success		200				{object}	models.UnitEvent
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		403				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/units/events/admin [get]
+//
+// GET /units/events/admin
+// Stream events of units of any user.
+func (s *CEEMSServer) unitEventsAdmin(w http.ResponseWriter, r *http.Request) {
+	s.unitEventsStreamer(r.URL.Query()["user"], nil, w, r)
+}
+
+// unitEvents        godoc
+//
+//	@Summary		User endpoint to stream unit lifecycle events
+//	@Description	This user endpoint streams the lifecycle events of compute units of the
+//	@Description	current user and of the projects coordinated by the current user as
+//	@Description	Server-Sent Events. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	Events are generated when the DB is updated. The type of each event is
+//	@Description	one of `created`, `state_changed`, `ended` and `metrics_updated`, and its
+//	@Description	data is a JSON object with the unit identifiers and its current and previous
+//	@Description	states. Events can be restricted to certain types using `type` query parameter.
+//	@Description
+//	@Description	Every event has an increasing ID. A client that reconnects with the
+//	@Description	`Last-Event-ID` header (or `last_event_id` query parameter) receives all
+//	@Description	the events after that ID that are still retained in the DB. Without it,
+//	@Description	only new events are streamed.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		text/event-stream
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			Last-Event-ID	header		integer		false	"ID of last received event"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			type			query		[]string	false	"Event type"	collectionFormat(multi)
+//	@Param			last_event_id	query		integer		false	"ID of last received event"
+//	@Success		200				{object}	models.UnitEvent
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		403				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/units/events [get]
+//
+// GET /units/events
+// Stream events of units of dashboard user.
+func (s *CEEMSServer) unitEvents(w http.ResponseWriter, r *http.Request) {
+	// Get current logged user and dashboard user from headers
+	_, dashboardUser := s.getUser(r)
+
+	s.unitEventsStreamer([]string{dashboardUser}, []string{dashboardUser}, w, r)
+}
+
+// unitEventsStreamer streams events of units of queried users and of all
+// users in the projects coordinated by coordinators until the client
+// disconnects or server shuts down.
+func (s *CEEMSServer) unitEventsStreamer(
+	queriedUsers []string,
+	coordinators []string,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	// Get current logged user and dashboard user from headers
+	loggedUser, _ := s.getUser(r)
+
+	// Resume from last event ID if client sends one
+	lastID := int64(-1)
+
+	if v := r.Header.Get(lastEventIDHeader); v != "" || r.URL.Query().Has("last_event_id") {
+		if v == "" {
+			v = r.URL.Query().Get("last_event_id")
+		}
+
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			s.setHeaders(w)
+			errorResponse[any](w, &apiError{errorBadData, errInvalidEventID}, s.logger, nil)
+
+			return
+		}
+
+		lastID = id
+	}
+
+	// Start from the most recent event when client does not send an ID or
+	// when the ID is unknown, eg, after DB has been restored
+	var maxID int64
+	if err := s.db.QueryRowContext(
+		r.Context(), "SELECT COALESCE(MAX(id),0) FROM "+base.UnitEventsDBTableName,
+	).Scan(&maxID); err != nil {
+		s.logger.Error("Failed to fetch last unit event", "loggedUser", loggedUser, "err", err)
+		s.setHeaders(w)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	if lastID < 0 || lastID > maxID {
+		lastID = maxID
+	}
+
+	// Stream is long lived. Remove the deadlines set by the server
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.Error("Failed to reset write deadline", "err", err)
+	}
+
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		s.logger.Error("Failed to reset read deadline", "err", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable buffering in nginx
+	w.WriteHeader(http.StatusOK)
+
+	// Ask clients to reconnect after poll interval
+	fmt.Fprintf(w, "retry: %d\n\n", eventsPollInterval.Milliseconds())
+
+	if err := rc.Flush(); err != nil {
+		s.logger.Error("Failed to flush unit events", "err", err)
+
+		return
+	}
+
+	ticker := time.NewTicker(eventsPollInterval)
+	defer ticker.Stop()
+
+	lastWrite := time.Now()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		events, err := s.queriers.event(r.Context(), s.db, eventsQuery(lastID, queriedUsers, coordinators, r), s.logger)
+		if err != nil {
+			s.logger.Error("Failed to fetch unit events", "loggedUser", loggedUser, "err", err)
+
+			return
+		}
+
+		for _, event := range events {
+			if err := writeEvent(w, event); err != nil {
+				s.logger.Error("Failed to encode unit event", "id", event.ID, "err", err)
+			}
+
+			lastID = event.ID
+		}
+
+		switch {
+		case len(events) > 0:
+		case time.Since(lastWrite) >= eventsKeepAliveInterval:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		default:
+			continue
+		}
+
+		// Client has gone away when flush fails
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		lastWrite = time.Now()
+	}
+}
+
+// writeEvent writes unit event in SSE format.
+func writeEvent(w http.ResponseWriter, event models.UnitEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+
+	return err
+}
+
+// eventsQuery returns query that fetches unit events after lastID.
+func eventsQuery(lastID int64, queriedUsers []string, coordinators []string, r *http.Request) Query {
+	q := Query{}
+	q.query("SELECT * FROM " + base.UnitEventsDBTableName + " WHERE id > ")
+	q.param([]string{strconv.FormatInt(lastID, 10)})
+
+	// Add condition to query only for current dashboardUser
+	if len(queriedUsers) > 0 {
+		q.query(" AND ")
+		q.subQuery(unitsOwnersQuery(queriedUsers, coordinators))
+	}
+
+	if clusterIDs := r.URL.Query()["cluster_id"]; len(clusterIDs) > 0 {
+		q.query(" AND cluster_id IN ")
+		q.param(clusterIDs)
+	}
+
+	if types := r.URL.Query()["type"]; len(types) > 0 {
+		q.query(" AND type IN ")
+		q.param(types)
+	}
+
+	q.query(" ORDER BY id ASC")
+	q.page(eventsBatchSize, 0)
+
+	return q
+}
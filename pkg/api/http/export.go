@@ -0,0 +1,273 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Response formats.
+const (
+	jsonFormat   = "json"
+	csvFormat    = "csv"
+	ndjsonFormat = "ndjson"
+)
+
+// Content types of response formats.
+var formatContentTypes = map[string]string{
+	jsonFormat:   "application/json",
+	csvFormat:    "text/csv",
+	ndjsonFormat: "application/x-ndjson",
+}
+
+// responseFormat returns the format of response requested by the client. Query
+// parameter `format` takes precedence over `Accept` header. JSON is returned when
+// neither of them requests a supported format.
+func responseFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := formatContentTypes[format]; !ok {
+			return "", fmt.Errorf("%w: %s", errInvalidFormat, format)
+		}
+
+		return format, nil
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+
+		switch mediaType {
+		case "text/csv":
+			return csvFormat, nil
+		case "application/x-ndjson", "application/ndjson":
+			return ndjsonFormat, nil
+		case "application/json":
+			return jsonFormat, nil
+		}
+	}
+
+	return jsonFormat, nil
+}
+
+// setExportHeaders sets headers of export response of given format.
+func setExportHeaders(w http.ResponseWriter, format string, name string) {
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+}
+
+// csvColumn is a column in CSV export. Map fields are flattened into one
+// column per key.
+type csvColumn struct {
+	name  string
+	index int
+	key   string
+	isMap bool
+}
+
+// csvColumns returns the columns of CSV export of values. Only fields whose
+// JSON names are in fields are included. If fields is empty, all fields are
+// included.
+func csvColumns[T any](values []T, fields []string) []csvColumn {
+	var columns []csvColumn
+
+	typ := reflect.TypeOf(*new(T))
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := range typ.NumField() {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if len(fields) > 0 && !slices.Contains(fields, name) {
+			continue
+		}
+
+		if typ.Field(i).Type.Kind() != reflect.Map {
+			columns = append(columns, csvColumn{name: name, index: i})
+
+			continue
+		}
+
+		// Get all the keys of map across all values
+		keys := make(map[string]bool)
+
+		for _, v := range values {
+			iter := reflect.ValueOf(v).Field(i).MapRange()
+			for iter.Next() {
+				keys[iter.Key().String()] = true
+			}
+		}
+
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+
+		slices.Sort(sortedKeys)
+
+		for _, key := range sortedKeys {
+			columns = append(columns, csvColumn{name: name + "." + key, index: i, key: key, isMap: true})
+		}
+	}
+
+	return columns
+}
+
+// csvValue returns string representation of value in CSV.
+func csvValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	default:
+		// Lists and nested values are encoded as JSON
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return ""
+		}
+
+		return string(b)
+	}
+}
+
+// writeCSV writes values in CSV format with map fields flattened into
+// `<field>.<key>` columns.
+func writeCSV[T any](w http.ResponseWriter, name string, fields []string, values []T, logger *slog.Logger) {
+	setExportHeaders(w, csvFormat, name)
+	w.WriteHeader(http.StatusOK)
+
+	columns := csvColumns(values, fields)
+
+	writer := csv.NewWriter(w)
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.name
+	}
+
+	if err := writer.Write(record); err != nil {
+		logger.Error("Failed to write CSV response", "err", err)
+
+		return
+	}
+
+	for _, value := range values {
+		v := reflect.ValueOf(value)
+
+		for i, column := range columns {
+			if column.isMap {
+				record[i] = csvValue(v.Field(column.index).MapIndex(reflect.ValueOf(column.key)))
+			} else {
+				record[i] = csvValue(v.Field(column.index))
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			logger.Error("Failed to write CSV response", "err", err)
+
+			return
+		}
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		logger.Error("Failed to write CSV response", "err", err)
+	}
+}
+
+// writeNDJSON writes values in newline delimited JSON format.
+func writeNDJSON[T any](w http.ResponseWriter, name string, values []T, logger *slog.Logger) {
+	setExportHeaders(w, ndjsonFormat, name)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+
+	for _, value := range values {
+		if err := encoder.Encode(value); err != nil {
+			logger.Error("Failed to write NDJSON response", "err", err)
+
+			return
+		}
+	}
+}
+
+// writeExport writes values in CSV or NDJSON format and returns true. For JSON
+// format, nothing is written and false is returned so that caller writes the
+// standard JSON response.
+func writeExport[T any](
+	w http.ResponseWriter,
+	format string,
+	name string,
+	fields []string,
+	values []T,
+	logger *slog.Logger,
+) bool {
+	switch format {
+	case csvFormat:
+		writeCSV(w, name, fields, values, logger)
+	case ndjsonFormat:
+		writeNDJSON(w, name, values, logger)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// streamUnits writes units in NDJSON format as they are read from DB rows.
+func (s *CEEMSServer) streamUnits(q Query, w http.ResponseWriter, r *http.Request) {
+	setExportHeaders(w, ndjsonFormat, unitsResourceName)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	tz := r.URL.Query().Get("timezone")
+
+	var numUnits int
+
+	if err := s.queriers.unitStream(r.Context(), s.db, q, s.logger, func(unit models.Unit) error {
+		numUnits++
+
+		return encoder.Encode(s.inTargetTimeLocation(tz, []models.Unit{unit})[0])
+	}); err != nil {
+		// Headers have already been sent. We can only log the error
+		s.logger.Error("Failed to stream units", "num_units", numUnits, "err", err)
+	}
+}
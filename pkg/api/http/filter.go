@@ -0,0 +1,182 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Filters are of form <column>.<key><op><value>, eg, avg_gpu_usage.global<10.
+// Keys can contain alphanumeric characters, underscores, dots, slashes and
+// hyphens, eg, allocation.gres/gpu. Quotes are not allowed so that keys can be
+// safely formatted into quoted JSON paths.
+var filterRegexp = regexp.MustCompile(`^([a-z_]+)\.([A-Za-z0-9_./-]+)(==|!=|<=|>=|<|>)(.+)$`)
+
+// SQL operators of filter operators.
+var filterOps = map[string]string{
+	"==": "=",
+	"!=": "!=",
+	"<=": "<=",
+	">=": ">=",
+	"<":  "<",
+	">":  ">",
+}
+
+// Expressions that extract a JSON value as text in each SQL dialect. Keys are
+// quoted in JSON path as keys of usage dimensions contain dots.
+var jsonTextFuncs = map[string]string{
+	base.SQLite:   `json_extract(%[1]s,'$."%[2]s"')`,
+	base.Postgres: "%[1]s ->> '%[2]s'",
+}
+
+// Expressions that extract a JSON value as number in each SQL dialect.
+var jsonNumberFuncs = map[string]string{
+	base.SQLite:   `json_extract(%[1]s,'$."%[2]s"')`,
+	base.Postgres: "CAST(%[1]s ->> '%[2]s' AS DOUBLE PRECISION)",
+}
+
+// unitFilterColumns are the map columns of units that can be filtered on.
+var unitFilterColumns = mapColumns(models.Unit{})
+
+// filter is a predicate on the value of a key in a map column.
+type filter struct {
+	column  string
+	key     string
+	op      string
+	value   string
+	numeric bool
+}
+
+// Add a filter predicate to builder. Column and key are validated while
+// parsing filters and hence, they can be safely formatted into query.
+func (q *Query) filter(f filter, dialect string) {
+	if f.numeric {
+		q.query(fmt.Sprintf(" AND %s %s CAST(", fmt.Sprintf(jsonNumberFuncs[dialect], f.column, f.key), f.op))
+		q.param([]string{f.value})
+		q.query(" AS DOUBLE PRECISION) ")
+
+		return
+	}
+
+	q.query(fmt.Sprintf(" AND %s %s ", fmt.Sprintf(jsonTextFuncs[dialect], f.column, f.key), f.op))
+	q.param([]string{f.value})
+}
+
+// mapColumns returns the DB columns of model whose values are JSON maps.
+func mapColumns(model any) []string {
+	var columns []string
+
+	mapTypes := []reflect.Type{
+		reflect.TypeFor[models.MetricMap](),
+		reflect.TypeFor[models.Allocation](),
+		reflect.TypeFor[models.Tag](),
+	}
+
+	typ := reflect.TypeOf(model)
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if slices.Contains(mapTypes, field.Type) {
+			columns = append(columns, field.Tag.Get("sql"))
+		}
+	}
+
+	return columns
+}
+
+// parseFilters parses filter query parameters. Comparison operators require
+// numeric values and equality operators compare numerically when the value
+// is a number.
+func parseFilters(values []string, validColumns []string) ([]filter, error) {
+	filters := make([]filter, len(values))
+
+	for i, value := range values {
+		matches := filterRegexp.FindStringSubmatch(value)
+		if matches == nil {
+			return nil, fmt.Errorf("%w: %s", errInvalidFilter, value)
+		}
+
+		if !slices.Contains(validColumns, matches[1]) {
+			return nil, fmt.Errorf("%w: unknown field %s", errInvalidFilter, matches[1])
+		}
+
+		_, err := strconv.ParseFloat(matches[4], 64)
+		numeric := err == nil
+
+		if !numeric && matches[3] != "==" && matches[3] != "!=" {
+			return nil, fmt.Errorf("%w: %s requires a numeric value", errInvalidFilter, matches[3])
+		}
+
+		filters[i] = filter{
+			column:  matches[1],
+			key:     matches[2],
+			op:      filterOps[matches[3]],
+			value:   matches[4],
+			numeric: numeric,
+		}
+	}
+
+	return filters, nil
+}
+
+// unitFilters parses filter query parameters of request and checks that
+// the keys exist in units.
+func (s *CEEMSServer) unitFilters(r *http.Request) ([]filter, error) {
+	filters, err := parseFilters(r.URL.Query()["filter"], unitFilterColumns)
+	if err != nil || len(filters) == 0 {
+		return nil, err
+	}
+
+	// Keys are fetched only once for each column
+	keys := make(map[string][]string)
+
+	for _, f := range filters {
+		if _, ok := keys[f.column]; !ok {
+			if keys[f.column], err = s.jsonKeys(r, base.UnitsDBTableName, f.column); err != nil {
+				return nil, err
+			}
+		}
+
+		if !slices.Contains(keys[f.column], f.key) {
+			return nil, fmt.Errorf("%w: unknown key %s of field %s", errInvalidFilter, f.key, f.column)
+		}
+	}
+
+	return filters, nil
+}
+
+// jsonKeys returns the distinct keys of JSON map column of table. Keys are
+// cached as fetching them requires a scan of the whole table.
+func (s *CEEMSServer) jsonKeys(r *http.Request, table string, column string) ([]string, error) {
+	cacheKey := table + "." + column
+	if item := s.keysCache.Get(cacheKey); item != nil {
+		return item.Value(), nil
+	}
+
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT DISTINCT json_each.key AS name FROM %s AS u, json_each(%s)", table, column))
+	q.query(" WHERE json_each.key IS NOT NULL ")
+
+	keys, err := s.queriers.key(r.Context(), s.db, q, s.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.Name
+	}
+
+	s.keysCache.Set(cacheKey, names, ttlcache.DefaultTTL)
+
+	return names, nil
+}
@@ -0,0 +1,117 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+)
+
+// Usage can be grouped by attributes of allocation and tags of units, eg,
+// allocation.partition or tags.qos.
+var dimensionRegexp = regexp.MustCompile(`^(allocation|tags)\.([A-Za-z0-9_]+)$`)
+
+// Columns of usage tables that usage can be grouped by.
+var usageGroupByColumns = []string{"cluster_id", "groupname", "project", "username"}
+
+// dimension is an attribute of allocation or tags of units.
+type dimension struct {
+	name string // Name of dimension, eg, allocation.partition
+	expr string // SQL expression of value of dimension
+}
+
+// usageGroupBy contains the columns and dimensions that usage is grouped by.
+type usageGroupBy struct {
+	columns    []string
+	dimensions []dimension
+}
+
+// clause returns the expressions of GROUP BY clause.
+func (g usageGroupBy) clause() []string {
+	exprs := slices.Clone(g.columns)
+	for _, d := range g.dimensions {
+		exprs = append(exprs, d.expr)
+	}
+
+	return exprs
+}
+
+// selectQuery returns the query that selects values of dimensions as a JSON object.
+func (g usageGroupBy) selectQuery(dialect string) string {
+	if len(g.dimensions) == 0 {
+		return "NULL AS dimensions"
+	}
+
+	args := make([]string, len(g.dimensions))
+	for i, d := range g.dimensions {
+		args[i] = fmt.Sprintf("'%s',%s", d.name, d.expr)
+	}
+
+	return fmt.Sprintf("%s(%s) AS dimensions", jsonObjectFuncs[dialect], strings.Join(args, ","))
+}
+
+// usageGroupBy parses groupby query parameters of request. Usage is always
+// grouped by username and project. Dimensions are read from allocation and
+// tags of units and from persisted dimensions of daily usage.
+func (s *CEEMSServer) usageGroupBy(r *http.Request, table string) (usageGroupBy, error) {
+	groupBy := usageGroupBy{columns: []string{"username", "project"}}
+
+	// Keys of each JSON column are fetched only once
+	keys := make(map[string][]string)
+
+	dialect := base.Dialect(s.db)
+
+	for _, value := range r.URL.Query()["groupby"] {
+		if value == "" {
+			continue
+		}
+
+		if slices.Contains(usageGroupByColumns, value) {
+			groupBy.columns = append(groupBy.columns, value)
+
+			continue
+		}
+
+		matches := dimensionRegexp.FindStringSubmatch(value)
+		if matches == nil {
+			return usageGroupBy{}, fmt.Errorf("%w: %s", errInvalidUsageGroup, value)
+		}
+
+		// Daily usage has the values of dimensions that are configured to be
+		// persisted
+		column, key := matches[1], matches[2]
+		if table == base.DailyUsageDBTableName {
+			column, key = "dimensions", value
+		}
+
+		if _, ok := keys[column]; !ok {
+			var err error
+			if keys[column], err = s.jsonKeys(r, table, column); err != nil {
+				return usageGroupBy{}, err
+			}
+		}
+
+		if !slices.Contains(keys[column], key) {
+			return usageGroupBy{}, fmt.Errorf("%w: unknown dimension %s", errInvalidUsageGroup, value)
+		}
+
+		if !slices.ContainsFunc(groupBy.dimensions, func(d dimension) bool { return d.name == value }) {
+			groupBy.dimensions = append(groupBy.dimensions, dimension{
+				name: value,
+				expr: fmt.Sprintf(jsonTextFuncs[dialect], column, key),
+			})
+		}
+	}
+
+	// Remove duplicates values
+	slices.Sort(groupBy.columns)
+	groupBy.columns = slices.Compact(groupBy.columns)
+
+	return groupBy, nil
+}
@@ -0,0 +1,285 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Maximum size of request body of ingest endpoint.
+const maxIngestRequestSize = 32 << 20
+
+// Ingester is the interface that inserts compute units pushed by clients
+// into DB.
+type Ingester interface {
+	// Ingest inserts units, users and projects of cluster into DB
+	Ingest(
+		ctx context.Context,
+		clusterID string,
+		units []models.Unit,
+		users []models.User,
+		projects []models.Project,
+	) error
+}
+
+// IngestRequest is the request body to push compute units of a cluster.
+type IngestRequest struct {
+	ClusterID string            `json:"cluster_id"`                          // ID of cluster that is configured with push manager
+	Units     []json.RawMessage `json:"units"    swaggertype:"array,object"` // Compute units in the format of units endpoint
+	Users     []json.RawMessage `json:"users"    swaggertype:"array,object"` // Users in the format of users endpoint
+	Projects  []json.RawMessage `json:"projects" swaggertype:"array,object"` // Projects in the format of projects endpoint
+}
+
+// modelField is a field of model that can be set in ingest requests.
+type modelField struct {
+	kind       reflect.Kind
+	sqliteType string
+}
+
+// Fields of models keyed by their JSON names.
+var (
+	unitFields    = modelFields(models.Unit{})
+	userFields    = modelFields(models.User{})
+	projectFields = modelFields(models.Project{})
+)
+
+// modelFields returns the fields of model that are exposed in JSON.
+func modelFields(model any) map[string]modelField {
+	fields := make(map[string]modelField)
+
+	typ := reflect.TypeOf(model)
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		sqliteType, _, _ := strings.Cut(field.Tag.Get("sqlitetype"), " ")
+		fields[name] = modelField{kind: field.Type.Kind(), sqliteType: sqliteType}
+	}
+
+	return fields
+}
+
+// validate returns an error if value cannot be stored in field.
+func (f modelField) validate(value json.RawMessage) error {
+	value = bytes.TrimSpace(value)
+
+	// Unset values are stored as defaults
+	if len(value) == 0 || string(value) == "null" {
+		return nil
+	}
+
+	switch f.sqliteType {
+	case "integer":
+		if _, err := strconv.ParseInt(string(value), 10, 64); err != nil {
+			return errors.New("must be an integer")
+		}
+	case "real":
+		if _, err := strconv.ParseFloat(string(value), 64); err != nil {
+			return errors.New("must be a number")
+		}
+	case "text":
+		switch f.kind { //nolint:exhaustive
+		case reflect.String:
+			if value[0] != '"' {
+				return errors.New("must be a string")
+			}
+		case reflect.Map:
+			if value[0] != '{' {
+				return errors.New("must be an object")
+			}
+		case reflect.Slice:
+			if value[0] != '[' {
+				return errors.New("must be an array")
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeModel validates the fields of raw against the types of columns of
+// model and decodes it. Required fields must be non empty.
+func decodeModel[T any](raw json.RawMessage, fields map[string]modelField, required string) (T, error) {
+	var model T
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return model, errors.New("must be an object")
+	}
+
+	for name, value := range values {
+		field, ok := fields[name]
+		if !ok {
+			return model, fmt.Errorf("unknown field %s", name)
+		}
+
+		if err := field.validate(value); err != nil {
+			return model, fmt.Errorf("field %s %w", name, err)
+		}
+	}
+
+	if err := json.Unmarshal(raw, &model); err != nil {
+		return model, err
+	}
+
+	if v, ok := values[required]; !ok || string(v) == `""` || string(v) == "null" {
+		return model, fmt.Errorf("field %s is required", required)
+	}
+
+	return model, nil
+}
+
+// decodeModels decodes all raw messages into models.
+func decodeModels[T any](kind string, raws []json.RawMessage, fields map[string]modelField, required string) ([]T, error) {
+	decoded := make([]T, len(raws))
+
+	for i, raw := range raws {
+		var err error
+		if decoded[i], err = decodeModel[T](raw, fields, required); err != nil {
+			return nil, fmt.Errorf("%w: %s[%d]: %w", errInvalidRequest, kind, i, err)
+		}
+	}
+
+	return decoded, nil
+}
+
+// decodeIngestRequest validates the ingest request and decodes units, users
+// and projects.
+func decodeIngestRequest(req IngestRequest) ([]models.Unit, []models.User, []models.Project, error) {
+	if req.ClusterID == "" {
+		return nil, nil, nil, fmt.Errorf("%w: cluster_id is required", errInvalidRequest)
+	}
+
+	units, err := decodeModels[models.Unit]("units", req.Units, unitFields, "uuid")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for i, unit := range units {
+		if unit.ClusterID != "" && unit.ClusterID != req.ClusterID {
+			return nil, nil, nil, fmt.Errorf("%w: units[%d]: cluster_id %s does not match request", errInvalidRequest, i, unit.ClusterID)
+		}
+	}
+
+	users, err := decodeModels[models.User]("users", req.Users, userFields, "name")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	projects, err := decodeModels[models.Project]("projects", req.Projects, projectFields, "name")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return units, users, projects, nil
+}
+
+// ingestUnits         godoc
+//
+//	@Summary		Admin endpoint to push compute units
+//	@Description	This admin endpoint will insert compute units, users and projects
+//	@Description	of a cluster that is configured with `push` manager. The current user is
+//	@Description	always retrieved from the header `X-Grafana-User` or bearer token and
+//	@Description	API tokens must have `units` and `admin` scopes.
+//	@Description
+//	@Description	Units, users and projects must be in the same format as the ones
+//	@Description	returned by units, users and projects endpoints. Fields are validated
+//	@Description	against the types of DB columns and unknown fields are rejected.
+//	@Description	`uuid` of units and `name` of users and projects are required.
+//	@Description
+//	@Description	Units are updated with metrics from TSDB, billed and scored like the
+//	@Description	units fetched from other resource managers. Aggregate metrics of units
+//	@Description	must be the ones since the last push as they are added to the existing
+//	@Description	values of units and usage.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		units
+//	@Accept		json
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string			true	"Current user name"
+//	@Param		body			body		IngestRequest	true	"Units, users and projects of cluster"
+//	@Success	200				{object}	Response[models.IngestStats]
+//	@Failure	400				{object}	Response[any]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	403				{object}	Response[any]
+//	@Failure	404				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/units/ingest [post]
+//
+// POST /units/ingest
+// Push compute units of a cluster.
+func (s *CEEMSServer) ingestUnits(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "units ingest endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	if s.ingester == nil {
+		errorResponse[any](w, &apiError{errorNotFound, errNoIngester}, s.logger, nil)
+
+		return
+	}
+
+	// Middleware sets admin header only for authenticated admin users. Check
+	// it here as well so that units are never pushed by any other principal
+	if r.Header.Get(adminUserHeader) == "" {
+		errorResponse[any](w, &apiError{errorForbidden, errNoPrivs}, s.logger, nil)
+
+		return
+	}
+
+	var req IngestRequest
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIngestRequestSize)).Decode(&req); err != nil {
+		errorResponse[any](w, &apiError{errorBadData, fmt.Errorf("%w: %w", errInvalidRequest, err)}, s.logger, nil)
+
+		return
+	}
+
+	units, users, projects, err := decodeIngestRequest(req)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	if err := s.ingester.Ingest(r.Context(), req.ClusterID, units, users, projects); err != nil {
+		s.logger.Error("Failed to ingest units", "cluster_id", req.ClusterID, "err", err)
+
+		if errors.Is(err, db.ErrPushCluster) {
+			errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+		} else {
+			errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		}
+
+		return
+	}
+
+	response := Response[models.IngestStats]{
+		Status: "success",
+		Data:   []models.IngestStats{{Units: len(units), Users: len(users), Projects: len(projects)}},
+	}
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
@@ -5,6 +5,7 @@ package http
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"log/slog"
 	"net/http"
@@ -12,6 +13,10 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/audit"
+	"github.com/mahendrapaipuri/ceems/internal/oidc"
 )
 
 // Headers.
@@ -23,6 +28,11 @@ const (
 	ceemsUserHeader     = "X-Ceems-User" // Special header that will be included in requests from CEEMS LB
 )
 
+// User recorded in audit log for requests from CEEMS LB.
+const (
+	ceemsLBUser = "ceems_lb"
+)
+
 // Debug end point regex match.
 var (
 	debugEndpoints = regexp.MustCompile("/debug/(.*)")
@@ -35,17 +45,115 @@ type authenticationMiddleware struct {
 	whitelistedURLs *regexp.Regexp
 	db              *sql.DB
 	adminUsers      func(context.Context, *sql.DB, *slog.Logger) []string
+	verifier        *oidc.Verifier
+	tokens          *apiTokens
+	audit           *audit.Logger
+	lbSecret        string // Shared secret that CEEMS LB sends in X-Ceems-User header
+}
+
+// principal is the user identified in the request.
+type principal struct {
+	user   string
+	admin  bool     // True if user is admin based on the groups in bearer token
+	scopes []string // Scopes of API token. Nil if request is not authenticated by API token
+}
+
+// authenticate returns the user making the request. Personal API tokens are
+// always accepted. When OIDC is enabled, user is identified from bearer token
+// and Grafana user header is used only when request does not have a bearer
+// token and header auth fallback is allowed.
+func (amw *authenticationMiddleware) authenticate(r *http.Request) (principal, error) {
+	token, tokenErr := oidc.BearerToken(r)
+
+	// API tokens are identified by their prefix
+	if tokenErr == nil && amw.tokens != nil && strings.HasPrefix(token, apiTokenPrefix) {
+		user, scopes, err := amw.tokens.verify(r.Context(), token)
+		if err != nil {
+			amw.logger.Error("Failed to verify API token", "url", r.URL, "err", err)
+
+			return principal{}, errInvalidToken
+		}
+
+		return principal{user: user, scopes: scopes}, nil
+	}
+
+	if amw.verifier != nil {
+		if tokenErr == nil {
+			identity, err := amw.verifier.Verify(r.Context(), token)
+			if err != nil {
+				amw.logger.Error("Failed to verify bearer token", "url", r.URL, "err", err)
+
+				return principal{}, errInvalidToken
+			}
+
+			return principal{user: identity.Username, admin: identity.Admin}, nil
+		}
+
+		if !amw.verifier.HeaderAuth() {
+			amw.logger.Error("Bearer token not found. Denying authentication")
+
+			return principal{}, errNoUser
+		}
+	}
+
+	// Check if username header is available
+	if loggedUser := r.Header.Get(grafanaUserHeader); loggedUser != "" {
+		return principal{user: loggedUser}, nil
+	}
+
+	amw.logger.Error("Grafana user Header not found. Denying authentication")
+
+	return principal{}, errNoUser
+}
+
+// adminEndpoint returns true if requested URI can only be accessed by admin
+// users. Besides the endpoints with admin suffix, pushing units is restricted
+// to admin users.
+func adminEndpoint(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.Path, "admin") || strings.HasSuffix(r.URL.Path, "/"+unitsResourceName+"/ingest")
+}
+
+// fromLB returns true if request is a read only request from CEEMS LB, ie,
+// it carries X-Ceems-User header with the shared secret. Header is never
+// trusted when no secret is configured.
+func (amw *authenticationMiddleware) fromLB(r *http.Request) bool {
+	secret := r.Header.Get(ceemsUserHeader)
+	if amw.lbSecret == "" || secret == "" || r.Method != http.MethodGet {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(amw.lbSecret)) == 1
+}
+
+// checkScopes returns an error if the requested resource is not in scopes of
+// API token. API tokens cannot be used to manage tokens.
+func (amw *authenticationMiddleware) checkScopes(r *http.Request, scopes []string) error {
+	resource, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, amw.routerPrefix), "/")
+
+	if resource == tokensResourceName || !slices.Contains(scopes, resource) {
+		return errTokenScope
+	}
+
+	if adminEndpoint(r) && !slices.Contains(scopes, adminScope) {
+		return errTokenScope
+	}
+
+	return nil
 }
 
 // Middleware function, which will be called for each request.
 func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var loggedUser string
+		var p principal
 
 		var admUsers []string
 
 		var q url.Values
 
+		var rw *audit.ResponseWriter
+
+		var err error
+
 		// If requested URI is one of the following, skip checking for user header
 		//  - /
 		//  - /health endpoint
@@ -63,61 +171,87 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 			goto end
 		}
 
-		// If request has "special" CEEMS header, pass through. It must be
-		// coming from other CEEMS components
-		if _, ok := r.Header[ceemsUserHeader]; ok {
-			goto end
+		// Record the response of request in audit log
+		if amw.audit != nil {
+			rw = audit.NewResponseWriter(w)
+			w = rw
+
+			defer amw.record(rw, r, time.Now())
 		}
 
 		// Remove any X-Admin-User header or X-Logged-User if passed
 		r.Header.Del(adminUserHeader)
 		r.Header.Del(loggedUserHeader)
 
-		// Check if username header is available
-		loggedUser = r.Header.Get(grafanaUserHeader)
-		if loggedUser == "" {
-			amw.logger.Error("Grafana user Header not found. Denying authentication")
+		// If request has "special" CEEMS header with shared secret, pass through.
+		// It must be coming from CEEMS LB. Replace the secret so that it does
+		// not end up in audit log
+		if amw.fromLB(r) {
+			r.Header.Set(ceemsUserHeader, ceemsLBUser)
+
+			goto end
+		}
+
+		r.Header.Del(ceemsUserHeader)
 
+		// Identify user from bearer token or Grafana user header
+		p, err = amw.authenticate(r)
+		if err != nil {
 			// Write an error and stop the handler chain
-			errorResponse[any](w, &apiError{errorUnauthorized, errNoUser}, amw.logger, nil)
+			errorResponse[any](w, &apiError{errorUnauthorized, err}, amw.logger, nil)
 
 			return
 		}
 
-		amw.logger.Info("middleware", "loggedUser", loggedUser, "url", r.URL)
+		amw.logger.Info("middleware", "loggedUser", p.user, "url", r.URL)
+
+		// Requests authenticated by API tokens can only access resources in
+		// the scopes of token
+		if p.scopes != nil {
+			if err = amw.checkScopes(r, p.scopes); err != nil {
+				amw.logger.Error("API token accessing resource out of its scopes", "user", p.user, "url", r.URL)
+
+				// Write an error and stop the handler chain
+				errorResponse[any](w, &apiError{errorForbidden, err}, amw.logger, nil)
+
+				return
+			}
+		}
 
 		// Set logged user header
-		r.Header.Set(loggedUserHeader, loggedUser)
+		r.Header.Set(loggedUserHeader, p.user)
 
 		// Set user in URL query as well as we will use it as key for caching
 		q = r.URL.Query()
-		q.Add("logged_user", loggedUser)
+		q.Add("logged_user", p.user)
 		r.URL.RawQuery = q.Encode()
 
 		// Fetch admin users from DB
 		admUsers = amw.adminUsers(r.Context(), amw.db, amw.logger)
 
-		// If current user is in list of admin users, get "actual" user from
-		// X-Dashboard-User header. For normal users, this header will be exactly same
-		// as their username.
+		// If current user is in list of admin users or in one of admin groups of
+		// bearer token, get "actual" user from X-Dashboard-User header. For normal
+		// users, this header will be exactly same as their username.
 		// For admin users who can look at dashboard of "any" user this will be the
-		// username of the "impersonated" user and we take it into account
-		if slices.Contains(admUsers, loggedUser) {
+		// username of the "impersonated" user and we take it into account.
+		// API tokens of admin users without admin scope are treated like tokens
+		// of normal users
+		if (p.scopes == nil || slices.Contains(p.scopes, adminScope)) && (p.admin || slices.Contains(admUsers, p.user)) {
 			// Set X-Admin-User header
-			r.Header.Set(adminUserHeader, loggedUser)
+			r.Header.Set(adminUserHeader, p.user)
 
 			if dashboardUser := r.Header.Get(dashboardUserHeader); dashboardUser != "" {
 				amw.logger.Info(
-					"Admin user accessing dashboards", "loggedUser", loggedUser,
+					"Admin user accessing dashboards", "loggedUser", p.user,
 					"dashboardUser", dashboardUser, "url", r.URL,
 				)
 			} else {
-				r.Header.Set(dashboardUserHeader, loggedUser)
+				r.Header.Set(dashboardUserHeader, p.user)
 			}
 		} else {
 			// Check if requested URI is not admin endpoints
-			if strings.HasSuffix(r.URL.Path, "admin") {
-				amw.logger.Error("Unprivileged user accessing admin endpoint", "user", loggedUser, "url", r.URL)
+			if adminEndpoint(r) {
+				amw.logger.Error("Unprivileged user accessing admin endpoint", "user", p.user, "url", r.URL)
 
 				// Write an error and stop the handler chain
 				errorResponse[any](w, &apiError{errorForbidden, errNoPrivs}, amw.logger, nil)
@@ -125,7 +259,7 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 				return
 			}
 
-			r.Header.Set(dashboardUserHeader, loggedUser)
+			r.Header.Set(dashboardUserHeader, p.user)
 		}
 
 	end:
@@ -5,15 +5,29 @@ package http
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"database/sql"
+	"encoding/json"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
-
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/mahendrapaipuri/ceems/internal/oidc"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/prometheus/common/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func mockAdminUsers(_ context.Context, _ *sql.DB, _ *slog.Logger) []string {
@@ -139,3 +153,271 @@ func TestMiddlewareAdminFailurePresetHeader(t *testing.T) {
 	// Should not contain adminHeader
 	assert.Equal(t, "", req.Header.Get(adminUserHeader))
 }
+
+func TestMiddlewareAPITokenImpersonation(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	tokens := &apiTokens{logger: logger, db: dbConn, location: time.UTC, now: time.Now}
+
+	readOnlyToken, err := tokens.create(context.Background(), "adm1", "", []string{unitsResourceName}, time.Hour)
+	require.NoError(t, err)
+
+	adminToken, err := tokens.create(context.Background(), "adm1", "", []string{unitsResourceName, adminScope}, time.Hour)
+	require.NoError(t, err)
+
+	amw := authenticationMiddleware{
+		logger:          logger,
+		routerPrefix:    "/api/v1/",
+		whitelistedURLs: regexp.MustCompile("/api/v1/(swagger|debug|health|demo)(.*)"),
+		adminUsers:      mockAdminUsers,
+		tokens:          tokens,
+	}
+
+	var dashboardUser string
+
+	handlerToTest := amw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dashboardUser = r.Header.Get(dashboardUserHeader)
+	}))
+
+	tests := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{name: "token without admin scope", token: readOnlyToken.Token, expected: "adm1"},
+		{name: "token with admin scope", token: adminToken.Token, expected: "usr1"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		req.Header.Set(dashboardUserHeader, "usr1")
+
+		w := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(w, req)
+		require.Equal(t, 200, w.Code, test.name)
+
+		// Only tokens with admin scope can impersonate other users
+		assert.Equal(t, test.expected, dashboardUser, test.name)
+	}
+}
+
+func TestMiddlewareCEEMSHeader(t *testing.T) {
+	handlerToTest, _ := setupOIDCMiddleware(t, false)
+
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		code   int
+	}{
+		{name: "header without bearer token", method: http.MethodGet, secret: "admin", code: 401},
+		{name: "empty header", method: http.MethodGet, code: 401},
+		{name: "write request with secret", method: http.MethodPost, secret: "secret", code: 401},
+		{name: "read request with secret", method: http.MethodGet, secret: "secret", code: 200},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/api/v1/clusters/admin", nil)
+		req.Header.Set(ceemsUserHeader, test.secret)
+		req.Header.Set(adminUserHeader, "adm1")
+		req.Header.Set(loggedUserHeader, "adm1")
+
+		w := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(w, req)
+
+		assert.Equal(t, test.code, w.Code, test.name)
+
+		// Headers sent by client must always be removed
+		assert.Empty(t, req.Header.Get(adminUserHeader), test.name)
+		assert.Empty(t, req.Header.Get(loggedUserHeader), test.name)
+
+		if test.code == 200 {
+			assert.Equal(t, ceemsLBUser, req.Header.Get(ceemsUserHeader), test.name)
+		}
+	}
+
+	// Header must not be trusted when no secret is configured
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units/admin", nil)
+	req.Header.Set(ceemsUserHeader, "admin")
+
+	w := httptest.NewRecorder()
+	setupMiddleware().ServeHTTP(w, req)
+
+	assert.Equal(t, 401, w.Code)
+}
+
+func setupOIDCMiddleware(t *testing.T, headerAuth bool) (http.Handler, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// Write JWKS to a file
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "k1", Use: "sig"}}})
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, jwks, 0o600))
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	verifier, err := oidc.NewVerifier(oidc.Config{
+		Issuer:           "https://idp.example.com",
+		JWKSFile:         jwksFile,
+		Audiences:        []string{"ceems"},
+		UsernameClaim:    "preferred_username",
+		GroupsClaim:      "groups",
+		AdminGroups:      []string{"ceems-admins"},
+		HeaderAuth:       headerAuth,
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+	}, logger)
+	require.NoError(t, err)
+
+	amw := authenticationMiddleware{
+		logger:          logger,
+		whitelistedURLs: regexp.MustCompile("/api/v1/(swagger|debug|health|demo)(.*)"),
+		adminUsers:      mockAdminUsers,
+		verifier:        verifier,
+		lbSecret:        "secret",
+	}
+
+	return amw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})), key
+}
+
+func bearerToken(t *testing.T, key *rsa.PrivateKey, username string, groups []string) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithHeader(jose.HeaderKey("kid"), "k1"),
+	)
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(map[string]interface{}{
+		"iss":                "https://idp.example.com",
+		"aud":                "ceems",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"preferred_username": username,
+		"groups":             groups,
+	}).Serialize()
+	require.NoError(t, err)
+
+	return "Bearer " + token
+}
+
+func TestMiddlewareOIDC(t *testing.T) {
+	handlerToTest, key := setupOIDCMiddleware(t, true)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		url           string
+		headers       map[string]string
+		code          int
+		loggedUser    string
+		dashboardUser string
+		adminUser     string
+	}{
+		{
+			name:          "valid token",
+			url:           "/api/v1/units",
+			headers:       map[string]string{"Authorization": bearerToken(t, key, "usr1", nil)},
+			code:          200,
+			loggedUser:    "usr1",
+			dashboardUser: "usr1",
+		},
+		{
+			name: "token takes precedence over grafana header",
+			url:  "/api/v1/units",
+			headers: map[string]string{
+				"Authorization":   bearerToken(t, key, "usr1", nil),
+				grafanaUserHeader: "adm1",
+			},
+			code:          200,
+			loggedUser:    "usr1",
+			dashboardUser: "usr1",
+		},
+		{
+			name:    "token signed by unknown key",
+			url:     "/api/v1/units",
+			headers: map[string]string{"Authorization": bearerToken(t, otherKey, "usr1", nil)},
+			code:    401,
+		},
+		{
+			name: "admin from groups claim",
+			url:  "/api/v1/units/admin",
+			headers: map[string]string{
+				"Authorization":     bearerToken(t, key, "usr2", []string{"ceems-admins"}),
+				dashboardUserHeader: "usr1",
+			},
+			code:          200,
+			loggedUser:    "usr2",
+			dashboardUser: "usr1",
+			adminUser:     "usr2",
+		},
+		{
+			name:    "non admin accessing admin endpoint",
+			url:     "/api/v1/units/admin",
+			headers: map[string]string{"Authorization": bearerToken(t, key, "usr2", []string{"users"})},
+			code:    403,
+		},
+		{
+			name:          "header auth fallback",
+			url:           "/api/v1/units",
+			headers:       map[string]string{grafanaUserHeader: "usr3"},
+			code:          200,
+			loggedUser:    "usr3",
+			dashboardUser: "usr3",
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		for k, v := range test.headers {
+			req.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(w, req)
+
+		res := w.Result()
+		res.Body.Close()
+
+		assert.Equal(t, test.code, res.StatusCode, test.name)
+
+		if test.code == 200 {
+			assert.Equal(t, test.loggedUser, req.Header.Get(loggedUserHeader), test.name)
+			assert.Equal(t, test.dashboardUser, req.Header.Get(dashboardUserHeader), test.name)
+			assert.Equal(t, test.adminUser, req.Header.Get(adminUserHeader), test.name)
+		}
+	}
+}
+
+func TestMiddlewareOIDCWithoutHeaderAuth(t *testing.T) {
+	handlerToTest, _ := setupOIDCMiddleware(t, false)
+
+	// Grafana user header must not be trusted
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.Header.Set(grafanaUserHeader, "usr1")
+
+	w := httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, 401, res.StatusCode)
+}
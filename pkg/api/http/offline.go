@@ -0,0 +1,149 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+)
+
+// Custom errors.
+var (
+	errOfflineResource = errors.New("invalid resource. Supported resources are units and usage")
+	errOfflineMode     = errors.New("invalid usage mode. Supported modes are current and global")
+)
+
+// OfflineQuerier queries units and usage from a CEEMS DB file without running
+// the API server. It uses the same handlers as the admin endpoints of API server
+// and hence, it supports the same query parameters.
+type OfflineQuerier struct {
+	server *CEEMSServer
+}
+
+// NewOfflineQuerier returns a new instance of OfflineQuerier for SQLite DB at
+// dbPath. DB is opened in read only mode.
+func NewOfflineQuerier(dbPath string, location *time.Location, logger *slog.Logger) (*OfflineQuerier, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+
+	dbConn, err := sql.Open(sqlite3.DriverName, fmt.Sprintf("file:%s?%s", dbPath, "_mutex=no&mode=ro&_busy_timeout=5000"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DB: %w", err)
+	}
+
+	return &OfflineQuerier{
+		server: &CEEMSServer{
+			logger: logger,
+			db:     dbConn,
+			dbConfig: db.Config{
+				Data: db.DataConfig{
+					Path:     filepath.Dir(dbPath),
+					Timezone: db.Timezone{Location: location},
+				},
+			},
+			queriers:   newQueriers(),
+			usageCache: ttlcache.New(ttlcache.WithTTL[uint64, []models.Usage](cacheTTL)),
+			keysCache:  ttlcache.New(ttlcache.WithTTL[string, []string](cacheTTL)),
+		},
+	}, nil
+}
+
+// Query queries the resource using query parameters and writes response to w.
+// For usage resource, mode must be either current or global. The format of
+// response can be set using `format` query parameter.
+func (o *OfflineQuerier) Query(ctx context.Context, resource string, mode string, params url.Values, w io.Writer) error {
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	rw := &offlineResponseWriter{out: w, header: make(http.Header)}
+
+	switch resource {
+	case unitsResourceName:
+		o.server.unitsAdmin(rw, r)
+	case usageResourceName:
+		if mode != currentUsage && mode != globalUsage {
+			return fmt.Errorf("%w: %s", errOfflineMode, mode)
+		}
+
+		o.server.usageAdmin(rw, mux.SetURLVars(r, map[string]string{"mode": mode}))
+	default:
+		return fmt.Errorf("%w: %s", errOfflineResource, resource)
+	}
+
+	// Return error in the response
+	if rw.status >= http.StatusBadRequest {
+		var response Response[any]
+		if err := json.Unmarshal(rw.errBody.Bytes(), &response); err != nil || response.Error == "" {
+			return fmt.Errorf("query failed with status %d", rw.status)
+		}
+
+		return errors.New(response.Error)
+	}
+
+	return nil
+}
+
+// Close closes DB connection.
+func (o *OfflineQuerier) Close() error {
+	return o.server.db.Close()
+}
+
+// offlineResponseWriter writes response body to out. Error responses are
+// buffered so that they can be returned as errors.
+type offlineResponseWriter struct {
+	out     io.Writer
+	header  http.Header
+	status  int
+	errBody bytes.Buffer
+}
+
+// Header implements http.ResponseWriter interface.
+func (w *offlineResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (w *offlineResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write implements http.ResponseWriter interface.
+func (w *offlineResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if w.status >= http.StatusBadRequest {
+		return w.errBody.Write(b)
+	}
+
+	return w.out.Write(b)
+}
+
+// SetWriteDeadline is a no-op as there is no connection to the client.
+func (w *offlineResponseWriter) SetWriteDeadline(time.Time) error {
+	return nil
+}
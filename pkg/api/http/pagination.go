@@ -0,0 +1,234 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/internal/structset"
+)
+
+// Sort fields that support cursor based pagination. Cursors are only
+// supported on integer columns.
+var cursorSortFields = []string{"id", "ended_at_ts"}
+
+// Pagination contains the metadata needed to fetch the next page of results.
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset,omitempty"`
+	NextOffset int    `json:"next_offset,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor points to the last row of a page using the value of sort field
+// and id of the row.
+type cursor struct {
+	value int64
+	id    int64
+}
+
+// String returns opaque representation of cursor.
+func (c cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d,%d", c.value, c.id))
+}
+
+// decodeCursor decodes opaque string into cursor.
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	value, id, ok := strings.Cut(string(b), ",")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+
+	c := &cursor{}
+	if c.value, err = strconv.ParseInt(value, 10, 64); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	if c.id, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+// pageQuery contains sorting and pagination parameters of the request.
+type pageQuery struct {
+	sort   string
+	order  string
+	limit  int
+	offset int
+	cursor *cursor
+}
+
+// getPageQuery returns sorting and pagination parameters from query vars.
+func getPageQuery(urlValues url.Values) (pageQuery, error) {
+	p := pageQuery{
+		sort:  strings.TrimSpace(urlValues.Get("sort")),
+		order: "asc",
+	}
+
+	if o := urlValues.Get("order"); o != "" {
+		p.order = strings.ToLower(o)
+	}
+
+	if l := urlValues.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			return pageQuery{}, fmt.Errorf("query parameter 'limit': %w", errInvalidPageParam)
+		}
+
+		p.limit = limit
+	}
+
+	if o := urlValues.Get("offset"); o != "" {
+		offset, err := strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			return pageQuery{}, fmt.Errorf("query parameter 'offset': %w", errInvalidPageParam)
+		}
+
+		p.offset = offset
+	}
+
+	// Offset is applied only along with limit
+	if p.offset > 0 && p.limit == 0 {
+		return pageQuery{}, errOffsetNoLimit
+	}
+
+	if c := urlValues.Get("cursor"); c != "" {
+		if p.offset > 0 {
+			return pageQuery{}, errCursorOffset
+		}
+
+		// Use id as sort field for cursors by default
+		if p.sort == "" {
+			p.sort = "id"
+		}
+
+		if !slices.Contains(cursorSortFields, p.sort) {
+			return pageQuery{}, fmt.Errorf("%w: %s", errCursorSortField, p.sort)
+		}
+
+		var err error
+		if p.cursor, err = decodeCursor(c); err != nil {
+			return pageQuery{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// apply adds keyset condition, sort order and limits to the query. When no
+// sort field is requested, defaultOrder is used as ORDER BY clause.
+func (p pageQuery) apply(q *Query, defaultOrder string, validColumns []string) error {
+	// Rows can always be sorted by id
+	validColumns = append(slices.Clone(validColumns), "id")
+
+	if p.cursor != nil {
+		if err := q.seek(
+			[]string{p.sort, "id"}, p.order, []int64{p.cursor.value, p.cursor.id}, validColumns,
+		); err != nil {
+			return err
+		}
+	}
+
+	switch p.sort {
+	case "":
+		if !slices.Contains([]string{"asc", "desc"}, p.order) {
+			return errInvalidSortOrder
+		}
+
+		q.query(" ORDER BY " + defaultOrder)
+	case "id":
+		if err := q.orderBy([]string{"id"}, p.order, validColumns); err != nil {
+			return err
+		}
+	default:
+		// Use id as tie breaker to get a deterministic order
+		if err := q.orderBy([]string{p.sort, "id"}, p.order, validColumns); err != nil {
+			return err
+		}
+	}
+
+	q.page(p.limit, p.offset)
+
+	return nil
+}
+
+// selectFields returns fields to select from DB. Sort field and id are always
+// selected when cursor of next page can be estimated even if they are not
+// among the requested fields.
+func (p pageQuery) selectFields(fields []string) []string {
+	if !p.cursorable() {
+		return fields
+	}
+
+	selected := slices.Clone(fields)
+
+	for _, f := range []string{p.sort, "id"} {
+		if !slices.Contains(selected, f) {
+			selected = append(selected, f)
+		}
+	}
+
+	return selected
+}
+
+// cursorable returns true if cursor of next page can be estimated.
+func (p pageQuery) cursorable() bool {
+	return p.limit > 0 && slices.Contains(cursorSortFields, p.sort)
+}
+
+// nextPage returns the pagination metadata of the page of values. Metadata
+// is only returned when limit is set in request.
+func nextPage[T any](p pageQuery, values []T) *Pagination {
+	if p.limit == 0 {
+		return nil
+	}
+
+	page := &Pagination{
+		Limit:  p.limit,
+		Offset: p.offset,
+	}
+
+	// Last page
+	if len(values) < p.limit {
+		return page
+	}
+
+	if p.cursor == nil {
+		page.NextOffset = p.offset + p.limit
+	}
+
+	if !p.cursorable() {
+		return page
+	}
+
+	// Get sort field value and id of last row
+	last := reflect.ValueOf(values[len(values)-1])
+	if last.Kind() != reflect.Struct {
+		return page
+	}
+
+	indexes := structset.CachedFieldIndexes(last.Type())
+
+	value, valueOK := indexes[p.sort]
+	id, idOK := indexes["id"]
+
+	if valueOK && idOK && last.Field(value).CanInt() && last.Field(id).CanInt() {
+		page.NextCursor = cursor{value: last.Field(value).Int(), id: last.Field(id).Int()}.String()
+	}
+
+	return page
+}
@@ -0,0 +1,174 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPostgresServer returns a server that queries a PostgreSQL DB populated
+// with mock data. Tests are skipped when CEEMS_TEST_POSTGRES_DSN is not set.
+func setupPostgresServer(t *testing.T) *CEEMSServer {
+	t.Helper()
+
+	dsn := os.Getenv("CEEMS_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CEEMS_TEST_POSTGRES_DSN is not set")
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := db.OpenPostgres(dsn)
+	require.NoError(t, err)
+
+	// Use a separate schema so that tests of DB package can run in parallel
+	_, err = dbConn.Exec("DROP SCHEMA IF EXISTS ceems_http CASCADE; CREATE SCHEMA ceems_http;")
+	require.NoError(t, err)
+
+	dbConn.Close()
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+
+	q := u.Query()
+	q.Set("search_path", "ceems_http")
+	u.RawQuery = q.Encode()
+	dsn = u.String()
+
+	dbConn, err = db.OpenPostgres(dsn)
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.PostgresMigrationsFS, "migrations/postgres", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyPostgresMigrations(dbConn))
+
+	now := time.Now().UTC().Format(base.DatetimeLayout)
+
+	for _, stmt := range []string{
+		"INSERT INTO admin_users (source, users) VALUES ('ceems', '[\"adm1\"]')",
+		"INSERT INTO users (cluster_id, resource_manager, name, projects) VALUES ('slurm-0', 'slurm', 'usr1', '[\"prj1\"]')",
+		"INSERT INTO projects (cluster_id, resource_manager, name, users) VALUES ('slurm-0', 'slurm', 'prj1', '[\"usr1\"]')",
+		`INSERT INTO units (resource_manager, cluster_id, uuid, project, groupname, username, started_at_ts, ended_at_ts, total_time_seconds, avg_cpu_usage, last_updated_at) VALUES
+			('slurm', 'slurm-0', '1000', 'prj1', 'grp1', 'usr1', 1700000000000, 1700003600000, '{"walltime": 3600, "alloc_cputime": 7200}', '{"global": 20}', $now),
+			('slurm', 'slurm-0', '1001', 'prj1', 'grp1', 'usr1', 1700000000000, 0, '{"walltime": 1800, "alloc_cputime": 1800}', '{"global": 40}', $now)`,
+		`INSERT INTO usage (resource_manager, cluster_id, num_units, project, groupname, username, total_time_seconds, avg_cpu_usage, last_updated_at) VALUES
+			('slurm', 'slurm-0', 2, 'prj1', 'grp1', 'usr1', '{"walltime": 5400, "alloc_cputime": 9000}', '{"global": 28}', $now)`,
+		`INSERT INTO daily_usage (resource_manager, cluster_id, num_units, project, groupname, username, total_time_seconds, avg_cpu_usage, total_cost, last_updated_at) VALUES
+			('slurm', 'slurm-0', 2, 'prj1', 'grp1', 'usr1', '{"walltime": 5400, "alloc_cputime": 9000}', '{"global": 28}', '{"total": 5}', $now)`,
+	} {
+		_, err = dbConn.Exec(strings.ReplaceAll(stmt, "$now", "'"+now+"'"))
+		require.NoError(t, err, stmt)
+	}
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Driver:   db.Postgres,
+					DSN:      config_util.Secret(dsn),
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		server.Shutdown(context.Background())
+	})
+
+	return server
+}
+
+// postgresRequest makes request to server as user and decodes the response.
+func postgresRequest[T any](t *testing.T, server *CEEMSServer, user string, path string) []T {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+path, nil)
+	req.Header.Set(grafanaUserHeader, user)
+
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, "%s: %s", path, w.Body.String())
+
+	var response Response[T]
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), path)
+	assert.Equal(t, "success", response.Status, path)
+
+	return response.Data
+}
+
+func TestPostgresUsageHandlers(t *testing.T) {
+	server := setupPostgresServer(t)
+
+	for _, path := range []string{
+		"/usage/current",
+		"/usage/current?groupby=cluster_id&groupby=groupname",
+		"/usage/current?field=avg_cpu_usage&field=total_time_seconds&field=resource_manager&field=groupname&field=last_updated_at",
+		"/usage/global",
+	} {
+		usage := postgresRequest[models.Usage](t, server, "usr1", path)
+		require.Len(t, usage, 1, path)
+		assert.Equal(t, "usr1", usage[0].User, path)
+		assert.Equal(t, "prj1", usage[0].Project, path)
+		assert.Equal(t, int64(2), usage[0].NumUnits, path)
+	}
+
+	usage := postgresRequest[models.Usage](t, server, "adm1", "/usage/current/admin?field=resource_manager&field=groupname")
+	require.Len(t, usage, 1)
+	assert.Equal(t, "slurm", usage[0].ResourceManager)
+	assert.Equal(t, "grp1", usage[0].Group)
+}
+
+func TestPostgresStatsHandlers(t *testing.T) {
+	server := setupPostgresServer(t)
+
+	for _, mode := range []string{"current", "global"} {
+		stats := postgresRequest[models.Stat](t, server, "adm1", "/stats/"+mode+"/admin")
+		require.Len(t, stats, 1, mode)
+		assert.Equal(t, "slurm-0", stats[0].ClusterID, mode)
+		assert.Equal(t, "slurm", stats[0].ResourceManager, mode)
+		assert.Equal(t, int64(2), stats[0].NumUnits, mode)
+		assert.Equal(t, int64(1), stats[0].NumActiveUnits, mode)
+	}
+}
+
+func TestPostgresBillingHandlers(t *testing.T) {
+	server := setupPostgresServer(t)
+
+	for _, mode := range []string{"user", "project"} {
+		invoices := postgresRequest[models.Invoice](t, server, "usr1", "/billing/"+mode)
+		require.Len(t, invoices, 1, mode)
+		assert.Equal(t, "slurm", invoices[0].ResourceManager, mode)
+		assert.Equal(t, "prj1", invoices[0].Project, mode)
+		assert.Equal(t, int64(2), invoices[0].NumUnits, mode)
+	}
+}
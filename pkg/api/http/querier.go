@@ -8,6 +8,8 @@ import (
 	"log/slog"
 	"reflect"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/mahendrapaipuri/ceems/internal/structset"
@@ -17,10 +19,18 @@ import (
 
 var queryRegexp = regexp.MustCompile("SELECT (.*?) FROM (.*)")
 
+// Custom errors.
+var (
+	errInvalidSortField = errors.New("invalid sort field")
+	errInvalidSortOrder = errors.New("invalid sort order. Allowed orders are asc and desc")
+)
+
 // Query builder struct.
 type Query struct {
 	builder strings.Builder
 	params  []string
+	limit   int
+	offset  int
 }
 
 // Add query to builder.
@@ -28,6 +38,11 @@ func (q *Query) query(s string) {
 	q.builder.WriteString(s)
 }
 
+// Returns true when nothing has been added to builder.
+func (q *Query) empty() bool {
+	return q.builder.Len() == 0
+}
+
 // Add parameter and its placeholder.
 func (q *Query) param(val []string) {
 	q.builder.WriteString(fmt.Sprintf("(%s)", strings.Join(strings.Split(strings.Repeat("?", len(val)), ""), ",")))
@@ -41,18 +56,82 @@ func (q *Query) subQuery(sq Query) {
 	q.params = append(q.params, subQueryParams...)
 }
 
+// Add ORDER BY clause to builder. Column names cannot be passed as parameters
+// of prepared statements and hence, they are checked against validColumns to
+// avoid SQL injection.
+func (q *Query) orderBy(columns []string, order string, validColumns []string) error {
+	order = strings.ToUpper(order)
+	if order != "ASC" && order != "DESC" {
+		return errInvalidSortOrder
+	}
+
+	clauses := make([]string, len(columns))
+
+	for i, column := range columns {
+		if !slices.Contains(validColumns, column) {
+			return fmt.Errorf("%w: %s", errInvalidSortField, column)
+		}
+
+		clauses[i] = fmt.Sprintf("%s %s", column, order)
+	}
+
+	q.builder.WriteString(fmt.Sprintf(" ORDER BY %s ", strings.Join(clauses, ", ")))
+
+	return nil
+}
+
+// Add a keyset condition that selects rows that come after the row with given
+// values of integer columns in the given sort order.
+func (q *Query) seek(columns []string, order string, values []int64, validColumns []string) error {
+	for _, column := range columns {
+		if !slices.Contains(validColumns, column) {
+			return fmt.Errorf("%w: %s", errInvalidSortField, column)
+		}
+	}
+
+	op := ">"
+	if strings.EqualFold(order, "desc") {
+		op = "<"
+	}
+
+	// Values are integers and can be safely formatted into query
+	vals := make([]string, len(values))
+	for i, v := range values {
+		vals[i] = strconv.FormatInt(v, 10)
+	}
+
+	q.builder.WriteString(
+		fmt.Sprintf(" AND (%s) %s (%s) ", strings.Join(columns, ","), op, strings.Join(vals, ",")),
+	)
+
+	return nil
+}
+
+// Set LIMIT and OFFSET of query. They are appended only to the final query
+// so that rows can still be counted on the query without pagination.
+func (q *Query) page(limit, offset int) {
+	q.limit = limit
+	q.offset = offset
+}
+
 // Get current query string and its parameters.
 func (q *Query) get() (string, []string) {
+	if q.limit > 0 {
+		return q.builder.String() + fmt.Sprintf(" LIMIT %d OFFSET %d", q.limit, q.offset), q.params
+	}
+
 	return q.builder.String(), q.params
 }
 
-// projectsSubQuery returns a sub query that returns projects of users
+// projectsSubQuery returns a sub query that returns cluster IDs and names of
+// projects of users
 // With my limited SQL skills the best query I came up with is following:
-// SELECT * FROM usage WHERE project IN (SELECT name FROM projects WHERE EXISTS (SELECT 1 FROM json_each(users) WHERE value = 'usr1'))
+// SELECT * FROM usage WHERE (cluster_id, project) IN (SELECT cluster_id, name FROM projects WHERE EXISTS (SELECT 1 FROM json_each(users) WHERE value = 'usr1'))
 // Not sure if it is the most optimal but will do for the time being.
+// Projects that users coordinate are returned as well.
 func projectsSubQuery(users []string) Query {
 	// Make a sub query that will fetch projects of users
-	// SELECT name FROM projects WHERE EXISTS (SELECT 1 FROM json_each(users) WHERE value = 'usr1')
+	// SELECT cluster_id, name FROM projects WHERE EXISTS (SELECT 1 FROM json_each(users) WHERE value = 'usr1')
 	innerQuery := Query{}
 	innerQuery.query("SELECT 1 FROM json_each(users)")
 
@@ -64,13 +143,61 @@ func projectsSubQuery(users []string) Query {
 
 	// Sub query with inner query
 	qSub := Query{}
-	qSub.query("SELECT name FROM " + base.ProjectsDBTableName)
+	qSub.query("SELECT cluster_id, name FROM " + base.ProjectsDBTableName)
 	qSub.query(" WHERE EXISTS ")
 	qSub.subQuery(innerQuery)
 
+	// Add projects coordinated by users
+	if len(users) > 0 {
+		qSub.query(" OR ")
+		qSub.subQuery(coordinatorsQuery(users))
+	}
+
 	return qSub
 }
 
+// coordinatedProjectsSubQuery returns a sub query that returns cluster IDs and
+// names of projects coordinated by users. Projects with same name on different
+// clusters are different projects and hence, both columns must be matched.
+// SELECT cluster_id, name FROM projects WHERE EXISTS (SELECT 1 FROM json_each(coordinators) WHERE value = 'usr1')
+func coordinatedProjectsSubQuery(users []string) Query {
+	qSub := Query{}
+	qSub.query("SELECT cluster_id, name FROM " + base.ProjectsDBTableName)
+	qSub.query(" WHERE ")
+	qSub.subQuery(coordinatorsQuery(users))
+
+	return qSub
+}
+
+// unitsOwnersQuery returns a condition that selects units of users and units
+// of all users in the projects coordinated by coordinators.
+func unitsOwnersQuery(users []string, coordinators []string) Query {
+	q := Query{}
+	q.query("username IN ")
+	q.param(users)
+
+	if len(coordinators) > 0 {
+		q.query(" OR (cluster_id, project) IN ")
+		q.subQuery(coordinatedProjectsSubQuery(coordinators))
+	}
+
+	return q
+}
+
+// coordinatorsQuery returns a condition that is true when any of users is
+// a coordinator of the project.
+func coordinatorsQuery(users []string) Query {
+	innerQuery := Query{}
+	innerQuery.query("SELECT 1 FROM json_each(coordinators) WHERE value IN ")
+	innerQuery.param(users)
+
+	q := Query{}
+	q.query("EXISTS ")
+	q.subQuery(innerQuery)
+
+	return q
+}
+
 // Scan rows
 // We use numRows only for units query as returned number of units can be very big
 // and preallocating can have positive impact on performance
@@ -124,17 +251,22 @@ func scanRows[T any](rows *sql.Rows, numRows int) ([]T, error) {
 		err = errors.Join(err, errRows)
 	}
 
+	// Rows might have been deleted between counting and querying
+	if numRows > rowIdx {
+		values = values[:rowIdx]
+	}
+
 	return values, err
 }
 
 func countRows(ctx context.Context, dbConn *sql.DB, query Query) (int, error) {
 	var numRows int
 
-	// Get query string and params
-	queryString, queryParams := query.get()
+	// Get query string and params without pagination
+	queryString, queryParams := query.builder.String(), query.params
 
 	// Prepare SQL statements
-	countQuery := queryRegexp.ReplaceAllString(queryString, "SELECT COUNT(*) FROM $2")
+	countQuery := base.Rebind(base.Dialect(dbConn), queryRegexp.ReplaceAllString(queryString, "SELECT COUNT(*) FROM $2"))
 
 	countStmt, err := dbConn.Prepare(countQuery)
 	if err != nil {
@@ -188,12 +320,18 @@ func Querier[T any](ctx context.Context, dbConn *sql.DB, query Query, logger *sl
 
 			return nil, err
 		}
+
+		// Number of rows that will be returned in current page
+		if query.limit > 0 {
+			numRows = max(min(numRows-query.offset, query.limit), 0)
+		}
 	default:
 		numRows = 0
 	}
 
 	// Get query string and params
 	queryString, queryParams := query.get()
+	queryString = base.Rebind(base.Dialect(dbConn), queryString)
 
 	queryStmt, err := dbConn.Prepare(queryString)
 	if err != nil {
@@ -229,3 +367,64 @@ func Querier[T any](ctx context.Context, dbConn *sql.DB, query Query, logger *sl
 
 	return scanRows[T](rows, numRows)
 }
+
+// Streamer queries the DB and calls fn for each returned row. Unlike Querier,
+// rows are not accumulated in memory and hence, it is suitable to export large
+// number of rows.
+func Streamer[T any](
+	ctx context.Context,
+	dbConn *sql.DB,
+	query Query,
+	logger *slog.Logger,
+	fn func(T) error,
+) error {
+	// Get query string and params
+	queryString, queryParams := query.get()
+	queryString = base.Rebind(base.Dialect(dbConn), queryString)
+
+	// queryParams has to be an inteface. Do casting here
+	qParams := make([]interface{}, len(queryParams))
+	for i, v := range queryParams {
+		qParams[i] = v
+	}
+
+	rows, err := dbConn.QueryContext(ctx, queryString, qParams...)
+	if err != nil {
+		logger.Error("Failed to get rows",
+			"query", queryString, "queryParams", strings.Join(queryParams, ","), "err", err,
+		)
+
+		return err
+	}
+	defer rows.Close()
+
+	// Get indexes and columns
+	indexes := structset.CachedFieldIndexes(reflect.TypeOf(new(T)).Elem())
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("cannot fetch columns: %w", err)
+	}
+
+	scanErrs := 0
+
+	for rows.Next() {
+		// Use a new value for each row as NULL columns are not scanned
+		var value T
+		if err := structset.ScanRow(rows, columns, indexes, &value); err != nil {
+			scanErrs++
+
+			continue
+		}
+
+		if err := fn(value); err != nil {
+			return err
+		}
+	}
+
+	if scanErrs > 0 {
+		err = fmt.Errorf("failed to scan %d rows", scanErrs)
+	}
+
+	return errors.Join(err, rows.Err())
+}
@@ -6,15 +6,26 @@ package http
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	ceems_db "github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -420,3 +431,435 @@ func TestSubQueryBuilder(t *testing.T) {
 	require.Equal(t, expectedQueryString, queryString)
 	assert.Equal(t, expectedQueryParams, queryParams)
 }
+
+func TestPaginatedQueryBuilder(t *testing.T) {
+	expectedQueryString := "SELECT * FROM table WHERE a IN (?) AND (b,id) < (10,2)  ORDER BY b DESC, id DESC  LIMIT 5 OFFSET 0"
+	expectedQueryParams := []string{"a1"}
+
+	q := Query{}
+	q.query("SELECT * FROM table")
+	q.query(" WHERE a IN ")
+	q.param([]string{"a1"})
+
+	err := q.seek([]string{"b", "id"}, "desc", []int64{10, 2}, []string{"a", "b", "id"})
+	require.NoError(t, err)
+
+	err = q.orderBy([]string{"b", "id"}, "desc", []string{"a", "b", "id"})
+	require.NoError(t, err)
+
+	q.page(5, 0)
+
+	// Get built query
+	queryString, queryParams := q.get()
+	require.Equal(t, expectedQueryString, queryString)
+	assert.Equal(t, expectedQueryParams, queryParams)
+
+	// Sort fields that are not columns must be rejected
+	err = q.orderBy([]string{"b; DROP TABLE table"}, "asc", []string{"a", "b", "id"})
+	require.ErrorIs(t, err, errInvalidSortField)
+
+	err = q.seek([]string{"c"}, "asc", []int64{1}, []string{"a", "b", "id"})
+	require.ErrorIs(t, err, errInvalidSortField)
+
+	err = q.orderBy([]string{"b"}, "random()", []string{"a", "b", "id"})
+	require.ErrorIs(t, err, errInvalidSortOrder)
+}
+
+func TestUnitsQuerierPagination(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, err := setupTestDB()
+	require.NoError(t, err, "failed to setup test DB")
+	defer db.Close()
+
+	baseQuery := fmt.Sprintf("SELECT * FROM %s WHERE ignore = 0 AND cluster_id IN ('slurm-0')", base.UnitsDBTableName)
+
+	// Get all units sorted by ended_at_ts
+	q := Query{}
+	q.query(baseQuery)
+	q.query(" ORDER BY ended_at_ts DESC, id DESC")
+	allUnits, err := Querier[models.Unit](context.Background(), db, q, logger)
+	require.NoError(t, err)
+	require.Greater(t, len(allUnits), 4)
+
+	for _, test := range []struct {
+		name   string
+		values url.Values
+	}{
+		{
+			name:   "offset",
+			values: url.Values{"sort": {"ended_at_ts"}, "order": {"desc"}, "limit": {"3"}},
+		},
+		{
+			name:   "cursor",
+			values: url.Values{"sort": {"ended_at_ts"}, "order": {"desc"}, "limit": {"3"}, "cursor": {""}},
+		},
+	} {
+		var units []models.Unit
+
+		for range len(allUnits) {
+			p, err := getPageQuery(test.values)
+			require.NoError(t, err, test.name)
+
+			q := Query{}
+			q.query(baseQuery)
+			require.NoError(t, p.apply(&q, "id ASC", base.UnitsDBTableColNames), test.name)
+
+			page, err := Querier[models.Unit](context.Background(), db, q, logger)
+			require.NoError(t, err, test.name)
+			require.LessOrEqual(t, len(page), 3, test.name)
+
+			units = append(units, page...)
+
+			pagination := nextPage(p, page)
+			require.NotNil(t, pagination, test.name)
+
+			if pagination.NextCursor != "" && test.values.Has("cursor") {
+				test.values.Set("cursor", pagination.NextCursor)
+			} else if pagination.NextOffset > 0 && !test.values.Has("cursor") {
+				test.values.Set("offset", strconv.Itoa(pagination.NextOffset))
+			} else {
+				break
+			}
+		}
+
+		assert.Equal(t, allUnits, units, test.name)
+	}
+}
+
+func TestPageQuerySelectFields(t *testing.T) {
+	// Seek columns must be selected when cursor can be estimated
+	p, err := getPageQuery(url.Values{"sort": {"ended_at_ts"}, "limit": {"2"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uuid", "ended_at_ts", "id"}, p.selectFields([]string{"uuid"}))
+
+	// No extra columns without limit
+	p, err = getPageQuery(url.Values{"sort": {"ended_at_ts"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uuid"}, p.selectFields([]string{"uuid"}))
+
+	// Next cursor must be estimated on the units selected with seek columns
+	db, err := setupTestDB()
+	require.NoError(t, err, "failed to setup test DB")
+	defer db.Close()
+
+	p, err = getPageQuery(url.Values{"sort": {"ended_at_ts"}, "limit": {"2"}})
+	require.NoError(t, err)
+
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE ignore = 0", strings.Join(p.selectFields([]string{"uuid"}), ","), base.UnitsDBTableName))
+	require.NoError(t, p.apply(&q, "id ASC", base.UnitsDBTableColNames))
+
+	units, err := Querier[models.Unit](context.Background(), db, q, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.Len(t, units, 2)
+	assert.NotEmpty(t, nextPage(p, units).NextCursor)
+}
+
+func TestUnitsStreamer(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, err := setupTestDB()
+	require.NoError(t, err, "failed to setup test DB")
+	defer db.Close()
+
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT * FROM %s WHERE ignore = 0 AND cluster_id IN ('slurm-0') ORDER BY id", base.UnitsDBTableName))
+
+	expectedUnits, err := Querier[models.Unit](context.Background(), db, q, logger)
+	require.NoError(t, err)
+
+	var units []models.Unit
+
+	err = Streamer(context.Background(), db, q, logger, func(unit models.Unit) error {
+		units = append(units, unit)
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expectedUnits, units)
+}
+
+func TestUsageTimeSeriesQuerier(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	db, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	// Create minimal tables with daily usage of two users of a project
+	for _, stmt := range []string{
+		"CREATE TABLE projects (cluster_id text, name text, users text, coordinators text)",
+		"INSERT INTO projects VALUES ('slurm-0', 'foo', '[\"usr1\",\"usr2\"]', '[]'), ('slurm-0', 'bar', '[\"usr3\"]', '[]')",
+		"CREATE TABLE daily_usage (cluster_id text, project text, username text, num_units integer, " +
+			"total_time_seconds text, avg_cpu_usage text, last_updated_at text)",
+		`INSERT INTO daily_usage VALUES
+			('slurm-0', 'foo', 'usr1', 1, '{"walltime":100,"alloc_cputime":100}', '{"usage":10}', '2024-10-01T00:00:00'),
+			('slurm-0', 'foo', 'usr2', 2, '{"walltime":300,"alloc_cputime":300}', '{"usage":50}', '2024-10-15T00:00:00'),
+			('slurm-0', 'foo', 'usr1', 1, '{"walltime":100,"alloc_cputime":100}', '{"usage":20}', '2024-11-02T00:00:00'),
+			('slurm-0', 'bar', 'usr3', 4, '{"walltime":100,"alloc_cputime":100}', '{"usage":90}', '2024-10-02T00:00:00')`,
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	db.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.maxQueryPeriod = 0
+	server.queriers.timeSeries = Querier[models.UsageTimeSeries]
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []models.UsageTimeSeries
+	}{
+		{
+			name:  "monthly weighted averages",
+			query: "step=1M",
+			expected: []models.UsageTimeSeries{
+				{
+					Timestamp: 1727740800000, NumUnits: 3,
+					TotalTime:   models.MetricMap{"walltime": 400, "alloc_cputime": 400},
+					AveCPUUsage: models.MetricMap{"usage": 40},
+				},
+				{
+					Timestamp: 1730419200000, NumUnits: 1,
+					TotalTime:   models.MetricMap{"walltime": 100, "alloc_cputime": 100},
+					AveCPUUsage: models.MetricMap{"usage": 20},
+				},
+			},
+		},
+		{
+			name:  "weekly time series grouped by user",
+			query: "step=1w&groupby=user&to=1728518400",
+			expected: []models.UsageTimeSeries{
+				{
+					Timestamp: 1727654400000, User: "usr1", NumUnits: 1,
+					TotalTime:   models.MetricMap{"walltime": 100, "alloc_cputime": 100},
+					AveCPUUsage: models.MetricMap{"usage": 10},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(
+			http.MethodGet,
+			"/api/"+base.APIVersion+"/usage/timeseries?from=1727740800&field=num_units&field=total_time_seconds&field=avg_cpu_usage&"+test.query,
+			nil,
+		)
+		request.Header.Set(dashboardUserHeader, "usr1")
+
+		w := httptest.NewRecorder()
+		server.usageTimeSeries(w, request)
+		require.Equal(t, http.StatusOK, w.Code, test.name)
+
+		var response Response[models.UsageTimeSeries]
+
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), test.name)
+		assert.Equal(t, test.expected, response.Data, test.name)
+	}
+
+	// Buckets must start at midnight in the time zone of the server
+	server.dbConfig.Data.Timezone = ceems_db.Timezone{Location: time.FixedZone("CET", 3600)}
+
+	request := httptest.NewRequest(
+		http.MethodGet,
+		"/api/"+base.APIVersion+"/usage/timeseries?from=1727733600&field=num_units&step=1M",
+		nil,
+	)
+	request.Header.Set(dashboardUserHeader, "usr1")
+
+	w := httptest.NewRecorder()
+	server.usageTimeSeries(w, request)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response Response[models.UsageTimeSeries]
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []models.UsageTimeSeries{
+		{Timestamp: 1727737200000, NumUnits: 3},
+		{Timestamp: 1730415600000, NumUnits: 1},
+	}, response.Data)
+}
+
+func TestUsageRollupQuerier(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(ceems_db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Expired units of January and February are rolled up into monthly usage
+	// and a recent unit with a step is still in units table
+	lastUpdatedAt := time.Now().UTC().Add(-time.Hour).Format(base.DatetimeLayout)
+	for _, stmt := range []string{
+		"INSERT INTO projects (cluster_id, name, users) VALUES ('slurm-0', 'foo', '[\"usr1\"]')",
+		`INSERT INTO monthly_usage (cluster_id, project, username, num_units, total_time_seconds, avg_cpu_usage, last_updated_at) VALUES
+			('slurm-0', 'foo', 'usr1', 3, '{"walltime":500,"alloc_cputime":1000}', '{"usage":50}', '2024-01-01T00:00:00'),
+			('slurm-0', 'foo', 'usr1', 1, '{"walltime":200,"alloc_cputime":400}', '{"usage":20}', '2024-02-01T00:00:00')`,
+		fmt.Sprintf(`INSERT INTO units (cluster_id, uuid, project, username, parent_uuid, total_time_seconds, avg_cpu_usage, last_updated_at) VALUES
+			('slurm-0', '1000', 'foo', 'usr1', '', '{"walltime":300,"alloc_cputime":600}', '{"usage":40}', '%[1]s'),
+			('slurm-0', '1000.0', 'foo', 'usr1', '1000', '{"walltime":300,"alloc_cputime":600}', '{"usage":40}', '%[1]s')`, lastUpdatedAt),
+	} {
+		_, err := dbConn.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	dbConn.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.maxQueryPeriod = 0
+	server.dbConfig.Data.RetentionPeriod = model.Duration(30 * 24 * time.Hour)
+	server.dbConfig.Data.RetentionPolicy = ceems_db.RetentionPolicyRollup
+	server.queriers.usage = Querier[models.Usage]
+	server.queriers.key = Querier[models.Key]
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []models.Usage
+	}{
+		{
+			name:  "rollups and units",
+			query: "from=1705276800",
+			expected: []models.Usage{
+				{
+					Project: "foo", User: "usr1", NumUnits: 5,
+					TotalTime:   models.MetricMap{"walltime": 1000, "alloc_cputime": 2000},
+					AveCPUUsage: models.MetricMap{"usage": 41},
+				},
+			},
+		},
+		{
+			name:  "only rollups",
+			query: "from=1705276800&to=1706659200",
+			expected: []models.Usage{
+				{
+					Project: "foo", User: "usr1", NumUnits: 3,
+					TotalTime:   models.MetricMap{"walltime": 500, "alloc_cputime": 1000},
+					AveCPUUsage: models.MetricMap{"usage": 50},
+				},
+			},
+		},
+		{
+			name:  "only units within retention period",
+			query: "from=" + strconv.FormatInt(time.Now().Add(-24*time.Hour).Unix(), 10),
+			expected: []models.Usage{
+				{
+					Project: "foo", User: "usr1", NumUnits: 1,
+					TotalTime:   models.MetricMap{"walltime": 300, "alloc_cputime": 600},
+					AveCPUUsage: models.MetricMap{"usage": 40},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(
+			http.MethodGet,
+			"/api/"+base.APIVersion+"/usage/current?field=project&field=username&field=num_units&field=total_time_seconds&field=avg_cpu_usage&"+test.query,
+			nil,
+		)
+		request = mux.SetURLVars(request, map[string]string{"mode": "current"})
+		request.Header.Set(dashboardUserHeader, "usr1")
+
+		w := httptest.NewRecorder()
+		server.usage(w, request)
+		require.Equal(t, http.StatusOK, w.Code, test.name)
+
+		var response Response[models.Usage]
+
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), test.name)
+		assert.Equal(t, test.expected, response.Data, test.name)
+	}
+}
+
+func TestOfflineQuerier(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dbPath := filepath.Join(t.TempDir(), base.CEEMSDBName)
+
+	dbConn, err := sql.Open("sqlite3", dbPath)
+	require.NoError(t, err)
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(ceems_db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	for _, stmt := range []string{
+		"INSERT INTO projects (cluster_id, name, users) VALUES ('slurm-0', 'foo', '[\"usr1\"]')",
+		`INSERT INTO units (cluster_id, uuid, project, username, parent_uuid, ignore, started_at, ended_at) VALUES
+			('slurm-0', '1000', 'foo', 'usr1', '', 0, '2023-02-21T14:49:06', '2023-02-21T14:57:23')`,
+		`INSERT INTO usage (cluster_id, project, username, num_units, total_time_seconds) VALUES
+			('slurm-0', 'foo', 'usr1', 1, '{"walltime":500}')`,
+	} {
+		_, err := dbConn.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	dbConn.Close()
+
+	querier, err := NewOfflineQuerier(dbPath, time.UTC, logger)
+	require.NoError(t, err)
+
+	defer querier.Close()
+
+	// Query units in NDJSON format
+	var buf strings.Builder
+
+	params := url.Values{}
+	params.Add("cluster_id", "slurm-0")
+	params.Add("from", "1676934000")
+	params.Add("to", "1677000000")
+	params.Add("field", "uuid")
+	params.Add("field", "project")
+	params.Add("format", "ndjson")
+
+	err = querier.Query(context.Background(), "units", "", params, &buf)
+	require.NoError(t, err)
+
+	var unit models.Unit
+
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &unit))
+	assert.Equal(t, "1000", unit.UUID)
+
+	// Query global usage in JSON format
+	buf.Reset()
+
+	params = url.Values{}
+	params.Add("user", "usr1")
+	params.Add("field", "project")
+	params.Add("field", "total_time_seconds")
+
+	err = querier.Query(context.Background(), "usage", "global", params, &buf)
+	require.NoError(t, err)
+
+	var response Response[models.Usage]
+
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &response))
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, models.MetricMap{"walltime": 500}, response.Data[0].TotalTime)
+
+	// Errors of handlers must be returned
+	params = url.Values{}
+	params.Add("format", "xml")
+
+	err = querier.Query(context.Background(), "units", "", params, io.Discard)
+	require.Error(t, err)
+
+	// Invalid resource and mode
+	err = querier.Query(context.Background(), "projects", "", nil, io.Discard)
+	require.ErrorIs(t, err, errOfflineResource)
+
+	err = querier.Query(context.Background(), "usage", "all", nil, io.Discard)
+	require.ErrorIs(t, err, errOfflineMode)
+}
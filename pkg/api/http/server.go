@@ -27,7 +27,9 @@ import (
 	"github.com/go-chi/httprate"
 	"github.com/gorilla/mux"
 	"github.com/jellydator/ttlcache/v3"
+	"github.com/mahendrapaipuri/ceems/internal/audit"
 	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/internal/oidc"
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/db"
 	"github.com/mahendrapaipuri/ceems/pkg/api/http/docs"
@@ -48,6 +50,10 @@ const (
 	projectsResourceName   = "projects"
 	clustersResourceName   = "clusters"
 	statsResourceName      = "stats"
+	billingResourceName    = "billing"
+	budgetsResourceName    = "budgets"
+	tokensResourceName     = "tokens"
+	auditResourceName      = "audit"
 )
 
 // Usage modes.
@@ -66,6 +72,9 @@ type WebConfig struct {
 	MaxQueryPeriod    model.Duration          `yaml:"max_query"`
 	RequestsLimit     int                     `yaml:"requests_limit"`
 	URL               string                  `yaml:"url"`
+	OIDC              oidc.Config             `yaml:"oidc"`
+	LBSecret          config.Secret           `yaml:"lb_secret"`
+	Audit             audit.Config            `yaml:"audit"`
 	HTTPClientConfig  config.HTTPClientConfig `yaml:",inline"`
 }
 
@@ -96,9 +105,10 @@ func (c *WebConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Config makes a server config.
 type Config struct {
-	Logger *slog.Logger
-	Web    WebConfig
-	DB     db.Config
+	Logger   *slog.Logger
+	Web      WebConfig
+	DB       db.Config
+	Ingester Ingester // Inserts units pushed to ingest endpoint. Ingestion is disabled when nil
 }
 
 type queriers struct {
@@ -109,6 +119,14 @@ type queriers struct {
 	cluster func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Cluster, error)
 	stat    func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Stat, error)
 	key     func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Key, error)
+	invoice func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Invoice, error)
+	budget  func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Budget, error)
+	event   func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.UnitEvent, error)
+
+	timeSeries func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.UsageTimeSeries, error)
+
+	// unitStream streams units row by row instead of returning them at once
+	unitStream func(context.Context, *sql.DB, Query, *slog.Logger, func(models.Unit) error) error
 }
 
 // CEEMSServer struct implements HTTP server for stats.
@@ -121,27 +139,40 @@ type CEEMSServer struct {
 	maxQueryPeriod time.Duration
 	queriers       queriers
 	usageCache     *ttlcache.Cache[uint64, []models.Usage] // Cache that stores usage query results
+	keysCache      *ttlcache.Cache[string, []string]       // Cache that stores keys of JSON columns
 	healthCheck    func(*sql.DB, *slog.Logger) bool
+	tokens         *apiTokens
+	audit          *audit.Logger
+	done           chan struct{} // Closed when server shuts down to end event streams
+	ingester       Ingester
 }
 
 // Response defines the response model of CEEMSAPIServer.
 type Response[T any] struct {
-	Status    string    `json:"status"`
-	Data      []T       `json:"data"`
-	ErrorType errorType `json:"errorType,omitempty"`
-	Error     string    `json:"error,omitempty"`
-	Warnings  []string  `json:"warnings,omitempty"`
+	Status     string      `json:"status"`
+	Data       []T         `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	ErrorType  errorType   `json:"errorType,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Warnings   []string    `json:"warnings,omitempty"`
 }
 
 var (
+	// SQL functions that build JSON object from key value pairs in each dialect.
+	jsonObjectFuncs = map[string]string{
+		base.SQLite:   "json_object",
+		base.Postgres: "json_build_object",
+	}
+
 	aggUsageQueries    = make(map[string]string, len(base.UsageDBTableColNames))
+	rollupUsageSource  string
 	cacheTTL           = 15 * time.Minute
 	defaultQueryWindow = 24 * time.Hour // One day
 )
 
 const (
 	// Query to get quick stats like active projects, groups, jobs, etc.
-	statsQuery = `cluster_id,resource_manager,COUNT(*) AS num_units,COUNT(CASE WHEN ended_at_ts > 0 THEN 1 END) as num_inactive_units,COUNT(CASE WHEN ended_at_ts = 0 THEN 1 END) as num_active_units,COUNT(DISTINCT project) AS num_projects,COUNT(DISTINCT username) AS num_users`
+	statsQuery = `cluster_id,MIN(resource_manager) AS resource_manager,COUNT(*) AS num_units,COUNT(CASE WHEN ended_at_ts > 0 THEN 1 END) as num_inactive_units,COUNT(CASE WHEN ended_at_ts = 0 THEN 1 END) as num_active_units,COUNT(DISTINCT project) AS num_projects,COUNT(DISTINCT username) AS num_users`
 )
 
 // Make summary DB col names by using aggregate SQL functions.
@@ -158,13 +189,33 @@ func init() {
 				aggUsageQueries[col] = "SUM(u.num_updates) AS num_updates"
 			}
 		case strings.HasPrefix(col, "total"):
-			aggUsageQueries[col] = `{{- $mn := .MetricName -}}json_object({{range $i, $r := .MetricKeys}}{{if $i}},{{end}}'{{$r.Name}}',(SUM({{$mn}}_{{$r.Name}}.value)){{end}}) AS {{.MetricName}}||{{range $i, $r := .MetricKeys}}{{if $i}}|{{end}}json_each({{$mn}},'$.{{$r.Name}}') AS {{$mn}}_{{$r.Name}}{{end}}`
+			aggUsageQueries[col] = `{{- $mn := .MetricName -}}{{.JSONObject}}({{range $i, $r := .MetricKeys}}{{if $i}},{{end}}'{{$r.Name}}',(SUM({{$mn}}_{{$r.Name}}.value)){{end}}) AS {{.MetricName}}||{{range $i, $r := .MetricKeys}}{{if $i}}|{{end}}json_each({{$mn}},'$.{{$r.Name}}') AS {{$mn}}_{{$r.Name}}{{end}}`
 		case strings.HasPrefix(col, "avg"):
-			aggUsageQueries[col] = `{{- $mn := .MetricName -}}{{- $mw := .MetricWeight -}}{{- $tmn := .TimesMetricName -}}json_object({{range $i, $r := .MetricKeys}}{{if $i}},{{end}}'{{$r.Name}}',(SUM({{$mn}}_{{$r.Name}}.value*{{$tmn}}_{{$mw}}.value) / SUM({{$tmn}}_{{$mw}}.value)){{end}}) AS {{.MetricName}}||{{range $i, $r := .MetricKeys}}{{if $i}}|{{end}}json_each({{$mn}},'$.{{$r.Name}}') AS {{$mn}}_{{$r.Name}}{{end}}|json_each({{$tmn}},'$.{{$mw}}') AS {{$tmn}}_{{$mw}}`
+			aggUsageQueries[col] = `{{- $mn := .MetricName -}}{{- $mw := .MetricWeight -}}{{- $tmn := .TimesMetricName -}}{{.JSONObject}}({{range $i, $r := .MetricKeys}}{{if $i}},{{end}}'{{$r.Name}}',(SUM({{$mn}}_{{$r.Name}}.value*{{$tmn}}_{{$mw}}.value) / SUM({{$tmn}}_{{$mw}}.value)){{end}}) AS {{.MetricName}}||{{range $i, $r := .MetricKeys}}{{if $i}}|{{end}}json_each({{$mn}},'$.{{$r.Name}}') AS {{$mn}}_{{$r.Name}}{{end}}|json_each({{$tmn}},'$.{{$mw}}') AS {{$tmn}}_{{$mw}}`
 		default:
-			aggUsageQueries[col] = col
+			// Columns that are not in GROUP BY clause must be aggregated
+			// for PostgreSQL. MIN is a no-op for columns that are grouped
+			aggUsageQueries[col] = fmt.Sprintf("MIN(%[1]s) AS %[1]s", col)
+		}
+	}
+
+	// When expired units are rolled up into monthly usage, historical usage is
+	// estimated from union of monthly rollups and units. Each unit is counted
+	// as one unit
+	cols := []string{"id", "cluster_id", "resource_manager", "project", "groupname", "username", "num_updates", "last_updated_at"}
+
+	for _, col := range base.UsageDBTableColNames {
+		if strings.HasPrefix(col, "total") || strings.HasPrefix(col, "avg") {
+			cols = append(cols, col)
 		}
 	}
+
+	// Allocation and tags of units are included so that usage of units can still
+	// be grouped by dimensions. Rollups do not have them
+	rollupUsageSource = fmt.Sprintf(
+		"(SELECT %[1]s,num_units,NULL AS allocation,NULL AS tags FROM %[2]s UNION ALL SELECT %[1]s,1 AS num_units,allocation,tags FROM %[3]s WHERE parent_uuid = '')",
+		strings.Join(cols, ","), base.MonthlyUsageDBTableName, base.UnitsDBTableName,
+	)
 }
 
 // Ping DB for connection test.
@@ -178,6 +229,25 @@ func getDBStatus(dbConn *sql.DB, logger *slog.Logger) bool {
 	return true
 }
 
+// newQueriers returns queriers that fetch data from DB.
+func newQueriers() queriers {
+	return queriers{
+		unit:    Querier[models.Unit],
+		usage:   Querier[models.Usage],
+		user:    Querier[models.User],
+		project: Querier[models.Project],
+		cluster: Querier[models.Cluster],
+		stat:    Querier[models.Stat],
+		key:     Querier[models.Key],
+		invoice: Querier[models.Invoice],
+		budget:  Querier[models.Budget],
+		event:   Querier[models.UnitEvent],
+
+		timeSeries: Querier[models.UsageTimeSeries],
+		unitStream: Streamer[models.Unit],
+	}
+}
+
 // New creates new CEEMSServer struct instance.
 func New(c *Config) (*CEEMSServer, func(), error) {
 	var err error
@@ -199,16 +269,10 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 		},
 		dbConfig:       c.DB,
 		maxQueryPeriod: time.Duration(c.Web.MaxQueryPeriod),
-		queriers: queriers{
-			unit:    Querier[models.Unit],
-			usage:   Querier[models.Usage],
-			user:    Querier[models.User],
-			project: Querier[models.Project],
-			cluster: Querier[models.Cluster],
-			stat:    Querier[models.Stat],
-			key:     Querier[models.Key],
-		},
-		healthCheck: getDBStatus,
+		queriers:       newQueriers(),
+		healthCheck:    getDBStatus,
+		done:           make(chan struct{}),
+		ingester:       c.Ingester,
 	}
 
 	// Get route prefix based on external URL path
@@ -251,18 +315,58 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 	subRouter.HandleFunc("/"+unitsResourceName, server.units).Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:current|global)}", usageResourceName), server.usage).
 		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/timeseries", usageResourceName), server.usageTimeSeries).
+		Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/verify", unitsResourceName), server.verifyUnitsOwnership).
 		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/events", unitsResourceName), server.unitEvents).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/efficiency", unitsResourceName), server.unitsEfficiency).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:current|global)}/efficiency", usageResourceName), server.usageEfficiency).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{uuid}/steps", unitsResourceName), server.unitSteps).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:user|project)}", billingResourceName), server.billing).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc("/"+budgetsResourceName, server.budgets).Methods(http.MethodGet)
+
+	// API tokens management end points
+	subRouter.HandleFunc("/"+tokensResourceName, server.listTokens).Methods(http.MethodGet)
+	subRouter.HandleFunc("/"+tokensResourceName, server.createToken).Methods(http.MethodPost)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{id}", tokensResourceName), server.revokeToken).Methods(http.MethodDelete)
 
 	// Admin end points
 	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", usersResourceName), server.usersAdmin).Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", projectsResourceName), server.projectsAdmin).Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", clustersResourceName), server.clustersAdmin).Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", unitsResourceName), server.unitsAdmin).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{uuid}/steps/admin", unitsResourceName), server.unitStepsAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/events/admin", unitsResourceName), server.unitEventsAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/efficiency/admin", unitsResourceName), server.unitsEfficiencyAdmin).
+		Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:current|global)}/admin", usageResourceName), server.usageAdmin).
 		Methods(http.MethodGet)
+	subRouter.HandleFunc(
+		fmt.Sprintf("/%s/{mode:(?:current|global)}/efficiency/admin", usageResourceName), server.usageEfficiencyAdmin,
+	).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/timeseries/admin", usageResourceName), server.usageTimeSeriesAdmin).
+		Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:current|global)}/admin", statsResourceName), server.statsAdmin).
 		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:user|project)}/admin", billingResourceName), server.billingAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", budgetsResourceName), server.budgetsAdmin).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", tokensResourceName), server.tokensAdmin).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{id}/admin", tokensResourceName), server.revokeTokenAdmin).
+		Methods(http.MethodDelete)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", auditResourceName), server.auditAdmin).Methods(http.MethodGet)
+
+	// Ingest end point for clusters with push manager. It is restricted to admin
+	// users by authentication middleware
+	subRouter.HandleFunc(fmt.Sprintf("/%s/ingest", unitsResourceName), server.ingestUnits).Methods(http.MethodPost)
 
 	// A demo end point that returns mocked data for units and/or usage tables
 	subRouter.HandleFunc("/demo/{resource:(?:units|usage)}", server.demo).Methods(http.MethodGet)
@@ -279,14 +383,41 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 		httpSwagger.DomID("swagger-ui"),
 	)).Methods(http.MethodGet)
 
+	// API tokens are the only data written by API server. Use a separate
+	// connection for them as SQLite DB is opened in read only mode
+	server.tokens = &apiTokens{
+		logger:   c.Logger,
+		location: c.DB.Data.Timezone.Location,
+		now:      time.Now,
+	}
+
 	// Open DB connection
-	dsn := fmt.Sprintf(
-		"file:%s?%s",
-		filepath.Join(c.DB.Data.Path, base.CEEMSDBName),
-		"_mutex=no&mode=ro&_busy_timeout=5000",
-	)
-	if server.db, err = sql.Open(sqlite3.DriverName, dsn); err != nil {
-		return nil, func() {}, fmt.Errorf("failed to open DB: %w", err)
+	if c.DB.Data.Driver == db.Postgres {
+		if server.db, err = db.OpenPostgres(string(c.DB.Data.DSN)); err != nil {
+			return nil, func() {}, fmt.Errorf("failed to open DB: %w", err)
+		}
+
+		server.tokens.db = server.db
+	} else {
+		dbPath := filepath.Join(c.DB.Data.Path, base.CEEMSDBName)
+
+		dsn := fmt.Sprintf("file:%s?%s", dbPath, "_mutex=no&mode=ro&_busy_timeout=5000")
+		if server.db, err = sql.Open(sqlite3.DriverName, dsn); err != nil {
+			return nil, func() {}, fmt.Errorf("failed to open DB: %w", err)
+		}
+
+		dsn = fmt.Sprintf("file:%s?%s", dbPath, "_mutex=no&_busy_timeout=5000")
+		if server.tokens.db, err = sql.Open(sqlite3.DriverName, dsn); err != nil {
+			return nil, func() {}, fmt.Errorf("failed to open DB: %w", err)
+		}
+	}
+
+	// Record access decisions in audit log. Audit records are written using the
+	// same read-write connection as API tokens
+	if c.Web.Audit.Enabled() {
+		if server.audit, err = newAuditLogger(c.Web.Audit, server.tokens.db, c.Logger); err != nil {
+			return nil, func() {}, fmt.Errorf("failed to setup audit log: %w", err)
+		}
 	}
 
 	// Rate limit requests by RealIP
@@ -303,7 +434,18 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 		whitelistedURLs: regexp.MustCompile(routePrefix + "(swagger|health|demo)(.*)"),
 		db:              server.db,
 		adminUsers:      adminUsers,
+		tokens:          server.tokens,
+		audit:           server.audit,
+		lbSecret:        string(c.Web.LBSecret),
 	}
+
+	// Verify bearer tokens when OIDC authentication is enabled
+	if c.Web.OIDC.Enabled() {
+		if amw.verifier, err = oidc.NewVerifier(c.Web.OIDC, c.Logger); err != nil {
+			return nil, func() {}, fmt.Errorf("failed to setup OIDC authentication: %w", err)
+		}
+	}
+
 	router.Use(amw.Middleware)
 
 	// Instantiate new cache for storing current usage query results with TTL of 15 min
@@ -313,6 +455,13 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 	// starts automatic expired item deletion
 	go server.usageCache.Start()
 
+	// Instantiate new cache for storing keys of JSON columns that are used to
+	// validate filters and dimensions
+	server.keysCache = ttlcache.New(
+		ttlcache.WithTTL[string, []string](cacheTTL),
+	)
+	go server.keysCache.Start()
+
 	return server, func() {}, nil
 }
 
@@ -361,6 +510,14 @@ func (s *CEEMSServer) Start() error {
 
 // Shutdown server.
 func (s *CEEMSServer) Shutdown(ctx context.Context) error {
+	// End event streams before closing DB connection as clients never end them
+	close(s.done)
+
+	// Write pending audit records before closing DB connection
+	if err := s.audit.Close(); err != nil {
+		s.logger.Error("Failed to close audit log", "err", err)
+	}
+
 	// Close DB connection
 	if err := s.db.Close(); err != nil {
 		s.logger.Error("Failed to close DB connection", "err", err)
@@ -368,6 +525,15 @@ func (s *CEEMSServer) Shutdown(ctx context.Context) error {
 		return err
 	}
 
+	// Close connection of API tokens if it is not shared
+	if s.tokens != nil && s.tokens.db != s.db {
+		if err := s.tokens.db.Close(); err != nil {
+			s.logger.Error("Failed to close DB connection", "err", err)
+
+			return err
+		}
+	}
+
 	// Shutdown the server
 	if err := s.server.Shutdown(ctx); err != nil {
 		s.logger.Error("Failed to shutdown HTTP server", "err", err)
@@ -550,6 +716,46 @@ func (s *CEEMSServer) getQueryWindow(r *http.Request, column string, running boo
 	return subQuery, nil
 }
 
+// rollupQueryWindow returns the query window on monthly rollups of expired units
+// when the retention policy of DB is rollup and `from` query parameter is older
+// than retention period. Rollups are aggregated per month and hence, `from` is
+// truncated to the start of its month. The returned boolean is false when the
+// query can be answered from units alone.
+func (s *CEEMSServer) rollupQueryWindow(r *http.Request) (Query, bool) {
+	if s.dbConfig.Data.RetentionPolicy != db.RetentionPolicyRollup {
+		return Query{}, false
+	}
+
+	q := r.URL.Query()
+	loc := s.dbConfig.Data.Timezone.Location
+
+	// Query window must have been validated already and hence, we ignore errors
+	from, err := strconv.ParseInt(q.Get("from"), 10, 64)
+	if err != nil {
+		return Query{}, false
+	}
+
+	fromTime := time.Unix(from, 0).In(loc)
+	if !fromTime.Before(time.Now().Add(-time.Duration(s.dbConfig.Data.RetentionPeriod))) {
+		return Query{}, false
+	}
+
+	toTime := time.Now().In(loc)
+	if to, err := strconv.ParseInt(q.Get("to"), 10, 64); err == nil {
+		toTime = time.Unix(to, 0).In(loc)
+	}
+
+	fromTime = time.Date(fromTime.Year(), fromTime.Month(), 1, 0, 0, 0, 0, loc)
+
+	subQuery := Query{}
+	subQuery.query("last_updated_at BETWEEN ")
+	subQuery.param([]string{fromTime.Format(base.DatetimeLayout)})
+	subQuery.query(" AND ")
+	subQuery.param([]string{toTime.Format(base.DatetimeLayout)})
+
+	return subQuery, true
+}
+
 // roundQueryWindow rounds `to` and `from` query parameters to nearest multiple of
 // `cacheTTL`.
 func (s *CEEMSServer) roundQueryWindow(r *http.Request) error {
@@ -629,9 +835,11 @@ func (s *CEEMSServer) inTargetTimeLocation(tz string, units []models.Unit) []mod
 	return units
 }
 
-// unitsQuerier queries for compute units and write response.
+// unitsQuerier queries for compute units and write response. Units of all users
+// in the projects coordinated by coordinators are included as well.
 func (s *CEEMSServer) unitsQuerier(
 	queriedUsers []string,
+	coordinators []string,
 	w http.ResponseWriter,
 	r *http.Request,
 ) {
@@ -662,22 +870,51 @@ func (s *CEEMSServer) unitsQuerier(
 		return
 	}
 
-	// Initialise query builder
+	// Get response format
+	format, err := responseFormat(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Get sorting and pagination query parameters if any
+	page, err := getPageQuery(r.URL.Query())
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Initialise query builder. Sort field and id are needed to estimate
+	// cursor of next page
 	q := Query{}
-	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(queriedFields, ","), base.UnitsDBTableName))
+	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(page.selectFields(queriedFields), ","), base.UnitsDBTableName))
 
-	// Query for only unignored units
-	q.query(" WHERE ignore = 0 ")
+	// Query for only unignored units. Sub units are returned by steps endpoint
+	q.query(" WHERE ignore = 0 AND parent_uuid = '' ")
 
 	// Add condition to query only for current dashboardUser
 	if len(queriedUsers) > 0 {
-		q.query(" AND username IN ")
-		q.param(queriedUsers)
+		q.query(" AND ")
+		q.subQuery(unitsOwnersQuery(queriedUsers, coordinators))
 	}
 
 	// Add common query parameters
 	q = s.getCommonQueryParams(&q, r.URL.Query())
 
+	// Add filters on values of map fields
+	filters, err := s.unitFilters(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	for _, f := range filters {
+		q.filter(f, base.Dialect(s.db))
+	}
+
 	// Check if uuid present in query params and add them
 	// If any of uuid query params are present
 	// do not check query window as we are fetching a specific unit(s)
@@ -710,8 +947,19 @@ func (s *CEEMSServer) unitsQuerier(
 	q.subQuery(timeQuery)
 
 queryUnits:
-	// Sort by uuid
-	q.query(" ORDER BY cluster_id ASC, uuid ASC ")
+	// Sort by cluster_id and uuid unless a sort field is requested
+	if err = page.apply(&q, "cluster_id ASC, uuid ASC ", base.UnitsDBTableColNames); err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Stream units directly from DB rows for NDJSON exports
+	if format == ndjsonFormat {
+		s.streamUnits(q, w, r)
+
+		return
+	}
 
 	// Get all user units in the given time window
 	units, err := s.queriers.unit(r.Context(), s.db, q, s.logger)
@@ -725,6 +973,86 @@ queryUnits:
 	// Convert times to time zone provided in the query
 	units = s.inTargetTimeLocation(r.URL.Query().Get("timezone"), units)
 
+	// Write units in CSV format if requested
+	if writeExport(w, format, unitsResourceName, queriedFields, units, s.logger) {
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	response := Response[models.Unit]{
+		Status:     "success",
+		Data:       units,
+		Pagination: nextPage(page, units),
+	}
+	if err != nil {
+		response.Warnings = append(response.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// unitStepsQuerier queries for sub units of a unit and write response.
+func (s *CEEMSServer) unitStepsQuerier(queriedUsers []string, coordinators []string, w http.ResponseWriter, r *http.Request) {
+	// Get current logged user and dashboard user from headers
+	loggedUser, _ := s.getUser(r)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Get UUID of parent unit from path
+	uuid, exists := mux.Vars(r)["uuid"]
+	if !exists || uuid == "" {
+		errorResponse[any](w, &apiError{errorBadData, errInvalidRequest}, s.logger, nil)
+
+		return
+	}
+
+	// Get fields query parameters if any
+	queriedFields := s.getQueriedFields(r.URL.Query(), base.UnitsDBTableColNames)
+	if len(queriedFields) == 0 {
+		s.logger.Error("Invalid query fields", "loggedUser", loggedUser, "err", errInvalidQueryField)
+		errorResponse[any](w, &apiError{errorBadData, errInvalidQueryField}, s.logger, nil)
+
+		return
+	}
+
+	// Initialise query builder
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(queriedFields, ","), base.UnitsDBTableName))
+
+	// Query for sub units of the given unit
+	q.query(" WHERE parent_uuid IN ")
+	q.param([]string{uuid})
+
+	// Add condition to query only for current dashboardUser
+	if len(queriedUsers) > 0 {
+		q.query(" AND ")
+		q.subQuery(unitsOwnersQuery(queriedUsers, coordinators))
+	}
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	// Sort by uuid
+	q.query(" ORDER BY cluster_id ASC, uuid ASC ")
+
+	// Get all sub units
+	units, err := s.queriers.unit(r.Context(), s.db, q, s.logger)
+	if units == nil && err != nil {
+		s.logger.Error("Failed to fetch unit steps", "loggedUser", loggedUser, "uuid", uuid, "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	// Convert times to time zone provided in the query
+	units = s.inTargetTimeLocation(r.URL.Query().Get("timezone"), units)
+
 	// Write response
 	w.WriteHeader(http.StatusOK)
 
@@ -767,24 +1095,49 @@ queryUnits:
 //	@Description
 //	@Description	To limit the number of fields in the response, use `field` query parameter. By default, all
 //	@Description	fields will be included in the response if they are _non-empty_.
-//	@Security		BasicAuth
-//	@Tags			units
-//	@Produce		json
-//	@Param			X-Grafana-User	header		string		true	"Current user name"
-//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
-//	@Param			uuid			query		[]string	false	"Unit UUID"		collectionFormat(multi)
-//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
-//	@Param			user			query		[]string	false	"User name"		collectionFormat(multi)
-//	@Param			running			query		bool		false	"Whether to fetch running units"
-//	@Param			from			query		string		false	"From timestamp"
-//	@Param			to				query		string		false	"To timestamp"
-//	@Param			timezone		query		string		false	"Time zone in IANA format"
-//	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
-//	@Success		200				{object}	Response[models.Unit]
-//	@Failure		401				{object}	Response[any]
-//	@Failure		403				{object}	Response[any]
-//	@Failure		500				{object}	Response[any]
-//	@Router			/units/admin [get]
+//	@Description
+//	@Description	Results can be paginated using `limit` and `offset` query parameters and sorted
+//	@Description	on any field using `sort` and `order` query parameters. When results are sorted by
+//	@Description	`id` or `ended_at_ts`, `cursor` query parameter can be used instead of `offset`.
+//	@Description	The `pagination` object in the response contains `next_offset` and/or `next_cursor`
+//	@Description	to fetch the next page.
+//	@Description	The response can be exported in CSV or newline delimited JSON (NDJSON) formats
+//	@Description	using the query parameter `format` or `Accept` header (`text/csv` or
+//	@Description	`application/x-ndjson`). In CSV format, map fields are flattened into one column
+//	@Description	per key, for instance, `total_cpu_energy_usage_kwh.total`.
+//	@Description
+//	@Description	Units can be filtered on the values of map fields using `filter` query parameter
+//	@Description	of form `<field>.<key><op><value>` where `op` is one of `==`, `!=`, `<`, `<=`, `>`
+//	@Description	and `>=`. For instance, `?filter=avg_gpu_usage.global<10&filter=tags.partition==gpu`.
+//	@Description	Multiple filters are combined with AND and only existing keys can be used.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		units
+//	@Produce	json
+//	@Produce	text/csv
+//	@Produce	application/x-ndjson
+//	@Param		X-Grafana-User	header		string		true	"Current user name"
+//	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param		uuid			query		[]string	false	"Unit UUID"		collectionFormat(multi)
+//	@Param		project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param		user			query		[]string	false	"User name"		collectionFormat(multi)
+//	@Param		running			query		bool		false	"Whether to fetch running units"
+//	@Param		from			query		string		false	"From timestamp"
+//	@Param		to				query		string		false	"To timestamp"
+//	@Param		timezone		query		string		false	"Time zone in IANA format"
+//	@Param		field			query		[]string	false	"Fields to return in response"		collectionFormat(multi)
+//	@Param		filter			query		[]string	false	"Filters on values of map fields"	collectionFormat(multi)
+//	@Param		limit			query		integer		false	"Maximum number of units to return"
+//	@Param		offset			query		integer		false	"Number of units to skip"
+//	@Param		cursor			query		string		false	"Cursor returned in pagination of previous page"
+//	@Param		sort			query		string		false	"Field to sort units"
+//	@Param		order			query		string		false	"Sort order"		Enums(asc, desc)
+//	@Param		format			query		string		false	"Response format"	Enums(json, csv, ndjson)
+//	@Success	200				{object}	Response[models.Unit]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	403				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/units/admin [get]
 //
 // GET /units/admin
 // Get any unit of any user.
@@ -793,7 +1146,7 @@ func (s *CEEMSServer) unitsAdmin(w http.ResponseWriter, r *http.Request) {
 	defer common.TimeTrack(time.Now(), "units admin endpoint", s.logger)
 
 	// Query for units and write response
-	s.unitsQuerier(r.URL.Query()["user"], w, r)
+	s.unitsQuerier(r.URL.Query()["user"], nil, w, r)
 }
 
 // units         godoc
@@ -818,35 +1171,143 @@ func (s *CEEMSServer) unitsAdmin(w http.ResponseWriter, r *http.Request) {
 //	@Description
 //	@Description	To limit the number of fields in the response, use `field` query parameter. By default, all
 //	@Description	fields will be included in the response if they are _non-empty_.
+//	@Description
+//	@Description	Results can be paginated using `limit` and `offset` query parameters and sorted
+//	@Description	on any field using `sort` and `order` query parameters. When results are sorted by
+//	@Description	`id` or `ended_at_ts`, `cursor` query parameter can be used instead of `offset`.
+//	@Description	The `pagination` object in the response contains `next_offset` and/or `next_cursor`
+//	@Description	to fetch the next page.
+//	@Description	The response can be exported in CSV or newline delimited JSON (NDJSON) formats
+//	@Description	using the query parameter `format` or `Accept` header (`text/csv` or
+//	@Description	`application/x-ndjson`). In CSV format, map fields are flattened into one column
+//	@Description	per key, for instance, `total_cpu_energy_usage_kwh.total`.
+//	@Description
+//	@Description	Units can be filtered on the values of map fields using `filter` query parameter
+//	@Description	of form `<field>.<key><op><value>` where `op` is one of `==`, `!=`, `<`, `<=`, `>`
+//	@Description	and `>=`. For instance, `?filter=avg_gpu_usage.global<10&filter=tags.partition==gpu`.
+//	@Description	Multiple filters are combined with AND and only existing keys can be used.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		units
+//	@Produce	json
+//	@Produce	text/csv
+//	@Produce	application/x-ndjson
+//	@Param		X-Grafana-User	header		string		true	"Current user name"
+//	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param		uuid			query		[]string	false	"Unit UUID"		collectionFormat(multi)
+//	@Param		project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param		running			query		bool		false	"Whether to fetch running units"
+//	@Param		from			query		string		false	"From timestamp"
+//	@Param		to				query		string		false	"To timestamp"
+//	@Param		timezone		query		string		false	"Time zone in IANA format"
+//	@Param		field			query		[]string	false	"Fields to return in response"		collectionFormat(multi)
+//	@Param		filter			query		[]string	false	"Filters on values of map fields"	collectionFormat(multi)
+//	@Param		limit			query		integer		false	"Maximum number of units to return"
+//	@Param		offset			query		integer		false	"Number of units to skip"
+//	@Param		cursor			query		string		false	"Cursor returned in pagination of previous page"
+//	@Param		sort			query		string		false	"Field to sort units"
+//	@Param		order			query		string		false	"Sort order"		Enums(asc, desc)
+//	@Param		format			query		string		false	"Response format"	Enums(json, csv, ndjson)
+//	@Success	200				{object}	Response[models.Unit]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	403				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/units [get]
+//
+// GET /units
+// Get unit of dashboard user.
+func (s *CEEMSServer) units(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "units endpoint", s.logger)
+
+	// Get current logged user and dashboard user from headers
+	_, dashboardUser := s.getUser(r)
+
+	// Query for units and write response
+	s.unitsQuerier([]string{dashboardUser}, []string{dashboardUser}, w, r)
+}
+
+// unitStepsAdmin    godoc
+//
+//	@Summary		Admin endpoint for fetching steps of a compute unit.
+//	@Description	This admin endpoint will fetch sub units of a compute unit of _any_ user. Sub units
+//	@Description	are job steps and components of heterogeneous jobs of batch schedulers. They are
+//	@Description	stored only when the resource manager is configured to fetch them.
+//	@Description	The current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	If query parameter `timezone` is provided, the unit's created, start and end time strings
+//	@Description	will be presented in that time zone.
+//	@Description
+//	@Description	To limit the number of fields in the response, use `field` query parameter. By default, all
+//	@Description	fields will be included in the response if they are _non-empty_.
 //	@Security		BasicAuth
 //	@Tags			units
 //	@Produce		json
 //	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			uuid			path		string		true	"Unit UUID"
 //	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
-//	@Param			uuid			query		[]string	false	"Unit UUID"		collectionFormat(multi)
-//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
-//	@Param			running			query		bool		false	"Whether to fetch running units"
-//	@Param			from			query		string		false	"From timestamp"
-//	@Param			to				query		string		false	"To timestamp"
+//	@Param			user			query		[]string	false	"User name"		collectionFormat(multi)
 //	@Param			timezone		query		string		false	"Time zone in IANA format"
 //	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
 //	@Success		200				{object}	Response[models.Unit]
 //	@Failure		401				{object}	Response[any]
 //	@Failure		403				{object}	Response[any]
 //	@Failure		500				{object}	Response[any]
-//	@Router			/units [get]
+//	@Router			/units/{uuid}/steps/admin [get]
 //
-// GET /units
-// Get unit of dashboard user.
-func (s *CEEMSServer) units(w http.ResponseWriter, r *http.Request) {
+// GET /units/{uuid}/steps/admin
+// Get steps of any unit of any user.
+func (s *CEEMSServer) unitStepsAdmin(w http.ResponseWriter, r *http.Request) {
 	// Measure elapsed time
-	defer common.TimeTrack(time.Now(), "units endpoint", s.logger)
+	defer common.TimeTrack(time.Now(), "unit steps admin endpoint", s.logger)
+
+	// Query for unit steps and write response
+	s.unitStepsQuerier(r.URL.Query()["user"], nil, w, r)
+}
+
+// unitSteps         godoc
+//
+//	@Summary		User endpoint for fetching steps of a compute unit
+//	@Description	This user endpoint will fetch sub units of a compute unit of the current user.
+//	@Description	Sub units are job steps and components of heterogeneous jobs of batch schedulers.
+//	@Description	They are stored only when the resource manager is configured to fetch them.
+//	@Description	The current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	If query parameter `timezone` is provided, the unit's created, start and end time strings
+//	@Description	will be presented in that time zone.
+//	@Description
+//	@Description	To limit the number of fields in the response, use `field` query parameter. By default, all
+//	@Description	fields will be included in the response if they are _non-empty_.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			uuid			path		string		true	"Unit UUID"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			timezone		query		string		false	"Time zone in IANA format"
+//	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
+//	@Success		200				{object}	Response[models.Unit]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		403				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/units/{uuid}/steps [get]
+//
+// GET /units/{uuid}/steps
+// Get steps of unit of dashboard user.
+func (s *CEEMSServer) unitSteps(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "unit steps endpoint", s.logger)
 
 	// Get current logged user and dashboard user from headers
 	_, dashboardUser := s.getUser(r)
 
-	// Query for units and write response
-	s.unitsQuerier([]string{dashboardUser}, w, r)
+	// Query for unit steps and write response
+	s.unitStepsQuerier([]string{dashboardUser}, []string{dashboardUser}, w, r)
 }
 
 // verifyUnitsOwnership         godoc
@@ -916,8 +1377,13 @@ func (s *CEEMSServer) verifyUnitsOwnership(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	// Admin users who are not impersonating other users can access all units. Admin
+	// users identified by groups in bearer token are not in DB and hence, they must
+	// be checked here
+	isAdmin := dashboardUser != "" && r.Header.Get(adminUserHeader) == dashboardUser
+
 	// Check if user is owner of the queries uuids
-	if VerifyOwnership(r.Context(), dashboardUser, clusterID, uuids, starts, s.db, s.logger) {
+	if isAdmin || VerifyOwnership(r.Context(), dashboardUser, clusterID, uuids, starts, s.db, s.logger) {
 		w.WriteHeader(http.StatusOK)
 
 		response := Response[string]{
@@ -999,11 +1465,20 @@ func (s *CEEMSServer) clustersAdmin(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// Get user details.
-func (s *CEEMSServer) usersQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+// Get user details. Users of the projects coordinated by coordinators are
+// included as well.
+func (s *CEEMSServer) usersQuerier(users []string, coordinators []string, w http.ResponseWriter, r *http.Request) {
 	// Set headers
 	s.setHeaders(w)
 
+	// Get sorting and pagination query parameters if any
+	page, err := getPageQuery(r.URL.Query())
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
 	// Make query
 	q := Query{}
 	q.query("SELECT * FROM " + base.UsersDBTableName)
@@ -1012,8 +1487,20 @@ func (s *CEEMSServer) usersQuerier(users []string, w http.ResponseWriter, r *htt
 	if len(users) == 0 {
 		q.query(" WHERE name LIKE '%' ")
 	} else {
-		q.query(" WHERE name IN ")
+		q.query(" WHERE (name IN ")
 		q.param(users)
+
+		// Add users of projects coordinated by coordinators
+		if len(coordinators) > 0 {
+			q.query(" OR EXISTS ")
+
+			innerQuery := Query{}
+			innerQuery.query("SELECT 1 FROM json_each(projects) WHERE (cluster_id, value) IN ")
+			innerQuery.subQuery(coordinatedProjectsSubQuery(coordinators))
+			q.subQuery(innerQuery)
+		}
+
+		q.query(") ")
 	}
 
 	// Get cluster_id query parameters if any
@@ -1022,8 +1509,12 @@ func (s *CEEMSServer) usersQuerier(users []string, w http.ResponseWriter, r *htt
 		q.param(clusterIDs)
 	}
 
-	// Sort by cluster_id and name
-	q.query(" ORDER BY cluster_id ASC, name ASC ")
+	// Sort by cluster_id and name unless a sort field is requested
+	if err = page.apply(&q, "cluster_id ASC, name ASC ", base.UsersDBTableColNames); err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
 
 	// Make query and check for users returned in usage
 	userModels, err := s.queriers.user(r.Context(), s.db, q, s.logger)
@@ -1038,8 +1529,9 @@ func (s *CEEMSServer) usersQuerier(users []string, w http.ResponseWriter, r *htt
 	w.WriteHeader(http.StatusOK)
 
 	usersResponse := Response[models.User]{
-		Status: "success",
-		Data:   userModels,
+		Status:     "success",
+		Data:       userModels,
+		Pagination: nextPage(page, userModels),
 	}
 	if err != nil {
 		usersResponse.Warnings = append(usersResponse.Warnings, err.Error())
@@ -1065,6 +1557,11 @@ func (s *CEEMSServer) usersQuerier(users []string, w http.ResponseWriter, r *htt
 //	@Produce	json
 //	@Param		X-Grafana-User	header		string		true	"Current user name"
 //	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param		limit			query		integer		false	"Maximum number of results to return"
+//	@Param		offset			query		integer		false	"Number of results to skip"
+//	@Param		cursor			query		string		false	"Cursor returned in pagination of previous page"
+//	@Param		sort			query		string		false	"Field to sort results"
+//	@Param		order			query		string		false	"Sort order"	Enums(asc, desc)
 //	@Success	200				{object}	Response[models.User]
 //	@Failure	401				{object}	Response[any]
 //	@Failure	500				{object}	Response[any]
@@ -1080,7 +1577,7 @@ func (s *CEEMSServer) users(w http.ResponseWriter, r *http.Request) {
 	_, dashboardUser := s.getUser(r)
 
 	// Query for users and write response
-	s.usersQuerier([]string{dashboardUser}, w, r)
+	s.usersQuerier([]string{dashboardUser}, []string{dashboardUser}, w, r)
 }
 
 // usersAdmin         godoc
@@ -1104,6 +1601,11 @@ func (s *CEEMSServer) users(w http.ResponseWriter, r *http.Request) {
 //	@Param		X-Grafana-User	header		string		true	"Current user name"
 //	@Param		user			query		[]string	false	"User name"		collectionFormat(multi)
 //	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param		limit			query		integer		false	"Maximum number of results to return"
+//	@Param		offset			query		integer		false	"Number of results to skip"
+//	@Param		cursor			query		string		false	"Cursor returned in pagination of previous page"
+//	@Param		sort			query		string		false	"Field to sort results"
+//	@Param		order			query		string		false	"Sort order"	Enums(asc, desc)
 //	@Success	200				{object}	Response[models.User]
 //	@Failure	401				{object}	Response[any]
 //	@Failure	500				{object}	Response[any]
@@ -1116,7 +1618,7 @@ func (s *CEEMSServer) usersAdmin(w http.ResponseWriter, r *http.Request) {
 	defer common.TimeTrack(time.Now(), "users admin endpoint", s.logger)
 
 	// Query for users and write response
-	s.usersQuerier(r.URL.Query()["user"], w, r)
+	s.usersQuerier(r.URL.Query()["user"], nil, w, r)
 }
 
 // Get project details.
@@ -1124,6 +1626,14 @@ func (s *CEEMSServer) projectsQuerier(users []string, w http.ResponseWriter, r *
 	// Set headers
 	s.setHeaders(w)
 
+	// Get sorting and pagination query parameters if any
+	page, err := getPageQuery(r.URL.Query())
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
 	// Get sub query for projects
 	qSub := projectsSubQuery(users)
 
@@ -1132,7 +1642,7 @@ func (s *CEEMSServer) projectsQuerier(users []string, w http.ResponseWriter, r *
 	q.query("SELECT * FROM " + base.ProjectsDBTableName)
 
 	// First select all projects that user is part of using subquery
-	q.query(" WHERE name IN ")
+	q.query(" WHERE (cluster_id, name) IN ")
 	q.subQuery(qSub)
 
 	// Get project query parameters if any
@@ -1147,8 +1657,12 @@ func (s *CEEMSServer) projectsQuerier(users []string, w http.ResponseWriter, r *
 		q.param(clusterIDs)
 	}
 
-	// Sort by cluster_id and name
-	q.query(" ORDER BY cluster_id ASC, name ASC ")
+	// Sort by cluster_id and name unless a sort field is requested
+	if err = page.apply(&q, "cluster_id ASC, name ASC ", base.ProjectsDBTableColNames); err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
 
 	// Make query
 	projectModels, err := s.queriers.project(r.Context(), s.db, q, s.logger)
@@ -1166,8 +1680,9 @@ func (s *CEEMSServer) projectsQuerier(users []string, w http.ResponseWriter, r *
 	w.WriteHeader(http.StatusOK)
 
 	projectsResponse := Response[models.Project]{
-		Status: "success",
-		Data:   projectModels,
+		Status:     "success",
+		Data:       projectModels,
+		Pagination: nextPage(page, projectModels),
 	}
 	if err != nil {
 		projectsResponse.Warnings = append(projectsResponse.Warnings, err.Error())
@@ -1196,6 +1711,11 @@ func (s *CEEMSServer) projectsQuerier(users []string, w http.ResponseWriter, r *
 //	@Param		X-Grafana-User	header		string		true	"Current user name"
 //	@Param		project			query		[]string	false	"Project"		collectionFormat(multi)
 //	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param		limit			query		integer		false	"Maximum number of results to return"
+//	@Param		offset			query		integer		false	"Number of results to skip"
+//	@Param		cursor			query		string		false	"Cursor returned in pagination of previous page"
+//	@Param		sort			query		string		false	"Field to sort results"
+//	@Param		order			query		string		false	"Sort order"	Enums(asc, desc)
 //	@Success	200				{object}	Response[models.Project]
 //	@Failure	401				{object}	Response[any]
 //	@Failure	500				{object}	Response[any]
@@ -1234,6 +1754,11 @@ func (s *CEEMSServer) projects(w http.ResponseWriter, r *http.Request) {
 //	@Param		X-Grafana-User	header		string		true	"Current user name"
 //	@Param		project			query		[]string	false	"Project"		collectionFormat(multi)
 //	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param		limit			query		integer		false	"Maximum number of results to return"
+//	@Param		offset			query		integer		false	"Number of results to skip"
+//	@Param		cursor			query		string		false	"Cursor returned in pagination of previous page"
+//	@Param		sort			query		string		false	"Field to sort results"
+//	@Param		order			query		string		false	"Sort order"	Enums(asc, desc)
 //	@Success	200				{object}	Response[models.Project]
 //	@Failure	401				{object}	Response[any]
 //	@Failure	500				{object}	Response[any]
@@ -1253,11 +1778,12 @@ func (s *CEEMSServer) projectsAdmin(w http.ResponseWriter, r *http.Request) {
 func (s *CEEMSServer) aggQueryBuilder(
 	r *http.Request,
 	metric string,
+	table string,
 	timeQuery Query,
 ) string {
 	// Query to return all unqiue json keys
 	q := Query{}
-	q.query(fmt.Sprintf("SELECT DISTINCT json_each.key AS name FROM %s, json_each(%s)", base.UnitsDBTableName, metric))
+	q.query(fmt.Sprintf("SELECT DISTINCT json_each.key AS name FROM %s AS u, json_each(%s)", table, metric))
 
 	// Ignore null values
 	q.query(" WHERE json_each.key IS NOT NULL ")
@@ -1266,8 +1792,10 @@ func (s *CEEMSServer) aggQueryBuilder(
 	q = s.getCommonQueryParams(&q, r.URL.Query())
 
 	// Add time query as sub query to main query
-	q.query(" AND ")
-	q.subQuery(timeQuery)
+	if !timeQuery.empty() {
+		q.query(" AND ")
+		q.subQuery(timeQuery)
+	}
 
 	// Make query and get keys
 	keys, err := s.queriers.key(r.Context(), s.db, q, s.logger)
@@ -1283,6 +1811,7 @@ func (s *CEEMSServer) aggQueryBuilder(
 		"TimesMetricName": "total_time_seconds",
 		"MetricWeight":    db.Weights[metric],
 		"MetricKeys":      keys,
+		"JSONObject":      jsonObjectFuncs[base.Dialect(s.db)],
 	}
 
 	// Execute template and get query
@@ -1298,73 +1827,38 @@ func (s *CEEMSServer) aggQueryBuilder(
 	return query.String()
 }
 
-// GET /usage/current
-// Get current usage statistics.
-func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.ResponseWriter, r *http.Request) {
-	var usage []models.Usage
-
-	var groupby []string
-
-	var targetTable string
-
-	var q, timeQuery Query
-
+// aggUsageQuery builds the query that aggregates usage of targetTable within
+// timeQuery. An empty timeQuery aggregates all the rows of targetTable.
+func (s *CEEMSServer) aggUsageQuery(
+	r *http.Request,
+	users []string,
+	fields []string,
+	targetTable string,
+	keysTable string,
+	timeQuery Query,
+	groupBy usageGroupBy,
+) (Query, error) {
 	queryParts := make([]string, len(fields))
 
 	var queries, virtualTables []string
 
-	var wg sync.WaitGroup
-
 	var mu sync.RWMutex
 
-	var err, qErrs error
-
-	// Round `to` and `from` query parameters to cacheTTL
-	if err := s.roundQueryWindow(r); err != nil {
-		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
-
-		return
-	}
-
-	// Get only units that have finished. We do not present this
-	// query parameter for end users. **Only used in testing**
-	_, terminated := r.URL.Query()["__terminated"]
-
-	// Get query window time stamps
-	timeQuery, err = s.getQueryWindow(r, "last_updated_at", false, terminated)
-	if err != nil {
-		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
-
-		return
-	}
-
-	// Attempt to retrieve from cache if present
-	// Use URL as cache key
-	// Add Expires header when cached value is being returned
-	cacheKey := common.GenerateKey(r.URL.String())
-	if present := s.usageCache.Has(cacheKey); present {
-		cacheValue := s.usageCache.Get(cacheKey)
-		usage = cacheValue.Value()
-		w.Header().Set("Expires", cacheValue.ExpiresAt().Format(time.RFC1123))
-
-		goto writer
-	}
-
-	// Set write deadline
-	s.setWriteDeadline(5*time.Minute, w)
+	var qErrs error
 
 	// Start a wait group
-	wg = sync.WaitGroup{}
+	wg := sync.WaitGroup{}
 
 	// Get aggUsageCols based on queried fields
 	for iField, field := range fields {
-		if strings.HasPrefix(field, "avg") || strings.HasPrefix(field, "total") {
+		switch {
+		case strings.HasPrefix(field, "avg") || strings.HasPrefix(field, "total"):
 			wg.Add(1)
 
 			go func(i int, f string) {
 				defer wg.Done()
 
-				if query := s.aggQueryBuilder(r, f, timeQuery); query != "" {
+				if query := s.aggQueryBuilder(r, f, keysTable, timeQuery); query != "" {
 					queryParts[i] = query
 				} else {
 					mu.Lock()
@@ -1372,7 +1866,9 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 					mu.Unlock()
 				}
 			}(iField, field)
-		} else {
+		case field == "dimensions":
+			queryParts[iField] = groupBy.selectQuery(base.Dialect(s.db))
+		default:
 			queryParts[iField] = aggUsageQueries[field]
 		}
 	}
@@ -1403,20 +1899,17 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 		}
 	}
 
-	if _, ok := r.URL.Query()["experimental"]; ok {
-		targetTable = base.DailyUsageDBTableName
-
+	// Usage tables and rollups have number of units in each row
+	if targetTable != base.UnitsDBTableName {
 		for iQuery, query := range queries {
 			if strings.Contains(query, "COUNT") {
 				queries[iQuery] = "SUM(u.num_units) AS num_units"
 			}
 		}
-	} else {
-		targetTable = base.UnitsDBTableName
 	}
 
 	// Make query
-	q = Query{}
+	q := Query{}
 	q.query(
 		fmt.Sprintf(
 			"SELECT %s FROM (%s AS u LEFT JOIN %s)",
@@ -1427,31 +1920,129 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 	)
 
 	// First select all projects that user is part of using subquery
-	q.query(" WHERE project IN ")
+	q.query(" WHERE (u.cluster_id, u.project) IN ")
 	q.subQuery(projectsSubQuery(users)) // Get sub query for projects
 
+	// Sub units are already accounted in their parent units
+	if targetTable == base.UnitsDBTableName {
+		q.query(" AND parent_uuid = '' ")
+	}
+
 	// Add common query parameters
 	q = s.getCommonQueryParams(&q, r.URL.Query())
 
 	// Add time query as sub query to main query
-	q.query(" AND ")
-	q.subQuery(timeQuery)
+	if !timeQuery.empty() {
+		q.query(" AND ")
+		q.subQuery(timeQuery)
+	}
 
-	// Finally add GROUP BY clause. Always group by username,project
-	groupby = []string{"username", "project"}
+	// Finally add GROUP BY clause
+	q.query(" GROUP BY " + strings.Join(groupBy.clause(), ","))
 
-	for _, q := range r.URL.Query()["groupby"] {
-		if q != "" {
-			groupby = append(groupby, q)
-		}
+	// Sort by cluster_id, username, project and dimensions
+	orderBy := []string{"cluster_id ASC", "username ASC", "project ASC"}
+	for _, d := range groupBy.dimensions {
+		orderBy = append(orderBy, d.expr+" ASC")
 	}
-	// Remove duplicates values
-	slices.Sort(groupby)
-	groupby = slices.Compact(groupby)
-	q.query(" GROUP BY " + strings.Join(groupby, ","))
 
-	// Sort by cluster_id, username and project
-	q.query(" ORDER BY cluster_id ASC, username ASC, project ASC ")
+	q.query(" ORDER BY " + strings.Join(orderBy, ", ") + " ")
+
+	return q, qErrs
+}
+
+// GET /usage/current
+// Get current usage statistics.
+func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.ResponseWriter, r *http.Request) {
+	var usage []models.Usage
+
+	var groupBy usageGroupBy
+
+	var targetTable string
+
+	var q, timeQuery Query
+
+	var err, qErrs error
+
+	// Get response format
+	format, err := responseFormat(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Round `to` and `from` query parameters to cacheTTL
+	if err := s.roundQueryWindow(r); err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Get only units that have finished. We do not present this
+	// query parameter for end users. **Only used in testing**
+	_, terminated := r.URL.Query()["__terminated"]
+
+	// Get query window time stamps
+	timeQuery, err = s.getQueryWindow(r, "last_updated_at", false, terminated)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Units older than retention period might have been rolled up into
+	// monthly usage. In that case use union of rollups and units
+	_, experimental := r.URL.Query()["experimental"]
+
+	rollupQuery, rollup := s.rollupQueryWindow(r)
+
+	switch {
+	case rollup:
+		targetTable = rollupUsageSource
+		timeQuery = rollupQuery
+	case experimental:
+		targetTable = base.DailyUsageDBTableName
+	default:
+		targetTable = base.UnitsDBTableName
+	}
+
+	// Metric keys are always fetched from units or their rollups
+	keysTable := base.UnitsDBTableName
+	if rollup {
+		keysTable = rollupUsageSource
+	}
+
+	// Get group by columns and dimensions. Dimensions of daily usage are the
+	// ones persisted in the table
+	dimensionsTable := base.UnitsDBTableName
+	if targetTable == base.DailyUsageDBTableName {
+		dimensionsTable = base.DailyUsageDBTableName
+	}
+
+	if groupBy, err = s.usageGroupBy(r, dimensionsTable); err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Attempt to retrieve from cache if present
+	// Use URL as cache key
+	// Add Expires header when cached value is being returned
+	cacheKey := common.GenerateKey(r.URL.String())
+	if present := s.usageCache.Has(cacheKey); present {
+		cacheValue := s.usageCache.Get(cacheKey)
+		usage = cacheValue.Value()
+		w.Header().Set("Expires", cacheValue.ExpiresAt().Format(time.RFC1123))
+
+		goto writer
+	}
+
+	// Set write deadline
+	s.setWriteDeadline(5*time.Minute, w)
+
+	// Make aggregate query
+	q, qErrs = s.aggUsageQuery(r, users, fields, targetTable, keysTable, timeQuery, groupBy)
 
 	// Make query and check for returned number of rows
 	usage, err = s.queriers.usage(r.Context(), s.db, q, s.logger)
@@ -1468,6 +2059,11 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 	}
 
 writer:
+	// Write usage in CSV/NDJSON format if requested
+	if writeExport(w, format, usageResourceName, fields, usage, s.logger) {
+		return
+	}
+
 	// Write response
 	w.WriteHeader(http.StatusOK)
 
@@ -1492,22 +2088,51 @@ writer:
 // GET /usage/global
 // Get global usage statistics.
 func (s *CEEMSServer) globalUsage(users []string, queriedFields []string, w http.ResponseWriter, r *http.Request) {
-	// Get sub query for projects
-	qSub := projectsSubQuery(users)
+	// Get response format
+	format, err := responseFormat(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
 
-	// Make query
-	q := Query{}
-	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(queriedFields, ","), base.UsageDBTableName))
+		return
+	}
 
-	// First select all projects that user is part of using subquery
-	q.query(" WHERE project IN ")
-	q.subQuery(qSub)
+	// Get group by dimensions
+	groupBy, err := s.usageGroupBy(r, base.DailyUsageDBTableName)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
 
-	// Add common query parameters
-	q = s.getCommonQueryParams(&q, r.URL.Query())
+		return
+	}
 
-	// Sort by cluster_id, username and project
-	q.query(" ORDER BY cluster_id ASC, username ASC, project ASC ")
+	var q Query
+
+	var qErrs error
+
+	if len(groupBy.dimensions) > 0 {
+		// Global usage is not split by dimensions. Aggregate daily usage where
+		// dimensions are persisted instead
+		s.setWriteDeadline(5*time.Minute, w)
+
+		q, qErrs = s.aggUsageQuery(
+			r, users, queriedFields, base.DailyUsageDBTableName, base.DailyUsageDBTableName, Query{}, groupBy,
+		)
+	} else {
+		// Get sub query for projects
+		qSub := projectsSubQuery(users)
+
+		// Make query
+		q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(queriedFields, ","), base.UsageDBTableName))
+
+		// First select all projects that user is part of using subquery
+		q.query(" WHERE (cluster_id, project) IN ")
+		q.subQuery(qSub)
+
+		// Add common query parameters
+		q = s.getCommonQueryParams(&q, r.URL.Query())
+
+		// Sort by cluster_id, username and project
+		q.query(" ORDER BY cluster_id ASC, username ASC, project ASC ")
+	}
 
 	// Make query and check for returned number of rows
 	usage, err := s.queriers.usage(r.Context(), s.db, q, s.logger)
@@ -1518,6 +2143,11 @@ func (s *CEEMSServer) globalUsage(users []string, queriedFields []string, w http
 		return
 	}
 
+	// Write usage in CSV/NDJSON format if requested
+	if writeExport(w, format, usageResourceName, queriedFields, usage, s.logger) {
+		return
+	}
+
 	// Write response
 	w.WriteHeader(http.StatusOK)
 
@@ -1525,6 +2155,10 @@ func (s *CEEMSServer) globalUsage(users []string, queriedFields []string, w http
 		Status: "success",
 		Data:   usage,
 	}
+	if qErrs != nil {
+		usageResponse.Warnings = append(usageResponse.Warnings, qErrs.Error())
+	}
+
 	if err != nil {
 		usageResponse.Warnings = append(usageResponse.Warnings, err.Error())
 	}
@@ -1573,20 +2207,40 @@ func (s *CEEMSServer) globalUsage(users []string, queriedFields []string, w http
 //	@Description	cache results and subsequent queries, for a given user and same URL
 //	@Description	query parameters, will return the same cached result until the cache
 //	@Description	is invalidated after 15 min.
-//	@Security		BasicAuth
-//	@Tags			usage
-//	@Produce		json
-//	@Param			X-Grafana-User	header		string		true	"Current user name"
-//	@Param			mode			path		string		true	"Whether to get usage stats within a period or global"	Enums(current, global)
-//	@Param			cluster_id		query		[]string	false	"cluster ID"											collectionFormat(multi)
-//	@Param			project			query		[]string	false	"Project"												collectionFormat(multi)
-//	@Param			from			query		string		false	"From timestamp"
-//	@Param			to				query		string		false	"To timestamp"
-//	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
-//	@Success		200				{object}	Response[models.Usage]
-//	@Failure		401				{object}	Response[any]
-//	@Failure		500				{object}	Response[any]
-//	@Router			/usage/{mode} [get]
+//	@Description
+//	@Description	When the retention policy of DB is `rollup` and `from` is older than the retention
+//	@Description	period, usage is estimated from monthly aggregates of expired units along with
+//	@Description	the units that are still in the DB. In this case, `from` is truncated to the start
+//	@Description	of its month.
+//	@Description	The response can be exported in CSV or newline delimited JSON (NDJSON) formats
+//	@Description	using the query parameter `format` or `Accept` header (`text/csv` or
+//	@Description	`application/x-ndjson`). In CSV format, map fields are flattened into one column
+//	@Description	per key, for instance, `total_cpu_energy_usage_kwh.total`.
+//	@Description
+//	@Description	Usage is always grouped by user and project. It can be split further using
+//	@Description	`groupby` query parameter by `cluster_id`, `groupname` or attributes of allocation
+//	@Description	and tags of units like `allocation.partition` and `tags.flavor`. Values of the
+//	@Description	attributes of each group are returned in `dimensions` field. In `global` mode,
+//	@Description	only the attributes persisted in daily usage can be used.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		usage
+//	@Produce	json
+//	@Produce	text/csv
+//	@Produce	application/x-ndjson
+//	@Param		X-Grafana-User	header		string		true	"Current user name"
+//	@Param		mode			path		string		true	"Whether to get usage stats within a period or global"	Enums(current, global)
+//	@Param		cluster_id		query		[]string	false	"cluster ID"											collectionFormat(multi)
+//	@Param		project			query		[]string	false	"Project"												collectionFormat(multi)
+//	@Param		from			query		string		false	"From timestamp"
+//	@Param		to				query		string		false	"To timestamp"
+//	@Param		field			query		[]string	false	"Fields to return in response"			collectionFormat(multi)
+//	@Param		groupby			query		[]string	false	"Group usage by column or attribute"	collectionFormat(multi)
+//	@Param		format			query		string		false	"Response format"						Enums(json, csv, ndjson)
+//	@Success	200				{object}	Response[models.Usage]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/usage/{mode} [get]
 //
 // GET /usage/{mode}
 // Get current/global usage statistics.
@@ -1671,22 +2325,42 @@ func (s *CEEMSServer) usage(w http.ResponseWriter, r *http.Request) {
 //	@Description	cache results and subsequent queries, for a given user and same URL
 //	@Description	query parameters, will return the same cached result until the cache
 //	@Description	is invalidated after 15 min.
-//	@Security		BasicAuth
-//	@Tags			usage
-//	@Produce		json
-//	@Param			X-Grafana-User	header		string		true	"Current user name"
-//	@Param			mode			path		string		true	"Whether to get usage stats within a period or global"	Enums(current, global)
-//	@Param			cluster_id		query		[]string	false	"cluster ID"											collectionFormat(multi)
-//	@Param			project			query		[]string	false	"Project"
-//	@Param			user			query		[]string	false	"Username"	collectionFormat(multi)
-//	@Param			from			query		string		false	"From timestamp"
-//	@Param			to				query		string		false	"To timestamp"
-//	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
-//	@Success		200				{object}	Response[models.Usage]
-//	@Failure		401				{object}	Response[any]
-//	@Failure		403				{object}	Response[any]
-//	@Failure		500				{object}	Response[any]
-//	@Router			/usage/{mode}/admin [get]
+//	@Description
+//	@Description	When the retention policy of DB is `rollup` and `from` is older than the retention
+//	@Description	period, usage is estimated from monthly aggregates of expired units along with
+//	@Description	the units that are still in the DB. In this case, `from` is truncated to the start
+//	@Description	of its month.
+//	@Description	The response can be exported in CSV or newline delimited JSON (NDJSON) formats
+//	@Description	using the query parameter `format` or `Accept` header (`text/csv` or
+//	@Description	`application/x-ndjson`). In CSV format, map fields are flattened into one column
+//	@Description	per key, for instance, `total_cpu_energy_usage_kwh.total`.
+//	@Description
+//	@Description	Usage is always grouped by user and project. It can be split further using
+//	@Description	`groupby` query parameter by `cluster_id`, `groupname` or attributes of allocation
+//	@Description	and tags of units like `allocation.partition` and `tags.flavor`. Values of the
+//	@Description	attributes of each group are returned in `dimensions` field. In `global` mode,
+//	@Description	only the attributes persisted in daily usage can be used.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		usage
+//	@Produce	json
+//	@Produce	text/csv
+//	@Produce	application/x-ndjson
+//	@Param		X-Grafana-User	header		string		true	"Current user name"
+//	@Param		mode			path		string		true	"Whether to get usage stats within a period or global"	Enums(current, global)
+//	@Param		cluster_id		query		[]string	false	"cluster ID"											collectionFormat(multi)
+//	@Param		project			query		[]string	false	"Project"
+//	@Param		user			query		[]string	false	"Username"	collectionFormat(multi)
+//	@Param		from			query		string		false	"From timestamp"
+//	@Param		to				query		string		false	"To timestamp"
+//	@Param		field			query		[]string	false	"Fields to return in response"			collectionFormat(multi)
+//	@Param		groupby			query		[]string	false	"Group usage by column or attribute"	collectionFormat(multi)
+//	@Param		format			query		string		false	"Response format"						Enums(json, csv, ndjson)
+//	@Success	200				{object}	Response[models.Usage]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	403				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/usage/{mode}/admin [get]
 //
 // GET /usage/{mode}/admin
 // Get current/global usage statistics of any user.
@@ -1741,12 +2415,20 @@ func (s *CEEMSServer) currentStats(users []string, w http.ResponseWriter, r *htt
 
 	var err error
 
+	// Get response format
+	format, err := responseFormat(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
 	// Set write deadline
 	s.setWriteDeadline(1*time.Minute, w)
 
 	// Make query
 	q = Query{}
-	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", statsQuery, base.UnitsDBTableName))
+	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE parent_uuid = ''", statsQuery, base.UnitsDBTableName))
 
 	// Get query window time stamps
 	timeQuery, err = s.getQueryWindow(r, "ended_at", true, false)
@@ -1781,6 +2463,11 @@ func (s *CEEMSServer) currentStats(users []string, w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Write stats in CSV/NDJSON format if requested
+	if writeExport(w, format, statsResourceName, nil, stats, s.logger) {
+		return
+	}
+
 	// Write response
 	w.WriteHeader(http.StatusOK)
 
@@ -1807,12 +2494,20 @@ func (s *CEEMSServer) globalStats(users []string, w http.ResponseWriter, r *http
 
 	var err error
 
+	// Get response format
+	format, err := responseFormat(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
 	// Set write deadline
 	s.setWriteDeadline(1*time.Minute, w)
 
 	// Make query
 	q = Query{}
-	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", statsQuery, base.UnitsDBTableName))
+	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE parent_uuid = ''", statsQuery, base.UnitsDBTableName))
 
 	// Get cluster_id query parameters if any
 	if clusterIDs := r.URL.Query()["cluster_id"]; len(clusterIDs) > 0 {
@@ -1835,6 +2530,11 @@ func (s *CEEMSServer) globalStats(users []string, w http.ResponseWriter, r *http
 		return
 	}
 
+	// Write stats in CSV/NDJSON format if requested
+	if writeExport(w, format, statsResourceName, nil, stats, s.logger) {
+		return
+	}
+
 	// Write response
 	w.WriteHeader(http.StatusOK)
 
@@ -1876,14 +2576,22 @@ func (s *CEEMSServer) globalStats(users []string, w http.ResponseWriter, r *http
 //	@Description	query parameter is not used, a default query window of 24 hours will be used.
 //	@Description	It means if `to` is provided, `from` will be calculated as `to` - 24hrs.
 //	@Description
+//	@Description	The response can be exported in CSV or newline delimited JSON (NDJSON) formats
+//	@Description	using the query parameter `format` or `Accept` header (`text/csv` or
+//	@Description	`application/x-ndjson`). In CSV format, map fields are flattened into one column
+//	@Description	per key, for instance, `total_cpu_energy_usage_kwh.total`.
+//	@Description
 //	@Security	BasicAuth
 //	@Tags		stats
 //	@Produce	json
+//	@Produce	text/csv
+//	@Produce	application/x-ndjson
 //	@Param		X-Grafana-User	header		string		true	"Current user name"
 //	@Param		mode			path		string		true	"Whether to get quick stats within a period or global"	Enums(current, global)
 //	@Param		cluster_id		query		[]string	false	"cluster ID"											collectionFormat(multi)
 //	@Param		from			query		string		false	"From timestamp"
 //	@Param		to				query		string		false	"To timestamp"
+//	@Param		format			query		string		false	"Response format"	Enums(json, csv, ndjson)
 //	@Success	200				{object}	Response[models.Stat]
 //	@Failure	401				{object}	Response[any]
 //	@Failure	403				{object}	Response[any]
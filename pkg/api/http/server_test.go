@@ -4,8 +4,10 @@
 package http
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"io"
@@ -15,13 +17,16 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/audit"
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -64,6 +69,28 @@ var (
 	mockKeys = []models.Key{
 		{Name: "global"},
 	}
+	mockInvoices = []models.Invoice{
+		{
+			ClusterID: "slurm-0", ResourceManager: "slurm", Period: "2024-10", Project: "foo", User: "foousr", NumUnits: 2,
+			TotalTime: models.MetricMap{"walltime": 3600, "alloc_cputime": 7200, "alloc_gputime": 0},
+			TotalCost: models.MetricMap{"cpu": 0.02, "gpu": 0, "energy": 0.5, "total": 0.52},
+		},
+	}
+	mockBudgets = []models.Budget{
+		{
+			ClusterID: "slurm-0", Project: "foo", Period: "month", PeriodStart: "2024-10-01T00:00:00",
+			Limits:      models.MetricMap{"cpu_hours": 100},
+			Consumed:    models.MetricMap{"cpu_hours": 85, "gpu_hours": 0, "energy_kwh": 10, "emissions_gms": 500},
+			UsedPercent: models.MetricMap{"cpu_hours": 85},
+		},
+	}
+	mockTimeSeries = []models.UsageTimeSeries{
+		{
+			Timestamp: 1727740800000, Project: "foo", NumUnits: 2,
+			TotalTime:   models.MetricMap{"walltime": 3600, "alloc_cputime": 7200},
+			AveCPUUsage: models.MetricMap{"usage": 50},
+		},
+	}
 	errTest = errors.New("failed to query 10 rows")
 )
 
@@ -93,6 +120,11 @@ func setupServer(d string) *CEEMSServer {
 		cluster: clusterQuerier,
 		stat:    statQuerier,
 		key:     keyQuerier,
+		invoice: invoiceQuerier,
+		budget:  budgetQuerier,
+
+		unitStream: unitStreamer,
+		timeSeries: timeSeriesQuerier,
 	}
 
 	return server
@@ -102,6 +134,20 @@ func unitQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger)
 	return mockServerUnits, nil
 }
 
+func unitStreamer(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger, fn func(models.Unit) error) error {
+	for _, unit := range mockServerUnits {
+		if err := fn(unit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func timeSeriesQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.UsageTimeSeries, error) {
+	return mockTimeSeries, nil
+}
+
 func usageQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Usage, error) {
 	return mockServerUsage, nil
 }
@@ -126,6 +172,14 @@ func keyQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) (
 	return mockKeys, nil
 }
 
+func invoiceQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Invoice, error) {
+	return mockInvoices, nil
+}
+
+func budgetQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Budget, error) {
+	return mockBudgets, nil
+}
+
 func keyQuerierErr(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Key, error) {
 	return nil, errors.New("failed query")
 }
@@ -333,6 +387,311 @@ func TestUnitsHandler(t *testing.T) {
 	}
 }
 
+// Test unit steps and unit steps admin handlers.
+func TestUnitStepsHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
+	if err != nil {
+		require.NoError(t, err)
+	}
+
+	defer f.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	// Test cases
+	tests := []testCase{
+		{
+			name:    "unit steps",
+			req:     "/api/" + base.APIVersion + "/units/1000/steps",
+			user:    "foousr",
+			admin:   false,
+			handler: server.unitSteps,
+			code:    200,
+		},
+		{
+			name:    "unit steps admin",
+			req:     "/api/" + base.APIVersion + "/units/1000/steps/admin",
+			user:    "foousr",
+			admin:   true,
+			handler: server.unitStepsAdmin,
+			code:    200,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, test.req, nil)
+		request.Header.Set("X-Grafana-User", test.user)
+		request = mux.SetURLVars(request, map[string]string{"uuid": "1000"})
+
+		if test.admin {
+			q := url.Values{}
+			q.Add("user", "foousr")
+			request.URL.RawQuery = q.Encode()
+		}
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		test.handler(w, request)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		// Get body
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		// Unmarshal byte into structs.
+		var response Response[models.Unit]
+
+		json.Unmarshal(data, &response)
+		assert.Equal(t, test.code, w.Code)
+		assert.Equal(t, "success", response.Status)
+		assert.Equal(t, mockServerUnits, response.Data)
+	}
+
+	// Request without uuid path parameter
+	request := httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+"/units//steps", nil)
+	request.Header.Set("X-Grafana-User", "foousr")
+
+	w := httptest.NewRecorder()
+	server.unitSteps(w, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// Test billing and billing admin handlers.
+func TestBillingHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
+	if err != nil {
+		require.NoError(t, err)
+	}
+
+	defer f.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.dbConfig.Billing.Currency = "EUR"
+
+	expectedInvoices := []models.Invoice{mockInvoices[0]}
+	expectedInvoices[0].Currency = "EUR"
+
+	// Test cases
+	tests := []testCase{
+		{
+			name:    "user invoices",
+			req:     "/api/" + base.APIVersion + "/billing/user",
+			user:    "foousr",
+			admin:   false,
+			handler: server.billing,
+			code:    200,
+		},
+		{
+			name:    "project invoices admin",
+			req:     "/api/" + base.APIVersion + "/billing/project/admin",
+			user:    "foousr",
+			admin:   true,
+			handler: server.billingAdmin,
+			code:    200,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, test.req, nil)
+		request.Header.Set("X-Grafana-User", test.user)
+		request = mux.SetURLVars(request, map[string]string{"mode": "user"})
+
+		if test.admin {
+			q := url.Values{}
+			q.Add("user", "foousr")
+			request.URL.RawQuery = q.Encode()
+		}
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		test.handler(w, request)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		// Get body
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		// Unmarshal byte into structs.
+		var response Response[models.Invoice]
+
+		json.Unmarshal(data, &response)
+		assert.Equal(t, test.code, w.Code)
+		assert.Equal(t, "success", response.Status)
+		assert.Equal(t, expectedInvoices, response.Data)
+	}
+
+	// CSV format
+	request := httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+"/billing/user?format=csv&period=year", nil)
+	request.Header.Set("X-Grafana-User", "foousr")
+	request = mux.SetURLVars(request, map[string]string{"mode": "user"})
+
+	w := httptest.NewRecorder()
+	server.billing(w, request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(
+		t,
+		"cluster_id,resource_manager,period,project,username,num_units,walltime_seconds,alloc_cputime_seconds,alloc_gputime_seconds,cpu_cost,gpu_cost,energy_cost,total_cost,currency\n"+
+			"slurm-0,slurm,2024-10,foo,foousr,2,3600,7200,0,0.02,0.00,0.50,0.52,EUR\n",
+		w.Body.String(),
+	)
+
+	// Invalid period and format
+	for _, query := range []string{"period=week", "format=xml"} {
+		request = httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+"/billing/user?"+query, nil)
+		request.Header.Set("X-Grafana-User", "foousr")
+		request = mux.SetURLVars(request, map[string]string{"mode": "user"})
+
+		w = httptest.NewRecorder()
+		server.billing(w, request)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// Test usage time series handlers.
+func TestUsageTimeSeriesHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
+	if err != nil {
+		require.NoError(t, err)
+	}
+
+	defer f.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	// Test cases
+	tests := []testCase{
+		{
+			name:    "usage time series",
+			req:     "/api/" + base.APIVersion + "/usage/timeseries?step=1M&groupby=project",
+			user:    "foousr",
+			handler: server.usageTimeSeries,
+			code:    200,
+		},
+		{
+			name:    "usage time series admin",
+			req:     "/api/" + base.APIVersion + "/usage/timeseries/admin?step=1w&user=foousr",
+			user:    "foousr",
+			admin:   true,
+			handler: server.usageTimeSeriesAdmin,
+			code:    200,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, test.req, nil)
+		request.Header.Set("X-Grafana-User", test.user)
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		test.handler(w, request)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		// Get body
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		// Unmarshal byte into structs.
+		var response Response[models.UsageTimeSeries]
+
+		json.Unmarshal(data, &response)
+		assert.Equal(t, test.code, w.Code, test.name)
+		assert.Equal(t, "success", response.Status, test.name)
+		assert.Equal(t, mockTimeSeries, response.Data, test.name)
+	}
+
+	// Invalid step, groupby and field
+	for _, query := range []string{"step=2d", "groupby=resource_manager", "field=uuid"} {
+		request := httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+"/usage/timeseries?"+query, nil)
+		request.Header.Set("X-Grafana-User", "foousr")
+
+		w := httptest.NewRecorder()
+		server.usageTimeSeries(w, request)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+// Test budgets and budgets admin handlers.
+func TestBudgetsHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
+	if err != nil {
+		require.NoError(t, err)
+	}
+
+	defer f.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	// Test cases
+	tests := []testCase{
+		{
+			name:    "budgets",
+			req:     "/api/" + base.APIVersion + "/budgets",
+			user:    "foousr",
+			admin:   false,
+			handler: server.budgets,
+			code:    200,
+		},
+		{
+			name:    "budgets admin",
+			req:     "/api/" + base.APIVersion + "/budgets/admin",
+			user:    "foousr",
+			admin:   true,
+			handler: server.budgetsAdmin,
+			code:    200,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, test.req, nil)
+		request.Header.Set("X-Grafana-User", test.user)
+
+		if test.admin {
+			q := url.Values{}
+			q.Add("user", "foousr")
+			request.URL.RawQuery = q.Encode()
+		}
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		test.handler(w, request)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		// Get body
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		// Unmarshal byte into structs.
+		var response Response[models.Budget]
+
+		json.Unmarshal(data, &response)
+		assert.Equal(t, test.code, w.Code)
+		assert.Equal(t, "success", response.Status)
+		assert.Equal(t, mockBudgets, response.Data)
+	}
+}
+
 // Test usage and usage admin handlers.
 func TestUsageHandlers(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -588,8 +947,8 @@ func TestStatsHandlers(t *testing.T) {
 	}
 }
 
-// Test verify handler.
-func TestVerifyHandler(t *testing.T) {
+// Test CSV and NDJSON export formats.
+func TestExportFormats(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
@@ -602,10 +961,118 @@ func TestVerifyHandler(t *testing.T) {
 	server := setupServer(tmpDir)
 	defer server.Shutdown(context.Background())
 
-	tests := []testCase{
-		{
-			name:    "verify bad data",
-			req:     "/api/" + base.APIVersion + "/units/verify",
+	// Units with metric maps to test flattening
+	server.queriers.unit = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Unit, error) {
+		return []models.Unit{
+			{
+				UUID: "1000", ClusterID: "slurm-0", User: "foousr",
+				TotalCPUEnergyUsage: models.MetricMap{"global": 1.5},
+				Tags:                models.Tag{"qos": "normal"},
+			},
+			{
+				UUID: "1001", ClusterID: "slurm-0", User: "foousr",
+				TotalCPUEnergyUsage: models.MetricMap{"global": 2, "rte": 1},
+			},
+		}, nil
+	}
+
+	// CSV export of units
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/units?format=csv&field=uuid&field=total_cpu_energy_usage_kwh&field=tags", nil)
+	request.Header.Set("X-Grafana-User", "foousr")
+
+	w := httptest.NewRecorder()
+	server.units(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[][]string{
+			{"uuid", "total_cpu_energy_usage_kwh.global", "total_cpu_energy_usage_kwh.rte", "tags.qos"},
+			{"1000", "1.5", "", "normal"},
+			{"1001", "2", "1", ""},
+		},
+		records,
+	)
+
+	// NDJSON export of units using Accept header
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	request.Header.Set("X-Grafana-User", "foousr")
+	request.Header.Set("Accept", "application/x-ndjson")
+
+	w = httptest.NewRecorder()
+	server.units(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	var units []models.Unit
+
+	decoder := json.NewDecoder(w.Body)
+	for decoder.More() {
+		var unit models.Unit
+		require.NoError(t, decoder.Decode(&unit))
+
+		units = append(units, unit)
+	}
+
+	assert.Equal(t, mockServerUnits, units)
+
+	// NDJSON export of usage
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/usage/global?format=ndjson", nil)
+	request.Header.Set("X-Grafana-User", "foousr")
+	request = mux.SetURLVars(request, map[string]string{"mode": "global"})
+
+	w = httptest.NewRecorder()
+	server.usage(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, strings.Split(strings.TrimSpace(w.Body.String()), "\n"), len(mockServerUsage))
+
+	// CSV export of stats
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/stats/global/admin?format=csv", nil)
+	request.Header.Set("X-Grafana-User", "adm1")
+	request = mux.SetURLVars(request, map[string]string{"mode": "global"})
+
+	w = httptest.NewRecorder()
+	server.statsAdmin(w, request)
+
+	records, err = csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, len(mockStats)+1)
+	assert.Equal(t, []string{"slurm-0", "slurm", "10", "2", "8", "0", "0"}, records[1])
+
+	// Unsupported format
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/units?format=xml", nil)
+	request.Header.Set("X-Grafana-User", "foousr")
+
+	w = httptest.NewRecorder()
+	server.units(w, request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// Test verify handler.
+func TestVerifyHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
+	if err != nil {
+		require.NoError(t, err)
+	}
+
+	defer f.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	tests := []testCase{
+		{
+			name:    "verify bad data",
+			req:     "/api/" + base.APIVersion + "/units/verify",
 			user:    "foousr",
 			admin:   false,
 			handler: server.verifyUnitsOwnership,
@@ -778,6 +1245,105 @@ func TestUnitsHandlerWithMalformedQueryParams(t *testing.T) {
 	assert.Empty(t, response.Data)
 }
 
+// Test /units with sorting and pagination query parameters.
+func TestUnitsHandlerWithPageQueryParams(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
+	if err != nil {
+		require.NoError(t, err)
+	}
+
+	defer f.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	tests := []struct {
+		name       string
+		params     url.Values
+		status     string
+		pagination *Pagination
+	}{
+		{
+			name:       "offset pagination",
+			params:     url.Values{"limit": {"2"}, "offset": {"2"}, "sort": {"uuid"}},
+			status:     "success",
+			pagination: &Pagination{Limit: 2, Offset: 2, NextOffset: 4},
+		},
+		{
+			name:       "last page",
+			params:     url.Values{"limit": {"10"}},
+			status:     "success",
+			pagination: &Pagination{Limit: 10},
+		},
+		{
+			name:   "invalid sort field",
+			params: url.Values{"sort": {"uuid; DROP TABLE units"}},
+			status: "error",
+		},
+		{
+			name:   "invalid sort order",
+			params: url.Values{"sort": {"uuid"}, "order": {"up"}},
+			status: "error",
+		},
+		{
+			name:   "negative limit",
+			params: url.Values{"limit": {"-1"}},
+			status: "error",
+		},
+		{
+			name:   "offset without limit",
+			params: url.Values{"offset": {"2"}},
+			status: "error",
+		},
+		{
+			name:   "invalid cursor",
+			params: url.Values{"cursor": {"foo"}},
+			status: "error",
+		},
+		{
+			name:   "cursor with offset",
+			params: url.Values{"cursor": {cursor{value: 1, id: 1}.String()}, "offset": {"10"}},
+			status: "error",
+		},
+		{
+			name:   "cursor with non integer sort field",
+			params: url.Values{"cursor": {cursor{value: 1, id: 1}.String()}, "sort": {"uuid"}},
+			status: "error",
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+		req.Header.Set("X-Grafana-User", "foo")
+		req.URL.RawQuery = test.params.Encode()
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		server.units(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		// Get body
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		// Unmarshal byte into structs.
+		var response Response[models.Unit]
+
+		json.Unmarshal(data, &response)
+
+		assert.Equal(t, test.status, response.Status, test.name)
+		assert.Equal(t, test.pagination, response.Pagination, test.name)
+
+		if test.status == "error" {
+			assert.Equal(t, errorType("bad_data"), response.ErrorType, test.name)
+		}
+	}
+}
+
 // Test /units when from/to query parameters exceed max time window.
 func TestUnitsHandlerWithQueryWindowExceeded(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -912,3 +1478,1138 @@ func TestUnitsHandlerWithUnituuidsQueryParams(t *testing.T) {
 // 		t.Errorf("expected usage %#v usage, got %#v", expectedUsage, response.Data)
 // 	}
 // }
+
+// Test API tokens handlers and authentication with API tokens.
+func TestTokensHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	_, err = dbConn.Exec("INSERT INTO admin_users (source, users) VALUES ('ceems', '[\"adm1\"]')")
+	require.NoError(t, err)
+
+	dbConn.Close()
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	defer server.Shutdown(context.Background())
+
+	server.maxQueryPeriod = time.Hour * 168
+	server.queriers.unit = unitQuerier
+
+	// Make a request with either Grafana user header or API token
+	request := func(method, path, user, token, body string) (int, Response[models.APIToken]) {
+		req := httptest.NewRequest(method, "/api/"+base.APIVersion+path, strings.NewReader(body))
+		if user != "" {
+			req.Header.Set(grafanaUserHeader, user)
+		}
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		var response Response[models.APIToken]
+
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		return w.Code, response
+	}
+
+	// Create tokens
+	code, response := request(http.MethodPost, "/tokens", "usr1", "", `{"name":"notebook"}`)
+	require.Equal(t, 201, code)
+	require.Len(t, response.Data, 1)
+
+	usrToken := response.Data[0]
+	assert.True(t, strings.HasPrefix(usrToken.Token, apiTokenPrefix))
+	assert.Equal(t, "usr1", usrToken.User)
+	assert.Len(t, usrToken.Scopes, len(defaultTokenScopes))
+
+	code, _ = request(http.MethodPost, "/tokens", "usr1", "", `{"scopes":["admin"]}`)
+	assert.Equal(t, 403, code)
+
+	code, _ = request(http.MethodPost, "/tokens", "usr1", "", `{"scopes":["foo"]}`)
+	assert.Equal(t, 400, code)
+
+	code, _ = request(http.MethodPost, "/tokens", "usr1", "", `{"expires_in":"2y"}`)
+	assert.Equal(t, 400, code)
+
+	code, response = request(http.MethodPost, "/tokens", "adm1", "", `{"scopes":["units","admin"]}`)
+	require.Equal(t, 201, code)
+
+	admToken := response.Data[0]
+
+	// Use tokens
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		code  int
+	}{
+		{name: "token in scope", path: "/units", token: usrToken.Token, code: 200},
+		{name: "admin endpoint without admin scope", path: "/units/admin", token: usrToken.Token, code: 403},
+		{name: "token managing tokens", path: "/tokens", token: usrToken.Token, code: 403},
+		{name: "admin token", path: "/units/admin", token: admToken.Token, code: 200},
+		{name: "admin token out of scope", path: "/users/admin", token: admToken.Token, code: 403},
+		{name: "unknown token", path: "/units", token: apiTokenPrefix + "unknown", code: 401},
+	}
+
+	for _, test := range tests {
+		code, _ := request(http.MethodGet, test.path, "", test.token, "")
+		assert.Equal(t, test.code, code, test.name)
+	}
+
+	// List tokens. Tokens must never be returned
+	code, response = request(http.MethodGet, "/tokens", "usr1", "", "")
+	require.Equal(t, 200, code)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, usrToken.TokenID, response.Data[0].TokenID)
+	assert.Empty(t, response.Data[0].Token)
+	assert.NotEmpty(t, response.Data[0].LastUsedAt)
+
+	code, response = request(http.MethodGet, "/tokens/admin", "adm1", "", "")
+	require.Equal(t, 200, code)
+	assert.Len(t, response.Data, 2)
+
+	// Revoke tokens
+	code, _ = request(http.MethodDelete, "/tokens/"+usrToken.TokenID, "usr2", "", "")
+	assert.Equal(t, 404, code)
+
+	code, _ = request(http.MethodDelete, "/tokens/"+usrToken.TokenID+"/admin", "usr2", "", "")
+	assert.Equal(t, 403, code)
+
+	code, _ = request(http.MethodDelete, "/tokens/"+usrToken.TokenID+"/admin", "adm1", "", "")
+	assert.Equal(t, 200, code)
+
+	code, _ = request(http.MethodGet, "/units", "", usrToken.Token, "")
+	assert.Equal(t, 401, code)
+
+	code, response = request(http.MethodGet, "/tokens", "usr1", "", "")
+	require.Equal(t, 200, code)
+	assert.Equal(t, "adm1", response.Data[0].RevokedBy)
+
+	code, _ = request(http.MethodDelete, "/tokens/"+admToken.TokenID, "adm1", "", "")
+	assert.Equal(t, 200, code)
+
+	// Expired tokens must be rejected
+	server.tokens.now = func() time.Time { return time.Now().Add(-48 * time.Hour) }
+
+	expiredToken, err := server.tokens.create(context.Background(), "usr1", "", defaultTokenScopes, 24*time.Hour)
+	require.NoError(t, err)
+
+	server.tokens.now = time.Now
+
+	code, _ = request(http.MethodGet, "/units", "", expiredToken.Token, "")
+	assert.Equal(t, 401, code)
+}
+
+func TestCoordinatorHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// pi1 coordinates prj1 of slurm-0 without being its member. prj1 of slurm-1
+	// is a different project with same name
+	for _, stmt := range []string{
+		`INSERT INTO projects (uid, cluster_id, resource_manager, name, users, coordinators, tags, last_updated_at) VALUES
+			('', 'slurm-0', 'slurm', 'prj1', '["usr1","usr2"]', '["pi1"]', '[]', ''),
+			('', 'slurm-0', 'slurm', 'prj2', '["usr3"]', '[]', '[]', ''),
+			('', 'slurm-1', 'slurm', 'prj1', '["usr4"]', '[]', '[]', '')`,
+		`INSERT INTO users (uid, cluster_id, resource_manager, name, projects, tags, last_updated_at) VALUES
+			('', 'slurm-0', 'slurm', 'usr1', '["prj1"]', '[]', ''),
+			('', 'slurm-0', 'slurm', 'usr2', '["prj1"]', '[]', ''),
+			('', 'slurm-0', 'slurm', 'usr3', '["prj2"]', '[]', ''),
+			('', 'slurm-1', 'slurm', 'usr4', '["prj1"]', '[]', '')`,
+		`INSERT INTO units (cluster_id, resource_manager, uuid, project, username, ignore, parent_uuid) VALUES
+			('slurm-0', 'slurm', '1', 'prj1', 'usr1', 0, ''),
+			('slurm-0', 'slurm', '2', 'prj1', 'usr2', 0, ''),
+			('slurm-0', 'slurm', '3', 'prj2', 'usr3', 0, ''),
+			('slurm-1', 'slurm', '4', 'prj1', 'usr4', 0, '')`,
+	} {
+		_, err = dbConn.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	dbConn.Close()
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	defer server.Shutdown(context.Background())
+
+	// Make a request as user and return names of resources in response
+	request := func(path, user string) []string {
+		req := httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+path, nil)
+		req.Header.Set(grafanaUserHeader, user)
+
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+		require.Equal(t, 200, w.Code, path)
+
+		var response Response[map[string]interface{}]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		var names []string
+
+		for _, d := range response.Data {
+			if uuid, ok := d["uuid"]; ok {
+				names = append(names, uuid.(string))
+			} else {
+				names = append(names, d["name"].(string))
+			}
+		}
+
+		return names
+	}
+
+	unitsPath := "/units?uuid=1&uuid=2&uuid=3&uuid=4&field=uuid&field=project&field=username"
+
+	// Coordinator must see all units, users and projects of coordinated project
+	assert.Equal(t, []string{"1", "2"}, request(unitsPath, "pi1"))
+	assert.Equal(t, []string{"usr1", "usr2"}, request("/users", "pi1"))
+	assert.Equal(t, []string{"prj1"}, request("/projects", "pi1"))
+
+	// Regular users must only see their own units
+	assert.Equal(t, []string{"1"}, request(unitsPath, "usr1"))
+	assert.Equal(t, []string{"usr1"}, request("/users", "usr1"))
+	assert.Equal(t, []string{"3"}, request(unitsPath, "usr3"))
+}
+
+func TestAuditLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	_, err = dbConn.Exec(`INSERT INTO admin_users (source, users, last_updated_at) VALUES ('ceems', '["adm1"]', '')`)
+	require.NoError(t, err)
+
+	dbConn.Close()
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+				Audit:       audit.Config{Backend: audit.BackendDB},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	defer server.Shutdown(context.Background())
+
+	request := func(path, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+path, nil)
+		if user != "" {
+			req.Header.Set(grafanaUserHeader, user)
+		}
+
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		return w
+	}
+
+	assert.Equal(t, 200, request("/units?uuid=1&cluster_id=slurm-0", "usr1").Code)
+	assert.Equal(t, 403, request("/units/admin", "usr1").Code)
+	assert.Equal(t, 401, request("/units", "").Code)
+	assert.Equal(t, 200, request("/units/admin?user=usr3", "adm1").Code)
+	assert.Equal(t, 200, request("/health", "").Code)
+
+	// Flush pending records. Records can still be queried from DB
+	require.NoError(t, server.audit.Close())
+
+	records, err := server.audit.Query(context.Background(), audit.Filter{To: time.Now().UnixMilli()})
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	assert.Equal(t, "usr1", records[3].User)
+	assert.Equal(t, audit.DecisionAllow, records[3].Decision)
+	assert.Equal(t, models.List{"1"}, records[3].UUIDs)
+	assert.Equal(t, models.List{"slurm-0"}, records[3].ClusterIDs)
+	assert.Equal(t, base.CEEMSServerAppName, records[3].Source)
+
+	// Fetch records using admin endpoint
+	audited := func(path, user string) []models.AuditRecord {
+		w := request(path, user)
+		require.Equal(t, 200, w.Code, path)
+
+		var response Response[models.AuditRecord]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		return response.Data
+	}
+
+	records = audited("/audit/admin?decision=deny", "adm1")
+	require.Len(t, records, 2)
+	assert.Equal(t, "", records[0].User)
+	assert.Equal(t, 401, records[0].StatusCode)
+	assert.Equal(t, "usr1", records[1].User)
+	assert.Equal(t, "/api/v1/units/admin", records[1].Endpoint)
+
+	records = audited("/audit/admin?user=adm1", "adm1")
+	require.Len(t, records, 1)
+	assert.Equal(t, models.List{"usr3"}, records[0].QueriedUsers)
+
+	// Window without any records
+	assert.Empty(t, audited("/audit/admin?from=0&to=3600", "adm1"))
+
+	// Sorting is not supported and admin endpoint is forbidden for users
+	assert.Equal(t, 400, request("/audit/admin?sort=user", "adm1").Code)
+	assert.Equal(t, 403, request("/audit/admin", "usr1").Code)
+}
+
+func TestUnitEventsStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Poll DB frequently in test
+	eventsPollInterval = 10 * time.Millisecond
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	insertEvent := func(typ, uuid, user, project string) {
+		_, err := dbConn.Exec(
+			"INSERT INTO unit_events (type,cluster_id,resource_manager,uuid,project,username,state,timestamp) VALUES (?,'slurm-0','slurm',?,?,?,'RUNNING',?)",
+			typ, uuid, project, user, time.Now().UnixMilli(),
+		)
+		require.NoError(t, err)
+	}
+
+	_, err = dbConn.Exec(`INSERT INTO admin_users (source, users, last_updated_at) VALUES ('ceems', '["adm1"]', '')`)
+	require.NoError(t, err)
+	_, err = dbConn.Exec(`INSERT INTO projects (cluster_id, name, users, coordinators) VALUES ('slurm-0', 'prj2', '["usr2"]', '["usr3"]')`)
+	require.NoError(t, err)
+
+	insertEvent(models.UnitEventCreated, "1", "usr1", "prj1")
+	insertEvent(models.UnitEventEnded, "1", "usr1", "prj1")
+	insertEvent(models.UnitEventCreated, "2", "usr2", "prj2")
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(server.server.Handler)
+	defer ts.Close()
+
+	// Opens a stream and returns IDs and types of first n events
+	stream := func(path, user, lastEventID string, n int, onOpen func()) ([]string, int) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/"+base.APIVersion+path, nil)
+		require.NoError(t, err)
+		req.Header.Set(grafanaUserHeader, user)
+
+		if lastEventID != "" {
+			req.Header.Set(lastEventIDHeader, lastEventID)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, resp.StatusCode
+		}
+
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		if onOpen != nil {
+			onOpen()
+		}
+
+		var events []string
+
+		var id string
+
+		scanner := bufio.NewScanner(resp.Body)
+		for len(events) < n && scanner.Scan() {
+			line := scanner.Text()
+
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				events = append(events, id+":"+strings.TrimPrefix(line, "event: "))
+			case strings.HasPrefix(line, "data: "):
+				var event models.UnitEvent
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+				assert.Equal(t, id, strconv.FormatInt(event.ID, 10))
+			}
+		}
+
+		return events, resp.StatusCode
+	}
+
+	// Resume from beginning. Events of other users must not be streamed
+	events, code := stream("/units/events", "usr1", "0", 2, nil)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, []string{"1:created", "2:ended"}, events)
+
+	// Resume from an event
+	events, _ = stream("/units/events?last_event_id=1", "usr1", "", 1, nil)
+	assert.Equal(t, []string{"2:ended"}, events)
+
+	// Coordinators get events of units in their projects
+	events, _ = stream("/units/events?type=created", "usr3", "0", 1, nil)
+	assert.Equal(t, []string{"3:created"}, events)
+
+	// Without last event ID, only new events are streamed
+	events, _ = stream("/units/events", "usr1", "", 1, func() {
+		insertEvent(models.UnitEventStateChanged, "3", "usr2", "prj2")
+		insertEvent(models.UnitEventStateChanged, "1", "usr1", "prj1")
+	})
+	assert.Equal(t, []string{"5:state_changed"}, events)
+
+	// Admin can stream events of any user
+	events, _ = stream("/units/events/admin?user=usr2", "adm1", "0", 2, nil)
+	assert.Equal(t, []string{"3:created", "4:state_changed"}, events)
+
+	// Invalid requests
+	_, code = stream("/units/events", "usr1", "foo", 1, nil)
+	assert.Equal(t, 400, code)
+	_, code = stream("/units/events/admin", "usr1", "0", 1, nil)
+	assert.Equal(t, 403, code)
+
+	require.NoError(t, server.Shutdown(context.Background()))
+}
+
+func TestUnitEventsStreamWithAudit(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Poll DB frequently in test
+	eventsPollInterval = 10 * time.Millisecond
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	_, err = dbConn.Exec(
+		"INSERT INTO unit_events (type,cluster_id,resource_manager,uuid,project,username,state,timestamp) VALUES (?,'slurm-0','slurm','1','prj1','usr1','RUNNING',?)",
+		models.UnitEventCreated, time.Now().UnixMilli(),
+	)
+	require.NoError(t, err)
+
+	dbConn.Close()
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+				Audit:       audit.Config{Backend: audit.BackendDB},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(server.server.Handler)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/"+base.APIVersion+"/units/events", nil)
+	require.NoError(t, err)
+	req.Header.Set(grafanaUserHeader, "usr1")
+	req.Header.Set(lastEventIDHeader, "0")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	// Events must be flushed through the response writer of audit middleware
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var event string
+
+	scanner := bufio.NewScanner(resp.Body)
+	for event == "" && scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+		}
+	}
+
+	resp.Body.Close()
+	assert.Equal(t, models.UnitEventCreated, event)
+
+	require.NoError(t, server.Shutdown(context.Background()))
+}
+
+func TestEfficiencyHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	insertUnit := func(uuid, user, project, efficiency string) {
+		_, err := dbConn.Exec(
+			`INSERT INTO units (cluster_id,resource_manager,uuid,name,project,username,state,started_at,ended_at,ended_at_ts,total_time_seconds,efficiency,parent_uuid,ignore)
+			VALUES ('slurm-0','slurm',?,'job',?,?,'RUNNING','2024-10-01T00:00:00','Unknown',0,'{"walltime":3600,"alloc_cputime":3600}',?,'',0)`,
+			uuid, project, user, efficiency,
+		)
+		require.NoError(t, err)
+	}
+
+	_, err = dbConn.Exec(`INSERT INTO admin_users (source, users, last_updated_at) VALUES ('ceems', '["adm1"]', '')`)
+	require.NoError(t, err)
+	_, err = dbConn.Exec(`INSERT INTO projects (cluster_id, name, users) VALUES ('slurm-0', 'prj1', '["usr1","usr2"]')`)
+	require.NoError(t, err)
+
+	insertUnit("1", "usr1", "prj1", `{"cpu":0.2,"score":0.2,"wasted_energy_kwh":2}`)
+	insertUnit("2", "usr2", "prj1", `{"cpu":0.6,"score":0.6,"wasted_energy_kwh":1}`)
+	insertUnit("3", "usr3", "prj2", `{"cpu":0.9,"score":0.9}`)
+	insertUnit("4", "usr1", "prj1", `{}`)
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	defer server.Shutdown(context.Background())
+
+	ts := httptest.NewServer(server.server.Handler)
+	defer ts.Close()
+
+	get := func(path, user string, v any) int {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/"+base.APIVersion+path, nil)
+		require.NoError(t, err)
+		req.Header.Set(grafanaUserHeader, user)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		defer resp.Body.Close()
+
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+
+		return resp.StatusCode
+	}
+
+	// Least efficient units come first and unscored units are skipped
+	var units Response[models.Unit]
+	assert.Equal(t, 200, get("/units/efficiency/admin?running", "adm1", &units))
+	require.Len(t, units.Data, 3)
+	assert.Equal(t, []string{"1", "2", "3"}, []string{units.Data[0].UUID, units.Data[1].UUID, units.Data[2].UUID})
+
+	units = Response[models.Unit]{}
+	assert.Equal(t, 200, get("/units/efficiency/admin?running&sort=cpu&order=desc&limit=1", "adm1", &units))
+	require.Len(t, units.Data, 1)
+	assert.Equal(t, "3", units.Data[0].UUID)
+	assert.Equal(t, 1, units.Pagination.NextOffset)
+
+	// Users only get their own units
+	units = Response[models.Unit]{}
+	assert.Equal(t, 200, get("/units/efficiency?running", "usr1", &units))
+	require.Len(t, units.Data, 1)
+	assert.Equal(t, "1", units.Data[0].UUID)
+
+	// Project rollups
+	var rollups Response[models.Efficiency]
+	assert.Equal(t, 200, get("/usage/global/efficiency/admin", "adm1", &rollups))
+	require.Len(t, rollups.Data, 2)
+	assert.Equal(t, "prj1", rollups.Data[0].Project)
+	assert.Equal(t, int64(2), rollups.Data[0].NumUnits)
+	assert.InEpsilon(t, 0.4, float64(rollups.Data[0].Efficiency["score"]), 1e-9)
+	assert.InEpsilon(t, 3, float64(rollups.Data[0].Efficiency["wasted_energy_kwh"]), 1e-9)
+
+	// Users get rollups of their projects split by users
+	rollups = Response[models.Efficiency]{}
+	assert.Equal(t, 200, get("/usage/current/efficiency?groupby=username", "usr1", &rollups))
+	require.Len(t, rollups.Data, 2)
+	assert.Equal(t, "usr1", rollups.Data[0].User)
+	assert.Equal(t, "usr2", rollups.Data[1].User)
+
+	// Invalid sort fields and cursors
+	for _, query := range []string{"sort=uuid", "order=up", "cursor=abc"} {
+		var resp Response[any]
+		assert.Equal(t, 400, get("/units/efficiency?"+query, "usr1", &resp), query)
+	}
+
+	// Non admin users cannot access admin endpoints
+	var resp Response[any]
+	assert.Equal(t, 403, get("/usage/global/efficiency/admin", "usr1", &resp))
+}
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		filters []filter
+		err     bool
+	}{
+		{
+			name:   "numeric comparison",
+			values: []string{"avg_gpu_usage.global<10", "total_time_seconds.walltime>=3600"},
+			filters: []filter{
+				{column: "avg_gpu_usage", key: "global", op: "<", value: "10", numeric: true},
+				{column: "total_time_seconds", key: "walltime", op: ">=", value: "3600", numeric: true},
+			},
+		},
+		{
+			name:   "string equality",
+			values: []string{"tags.partition==gpu", "tags.qos!=low"},
+			filters: []filter{
+				{column: "tags", key: "partition", op: "=", value: "gpu"},
+				{column: "tags", key: "qos", op: "!=", value: "low"},
+			},
+		},
+		{
+			name:   "keys with slashes, dots and hyphens",
+			values: []string{"allocation.gres/gpu>=1", "tags.job-name==train", "tags.node.role==login"},
+			filters: []filter{
+				{column: "allocation", key: "gres/gpu", op: ">=", value: "1", numeric: true},
+				{column: "tags", key: "job-name", op: "=", value: "train"},
+				{column: "tags", key: "node.role", op: "=", value: "login"},
+			},
+		},
+		{
+			name:   "unknown column",
+			values: []string{"username.foo==bar"},
+			err:    true,
+		},
+		{
+			name:   "string comparison",
+			values: []string{"tags.partition<gpu"},
+			err:    true,
+		},
+		{
+			name:   "malformed key",
+			values: []string{"tags.part'ition==gpu"},
+			err:    true,
+		},
+		{
+			name:   "missing operator",
+			values: []string{"tags.partition"},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		filters, err := parseFilters(test.values, unitFilterColumns)
+		if test.err {
+			require.ErrorIs(t, err, errInvalidFilter, test.name)
+
+			continue
+		}
+
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.filters, filters, test.name)
+	}
+}
+
+func TestUnitsFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	insertUnit := func(uuid, gpuUsage, tags string) {
+		_, err := dbConn.Exec(
+			`INSERT INTO units (cluster_id,resource_manager,uuid,name,project,username,state,started_at,ended_at,ended_at_ts,avg_gpu_usage,tags,parent_uuid,ignore)
+			VALUES ('slurm-0','slurm',?,'job','prj1','usr1','RUNNING','2024-10-01T00:00:00','Unknown',0,?,?,'',0)`,
+			uuid, gpuUsage, tags,
+		)
+		require.NoError(t, err)
+	}
+
+	_, err = dbConn.Exec(`INSERT INTO admin_users (source, users, last_updated_at) VALUES ('ceems', '["adm1"]', '')`)
+	require.NoError(t, err)
+
+	insertUnit("1", `{"global":5,"gres/gpu":1}`, `{"partition":"gpu","qos":"high"}`)
+	insertUnit("2", `{"global":50,"gres/gpu":4}`, `{"partition":"gpu","qos":"low"}`)
+	insertUnit("3", `{}`, `{"partition":"cpu","qos":"high","job-name":"train"}`)
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	defer server.Shutdown(context.Background())
+
+	ts := httptest.NewServer(server.server.Handler)
+	defer ts.Close()
+
+	tests := []struct {
+		query string
+		code  int
+		uuids []string
+	}{
+		{query: "filter=avg_gpu_usage.global<10", code: 200, uuids: []string{"1"}},
+		{query: "filter=avg_gpu_usage.global>=5", code: 200, uuids: []string{"1", "2"}},
+		{query: "filter=tags.partition==gpu&filter=tags.qos==high", code: 200, uuids: []string{"1"}},
+		{query: "filter=tags.partition!=gpu", code: 200, uuids: []string{"3"}},
+		{query: "filter=avg_gpu_usage.gres/gpu>2", code: 200, uuids: []string{"2"}},
+		{query: "filter=tags.job-name==train", code: 200, uuids: []string{"3"}},
+		{query: "filter=tags.account==prj1", code: 400},
+		{query: "filter=tags.partition>gpu", code: 400},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/"+base.APIVersion+"/units/admin?running&"+test.query, nil)
+		require.NoError(t, err)
+		req.Header.Set(grafanaUserHeader, "adm1")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		var units Response[models.Unit]
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&units))
+		resp.Body.Close()
+
+		assert.Equal(t, test.code, resp.StatusCode, test.query)
+
+		uuids := make([]string, len(units.Data))
+		for i, unit := range units.Data {
+			uuids[i] = unit.UUID
+		}
+
+		if test.code == 200 {
+			assert.Equal(t, test.uuids, uuids, test.query)
+		}
+	}
+
+	// Keys must be cached after first use
+	assert.True(t, server.keysCache.Has(base.UnitsDBTableName+".tags"))
+	assert.True(t, server.keysCache.Has(base.UnitsDBTableName+".avg_gpu_usage"))
+}
+
+func TestUsageGroupByDimensions(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	insertUnit := func(uuid, energy, allocation, tags string) {
+		_, err := dbConn.Exec(
+			`INSERT INTO units (cluster_id,resource_manager,uuid,name,project,username,state,started_at,ended_at,ended_at_ts,total_cpu_energy_usage_kwh,allocation,tags,parent_uuid,ignore,last_updated_at)
+			VALUES ('slurm-0','slurm',?,'job','prj1','usr1','COMPLETED','2024-10-01T10:00:00','2024-10-01T11:00:00',1727780400000,?,?,?,'',0,'2024-10-01T12:00:00')`,
+			uuid, energy, allocation, tags,
+		)
+		require.NoError(t, err)
+	}
+
+	insertDailyUsage := func(day, energy, dimensions string) {
+		_, err := dbConn.Exec(
+			`INSERT INTO daily_usage (cluster_id,resource_manager,num_units,project,groupname,username,last_updated_at,total_cpu_energy_usage_kwh,dimensions,num_updates)
+			VALUES ('slurm-0','slurm',1,'prj1','grp1','usr1',?,?,?,1)`,
+			day, energy, dimensions,
+		)
+		require.NoError(t, err)
+	}
+
+	_, err = dbConn.Exec(`INSERT INTO admin_users (source, users, last_updated_at) VALUES ('ceems', '["adm1"]', '')`)
+	require.NoError(t, err)
+
+	_, err = dbConn.Exec(`INSERT INTO projects (cluster_id, resource_manager, name, users, last_updated_at) VALUES ('slurm-0', 'slurm', 'prj1', '["usr1"]', '')`)
+	require.NoError(t, err)
+
+	insertUnit("1", `{"total":1}`, `{"partition":"gpu"}`, `{"qos":"high"}`)
+	insertUnit("2", `{"total":2}`, `{"partition":"gpu"}`, `{"qos":"low"}`)
+	insertUnit("3", `{"total":4}`, `{"partition":"cpu"}`, `{"qos":"high"}`)
+
+	insertDailyUsage("2024-09-30T00:00:00", `{"total":10}`, `{"allocation.partition":"gpu"}`)
+	insertDailyUsage("2024-10-01T00:00:00", `{"total":20}`, `{"allocation.partition":"gpu"}`)
+	insertDailyUsage("2024-10-01T00:00:00", `{"total":40}`, `{"allocation.partition":"cpu"}`)
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	defer server.Shutdown(context.Background())
+
+	ts := httptest.NewServer(server.server.Handler)
+	defer ts.Close()
+
+	tests := []struct {
+		path       string
+		code       int
+		dimensions []models.Tag
+		energy     []float64
+	}{
+		{
+			path:       "current/admin?from=1727740800&to=1727827200&field=total_cpu_energy_usage_kwh&field=dimensions&groupby=allocation.partition",
+			code:       200,
+			dimensions: []models.Tag{{"allocation.partition": "cpu"}, {"allocation.partition": "gpu"}},
+			energy:     []float64{4, 3},
+		},
+		{
+			path:       "current/admin?from=1727740800&to=1727827200&field=total_cpu_energy_usage_kwh&field=dimensions&groupby=allocation.partition&groupby=tags.qos",
+			code:       200,
+			dimensions: []models.Tag{{"allocation.partition": "cpu", "tags.qos": "high"}, {"allocation.partition": "gpu", "tags.qos": "high"}, {"allocation.partition": "gpu", "tags.qos": "low"}},
+			energy:     []float64{4, 1, 2},
+		},
+		{
+			path:       "current/admin?from=1727740800&to=1727827200&field=total_cpu_energy_usage_kwh&field=dimensions&groupby=allocation.partition&experimental",
+			code:       200,
+			dimensions: []models.Tag{{"allocation.partition": "cpu"}, {"allocation.partition": "gpu"}},
+			energy:     []float64{40, 20},
+		},
+		{
+			path:       "global/admin?field=total_cpu_energy_usage_kwh&field=dimensions&groupby=allocation.partition",
+			code:       200,
+			dimensions: []models.Tag{{"allocation.partition": "cpu"}, {"allocation.partition": "gpu"}},
+			energy:     []float64{40, 30},
+		},
+		{path: "current/admin?groupby=allocation.nodes", code: 400},
+		{path: "current/admin?groupby=uuid", code: 400},
+		{path: "global/admin?groupby=tags.qos", code: 400},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/"+base.APIVersion+"/usage/"+test.path, nil)
+		require.NoError(t, err)
+		req.Header.Set(grafanaUserHeader, "adm1")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+
+		var usage Response[models.Usage]
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&usage))
+		resp.Body.Close()
+
+		require.Equal(t, test.code, resp.StatusCode, test.path)
+
+		if test.code != 200 {
+			continue
+		}
+
+		dimensions := make([]models.Tag, len(usage.Data))
+		energy := make([]float64, len(usage.Data))
+
+		for i, u := range usage.Data {
+			dimensions[i] = u.Dimensions
+			energy[i] = float64(u.TotalCPUEnergyUsage["total"])
+		}
+
+		assert.Equal(t, test.dimensions, dimensions, test.path)
+		assert.InEpsilonSlice(t, test.energy, energy, 1e-6, test.path)
+	}
+}
+
+// mockIngester records ingested units.
+type mockIngester struct {
+	clusterID string
+	units     []models.Unit
+	users     []models.User
+	projects  []models.Project
+}
+
+func (m *mockIngester) Ingest(
+	_ context.Context,
+	clusterID string,
+	units []models.Unit,
+	users []models.User,
+	projects []models.Project,
+) error {
+	if clusterID != "push-0" {
+		return db.ErrPushCluster
+	}
+
+	m.clusterID, m.units, m.users, m.projects = clusterID, units, users, projects
+
+	return nil
+}
+
+func TestIngestHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dbConn, err := sql.Open("sqlite3", filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	// Create all tables using migrations
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	_, err = dbConn.Exec("INSERT INTO admin_users (source, users) VALUES ('ceems', '[\"adm1\"]')")
+	require.NoError(t, err)
+
+	dbConn.Close()
+
+	ingester := &mockIngester{}
+
+	server, _, err := New(
+		&Config{
+			Logger: logger,
+			DB: db.Config{
+				Data: db.DataConfig{
+					Path:     tmpDir,
+					Timezone: db.Timezone{Location: time.UTC},
+				},
+			},
+			Web: WebConfig{
+				Addresses:   []string{"localhost:9020"}, // dummy address
+				RoutePrefix: "/",
+				LBSecret:    "secret",
+			},
+			Ingester: ingester,
+		},
+	)
+	require.NoError(t, err)
+
+	defer server.Shutdown(context.Background())
+
+	request := func(headers map[string]string, body string) (int, Response[models.IngestStats]) {
+		req := httptest.NewRequest(http.MethodPost, "/api/"+base.APIVersion+"/units/ingest", strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		var response Response[models.IngestStats]
+
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		return w.Code, response
+	}
+
+	body := `{
+		"cluster_id": "push-0",
+		"units": [{"uuid": "1000", "username": "foo1", "project": "fooprj", "started_at_ts": 1700000000000, "allocation": {"cpus": 2}, "total_cpu_energy_usage_kwh": {"total": 1.5}}],
+		"users": [{"name": "foo1", "projects": ["fooprj"]}],
+		"projects": [{"name": "fooprj", "users": ["foo1"]}]
+	}`
+
+	// Only admin users can push units
+	code, _ := request(map[string]string{grafanaUserHeader: "usr1"}, body)
+	assert.Equal(t, 403, code)
+
+	// CEEMS header must not bypass authentication even with the secret of LB
+	for _, secret := range []string{"", "admin", "secret"} {
+		code, _ = request(map[string]string{ceemsUserHeader: secret, adminUserHeader: "adm1"}, body)
+		assert.Equal(t, 401, code, secret)
+	}
+
+	code, _ = request(map[string]string{ceemsUserHeader: "secret", grafanaUserHeader: "usr1"}, body)
+	assert.Equal(t, 403, code)
+
+	assert.Empty(t, ingester.units)
+
+	// Handler must reject requests without admin principal
+	req := httptest.NewRequest(http.MethodPost, "/api/"+base.APIVersion+"/units/ingest", strings.NewReader(body))
+	req.Header.Set(loggedUserHeader, "adm1")
+
+	w := httptest.NewRecorder()
+	server.ingestUnits(w, req)
+	assert.Equal(t, 403, w.Code)
+	assert.Empty(t, ingester.units)
+
+	code, response := request(map[string]string{grafanaUserHeader: "adm1"}, body)
+	require.Equal(t, 200, code)
+	assert.Equal(t, []models.IngestStats{{Units: 1, Users: 1, Projects: 1}}, response.Data)
+
+	require.Len(t, ingester.units, 1)
+	assert.Equal(t, "push-0", ingester.clusterID)
+	assert.Equal(t, models.JSONFloat(1.5), ingester.units[0].TotalCPUEnergyUsage["total"])
+	assert.Equal(t, models.List{"fooprj"}, ingester.users[0].Projects)
+
+	// Clusters without push manager are rejected
+	code, _ = request(map[string]string{grafanaUserHeader: "adm1"}, `{"cluster_id": "slurm-0", "units": [{"uuid": "1000"}]}`)
+	assert.Equal(t, 400, code)
+
+	code, _ = request(map[string]string{grafanaUserHeader: "adm1"}, `{"cluster_id": "push-0"`)
+	assert.Equal(t, 400, code)
+
+	// Ingestion is disabled without ingester
+	server.ingester = nil
+
+	code, _ = request(map[string]string{grafanaUserHeader: "adm1"}, body)
+	assert.Equal(t, 404, code)
+}
+
+func TestDecodeIngestRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  string
+		err  string
+	}{
+		{name: "valid request", req: `{"cluster_id":"push-0","units":[{"uuid":"1","ended_at_ts":null,"tags":{"qos":"normal"}}]}`},
+		{name: "missing cluster", req: `{"units":[{"uuid":"1"}]}`, err: "cluster_id is required"},
+		{name: "missing uuid", req: `{"cluster_id":"push-0","units":[{"name":"job"}]}`, err: "units[0]: field uuid is required"},
+		{name: "unknown field", req: `{"cluster_id":"push-0","units":[{"uuid":"1","foo":"bar"}]}`, err: "units[0]: unknown field foo"},
+		{name: "internal field", req: `{"cluster_id":"push-0","units":[{"uuid":"1","ignore":1}]}`, err: "units[0]: unknown field ignore"},
+		{name: "float integer", req: `{"cluster_id":"push-0","units":[{"uuid":"1","started_at_ts":1.5}]}`, err: "field started_at_ts must be an integer"},
+		{name: "number as text", req: `{"cluster_id":"push-0","units":[{"uuid":"1","state":1}]}`, err: "field state must be a string"},
+		{name: "invalid map", req: `{"cluster_id":"push-0","units":[{"uuid":"1","allocation":[1]}]}`, err: "field allocation must be an object"},
+		{name: "invalid list", req: `{"cluster_id":"push-0","users":[{"name":"foo","projects":"bar"}]}`, err: "users[0]: field projects must be an array"},
+		{name: "missing name", req: `{"cluster_id":"push-0","projects":[{"users":["foo"]}]}`, err: "projects[0]: field name is required"},
+		{name: "not an object", req: `{"cluster_id":"push-0","units":["1"]}`, err: "units[0]: must be an object"},
+		{name: "other cluster", req: `{"cluster_id":"push-0","units":[{"uuid":"1","cluster_id":"push-1"}]}`, err: "cluster_id push-1 does not match request"},
+	}
+
+	for _, test := range tests {
+		var req IngestRequest
+
+		require.NoError(t, json.Unmarshal([]byte(test.req), &req), test.name)
+
+		_, _, _, err := decodeIngestRequest(req)
+		if test.err == "" {
+			require.NoError(t, err, test.name)
+
+			continue
+		}
+
+		require.ErrorIs(t, err, errInvalidRequest, test.name)
+		assert.ErrorContains(t, err, test.err, test.name)
+	}
+}
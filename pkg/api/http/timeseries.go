@@ -0,0 +1,312 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+var (
+	// Expressions that return the date of the start of time bucket of each step in
+	// each SQL dialect. Weeks start on Monday. As last_updated_at is stored in the
+	// time zone of the server, dates are converted to epoch timestamps in that time
+	// zone after querying.
+	timeSeriesSteps = map[string]map[string]string{
+		"1d": {
+			base.SQLite:   "date(last_updated_at)",
+			base.Postgres: "to_char(date_trunc('day', CAST(last_updated_at AS timestamp)), 'YYYY-MM-DD')",
+		},
+		"1w": {
+			base.SQLite:   "date(last_updated_at, 'weekday 0', '-6 days')",
+			base.Postgres: "to_char(date_trunc('week', CAST(last_updated_at AS timestamp)), 'YYYY-MM-DD')",
+		},
+		"1M": {
+			base.SQLite:   "date(last_updated_at, 'start of month')",
+			base.Postgres: "to_char(date_trunc('month', CAST(last_updated_at AS timestamp)), 'YYYY-MM-DD')",
+		},
+	}
+
+	// Columns of daily_usage table for each groupby query parameter.
+	timeSeriesGroupBy = map[string]string{
+		"cluster_id": "cluster_id",
+		"project":    "project",
+		"user":       "username",
+	}
+
+	// Fields of usage that can be aggregated in time series.
+	timeSeriesFields []string
+
+	errInvalidStep    = errors.New("invalid step. Allowed steps are 1d, 1w and 1M")
+	errInvalidGroupBy = errors.New("invalid groupby. Allowed values are cluster_id, project and user")
+)
+
+func init() {
+	for _, col := range base.UsageDBTableColNames {
+		if col == "num_units" || strings.HasPrefix(col, "total") || strings.HasPrefix(col, "avg") {
+			timeSeriesFields = append(timeSeriesFields, col)
+		}
+	}
+}
+
+// timeSeriesAggQuery returns the aggregate SQL expression of a usage field. Averages are
+// weighed using the same weights that are used to update the usage tables.
+func timeSeriesAggQuery(field string) string {
+	switch {
+	case field == "num_units":
+		return "SUM(num_units) AS num_units"
+	case strings.HasPrefix(field, "avg"):
+		return fmt.Sprintf(
+			"avg_metric_map_agg(COALESCE(%[1]s,'{}'),COALESCE(CAST(json_extract(total_time_seconds,'$.%[2]s') AS REAL),0.0)) AS %[1]s",
+			field, db.Weights[field],
+		)
+	default:
+		return fmt.Sprintf("sum_metric_map_agg(COALESCE(%[1]s,'{}')) AS %[1]s", field)
+	}
+}
+
+// usageTimeSeriesQuerier queries the daily usage of projects of users in time buckets
+// and writes response.
+func (s *CEEMSServer) usageTimeSeriesQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	// Get response format
+	format, err := responseFormat(r)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Get step of time series
+	step := r.URL.Query().Get("step")
+	if step == "" {
+		step = "1d"
+	}
+
+	buckets, ok := timeSeriesSteps[step]
+	if !ok {
+		errorResponse[any](w, &apiError{errorBadData, fmt.Errorf("%w: %s", errInvalidStep, step)}, s.logger, nil)
+
+		return
+	}
+
+	// Get group by columns
+	var groupby []string
+
+	for _, g := range r.URL.Query()["groupby"] {
+		col, ok := timeSeriesGroupBy[g]
+		if !ok {
+			errorResponse[any](w, &apiError{errorBadData, fmt.Errorf("%w: %s", errInvalidGroupBy, g)}, s.logger, nil)
+
+			return
+		}
+
+		if !slices.Contains(groupby, col) {
+			groupby = append(groupby, col)
+		}
+	}
+
+	// Get fields query parameters if any
+	queriedFields := s.getQueriedFields(r.URL.Query(), timeSeriesFields)
+	if len(queriedFields) == 0 {
+		errorResponse[any](w, &apiError{errorBadData, errInvalidQueryField}, s.logger, nil)
+
+		return
+	}
+
+	// Get query window time stamps
+	timeQuery, err := s.getQueryWindow(r, "last_updated_at", false, false)
+	if err != nil {
+		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+
+		return
+	}
+
+	// Set write deadline
+	s.setWriteDeadline(5*time.Minute, w)
+
+	cols := []string{buckets[base.Dialect(s.db)] + " AS bucket"}
+	cols = append(cols, groupby...)
+
+	for _, field := range queriedFields {
+		cols = append(cols, timeSeriesAggQuery(field))
+	}
+
+	// Make query
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(cols, ","), base.DailyUsageDBTableName))
+
+	// First select all projects that user is part of using subquery
+	q.query(" WHERE (cluster_id, project) IN ")
+	q.subQuery(projectsSubQuery(users))
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	// Add time query as sub query to main query
+	q.query(" AND ")
+	q.subQuery(timeQuery)
+
+	// Group by time bucket and requested columns
+	q.query(" GROUP BY " + strings.Join(append([]string{"bucket"}, groupby...), ","))
+	q.query(" ORDER BY " + strings.Join(append([]string{"bucket"}, groupby...), " ASC,") + " ASC")
+
+	// Make query and check for returned number of rows
+	usage, err := s.queriers.timeSeries(r.Context(), s.db, q, s.logger)
+	if usage == nil && err != nil {
+		s.logger.Error("Failed to fetch usage time series", "users", strings.Join(users, ","), "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	// Convert dates of buckets to timestamps in the time zone of the server
+	for i := range usage {
+		if usage[i].Bucket == "" {
+			continue
+		}
+
+		bucket, err := time.ParseInLocation(time.DateOnly, usage[i].Bucket, s.dbConfig.Data.Timezone.Location)
+		if err != nil {
+			s.logger.Error("Failed to parse time bucket", "bucket", usage[i].Bucket, "err", err)
+
+			continue
+		}
+
+		usage[i].Timestamp = bucket.UnixMilli()
+	}
+
+	// Write usage in CSV/NDJSON format if requested
+	if writeExport(w, format, usageResourceName, slices.Concat([]string{"timestamp"}, groupby, queriedFields), usage, s.logger) {
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	usageResponse := Response[models.UsageTimeSeries]{
+		Status: "success",
+		Data:   usage,
+	}
+	if err != nil {
+		usageResponse.Warnings = append(usageResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&usageResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// usageTimeSeries         godoc
+//
+//	@Summary		Usage time series
+//	@Description	This endpoint will return the usage statistics of the projects of current user
+//	@Description	aggregated in time buckets. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The usage statistics are estimated from daily usage of projects and hence, they
+//	@Description	are available beyond the retention period of TSDB. The query parameter `step`
+//	@Description	sets the size of time bucket which can be one day (`1d`), one week (`1w`) or one
+//	@Description	calendar month (`1M`). Weeks start on Monday. The `timestamp` of each bucket is the
+//	@Description	start of the bucket in epoch milliseconds.
+//	@Description
+//	@Description	By default, usage statistics of all projects are aggregated in each bucket. The query
+//	@Description	parameter `groupby` can be used to split the statistics by `project`, `user` and/or
+//	@Description	`cluster_id`. Average metrics are weighed by the allocated time of each resource.
+//	@Description
+//	@Description	If `to` query parameter is not provided, current time will be used. If `from`
+//	@Description	query parameter is not used, a default query window of 24 hours will be used.
+//	@Description
+//	@Description	To limit the number of fields in the response, use `field` query parameter. By default, all
+//	@Description	fields will be included in the response if they are _non-empty_.
+//	@Security		BasicAuth
+//	@Tags			usage
+//	@Produce		json
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			step			query		string		false	"Size of time bucket"	Enums(1d, 1w, 1M)
+//	@Param			groupby			query		[]string	false	"Group by"				collectionFormat(multi)
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"			collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"				collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
+//	@Param			format			query		string		false	"Response format"				Enums(json, csv, ndjson)
+//	@Success		200				{object}	Response[models.UsageTimeSeries]
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/usage/timeseries [get]
+//
+// GET /usage/timeseries
+// Get usage time series of projects of current user.
+func (s *CEEMSServer) usageTimeSeries(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "usage time series endpoint", s.logger)
+
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	// Make query and write response
+	s.usageTimeSeriesQuerier([]string{dashboardUser}, w, r)
+}
+
+// usageTimeSeriesAdmin         godoc
+//
+//	@Summary		Admin endpoint for usage time series
+//	@Description	This admin endpoint will return the usage statistics of projects aggregated
+//	@Description	in time buckets. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	If query parameter `user` is provided, only usage of the projects of these
+//	@Description	users will be returned. If not, usage of all projects will be returned.
+//	@Description	Rest of the query parameters are same as `/usage/timeseries` endpoint.
+//	@Security		BasicAuth
+//	@Tags			usage
+//	@Produce		json
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			user			query		[]string	false	"Username"				collectionFormat(multi)
+//	@Param			step			query		string		false	"Size of time bucket"	Enums(1d, 1w, 1M)
+//	@Param			groupby			query		[]string	false	"Group by"				collectionFormat(multi)
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"			collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"				collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
+//	@Param			format			query		string		false	"Response format"				Enums(json, csv, ndjson)
+//	@Success		200				{object}	Response[models.UsageTimeSeries]
+//	@Failure		400				{object}	Response[any]
+//	@Failure		401				{object}	Response[any]
+//	@Failure		403				{object}	Response[any]
+//	@Failure		500				{object}	Response[any]
+//	@Router			/usage/timeseries/admin [get]
+//
+// GET /usage/timeseries/admin
+// Get usage time series of any project.
+func (s *CEEMSServer) usageTimeSeriesAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "usage time series admin endpoint", s.logger)
+
+	// Make query and write response
+	s.usageTimeSeriesQuerier(r.URL.Query()["user"], w, r)
+}
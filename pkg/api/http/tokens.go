@@ -0,0 +1,488 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// All API tokens start with this prefix so that they can be distinguished
+	// from OIDC tokens.
+	apiTokenPrefix = "ceems_"
+
+	// Scope that allows access to admin endpoints.
+	adminScope = "admin"
+
+	defaultTokenLifetime = 30 * 24 * time.Hour
+	maxTokenLifetime     = 365 * 24 * time.Hour
+
+	// Maximum size of request body to create tokens.
+	maxTokenRequestSize = 64 << 10
+)
+
+var (
+	// Scopes that can be granted to API tokens. Each scope, except admin,
+	// is a resource of API server.
+	tokenScopes = []string{
+		unitsResourceName, usageResourceName, usersResourceName, projectsResourceName,
+		clustersResourceName, statsResourceName, billingResourceName, budgetsResourceName,
+		auditResourceName, adminScope,
+	}
+
+	// Scopes granted to tokens when none are requested.
+	defaultTokenScopes = slices.DeleteFunc(slices.Clone(tokenScopes), func(s string) bool { return s == adminScope })
+)
+
+// TokenRequest is the request body to create API tokens.
+type TokenRequest struct {
+	Name      string         `json:"name"`                            // Name of the token
+	Scopes    []string       `json:"scopes"`                          // Resources that can be accessed with token. Defaults to all non admin resources
+	ExpiresIn model.Duration `json:"expires_in" swaggertype:"string"` // Lifetime of the token. Defaults to 30d
+}
+
+// apiTokens manages personal API tokens of users stored in DB.
+type apiTokens struct {
+	logger   *slog.Logger
+	db       *sql.DB
+	location *time.Location
+	now      func() time.Time
+}
+
+// hashToken returns SHA256 hash of token in hex format.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// create creates a new token for user and stores its hash in DB. The returned
+// token is the only place where the token is available in plain text.
+func (t *apiTokens) create(ctx context.Context, user string, name string, scopes []string, lifetime time.Duration) (models.APIToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return models.APIToken{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := t.now()
+	expiresAt := now.Add(lifetime)
+
+	scopesList := make(models.List, len(scopes))
+	for i, scope := range scopes {
+		scopesList[i] = scope
+	}
+
+	apiToken := models.APIToken{
+		TokenID:     uuid.NewString(),
+		User:        user,
+		Name:        name,
+		Prefix:      token[:len(apiTokenPrefix)+4],
+		Hash:        hashToken(token),
+		Scopes:      scopesList,
+		CreatedAt:   now.In(t.location).Format(base.DatetimezoneLayout),
+		ExpiresAt:   expiresAt.In(t.location).Format(base.DatetimezoneLayout),
+		ExpiresAtTS: expiresAt.UnixMilli(),
+	}
+
+	query := base.Rebind(
+		base.Dialect(t.db),
+		"INSERT INTO "+base.APITokensDBTableName+
+			" (token_id,username,name,prefix,token_hash,scopes,created_at,expires_at,expires_at_ts) VALUES (?,?,?,?,?,?,?,?,?)",
+	)
+	if _, err := t.db.ExecContext(
+		ctx, query, apiToken.TokenID, apiToken.User, apiToken.Name, apiToken.Prefix, apiToken.Hash,
+		apiToken.Scopes, apiToken.CreatedAt, apiToken.ExpiresAt, apiToken.ExpiresAtTS,
+	); err != nil {
+		return models.APIToken{}, fmt.Errorf("failed to store token: %w", err)
+	}
+
+	apiToken.Token = token
+
+	return apiToken, nil
+}
+
+// list returns tokens of users. If users is empty, tokens of all users are returned.
+func (t *apiTokens) list(ctx context.Context, users []string) ([]models.APIToken, error) {
+	q := Query{}
+	q.query("SELECT * FROM " + base.APITokensDBTableName)
+
+	if len(users) > 0 {
+		q.query(" WHERE username IN ")
+		q.param(users)
+	}
+
+	q.query(" ORDER BY id ASC")
+
+	return Querier[models.APIToken](ctx, t.db, q, t.logger)
+}
+
+// revoke revokes the token with tokenID. When owner is not empty, only tokens
+// of the owner can be revoked.
+func (t *apiTokens) revoke(ctx context.Context, tokenID string, owner string, revokedBy string) error {
+	query := "UPDATE " + base.APITokensDBTableName + " SET revoked_at = ?, revoked_by = ? WHERE token_id = ? AND revoked_at = ''"
+	args := []interface{}{t.now().In(t.location).Format(base.DatetimezoneLayout), revokedBy, tokenID}
+
+	if owner != "" {
+		query += " AND username = ?"
+
+		args = append(args, owner)
+	}
+
+	res, err := t.db.ExecContext(ctx, base.Rebind(base.Dialect(t.db), query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errTokenNotFound
+	}
+
+	return nil
+}
+
+// verify returns the owner and scopes of token if the token is valid.
+func (t *apiTokens) verify(ctx context.Context, token string) (string, []string, error) {
+	var id, expiresAtTS int64
+
+	var user, revokedAt string
+
+	var scopes models.List
+
+	query := base.Rebind(
+		base.Dialect(t.db),
+		"SELECT id,username,scopes,expires_at_ts,revoked_at FROM "+base.APITokensDBTableName+" WHERE token_hash = ?",
+	)
+	if err := t.db.QueryRowContext(ctx, query, hashToken(token)).Scan(&id, &user, &scopes, &expiresAtTS, &revokedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil, errTokenNotFound
+		}
+
+		return "", nil, err
+	}
+
+	if revokedAt != "" {
+		return "", nil, errTokenRevoked
+	}
+
+	now := t.now()
+	if now.UnixMilli() >= expiresAtTS {
+		return "", nil, errTokenExpired
+	}
+
+	// Failing to update last usage must not deny access
+	if _, err := t.db.ExecContext(
+		ctx,
+		base.Rebind(base.Dialect(t.db), "UPDATE "+base.APITokensDBTableName+" SET last_used_at = ? WHERE id = ?"),
+		now.In(t.location).Format(base.DatetimezoneLayout), id,
+	); err != nil {
+		t.logger.Error("Failed to update last usage of token", "user", user, "err", err)
+	}
+
+	scopeNames := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		if s, ok := scope.(string); ok {
+			scopeNames = append(scopeNames, s)
+		}
+	}
+
+	return user, scopeNames, nil
+}
+
+// tokensResponse writes tokens in response with given status code.
+func (s *CEEMSServer) tokensResponse(w http.ResponseWriter, code int, tokens []models.APIToken) {
+	w.WriteHeader(code)
+
+	response := Response[models.APIToken]{
+		Status: "success",
+		Data:   tokens,
+	}
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// tokensQuerier queries tokens of users and writes response.
+func (s *CEEMSServer) tokensQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	tokens, err := s.tokens.list(r.Context(), users)
+	if err != nil {
+		s.logger.Error("Failed to fetch tokens", "users", strings.Join(users, ","), "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	s.tokensResponse(w, http.StatusOK, tokens)
+}
+
+// tokenRevoker revokes token in the path of request. When owner is not empty,
+// only tokens of the owner can be revoked.
+func (s *CEEMSServer) tokenRevoker(owner string, w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	loggedUser, _ := s.getUser(r)
+	tokenID := mux.Vars(r)["id"]
+
+	if err := s.tokens.revoke(r.Context(), tokenID, owner, loggedUser); err != nil {
+		if errors.Is(err, errTokenNotFound) {
+			errorResponse[any](w, &apiError{errorNotFound, err}, s.logger, nil)
+
+			return
+		}
+
+		s.logger.Error("Failed to revoke token", "id", tokenID, "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	s.logger.Info("API token revoked", "id", tokenID, "revoked_by", loggedUser)
+
+	s.tokensResponse(w, http.StatusOK, nil)
+}
+
+// createToken         godoc
+//
+//	@Summary		Create API token
+//	@Description	This endpoint creates a personal API token for the current user. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	The token is returned only once in the response and it cannot be
+//	@Description	retrieved later. Requests made with `Authorization: Bearer <token>`
+//	@Description	header will be authenticated as the owner of the token with the same
+//	@Description	visibility rules as `X-Grafana-User` header.
+//	@Description
+//	@Description	Scopes limit the resources that can be accessed with the token. Allowed
+//	@Description	scopes are names of resources like `units`, `usage`, _etc_ and `admin`
+//	@Description	which is needed to access admin endpoints. Only admin users can create
+//	@Description	tokens with `admin` scope. API tokens cannot be used to manage tokens.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		tokens
+//	@Accept		json
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string			true	"Current user name"
+//	@Param		body			body		TokenRequest	true	"Token request"
+//	@Success	201				{object}	Response[models.APIToken]
+//	@Failure	400				{object}	Response[any]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/tokens [post]
+//
+// POST /tokens
+// Create a token for current user.
+func (s *CEEMSServer) createToken(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "create token endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Tokens are always owned by the logged user even when an admin is
+	// impersonating other users
+	loggedUser, _ := s.getUser(r)
+
+	var req TokenRequest
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTokenRequestSize)).Decode(&req); err != nil {
+		errorResponse[any](w, &apiError{errorBadData, fmt.Errorf("%w: %w", errInvalidRequest, err)}, s.logger, nil)
+
+		return
+	}
+
+	// Set defaults
+	if req.ExpiresIn == 0 {
+		req.ExpiresIn = model.Duration(defaultTokenLifetime)
+	}
+
+	if len(req.Scopes) == 0 {
+		req.Scopes = defaultTokenScopes
+	}
+
+	// Validate request
+	if lifetime := time.Duration(req.ExpiresIn); lifetime < 0 || lifetime > maxTokenLifetime {
+		errorResponse[any](w, &apiError{errorBadData, errTokenLifetime}, s.logger, nil)
+
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !slices.Contains(tokenScopes, scope) {
+			errorResponse[any](w, &apiError{errorBadData, fmt.Errorf("%w: %s", errInvalidScope, scope)}, s.logger, nil)
+
+			return
+		}
+
+		if scope == adminScope && r.Header.Get(adminUserHeader) == "" {
+			errorResponse[any](w, &apiError{errorForbidden, errNoPrivs}, s.logger, nil)
+
+			return
+		}
+	}
+
+	token, err := s.tokens.create(r.Context(), loggedUser, req.Name, req.Scopes, time.Duration(req.ExpiresIn))
+	if err != nil {
+		s.logger.Error("Failed to create token", "user", loggedUser, "err", err)
+		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+
+		return
+	}
+
+	s.logger.Info("API token created", "user", loggedUser, "id", token.TokenID, "expires_at", token.ExpiresAt)
+
+	s.tokensResponse(w, http.StatusCreated, []models.APIToken{token})
+}
+
+// tokens         godoc
+//
+//	@Summary		Show API tokens
+//	@Description	This endpoint will show the API tokens of the current user. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	Tokens themselves are never returned. Expired and revoked tokens are
+//	@Description	included in the response.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		tokens
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string	true	"Current user name"
+//	@Success	200				{object}	Response[models.APIToken]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/tokens [get]
+//
+// GET /tokens
+// Get tokens of current user.
+func (s *CEEMSServer) listTokens(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "tokens endpoint", s.logger)
+
+	loggedUser, _ := s.getUser(r)
+
+	// Make query and write response
+	s.tokensQuerier([]string{loggedUser}, w, r)
+}
+
+// tokensAdmin         godoc
+//
+//	@Summary		Admin endpoint to fetch API tokens
+//	@Description	This admin endpoint will show the API tokens of users. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	If query parameter `user` is provided, only tokens of these users will
+//	@Description	be returned. If not, tokens of all users will be returned.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		tokens
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string		true	"Current user name"
+//	@Param		user			query		[]string	false	"Username"	collectionFormat(multi)
+//	@Success	200				{object}	Response[models.APIToken]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	403				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/tokens/admin [get]
+//
+// GET /tokens/admin
+// Get tokens of any user.
+func (s *CEEMSServer) tokensAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "tokens admin endpoint", s.logger)
+
+	// Make query and write response
+	s.tokensQuerier(r.URL.Query()["user"], w, r)
+}
+
+// revokeToken         godoc
+//
+//	@Summary		Revoke API token
+//	@Description	This endpoint revokes an API token of the current user. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		tokens
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string	true	"Current user name"
+//	@Param		id				path		string	true	"Token ID"
+//	@Success	200				{object}	Response[any]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	404				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/tokens/{id} [delete]
+//
+// DELETE /tokens/{id}
+// Revoke token of current user.
+func (s *CEEMSServer) revokeToken(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "revoke token endpoint", s.logger)
+
+	loggedUser, _ := s.getUser(r)
+
+	s.tokenRevoker(loggedUser, w, r)
+}
+
+// revokeTokenAdmin         godoc
+//
+//	@Summary		Admin endpoint to revoke API tokens
+//	@Description	This admin endpoint revokes an API token of any user. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		tokens
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string	true	"Current user name"
+//	@Param		id				path		string	true	"Token ID"
+//	@Success	200				{object}	Response[any]
+//	@Failure	401				{object}	Response[any]
+//	@Failure	403				{object}	Response[any]
+//	@Failure	404				{object}	Response[any]
+//	@Failure	500				{object}	Response[any]
+//	@Router		/tokens/{id}/admin [delete]
+//
+// DELETE /tokens/{id}/admin
+// Revoke token of any user.
+func (s *CEEMSServer) revokeTokenAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "revoke token admin endpoint", s.logger)
+
+	s.tokenRevoker("", w, r)
+}
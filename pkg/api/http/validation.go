@@ -99,7 +99,7 @@ func VerifyOwnership(
 	q.query("SELECT uuid,cluster_id FROM " + base.UnitsDBTableName)
 
 	// Add project sub query
-	q.query(" WHERE project IN ")
+	q.query(" WHERE (cluster_id, project) IN ")
 	q.subQuery(qSub)
 
 	// Add cluster IDs conditional clause
@@ -129,11 +129,18 @@ func VerifyOwnership(
 		return false
 	}
 
+	// UUIDs can be reused by resource managers and hence, same UUID can be
+	// returned more than once when user has access to several projects
+	foundUUIDs := make(map[string]struct{}, len(units))
+	for _, unit := range units {
+		foundUUIDs[unit.UUID] = struct{}{}
+	}
+
 	// If returned number of UUIDs is not same as queried UUIDs, user is attempting
 	// to query for jobs of other user
-	if len(units) != len(uuids) {
+	if len(foundUUIDs) != len(uuids) {
 		logger.Debug("Unauthorized query", "user", user,
-			"queried_uuids", len(uuids), "found_uuids", len(units),
+			"queried_uuids", len(uuids), "found_uuids", len(foundUUIDs),
 		)
 
 		return false
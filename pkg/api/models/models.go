@@ -6,12 +6,18 @@ import (
 )
 
 const (
-	unitsTableName      = "units"
-	usageTableName      = "usage"
-	dailyUsageTableName = "daily_usage"
-	projectsTableName   = "projects"
-	usersTableName      = "users"
-	adminUsersTableName = "admin_users"
+	unitsTableName        = "units"
+	usageTableName        = "usage"
+	dailyUsageTableName   = "daily_usage"
+	monthlyUsageTableName = "monthly_usage"
+	projectsTableName     = "projects"
+	usersTableName        = "users"
+	adminUsersTableName   = "admin_users"
+	budgetsTableName      = "budgets"
+	apiTokensTableName    = "api_tokens"
+	auditLogTableName     = "audit_log"
+	unitEventsTableName   = "unit_events"
+	reportsTableName      = "report_schedules"
 )
 
 // Unit is an abstract compute unit that can mean Job (batchjobs), VM (cloud) or Pod (k8s).
@@ -46,7 +52,10 @@ type Unit struct {
 	TotalIOReadStats    MetricMap  `json:"total_io_read_stats,omitempty"        sql:"total_io_read_stats"        sqlitetype:"text"`    // Total IO read statistics GB during lifetime of unit
 	TotalIngressStats   MetricMap  `json:"total_ingress_stats,omitempty"        sql:"total_ingress_stats"        sqlitetype:"text"`    // Total Ingress statistics of unit
 	TotalOutgressStats  MetricMap  `json:"total_outgress_stats,omitempty"       sql:"total_outgress_stats"       sqlitetype:"text"`    // Total Outgress statistics of unit
+	TotalCost           MetricMap  `json:"total_cost,omitempty"                 sql:"total_cost"                 sqlitetype:"text"`    // Total cost of unit split by resource type. It is estimated using price tables in billing config
+	Efficiency          MetricMap  `json:"efficiency,omitempty"                 sql:"efficiency"                 sqlitetype:"text"`    // Efficiency scores of unit like CPU efficiency, GPU idle fraction and wasted energy in kWh
 	Tags                Tag        `json:"tags,omitempty"                       sql:"tags"                       sqlitetype:"text"`    // A map to store generic info. String and int64 are valid value types of map
+	ParentUUID          string     `json:"parent_uuid,omitempty"                sql:"parent_uuid"                sqlitetype:"text"`    // UUID of parent unit. It is set only for sub units like job steps and components of heterogeneous jobs
 	Ignore              int        `json:"-"                                    sql:"ignore"                     sqlitetype:"integer"` // Whether to ignore unit
 	NumUpdates          int64      `json:"-"                                    sql:"num_updates"                sqlitetype:"integer"` // Number of updates. This is used internally to update aggregate metrics
 	LastUpdatedAt       string     `json:"-"                                    sql:"last_updated_at"            sqlitetype:"text"`    // Last updated time. It can be used to clean up DB
@@ -91,6 +100,8 @@ type Usage struct {
 	TotalIOReadStats    MetricMap `json:"total_io_read_stats,omitempty"        sql:"total_io_read_stats"        sqlitetype:"text"`    // Total IO read statistics GB during lifetime of unit
 	TotalIngressStats   MetricMap `json:"total_ingress_stats,omitempty"        sql:"total_ingress_stats"        sqlitetype:"text"`    // Total Ingress statistics of unit
 	TotalOutgressStats  MetricMap `json:"total_outgress_stats,omitempty"       sql:"total_outgress_stats"       sqlitetype:"text"`    // Total Outgress statistics of unit
+	TotalCost           MetricMap `json:"total_cost,omitempty"                 sql:"total_cost"                 sqlitetype:"text"`    // Total cost of project split by resource type
+	Dimensions          Tag       `json:"dimensions,omitempty"                 sql:"dimensions"                 sqlitetype:"text"`    // Values of allocation and tag attributes, eg, `allocation.partition`, that usage is grouped by
 	NumUpdates          int64     `json:"-"                                    sql:"num_updates"                sqlitetype:"text"`    // Number of updates. This is used internally to update aggregate metrics
 }
 
@@ -120,6 +131,16 @@ func (DailyUsage) TableName() string {
 	return dailyUsageTableName
 }
 
+// MonthlyUsage statistics of each project/tenant/namespace aggregated from expired units.
+type MonthlyUsage struct {
+	Usage
+}
+
+// TableName returns the table which usage stats are stored into.
+func (MonthlyUsage) TableName() string {
+	return monthlyUsageTableName
+}
+
 // Stat represents high level statistics of each cluster.
 type Stat struct {
 	ClusterID        string `json:"cluster_id"         sql:"cluster_id"         sqlitetype:"text"`    // Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.
@@ -142,16 +163,98 @@ func (s Stat) TagMap(keyTag string, valueTag string) map[string]string {
 	return structset.StructFieldTagMap(s, keyTag, valueTag)
 }
 
+// UsageTimeSeries represents the usage statistics aggregated in a time bucket.
+type UsageTimeSeries struct {
+	Timestamp           int64     `json:"timestamp"                            sql:"timestamp"                  sqlitetype:"integer"` // Start of time bucket in epoch milliseconds
+	ClusterID           string    `json:"cluster_id,omitempty"                 sql:"cluster_id"                 sqlitetype:"text"`    // Identifier of the resource manager. It is set only when usage is grouped by cluster_id
+	Project             string    `json:"project,omitempty"                    sql:"project"                    sqlitetype:"text"`    // Account in batch systems, Tenant in Openstack, Namespace in k8s. It is set only when usage is grouped by project
+	User                string    `json:"username,omitempty"                   sql:"username"                   sqlitetype:"text"`    // Username. It is set only when usage is grouped by user
+	NumUnits            int64     `json:"num_units,omitempty"                  sql:"num_units"                  sqlitetype:"integer"` // Number of consumed units in the bucket
+	TotalTime           MetricMap `json:"total_time_seconds,omitempty"         sql:"total_time_seconds"         sqlitetype:"text"`    // Different times in seconds consumed in the bucket
+	AveCPUUsage         MetricMap `json:"avg_cpu_usage,omitempty"              sql:"avg_cpu_usage"              sqlitetype:"text"`    // Average CPU usage(s) in the bucket
+	AveCPUMemUsage      MetricMap `json:"avg_cpu_mem_usage,omitempty"          sql:"avg_cpu_mem_usage"          sqlitetype:"text"`    // Average CPU memory usage(s) in the bucket
+	TotalCPUEnergyUsage MetricMap `json:"total_cpu_energy_usage_kwh,omitempty" sql:"total_cpu_energy_usage_kwh" sqlitetype:"text"`    // Total CPU energy usage(s) in kWh in the bucket
+	TotalCPUEmissions   MetricMap `json:"total_cpu_emissions_gms,omitempty"    sql:"total_cpu_emissions_gms"    sqlitetype:"text"`    // Total CPU emissions from source(s) in grams in the bucket
+	AveGPUUsage         MetricMap `json:"avg_gpu_usage,omitempty"              sql:"avg_gpu_usage"              sqlitetype:"text"`    // Average GPU usage(s) in the bucket
+	AveGPUMemUsage      MetricMap `json:"avg_gpu_mem_usage,omitempty"          sql:"avg_gpu_mem_usage"          sqlitetype:"text"`    // Average GPU memory usage(s) in the bucket
+	TotalGPUEnergyUsage MetricMap `json:"total_gpu_energy_usage_kwh,omitempty" sql:"total_gpu_energy_usage_kwh" sqlitetype:"text"`    // Total GPU energy usage(s) in kWh in the bucket
+	TotalGPUEmissions   MetricMap `json:"total_gpu_emissions_gms,omitempty"    sql:"total_gpu_emissions_gms"    sqlitetype:"text"`    // Total GPU emissions from source(s) in grams in the bucket
+	TotalIOWriteStats   MetricMap `json:"total_io_write_stats,omitempty"       sql:"total_io_write_stats"       sqlitetype:"text"`    // Total IO write statistics in the bucket
+	TotalIOReadStats    MetricMap `json:"total_io_read_stats,omitempty"        sql:"total_io_read_stats"        sqlitetype:"text"`    // Total IO read statistics in the bucket
+	TotalIngressStats   MetricMap `json:"total_ingress_stats,omitempty"        sql:"total_ingress_stats"        sqlitetype:"text"`    // Total Ingress statistics in the bucket
+	TotalOutgressStats  MetricMap `json:"total_outgress_stats,omitempty"       sql:"total_outgress_stats"       sqlitetype:"text"`    // Total Outgress statistics in the bucket
+	TotalCost           MetricMap `json:"total_cost,omitempty"                 sql:"total_cost"                 sqlitetype:"text"`    // Total cost in the bucket split by resource type
+	Bucket              string    `json:"-"                                    sql:"bucket"                     sqlitetype:"text"`    // Date of start of time bucket in the time zone of the server
+}
+
+// TagNames returns a slice of all tag names.
+func (u UsageTimeSeries) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(u, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (u UsageTimeSeries) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(u, keyTag, valueTag)
+}
+
+// Invoice represents the cost of a project or user in a given billing period.
+type Invoice struct {
+	ClusterID       string    `json:"cluster_id"                   sql:"cluster_id"         sqlitetype:"text"`    // Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.
+	ResourceManager string    `json:"resource_manager"             sql:"resource_manager"   sqlitetype:"text"`    // Name of the resource manager that owns project. Eg slurm, openstack, kubernetes, etc
+	Period          string    `json:"period"                       sql:"period"             sqlitetype:"text"`    // Billing period. Eg 2024-10 for monthly invoices
+	Project         string    `json:"project"                      sql:"project"            sqlitetype:"text"`    // Account in batch systems, Tenant in Openstack, Namespace in k8s
+	User            string    `json:"username,omitempty"           sql:"username"           sqlitetype:"text"`    // Username. It is set only for invoices of users
+	NumUnits        int64     `json:"num_units"                    sql:"num_units"          sqlitetype:"integer"` // Number of units billed in the period
+	TotalTime       MetricMap `json:"total_time_seconds,omitempty" sql:"total_time_seconds" sqlitetype:"text"`    // Different times in seconds consumed in the period
+	TotalCost       MetricMap `json:"total_cost,omitempty"         sql:"total_cost"         sqlitetype:"text"`    // Total cost in the period split by resource type
+	Currency        string    `json:"currency,omitempty"           sql:"currency"           sqlitetype:"text"`    // Currency of the cost
+}
+
+// TagNames returns a slice of all tag names.
+func (i Invoice) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(i, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (i Invoice) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(i, keyTag, valueTag)
+}
+
+// Efficiency represents the aggregated efficiency scores of units of a project or user.
+type Efficiency struct {
+	ClusterID       string    `json:"cluster_id"                   sql:"cluster_id"         sqlitetype:"text"`    // Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.
+	ResourceManager string    `json:"resource_manager"             sql:"resource_manager"   sqlitetype:"text"`    // Name of the resource manager that owns project. Eg slurm, openstack, kubernetes, etc
+	Project         string    `json:"project"                      sql:"project"            sqlitetype:"text"`    // Account in batch systems, Tenant in Openstack, Namespace in k8s
+	User            string    `json:"username,omitempty"           sql:"username"           sqlitetype:"text"`    // Username. It is set only when scores are grouped by users
+	NumUnits        int64     `json:"num_units"                    sql:"num_units"          sqlitetype:"integer"` // Number of scored units
+	TotalTime       MetricMap `json:"total_time_seconds,omitempty" sql:"total_time_seconds" sqlitetype:"text"`    // Different times in seconds consumed by scored units
+	Efficiency      MetricMap `json:"efficiency,omitempty"         sql:"efficiency"         sqlitetype:"text"`    // Efficiency scores averaged over units and total wasted energy in kWh
+}
+
+// TagNames returns a slice of all tag names.
+func (e Efficiency) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(e, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (e Efficiency) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(e, keyTag, valueTag)
+}
+
 // Project is the container for a given account/tenant/namespace of cluster.
 type Project struct {
-	ID              int64  `json:"-"                sql:"id"               sqlitetype:"integer not null primary key"`
-	UID             string `json:"uid,omitempty"    sql:"uid"              sqlitetype:"text"` // Unique identifier of the project provided by cluster
-	ClusterID       string `json:"cluster_id"       sql:"cluster_id"       sqlitetype:"text"` // Identifier of the resource manager that owns project. It is used to differentiate multiple clusters of same resource manager.
-	ResourceManager string `json:"resource_manager" sql:"resource_manager" sqlitetype:"text"` // Name of the resource manager that owns project. Eg slurm, openstack, kubernetes, etc
-	Name            string `json:"name"             sql:"name"             sqlitetype:"text"` // Name of the project
-	Users           List   `json:"users"            sql:"users"            sqlitetype:"text"` // List of users of the project
-	Tags            List   `json:"tags,omitempty"   sql:"tags"             sqlitetype:"text"` // List of meta data tags of the project
-	LastUpdatedAt   string `json:"-"                sql:"last_updated_at"  sqlitetype:"text"` // Last Updated time
+	ID              int64  `json:"-"                      sql:"id"               sqlitetype:"integer not null primary key"`
+	UID             string `json:"uid,omitempty"          sql:"uid"              sqlitetype:"text"` // Unique identifier of the project provided by cluster
+	ClusterID       string `json:"cluster_id"             sql:"cluster_id"       sqlitetype:"text"` // Identifier of the resource manager that owns project. It is used to differentiate multiple clusters of same resource manager.
+	ResourceManager string `json:"resource_manager"       sql:"resource_manager" sqlitetype:"text"` // Name of the resource manager that owns project. Eg slurm, openstack, kubernetes, etc
+	Name            string `json:"name"                   sql:"name"             sqlitetype:"text"` // Name of the project
+	Users           List   `json:"users"                  sql:"users"            sqlitetype:"text"` // List of users of the project
+	Coordinators    List   `json:"coordinators,omitempty" sql:"coordinators"     sqlitetype:"text"` // List of coordinators of the project
+	Tags            List   `json:"tags,omitempty"         sql:"tags"             sqlitetype:"text"` // List of meta data tags of the project
+	LastUpdatedAt   string `json:"-"                      sql:"last_updated_at"  sqlitetype:"text"` // Last Updated time
 }
 
 // TableName returns the table which admin users list is stored into.
@@ -222,6 +325,167 @@ func (a AdminUsers) TagMap(keyTag string, valueTag string) map[string]string {
 	return structset.StructFieldTagMap(a, keyTag, valueTag)
 }
 
+// Budget represents the consumption of the budget of a project in the current period.
+type Budget struct {
+	ID                int64     `json:"-"                      sql:"id"                 sqlitetype:"integer not null primary key"`
+	ClusterID         string    `json:"cluster_id"             sql:"cluster_id"         sqlitetype:"text"` // Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.
+	Project           string    `json:"project"                sql:"project"            sqlitetype:"text"` // Account in batch systems, Tenant in Openstack, Namespace in k8s
+	Period            string    `json:"period"                 sql:"period"             sqlitetype:"text"` // Budget period. One of month, year or global
+	PeriodStart       string    `json:"period_start,omitempty" sql:"period_start"       sqlitetype:"text"` // Start of the current budget period. Empty for global budgets
+	Limits            MetricMap `json:"limits"                 sql:"limits"             sqlitetype:"text"` // Limits of the budget for each resource
+	Consumed          MetricMap `json:"consumed"               sql:"consumed"           sqlitetype:"text"` // Consumption of each resource in the current period
+	UsedPercent       MetricMap `json:"used_percent"           sql:"used_percent"       sqlitetype:"text"` // Percentage of the budget consumed for each resource
+	NotifiedThreshold float64   `json:"notified_threshold"     sql:"notified_threshold" sqlitetype:"real"` // Highest threshold that has been notified in the current period
+	LastUpdatedAt     string    `json:"last_updated_at"        sql:"last_updated_at"    sqlitetype:"text"` // Last Updated time
+}
+
+// TableName returns the table which budgets are stored into.
+func (Budget) TableName() string {
+	return budgetsTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (b Budget) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(b, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (b Budget) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(b, keyTag, valueTag)
+}
+
+// APIToken represents a personal API token of a user. Only the hash of the token
+// is stored in DB and the token itself is returned only once at creation.
+type APIToken struct {
+	ID          int64  `json:"-"                      sql:"id"            sqlitetype:"integer not null primary key"`
+	TokenID     string `json:"id"                     sql:"token_id"      sqlitetype:"text"`    // Public identifier of token used to manage it
+	User        string `json:"username"               sql:"username"      sqlitetype:"text"`    // Owner of the token
+	Name        string `json:"name"                   sql:"name"          sqlitetype:"text"`    // Name of the token given by user
+	Prefix      string `json:"prefix"                 sql:"prefix"        sqlitetype:"text"`    // First characters of token to help users to identify it
+	Hash        string `json:"-"                      sql:"token_hash"    sqlitetype:"text"`    // SHA256 hash of token
+	Scopes      List   `json:"scopes"                 sql:"scopes"        sqlitetype:"text"`    // Resources that can be accessed with token
+	CreatedAt   string `json:"created_at"             sql:"created_at"    sqlitetype:"text"`    // Creation time
+	ExpiresAt   string `json:"expires_at"             sql:"expires_at"    sqlitetype:"text"`    // Expiry time
+	ExpiresAtTS int64  `json:"-"                      sql:"expires_at_ts" sqlitetype:"integer"` // Expiry time in epoch milliseconds
+	LastUsedAt  string `json:"last_used_at,omitempty" sql:"last_used_at"  sqlitetype:"text"`    // Time at which token was last used
+	RevokedAt   string `json:"revoked_at,omitempty"   sql:"revoked_at"    sqlitetype:"text"`    // Revocation time
+	RevokedBy   string `json:"revoked_by,omitempty"   sql:"revoked_by"    sqlitetype:"text"`    // User who revoked the token
+	Token       string `json:"token,omitempty"        sql:"-"`                                  // Token. Only set in the response of token creation
+}
+
+// TableName returns the table which API tokens are stored into.
+func (APIToken) TableName() string {
+	return apiTokensTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (t APIToken) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(t, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (t APIToken) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(t, keyTag, valueTag)
+}
+
+// AuditRecord represents an access decision made by CEEMS API server or load balancer.
+type AuditRecord struct {
+	ID           int64   `json:"-"                       sql:"id"            sqlitetype:"integer not null primary key"`
+	Timestamp    int64   `json:"timestamp"               sql:"timestamp"     sqlitetype:"integer"` // Time of request in epoch milliseconds
+	Source       string  `json:"source"                  sql:"source"        sqlitetype:"text"`    // CEEMS component that served the request
+	User         string  `json:"user"                    sql:"username"      sqlitetype:"text"`    // User making the request
+	QueriedUsers List    `json:"queried_users,omitempty" sql:"queried_users" sqlitetype:"text"`    // Users whose data are queried by admin users
+	Method       string  `json:"method"                  sql:"method"        sqlitetype:"text"`    // HTTP method of request
+	Endpoint     string  `json:"endpoint"                sql:"endpoint"      sqlitetype:"text"`    // Path of request
+	ClusterIDs   List    `json:"cluster_ids,omitempty"   sql:"cluster_ids"   sqlitetype:"text"`    // Queried cluster IDs
+	UUIDs        List    `json:"uuids,omitempty"         sql:"uuids"         sqlitetype:"text"`    // Queried unit UUIDs
+	Decision     string  `json:"decision"                sql:"decision"      sqlitetype:"text"`    // Access decision: allow, deny or error
+	StatusCode   int     `json:"status_code"             sql:"status_code"   sqlitetype:"integer"` // HTTP status code of response
+	Latency      float64 `json:"latency_ms"              sql:"latency_ms"    sqlitetype:"real"`    // Time taken to serve the request in milliseconds
+	RemoteAddr   string  `json:"remote_addr,omitempty"   sql:"remote_addr"   sqlitetype:"text"`    // Address of the client
+}
+
+// TableName returns the table which audit records are stored into.
+func (AuditRecord) TableName() string {
+	return auditLogTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (a AuditRecord) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(a, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (a AuditRecord) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(a, keyTag, valueTag)
+}
+
+// Unit lifecycle event types.
+const (
+	UnitEventCreated        = "created"
+	UnitEventStateChanged   = "state_changed"
+	UnitEventEnded          = "ended"
+	UnitEventMetricsUpdated = "metrics_updated"
+)
+
+// UnitEvent represents a change in the lifecycle of a compute unit observed during a DB update.
+type UnitEvent struct {
+	ID              int64  `json:"id"                       sql:"id"               sqlitetype:"integer not null primary key autoincrement"` // Monotonically increasing ID of event
+	Type            string `json:"type"                     sql:"type"             sqlitetype:"text"`                                       // Type of event: created, state_changed, ended or metrics_updated
+	ClusterID       string `json:"cluster_id"               sql:"cluster_id"       sqlitetype:"text"`                                       // Identifier of the resource manager that owns compute unit
+	ResourceManager string `json:"resource_manager"         sql:"resource_manager" sqlitetype:"text"`                                       // Name of the resource manager that owns compute unit
+	UUID            string `json:"uuid"                     sql:"uuid"             sqlitetype:"text"`                                       // Unique identifier of unit
+	Name            string `json:"name,omitempty"           sql:"name"             sqlitetype:"text"`                                       // Name of compute unit
+	Project         string `json:"project,omitempty"        sql:"project"          sqlitetype:"text"`                                       // Project of compute unit
+	User            string `json:"username,omitempty"       sql:"username"         sqlitetype:"text"`                                       // Owner of compute unit
+	State           string `json:"state,omitempty"          sql:"state"            sqlitetype:"text"`                                       // Current state of unit
+	PreviousState   string `json:"previous_state,omitempty" sql:"previous_state"   sqlitetype:"text"`                                       // State of unit in previous DB update
+	Timestamp       int64  `json:"timestamp"                sql:"timestamp"        sqlitetype:"integer"`                                    // Time of DB update in epoch milliseconds
+}
+
+// TableName returns the table which unit events are stored into.
+func (UnitEvent) TableName() string {
+	return unitEventsTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (e UnitEvent) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(e, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (e UnitEvent) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(e, keyTag, valueTag)
+}
+
+// ReportSchedule represents the last report period for which digests have been sent.
+type ReportSchedule struct {
+	ID            int64  `json:"-"               sql:"id"              sqlitetype:"integer not null primary key"`
+	Period        string `json:"period"          sql:"period"          sqlitetype:"text"` // Report period. One of weekly or monthly
+	PeriodStart   string `json:"period_start"    sql:"period_start"    sqlitetype:"text"` // Start of the last reported period
+	LastUpdatedAt string `json:"last_updated_at" sql:"last_updated_at" sqlitetype:"text"` // Time at which digests have been sent
+}
+
+// TableName returns the table which report schedules are stored into.
+func (ReportSchedule) TableName() string {
+	return reportsTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (r ReportSchedule) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(r, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (r ReportSchedule) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(r, keyTag, valueTag)
+}
+
 // Key represents arbritrary keys used in metric maps.
 type Key struct {
 	Name string `json:"name" sql:"name" sqlitetype:"text"` // Name of the metric key
@@ -238,6 +502,14 @@ func (k Key) TagMap(keyTag string, valueTag string) map[string]string {
 	return structset.StructFieldTagMap(k, keyTag, valueTag)
 }
 
+// IngestStats represents the number of compute units, users and projects
+// ingested in a push request.
+type IngestStats struct {
+	Units    int `json:"units"`    // Number of compute units
+	Users    int `json:"users"`    // Number of users
+	Projects int `json:"projects"` // Number of projects
+}
+
 // // Ownership mode for a given compute unit
 // type Ownership struct {
 // 	UUID string `json:"uuid"` // UUID of the compute unit
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Default subject of digest emails.
+const defaultSubject = "CEEMS {{ .Period }} usage report of {{ .Kind }} {{ .Name }} ({{ .Label }})"
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+// Functions available in templates.
+var templateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string {
+		return t.Format(time.DateOnly)
+	},
+	"float": func(v int64) float64 {
+		return float64(v)
+	},
+	// change returns the relative change of current w.r.t previous value
+	"change": func(current, previous float64) string {
+		if previous == 0 {
+			if current == 0 {
+				return "0.0%"
+			}
+
+			return "n/a"
+		}
+
+		return fmt.Sprintf("%+.1f%%", 100*(current-previous)/previous)
+	},
+	// score returns the efficiency score of key as percentage
+	"score": func(m models.MetricMap, key string) string {
+		v, ok := m[key]
+		if !ok || math.IsNaN(float64(v)) {
+			return "n/a"
+		}
+
+		return fmt.Sprintf("%.1f%%", 100*v)
+	},
+}
+
+// templates contains the parsed templates of digests.
+type templates struct {
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+// newTemplates parses the templates of config falling back to embedded
+// templates when files are not configured.
+func newTemplates(c TemplatesConfig) (*templates, error) {
+	subject := c.Subject
+	if subject == "" {
+		subject = defaultSubject
+	}
+
+	textContent, err := readTemplate(c.Text, "templates/digest.txt.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	htmlContent, err := readTemplate(c.HTML, "templates/digest.html.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &templates{}
+
+	if t.subject, err = template.New("subject").Funcs(templateFuncs).Parse(subject); err != nil {
+		return nil, fmt.Errorf("failed to parse subject template: %w", err)
+	}
+
+	if t.text, err = template.New("text").Funcs(templateFuncs).Parse(textContent); err != nil {
+		return nil, fmt.Errorf("failed to parse text template: %w", err)
+	}
+
+	if t.html, err = htmltemplate.New("html").Funcs(templateFuncs).Parse(htmlContent); err != nil {
+		return nil, fmt.Errorf("failed to parse HTML template: %w", err)
+	}
+
+	return t, nil
+}
+
+// readTemplate returns the content of template file or the embedded template
+// when file is empty.
+func readTemplate(file, embedded string) (string, error) {
+	var content []byte
+
+	var err error
+
+	if file == "" {
+		content, err = templatesFS.ReadFile(embedded)
+	} else {
+		content, err = os.ReadFile(file)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+
+	return string(content), nil
+}
+
+// rendered is a digest rendered with templates.
+type rendered struct {
+	subject string
+	text    string
+	html    string
+}
+
+// render renders the digest using templates.
+func (t *templates) render(d Digest) (rendered, error) {
+	var subject, text, html bytes.Buffer
+
+	if err := t.subject.Execute(&subject, d); err != nil {
+		return rendered{}, fmt.Errorf("failed to render subject of digest: %w", err)
+	}
+
+	if err := t.text.Execute(&text, d); err != nil {
+		return rendered{}, fmt.Errorf("failed to render text digest: %w", err)
+	}
+
+	if err := t.html.Execute(&html, d); err != nil {
+		return rendered{}, fmt.Errorf("failed to render HTML digest: %w", err)
+	}
+
+	return rendered{subject: subject.String(), text: text.String(), html: html.String()}, nil
+}
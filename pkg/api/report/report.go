@@ -0,0 +1,289 @@
+// Package report implements periodic usage digests of users and projects
+// that are delivered by email, webhooks and/or files in a directory.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Custom errors.
+var (
+	ErrInvalidPeriod   = errors.New("invalid report period. Allowed periods are weekly and monthly")
+	ErrNoDelivery      = errors.New("at least one of email, webhooks or directory must be configured for reports")
+	ErrInvalidEmail    = errors.New("smarthost and from are required for email delivery of reports")
+	ErrInvalidTopUnits = errors.New("top_units must be non negative")
+)
+
+// Report periods.
+const (
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// Kinds of digests.
+const (
+	UserDigest    = "user"
+	ProjectDigest = "project"
+)
+
+// SMTPConfig contains the SMTP configuration for email delivery.
+type SMTPConfig struct {
+	SmartHost    string        `yaml:"smarthost"`
+	From         string        `yaml:"from"`
+	AuthUsername string        `yaml:"auth_username"`
+	AuthPassword config.Secret `yaml:"auth_password"`
+}
+
+// TemplatesConfig contains the templates used to render digests. Embedded
+// templates are used when files are not configured.
+type TemplatesConfig struct {
+	Subject string `yaml:"subject"`
+	Text    string `yaml:"text"`
+	HTML    string `yaml:"html"`
+}
+
+// RecipientsConfig contains the sources of addresses of users and projects.
+type RecipientsConfig struct {
+	File string `yaml:"file"`
+	Tag  string `yaml:"tag"`
+}
+
+// Config is the container for reports related config.
+type Config struct {
+	Periods         []string           `yaml:"periods"`
+	TopUnits        int                `yaml:"top_units"`
+	EnergyMetric    string             `yaml:"energy_metric"`
+	EmissionsMetric string             `yaml:"emissions_metric"`
+	Templates       TemplatesConfig    `yaml:"templates"`
+	Recipients      RecipientsConfig   `yaml:"recipients"`
+	Email           *SMTPConfig        `yaml:"email"`
+	Webhooks        []models.WebConfig `yaml:"webhooks"`
+	Directory       string             `yaml:"directory"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = Config{
+		TopUnits:        5,
+		EnergyMetric:    "total",
+		EmissionsMetric: "owid_total",
+		Templates: TemplatesConfig{
+			Subject: defaultSubject,
+		},
+		Recipients: RecipientsConfig{
+			Tag: "email",
+		},
+	}
+
+	type plain Config
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	c.Periods = slices.Compact(c.Periods)
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	// Reports are disabled
+	if len(c.Periods) == 0 {
+		return nil
+	}
+
+	for _, period := range c.Periods {
+		if period != Weekly && period != Monthly {
+			return fmt.Errorf("%w: %s", ErrInvalidPeriod, period)
+		}
+	}
+
+	if c.TopUnits < 0 {
+		return ErrInvalidTopUnits
+	}
+
+	if c.Email == nil && len(c.Webhooks) == 0 && c.Directory == "" {
+		return ErrNoDelivery
+	}
+
+	if c.Email != nil && (c.Email.SmartHost == "" || c.Email.From == "") {
+		return ErrInvalidEmail
+	}
+
+	for _, webhook := range c.Webhooks {
+		if err := webhook.HTTPClientConfig.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Ensure templates can be parsed
+	if _, err := newTemplates(c.Templates); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetDirectory joins any relative file paths with dir.
+func (c *Config) SetDirectory(dir string) {
+	c.Templates.Text = config.JoinDir(dir, c.Templates.Text)
+	c.Templates.HTML = config.JoinDir(dir, c.Templates.HTML)
+	c.Recipients.File = config.JoinDir(dir, c.Recipients.File)
+	c.Directory = config.JoinDir(dir, c.Directory)
+
+	for i := range c.Webhooks {
+		c.Webhooks[i].SetDirectory(dir)
+	}
+}
+
+// PeriodStart returns the start of the report period that contains t. Weeks
+// start on Monday.
+func PeriodStart(period string, t time.Time) time.Time {
+	switch period {
+	case Weekly:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case Monthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Time{}
+	}
+}
+
+// PreviousPeriodStart returns the start of the report period that precedes
+// the one starting at start.
+func PreviousPeriodStart(period string, start time.Time) time.Time {
+	switch period {
+	case Weekly:
+		return start.AddDate(0, 0, -7)
+	case Monthly:
+		return start.AddDate(0, -1, 0)
+	default:
+		return time.Time{}
+	}
+}
+
+// PeriodLabel returns a human readable label of the report period starting at
+// start. ISO week numbers are used for weekly periods.
+func PeriodLabel(period string, start time.Time) string {
+	switch period {
+	case Weekly:
+		year, week := start.ISOWeek()
+
+		return fmt.Sprintf("%d-W%02d", year, week)
+	default:
+		return start.Format("2006-01")
+	}
+}
+
+// Summary contains the aggregate usage of a user or project in a period.
+type Summary struct {
+	NumUnits     int64            `json:"num_units"`
+	CPUHours     float64          `json:"cpu_hours"`
+	GPUHours     float64          `json:"gpu_hours"`
+	EnergyKWh    float64          `json:"energy_kwh"`
+	EmissionsGms float64          `json:"emissions_gms"`
+	Efficiency   models.MetricMap `json:"efficiency,omitempty"`
+}
+
+// UnitSummary contains the usage of a single unit reported in digests.
+type UnitSummary struct {
+	ClusterID  string           `json:"cluster_id"`
+	UUID       string           `json:"uuid"`
+	Name       string           `json:"name"`
+	Project    string           `json:"project"`
+	User       string           `json:"username"`
+	CPUHours   float64          `json:"cpu_hours"`
+	GPUHours   float64          `json:"gpu_hours"`
+	EnergyKWh  float64          `json:"energy_kwh"`
+	Efficiency models.MetricMap `json:"efficiency,omitempty"`
+}
+
+// Digest is the usage report of a user or project in a period compared
+// with the previous period.
+type Digest struct {
+	Kind       string        `json:"kind"`
+	Name       string        `json:"name"`
+	ClusterID  string        `json:"cluster_id"`
+	Period     string        `json:"period"`
+	Label      string        `json:"label"`
+	From       time.Time     `json:"from"`
+	To         time.Time     `json:"to"`
+	Current    Summary       `json:"current"`
+	Previous   Summary       `json:"previous"`
+	TopUnits   []UnitSummary `json:"top_units"`
+	Recipients []string      `json:"-"`
+}
+
+// Recipients resolves the addresses of users and projects from the mapping
+// file and user tags.
+type Recipients struct {
+	Users    map[string]string   `yaml:"users"`
+	Projects map[string][]string `yaml:"projects"`
+	tag      string
+}
+
+// loadRecipients reads the mapping file of recipients when it is configured.
+func loadRecipients(c RecipientsConfig) (*Recipients, error) {
+	recipients := &Recipients{tag: c.Tag}
+
+	if c.File == "" {
+		return recipients, nil
+	}
+
+	content, err := os.ReadFile(c.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipients file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, recipients); err != nil {
+		return nil, fmt.Errorf("failed to parse recipients file: %w", err)
+	}
+
+	return recipients, nil
+}
+
+// User returns the address of user. Mapping file takes precedence over tags.
+// A tag can either be a string of format <tag>=<address> or a map with tag
+// as key.
+func (r *Recipients) User(name string, tags models.List) string {
+	if addr := r.Users[name]; addr != "" {
+		return addr
+	}
+
+	if r.tag == "" {
+		return ""
+	}
+
+	for _, tag := range tags {
+		switch t := tag.(type) {
+		case string:
+			if key, value, ok := strings.Cut(t, "="); ok && key == r.tag {
+				return value
+			}
+		case map[string]interface{}:
+			if value, ok := t[r.tag].(string); ok {
+				return value
+			}
+		}
+	}
+
+	return ""
+}
+
+// Project returns the addresses of project found in mapping file.
+func (r *Recipients) Project(name string) []string {
+	return r.Projects[name]
+}
@@ -0,0 +1,274 @@
+package report
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var noOpLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func testDigest() Digest {
+	from := time.Date(2026, time.October, 5, 0, 0, 0, 0, time.UTC)
+
+	return Digest{
+		Kind:      UserDigest,
+		Name:      "usr1",
+		ClusterID: "slurm-0",
+		Period:    Weekly,
+		Label:     PeriodLabel(Weekly, from),
+		From:      from,
+		To:        from.AddDate(0, 0, 7),
+		Current: Summary{
+			NumUnits:   4,
+			CPUHours:   15,
+			EnergyKWh:  2,
+			Efficiency: models.MetricMap{"score": 0.5, "cpu": 0.5, "wasted_energy_kwh": 1},
+		},
+		Previous: Summary{
+			NumUnits: 2,
+			CPUHours: 10,
+		},
+		TopUnits: []UnitSummary{
+			{UUID: "1234", Name: "<job>", Project: "acc1", User: "usr1", EnergyKWh: 2},
+		},
+		Recipients: []string{"usr1@example.com"},
+	}
+}
+
+// fakeSMTPServer accepts a single SMTP session and sends the received
+// message on the returned channel.
+func fakeSMTPServer(t *testing.T) (string, <-chan string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { l.Close() })
+
+	msgs := make(chan string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		w := bufio.NewWriter(conn)
+
+		reply := func(s string) {
+			w.WriteString(s + "\r\n")
+			w.Flush()
+		}
+
+		reply("220 localhost ESMTP")
+
+		var data strings.Builder
+
+		inData := false
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					msgs <- data.String()
+
+					reply("250 OK")
+				} else {
+					data.WriteString(line)
+				}
+
+				continue
+			}
+
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "DATA"):
+				inData = true
+
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 Bye")
+
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return l.Addr().String(), msgs
+}
+
+func TestConfig(t *testing.T) {
+	var c Config
+
+	require.NoError(t, yaml.Unmarshal([]byte("periods: [weekly]\ndirectory: reports"), &c))
+	assert.Equal(t, 5, c.TopUnits)
+	assert.Equal(t, "email", c.Recipients.Tag)
+	require.NoError(t, c.Validate())
+
+	c.SetDirectory("/etc/ceems")
+	assert.Equal(t, "/etc/ceems/reports", c.Directory)
+
+	// Disabled reports
+	require.NoError(t, (&Config{}).Validate())
+
+	require.ErrorIs(t, (&Config{Periods: []string{"daily"}, Directory: "/tmp"}).Validate(), ErrInvalidPeriod)
+	require.ErrorIs(t, (&Config{Periods: []string{Monthly}}).Validate(), ErrNoDelivery)
+	require.ErrorIs(t, (&Config{Periods: []string{Monthly}, Email: &SMTPConfig{From: "ceems@example.com"}}).Validate(), ErrInvalidEmail)
+	require.Error(t, (&Config{Periods: []string{Monthly}, Directory: "/tmp", Templates: TemplatesConfig{Subject: "{{ .Name "}}).Validate())
+}
+
+func TestPeriods(t *testing.T) {
+	// Thursday
+	now := time.Date(2026, time.October, 15, 10, 0, 0, 0, time.UTC)
+
+	start := PeriodStart(Weekly, now)
+	assert.Equal(t, time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, time.October, 5, 0, 0, 0, 0, time.UTC), PreviousPeriodStart(Weekly, start))
+	assert.Equal(t, "2026-W42", PeriodLabel(Weekly, start))
+
+	// Monday is start of its own week
+	assert.Equal(t, start, PeriodStart(Weekly, start))
+
+	start = PeriodStart(Monthly, now)
+	assert.Equal(t, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC), PreviousPeriodStart(Monthly, start))
+	assert.Equal(t, "2026-10", PeriodLabel(Monthly, start))
+}
+
+func TestRecipients(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "recipients.yml")
+	require.NoError(t, os.WriteFile(file, []byte("users:\n  usr1: usr1@example.com\nprojects:\n  acc1:\n    - pi@example.com\n"), 0o600))
+
+	r, err := loadRecipients(RecipientsConfig{File: file, Tag: "email"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "usr1@example.com", r.User("usr1", models.List{"email=other@example.com"}))
+	assert.Equal(t, "usr2@example.com", r.User("usr2", models.List{"dept=hpc", "email=usr2@example.com"}))
+	assert.Equal(t, "usr3@example.com", r.User("usr3", models.List{map[string]interface{}{"email": "usr3@example.com"}}))
+	assert.Empty(t, r.User("usr4", nil))
+	assert.Equal(t, []string{"pi@example.com"}, r.Project("acc1"))
+
+	_, err = loadRecipients(RecipientsConfig{File: "non-existent"})
+	require.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := newTemplates(TemplatesConfig{})
+	require.NoError(t, err)
+
+	out, err := tmpl.render(testDigest())
+	require.NoError(t, err)
+
+	assert.Equal(t, "CEEMS weekly usage report of user usr1 (2026-W41)", out.subject)
+	assert.Contains(t, out.text, "+100.0%")
+	assert.Contains(t, out.text, "+50.0%")
+	assert.Contains(t, out.text, "Overall score     50.0%")
+	assert.Contains(t, out.text, "GPU               n/a")
+	assert.Contains(t, out.text, "<job>")
+	assert.Contains(t, out.html, "&lt;job&gt;")
+
+	// Custom templates
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "digest.txt"), []byte("{{ .Name }}: {{ change .Current.CPUHours .Previous.CPUHours }}"), 0o600))
+
+	tmpl, err = newTemplates(TemplatesConfig{Subject: "{{ .Label }}", Text: filepath.Join(dir, "digest.txt")})
+	require.NoError(t, err)
+
+	out, err = tmpl.render(testDigest())
+	require.NoError(t, err)
+	assert.Equal(t, "2026-W41", out.subject)
+	assert.Equal(t, "usr1: +50.0%", out.text)
+}
+
+func TestSendEmail(t *testing.T) {
+	addr, msgs := fakeSMTPServer(t)
+
+	r, err := New(&Config{
+		Periods: []string{Weekly},
+		Email:   &SMTPConfig{SmartHost: addr, From: "ceems@example.com"},
+	}, noOpLogger)
+	require.NoError(t, err)
+
+	noRecipients := testDigest()
+	noRecipients.Recipients = nil
+
+	require.NoError(t, r.Send(context.Background(), []Digest{noRecipients, testDigest()}))
+
+	select {
+	case msg := <-msgs:
+		assert.Contains(t, msg, "To: <usr1@example.com>")
+		assert.Contains(t, msg, "Subject: CEEMS weekly usage report of user usr1 (2026-W41)")
+		assert.Contains(t, msg, "Content-Type: multipart/alternative")
+		assert.Contains(t, msg, "Content-Type: text/plain; charset=UTF-8")
+		assert.Contains(t, msg, "Content-Type: text/html; charset=UTF-8")
+	case <-time.After(5 * time.Second):
+		t.Fatal("email not received")
+	}
+}
+
+func TestSendWebhookAndFiles(t *testing.T) {
+	var payload struct {
+		Text   string `json:"text"`
+		Digest Digest `json:"digest"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	var c Config
+
+	require.NoError(t, yaml.Unmarshal([]byte("periods: [weekly]\nwebhooks:\n  - url: "+server.URL), &c))
+	c.Directory = dir
+
+	r, err := New(&c, noOpLogger)
+	require.NoError(t, err)
+
+	d := testDigest()
+	d.ClusterID = "slurm/0"
+
+	require.NoError(t, r.Send(context.Background(), []Digest{d}))
+
+	assert.Equal(t, "usr1", payload.Digest.Name)
+	assert.Contains(t, payload.Text, "CEEMS weekly usage report of user usr1")
+
+	for _, ext := range []string{".txt", ".html"} {
+		_, err := os.Stat(filepath.Join(dir, Weekly, "2026-W41", "user-slurm_0-usr1"+ext))
+		require.NoError(t, err)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	r, err := New(&Config{}, noOpLogger)
+	require.NoError(t, err)
+	assert.Nil(t, r)
+}
@@ -0,0 +1,238 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/mahendrapaipuri/ceems/internal/email"
+	config_util "github.com/prometheus/common/config"
+)
+
+// Characters that are not allowed in names of digest files.
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+type webhook struct {
+	url    string
+	client *http.Client
+}
+
+// Reporter renders digests and delivers them to the configured receivers.
+type Reporter struct {
+	logger    *slog.Logger
+	config    *Config
+	templates *templates
+	webhooks  []webhook
+}
+
+// New returns a new instance of Reporter. A nil reporter is returned when
+// no report periods are configured.
+func New(c *Config, logger *slog.Logger) (*Reporter, error) {
+	if len(c.Periods) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	t, err := newTemplates(c.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter := &Reporter{
+		logger:    logger,
+		config:    c,
+		templates: t,
+	}
+
+	for _, w := range c.Webhooks {
+		client, err := config_util.NewClientFromConfig(w.HTTPClientConfig, "report_webhook")
+		if err != nil {
+			return nil, err
+		}
+
+		reporter.webhooks = append(reporter.webhooks, webhook{url: w.URL, client: client})
+	}
+
+	return reporter, nil
+}
+
+// Config returns the config of reporter.
+func (r *Reporter) Config() *Config {
+	return r.config
+}
+
+// Recipients returns the recipients resolver. Mapping file is read on every
+// call so that changes are taken into account without restarting the server.
+func (r *Reporter) Recipients() (*Recipients, error) {
+	return loadRecipients(r.config.Recipients)
+}
+
+// Send renders digests and delivers them to all the configured receivers.
+func (r *Reporter) Send(ctx context.Context, digests []Digest) error {
+	if len(digests) == 0 {
+		return nil
+	}
+
+	var errs error
+
+	var numSkipped int
+
+	for _, digest := range digests {
+		out, err := r.templates.render(digest)
+		if err != nil {
+			errs = errors.Join(errs, err)
+
+			continue
+		}
+
+		if r.config.Directory != "" {
+			if err := r.writeFiles(digest, out); err != nil {
+				errs = errors.Join(errs, err)
+			}
+		}
+
+		for _, w := range r.webhooks {
+			if err := r.sendWebhook(ctx, w, digest, out); err != nil {
+				errs = errors.Join(errs, err)
+			}
+		}
+
+		if r.config.Email != nil {
+			if len(digest.Recipients) == 0 {
+				r.logger.Debug(
+					"No recipients found for digest", "kind", digest.Kind,
+					"name", digest.Name, "cluster_id", digest.ClusterID,
+				)
+
+				numSkipped++
+
+				continue
+			}
+
+			if err := r.sendEmail(digest, out); err != nil {
+				errs = errors.Join(errs, err)
+			}
+		}
+	}
+
+	r.logger.Info(
+		"Usage reports sent", "num_digests", len(digests),
+		"num_skipped_emails", numSkipped, "errors", errs,
+	)
+
+	return errs
+}
+
+// writeFiles writes text and HTML digests into the directory of the period.
+func (r *Reporter) writeFiles(d Digest, out rendered) error {
+	dir := filepath.Join(r.config.Directory, d.Period, d.Label)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %w", err)
+	}
+
+	name := unsafeFileChars.ReplaceAllString(fmt.Sprintf("%s-%s-%s", d.Kind, d.ClusterID, d.Name), "_")
+
+	for ext, content := range map[string]string{".txt": out.text, ".html": out.html} {
+		if err := os.WriteFile(filepath.Join(dir, name+ext), []byte(content), 0o644); err != nil { //nolint:gosec
+			return fmt.Errorf("failed to write digest file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// sendWebhook posts the digest to a Slack/Mattermost compatible webhook. The
+// raw digest is included in the payload for other consumers.
+func (r *Reporter) sendWebhook(ctx context.Context, w webhook, d Digest, out rendered) error {
+	payload, err := json.Marshal(map[string]interface{}{"text": out.text, "digest": d})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send digest to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to send digest to webhook: unexpected status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// sendEmail sends the digest as a multipart email with text and HTML parts.
+func (r *Reporter) sendEmail(d Digest, out rendered) error {
+	server := email.SMTP{
+		SmartHost:    r.config.Email.SmartHost,
+		AuthUsername: r.config.Email.AuthUsername,
+		AuthPassword: string(r.config.Email.AuthPassword),
+	}
+
+	msg, err := message(r.config.Email.From, d.Recipients, out)
+	if err != nil {
+		return err
+	}
+
+	if err := email.Send(server, msg); err != nil {
+		return fmt.Errorf("failed to send digest email: %w", err)
+	}
+
+	return nil
+}
+
+// message returns a multipart/alternative email message of digest.
+func message(from string, to []string, out rendered) (email.Message, error) {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+
+	// Parts must be ordered from the least to the most preferred one
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain", out.text},
+		{"text/html", out.html},
+	} {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {part.contentType + "; charset=UTF-8"},
+		})
+		if err != nil {
+			return email.Message{}, err
+		}
+
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return email.Message{}, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return email.Message{}, err
+	}
+
+	return email.Message{
+		From:        from,
+		To:          to,
+		Subject:     out.subject,
+		ContentType: "multipart/alternative; boundary=" + writer.Boundary(),
+		Body:        body.Bytes(),
+	}, nil
+}
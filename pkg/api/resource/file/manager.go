@@ -0,0 +1,444 @@
+// Package file implements the fetcher interface to fetch compute units from
+// accounting files exported by resource managers
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+)
+
+const fileManager = "file"
+
+// Supported file formats.
+const (
+	csvFormat  = "csv"
+	jsonFormat = "json"
+	pbsFormat  = "pbs"
+)
+
+// Name of the file that records the files already ingested.
+const defaultStateFile = ".ceems_ingested.json"
+
+// Default mapping of PBS accounting log attributes to unit fields.
+var defaultPBSColumns = map[string]string{
+	"uuid":                        "jobid",
+	"name":                        "jobname",
+	"username":                    "user",
+	"groupname":                   "group",
+	"project":                     "account",
+	"created_at":                  "ctime",
+	"started_at":                  "start",
+	"ended_at":                    "end",
+	"elapsed":                     "resources_used.walltime",
+	"allocation.nodes":            "Resource_List.nodect",
+	"allocation.cpus":             "Resource_List.ncpus",
+	"allocation.gpus":             "Resource_List.ngpus",
+	"allocation.mem":              "Resource_List.mem",
+	"allocation.walltime":         "Resource_List.walltime",
+	"total_time_seconds.walltime": "resources_used.walltime",
+	"total_time_seconds.cputime":  "resources_used.cput",
+	"tags.queue":                  "queue",
+	"tags.exit_status":            "Exit_status",
+	"tags.exec_host":              "exec_host",
+	"tags.jobid":                  "jobid",
+}
+
+var (
+	errNoPath    = errors.New("missing path of accounting files")
+	errNoUUID    = errors.New("columns must have a mapping for uuid")
+	errFormat    = errors.New("invalid format. Supported formats are csv, json and pbs")
+	errDelimiter = errors.New("delimiter must be a single character")
+)
+
+// fileConfig is the extra_config of file manager.
+type fileConfig struct {
+	Path       string            `yaml:"path"`
+	Pattern    string            `yaml:"pattern"`
+	Format     string            `yaml:"format"`
+	Delimiter  string            `yaml:"delimiter"`
+	TimeLayout string            `yaml:"time_layout"`
+	Columns    map[string]string `yaml:"columns"`
+	StateFile  string            `yaml:"state_file"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *fileConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = fileConfig{
+		Pattern:   "*",
+		Delimiter: ",",
+	}
+
+	type plain fileConfig
+
+	return unmarshal((*plain)(c))
+}
+
+// Validate validates the config.
+func (c *fileConfig) Validate() error {
+	if c.Path == "" {
+		return errNoPath
+	}
+
+	if c.Format != "" && !slices.Contains([]string{csvFormat, jsonFormat, pbsFormat}, c.Format) {
+		return errFormat
+	}
+
+	if len([]rune(c.Delimiter)) != 1 {
+		return errDelimiter
+	}
+
+	// PBS accounting logs have standard attributes and hence, a default
+	// mapping is used when none is configured
+	if len(c.Columns) == 0 && c.Format == pbsFormat {
+		c.Columns = defaultPBSColumns
+	}
+
+	if _, ok := c.Columns["uuid"]; !ok {
+		return errNoUUID
+	}
+
+	for field := range c.Columns {
+		if _, err := newFieldSetter(field); err != nil {
+			return err
+		}
+	}
+
+	if c.StateFile == "" {
+		c.StateFile = filepath.Join(c.Path, defaultStateFile)
+	}
+
+	return nil
+}
+
+// fileFetcher is the struct containing the configuration of a given cluster
+// whose compute units are read from accounting files.
+type fileFetcher struct {
+	logger       *slog.Logger
+	cluster      models.Cluster
+	config       *fileConfig
+	ingested     map[string]string   // Files that have been ingested and their ingestion times
+	fetched      []string            // Files fetched during last update that are not acknowledged yet
+	userProjects map[string][]string // Users and their projects seen so far in the files
+	mu           sync.Mutex
+}
+
+func init() {
+	// Register file manager
+	resource.Register(fileManager, New)
+}
+
+// New returns a new fileFetcher that returns compute units from accounting files.
+func New(cluster models.Cluster, logger *slog.Logger) (resource.Fetcher, error) {
+	fetcher := &fileFetcher{
+		logger:       logger,
+		cluster:      cluster,
+		config:       &fileConfig{Pattern: "*", Delimiter: ","},
+		ingested:     make(map[string]string),
+		userProjects: make(map[string][]string),
+	}
+
+	// Decode extra_config when provided
+	if !cluster.Extra.IsZero() {
+		if err := cluster.Extra.Decode(fetcher.config); err != nil {
+			logger.Error("Failed to decode extra_config for file cluster", "id", cluster.ID, "err", err)
+
+			return nil, err
+		}
+	}
+
+	if err := fetcher.config.Validate(); err != nil {
+		logger.Error("Invalid extra_config for file cluster", "id", cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	// Load the files that were already ingested
+	if err := fetcher.loadState(); err != nil {
+		logger.Error("Failed to read state file of file cluster", "id", cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	logger.Info("Compute units from accounting files will be fetched", "id", cluster.ID, "path", fetcher.config.Path)
+
+	return fetcher, nil
+}
+
+// FetchUnits fetches compute units from accounting files that appeared
+// before the end of the interval and have not been ingested yet. Files are
+// marked as ingested only when units are acknowledged by Ack.
+func (f *fileFetcher) FetchUnits(
+	_ context.Context,
+	start time.Time,
+	end time.Time,
+) ([]models.ClusterUnits, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Files of previous update that are not acknowledged must be fetched again
+	f.fetched = nil
+
+	files, err := f.pendingFiles(end)
+	if err != nil {
+		f.logger.Error("Failed to list accounting files", "cluster_id", f.cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	var units []models.Unit
+
+	for _, file := range files {
+		fileUnits, err := f.parseFile(file, end.Location())
+		if err != nil {
+			// Skip malformed files so that they do not block ingesting others.
+			// They will be retried during next update
+			f.logger.Error("Failed to parse accounting file", "cluster_id", f.cluster.ID, "file", file, "err", err)
+
+			continue
+		}
+
+		units = append(units, fileUnits...)
+		f.fetched = append(f.fetched, filepath.Base(file))
+	}
+
+	// Keep track of users and their projects
+	for _, unit := range units {
+		if unit.User != "" && unit.Project != "" && !slices.Contains(f.userProjects[unit.User], unit.Project) {
+			f.userProjects[unit.User] = append(f.userProjects[unit.User], unit.Project)
+		}
+	}
+
+	f.logger.Info("Accounting files fetched", "cluster_id", f.cluster.ID, "start", start, "end", end, "num_files", len(files), "num_units", len(units))
+
+	return []models.ClusterUnits{{Cluster: f.cluster, Units: units}}, nil
+}
+
+// Cluster returns the cluster of resource manager.
+func (f *fileFetcher) Cluster() models.Cluster {
+	return f.cluster
+}
+
+// Ack marks the files fetched during last update as ingested once their units
+// are committed to DB.
+func (f *fileFetcher) Ack(end time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.fetched) == 0 {
+		return nil
+	}
+
+	for _, name := range f.fetched {
+		f.ingested[name] = end.Format(base.DatetimezoneLayout)
+	}
+
+	f.fetched = nil
+
+	return f.saveState()
+}
+
+// FetchUsersProjects returns users and projects seen so far in accounting files.
+func (f *fileFetcher) FetchUsersProjects(
+	_ context.Context,
+	current time.Time,
+) ([]models.ClusterUsers, []models.ClusterProjects, error) {
+	f.mu.Lock()
+	users, projects := assocModels(f.userProjects, current.Format(base.DatetimeLayout))
+	f.mu.Unlock()
+
+	return []models.ClusterUsers{
+		{Cluster: f.cluster, Users: users},
+	}, []models.ClusterProjects{
+		{Cluster: f.cluster, Projects: projects},
+	}, nil
+}
+
+// pendingFiles returns the files matching pattern that were modified before
+// end and have not been ingested yet sorted by their names.
+func (f *fileFetcher) pendingFiles(end time.Time) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(f.config.Path, f.config.Pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+
+	for _, match := range matches {
+		name := filepath.Base(match)
+
+		// Ignore hidden files like state file and files being written
+		if strings.HasPrefix(name, ".") || match == f.config.StateFile {
+			continue
+		}
+
+		if _, ok := f.ingested[name]; ok {
+			continue
+		}
+
+		info, err := os.Stat(match)
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(end) {
+			continue
+		}
+
+		files = append(files, match)
+	}
+
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// parseFile parses accounting file into compute units.
+func (f *fileFetcher) parseFile(file string, loc *time.Location) ([]models.Unit, error) {
+	r, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	format := f.config.Format
+	if format == "" {
+		format = fileFormat(file)
+	}
+
+	var records []map[string]string
+
+	switch format {
+	case csvFormat:
+		records, err = parseCSV(r, []rune(f.config.Delimiter)[0])
+	case jsonFormat:
+		records, err = parseJSON(r)
+	default:
+		records, err = parsePBSAccounting(r)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// PBS logs have times as epochs
+	layout := f.config.TimeLayout
+	if layout == "" && format == pbsFormat {
+		layout = unixLayout
+	}
+
+	return recordUnits(records, f.config.Columns, layout, f.cluster.Manager, loc)
+}
+
+// loadState reads the files already ingested from state file.
+func (f *fileFetcher) loadState() error {
+	data, err := os.ReadFile(f.config.StateFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	return json.Unmarshal(data, &f.ingested)
+}
+
+// saveState writes the files already ingested to state file atomically.
+func (f *fileFetcher) saveState() error {
+	data, err := json.Marshal(f.ingested)
+	if err != nil {
+		return err
+	}
+
+	tmpFile := f.config.StateFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, f.config.StateFile)
+}
+
+// fileFormat returns format of file based on its extension. Files without a
+// known extension are assumed to be PBS accounting logs which are named after
+// their dates.
+func fileFormat(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv":
+		return csvFormat
+	case ".json":
+		return jsonFormat
+	default:
+		return pbsFormat
+	}
+}
+
+// assocModels returns users and projects models from user projects map.
+func assocModels(assoc map[string][]string, currentTime string) ([]models.User, []models.Project) {
+	projectUserMap := make(map[string][]string)
+
+	var users []string
+
+	var projects []string
+
+	for user, userProjects := range assoc {
+		users = append(users, user)
+
+		for _, project := range userProjects {
+			projectUserMap[project] = append(projectUserMap[project], user)
+			projects = append(projects, project)
+		}
+	}
+
+	// Sort projects and users to get deterministic output
+	slices.Sort(projects)
+	projects = slices.Compact(projects)
+
+	slices.Sort(users)
+
+	projectModels := make([]models.Project, len(projects))
+
+	for i := range projects {
+		projectUsers := projectUserMap[projects[i]]
+		slices.Sort(projectUsers)
+
+		var usersList models.List
+		for _, u := range slices.Compact(projectUsers) {
+			usersList = append(usersList, u)
+		}
+
+		projectModels[i] = models.Project{
+			Name:          projects[i],
+			Users:         usersList,
+			LastUpdatedAt: currentTime,
+		}
+	}
+
+	userModels := make([]models.User, len(users))
+
+	for i := range users {
+		userProjects := slices.Clone(assoc[users[i]])
+		slices.Sort(userProjects)
+
+		var projectsList models.List
+		for _, p := range slices.Compact(userProjects) {
+			projectsList = append(projectsList, p)
+		}
+
+		userModels[i] = models.User{
+			Name:          users[i],
+			Projects:      projectsList,
+			LastUpdatedAt: currentTime,
+		}
+	}
+
+	return userModels, projectModels
+}
@@ -0,0 +1,397 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const csvConfig = `
+path: %s
+pattern: "*.csv"
+delimiter: "|"
+columns:
+  uuid: JobID
+  name: JobName
+  username: User
+  groupname: Group
+  project: Account
+  state: State
+  created_at: Submit
+  started_at: Start
+  ended_at: End
+  allocation.cpus: AllocCPUS
+  allocation.gpus: AllocGPUS
+  tags.partition: Partition
+  total_time_seconds.walltime: ElapsedRaw
+`
+
+var (
+	end = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	expectedCSVUnits = []models.Unit{
+		{
+			ResourceManager: "file",
+			UUID:            "1479763",
+			Name:            "test_script1",
+			Project:         "acc1",
+			Group:           "grp1",
+			User:            "usr1",
+			CreatedAt:       "2024-04-18T10:00:00+0000",
+			StartedAt:       "2024-04-18T10:00:05+0000",
+			EndedAt:         "2024-04-18T11:00:05+0000",
+			CreatedAtTS:     1713434400000,
+			StartedAtTS:     1713434405000,
+			EndedAtTS:       1713438005000,
+			Elapsed:         "01:00:00",
+			State:           "COMPLETED",
+			Allocation:      models.Allocation{"cpus": int64(4), "gpus": int64(0)},
+			TotalTime: models.MetricMap{
+				"walltime":         3600,
+				"alloc_cputime":    14400,
+				"alloc_cpumemtime": 3600,
+				"alloc_gputime":    0,
+				"alloc_gpumemtime": 0,
+			},
+			Tags: models.Tag{"partition": "cpu"},
+		},
+		{
+			ResourceManager: "file",
+			UUID:            "1479765",
+			Name:            "test_script2",
+			Project:         "acc2",
+			Group:           "grp2",
+			User:            "usr2",
+			CreatedAt:       "2024-04-18T10:30:00+0000",
+			StartedAt:       "2024-04-18T10:30:00+0000",
+			EndedAt:         "2024-04-18T10:45:00+0000",
+			CreatedAtTS:     1713436200000,
+			StartedAtTS:     1713436200000,
+			EndedAtTS:       1713437100000,
+			Elapsed:         "00:15:00",
+			State:           "FAILED",
+			Allocation:      models.Allocation{"cpus": int64(8), "gpus": int64(2)},
+			TotalTime: models.MetricMap{
+				"walltime":         900,
+				"alloc_cputime":    7200,
+				"alloc_cpumemtime": 900,
+				"alloc_gputime":    1800,
+				"alloc_gpumemtime": 900,
+			},
+			Tags: models.Tag{"partition": "gpu"},
+		},
+		{
+			ResourceManager: "file",
+			UUID:            "1479766",
+			Name:            "test_script3",
+			Project:         "acc1",
+			Group:           "grp1",
+			User:            "usr1",
+			CreatedAt:       "2024-04-18T11:00:00+0000",
+			CreatedAtTS:     1713438000000,
+			EndedAt:         "N/A",
+			Elapsed:         "00:00:00",
+			State:           "CANCELLED",
+			Allocation:      models.Allocation{"cpus": int64(2), "gpus": int64(0)},
+			TotalTime: models.MetricMap{
+				"walltime":         0,
+				"alloc_cputime":    0,
+				"alloc_cpumemtime": 0,
+				"alloc_gputime":    0,
+				"alloc_gpumemtime": 0,
+			},
+			Tags: models.Tag{"partition": "cpu"},
+		},
+	}
+)
+
+func mockCluster(extraConfig string) (models.Cluster, error) {
+	var extra yaml.Node
+
+	if err := yaml.Unmarshal([]byte(extraConfig), &extra); err != nil {
+		return models.Cluster{}, err
+	}
+
+	return models.Cluster{
+		ID:      "file-0",
+		Manager: "file",
+		Extra:   extra,
+	}, nil
+}
+
+// copyTestdata copies accounting files from testdata into dir.
+func copyTestdata(t *testing.T, dir string, names ...string) {
+	t.Helper()
+
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "file", name))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0o600))
+	}
+}
+
+func TestFileFetcher(t *testing.T) {
+	dir := t.TempDir()
+	copyTestdata(t, dir, "sacct-2024-04.csv")
+
+	cluster, err := mockCluster(fmt.Sprintf(csvConfig, dir))
+	require.NoError(t, err)
+
+	fetcher, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// Files modified after end of interval are not ingested
+	units, err := fetcher.FetchUnits(ctx, end.Add(-time.Hour), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, units[0].Units)
+
+	units, err = fetcher.FetchUnits(ctx, end.Add(-time.Hour), time.Now().Add(time.Minute).In(time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, expectedCSVUnits, units[0].Units)
+
+	users, projects, err := fetcher.FetchUsersProjects(ctx, end)
+	require.NoError(t, err)
+	assert.Len(t, users[0].Users, 2)
+	assert.Equal(t, models.List{"usr1"}, projects[0].Projects[0].Users)
+
+	// Files are fetched again when units are not acknowledged, eg, when DB
+	// update failed
+	units, err = fetcher.FetchUnits(ctx, end.Add(-time.Hour), time.Now().Add(time.Minute).In(time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, expectedCSVUnits, units[0].Units)
+
+	_, err = os.Stat(filepath.Join(dir, defaultStateFile))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, fetcher.(resource.Acknowledger).Ack(end))
+
+	units, err = fetcher.FetchUnits(ctx, end, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, units[0].Units)
+
+	// Files must be ingested only once even after restarts
+	fetcher, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	units, err = fetcher.FetchUnits(ctx, end, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, units[0].Units)
+
+	// New files are ingested
+	copyTestdata(t, dir, "sacct-2024-04.csv")
+	require.NoError(t, os.Rename(filepath.Join(dir, "sacct-2024-04.csv"), filepath.Join(dir, "sacct-2024-05.csv")))
+
+	units, err = fetcher.FetchUnits(ctx, end, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, units[0].Units, 3)
+
+	// Acknowledging without fetched files is a no-op
+	require.NoError(t, fetcher.(resource.Acknowledger).Ack(end))
+	require.NoError(t, fetcher.(resource.Acknowledger).Ack(end))
+	assert.Len(t, fetcher.(*fileFetcher).ingested, 2)
+}
+
+func TestFileFetcherFormats(t *testing.T) {
+	dir := t.TempDir()
+	copyTestdata(t, dir, "jobs-2024-04.json", "20240418")
+
+	tests := []struct {
+		name     string
+		config   string
+		expected []models.Unit
+	}{
+		{
+			name: "pbs accounting logs",
+			config: `
+path: %s
+pattern: "2024*"
+format: pbs
+state_file: %s/.pbs.json`,
+			expected: []models.Unit{
+				{
+					ResourceManager: "file",
+					UUID:            "1234",
+					Name:            "sim",
+					Project:         "acc4",
+					Group:           "grp4",
+					User:            "usr4",
+					CreatedAt:       "2024-04-18T10:00:00+0000",
+					StartedAt:       "2024-04-18T10:00:05+0000",
+					EndedAt:         "2024-04-18T11:00:05+0000",
+					CreatedAtTS:     1713434400000,
+					StartedAtTS:     1713434405000,
+					EndedAtTS:       1713438005000,
+					Elapsed:         "01:00:00",
+					Allocation: models.Allocation{
+						"cpus": int64(4), "nodes": int64(1), "mem": "4gb", "walltime": "02:00:00",
+					},
+					TotalTime: models.MetricMap{
+						"walltime":         3600,
+						"cputime":          12000,
+						"alloc_cputime":    14400,
+						"alloc_cpumemtime": 3600,
+						"alloc_gputime":    0,
+						"alloc_gpumemtime": 0,
+					},
+					Tags: models.Tag{"queue": "workq", "exit_status": int64(0), "exec_host": "node1/0*4", "jobid": int64(1234)},
+				},
+				{
+					ResourceManager: "file",
+					UUID:            "1235",
+					Name:            "gpu",
+					Project:         "grp5",
+					Group:           "grp5",
+					User:            "usr5",
+					CreatedAt:       "2024-04-18T10:30:00+0000",
+					StartedAt:       "2024-04-18T10:30:00+0000",
+					EndedAt:         "2024-04-18T11:30:00+0000",
+					CreatedAtTS:     1713436200000,
+					StartedAtTS:     1713436200000,
+					EndedAtTS:       1713439800000,
+					Elapsed:         "01:00:00",
+					Allocation:      models.Allocation{"cpus": int64(8), "gpus": int64(1), "nodes": int64(1)},
+					TotalTime: models.MetricMap{
+						"walltime":         3600,
+						"cputime":          3600,
+						"alloc_cputime":    28800,
+						"alloc_cpumemtime": 3600,
+						"alloc_gputime":    3600,
+						"alloc_gpumemtime": 3600,
+					},
+					Tags: models.Tag{"queue": "gpuq", "exit_status": int64(1), "exec_host": "gnode1/0*8", "jobid": int64(1235)},
+				},
+			},
+		},
+		{
+			name: "json with epochs",
+			config: `
+path: %s
+pattern: "*.json"
+time_layout: unix
+state_file: %s/.json.json
+columns:
+  uuid: id
+  username: owner
+  project: tenant
+  created_at: created
+  started_at: launched
+  ended_at: terminated
+  allocation.cpus: vcpus
+  tags.flavor: flavor
+  total_cpu_energy_usage_kwh.total: energy_kwh`,
+			expected: []models.Unit{
+				{
+					ResourceManager:     "file",
+					UUID:                "vm-01",
+					Project:             "prj3",
+					User:                "usr3",
+					CreatedAt:           "2024-04-18T10:00:00+0000",
+					StartedAt:           "2024-04-18T10:01:00+0000",
+					EndedAt:             "2024-04-18T11:01:00+0000",
+					CreatedAtTS:         1713434400000,
+					StartedAtTS:         1713434460000,
+					EndedAtTS:           1713438060000,
+					Elapsed:             "01:00:00",
+					Allocation:          models.Allocation{"cpus": int64(2)},
+					TotalCPUEnergyUsage: models.MetricMap{"total": 0.5},
+					TotalTime: models.MetricMap{
+						"walltime":         3600,
+						"alloc_cputime":    7200,
+						"alloc_cpumemtime": 3600,
+						"alloc_gputime":    0,
+						"alloc_gpumemtime": 0,
+					},
+					Tags: models.Tag{"flavor": "m1.small"},
+				},
+				{
+					ResourceManager:     "file",
+					UUID:                "vm-02",
+					Project:             "prj3",
+					User:                "usr3",
+					CreatedAt:           "2024-04-18T10:00:00+0000",
+					StartedAt:           "2024-04-18T10:01:00+0000",
+					EndedAt:             "N/A",
+					CreatedAtTS:         1713434400000,
+					StartedAtTS:         1713434460000,
+					Elapsed:             "00:00:00",
+					Allocation:          models.Allocation{"cpus": int64(4)},
+					TotalCPUEnergyUsage: models.MetricMap{"total": 1.25},
+					TotalTime: models.MetricMap{
+						"walltime":         0,
+						"alloc_cputime":    0,
+						"alloc_cpumemtime": 0,
+						"alloc_gputime":    0,
+						"alloc_gpumemtime": 0,
+					},
+					Tags: models.Tag{"flavor": "m1.large"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		cluster, err := mockCluster(fmt.Sprintf(test.config, dir, dir))
+		require.NoError(t, err, test.name)
+
+		fetcher, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err, test.name)
+
+		units, err := fetcher.FetchUnits(context.Background(), end, time.Now().Add(time.Minute).In(time.UTC))
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, units[0].Units, test.name)
+	}
+}
+
+func TestFileFetcherMalformedFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.csv"), []byte("JobID|Start\n1|yesterday\n"), 0o600))
+
+	cluster, err := mockCluster(fmt.Sprintf(csvConfig, dir))
+	require.NoError(t, err)
+
+	fetcher, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// Malformed files are skipped and retried during next update
+	units, err := fetcher.FetchUnits(context.Background(), end, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, units[0].Units)
+	assert.Empty(t, fetcher.(*fileFetcher).ingested)
+}
+
+func TestFileFetcherConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    error
+	}{
+		{name: "missing path", config: "format: pbs", err: errNoPath},
+		{name: "unknown format", config: "path: /tmp\nformat: xml", err: errFormat},
+		{name: "invalid delimiter", config: "path: /tmp\nformat: pbs\ndelimiter: '||'", err: errDelimiter},
+		{name: "missing uuid", config: "path: /tmp\ncolumns:\n  name: JobName", err: errNoUUID},
+		{name: "unknown field", config: "path: /tmp\ncolumns:\n  uuid: JobID\n  foo: Bar", err: errUnknownField},
+		{name: "reserved field", config: "path: /tmp\ncolumns:\n  uuid: JobID\n  cluster_id: Cluster", err: errUnknownField},
+		{name: "map field without key", config: "path: /tmp\ncolumns:\n  uuid: JobID\n  allocation: Alloc", err: errMissingKey},
+	}
+
+	for _, test := range tests {
+		cluster, err := mockCluster(test.config)
+		require.NoError(t, err, test.name)
+
+		_, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.ErrorIs(t, err, test.err, test.name)
+	}
+}
@@ -0,0 +1,466 @@
+package file
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/helper"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Special time layouts for epochs.
+const (
+	unixLayout      = "unix"
+	unixMilliLayout = "unixmilli"
+)
+
+// Time layouts tried when no time layout is configured.
+var defaultTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", time.DateTime}
+
+// Values that resource managers use for unset fields like end time of
+// running jobs.
+var unsetValues = []string{"", "Unknown", "None", "N/A"}
+
+// Fields of units that are set by fetcher and cannot be mapped.
+var reservedFields = []string{"cluster_id", "resource_manager"}
+
+// Time fields and their timestamp counterparts.
+var timeFields = map[string]string{
+	"created_at":    "created_at_ts",
+	"started_at":    "started_at_ts",
+	"ended_at":      "ended_at_ts",
+	"created_at_ts": "created_at_ts",
+	"started_at_ts": "started_at_ts",
+	"ended_at_ts":   "ended_at_ts",
+}
+
+var (
+	errUnknownField = errors.New("unknown unit field")
+	errMissingKey   = errors.New("map fields must be of form <field>.<key>")
+	errNumber       = errors.New("must be a number or a duration of form [D-]HH:MM:SS")
+)
+
+// Index of fields of unit keyed by their JSON names.
+var unitFields = func() map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+
+	typ := reflect.TypeFor[models.Unit]()
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" || slices.Contains(reservedFields, name) {
+			continue
+		}
+
+		fields[name] = field
+	}
+
+	return fields
+}()
+
+// fieldSetter sets a field of unit from the value of a record.
+type fieldSetter struct {
+	field reflect.StructField
+	key   string // Key of map fields
+	time  string // Name of timestamp field for time fields
+}
+
+// newFieldSetter returns a fieldSetter of a field of form <field> or
+// <field>.<key> for map fields.
+func newFieldSetter(name string) (fieldSetter, error) {
+	name, key, _ := strings.Cut(name, ".")
+
+	if ts, ok := timeFields[name]; ok && key == "" {
+		return fieldSetter{time: ts}, nil
+	}
+
+	field, ok := unitFields[name]
+	if !ok {
+		return fieldSetter{}, fmt.Errorf("%w: %s", errUnknownField, name)
+	}
+
+	if (field.Type.Kind() == reflect.Map) != (key != "") {
+		return fieldSetter{}, fmt.Errorf("%w: %s", errMissingKey, name)
+	}
+
+	return fieldSetter{field: field, key: key}, nil
+}
+
+// set sets the field of unit from value.
+func (s fieldSetter) set(unit *models.Unit, value string, layout string, loc *time.Location) error {
+	if s.time != "" {
+		t, err := parseTime(value, layout, loc)
+		if err != nil {
+			return err
+		}
+
+		setTime(unit, s.time, t)
+
+		return nil
+	}
+
+	v := reflect.ValueOf(unit).Elem().FieldByIndex(s.field.Index)
+
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(value)
+	case models.MetricMap:
+		number, err := parseNumber(value)
+		if err != nil {
+			return err
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.ValueOf(models.MetricMap{}))
+		}
+
+		v.Interface().(models.MetricMap)[s.key] = models.JSONFloat(number)
+	case models.Generic:
+		if v.IsNil() {
+			v.Set(reflect.ValueOf(models.Generic{}))
+		}
+
+		// Only string and int64 values are supported in allocation and tags
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			v.Interface().(models.Generic)[s.key] = i
+		} else {
+			v.Interface().(models.Generic)[s.key] = value
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnknownField, s.field.Name)
+	}
+
+	return nil
+}
+
+// setTime sets time and its timestamp fields of unit.
+func setTime(unit *models.Unit, field string, t time.Time) {
+	formatted := t.Format(base.DatetimezoneLayout)
+
+	switch field {
+	case "created_at_ts":
+		unit.CreatedAt, unit.CreatedAtTS = formatted, t.UnixMilli()
+	case "started_at_ts":
+		unit.StartedAt, unit.StartedAtTS = formatted, t.UnixMilli()
+	case "ended_at_ts":
+		unit.EndedAt, unit.EndedAtTS = formatted, t.UnixMilli()
+	}
+}
+
+// parseTime parses value using layout in the given location. Epochs are
+// supported using unix and unixmilli layouts.
+func parseTime(value string, layout string, loc *time.Location) (time.Time, error) {
+	switch layout {
+	case unixLayout, unixMilliLayout:
+		epoch, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if layout == unixLayout {
+			return time.Unix(epoch, 0).In(loc), nil
+		}
+
+		return time.UnixMilli(epoch).In(loc), nil
+	case "":
+		var err error
+
+		for _, l := range defaultTimeLayouts {
+			var t time.Time
+			if t, err = time.ParseInLocation(l, value, loc); err == nil {
+				return t.In(loc), nil
+			}
+		}
+
+		return time.Time{}, err
+	default:
+		t, err := time.ParseInLocation(layout, value, loc)
+
+		return t.In(loc), err
+	}
+}
+
+// parseNumber parses value as a number or a duration of form [D-]HH:MM:SS
+// into seconds.
+func parseNumber(value string) (float64, error) {
+	if number, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(number) && !math.IsInf(number, 0) {
+		return number, nil
+	}
+
+	var days int64
+
+	if d, rest, ok := strings.Cut(value, "-"); ok {
+		var err error
+		if days, err = strconv.ParseInt(d, 10, 64); err != nil {
+			return 0, errNumber
+		}
+
+		value = rest
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, errNumber
+	}
+
+	var seconds float64
+
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, errNumber
+		}
+
+		seconds = seconds*60 + v
+	}
+
+	return float64(days*86400) + seconds, nil
+}
+
+// recordUnits converts records into units using the mapping of unit fields
+// to record columns.
+func recordUnits(
+	records []map[string]string,
+	columns map[string]string,
+	layout string,
+	manager string,
+	loc *time.Location,
+) ([]models.Unit, error) {
+	setters := make(map[string]fieldSetter, len(columns))
+
+	for field := range columns {
+		setter, err := newFieldSetter(field)
+		if err != nil {
+			return nil, err
+		}
+
+		setters[field] = setter
+	}
+
+	// Sort fields to set them in a deterministic order
+	fields := make([]string, 0, len(columns))
+	for field := range columns {
+		fields = append(fields, field)
+	}
+
+	slices.Sort(fields)
+
+	units := make([]models.Unit, 0, len(records))
+
+	for i, record := range records {
+		unit := models.Unit{ResourceManager: manager, EndedAt: "N/A"}
+
+		for _, field := range fields {
+			value := strings.TrimSpace(record[columns[field]])
+			if slices.Contains(unsetValues, value) {
+				continue
+			}
+
+			if err := setters[field].set(&unit, value, layout, loc); err != nil {
+				return nil, fmt.Errorf("record %d: field %s: %w", i, field, err)
+			}
+		}
+
+		// Ignore records without identifier like headers of sub sections
+		if unit.UUID == "" {
+			continue
+		}
+
+		units = append(units, deriveTimes(unit))
+	}
+
+	return units, nil
+}
+
+// deriveTimes estimates walltime and allocated times of unit when they are
+// not present in the records.
+func deriveTimes(unit models.Unit) models.Unit {
+	if unit.TotalTime == nil {
+		unit.TotalTime = models.MetricMap{}
+	}
+
+	// Walltime of units that have not ended yet is unknown
+	if _, ok := unit.TotalTime["walltime"]; !ok {
+		unit.TotalTime["walltime"] = 0
+		if unit.StartedAtTS > 0 && unit.EndedAtTS > unit.StartedAtTS {
+			unit.TotalTime["walltime"] = models.JSONFloat((unit.EndedAtTS - unit.StartedAtTS) / 1000)
+		}
+	}
+
+	walltime := float64(unit.TotalTime["walltime"])
+
+	if unit.Elapsed == "" {
+		unit.Elapsed = helper.FormatElapsed(time.Duration(walltime) * time.Second)
+	}
+
+	cpus := allocationNumber(unit.Allocation, "cpus")
+	gpus := allocationNumber(unit.Allocation, "gpus")
+
+	// Same as other batch schedulers, walltime is used as CPU memory time when
+	// memory is unknown and as GPU memory time for jobs with GPUs
+	derived := map[string]float64{
+		"alloc_cputime":    cpus * walltime,
+		"alloc_cpumemtime": walltime,
+		"alloc_gputime":    gpus * walltime,
+		"alloc_gpumemtime": 0,
+	}
+
+	if gpus > 0 {
+		derived["alloc_gpumemtime"] = walltime
+	}
+
+	for name, value := range derived {
+		if _, ok := unit.TotalTime[name]; !ok {
+			unit.TotalTime[name] = models.JSONFloat(value)
+		}
+	}
+
+	return unit
+}
+
+// allocationNumber returns numeric value of allocation key.
+func allocationNumber(allocation models.Allocation, key string) float64 {
+	if v, ok := allocation[key].(int64); ok {
+		return float64(v)
+	}
+
+	return 0
+}
+
+// parseCSV parses CSV file with a header into records keyed by column names.
+func parseCSV(r io.Reader, delimiter rune) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var records []map[string]string
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		record := make(map[string]string, len(header))
+		for i, value := range row {
+			if i < len(header) {
+				record[header[i]] = value
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// parseJSON parses JSON file with either an array of objects or one object
+// per line into records keyed by object keys.
+func parseJSON(r io.Reader) ([]map[string]string, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var records []map[string]string
+
+	for {
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
+		}
+
+		objects, ok := value.([]any)
+		if !ok {
+			objects = []any{value}
+		}
+
+		for _, object := range objects {
+			values, ok := object.(map[string]any)
+			if !ok {
+				return nil, errors.New("records must be JSON objects")
+			}
+
+			record := make(map[string]string, len(values))
+			for key, v := range values {
+				switch v := v.(type) {
+				case nil:
+					continue
+				case string:
+					record[key] = v
+				case json.Number:
+					record[key] = v.String()
+				default:
+					record[key] = fmt.Sprint(v)
+				}
+			}
+
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// parsePBSAccounting parses PBS accounting logs into records of finished
+// jobs. Each line of log is of form <datetime>;<type>;<jobid>;<key=value ...>
+// and only the end records of jobs, ie, of type E, are used.
+func parsePBSAccounting(r io.Reader) ([]map[string]string, error) {
+	var records []map[string]string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ";", 4)
+		if len(parts) != 4 || parts[1] != "E" {
+			continue
+		}
+
+		// Job ID without server name same as PBS manager
+		record := map[string]string{"jobid": strings.Split(parts[2], ".")[0]}
+
+		for _, attr := range strings.Fields(parts[3]) {
+			if key, value, ok := strings.Cut(attr, "="); ok {
+				record[key] = value
+			}
+		}
+
+		// Group of the user is used as project when account is not set
+		if _, ok := record["account"]; !ok {
+			record["account"] = record["group"]
+		}
+
+		records = append(records, record)
+	}
+
+	return records, scanner.Err()
+}
@@ -0,0 +1,93 @@
+package file
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected float64
+		err      bool
+	}{
+		{value: "12.5", expected: 12.5},
+		{value: "3600", expected: 3600},
+		{value: "01:00:00", expected: 3600},
+		{value: "1-02:00:00", expected: 93600},
+		{value: "05:30", expected: 330},
+		{value: "NaN", err: true},
+		{value: "4gb", err: true},
+	}
+
+	for _, test := range tests {
+		got, err := parseNumber(test.value)
+		if test.err {
+			require.Error(t, err, test.value)
+
+			continue
+		}
+
+		require.NoError(t, err, test.value)
+		assert.InDelta(t, test.expected, got, 0, test.value)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2024, 4, 18, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value  string
+		layout string
+	}{
+		{value: "1713434400", layout: unixLayout},
+		{value: "1713434400000", layout: unixMilliLayout},
+		{value: "2024-04-18T10:00:00Z"},
+		{value: "2024-04-18T10:00:00"},
+		{value: "2024-04-18 10:00:00"},
+		{value: "18/04/2024 10:00", layout: "02/01/2006 15:04"},
+	}
+
+	for _, test := range tests {
+		got, err := parseTime(test.value, test.layout, time.UTC)
+		require.NoError(t, err, test.value)
+		assert.True(t, expected.Equal(got), test.value)
+	}
+
+	_, err := parseTime("yesterday", "", time.UTC)
+	require.Error(t, err)
+}
+
+func TestParseJSON(t *testing.T) {
+	// Concatenated objects must be supported as well
+	records, err := parseJSON(strings.NewReader(`{"id": "a", "cpus": 2, "end": null} {"id": "b", "ok": true}`))
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"id": "a", "cpus": "2"}, {"id": "b", "ok": "true"}}, records)
+
+	_, err = parseJSON(strings.NewReader(`["a"]`))
+	require.Error(t, err)
+}
+
+func TestParsePBSAccounting(t *testing.T) {
+	log := `04/18/2024 10:00:00;Q;1234.pbs-server;queue=workq
+04/18/2024 11:00:05;E;1234.pbs-server;user=usr1 group=grp1 Resource_List.ncpus=4 start=1713434405 end=1713438005
+`
+
+	records, err := parsePBSAccounting(strings.NewReader(log))
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{
+		{
+			"jobid":               "1234",
+			"user":                "usr1",
+			"group":               "grp1",
+			"account":             "grp1",
+			"Resource_List.ncpus": "4",
+			"start":               "1713434405",
+			"end":                 "1713438005",
+		},
+	}, records)
+}
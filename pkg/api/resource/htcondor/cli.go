@@ -0,0 +1,469 @@
+package htcondor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	internal_osexec "github.com/mahendrapaipuri/ceems/internal/osexec"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/helper"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Constraint to get only running jobs from condor_q.
+const runningConstraint = "JobStatus == 2"
+
+var (
+	// Job ClassAd attributes that are used to build units.
+	jobAttributes = []string{
+		"GlobalJobId", "ClusterId", "ProcId", "Owner", "AcctGroup", "JobStatus",
+		"QDate", "JobStartDate", "JobCurrentStartDate", "CompletionDate",
+		"EnteredCurrentStatus", "RequestCpus", "RequestMemory", "RequestDisk",
+		"RequestGpus", "RemoteHost", "LastRemoteHost", "ExitCode", "JobUniverse",
+		"JobBatchName", "Cmd", "Iwd",
+	}
+
+	// Job ClassAd attributes that are used to build user project associations.
+	assocAttributes = []string{"Owner", "AcctGroup"}
+
+	// HTCondor job states.
+	// Ref: https://htcondor.readthedocs.io/en/latest/classad-attributes/job-classad-attributes.html
+	condorStates = map[int64]string{
+		1: "IDLE",
+		2: "RUNNING",
+		3: "REMOVED",
+		4: "COMPLETED",
+		5: "HELD",
+		6: "TRANSFERRING_OUTPUT",
+		7: "SUSPENDED",
+	}
+)
+
+// jobAd is the job ClassAd in JSON output of condor_q and condor_history.
+// Attributes are decoded as interface as ClassAd values can be
+// expressions that are printed as strings.
+type jobAd map[string]interface{}
+
+// strAttr returns the attribute value as string.
+func (j jobAd) strAttr(attr string) string {
+	switch value := j[attr].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+// intAttr returns the attribute value as int64.
+func (j jobAd) intAttr(attr string) int64 {
+	switch value := j[attr].(type) {
+	case float64:
+		return int64(value)
+	case string:
+		i, _ := strconv.ParseInt(value, 10, 64)
+
+		return i
+	}
+
+	return 0
+}
+
+// timeAttr returns the attribute value as time. Zero time is returned when
+// attribute is not set.
+func (j jobAd) timeAttr(attr string) time.Time {
+	if ts := j.intAttr(attr); ts > 0 {
+		return time.Unix(ts, 0)
+	}
+
+	return time.Time{}
+}
+
+// jobID returns ClusterId.ProcId of the job.
+func (j jobAd) jobID() string {
+	return fmt.Sprintf("%d.%d", j.intAttr("ClusterId"), j.intAttr("ProcId"))
+}
+
+// Run preflights for CLI execution mode.
+func preflightsCLI(condor *htcondorScheduler) error {
+	condor.fetchMode = cliMode
+	condor.logger.Debug("Using HTCondor CLI commands")
+
+	// If no path is provided, assume condor_q is available on PATH
+	if condor.cluster.CLI.Path == "" {
+		path, err := exec.LookPath("condor_q")
+		if err != nil {
+			condor.logger.Error("Failed to find HTCondor utility executables on PATH", "err", err)
+
+			return err
+		}
+
+		condor.cluster.CLI.Path = filepath.Dir(path)
+	} else {
+		// Check if HTCondor binary directory exists at the given path
+		if _, err := os.Stat(condor.cluster.CLI.Path); err != nil {
+			condor.logger.Error("Failed to open HTCondor bin dir", "path", condor.cluster.CLI.Path, "err", err)
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeJobAds decodes the JSON output of condor_q and condor_history. When no
+// jobs are found, these commands do not output anything.
+func decodeJobAds(output []byte) ([]jobAd, error) {
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, nil
+	}
+
+	var ads []jobAd
+	if err := json.Unmarshal(output, &ads); err != nil {
+		return nil, err
+	}
+
+	return ads, nil
+}
+
+// parseCondorCmdOutput parses condor_q and condor_history outputs and returns
+// units that were running during the interval between start and end.
+func parseCondorCmdOutput(runningOutput []byte, finishedOutput []byte, start time.Time, end time.Time) ([]models.Unit, error) {
+	runningAds, err := decodeJobAds(runningOutput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode condor_q output: %w", err)
+	}
+
+	finishedAds, err := decodeJobAds(finishedOutput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode condor_history output: %w", err)
+	}
+
+	// Get current location
+	loc := end.Location()
+
+	// A job can be in both outputs if it finished between the two command
+	// executions. Finished job ads are used in that case
+	ads := make(map[string]jobAd, len(runningAds)+len(finishedAds))
+	for _, ad := range slices.Concat(runningAds, finishedAds) {
+		if id := ad.strAttr("GlobalJobId"); id != "" {
+			ads[id] = ad
+		}
+	}
+
+	// Sort job IDs to get deterministic output
+	jobIDs := make([]string, 0, len(ads))
+	for id := range ads {
+		jobIDs = append(jobIDs, id)
+	}
+
+	slices.Sort(jobIDs)
+
+	var jobs []models.Unit
+
+	for _, id := range jobIDs {
+		ad := ads[id]
+
+		// Ignore jobs that never ran
+		startedAt := ad.timeAttr("JobStartDate")
+		if startedAt.IsZero() {
+			continue
+		}
+
+		// Start time of current execution of the job. Jobs can be evicted
+		// and restarted
+		currentStartedAt := ad.timeAttr("JobCurrentStartDate")
+		if currentStartedAt.IsZero() {
+			currentStartedAt = startedAt
+		}
+
+		// End time of the job. Removed jobs do not have CompletionDate
+		state := ad.intAttr("JobStatus")
+
+		var endedAt time.Time
+		if state == 3 || state == 4 {
+			if endedAt = ad.timeAttr("CompletionDate"); endedAt.IsZero() {
+				endedAt = ad.timeAttr("EnteredCurrentStatus")
+			}
+		}
+
+		// Ignore jobs that started after the current interval or finished
+		// before it
+		if currentStartedAt.After(end) || (!endedAt.IsZero() && endedAt.Before(start)) {
+			continue
+		}
+
+		// Get elapsed time of job in this interval in seconds
+		startMark, endMark := start, end
+		if currentStartedAt.After(start) {
+			startMark = currentStartedAt
+		}
+
+		if !endedAt.IsZero() && endedAt.Before(end) {
+			endMark = endedAt
+		}
+
+		elapsedSeconds := max(int64(endMark.Sub(startMark).Seconds()), 0)
+
+		// Allocated resources. Memory is in MiB and disk is in KiB
+		ncpus := ad.intAttr("RequestCpus")
+		ngpus := ad.intAttr("RequestGpus")
+		memMiB := ad.intAttr("RequestMemory")
+		diskKiB := ad.intAttr("RequestDisk")
+
+		// Get cpuSeconds and gpuSeconds of the current interval
+		cpuSeconds := ncpus * elapsedSeconds
+		gpuSeconds := ngpus * elapsedSeconds
+
+		// Get cpuMemSeconds and gpuMemSeconds of current interval in MB
+		var cpuMemSeconds, gpuMemSeconds int64
+		if memMiB > 0 {
+			cpuMemSeconds = memMiB * elapsedSeconds
+		} else {
+			cpuMemSeconds = elapsedSeconds
+		}
+
+		// Same as SLURM, use walltime as GPU mem time
+		if ngpus > 0 {
+			gpuMemSeconds = elapsedSeconds
+		}
+
+		// Allocation
+		allocation := models.Allocation{
+			"cpus": ncpus,
+			"mem":  memMiB * 1024 * 1024,
+			"gpus": ngpus,
+			"disk": diskKiB * 1024,
+		}
+
+		// Running jobs have RemoteHost and finished jobs have LastRemoteHost.
+		// They are of form slot1@host
+		remoteHost := ad.strAttr("RemoteHost")
+		if remoteHost == "" {
+			remoteHost = ad.strAttr("LastRemoteHost")
+		}
+
+		host := remoteHost
+		if _, after, found := strings.Cut(remoteHost, "@"); found {
+			host = after
+		}
+
+		// Tags
+		tags := models.Tag{
+			"jobid":       ad.jobID(),
+			"universe":    ad.intAttr("JobUniverse"),
+			"exit_code":   ad.strAttr("ExitCode"),
+			"remote_host": remoteHost,
+			"nodelistexp": host,
+			"iwd":         ad.strAttr("Iwd"),
+		}
+
+		// Initialise endedAt, endedAtTS
+		endedAtString := "N/A"
+
+		var endedAtTS int64 = 0
+
+		elapsed := end.Sub(currentStartedAt)
+
+		if !endedAt.IsZero() {
+			endedAtString = endedAt.In(loc).Format(base.DatetimezoneLayout)
+			endedAtTS = endedAt.UnixMilli()
+			elapsed = endedAt.Sub(currentStartedAt)
+		}
+
+		// Use batch name as job name when set
+		name := ad.strAttr("JobBatchName")
+		if name == "" {
+			name = filepath.Base(ad.strAttr("Cmd"))
+		}
+
+		createdAt := ad.timeAttr("QDate")
+
+		jobs = append(jobs, models.Unit{
+			ResourceManager: htcondorBatchScheduler,
+			UUID:            id,
+			Name:            name,
+			Project:         ad.strAttr("AcctGroup"),
+			User:            ad.strAttr("Owner"),
+			CreatedAt:       createdAt.In(loc).Format(base.DatetimezoneLayout),
+			StartedAt:       startedAt.In(loc).Format(base.DatetimezoneLayout),
+			EndedAt:         endedAtString,
+			CreatedAtTS:     createdAt.UnixMilli(),
+			StartedAtTS:     startedAt.UnixMilli(),
+			EndedAtTS:       endedAtTS,
+			Elapsed:         helper.FormatElapsed(elapsed),
+			State:           jobState(state),
+			Allocation:      allocation,
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(elapsedSeconds),
+				"alloc_cputime":    models.JSONFloat(cpuSeconds),
+				"alloc_cpumemtime": models.JSONFloat(cpuMemSeconds),
+				"alloc_gputime":    models.JSONFloat(gpuSeconds),
+				"alloc_gpumemtime": models.JSONFloat(gpuMemSeconds),
+			},
+			Tags: tags,
+		})
+	}
+
+	return jobs, nil
+}
+
+// parseCondorAssoc parses condor_q and condor_history outputs and returns
+// users and their accounting groups.
+func parseCondorAssoc(queuedOutput []byte, finishedOutput []byte) (map[string][]string, error) {
+	queuedAds, err := decodeJobAds(queuedOutput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode condor_q output: %w", err)
+	}
+
+	finishedAds, err := decodeJobAds(finishedOutput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode condor_history output: %w", err)
+	}
+
+	userProjects := make(map[string][]string)
+
+	for _, ad := range slices.Concat(queuedAds, finishedAds) {
+		user, project := ad.strAttr("Owner"), ad.strAttr("AcctGroup")
+
+		// Ignore jobs without accounting group and root user
+		if user == "" || project == "" || user == "root" {
+			continue
+		}
+
+		userProjects[user] = append(userProjects[user], project)
+	}
+
+	return userProjects, nil
+}
+
+// assocModels returns users and projects models from user projects map.
+func assocModels(assoc map[string][]string, currentTime string) ([]models.User, []models.Project) {
+	projectUserMap := make(map[string][]string)
+
+	var users []string
+
+	var projects []string
+
+	for user, userProjects := range assoc {
+		users = append(users, user)
+
+		for _, project := range userProjects {
+			projectUserMap[project] = append(projectUserMap[project], user)
+			projects = append(projects, project)
+		}
+	}
+
+	// Here we sort projects and users to get deterministic
+	// output as order in Go maps is undefined
+
+	// Sort and compact projects
+	slices.Sort(projects)
+	projects = slices.Compact(projects)
+
+	// Sort users
+	slices.Sort(users)
+
+	// Transform map into slice of projects
+	projectModels := make([]models.Project, len(projects))
+
+	for i := range projects {
+		projectUsers := projectUserMap[projects[i]]
+
+		// Sort users
+		slices.Sort(projectUsers)
+
+		var usersList models.List
+		for _, u := range slices.Compact(projectUsers) {
+			usersList = append(usersList, u)
+		}
+
+		// Make Association
+		projectModels[i] = models.Project{
+			Name:          projects[i],
+			Users:         usersList,
+			LastUpdatedAt: currentTime,
+		}
+	}
+
+	// Transform map into slice of users
+	userModels := make([]models.User, len(users))
+
+	for i := range users {
+		userProjects := slices.Clone(assoc[users[i]])
+
+		// Sort projects
+		slices.Sort(userProjects)
+
+		var projectsList models.List
+		for _, p := range slices.Compact(userProjects) {
+			projectsList = append(projectsList, p)
+		}
+
+		// Make Association
+		userModels[i] = models.User{
+			Name:          users[i],
+			Projects:      projectsList,
+			LastUpdatedAt: currentTime,
+		}
+	}
+
+	return userModels, projectModels
+}
+
+// jobState returns a human readable state of the job.
+func jobState(status int64) string {
+	if state, ok := condorStates[status]; ok {
+		return state
+	}
+
+	return strconv.FormatInt(status, 10)
+}
+
+// runCondorQCmd executes condor_q command and return output.
+func (s *htcondorScheduler) runCondorQCmd(ctx context.Context, attributes []string, constraint string) ([]byte, error) {
+	args := []string{"-allusers", "-json", "-attributes", strings.Join(attributes, ",")}
+	if constraint != "" {
+		args = append(args, "-constraint", constraint)
+	}
+
+	return s.runCmd(ctx, "condor_q", args)
+}
+
+// runCondorHistoryCmd executes condor_history command to get jobs completed
+// since given time and return output.
+func (s *htcondorScheduler) runCondorHistoryCmd(ctx context.Context, attributes []string, since time.Time) ([]byte, error) {
+	args := []string{
+		"-json", "-attributes", strings.Join(attributes, ","),
+		"-completedsince", strconv.FormatInt(since.Unix(), 10),
+	}
+
+	return s.runCmd(ctx, "condor_history", args)
+}
+
+// runCmd executes HTCondor command and return output.
+func (s *htcondorScheduler) runCmd(ctx context.Context, name string, args []string) ([]byte, error) {
+	cmdPath := filepath.Join(s.cluster.CLI.Path, name)
+
+	var env []string
+	for name, value := range s.cluster.CLI.EnvVars {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	return internal_osexec.ExecuteContext(ctx, cmdPath, args, env)
+}
+
+// Run preflight checks on provided config.
+func preflightChecks(s *htcondorScheduler) error {
+	return preflightsCLI(s)
+}
@@ -0,0 +1,46 @@
+package htcondor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCondorCmdOutput(t *testing.T) {
+	units, err := parseCondorCmdOutput([]byte(condorQCmdOutput), []byte(condorHistoryCmdOutput), start, end)
+	require.NoError(t, err)
+	require.Len(t, units, 3)
+	assert.Equal(t, expectedBatchJobs, units[:2])
+
+	// Job that is still in queue must be picked from history once it finishes
+	units, err = parseCondorCmdOutput([]byte(condorHistoryCmdOutput), []byte(condorHistoryCmdOutput), start, end)
+	require.NoError(t, err)
+	require.Len(t, units, 2)
+
+	// Empty outputs
+	units, err = parseCondorCmdOutput(nil, []byte("\n"), start, end)
+	require.NoError(t, err)
+	assert.Empty(t, units)
+
+	// Malformed output
+	_, err = parseCondorCmdOutput([]byte(`[{"ClusterId":`), nil, start, end)
+	require.Error(t, err)
+}
+
+func TestParseCondorAssoc(t *testing.T) {
+	assoc, err := parseCondorAssoc([]byte(condorQCmdOutput), []byte(condorHistoryCmdOutput))
+	require.NoError(t, err)
+
+	users, projects := assocModels(assoc, "2024-10-15T16:15:00")
+	assert.Equal(t, expectedUsers, users)
+	assert.Equal(t, expectedProjects, projects)
+}
+
+func TestJobState(t *testing.T) {
+	assert.Equal(t, "IDLE", jobState(1))
+	assert.Equal(t, "RUNNING", jobState(2))
+	assert.Equal(t, "REMOVED", jobState(3))
+	assert.Equal(t, "COMPLETED", jobState(4))
+	assert.Equal(t, "10", jobState(10))
+}
@@ -0,0 +1,197 @@
+// Package htcondor implements the fetcher interface to fetch compute units from
+// HTCondor resource manager
+package htcondor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+)
+
+// Fetch modes.
+const (
+	cliMode = "cli"
+)
+
+// assocLookback is the period of job history that is used to build users
+// and projects associations during the first update.
+const assocLookback = 30 * 24 * time.Hour
+
+// htcondorScheduler is the struct containing the configuration of a given HTCondor pool.
+type htcondorScheduler struct {
+	logger           *slog.Logger
+	cluster          models.Cluster
+	fetchMode        string              // Whether to fetch from REST API or CLI commands
+	userProjects     map[string][]string // Users and their projects seen so far in the jobs
+	lastAssocUpdated time.Time
+}
+
+const htcondorBatchScheduler = "htcondor"
+
+var assocLock = sync.RWMutex{}
+
+func init() {
+	// Register batch scheduler
+	resource.Register(htcondorBatchScheduler, New)
+}
+
+// New returns a new htcondorScheduler that returns batch job stats.
+func New(cluster models.Cluster, logger *slog.Logger) (resource.Fetcher, error) {
+	// Make htcondorScheduler configs from clusters
+	htcondorScheduler := htcondorScheduler{
+		logger:       logger,
+		cluster:      cluster,
+		userProjects: make(map[string][]string),
+	}
+
+	if err := preflightChecks(&htcondorScheduler); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Batch jobs from HTCondor pool will be fetched", "id", cluster.ID)
+
+	return &htcondorScheduler, nil
+}
+
+// FetchUnits fetches jobs from HTCondor.
+func (s *htcondorScheduler) FetchUnits(
+	ctx context.Context,
+	start time.Time,
+	end time.Time,
+) ([]models.ClusterUnits, error) {
+	var jobs []models.Unit
+
+	var err error
+	if s.fetchMode == cliMode {
+		if jobs, err = s.fetchFromCondor(ctx, start, end); err != nil {
+			s.logger.Error("Failed to execute HTCondor commands", "cluster_id", s.cluster.ID, "err", err)
+
+			return nil, err
+		}
+
+		return []models.ClusterUnits{{Cluster: s.cluster, Units: jobs}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown fetch mode for compute units HTCondor cluster %s", s.cluster.ID)
+}
+
+// FetchUsersProjects fetches current HTCondor users and accounting groups.
+func (s *htcondorScheduler) FetchUsersProjects(
+	ctx context.Context,
+	current time.Time,
+) ([]models.ClusterUsers, []models.ClusterProjects, error) {
+	var users []models.User
+
+	var projects []models.Project
+
+	var err error
+	if s.fetchMode == cliMode {
+		if users, projects, err = s.fetchAssocFromCondor(ctx, current); err != nil {
+			s.logger.Error("Failed to execute HTCondor commands", "cluster_id", s.cluster.ID, "err", err)
+
+			return nil, nil, err
+		}
+
+		return []models.ClusterUsers{
+			{Cluster: s.cluster, Users: users},
+		}, []models.ClusterProjects{
+			{Cluster: s.cluster, Projects: projects},
+		}, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown fetch mode for projects for HTCondor cluster %s", s.cluster.ID)
+}
+
+// Get jobs from HTCondor condor_q and condor_history commands.
+func (s *htcondorScheduler) fetchFromCondor(ctx context.Context, start time.Time, end time.Time) ([]models.Unit, error) {
+	// Get currently running jobs
+	runningJobs, err := s.runCondorQCmd(ctx, jobAttributes, runningConstraint)
+	if err != nil {
+		s.logger.Error("Failed to run condor_q command", "cluster_id", s.cluster.ID, "err", err)
+
+		return []models.Unit{}, err
+	}
+
+	// Get jobs that have finished since start of the interval
+	finishedJobs, err := s.runCondorHistoryCmd(ctx, jobAttributes, start)
+	if err != nil {
+		s.logger.Error("Failed to run condor_history command", "cluster_id", s.cluster.ID, "err", err)
+
+		return []models.Unit{}, err
+	}
+
+	// Parse outputs and create units slice
+	jobs, err := parseCondorCmdOutput(runningJobs, finishedJobs, start, end)
+	if err != nil {
+		s.logger.Error("Failed to parse HTCondor commands output", "cluster_id", s.cluster.ID, "err", err)
+
+		return []models.Unit{}, err
+	}
+
+	s.logger.Info("HTCondor jobs fetched", "cluster_id", s.cluster.ID, "start", start, "end", end, "num_jobs", len(jobs))
+
+	return jobs, nil
+}
+
+// Get user project association from HTCondor condor_q and condor_history commands.
+func (s *htcondorScheduler) fetchAssocFromCondor(
+	ctx context.Context,
+	current time.Time,
+) ([]models.User, []models.Project, error) {
+	// Get current time string
+	currentTime := current.Format(base.DatetimeLayout)
+
+	// Get all jobs in the queue
+	queuedJobs, err := s.runCondorQCmd(ctx, assocAttributes, "")
+	if err != nil {
+		s.logger.Error("Failed to run condor_q command", "cluster_id", s.cluster.ID, "err", err)
+
+		return nil, nil, err
+	}
+
+	// Get jobs finished since last update. During first update, look back
+	// for a reasonable period to get associations of users without queued jobs
+	since := s.lastAssocUpdated
+	if since.IsZero() {
+		since = current.Add(-assocLookback)
+	}
+
+	finishedJobs, err := s.runCondorHistoryCmd(ctx, assocAttributes, since)
+	if err != nil {
+		s.logger.Error("Failed to run condor_history command", "cluster_id", s.cluster.ID, "err", err)
+
+		return nil, nil, err
+	}
+
+	// Parse outputs to get user project associations
+	userProjects, err := parseCondorAssoc(queuedJobs, finishedJobs)
+	if err != nil {
+		s.logger.Error("Failed to parse HTCondor commands output", "cluster_id", s.cluster.ID, "err", err)
+
+		return nil, nil, err
+	}
+
+	// HTCondor does not maintain a database of accounting groups. So we merge
+	// the current associations with the ones we have seen so far.
+	assocLock.Lock()
+	for user, projects := range userProjects {
+		projects = append(s.userProjects[user], projects...)
+		slices.Sort(projects)
+		s.userProjects[user] = slices.Compact(projects)
+	}
+
+	users, projects := assocModels(s.userProjects, currentTime)
+	s.lastAssocUpdated = current
+	assocLock.Unlock()
+
+	s.logger.Info("HTCondor user project data fetched", "cluster_id", s.cluster.ID, "num_users", len(users), "num_projects", len(projects))
+
+	return users, projects, nil
+}
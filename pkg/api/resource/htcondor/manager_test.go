@@ -0,0 +1,341 @@
+package htcondor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	start, _         = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:00:00+0200")
+	end, _           = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:15:00+0200")
+	current, _       = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:15:00+0200")
+	condorQCmdOutput = `[
+{
+  "AcctGroup": "grp1",
+  "ClusterId": 100,
+  "Cmd": "/home/usr1/train.sh",
+  "GlobalJobId": "submit-0#100.0#1728999000",
+  "Iwd": "/home/usr1",
+  "JobBatchName": "train",
+  "JobCurrentStartDate": 1728999600,
+  "JobStartDate": 1728999600,
+  "JobStatus": 2,
+  "JobUniverse": 5,
+  "Owner": "usr1",
+  "ProcId": 0,
+  "QDate": 1728999000,
+  "RemoteHost": "slot1_1@node1",
+  "RequestCpus": 4,
+  "RequestDisk": 1048576,
+  "RequestGpus": 1,
+  "RequestMemory": 8192
+}
+,
+{
+  "AcctGroup": "grp2",
+  "ClusterId": 102,
+  "Cmd": "/home/usr3/run.sh",
+  "GlobalJobId": "submit-0#102.0#1729001000",
+  "Iwd": "/home/usr3",
+  "JobStatus": 1,
+  "JobUniverse": 5,
+  "Owner": "usr3",
+  "ProcId": 0,
+  "QDate": 1729001000,
+  "RequestCpus": 1,
+  "RequestDisk": 1024,
+  "RequestMemory": "ifthenelse(MemoryUsage =!= undefined,MemoryUsage,(ImageSize + 1023) / 1024)"
+}
+]`
+	condorHistoryCmdOutput = `[
+{
+  "AcctGroup": "grp2",
+  "ClusterId": 101,
+  "Cmd": "/bin/sleep",
+  "CompletionDate": 1729001300,
+  "EnteredCurrentStatus": 1729001300,
+  "ExitCode": 0,
+  "GlobalJobId": "submit-0#101.0#1729000700",
+  "Iwd": "/home/usr2",
+  "JobCurrentStartDate": 1729001000,
+  "JobStartDate": 1729001000,
+  "JobStatus": 4,
+  "JobUniverse": 5,
+  "LastRemoteHost": "slot1@node2",
+  "Owner": "usr2",
+  "ProcId": 0,
+  "QDate": 1729000700,
+  "RequestCpus": 1,
+  "RequestDisk": 1024,
+  "RequestMemory": 2048
+}
+,
+{
+  "AcctGroup": "grp3",
+  "ClusterId": 99,
+  "Cmd": "/home/usr1/long.sh",
+  "CompletionDate": 0,
+  "EnteredCurrentStatus": 1729001000,
+  "GlobalJobId": "submit-0#99.3#1729000000",
+  "JobCurrentStartDate": 1729000900,
+  "JobStartDate": 1729000100,
+  "JobStatus": 3,
+  "JobUniverse": 5,
+  "LastRemoteHost": "slot2@node3",
+  "Owner": "usr1",
+  "ProcId": 3,
+  "QDate": 1729000000,
+  "RequestCpus": 2,
+  "RequestMemory": 1024
+}
+,
+{
+  "AcctGroup": "grp1",
+  "ClusterId": 98,
+  "Cmd": "/home/usr1/old.sh",
+  "CompletionDate": 1729000000,
+  "GlobalJobId": "submit-0#98.0#1728990000",
+  "JobStartDate": 1728995000,
+  "JobStatus": 4,
+  "Owner": "usr1",
+  "ProcId": 0,
+  "QDate": 1728990000,
+  "RequestCpus": 1
+}
+,
+{
+  "ClusterId": 97,
+  "GlobalJobId": "submit-0#97.0#1728990000",
+  "JobStatus": 4,
+  "Owner": "root",
+  "ProcId": 0
+}
+]`
+	expectedBatchJobs = []models.Unit{
+		{
+			ResourceManager: "htcondor",
+			UUID:            "submit-0#100.0#1728999000",
+			Name:            "train",
+			Project:         "grp1",
+			User:            "usr1",
+			CreatedAt:       "2024-10-15T15:30:00+0200",
+			StartedAt:       "2024-10-15T15:40:00+0200",
+			EndedAt:         "N/A",
+			CreatedAtTS:     1728999000000,
+			StartedAtTS:     1728999600000,
+			EndedAtTS:       0,
+			Elapsed:         "00:35:00",
+			State:           "RUNNING",
+			Allocation: models.Generic{
+				"cpus": int64(4),
+				"mem":  int64(8589934592),
+				"gpus": int64(1),
+				"disk": int64(1073741824),
+			},
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(900),
+				"alloc_cputime":    models.JSONFloat(3600),
+				"alloc_cpumemtime": models.JSONFloat(7372800),
+				"alloc_gputime":    models.JSONFloat(900),
+				"alloc_gpumemtime": models.JSONFloat(900),
+			},
+			Tags: models.Generic{
+				"jobid":       "100.0",
+				"universe":    int64(5),
+				"exit_code":   "",
+				"remote_host": "slot1_1@node1",
+				"nodelistexp": "node1",
+				"iwd":         "/home/usr1",
+			},
+		},
+		{
+			ResourceManager: "htcondor",
+			UUID:            "submit-0#101.0#1729000700",
+			Name:            "sleep",
+			Project:         "grp2",
+			User:            "usr2",
+			CreatedAt:       "2024-10-15T15:58:20+0200",
+			StartedAt:       "2024-10-15T16:03:20+0200",
+			EndedAt:         "2024-10-15T16:08:20+0200",
+			CreatedAtTS:     1729000700000,
+			StartedAtTS:     1729001000000,
+			EndedAtTS:       1729001300000,
+			Elapsed:         "00:05:00",
+			State:           "COMPLETED",
+			Allocation: models.Generic{
+				"cpus": int64(1),
+				"mem":  int64(2147483648),
+				"gpus": int64(0),
+				"disk": int64(1048576),
+			},
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(300),
+				"alloc_cputime":    models.JSONFloat(300),
+				"alloc_cpumemtime": models.JSONFloat(614400),
+				"alloc_gputime":    models.JSONFloat(0),
+				"alloc_gpumemtime": models.JSONFloat(0),
+			},
+			Tags: models.Generic{
+				"jobid":       "101.0",
+				"universe":    int64(5),
+				"exit_code":   "0",
+				"remote_host": "slot1@node2",
+				"nodelistexp": "node2",
+				"iwd":         "/home/usr2",
+			},
+		},
+	}
+	expectedUsers = []models.User{
+		{
+			Name:          "usr1",
+			Projects:      models.List{"grp1", "grp3"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+		{
+			Name:          "usr2",
+			Projects:      models.List{"grp2"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+		{
+			Name:          "usr3",
+			Projects:      models.List{"grp2"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+	}
+	expectedProjects = []models.Project{
+		{
+			Name:          "grp1",
+			Users:         models.List{"usr1"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+		{
+			Name:          "grp2",
+			Users:         models.List{"usr2", "usr3"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+		{
+			Name:          "grp3",
+			Users:         models.List{"usr1"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+	}
+)
+
+func mockCondorCmds(t *testing.T, condorQOutput, condorHistoryOutput string) string {
+	t.Helper()
+
+	// Write condor_q and condor_history executables
+	tmpDir := t.TempDir()
+
+	for name, output := range map[string]string{"condor_q": condorQOutput, "condor_history": condorHistoryOutput} {
+		script := fmt.Sprintf(`#!/bin/bash
+printf '%%s' '%s'`, output)
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(script), 0o700) // #nosec
+	}
+
+	return tmpDir
+}
+
+func TestHTCondorFetcher(t *testing.T) {
+	binDir := mockCondorCmds(t, condorQCmdOutput, condorHistoryCmdOutput)
+
+	// mock config
+	clusters := []models.Cluster{
+		{
+			ID:      "condor-0",
+			Manager: "htcondor",
+			CLI:     models.CLIConfig{Path: binDir},
+		},
+		{
+			ID:      "condor-1",
+			Manager: "htcondor",
+			CLI:     models.CLIConfig{Path: binDir, EnvVars: map[string]string{"CONDOR_CONFIG": "/etc/condor/condor_config"}},
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, cluster := range clusters {
+		condor, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+
+		units, err := condor.FetchUnits(ctx, start, end)
+		require.NoError(t, err)
+		require.Len(t, units[0].Units, 3)
+		assert.Equal(t, expectedBatchJobs, units[0].Units[:2])
+
+		// Removed job must be accounted until it was removed using its
+		// last execution start time
+		assert.Equal(t, "submit-0#99.3#1729000000", units[0].Units[2].UUID)
+		assert.Equal(t, "REMOVED", units[0].Units[2].State)
+		assert.Equal(t, "99.3", units[0].Units[2].Tags["jobid"])
+		assert.InEpsilon(t, 100, float64(units[0].Units[2].TotalTime["walltime"]), 0)
+
+		users, projects, err := condor.FetchUsersProjects(ctx, current)
+		require.NoError(t, err)
+		assert.Equal(t, expectedUsers, users[0].Users)
+		assert.Equal(t, expectedProjects, projects[0].Projects)
+	}
+}
+
+func TestHTCondorFetcherNoJobs(t *testing.T) {
+	// condor_q and condor_history output nothing when there are no jobs
+	binDir := mockCondorCmds(t, "", "")
+
+	cluster := models.Cluster{
+		ID:      "condor-0",
+		Manager: "htcondor",
+		CLI:     models.CLIConfig{Path: binDir},
+	}
+
+	ctx := context.Background()
+
+	condor, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	units, err := condor.FetchUnits(ctx, start, end)
+	require.NoError(t, err)
+	assert.Empty(t, units[0].Units)
+
+	users, projects, err := condor.FetchUsersProjects(ctx, current)
+	require.NoError(t, err)
+	assert.Empty(t, users[0].Users)
+	assert.Empty(t, projects[0].Projects)
+}
+
+func TestHTCondorFetcherFail(t *testing.T) {
+	binDir := mockCondorCmds(t, "malformed", "")
+
+	cluster := models.Cluster{
+		ID:      "condor-0",
+		Manager: "htcondor",
+		CLI:     models.CLIConfig{Path: binDir},
+	}
+
+	ctx := context.Background()
+
+	condor, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, err = condor.FetchUnits(ctx, start, end)
+	require.Error(t, err)
+
+	_, _, err = condor.FetchUsersProjects(ctx, current)
+	require.Error(t, err)
+
+	// Non existent bin dir
+	cluster.CLI.Path = filepath.Join(binDir, "nonexistent")
+	_, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Error(t, err)
+}
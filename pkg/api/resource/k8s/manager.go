@@ -0,0 +1,187 @@
+// Package k8s implements the fetcher interface to fetch pods from Kubernetes
+// resource manager
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+	config_util "github.com/prometheus/common/config"
+)
+
+const k8sPodManager = "kubernetes"
+
+var (
+	defaultUsernameAnnotations = []string{"ceems.io/created-by"}
+	defaultGPUResourceNames    = []string{"nvidia.com/gpu", "amd.com/gpu"}
+	defaultIgnoreNamespaces    = []string{"kube-system", "kube-public", "kube-node-lease"}
+)
+
+// k8sManager is the struct containing the configuration of a given Kubernetes cluster.
+type k8sManager struct {
+	logger  *slog.Logger
+	cluster models.Cluster
+	apiURL  *url.URL
+	client  *http.Client
+	config  *k8sConfig
+}
+
+type k8sConfig struct {
+	UsernameAnnotations []string `yaml:"username_annotations"`
+	GPUResourceNames    []string `yaml:"gpu_resource_names"`
+	IgnoreNamespaces    []string `yaml:"ignore_namespaces"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *k8sConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = k8sConfig{
+		UsernameAnnotations: defaultUsernameAnnotations,
+		GPUResourceNames:    defaultGPUResourceNames,
+		IgnoreNamespaces:    defaultIgnoreNamespaces,
+	}
+
+	type plain k8sConfig
+
+	return unmarshal((*plain)(c))
+}
+
+func init() {
+	// Register Kubernetes pod manager
+	resource.Register(k8sPodManager, New)
+}
+
+// New returns a new k8sManager that returns pods.
+func New(cluster models.Cluster, logger *slog.Logger) (resource.Fetcher, error) {
+	k8sManager := &k8sManager{
+		logger:  logger,
+		cluster: cluster,
+		config: &k8sConfig{
+			UsernameAnnotations: defaultUsernameAnnotations,
+			GPUResourceNames:    defaultGPUResourceNames,
+			IgnoreNamespaces:    defaultIgnoreNamespaces,
+		},
+	}
+
+	var err error
+
+	// Ensure we have a valid API server URL
+	if cluster.Web.URL == "" {
+		logger.Error("Missing API server URL for Kubernetes cluster", "id", cluster.ID)
+
+		return nil, errors.New("missing api server url for kubernetes cluster")
+	}
+
+	if k8sManager.apiURL, err = url.Parse(cluster.Web.URL); err != nil {
+		logger.Error("Failed to parse API server URL for Kubernetes cluster", "id", cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	// Make a HTTP client for Kubernetes from client config
+	if k8sManager.client, err = config_util.NewClientFromConfig(cluster.Web.HTTPClientConfig, "kubernetes"); err != nil {
+		logger.Error("Failed to create HTTP client for Kubernetes cluster", "id", cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	// Decode extra_config when provided
+	if !cluster.Extra.IsZero() {
+		if err := cluster.Extra.Decode(k8sManager.config); err != nil {
+			logger.Error("Failed to decode extra_config for Kubernetes cluster", "id", cluster.ID, "err", err)
+
+			return nil, err
+		}
+	}
+
+	logger.Info("Pods from Kubernetes cluster will be fetched", "id", cluster.ID)
+
+	return k8sManager, nil
+}
+
+// FetchUnits fetches pods from Kubernetes.
+func (k *k8sManager) FetchUnits(
+	ctx context.Context,
+	start time.Time,
+	end time.Time,
+) ([]models.ClusterUnits, error) {
+	pods, err := k.activePods(ctx, start, end)
+	if err != nil {
+		k.logger.Error("Failed to fetch pods from Kubernetes cluster", "cluster_id", k.cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	return []models.ClusterUnits{{Cluster: k.cluster, Units: pods}}, nil
+}
+
+// FetchUsersProjects fetches current Kubernetes users and namespaces.
+func (k *k8sManager) FetchUsersProjects(
+	ctx context.Context,
+	current time.Time,
+) ([]models.ClusterUsers, []models.ClusterProjects, error) {
+	users, projects, err := k.usersProjectsAssoc(ctx, current)
+	if err != nil {
+		k.logger.Error("Failed to fetch users and namespaces from Kubernetes cluster", "cluster_id", k.cluster.ID, "err", err)
+
+		return nil, nil, err
+	}
+
+	return []models.ClusterUsers{
+		{Cluster: k.cluster, Users: users},
+	}, []models.ClusterProjects{
+		{Cluster: k.cluster, Projects: projects},
+	}, nil
+}
+
+// pods endpoint.
+func (k *k8sManager) pods() *url.URL {
+	return k.apiURL.JoinPath("/api/v1/pods")
+}
+
+// namespaces endpoint.
+func (k *k8sManager) namespaces() *url.URL {
+	return k.apiURL.JoinPath("/api/v1/namespaces")
+}
+
+// role bindings endpoint.
+func (k *k8sManager) roleBindings() *url.URL {
+	return k.apiURL.JoinPath("/apis/rbac.authorization.k8s.io/v1/rolebindings")
+}
+
+// fetchPods fetches all pods across namespaces.
+func (k *k8sManager) fetchPods(ctx context.Context) ([]Pod, error) {
+	pods, err := listObjects(ctx, k.client, k.pods(), func(l PodList) []Pod { return l.Items })
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete request to fetch pods for kubernetes cluster: %w", err)
+	}
+
+	return pods, nil
+}
+
+// fetchNamespaces fetches all namespaces.
+func (k *k8sManager) fetchNamespaces(ctx context.Context) ([]Namespace, error) {
+	namespaces, err := listObjects(ctx, k.client, k.namespaces(), func(l NamespaceList) []Namespace { return l.Items })
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete request to fetch namespaces for kubernetes cluster: %w", err)
+	}
+
+	return namespaces, nil
+}
+
+// fetchRoleBindings fetches all role bindings across namespaces.
+func (k *k8sManager) fetchRoleBindings(ctx context.Context) ([]RoleBinding, error) {
+	bindings, err := listObjects(ctx, k.client, k.roleBindings(), func(l RoleBindingList) []RoleBinding { return l.Items })
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete request to fetch role bindings for kubernetes cluster: %w", err)
+	}
+
+	return bindings, nil
+}
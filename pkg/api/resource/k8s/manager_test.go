@@ -0,0 +1,372 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const apiToken = "k8stokensecret"
+
+var (
+	start, _   = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:15:00+0200")
+	end, _     = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:45:00+0200")
+	current, _ = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:45:00+0200")
+
+	expectedUnits = []models.Unit{
+		{
+			ResourceManager: "kubernetes",
+			UUID:            "8f6e1c2a-4d1b-4a43-9d3e-2a6b7e1f0c11",
+			Name:            "notebook-alice",
+			Project:         "ml-team",
+			User:            "alice",
+			CreatedAt:       "2024-10-15T15:00:00+0200",
+			StartedAt:       "2024-10-15T15:00:05+0200",
+			EndedAt:         "N/A",
+			CreatedAtTS:     1728997200000,
+			StartedAtTS:     1728997205000,
+			EndedAtTS:       0,
+			Elapsed:         "01:44:55",
+			State:           "Running",
+			Allocation: models.Generic{
+				"cpus":     2.5,
+				"mem":      int64(9126805504),
+				"gpus":     int64(1),
+				"requests": map[string]float64{"cpu": 2.5, "memory": 9126805504, "nvidia.com/gpu": 1},
+				"limits":   map[string]float64{"cpu": 4, "memory": 8589934592, "nvidia.com/gpu": 1},
+			},
+			TotalTime: models.MetricMap{
+				"walltime":         1800,
+				"alloc_cputime":    4500,
+				"alloc_cpumemtime": 1.56672e+07,
+				"alloc_gputime":    1800,
+				"alloc_gpumemtime": 1800,
+			},
+			Tags: models.Generic{
+				"node":            "gpu-node-1",
+				"qos_class":       "Burstable",
+				"service_account": "default",
+				"label_app":       "jupyter",
+			},
+		},
+		{
+			ResourceManager: "kubernetes",
+			UUID:            "1b3c4d5e-6f70-4a81-92a3-b4c5d6e7f809",
+			Name:            "train-1",
+			Project:         "ml-team",
+			User:            "bob",
+			CreatedAt:       "2024-10-15T16:00:00+0200",
+			StartedAt:       "2024-10-15T16:00:30+0200",
+			EndedAt:         "2024-10-15T16:30:00+0200",
+			CreatedAtTS:     1729000800000,
+			StartedAtTS:     1729000830000,
+			EndedAtTS:       1729002600000,
+			Elapsed:         "00:29:30",
+			State:           "Succeeded",
+			Allocation: models.Generic{
+				"cpus":     float64(8),
+				"mem":      int64(34359738368),
+				"gpus":     int64(2),
+				"requests": map[string]float64{"cpu": 8, "memory": 34359738368, "nvidia.com/gpu": 2},
+				"limits":   map[string]float64{"nvidia.com/gpu": 2},
+			},
+			TotalTime: models.MetricMap{
+				"walltime":         900,
+				"alloc_cputime":    7200,
+				"alloc_cpumemtime": 2.94912e+07,
+				"alloc_gputime":    1800,
+				"alloc_gpumemtime": 900,
+			},
+			Tags: models.Generic{
+				"node":            "gpu-node-2",
+				"qos_class":       "Burstable",
+				"service_account": "trainer",
+				"label_job-name":  "train",
+			},
+		},
+		{
+			ResourceManager: "kubernetes",
+			UUID:            "3f4e5d6c-7b8a-4999-8a7b-6c5d4e3f2a10",
+			Name:            "etl-1",
+			Project:         "data-team",
+			User:            "carol",
+			CreatedAt:       "2024-10-15T16:20:00+0200",
+			StartedAt:       "2024-10-15T16:25:00+0200",
+			EndedAt:         "N/A",
+			CreatedAtTS:     1729002000000,
+			StartedAtTS:     1729002300000,
+			EndedAtTS:       0,
+			Elapsed:         "00:20:00",
+			State:           "Running",
+			Allocation: models.Generic{
+				"cpus":     1.5,
+				"mem":      int64(2000000000),
+				"gpus":     int64(0),
+				"requests": map[string]float64{},
+				"limits":   map[string]float64{"cpu": 1.5, "memory": 2e+09},
+			},
+			TotalTime: models.MetricMap{
+				"walltime":         1200,
+				"alloc_cputime":    1800,
+				"alloc_cpumemtime": 2.288818359375e+06,
+				"alloc_gputime":    0,
+				"alloc_gpumemtime": 0,
+			},
+			Tags: models.Generic{
+				"node":            "cpu-node-2",
+				"qos_class":       "Guaranteed",
+				"service_account": "default",
+			},
+		},
+	}
+	expectedUsers = []models.User{
+		{Name: "alice", Projects: models.List{"data-team", "ml-team"}, LastUpdatedAt: "2024-10-15T16:45:00+0200"},
+		{Name: "bob", Projects: models.List{"ml-team"}, LastUpdatedAt: "2024-10-15T16:45:00+0200"},
+		{Name: "carol", Projects: models.List{"data-team"}, LastUpdatedAt: "2024-10-15T16:45:00+0200"},
+	}
+	expectedProjects = []models.Project{
+		{UID: "b1c2d3e4-f506-4718-a92b-c3d4e5f60718", Name: "data-team", Users: models.List{"alice", "carol"}, LastUpdatedAt: "2024-10-15T16:45:00+0200"},
+		{UID: "c2d3e4f5-0617-4829-b03c-d4e5f6071829", Name: "default", LastUpdatedAt: "2024-10-15T16:45:00+0200"},
+		{UID: "a0b1c2d3-e4f5-4607-8819-b2c3d4e5f607", Name: "ml-team", Users: models.List{"alice", "bob"}, LastUpdatedAt: "2024-10-15T16:45:00+0200"},
+	}
+)
+
+func mockK8sAPIServer() *httptest.Server {
+	// Start test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+apiToken {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		var fileName string
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/api/v1/pods"):
+			// Return pods in two pages
+			if r.URL.Query().Get("continue") == "" {
+				fileName = "pods-0"
+			} else {
+				fileName = "pods-1"
+			}
+		case strings.HasSuffix(r.URL.Path, "/api/v1/namespaces"):
+			fileName = "namespaces"
+		case strings.HasSuffix(r.URL.Path, "/rolebindings"):
+			fileName = "rolebindings"
+		default:
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if data, err := os.ReadFile(fmt.Sprintf("../../testdata/k8s/%s.json", fileName)); err == nil {
+			w.Write(data)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	return server
+}
+
+func mockCluster(url string, extraConfig string) (models.Cluster, error) {
+	var extra yaml.Node
+
+	if extraConfig != "" {
+		if err := yaml.Unmarshal([]byte(extraConfig), &extra); err != nil {
+			return models.Cluster{}, err
+		}
+	}
+
+	cluster := models.Cluster{
+		ID:      "k8s-0",
+		Manager: "kubernetes",
+		Extra:   extra,
+	}
+	cluster.Web.URL = url
+	cluster.Web.HTTPClientConfig.BearerToken = config_util.Secret(apiToken)
+
+	return cluster, nil
+}
+
+func TestK8sFetcher(t *testing.T) {
+	// Setup mock API server
+	server := mockK8sAPIServer()
+	defer server.Close()
+
+	cluster, err := mockCluster(server.URL, "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	k8s, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	units, err := k8s.FetchUnits(ctx, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, expectedUnits, units[0].Units)
+
+	users, projects, err := k8s.FetchUsersProjects(ctx, current)
+	require.NoError(t, err)
+	assert.Equal(t, expectedUsers, users[0].Users)
+	assert.Equal(t, expectedProjects, projects[0].Projects)
+}
+
+func TestK8sFetcherExtraConfig(t *testing.T) {
+	// Setup mock API server
+	server := mockK8sAPIServer()
+	defer server.Close()
+
+	cluster, err := mockCluster(server.URL, `
+---
+username_annotations:
+  - example.com/owner
+ignore_namespaces:
+  - ml-team`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	k8s, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// Only pods of data-team and kube-system must be returned and users must be
+	// empty as annotation is not found
+	units, err := k8s.FetchUnits(ctx, start, end)
+	require.NoError(t, err)
+	require.Len(t, units[0].Units, 2)
+
+	for _, unit := range units[0].Units {
+		assert.NotEqual(t, "ml-team", unit.Project)
+		assert.Empty(t, unit.User)
+	}
+
+	// GPU resource names must be defaults
+	assert.Equal(t, defaultGPUResourceNames, k8s.(*k8sManager).config.GPUResourceNames)
+}
+
+func TestK8sFetcherFail(t *testing.T) {
+	// Setup mock API server
+	server := mockK8sAPIServer()
+
+	// Cluster with wrong token
+	cluster, err := mockCluster(server.URL, "")
+	require.NoError(t, err)
+
+	cluster.Web.HTTPClientConfig.BearerToken = config_util.Secret("wrongtoken")
+
+	ctx := context.Background()
+
+	k8s, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, err = k8s.FetchUnits(ctx, start, end)
+	require.Error(t, err)
+
+	// Stop test server to simulate when API server is offline
+	server.Close()
+
+	cluster.Web.HTTPClientConfig.BearerToken = config_util.Secret(apiToken)
+
+	k8s, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, _, err = k8s.FetchUsersProjects(ctx, current)
+	require.Error(t, err)
+
+	// Missing API server URL
+	cluster.Web.URL = ""
+	_, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Error(t, err)
+}
+
+func TestParseQuantity(t *testing.T) {
+	for _, test := range []struct {
+		quantity string
+		expected float64
+		err      bool
+	}{
+		{"2", 2, false},
+		{"500m", 0.5, false},
+		{"1.5", 1.5, false},
+		{"2Gi", 2 * (1 << 30), false},
+		{"512Mi", 512 * (1 << 20), false},
+		{"2G", 2e9, false},
+		{"1e3", 1000, false},
+		{"", 0, true},
+		{"2Xi", 0, true},
+	} {
+		got, err := parseQuantity(test.quantity)
+		if test.err {
+			require.Error(t, err, test.quantity)
+		} else {
+			require.NoError(t, err, test.quantity)
+			assert.InDelta(t, test.expected, got, 1e-9, test.quantity)
+		}
+	}
+}
+
+func TestPodEndTime(t *testing.T) {
+	finishedAt := time.Date(2024, 10, 15, 16, 30, 0, 0, time.UTC)
+	deletedAt := finishedAt.Add(time.Minute)
+	transitionAt := finishedAt.Add(2 * time.Minute)
+
+	for _, test := range []struct {
+		name     string
+		pod      Pod
+		expected time.Time
+	}{
+		{
+			name:     "running pod",
+			pod:      Pod{Status: PodStatus{Phase: "Running"}},
+			expected: time.Time{},
+		},
+		{
+			name: "terminated containers",
+			pod: Pod{Status: PodStatus{
+				Phase:             podSucceeded,
+				ContainerStatuses: []ContainerStatus{{State: ContainerState{Terminated: &ContainerStateTerminated{FinishedAt: finishedAt}}}},
+				Conditions:        []PodCondition{{Type: "Ready", LastTransitionTime: transitionAt}},
+			}},
+			expected: finishedAt,
+		},
+		{
+			name: "deletion timestamp",
+			pod: Pod{
+				Metadata: ObjectMeta{DeletionTimestamp: &deletedAt},
+				Status:   PodStatus{Phase: podFailed, Conditions: []PodCondition{{Type: "Ready", LastTransitionTime: transitionAt}}},
+			},
+			expected: deletedAt,
+		},
+		{
+			name: "pod conditions",
+			pod: Pod{Status: PodStatus{
+				Phase: podFailed,
+				Conditions: []PodCondition{
+					{Type: "PodScheduled", LastTransitionTime: finishedAt},
+					{Type: "Ready", LastTransitionTime: transitionAt},
+				},
+			}},
+			expected: transitionAt,
+		},
+	} {
+		assert.Equal(t, test.expected, podEndTime(test.pod), test.name)
+	}
+}
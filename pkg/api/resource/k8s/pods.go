@@ -0,0 +1,232 @@
+package k8s
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/helper"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Pod phases.
+const (
+	podSucceeded = "Succeeded"
+	podFailed    = "Failed"
+)
+
+// activePods returns the pods that were running during the interval between
+// start and end as compute units.
+func (k *k8sManager) activePods(ctx context.Context, start time.Time, end time.Time) ([]models.Unit, error) {
+	pods, err := k.fetchPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get current time location
+	loc := end.Location()
+
+	var units []models.Unit
+
+	for _, pod := range pods {
+		// Ignore pods in ignored namespaces
+		if slices.Contains(k.config.IgnoreNamespaces, pod.Metadata.Namespace) {
+			continue
+		}
+
+		// Ignore pods that have not been scheduled yet
+		if pod.Status.StartTime == nil {
+			continue
+		}
+
+		createdAt := pod.Metadata.CreationTimestamp.In(loc)
+		startedAt := pod.Status.StartTime.In(loc)
+		endedAt := podEndTime(pod)
+
+		// Ignore pods that started after the current interval or finished
+		// before it
+		if startedAt.After(end) || (!endedAt.IsZero() && endedAt.Before(start)) {
+			continue
+		}
+
+		// Initialise endedAt, endedAtTS
+		endedAtString := "N/A"
+
+		var endedAtTS int64 = 0
+
+		// Get boundaries of the pod's active time within this interval
+		startMark, endMark := start, end
+		if startedAt.After(start) {
+			startMark = startedAt
+		}
+
+		elapsedTime := helper.FormatElapsed(end.Sub(startedAt))
+
+		if !endedAt.IsZero() {
+			endedAt = endedAt.In(loc)
+			endedAtString = endedAt.Format(base.DatetimezoneLayout)
+			endedAtTS = endedAt.UnixMilli()
+			elapsedTime = helper.FormatElapsed(endedAt.Sub(startedAt))
+
+			if endedAt.Before(end) {
+				endMark = endedAt
+			}
+		}
+
+		activeTimeSeconds := max(endMark.Sub(startMark).Seconds(), 0)
+
+		// Get allocated resources of the pod
+		allocation, cpus, mem, gpus := k.podAllocation(pod)
+
+		// Memory time is in MiB seconds, same as other resource managers
+		cpuMemSeconds := mem / (1 << 20) * activeTimeSeconds
+
+		var gpuMemSeconds float64
+		if gpus > 0 {
+			gpuMemSeconds = activeTimeSeconds
+		}
+
+		// Total time
+		totalTime := models.MetricMap{
+			"walltime":         models.JSONFloat(activeTimeSeconds),
+			"alloc_cputime":    models.JSONFloat(cpus * activeTimeSeconds),
+			"alloc_cpumemtime": models.JSONFloat(cpuMemSeconds),
+			"alloc_gputime":    models.JSONFloat(gpus * activeTimeSeconds),
+			"alloc_gpumemtime": models.JSONFloat(gpuMemSeconds),
+		}
+
+		// Tags. Labels are flattened as tags only support string and int64 values
+		tags := models.Tag{
+			"node":            pod.Spec.NodeName,
+			"qos_class":       pod.Status.QOSClass,
+			"service_account": pod.Spec.ServiceAccountName,
+		}
+
+		for key, value := range pod.Metadata.Labels {
+			tags["label_"+key] = value
+		}
+
+		units = append(units, models.Unit{
+			ResourceManager: k8sPodManager,
+			UUID:            pod.Metadata.UID,
+			Name:            pod.Metadata.Name,
+			Project:         pod.Metadata.Namespace,
+			User:            k.podUser(pod),
+			CreatedAt:       createdAt.Format(base.DatetimezoneLayout),
+			StartedAt:       startedAt.Format(base.DatetimezoneLayout),
+			EndedAt:         endedAtString,
+			CreatedAtTS:     createdAt.UnixMilli(),
+			StartedAtTS:     startedAt.UnixMilli(),
+			EndedAtTS:       endedAtTS,
+			Elapsed:         elapsedTime,
+			State:           pod.Status.Phase,
+			TotalTime:       totalTime,
+			Allocation:      allocation,
+			Tags:            tags,
+		})
+	}
+
+	k.logger.Info("Kubernetes pods fetched", "cluster_id", k.cluster.ID, "start", start, "end", end, "num_pods", len(units))
+
+	return units, nil
+}
+
+// podEndTime returns the time at which pod has terminated. If pod is still
+// running, a zero time is returned.
+func podEndTime(pod Pod) time.Time {
+	if pod.Status.Phase != podSucceeded && pod.Status.Phase != podFailed {
+		return time.Time{}
+	}
+
+	// End time of pod is the time when last container has terminated
+	var endedAt time.Time
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil && status.State.Terminated.FinishedAt.After(endedAt) {
+			endedAt = status.State.Terminated.FinishedAt
+		}
+	}
+
+	// If none of the containers reported finish time, use deletion timestamp
+	if endedAt.IsZero() && pod.Metadata.DeletionTimestamp != nil {
+		endedAt = *pod.Metadata.DeletionTimestamp
+	}
+
+	// As a last resort, use the latest transition of pod conditions which
+	// happens when pod has terminated
+	if endedAt.IsZero() {
+		for _, condition := range pod.Status.Conditions {
+			if condition.LastTransitionTime.After(endedAt) {
+				endedAt = condition.LastTransitionTime
+			}
+		}
+	}
+
+	return endedAt
+}
+
+// podUser returns the user that created the pod from the configured annotations.
+func (k *k8sManager) podUser(pod Pod) string {
+	for _, annotation := range k.config.UsernameAnnotations {
+		if user, ok := pod.Metadata.Annotations[annotation]; ok && user != "" {
+			return user
+		}
+	}
+
+	return ""
+}
+
+// podAllocation returns the allocation of the pod along with number of CPUs,
+// memory in bytes and number of GPUs.
+// Requests are used as allocated resources and limits are used as a fallback
+// when requests are not set.
+func (k *k8sManager) podAllocation(pod Pod) (models.Allocation, float64, float64, float64) {
+	requests := make(map[string]float64)
+	limits := make(map[string]float64)
+
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			if v, err := parseQuantity(quantity); err == nil {
+				requests[name] = round(requests[name] + v)
+			} else {
+				k.logger.Debug("Failed to parse resource request of pod", "cluster_id", k.cluster.ID, "pod", pod.Metadata.UID, "resource", name, "err", err)
+			}
+		}
+
+		for name, quantity := range container.Resources.Limits {
+			if v, err := parseQuantity(quantity); err == nil {
+				limits[name] = round(limits[name] + v)
+			} else {
+				k.logger.Debug("Failed to parse resource limit of pod", "cluster_id", k.cluster.ID, "pod", pod.Metadata.UID, "resource", name, "err", err)
+			}
+		}
+	}
+
+	// Get allocated value of a resource
+	allocated := func(name string) float64 {
+		if v, ok := requests[name]; ok {
+			return v
+		}
+
+		return limits[name]
+	}
+
+	cpus := allocated("cpu")
+	mem := allocated("memory")
+
+	var gpus float64
+	for _, name := range k.config.GPUResourceNames {
+		gpus += allocated(name)
+	}
+
+	allocation := models.Allocation{
+		"cpus":     cpus,
+		"mem":      int64(mem),
+		"gpus":     int64(gpus),
+		"requests": requests,
+		"limits":   limits,
+	}
+
+	return allocation, cpus, mem, gpus
+}
@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+const userSubjectKind = "User"
+
+// usersProjectsAssoc returns users and projects of the cluster. Each namespace
+// is a project and users are the subjects of kind User in the role bindings
+// of that namespace.
+func (k *k8sManager) usersProjectsAssoc(ctx context.Context, current time.Time) ([]models.User, []models.Project, error) {
+	// Current time string
+	currentTime := current.Format(base.DatetimezoneLayout)
+
+	namespaces, err := k.fetchNamespaces(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bindings, err := k.fetchRoleBindings(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projectUsersList := make(map[string][]string)
+	userProjectsList := make(map[string][]string)
+
+	for _, binding := range bindings {
+		namespace := binding.Metadata.Namespace
+		if slices.Contains(k.config.IgnoreNamespaces, namespace) {
+			continue
+		}
+
+		for _, subject := range binding.Subjects {
+			if subject.Kind != userSubjectKind {
+				continue
+			}
+
+			projectUsersList[namespace] = append(projectUsersList[namespace], subject.Name)
+			userProjectsList[subject.Name] = append(userProjectsList[subject.Name], namespace)
+		}
+	}
+
+	// Transform namespaces into slice of projects
+	var projectModels []models.Project
+
+	for _, namespace := range namespaces {
+		if slices.Contains(k.config.IgnoreNamespaces, namespace.Metadata.Name) {
+			continue
+		}
+
+		projectUsers := projectUsersList[namespace.Metadata.Name]
+
+		// Sort users
+		slices.Sort(projectUsers)
+
+		var usersList models.List
+		for _, u := range slices.Compact(projectUsers) {
+			usersList = append(usersList, u)
+		}
+
+		projectModels = append(projectModels, models.Project{
+			UID:           namespace.Metadata.UID,
+			Name:          namespace.Metadata.Name,
+			Users:         usersList,
+			LastUpdatedAt: currentTime,
+		})
+	}
+
+	// Sort user names to get deterministic output
+	userNames := make([]string, 0, len(userProjectsList))
+	for user := range userProjectsList {
+		userNames = append(userNames, user)
+	}
+
+	slices.Sort(userNames)
+
+	// Transform map into slice of users
+	userModels := make([]models.User, len(userNames))
+
+	for iuser, user := range userNames {
+		userProjects := userProjectsList[user]
+
+		// Sort projects
+		slices.Sort(userProjects)
+
+		var projectsList models.List
+		for _, p := range slices.Compact(userProjects) {
+			projectsList = append(projectsList, p)
+		}
+
+		userModels[iuser] = models.User{
+			Name:          user,
+			Projects:      projectsList,
+			LastUpdatedAt: currentTime,
+		}
+	}
+
+	k.logger.Info("Kubernetes user data fetched", "cluster_id", k.cluster.ID, "num_users", len(userModels), "num_namespaces", len(projectModels))
+
+	return userModels, projectModels, nil
+}
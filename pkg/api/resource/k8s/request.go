@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// listPageSize is the number of objects fetched in each list request.
+const listPageSize = 500
+
+// apiRequest makes the request using client and returns response.
+func apiRequest[T any](req *http.Request, client *http.Client) (T, error) {
+	// Add necessary headers
+	req.Header.Add("Accept", "application/json")
+
+	// Make request
+	resp, err := client.Do(req)
+	if err != nil {
+		return *new(T), err
+	}
+	defer resp.Body.Close()
+
+	// Check status code
+	if resp.StatusCode != http.StatusOK {
+		return *new(T), fmt.Errorf("request failed with status: %d", resp.StatusCode)
+	}
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return *new(T), err
+	}
+
+	// Unpack into data
+	var data T
+	if err = json.Unmarshal(body, &data); err != nil {
+		return *new(T), err
+	}
+
+	return data, nil
+}
+
+// listObjects fetches all the objects of a list endpoint by following
+// continue tokens returned by the API server.
+func listObjects[T any, L interface{ continueToken() string }](
+	ctx context.Context,
+	client *http.Client,
+	u *url.URL,
+	items func(L) []T,
+) ([]T, error) {
+	var objects []T
+
+	var continueToken string
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add query parameters
+		q := req.URL.Query()
+		q.Add("limit", strconv.FormatInt(listPageSize, 10))
+
+		if continueToken != "" {
+			q.Add("continue", continueToken)
+		}
+
+		req.URL.RawQuery = q.Encode()
+
+		// Get response
+		resp, err := apiRequest[L](req, client)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, items(resp)...)
+
+		// If there are no more pages, break
+		if continueToken = resp.continueToken(); continueToken == "" {
+			break
+		}
+	}
+
+	return objects, nil
+}
+
+func (l PodList) continueToken() string {
+	return l.Metadata.Continue
+}
+
+func (l NamespaceList) continueToken() string {
+	return l.Metadata.Continue
+}
+
+func (l RoleBindingList) continueToken() string {
+	return l.Metadata.Continue
+}
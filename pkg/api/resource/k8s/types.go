@@ -0,0 +1,195 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListMeta is the metadata of a list response from Kubernetes API server.
+type ListMeta struct {
+	// Continue is the token to fetch next page of results.
+	Continue string `json:"continue,omitempty"`
+}
+
+// ObjectMeta is the metadata of a Kubernetes object.
+type ObjectMeta struct {
+	UID               string            `json:"uid"`
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace,omitempty"`
+	CreationTimestamp time.Time         `json:"creationTimestamp"`
+	DeletionTimestamp *time.Time        `json:"deletionTimestamp,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+}
+
+// ResourceRequirements contains requests and limits of a container.
+type ResourceRequirements struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+// Container is a single container in the pod.
+type Container struct {
+	Name      string               `json:"name"`
+	Resources ResourceRequirements `json:"resources"`
+}
+
+// PodSpec is the specification of a pod.
+type PodSpec struct {
+	NodeName           string      `json:"nodeName,omitempty"`
+	ServiceAccountName string      `json:"serviceAccountName,omitempty"`
+	Containers         []Container `json:"containers"`
+}
+
+// ContainerStateTerminated is the terminated state of a container.
+type ContainerStateTerminated struct {
+	ExitCode   int       `json:"exitCode"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// ContainerState is the current state of a container.
+type ContainerState struct {
+	Terminated *ContainerStateTerminated `json:"terminated,omitempty"`
+}
+
+// ContainerStatus is the status of a container in the pod.
+type ContainerStatus struct {
+	Name  string         `json:"name"`
+	State ContainerState `json:"state"`
+}
+
+// PodCondition is a condition of a pod.
+type PodCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// PodStatus is the current status of a pod.
+type PodStatus struct {
+	Phase             string            `json:"phase"`
+	QOSClass          string            `json:"qosClass,omitempty"`
+	StartTime         *time.Time        `json:"startTime,omitempty"`
+	Conditions        []PodCondition    `json:"conditions,omitempty"`
+	ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty"`
+}
+
+// Pod is a Kubernetes pod.
+type Pod struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     PodSpec    `json:"spec"`
+	Status   PodStatus  `json:"status"`
+}
+
+// PodList is the response of list pods API request.
+type PodList struct {
+	Metadata ListMeta `json:"metadata"`
+	Items    []Pod    `json:"items"`
+}
+
+// Namespace is a Kubernetes namespace.
+type Namespace struct {
+	Metadata ObjectMeta `json:"metadata"`
+}
+
+// NamespaceList is the response of list namespaces API request.
+type NamespaceList struct {
+	Metadata ListMeta    `json:"metadata"`
+	Items    []Namespace `json:"items"`
+}
+
+// Subject is a subject of a role binding.
+type Subject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RoleRef is the role that a role binding refers to.
+type RoleRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// RoleBinding is a Kubernetes role binding.
+type RoleBinding struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Subjects []Subject  `json:"subjects,omitempty"`
+	RoleRef  RoleRef    `json:"roleRef"`
+}
+
+// RoleBindingList is the response of list role bindings API request.
+type RoleBindingList struct {
+	Metadata ListMeta      `json:"metadata"`
+	Items    []RoleBinding `json:"items"`
+}
+
+// Multipliers of quantity suffixes.
+var quantitySuffixes = map[string]float64{
+	"n":  1e-9,
+	"u":  1e-6,
+	"m":  1e-3,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// parseQuantity parses a Kubernetes resource quantity string like `500m`, `2Gi`
+// or `1e3` into a float.
+func parseQuantity(q string) (float64, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return 0, errors.New("empty quantity")
+	}
+
+	// Split number and suffix
+	idx := strings.IndexFunc(q, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '+' && r != '-'
+	})
+
+	// No suffix
+	if idx == -1 {
+		return strconv.ParseFloat(q, 64)
+	}
+
+	number, suffix := q[:idx], q[idx:]
+
+	// Decimal exponent like 1e3
+	if suffix[0] == 'e' || suffix[0] == 'E' {
+		if _, err := strconv.Atoi(suffix[1:]); err == nil {
+			return strconv.ParseFloat(q, 64)
+		}
+	}
+
+	multiplier, ok := quantitySuffixes[suffix]
+	if !ok {
+		return 0, fmt.Errorf("invalid quantity suffix in %s", q)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return value * multiplier, nil
+}
+
+// round rounds float to 3 decimal places to avoid floating point noise
+// in parsed quantities.
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -42,6 +42,20 @@ type Fetcher interface {
 	) ([]models.ClusterUsers, []models.ClusterProjects, error)
 }
 
+// Acknowledger is implemented by resource managers that return compute units
+// of past periods only once, like the ones read from accounting files. Units
+// of such managers that are not in DB yet are counted as new units in usage
+// irrespective of their start times.
+type Acknowledger interface {
+	Fetcher
+	// Cluster returns the cluster of resource manager
+	Cluster() models.Cluster
+	// Ack acknowledges that the compute units fetched for the interval ending
+	// at end are committed to DB. Units that are not acknowledged must be
+	// returned again during next fetch
+	Ack(end time.Time) error
+}
+
 // Manager implements the interface to fetch compute units from different resource managers.
 type Manager struct {
 	Fetchers []Fetcher
@@ -268,3 +282,43 @@ func (b Manager) FetchUsersProjects(
 
 	return clusterUsers, clusterProjects, errs
 }
+
+// PushCluster returns the cluster with given ID whose compute units are pushed
+// to API server.
+func (b Manager) PushCluster(id string) (models.Cluster, bool) {
+	for _, fetcher := range b.Fetchers {
+		if p, ok := fetcher.(Pusher); ok && p.Cluster().ID == id {
+			return p.Cluster(), true
+		}
+	}
+
+	return models.Cluster{}, false
+}
+
+// AckCluster returns true if compute units of cluster with given ID must be
+// acknowledged once they are committed to DB.
+func (b Manager) AckCluster(id string) bool {
+	for _, fetcher := range b.Fetchers {
+		if a, ok := fetcher.(Acknowledger); ok && a.Cluster().ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Ack acknowledges the compute units fetched for the interval ending at end
+// to all the resource managers that need it.
+func (b Manager) Ack(end time.Time) error {
+	var errs error
+
+	for _, fetcher := range b.Fetchers {
+		if a, ok := fetcher.(Acknowledger); ok {
+			if err := a.Ack(end); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("cluster %s: %w", a.Cluster().ID, err))
+			}
+		}
+	}
+
+	return errs
+}
@@ -334,3 +334,33 @@ func TestNewManagerWithNoClusters(t *testing.T) {
 	assert.Empty(t, users[0].Users)
 	assert.Empty(t, projects[0].Projects)
 }
+
+func TestManagerPushCluster(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pusher, err := NewPushResourceManager(models.Cluster{ID: "push-0", Manager: "push"}, logger)
+	require.NoError(t, err)
+
+	fetcher, err := NewMockResourceManager(models.Cluster{ID: "mock"}, logger)
+	require.NoError(t, err)
+
+	manager := Manager{Fetchers: []Fetcher{fetcher, pusher}, Logger: logger}
+
+	cluster, ok := manager.PushCluster("push-0")
+	require.True(t, ok)
+	assert.Equal(t, "push", cluster.Manager)
+
+	// Clusters that are not pushed must not be found
+	_, ok = manager.PushCluster("mock")
+	assert.False(t, ok)
+
+	// Push manager must not return any units
+	units, err := manager.FetchUnits(context.Background(), time.Now(), time.Now())
+	require.NoError(t, err)
+
+	for _, u := range units {
+		if u.Cluster.ID == "push-0" {
+			assert.Empty(t, u.Units)
+		}
+	}
+}
@@ -114,6 +114,53 @@ func (o *openstackManager) fetchUserProjects(ctx context.Context, userID string)
 	return resp.Projects, nil
 }
 
+// fetchCoordinators fetches the users having coordinator roles on each project
+// from Openstack cluster and returns them keyed by project ID.
+func (o *openstackManager) fetchCoordinators(ctx context.Context) (map[string][]string, error) {
+	// Create a new GET request
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		o.roleAssignments().String(),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to fetch role assignments for openstack cluster: %w", err)
+	}
+
+	// Include names of roles, projects and users in response
+	q := req.URL.Query()
+	q.Add("include_names", "true")
+	req.URL.RawQuery = q.Encode()
+
+	// Add token to request headers
+	req, err = o.addTokenHeader(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate api token for openstack cluster: %w", err)
+	}
+
+	// Get response
+	resp, err := apiRequest[RoleAssignmentsResponse](req, o.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete request to fetch role assignments for openstack cluster: %w", err)
+	}
+
+	coordinators := make(map[string][]string)
+
+	for _, assignment := range resp.RoleAssignments {
+		// Ignore role assignments on domains and the ones without user
+		if assignment.Scope.Project.ID == "" || assignment.User.Name == "" {
+			continue
+		}
+
+		if slices.Contains(o.coordinatorRoles, assignment.Role.Name) {
+			coordinators[assignment.Scope.Project.ID] = append(coordinators[assignment.Scope.Project.ID], assignment.User.Name)
+		}
+	}
+
+	return coordinators, nil
+}
+
 // fetchUsers fetches a list of users or specific user from Openstack cluster.
 func (o *openstackManager) usersProjectsAssoc(ctx context.Context, current time.Time) (userProjectsCache, error) {
 	// Check if service is online
@@ -199,6 +246,15 @@ func (o *openstackManager) usersProjectsAssoc(ctx context.Context, current time.
 	slices.Sort(projectIDs)
 	projectIDs = slices.Compact(projectIDs)
 
+	// Fetch coordinators of projects when coordinator roles are configured.
+	// Coordinators are not essential and hence, log failures and continue
+	var projectCoordinators map[string][]string
+	if len(o.coordinatorRoles) > 0 {
+		if projectCoordinators, err = o.fetchCoordinators(ctx); err != nil {
+			o.logger.Warn("Failed to fetch coordinators of projects", "id", o.cluster.ID, "err", err)
+		}
+	}
+
 	// Transform map into slice of projects
 	projectModels := make([]models.Project, len(projectIDs))
 
@@ -213,11 +269,22 @@ func (o *openstackManager) usersProjectsAssoc(ctx context.Context, current time.
 			usersList = append(usersList, u)
 		}
 
+		coordinators := projectCoordinators[projectID]
+
+		// Sort coordinators
+		slices.Sort(coordinators)
+
+		var coordinatorsList models.List
+		for _, c := range slices.Compact(coordinators) {
+			coordinatorsList = append(coordinatorsList, c)
+		}
+
 		// Make Association
 		projectModels[iproject] = models.Project{
 			UID:           projectID,
 			Name:          projectIDNameMap[projectID],
 			Users:         usersList,
+			Coordinators:  coordinatorsList,
 			LastUpdatedAt: currentTime,
 		}
 	}
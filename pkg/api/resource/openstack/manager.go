@@ -52,6 +52,7 @@ type openstackManager struct {
 	userProjectsCache          userProjectsCache
 	userProjectsCacheTTL       time.Duration
 	userProjectsLastUpdateTime time.Time
+	coordinatorRoles           []string
 }
 
 type openstackConfig struct {
@@ -59,7 +60,8 @@ type openstackConfig struct {
 		Compute  string `yaml:"compute"`
 		Identity string `yaml:"identity"`
 	} `yaml:"api_service_endpoints"`
-	AuthConfig interface{} `yaml:"auth"`
+	AuthConfig       interface{} `yaml:"auth"`
+	CoordinatorRoles []string    `yaml:"coordinator_roles"`
 }
 
 // addAuthKey embeds AuthConfig as value under `auth` key.
@@ -142,6 +144,9 @@ func New(cluster models.Cluster, logger *slog.Logger) (resource.Fetcher, error)
 		return nil, errors.Unwrap(err)
 	}
 
+	// Users having these roles on a project are coordinators of the project
+	openstackManager.coordinatorRoles = osConfig.CoordinatorRoles
+
 	// Convert auth to bytes to embed into requests later
 	osConfig.addAuthKey()
 
@@ -231,6 +236,11 @@ func (o *openstackManager) userProjects(id string) *url.URL {
 	return o.apiURLs["identity"].JoinPath(fmt.Sprintf("/v3/users/%s/projects", id))
 }
 
+// role assignments endpoint.
+func (o *openstackManager) roleAssignments() *url.URL {
+	return o.apiURLs["identity"].JoinPath("/v3/role_assignments")
+}
+
 // addTokenHeader adds API token to request headers.
 func (o *openstackManager) addTokenHeader(ctx context.Context, req *http.Request) (*http.Request, error) {
 	// Check if token is still valid. If not rotate token
@@ -211,7 +211,19 @@ func mockOSComputeAPIServer() *httptest.Server {
 func mockOSIdentityAPIServer() *httptest.Server {
 	// Start test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.URL.Path, "users") {
+		if strings.HasSuffix(r.URL.Path, "role_assignments") {
+			if tokens := r.Header[tokenHeaderName]; len(tokens) == 0 || r.URL.Query().Get("include_names") != "true" {
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			if data, err := os.ReadFile("../../testdata/openstack/identity/role_assignments.json"); err == nil {
+				w.Write(data)
+
+				return
+			}
+		} else if strings.HasSuffix(r.URL.Path, "users") {
 			if tokens := r.Header[tokenHeaderName]; len(tokens) == 0 {
 				w.WriteHeader(http.StatusForbidden)
 
@@ -349,6 +361,40 @@ func TestOpenstackFetcher(t *testing.T) {
 	}
 }
 
+func TestOpenstackFetcherCoordinators(t *testing.T) {
+	// Setup mock API servers
+	computeAPIServer := mockOSComputeAPIServer()
+	defer computeAPIServer.Close()
+
+	identityAPIServer := mockOSIdentityAPIServer()
+	defer identityAPIServer.Close()
+
+	extraConfig, err := mockConfig(computeAPIServer.URL, identityAPIServer.URL)
+	require.NoError(t, err)
+
+	// Add coordinator roles to extra config
+	coordConfig := yaml.Node{}
+	require.NoError(t, yaml.Unmarshal([]byte("coordinator_roles: [admin, project_admin]"), &coordConfig))
+
+	extraConfig.Content[0].Content = append(extraConfig.Content[0].Content, coordConfig.Content[0].Content...)
+
+	os, err := New(models.Cluster{ID: "os-0", Manager: "openstack", Extra: extraConfig}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, projects, err := os.FetchUsersProjects(context.Background(), current)
+	require.NoError(t, err)
+
+	// Only users with coordinator roles on projects must be coordinators
+	expectedCoordinators := map[string]models.List{
+		"admin":          {"admin"},
+		"test-project-3": {"test-user-1"},
+	}
+
+	for _, project := range projects[0].Projects {
+		assert.Equal(t, expectedCoordinators[project.Name], project.Coordinators, project.Name)
+	}
+}
+
 func TestOpenstackFetcherFail(t *testing.T) {
 	// Setup mock API servers
 	computeAPIServer := mockOSComputeAPIServer()
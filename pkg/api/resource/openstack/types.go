@@ -358,3 +358,32 @@ type Project struct {
 type ProjectsResponse struct {
 	Projects []Project `json:"projects"`
 }
+
+// RoleAssignment represents a role assignment of a user on a project in the
+// OpenStack Identity Service. Names are only present when role assignments are
+// requested with include_names.
+type RoleAssignment struct {
+	// Role is the role that is assigned.
+	Role struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"role"`
+
+	// Scope is the scope of the role assignment.
+	Scope struct {
+		Project struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"project"`
+	} `json:"scope"`
+
+	// User is the user to whom the role is assigned.
+	User struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+type RoleAssignmentsResponse struct {
+	RoleAssignments []RoleAssignment `json:"role_assignments"`
+}
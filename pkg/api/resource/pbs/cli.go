@@ -0,0 +1,652 @@
+package pbs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	internal_osexec "github.com/mahendrapaipuri/ceems/internal/osexec"
+	"github.com/mahendrapaipuri/ceems/internal/security"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"kernel.org/pub/linux/libs/security/libcap/cap"
+)
+
+// qstat outputs times in ctime format in the timezone of the process. We
+// force it to be UTC by setting TZ env var to avoid any ambiguity.
+const qstatTimeLayout = time.ANSIC
+
+var (
+	// PBS gives sizes as 4gb, 1024kb, 100b or in words 8w. Regex will capture the
+	// number and unit (if exists) and we convert it to bytes.
+	sizeRegex = regexp.MustCompile(`^([0-9]+)([kmgtp]?)([bw]?)$`)
+	toBytes   = map[string]int64{
+		"":  1,
+		"k": 1024,
+		"m": 1024 * 1024,
+		"g": 1024 * 1024 * 1024,
+		"t": 1024 * 1024 * 1024 * 1024,
+		"p": 1024 * 1024 * 1024 * 1024 * 1024,
+	}
+
+	// PBS job states.
+	// Ref: https://help.altair.com/2022.1.0/PBS%20Professional/PBSReferenceGuide2022.1.pdf
+	pbsStates = map[string]string{
+		"B": "BEGUN",
+		"E": "EXITING",
+		"F": "FINISHED",
+		"H": "HELD",
+		"M": "MOVED",
+		"Q": "QUEUED",
+		"R": "RUNNING",
+		"S": "SUSPENDED",
+		"T": "TRANSITING",
+		"U": "USER_SUSPENDED",
+		"W": "WAITING",
+		"X": "EXPIRED",
+	}
+
+	// Required capabilities to execute PBS commands.
+	requiredCaps = []string{"cap_setuid", "cap_setgid"}
+)
+
+// qstatResponse is the JSON output of `qstat -f -F json` command.
+type qstatResponse struct {
+	Timestamp  int64          `json:"timestamp"`
+	PBSVersion string         `json:"pbs_version"`
+	PBSServer  string         `json:"pbs_server"`
+	Jobs       map[string]job `json:"Jobs"`
+}
+
+// job is a PBS job in qstat output.
+type job struct {
+	Name          string                 `json:"Job_Name"`
+	Owner         string                 `json:"Job_Owner"`
+	State         string                 `json:"job_state"`
+	Queue         string                 `json:"queue"`
+	Account       string                 `json:"Account_Name"`
+	Group         string                 `json:"egroup"`
+	User          string                 `json:"euser"`
+	ExecHost      string                 `json:"exec_host"`
+	ExitStatus    *int                   `json:"Exit_status"`
+	CreatedAt     string                 `json:"ctime"`
+	StartedAt     string                 `json:"stime"`
+	EndedAt       string                 `json:"obittime"`
+	JobDir        string                 `json:"jobdir"`
+	ResourcesUsed map[string]interface{} `json:"resources_used"`
+	ResourceList  map[string]interface{} `json:"Resource_List"`
+}
+
+// username returns the user who owns the job.
+func (j job) username() string {
+	if j.User != "" {
+		return j.User
+	}
+
+	// Job_Owner is of form user@host
+	return strings.Split(j.Owner, "@")[0]
+}
+
+// project returns the project of the job. If account is not set
+// on the job, group of the user is used as project.
+func (j job) project() string {
+	if j.Account != "" {
+		return j.Account
+	}
+
+	return j.Group
+}
+
+// Run preflights for CLI execution mode.
+func preflightsCLI(pbs *pbsScheduler) error {
+	// Assume execMode is always native
+	pbs.fetchMode = cliMode
+	pbs.cmdExecMode = "native"
+	pbs.logger.Debug("Using PBS CLI commands")
+
+	// If no qstat path is provided, assume it is available on PATH
+	if pbs.cluster.CLI.Path == "" {
+		path, err := exec.LookPath("qstat")
+		if err != nil {
+			pbs.logger.Error("Failed to find PBS utility executables on PATH", "err", err)
+
+			return err
+		}
+
+		pbs.cluster.CLI.Path = filepath.Dir(path)
+	} else {
+		// Check if PBS binary directory exists at the given path
+		if _, err := os.Stat(pbs.cluster.CLI.Path); err != nil {
+			pbs.logger.Error("Failed to open PBS bin dir", "path", pbs.cluster.CLI.Path, "err", err)
+
+			return err
+		}
+	}
+
+	// Check if current capabilities have required caps
+	haveCaps := true
+
+	currentCaps := cap.GetProc().String()
+	for _, cap := range requiredCaps {
+		if !strings.Contains(currentCaps, cap) {
+			haveCaps = false
+
+			break
+		}
+	}
+
+	// If current user is root or if current process has necessary caps setup security context
+	if currentUser, err := user.Current(); err == nil && currentUser.Uid == "0" || haveCaps {
+		pbs.cmdExecMode = capabilityMode
+		pbs.logger.Info("Current user/process have enough privileges to execute PBS commands", "user", currentUser.Username)
+
+		var caps []cap.Value
+
+		var err error
+
+		for _, name := range requiredCaps {
+			value, err := cap.FromName(name)
+			if err != nil {
+				pbs.logger.Error("Error parsing capability %s: %w", name, err)
+
+				continue
+			}
+
+			caps = append(caps, value)
+		}
+
+		// Setup new security context(s)
+		pbs.securityContexts[pbsExecCmdCtx], err = security.NewSecurityContext(
+			pbsExecCmdCtx,
+			caps,
+			security.ExecAsUser,
+			pbs.logger,
+		)
+		if err != nil {
+			pbs.logger.Error("Failed to create a security context for PBS", "err", err)
+
+			return err
+		}
+
+		return nil
+	}
+
+	// qstat path
+	qstatPath := filepath.Join(pbs.cluster.CLI.Path, "qstat")
+
+	// Last attempt to run qstat with sudo
+	if _, err := internal_osexec.ExecuteWithTimeout("sudo", []string{qstatPath, "--version"}, 5, nil); err == nil {
+		pbs.cmdExecMode = sudoMode
+		pbs.logger.Info("sudo will be used to execute PBS commands")
+
+		return nil
+	}
+
+	// If nothing works give up. In the worst case DB will be updated with only jobs from current user
+	pbs.logger.Warn("PBS commands will be executed as current user. Might not fetch jobs of all users")
+
+	return nil
+}
+
+// parseQstatCmdOutput parses qstat command output and returns units that were
+// running during the interval between start and end.
+func parseQstatCmdOutput(
+	qstatOutput []byte,
+	start time.Time,
+	end time.Time,
+	logger *slog.Logger,
+) ([]models.Unit, error) {
+	var resp qstatResponse
+	if err := json.Unmarshal(qstatOutput, &resp); err != nil {
+		return nil, err
+	}
+
+	// Get current location
+	loc := end.Location()
+
+	// Sort job IDs to get deterministic output
+	jobIDs := make([]string, 0, len(resp.Jobs))
+	for id := range resp.Jobs {
+		jobIDs = append(jobIDs, id)
+	}
+
+	slices.Sort(jobIDs)
+
+	var jobs []models.Unit
+
+	for _, id := range jobIDs {
+		j := resp.Jobs[id]
+
+		// Ignore array parent jobs. Subjobs are reported separately
+		if strings.Contains(id, "[]") {
+			continue
+		}
+
+		// Ignore jobs that never ran
+		startedAt, err := time.ParseInLocation(qstatTimeLayout, j.StartedAt, time.UTC)
+		if err != nil {
+			continue
+		}
+
+		// Use start time as creation time when it cannot be parsed
+		createdAt, err := time.ParseInLocation(qstatTimeLayout, j.CreatedAt, time.UTC)
+		if err != nil {
+			logger.Warn("Failed to parse creation time of PBS job", "jobid", id, "ctime", j.CreatedAt, "err", err)
+
+			createdAt = startedAt
+		}
+
+		// Only finished jobs have an end time
+		var endedAt time.Time
+		if j.EndedAt != "" {
+			if endedAt, err = time.ParseInLocation(qstatTimeLayout, j.EndedAt, time.UTC); err != nil {
+				logger.Warn("Failed to parse end time of PBS job", "jobid", id, "obittime", j.EndedAt, "err", err)
+			}
+		}
+
+		// Ignore jobs that started after the current interval or finished
+		// before it. qstat returns all the jobs in history and we do not want
+		// to account the same job again
+		if startedAt.After(end) || (!endedAt.IsZero() && endedAt.Before(start)) {
+			continue
+		}
+
+		// Get elapsed time of job in this interval in seconds
+		startMark, endMark := start, end
+		if startedAt.After(start) {
+			startMark = startedAt
+		}
+
+		if !endedAt.IsZero() && endedAt.Before(end) {
+			endMark = endedAt
+		}
+
+		elapsedSeconds := int64(endMark.Sub(startMark).Seconds())
+
+		// Allocated resources
+		ncpus := toInt64(j.ResourceList["ncpus"])
+		ngpus := toInt64(j.ResourceList["ngpus"])
+		nnodes := toInt64(j.ResourceList["nodect"])
+		mem := toBytesSize(j.ResourceList["mem"])
+
+		// Get cpuSeconds and gpuSeconds of the current interval
+		cpuSeconds := ncpus * elapsedSeconds
+		gpuSeconds := ngpus * elapsedSeconds
+
+		// Get cpuMemSeconds and gpuMemSeconds of current interval in MB
+		var cpuMemSeconds, gpuMemSeconds int64
+		if mem > 0 {
+			cpuMemSeconds = mem * elapsedSeconds / toBytes["m"]
+		} else {
+			cpuMemSeconds = elapsedSeconds
+		}
+
+		// Same as SLURM, use walltime as GPU mem time
+		if ngpus > 0 {
+			gpuMemSeconds = elapsedSeconds
+		}
+
+		// CPU time used by the job is cumulative. Estimate the share of it
+		// in current interval based on the walltime used by the job
+		var cpuTimeSeconds float64
+		if usedWalltime := toSeconds(j.ResourcesUsed["walltime"]); usedWalltime > 0 {
+			cpuTimeSeconds = float64(toSeconds(j.ResourcesUsed["cput"])) * float64(elapsedSeconds) / float64(usedWalltime)
+		}
+
+		// Allocation
+		allocation := models.Allocation{
+			"nodes":    nnodes,
+			"cpus":     ncpus,
+			"mem":      mem,
+			"gpus":     ngpus,
+			"walltime": toSeconds(j.ResourceList["walltime"]),
+			"select":   toString(j.ResourceList["select"]),
+			"place":    toString(j.ResourceList["place"]),
+		}
+
+		// Expand exec host into list of nodes
+		nodes := execHostNodes(j.ExecHost)
+
+		// Tags
+		tags := models.Tag{
+			"jobid":       id,
+			"queue":       j.Queue,
+			"exit_status": exitStatus(j.ExitStatus),
+			"exec_host":   j.ExecHost,
+			"nodelistexp": strings.Join(nodes, "|"),
+			"jobdir":      j.JobDir,
+		}
+
+		// Initialise endedAt, endedAtTS
+		endedAtString := "N/A"
+
+		var endedAtTS int64 = 0
+
+		if !endedAt.IsZero() {
+			endedAtString = endedAt.In(loc).Format(base.DatetimezoneLayout)
+			endedAtTS = endedAt.UnixMilli()
+		}
+
+		jobs = append(jobs, models.Unit{
+			ResourceManager: pbsBatchScheduler,
+			UUID:            jobUUID(id),
+			Name:            j.Name,
+			Project:         j.project(),
+			Group:           j.Group,
+			User:            j.username(),
+			CreatedAt:       createdAt.In(loc).Format(base.DatetimezoneLayout),
+			StartedAt:       startedAt.In(loc).Format(base.DatetimezoneLayout),
+			EndedAt:         endedAtString,
+			CreatedAtTS:     createdAt.UnixMilli(),
+			StartedAtTS:     startedAt.UnixMilli(),
+			EndedAtTS:       endedAtTS,
+			Elapsed:         toString(j.ResourcesUsed["walltime"]),
+			State:           jobState(j),
+			Allocation:      allocation,
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(elapsedSeconds),
+				"alloc_cputime":    models.JSONFloat(cpuSeconds),
+				"alloc_cpumemtime": models.JSONFloat(cpuMemSeconds),
+				"alloc_gputime":    models.JSONFloat(gpuSeconds),
+				"alloc_gpumemtime": models.JSONFloat(gpuMemSeconds),
+				"cputime":          models.JSONFloat(cpuTimeSeconds),
+			},
+			Tags: tags,
+		})
+	}
+
+	return jobs, nil
+}
+
+// parseQstatAssoc parses qstat command output and returns users and their projects.
+func parseQstatAssoc(qstatOutput []byte) (map[string][]string, error) {
+	var resp qstatResponse
+	if err := json.Unmarshal(qstatOutput, &resp); err != nil {
+		return nil, err
+	}
+
+	userProjects := make(map[string][]string)
+
+	for _, j := range resp.Jobs {
+		user, project := j.username(), j.project()
+
+		// Ignore root user/group and incomplete data
+		if user == "" || project == "" || user == "root" || project == "root" {
+			continue
+		}
+
+		userProjects[user] = append(userProjects[user], project)
+	}
+
+	return userProjects, nil
+}
+
+// assocModels returns users and projects models from user projects map.
+func assocModels(assoc map[string][]string, currentTime string) ([]models.User, []models.Project) {
+	projectUserMap := make(map[string][]string)
+
+	var users []string
+
+	var projects []string
+
+	for user, userProjects := range assoc {
+		users = append(users, user)
+
+		for _, project := range userProjects {
+			projectUserMap[project] = append(projectUserMap[project], user)
+			projects = append(projects, project)
+		}
+	}
+
+	// Here we sort projects and users to get deterministic
+	// output as order in Go maps is undefined
+
+	// Sort and compact projects
+	slices.Sort(projects)
+	projects = slices.Compact(projects)
+
+	// Sort users
+	slices.Sort(users)
+
+	// Transform map into slice of projects
+	projectModels := make([]models.Project, len(projects))
+
+	for i := range projects {
+		projectUsers := projectUserMap[projects[i]]
+
+		// Sort users
+		slices.Sort(projectUsers)
+
+		var usersList models.List
+		for _, u := range slices.Compact(projectUsers) {
+			usersList = append(usersList, u)
+		}
+
+		// Make Association
+		projectModels[i] = models.Project{
+			Name:          projects[i],
+			Users:         usersList,
+			LastUpdatedAt: currentTime,
+		}
+	}
+
+	// Transform map into slice of users
+	userModels := make([]models.User, len(users))
+
+	for i := range users {
+		userProjects := slices.Clone(assoc[users[i]])
+
+		// Sort projects
+		slices.Sort(userProjects)
+
+		var projectsList models.List
+		for _, p := range slices.Compact(userProjects) {
+			projectsList = append(projectsList, p)
+		}
+
+		// Make Association
+		userModels[i] = models.User{
+			Name:          users[i],
+			Projects:      projectsList,
+			LastUpdatedAt: currentTime,
+		}
+	}
+
+	return userModels, projectModels
+}
+
+// jobUUID returns the sequence number of job ID without the server name.
+// For example, 1234.pbs-server returns 1234 and 1234[1].pbs-server returns 1234[1].
+func jobUUID(id string) string {
+	return strings.Split(id, ".")[0]
+}
+
+// jobState returns a human readable state of the job.
+func jobState(j job) string {
+	// For finished jobs, use exit status to know if job has failed
+	if (j.State == "F" || j.State == "X") && j.ExitStatus != nil {
+		if *j.ExitStatus == 0 {
+			return "COMPLETED"
+		}
+
+		return "FAILED"
+	}
+
+	if state, ok := pbsStates[j.State]; ok {
+		return state
+	}
+
+	return j.State
+}
+
+// exitStatus returns exit status as string.
+func exitStatus(status *int) string {
+	if status == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*status)
+}
+
+// execHostNodes returns the list of unique nodes from exec_host. exec_host
+// is of form node1/0*4+node2/0*4.
+func execHostNodes(execHost string) []string {
+	if execHost == "" {
+		return nil
+	}
+
+	var nodes []string
+
+	for _, chunk := range strings.Split(execHost, "+") {
+		nodes = append(nodes, strings.Split(chunk, "/")[0])
+	}
+
+	// Chunks of same node need not be adjacent. Eg n1/0+n2/0+n1/1
+	slices.Sort(nodes)
+
+	return slices.Compact(nodes)
+}
+
+// toInt64 converts a qstat resource value to int64. Values can be either
+// numbers or strings in the JSON output.
+func toInt64(v interface{}) int64 {
+	switch value := v.(type) {
+	case float64:
+		return int64(value)
+	case string:
+		i, _ := strconv.ParseInt(value, 10, 64)
+
+		return i
+	}
+
+	return 0
+}
+
+// toString converts a qstat resource value to string.
+func toString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	return ""
+}
+
+// toBytesSize converts a PBS size like 4gb into bytes. Word sizes are converted
+// assuming 8 bytes words.
+func toBytesSize(v interface{}) int64 {
+	matches := sizeRegex.FindStringSubmatch(strings.ToLower(toString(v)))
+	if len(matches) != 4 {
+		return 0
+	}
+
+	size, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	size *= toBytes[matches[2]]
+
+	if matches[3] == "w" {
+		size *= 8
+	}
+
+	return size
+}
+
+// toSeconds converts a PBS duration like 01:30:00 into seconds.
+func toSeconds(v interface{}) int64 {
+	parts := strings.Split(toString(v), ":")
+
+	var seconds int64
+
+	for _, part := range parts {
+		value, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return 0
+		}
+
+		seconds = seconds*60 + value
+	}
+
+	return seconds
+}
+
+// runQstatCmd executes qstat command and return output.
+func (s *pbsScheduler) runQstatCmd(ctx context.Context) ([]byte, error) {
+	// qstat path
+	qstatPath := filepath.Join(s.cluster.CLI.Path, "qstat")
+
+	// Use TZ env var to get times in UTC
+	env := []string{"TZ=UTC"}
+	for name, value := range s.cluster.CLI.EnvVars {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	// Get all jobs including finished ones and expand array jobs into subjobs
+	args := []string{"-x", "-t", "-f", "-F", "json"}
+
+	// Run command as root user
+	if s.cmdExecMode == capabilityMode {
+		// Get security context
+		var securityCtx *security.SecurityContext
+
+		var ok bool
+		if securityCtx, ok = s.securityContexts[pbsExecCmdCtx]; !ok {
+			return nil, security.ErrNoSecurityCtx
+		}
+
+		cmd := []string{qstatPath}
+		cmd = append(cmd, args...)
+
+		// security context data
+		dataPtr := &security.ExecSecurityCtxData{
+			Context: ctx,
+			Cmd:     cmd,
+			Environ: env,
+			Logger:  s.logger,
+			UID:     0,
+			GID:     0,
+		}
+
+		return executeInSecurityContext(securityCtx, dataPtr)
+	} else if s.cmdExecMode == sudoMode {
+		// Important that we need to export env as well as we set environment variables in the
+		// command execution
+		args = append([]string{"-E", qstatPath}, args...)
+
+		return internal_osexec.ExecuteContext(ctx, sudoMode, args, env)
+	}
+
+	return internal_osexec.ExecuteContext(ctx, qstatPath, args, env)
+}
+
+// executeInSecurityContext executes PBS command within a security context.
+func executeInSecurityContext(
+	securityCtx *security.SecurityContext,
+	dataPtr *security.ExecSecurityCtxData,
+) ([]byte, error) {
+	// Read stdOut of command into data
+	if err := securityCtx.Exec(dataPtr); err != nil {
+		return nil, err
+	}
+
+	return dataPtr.StdOut, nil
+}
+
+// Run preflight checks on provided config.
+func preflightChecks(s *pbsScheduler) error {
+	return preflightsCLI(s)
+}
@@ -0,0 +1,70 @@
+package pbs
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQstatCmdOutput(t *testing.T) {
+	units, err := parseQstatCmdOutput([]byte(qstatCmdOutput), start, end, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.Len(t, units, 3)
+	assert.Equal(t, expectedBatchJobs, units[:2])
+
+	// Array sub job started inside current interval
+	assert.InEpsilon(t, 300, float64(units[2].TotalTime["walltime"]), 0)
+	assert.Equal(t, "node4", units[2].Tags["nodelistexp"])
+
+	// Malformed output
+	_, err = parseQstatCmdOutput([]byte(`{"Jobs":`), start, end, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Error(t, err)
+}
+
+func TestExecHostNodes(t *testing.T) {
+	assert.Empty(t, execHostNodes(""))
+	assert.Equal(t, []string{"n1"}, execHostNodes("n1/0*4"))
+	assert.Equal(t, []string{"n1", "n2"}, execHostNodes("n1/0+n2/0+n1/1"))
+}
+
+func TestToBytesSize(t *testing.T) {
+	for _, test := range []struct {
+		size     interface{}
+		expected int64
+	}{
+		{"100b", 100},
+		{"100", 100},
+		{"4kb", 4096},
+		{"8gb", 8589934592},
+		{"2MB", 2097152},
+		{"8w", 64},
+		{"1kw", 8192},
+		{float64(1024), 1024},
+		{"invalid", 0},
+		{nil, 0},
+	} {
+		assert.Equal(t, test.expected, toBytesSize(test.size), test.size)
+	}
+}
+
+func TestToSeconds(t *testing.T) {
+	assert.Equal(t, int64(5400), toSeconds("01:30:00"))
+	assert.Equal(t, int64(108000), toSeconds("30:00:00"))
+	assert.Equal(t, int64(90), toSeconds("01:30"))
+	assert.Equal(t, int64(30), toSeconds(float64(30)))
+	assert.Equal(t, int64(0), toSeconds("invalid"))
+	assert.Equal(t, int64(0), toSeconds(nil))
+}
+
+func TestJobState(t *testing.T) {
+	failed, succeeded := 271, 0
+
+	assert.Equal(t, "RUNNING", jobState(job{State: "R"}))
+	assert.Equal(t, "FINISHED", jobState(job{State: "F"}))
+	assert.Equal(t, "COMPLETED", jobState(job{State: "F", ExitStatus: &succeeded}))
+	assert.Equal(t, "FAILED", jobState(job{State: "X", ExitStatus: &failed}))
+	assert.Equal(t, "Z", jobState(job{State: "Z"}))
+}
@@ -0,0 +1,185 @@
+// Package pbs implements the fetcher interface to fetch compute units from PBS Pro
+// and OpenPBS resource managers
+package pbs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/security"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+)
+
+// Execution modes.
+const (
+	sudoMode       = "sudo"
+	capabilityMode = "cap"
+)
+
+// Fetch modes.
+const (
+	cliMode = "cli"
+)
+
+// Security contexts.
+const (
+	pbsExecCmdCtx = "pbs_exec_cmd"
+)
+
+// pbsScheduler is the struct containing the configuration of a given PBS cluster.
+type pbsScheduler struct {
+	logger           *slog.Logger
+	cluster          models.Cluster
+	fetchMode        string // Whether to fetch from REST API or CLI commands
+	cmdExecMode      string // If CLI mode is chosen, the mode of executing command, ie, sudo or cap or native
+	securityContexts map[string]*security.SecurityContext
+	userProjects     map[string][]string // Users and their projects seen so far in the job history
+}
+
+const pbsBatchScheduler = "pbs"
+
+var assocLock = sync.RWMutex{}
+
+func init() {
+	// Register batch scheduler
+	resource.Register(pbsBatchScheduler, New)
+}
+
+// New returns a new pbsScheduler that returns batch job stats.
+func New(cluster models.Cluster, logger *slog.Logger) (resource.Fetcher, error) {
+	// Make pbsScheduler configs from clusters
+	pbsScheduler := pbsScheduler{
+		logger:           logger,
+		cluster:          cluster,
+		securityContexts: make(map[string]*security.SecurityContext),
+		userProjects:     make(map[string][]string),
+	}
+
+	if err := preflightChecks(&pbsScheduler); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Batch jobs from PBS cluster will be fetched", "id", cluster.ID)
+
+	return &pbsScheduler, nil
+}
+
+// FetchUnits fetches jobs from PBS.
+func (s *pbsScheduler) FetchUnits(
+	ctx context.Context,
+	start time.Time,
+	end time.Time,
+) ([]models.ClusterUnits, error) {
+	var jobs []models.Unit
+
+	var err error
+	if s.fetchMode == cliMode {
+		if jobs, err = s.fetchFromQstat(ctx, start, end); err != nil {
+			s.logger.Error("Failed to execute PBS qstat command", "cluster_id", s.cluster.ID, "err", err)
+
+			return nil, err
+		}
+
+		return []models.ClusterUnits{{Cluster: s.cluster, Units: jobs}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown fetch mode for compute units PBS cluster %s", s.cluster.ID)
+}
+
+// FetchUsersProjects fetches current PBS users and projects.
+func (s *pbsScheduler) FetchUsersProjects(
+	ctx context.Context,
+	current time.Time,
+) ([]models.ClusterUsers, []models.ClusterProjects, error) {
+	var users []models.User
+
+	var projects []models.Project
+
+	var err error
+	if s.fetchMode == cliMode {
+		if users, projects, err = s.fetchAssocFromQstat(ctx, current); err != nil {
+			s.logger.Error("Failed to execute PBS qstat command", "cluster_id", s.cluster.ID, "err", err)
+
+			return nil, nil, err
+		}
+
+		return []models.ClusterUsers{
+			{Cluster: s.cluster, Users: users},
+		}, []models.ClusterProjects{
+			{Cluster: s.cluster, Projects: projects},
+		}, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown fetch mode for projects for PBS cluster %s", s.cluster.ID)
+}
+
+// Get jobs from PBS qstat command.
+func (s *pbsScheduler) fetchFromQstat(ctx context.Context, start time.Time, end time.Time) ([]models.Unit, error) {
+	// Execute qstat command to get all current and finished jobs
+	qstatOutput, err := s.runQstatCmd(ctx)
+	if err != nil {
+		s.logger.Error("Failed to run qstat command", "cluster_id", s.cluster.ID, "err", err)
+
+		return []models.Unit{}, err
+	}
+
+	// Parse qstat output and create units slice
+	jobs, err := parseQstatCmdOutput(qstatOutput, start, end, s.logger)
+	if err != nil {
+		s.logger.Error("Failed to parse qstat command output", "cluster_id", s.cluster.ID, "err", err)
+
+		return []models.Unit{}, err
+	}
+
+	s.logger.Info("PBS jobs fetched", "cluster_id", s.cluster.ID, "start", start, "end", end, "num_jobs", len(jobs))
+
+	return jobs, nil
+}
+
+// Get user project association from PBS qstat command.
+func (s *pbsScheduler) fetchAssocFromQstat(
+	ctx context.Context,
+	current time.Time,
+) ([]models.User, []models.Project, error) {
+	// Get current time string
+	currentTime := current.Format(base.DatetimeLayout)
+
+	// Execute qstat command
+	qstatOutput, err := s.runQstatCmd(ctx)
+	if err != nil {
+		s.logger.Error("Failed to run qstat command", "cluster_id", s.cluster.ID, "err", err)
+
+		return nil, nil, err
+	}
+
+	// Parse qstat output to get user project associations
+	userProjects, err := parseQstatAssoc(qstatOutput)
+	if err != nil {
+		s.logger.Error("Failed to parse qstat command output", "cluster_id", s.cluster.ID, "err", err)
+
+		return nil, nil, err
+	}
+
+	// PBS does not maintain an accounts database and jobs are purged from history
+	// after job_history_duration. So we merge the current associations with the ones
+	// we have seen so far to avoid losing associations of users without recent jobs.
+	assocLock.Lock()
+	for user, projects := range userProjects {
+		projects = append(s.userProjects[user], projects...)
+		slices.Sort(projects)
+		s.userProjects[user] = slices.Compact(projects)
+	}
+
+	users, projects := assocModels(s.userProjects, currentTime)
+	assocLock.Unlock()
+
+	s.logger.Info("PBS user project data fetched", "cluster_id", s.cluster.ID, "num_users", len(users), "num_projects", len(projects))
+
+	return users, projects, nil
+}
@@ -0,0 +1,395 @@
+package pbs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	start, _       = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:00:00+0200")
+	end, _         = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:15:00+0200")
+	current, _     = time.Parse(base.DatetimezoneLayout, "2024-10-15T16:15:00+0200")
+	qstatCmdOutput = `{
+    "timestamp":1729001700,
+    "pbs_version":"2022.1.1",
+    "pbs_server":"pbs",
+    "Jobs":{
+        "1001.pbs":{
+            "Job_Name":"test_script1",
+            "Job_Owner":"usr1@login-0",
+            "resources_used":{
+                "cpupercent":320,
+                "cput":"04:00:00",
+                "mem":"4194304kb",
+                "ncpus":4,
+                "vmem":"4194304kb",
+                "walltime":"01:15:00"
+            },
+            "job_state":"R",
+            "queue":"workq",
+            "server":"pbs",
+            "Account_Name":"prj1",
+            "ctime":"Tue Oct 15 12:59:00 2024",
+            "exec_host":"node1/0*4",
+            "egroup":"grp1",
+            "euser":"usr1",
+            "Resource_List":{
+                "mem":"8gb",
+                "ncpus":4,
+                "ngpus":1,
+                "nodect":1,
+                "place":"pack",
+                "select":"1:ncpus=4:mem=8gb:ngpus=1",
+                "walltime":"02:00:00"
+            },
+            "stime":"Tue Oct 15 13:00:00 2024",
+            "jobdir":"/home/usr1"
+        },
+        "1002.pbs":{
+            "Job_Name":"test_script2",
+            "Job_Owner":"usr2@login-0",
+            "resources_used":{
+                "cput":"00:09:00",
+                "ncpus":2,
+                "walltime":"00:05:00"
+            },
+            "job_state":"F",
+            "queue":"workq",
+            "server":"pbs",
+            "ctime":"Tue Oct 15 14:04:00 2024",
+            "exec_host":"node2/0*1+node3/0*1",
+            "egroup":"grp2",
+            "euser":"usr2",
+            "Exit_status":0,
+            "Resource_List":{
+                "mem":"1024mb",
+                "ncpus":2,
+                "nodect":2,
+                "place":"scatter",
+                "select":"2:ncpus=1:mem=512mb",
+                "walltime":"00:30:00"
+            },
+            "stime":"Tue Oct 15 14:05:00 2024",
+            "obittime":"Tue Oct 15 14:10:00 2024",
+            "jobdir":"/home/usr2"
+        },
+        "1003.pbs":{
+            "Job_Name":"test_script3",
+            "Job_Owner":"usr1@login-0",
+            "job_state":"F",
+            "queue":"workq",
+            "Account_Name":"prj2",
+            "ctime":"Tue Oct 15 09:59:00 2024",
+            "exec_host":"node1/0*4",
+            "egroup":"grp1",
+            "euser":"usr1",
+            "Exit_status":1,
+            "Resource_List":{
+                "mem":"8gb",
+                "ncpus":4,
+                "nodect":1
+            },
+            "stime":"Tue Oct 15 10:00:00 2024",
+            "obittime":"Tue Oct 15 11:00:00 2024"
+        },
+        "1004.pbs":{
+            "Job_Name":"test_script4",
+            "Job_Owner":"usr3@login-0",
+            "job_state":"Q",
+            "queue":"workq",
+            "Account_Name":"prj1",
+            "ctime":"Tue Oct 15 14:08:00 2024",
+            "egroup":"grp3",
+            "euser":"usr3",
+            "Resource_List":{
+                "ncpus":128,
+                "nodect":1
+            }
+        },
+        "1005[].pbs":{
+            "Job_Name":"array",
+            "Job_Owner":"usr1@login-0",
+            "job_state":"B",
+            "queue":"workq",
+            "Account_Name":"prj2",
+            "ctime":"Tue Oct 15 14:09:00 2024",
+            "egroup":"grp1",
+            "euser":"usr1",
+            "Resource_List":{
+                "ncpus":1,
+                "nodect":1
+            },
+            "stime":"Tue Oct 15 14:10:00 2024"
+        },
+        "1005[1].pbs":{
+            "Job_Name":"array",
+            "Job_Owner":"usr1@login-0",
+            "resources_used":{
+                "cput":"00:05:00",
+                "walltime":"00:05:00"
+            },
+            "job_state":"R",
+            "queue":"workq",
+            "Account_Name":"prj2",
+            "ctime":"Tue Oct 15 14:09:00 2024",
+            "exec_host":"node4/0",
+            "egroup":"grp1",
+            "euser":"usr1",
+            "Resource_List":{
+                "mem":"1gb",
+                "ncpus":1,
+                "nodect":1
+            },
+            "stime":"Tue Oct 15 14:10:00 2024"
+        },
+        "1006.pbs":{
+            "Job_Name":"maintenance",
+            "Job_Owner":"root@login-0",
+            "job_state":"F",
+            "queue":"workq",
+            "ctime":"Tue Oct 15 09:00:00 2024",
+            "egroup":"root",
+            "euser":"root",
+            "Exit_status":0,
+            "stime":"Tue Oct 15 09:00:00 2024",
+            "obittime":"Tue Oct 15 09:01:00 2024"
+        }
+    }
+}`
+	expectedBatchJobs = []models.Unit{
+		{
+			ResourceManager: "pbs",
+			UUID:            "1001",
+			Name:            "test_script1",
+			Project:         "prj1",
+			Group:           "grp1",
+			User:            "usr1",
+			CreatedAt:       "2024-10-15T14:59:00+0200",
+			StartedAt:       "2024-10-15T15:00:00+0200",
+			EndedAt:         "N/A",
+			CreatedAtTS:     1728997140000,
+			StartedAtTS:     1728997200000,
+			EndedAtTS:       0,
+			Elapsed:         "01:15:00",
+			State:           "RUNNING",
+			Allocation: models.Generic{
+				"nodes":    int64(1),
+				"cpus":     int64(4),
+				"mem":      int64(8589934592),
+				"gpus":     int64(1),
+				"walltime": int64(7200),
+				"select":   "1:ncpus=4:mem=8gb:ngpus=1",
+				"place":    "pack",
+			},
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(900),
+				"alloc_cputime":    models.JSONFloat(3600),
+				"alloc_cpumemtime": models.JSONFloat(7372800),
+				"alloc_gputime":    models.JSONFloat(900),
+				"alloc_gpumemtime": models.JSONFloat(900),
+				"cputime":          models.JSONFloat(2880),
+			},
+			Tags: models.Generic{
+				"jobid":       "1001.pbs",
+				"queue":       "workq",
+				"exit_status": "",
+				"exec_host":   "node1/0*4",
+				"nodelistexp": "node1",
+				"jobdir":      "/home/usr1",
+			},
+		},
+		{
+			ResourceManager: "pbs",
+			UUID:            "1002",
+			Name:            "test_script2",
+			Project:         "grp2",
+			Group:           "grp2",
+			User:            "usr2",
+			CreatedAt:       "2024-10-15T16:04:00+0200",
+			StartedAt:       "2024-10-15T16:05:00+0200",
+			EndedAt:         "2024-10-15T16:10:00+0200",
+			CreatedAtTS:     1729001040000,
+			StartedAtTS:     1729001100000,
+			EndedAtTS:       1729001400000,
+			Elapsed:         "00:05:00",
+			State:           "COMPLETED",
+			Allocation: models.Generic{
+				"nodes":    int64(2),
+				"cpus":     int64(2),
+				"mem":      int64(1073741824),
+				"gpus":     int64(0),
+				"walltime": int64(1800),
+				"select":   "2:ncpus=1:mem=512mb",
+				"place":    "scatter",
+			},
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(300),
+				"alloc_cputime":    models.JSONFloat(600),
+				"alloc_cpumemtime": models.JSONFloat(307200),
+				"alloc_gputime":    models.JSONFloat(0),
+				"alloc_gpumemtime": models.JSONFloat(0),
+				"cputime":          models.JSONFloat(540),
+			},
+			Tags: models.Generic{
+				"jobid":       "1002.pbs",
+				"queue":       "workq",
+				"exit_status": "0",
+				"exec_host":   "node2/0*1+node3/0*1",
+				"nodelistexp": "node2|node3",
+				"jobdir":      "/home/usr2",
+			},
+		},
+	}
+	expectedUsers = []models.User{
+		{
+			Name:          "usr1",
+			Projects:      models.List{"prj1", "prj2"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+		{
+			Name:          "usr2",
+			Projects:      models.List{"grp2"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+		{
+			Name:          "usr3",
+			Projects:      models.List{"prj1"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+	}
+	expectedProjects = []models.Project{
+		{
+			Name:          "grp2",
+			Users:         models.List{"usr2"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+		{
+			Name:          "prj1",
+			Users:         models.List{"usr1", "usr3"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+		{
+			Name:          "prj2",
+			Users:         models.List{"usr1"},
+			LastUpdatedAt: "2024-10-15T16:15:00",
+		},
+	}
+)
+
+func mockQstat(t *testing.T, output string) string {
+	t.Helper()
+
+	// Write qstat executable
+	tmpDir := t.TempDir()
+	qstatPath := filepath.Join(tmpDir, "qstat")
+	qstatScript := fmt.Sprintf(`#!/bin/bash
+printf '%%s' '%s'`, output)
+	os.WriteFile(qstatPath, []byte(qstatScript), 0o700) // #nosec
+
+	return tmpDir
+}
+
+func TestPBSFetcher(t *testing.T) {
+	qstatDir := mockQstat(t, qstatCmdOutput)
+
+	// mock config
+	clusters := []models.Cluster{
+		{
+			ID:      "pbs-0",
+			Manager: "pbs",
+			CLI:     models.CLIConfig{Path: qstatDir},
+		},
+		{
+			ID:      "pbs-1",
+			Manager: "pbs",
+			CLI:     models.CLIConfig{Path: qstatDir, EnvVars: map[string]string{"PBS_SERVER": "pbs"}},
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, cluster := range clusters {
+		pbs, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err)
+
+		units, err := pbs.FetchUnits(ctx, start, end)
+		require.NoError(t, err)
+		require.Len(t, units[0].Units, 3)
+		assert.Equal(t, expectedBatchJobs, units[0].Units[:2])
+		assert.Equal(t, "1005[1]", units[0].Units[2].UUID)
+
+		users, projects, err := pbs.FetchUsersProjects(ctx, current)
+		require.NoError(t, err)
+		assert.Equal(t, expectedUsers, users[0].Users)
+		assert.Equal(t, expectedProjects, projects[0].Projects)
+	}
+}
+
+func TestPBSFetcherAssocCache(t *testing.T) {
+	qstatDir := mockQstat(t, qstatCmdOutput)
+
+	cluster := models.Cluster{
+		ID:      "pbs-0",
+		Manager: "pbs",
+		CLI:     models.CLIConfig{Path: qstatDir},
+	}
+
+	ctx := context.Background()
+
+	pbs, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, _, err = pbs.FetchUsersProjects(ctx, current)
+	require.NoError(t, err)
+
+	// Replace qstat with one that has no jobs. Previously seen associations
+	// must be retained
+	pbs.(*pbsScheduler).cluster.CLI.Path = mockQstat(t, `{"Jobs":{}}`)
+
+	users, projects, err := pbs.FetchUsersProjects(ctx, current)
+	require.NoError(t, err)
+	assert.Equal(t, expectedUsers, users[0].Users)
+	assert.Equal(t, expectedProjects, projects[0].Projects)
+
+	// Units must be empty
+	units, err := pbs.FetchUnits(ctx, start, end)
+	require.NoError(t, err)
+	assert.Empty(t, units[0].Units)
+}
+
+func TestPBSFetcherFail(t *testing.T) {
+	qstatDir := mockQstat(t, `malformed`)
+
+	cluster := models.Cluster{
+		ID:      "pbs-0",
+		Manager: "pbs",
+		CLI:     models.CLIConfig{Path: qstatDir},
+	}
+
+	ctx := context.Background()
+
+	pbs, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, err = pbs.FetchUnits(ctx, start, end)
+	require.Error(t, err)
+
+	_, _, err = pbs.FetchUsersProjects(ctx, current)
+	require.Error(t, err)
+
+	// Non existent bin dir
+	cluster.CLI.Path = filepath.Join(qstatDir, "nonexistent")
+	_, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package resource
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+const pushManager = "push"
+
+// Pusher is implemented by resource managers whose compute units are pushed
+// to CEEMS API server by clients instead of being fetched.
+type Pusher interface {
+	Fetcher
+	// Cluster returns the cluster whose compute units are pushed
+	Cluster() models.Cluster
+}
+
+// pushResourceManager struct.
+type pushResourceManager struct {
+	logger  *slog.Logger
+	cluster models.Cluster
+}
+
+func init() {
+	// Register resource manager
+	Register(pushManager, NewPushResourceManager)
+}
+
+// NewPushResourceManager returns a new pushResourceManager. Compute units,
+// users and projects of the cluster are pushed to ingest endpoint of API
+// server and hence, nothing is fetched during DB updates.
+func NewPushResourceManager(cluster models.Cluster, logger *slog.Logger) (Fetcher, error) {
+	logger.Info("Compute units of cluster will be pushed to API server", "id", cluster.ID)
+
+	return &pushResourceManager{
+		logger:  logger,
+		cluster: cluster,
+	}, nil
+}
+
+// Cluster returns the cluster of resource manager.
+func (p *pushResourceManager) Cluster() models.Cluster {
+	return p.cluster
+}
+
+// Return empty units response.
+func (p *pushResourceManager) FetchUnits(
+	_ context.Context,
+	start time.Time,
+	end time.Time,
+) ([]models.ClusterUnits, error) {
+	return []models.ClusterUnits{
+		{
+			Cluster: p.cluster,
+		},
+	}, nil
+}
+
+// Return empty projects response.
+func (p *pushResourceManager) FetchUsersProjects(
+	_ context.Context,
+	currentTime time.Time,
+) ([]models.ClusterUsers, []models.ClusterProjects, error) {
+	return []models.ClusterUsers{
+			{
+				Cluster: p.cluster,
+			},
+		}, []models.ClusterProjects{
+			{
+				Cluster: p.cluster,
+			},
+		}, nil
+}
@@ -39,6 +39,10 @@ var (
 
 	// Required capabilities to execute SLURM commands.
 	requiredCaps = []string{"cap_setuid", "cap_setgid"}
+
+	// sacctmgr arguments to list associations and coordinators of accounts.
+	sacctMgrAssocArgs = []string{"--parsable2", "--noheader", "list", "associations", "format=Account,User"}
+	sacctMgrCoordArgs = []string{"--parsable2", "--noheader", "list", "accounts", "withcoord", "format=Account,Coordinators"}
 )
 
 // Run preflights for CLI execution mode.
@@ -139,13 +143,6 @@ func parseSacctCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]
 	// No header in output
 	sacctOutputLines := strings.Split(sacctOutput, "\n")
 
-	// Update period
-	intStartTS := start.UnixMilli()
-	intEndTS := end.UnixMilli()
-
-	// Get current location
-	loc := end.Location()
-
 	numJobs := 0
 
 	jobs := make([]models.Unit, len(sacctOutputLines))
@@ -155,228 +152,352 @@ func parseSacctCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]
 
 	for iline, line := range sacctOutputLines {
 		go func(i int, l string) {
-			var jobStat models.Unit
-
-			components := strings.Split(l, "|")
-			jobid := components[sacctFieldMap["jobidraw"]]
-
-			// Ignore if we cannot get all components
-			if len(components) < len(sacctFields) {
-				wg.Done()
+			defer wg.Done()
 
+			jobStat, ok := parseSacctJob(strings.Split(l, "|"), start, end)
+			if !ok {
 				return
 			}
 
-			// Ignore job steps
-			if strings.Contains(jobid, ".") {
-				wg.Done()
+			jobLock.Lock()
+			jobs[i] = jobStat
+			numJobs += 1
+			jobLock.Unlock()
+		}(iline, line)
+	}
 
-				return
-			}
+	wg.Wait()
 
-			// Ignore jobs that never ran
-			if components[sacctFieldMap["nodelist"]] == "None assigned" {
-				wg.Done()
+	return jobs, numJobs
+}
 
-				return
-			}
+// parseSacctJob returns compute unit from the fields of a job ordered as
+// sacctFields. Returns false if the job must be ignored.
+func parseSacctJob(components []string, start time.Time, end time.Time) (models.Unit, bool) {
+	// Ignore if we cannot get all components
+	if len(components) < len(sacctFields) {
+		return models.Unit{}, false
+	}
 
-			// Attempt to convert strings to int and ignore any errors in conversion
-			var gidInt, uidInt int64
-			gidInt, _ = strconv.ParseInt(components[sacctFieldMap["gid"]], 10, 64)
-			uidInt, _ = strconv.ParseInt(components[sacctFieldMap["uid"]], 10, 64)
-			// elapsedSeconds, _ = strconv.ParseInt(components[sacctFieldMap["elapsedraw"]], 10, 64)
+	jobid := components[sacctFieldMap["jobidraw"]]
 
-			// Convert time strings to configured time location
-			eventTS := make(map[string]int64, 3)
+	// Ignore job steps
+	if strings.Contains(jobid, ".") {
+		return models.Unit{}, false
+	}
 
-			for _, c := range []string{"submit", "start", "end"} {
-				if t, err := time.Parse(base.DatetimezoneLayout, components[sacctFieldMap[c]]); err == nil {
-					components[sacctFieldMap[c]] = t.In(loc).Format(base.DatetimezoneLayout)
-				}
+	// Ignore jobs that never ran
+	if components[sacctFieldMap["nodelist"]] == "None assigned" {
+		return models.Unit{}, false
+	}
 
-				eventTS[c] = helper.TimeToTimestamp(base.DatetimezoneLayout, components[sacctFieldMap[c]])
-			}
+	return sacctUnit(components, start, end), true
+}
 
-			// Parse alloctres to get billing, nnodes, ncpus, ngpus and mem
-			var billing, nnodes, ncpus, ngpus int64
+// parseSacctStepsCmdOutput parses sacct output that includes job steps and
+// returns jobs and their steps along with number of jobs and steps. Steps and
+// components of heterogeneous jobs are returned as sub units of their parent jobs.
+func parseSacctStepsCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]models.Unit, int, int) {
+	// No header in output
+	sacctOutputLines := strings.Split(sacctOutput, "\n")
 
-			var memString string
+	var units []models.Unit
 
-			for _, elem := range strings.Split(components[sacctFieldMap["alloctres"]], ",") {
-				// For MIG devices, it can be gres/gpu:<MIG ID>
-				// https://github.com/SchedMD/slurm/blob/db91ac3046b3b7b845cce4a99127db8c6f14a8e8/testsuite/expect/test39.19#L70
-				// Use a regex gres\/gpu:([^=]+)=(\d+) for identifying number of instances
-				matches := gresRegex.FindStringSubmatch(elem)
+	var steps [][]string
 
-				if len(matches) == 2 {
-					if val, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
-						ngpus = val
-					}
-				}
+	// Index and fields of jobs in units slice
+	jobIdx := make(map[string]int)
+	jobComponents := make(map[string][]string)
 
-				tresKV := strings.Split(elem, "=")
-				if tresKV[0] == "billing" {
-					billing, _ = strconv.ParseInt(tresKV[1], 10, 64)
-				}
+	// Parse jobs first as steps need their parent jobs
+	for _, line := range sacctOutputLines {
+		components := strings.Split(line, "|")
 
-				if tresKV[0] == "node" {
-					nnodes, _ = strconv.ParseInt(tresKV[1], 10, 64)
-				}
+		// Ignore if we cannot get all components
+		if len(components) < len(sacctStepFields) {
+			continue
+		}
 
-				if tresKV[0] == "cpu" {
-					ncpus, _ = strconv.ParseInt(tresKV[1], 10, 64)
-				}
+		if strings.Contains(components[sacctFieldMap["jobidraw"]], ".") {
+			steps = append(steps, components)
 
-				if tresKV[0] == "mem" {
-					memString = tresKV[1]
-				}
-			}
+			continue
+		}
 
-			// If mem is not empty string, convert the units [K|M|G|T] into numeric bytes
-			// The following logic covers the cases when memory is of form 200M, 250.5G
-			// and also without unit eg 20000, 40000. When there is no unit we assume
-			// it is already in bytes
-			matches := memRegex.FindStringSubmatch(memString)
-
-			var mem int64
-
-			if len(matches) >= 2 {
-				if memFloat, err := strconv.ParseFloat(matches[1], 64); err == nil {
-					if len(matches) == 3 {
-						if unitConv, ok := toBytes[matches[2]]; ok {
-							mem = int64(memFloat) * unitConv
-						}
-					}
-				}
-			}
+		unit, ok := parseSacctJob(components, start, end)
+		if !ok {
+			continue
+		}
 
-			// Assume job's elapsed time during this interval overlaps with interval's
-			// boundaries
-			startMark := intStartTS
-			endMark := intEndTS
+		jobIdx[unit.UUID] = len(units)
+		jobComponents[unit.UUID] = components
+		units = append(units, unit)
+	}
 
-			// If job has not started between interval's start and end time,
-			// elapsedTime should be zero. This can happen when job is in pending state
-			// after submission
-			if eventTS["start"] == 0 {
-				endMark = startMark
+	numJobs := len(units)
 
-				goto elapsed
-			}
+	// Components of heterogeneous jobs with non zero offset become sub units
+	// of the leader job. Allocation and times of the components are added to
+	// leader job so that the usage of entire heterogeneous job is accounted.
+	for i := range numJobs {
+		components := jobComponents[units[i].UUID]
 
-			// If job has already finished in the past we need to get boundaries from
-			// job's start and end time. This case should not arrive in production as
-			// there is no reason SLURM gives us the jobs that have finished in the past
-			// that do not overlap with interval boundaries
-			if eventTS["end"] > 0 && eventTS["end"] < intStartTS {
-				startMark = eventTS["start"]
-				endMark = eventTS["end"]
+		hetJobID := components[sacctFieldMap["hetjobid"]]
 
-				goto elapsed
-			}
+		hetJobOffset, err := strconv.ParseInt(components[sacctFieldMap["hetjoboffset"]], 10, 64)
+		if err != nil || hetJobID == "" || hetJobID == "0" {
+			continue
+		}
 
-			// If job has started **after** start of interval, we should mark job's start
-			// time as start of elapsed time
-			if eventTS["start"] > intStartTS {
-				startMark = eventTS["start"]
-			}
+		units[i].Tags["het_job_id"] = hetJobID
+		units[i].Tags["het_job_offset"] = hetJobOffset
 
-			// If job has ended before end of interval, we should mark job's end time
-			// as elapsed end time.
-			if eventTS["end"] > 0 && eventTS["end"] < intEndTS {
-				endMark = eventTS["end"]
-			}
+		// If leader job is not found, keep component as a job
+		leaderIdx, ok := jobIdx[hetJobID]
+		if hetJobOffset == 0 || !ok {
+			continue
+		}
 
-		elapsed:
-			// Get elapsed time of job in this interval in seconds
-			elapsedSeconds := (endMark - startMark) / 1000
-
-			// Get cpuSeconds and gpuSeconds of the current interval
-			var cpuSeconds, gpuSeconds int64
-			cpuSeconds = ncpus * elapsedSeconds
-			gpuSeconds = ngpus * elapsedSeconds
-
-			// Get cpuMemSeconds and gpuMemSeconds of current interval in MB
-			var cpuMemSeconds, gpuMemSeconds int64
-			if mem > 0 {
-				cpuMemSeconds = mem * elapsedSeconds / toBytes["M"]
-			} else {
-				cpuMemSeconds = elapsedSeconds
-			}
+		units[i].ParentUUID = hetJobID
 
-			// Currently we use walltime as GPU mem time. This wont be a correct proxy
-			// if MIG is enabled in GPUs where different portions of memory can be
-			// allocated
-			// NOTE: Not sure how SLURM outputs the gres/gpu when MIG is activated.
-			// We need to check it and update this part to take GPU memory into account
-			if ngpus > 0 {
-				gpuMemSeconds = elapsedSeconds
+		for _, name := range []string{"nodes", "cpus", "mem", "gpus", "billing"} {
+			if v, ok := units[i].Allocation[name].(int64); ok {
+				leaderValue, _ := units[leaderIdx].Allocation[name].(int64)
+				units[leaderIdx].Allocation[name] = leaderValue + v
 			}
+		}
+
+		for name, v := range units[i].TotalTime {
+			units[leaderIdx].TotalTime[name] += v
+		}
+	}
+
+	// Steps are sub units of jobs. Fields that are only reported for jobs
+	// are inherited from parent job
+	for _, components := range steps {
+		parentID, stepID, _ := strings.Cut(components[sacctFieldMap["jobidraw"]], ".")
 
-			// Expand nodelist range expressions
-			allNodes := helper.NodelistParser(components[sacctFieldMap["nodelist"]])
-			nodelistExp := strings.Join(allNodes, "|")
-
-			// Allocation
-			allocation := models.Allocation{
-				"nodes":   nnodes,
-				"cpus":    ncpus,
-				"mem":     mem,
-				"gpus":    ngpus,
-				"billing": billing,
+		parentComponents, ok := jobComponents[parentID]
+		if !ok {
+			continue
+		}
+
+		for _, field := range []string{"partition", "qos", "account", "group", "gid", "user", "uid", "workdir"} {
+			if components[sacctFieldMap[field]] == "" {
+				components[sacctFieldMap[field]] = parentComponents[sacctFieldMap[field]]
 			}
+		}
+
+		step := sacctUnit(components, start, end)
+
+		// sacct outputs all the steps of jobs running in the interval. Steps
+		// that finished before the interval have been accounted already
+		if step.EndedAtTS > 0 && step.EndedAtTS < start.UnixMilli() {
+			continue
+		}
+
+		step.ParentUUID = parentID
+		step.Tags["step"] = stepID
+		units = append(units, step)
+	}
+
+	return units, numJobs, len(units) - numJobs
+}
+
+// sacctUnit returns compute unit from the fields of a job or step ordered as
+// sacctFields.
+func sacctUnit(components []string, start time.Time, end time.Time) models.Unit {
+	// Update period
+	intStartTS := start.UnixMilli()
+	intEndTS := end.UnixMilli()
+
+	// Get current location
+	loc := end.Location()
+
+	// Attempt to convert strings to int and ignore any errors in conversion
+	var gidInt, uidInt int64
+	gidInt, _ = strconv.ParseInt(components[sacctFieldMap["gid"]], 10, 64)
+	uidInt, _ = strconv.ParseInt(components[sacctFieldMap["uid"]], 10, 64)
+	// elapsedSeconds, _ = strconv.ParseInt(components[sacctFieldMap["elapsedraw"]], 10, 64)
+
+	// Convert time strings to configured time location
+	eventTS := make(map[string]int64, 3)
+
+	for _, c := range []string{"submit", "start", "end"} {
+		if t, err := time.Parse(base.DatetimezoneLayout, components[sacctFieldMap[c]]); err == nil {
+			components[sacctFieldMap[c]] = t.In(loc).Format(base.DatetimezoneLayout)
+		}
+
+		eventTS[c] = helper.TimeToTimestamp(base.DatetimezoneLayout, components[sacctFieldMap[c]])
+	}
+
+	// Parse alloctres to get billing, nnodes, ncpus, ngpus and mem
+	var billing, nnodes, ncpus, ngpus int64
+
+	var memString string
 
-			// Tags
-			tags := models.Tag{
-				"uid":         uidInt,
-				"gid":         gidInt,
-				"partition":   components[sacctFieldMap["partition"]],
-				"qos":         components[sacctFieldMap["qos"]],
-				"exit_code":   components[sacctFieldMap["exitcode"]],
-				"nodelist":    components[sacctFieldMap["nodelist"]],
-				"nodelistexp": nodelistExp,
-				"workdir":     components[sacctFieldMap["workdir"]],
+	for _, elem := range strings.Split(components[sacctFieldMap["alloctres"]], ",") {
+		// For MIG devices, it can be gres/gpu:<MIG ID>
+		// https://github.com/SchedMD/slurm/blob/db91ac3046b3b7b845cce4a99127db8c6f14a8e8/testsuite/expect/test39.19#L70
+		// Use a regex gres\/gpu:([^=]+)=(\d+) for identifying number of instances
+		matches := gresRegex.FindStringSubmatch(elem)
+
+		if len(matches) == 2 {
+			if val, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
+				ngpus = val
 			}
+		}
+
+		tresKV := strings.Split(elem, "=")
+		if tresKV[0] == "billing" {
+			billing, _ = strconv.ParseInt(tresKV[1], 10, 64)
+		}
+
+		if tresKV[0] == "node" {
+			nnodes, _ = strconv.ParseInt(tresKV[1], 10, 64)
+		}
+
+		if tresKV[0] == "cpu" {
+			ncpus, _ = strconv.ParseInt(tresKV[1], 10, 64)
+		}
+
+		if tresKV[0] == "mem" {
+			memString = tresKV[1]
+		}
+	}
+
+	// If mem is not empty string, convert the units [K|M|G|T] into numeric bytes
+	// The following logic covers the cases when memory is of form 200M, 250.5G
+	// and also without unit eg 20000, 40000. When there is no unit we assume
+	// it is already in bytes
+	matches := memRegex.FindStringSubmatch(memString)
+
+	var mem int64
 
-			// Make jobStats struct for each job and put it in jobs slice
-			jobStat = models.Unit{
-				ResourceManager: "slurm",
-				UUID:            jobid,
-				Name:            components[sacctFieldMap["jobname"]],
-				Project:         components[sacctFieldMap["account"]],
-				Group:           components[sacctFieldMap["group"]],
-				User:            components[sacctFieldMap["user"]],
-				CreatedAt:       components[sacctFieldMap["submit"]],
-				StartedAt:       components[sacctFieldMap["start"]],
-				EndedAt:         components[sacctFieldMap["end"]],
-				CreatedAtTS:     eventTS["submit"],
-				StartedAtTS:     eventTS["start"],
-				EndedAtTS:       eventTS["end"],
-				Elapsed:         components[sacctFieldMap["elapsed"]],
-				State:           components[sacctFieldMap["state"]],
-				Allocation:      allocation,
-				TotalTime: models.MetricMap{
-					"walltime":         models.JSONFloat(elapsedSeconds),
-					"alloc_cputime":    models.JSONFloat(cpuSeconds),
-					"alloc_cpumemtime": models.JSONFloat(cpuMemSeconds),
-					"alloc_gputime":    models.JSONFloat(gpuSeconds),
-					"alloc_gpumemtime": models.JSONFloat(gpuMemSeconds),
-				},
-				Tags: tags,
+	if len(matches) >= 2 {
+		if memFloat, err := strconv.ParseFloat(matches[1], 64); err == nil {
+			if len(matches) == 3 {
+				if unitConv, ok := toBytes[matches[2]]; ok {
+					mem = int64(memFloat) * unitConv
+				}
 			}
+		}
+	}
 
-			jobLock.Lock()
-			jobs[i] = jobStat
-			numJobs += 1
-			jobLock.Unlock()
-			wg.Done()
-		}(iline, line)
+	// Assume job's elapsed time during this interval overlaps with interval's
+	// boundaries
+	startMark := intStartTS
+	endMark := intEndTS
+
+	// If job has not started between interval's start and end time,
+	// elapsedTime should be zero. This can happen when job is in pending state
+	// after submission
+	if eventTS["start"] == 0 {
+		endMark = startMark
+
+		goto elapsed
 	}
 
-	wg.Wait()
+	// If job has already finished in the past we need to get boundaries from
+	// job's start and end time. This case should not arrive in production as
+	// there is no reason SLURM gives us the jobs that have finished in the past
+	// that do not overlap with interval boundaries
+	if eventTS["end"] > 0 && eventTS["end"] < intStartTS {
+		startMark = eventTS["start"]
+		endMark = eventTS["end"]
 
-	return jobs, numJobs
+		goto elapsed
+	}
+
+	// If job has started **after** start of interval, we should mark job's start
+	// time as start of elapsed time
+	if eventTS["start"] > intStartTS {
+		startMark = eventTS["start"]
+	}
+
+	// If job has ended before end of interval, we should mark job's end time
+	// as elapsed end time.
+	if eventTS["end"] > 0 && eventTS["end"] < intEndTS {
+		endMark = eventTS["end"]
+	}
+
+elapsed:
+	// Get elapsed time of job in this interval in seconds
+	elapsedSeconds := (endMark - startMark) / 1000
+
+	// Get cpuSeconds and gpuSeconds of the current interval
+	var cpuSeconds, gpuSeconds int64
+	cpuSeconds = ncpus * elapsedSeconds
+	gpuSeconds = ngpus * elapsedSeconds
+
+	// Get cpuMemSeconds and gpuMemSeconds of current interval in MB
+	var cpuMemSeconds, gpuMemSeconds int64
+	if mem > 0 {
+		cpuMemSeconds = mem * elapsedSeconds / toBytes["M"]
+	} else {
+		cpuMemSeconds = elapsedSeconds
+	}
+
+	// Currently we use walltime as GPU mem time. This wont be a correct proxy
+	// if MIG is enabled in GPUs where different portions of memory can be
+	// allocated
+	// NOTE: Not sure how SLURM outputs the gres/gpu when MIG is activated.
+	// We need to check it and update this part to take GPU memory into account
+	if ngpus > 0 {
+		gpuMemSeconds = elapsedSeconds
+	}
+
+	// Expand nodelist range expressions
+	allNodes := helper.NodelistParser(components[sacctFieldMap["nodelist"]])
+	nodelistExp := strings.Join(allNodes, "|")
+
+	// Allocation
+	allocation := models.Allocation{
+		"nodes":   nnodes,
+		"cpus":    ncpus,
+		"mem":     mem,
+		"gpus":    ngpus,
+		"billing": billing,
+	}
+
+	// Tags
+	tags := models.Tag{
+		"uid":         uidInt,
+		"gid":         gidInt,
+		"partition":   components[sacctFieldMap["partition"]],
+		"qos":         components[sacctFieldMap["qos"]],
+		"exit_code":   components[sacctFieldMap["exitcode"]],
+		"nodelist":    components[sacctFieldMap["nodelist"]],
+		"nodelistexp": nodelistExp,
+		"workdir":     components[sacctFieldMap["workdir"]],
+	}
+
+	// Make jobStats struct for each job
+	return models.Unit{
+		ResourceManager: "slurm",
+		UUID:            components[sacctFieldMap["jobidraw"]],
+		Name:            components[sacctFieldMap["jobname"]],
+		Project:         components[sacctFieldMap["account"]],
+		Group:           components[sacctFieldMap["group"]],
+		User:            components[sacctFieldMap["user"]],
+		CreatedAt:       components[sacctFieldMap["submit"]],
+		StartedAt:       components[sacctFieldMap["start"]],
+		EndedAt:         components[sacctFieldMap["end"]],
+		CreatedAtTS:     eventTS["submit"],
+		StartedAtTS:     eventTS["start"],
+		EndedAtTS:       eventTS["end"],
+		Elapsed:         components[sacctFieldMap["elapsed"]],
+		State:           components[sacctFieldMap["state"]],
+		Allocation:      allocation,
+		TotalTime: models.MetricMap{
+			"walltime":         models.JSONFloat(elapsedSeconds),
+			"alloc_cputime":    models.JSONFloat(cpuSeconds),
+			"alloc_cpumemtime": models.JSONFloat(cpuMemSeconds),
+			"alloc_gputime":    models.JSONFloat(gpuSeconds),
+			"alloc_gpumemtime": models.JSONFloat(gpuMemSeconds),
+		},
+		Tags: tags,
+	}
 }
 
 // Parse sacctmgr command output and return association.
@@ -491,6 +612,46 @@ func parseSacctMgrCmdOutput(sacctMgrOutput string, currentTime string) ([]models
 	return userModels, projectModels
 }
 
+// Parse sacctmgr accounts command output and return coordinators of each account.
+// Each line has account name and comma separated coordinators.
+func parseSacctMgrCoordCmdOutput(sacctMgrOutput string) map[string][]string {
+	coordinators := make(map[string][]string)
+
+	for _, line := range strings.Split(sacctMgrOutput, "\n") {
+		account, coords, ok := strings.Cut(strings.TrimSpace(line), "|")
+		if !ok || account == "" || account == "root" || coords == "" {
+			continue
+		}
+
+		for _, coord := range strings.Split(coords, ",") {
+			if coord = strings.TrimSpace(coord); coord != "" {
+				coordinators[account] = append(coordinators[account], coord)
+			}
+		}
+	}
+
+	return coordinators
+}
+
+// setCoordinators sets coordinators of projects from coordinators map.
+func setCoordinators(projects []models.Project, coordinators map[string][]string) {
+	for i := range projects {
+		coords := coordinators[projects[i].Name]
+		if len(coords) == 0 {
+			continue
+		}
+
+		slices.Sort(coords)
+
+		var coordsList models.List
+		for _, c := range slices.Compact(coords) {
+			coordsList = append(coordsList, c)
+		}
+
+		projects[i].Coordinators = coordsList
+	}
+}
+
 // runSacctCmd executes sacct command and return output.
 func (s *slurmScheduler) runSacctCmd(ctx context.Context, start, end time.Time) ([]byte, error) {
 	// sacct path
@@ -502,14 +663,24 @@ func (s *slurmScheduler) runSacctCmd(ctx context.Context, start, end time.Time)
 		env = append(env, fmt.Sprintf("%s=%s", name, value))
 	}
 
+	// Output only allocations by default. When steps are included, drop -X
+	// flag to output steps as well
+	args := []string{"-D", "-X"}
+	fields := sacctFields
+
+	if s.config.IncludeSteps {
+		args = []string{"-D"}
+		fields = sacctStepFields
+	}
+
 	// Use jobIDRaw that outputs the array jobs as regular job IDs instead of id_array format
-	args := []string{
-		"-D", "-X", "--noheader", "--allusers", "--parsable2",
-		"--format", strings.Join(sacctFields, ","),
+	args = append(args,
+		"--noheader", "--allusers", "--parsable2",
+		"--format", strings.Join(fields, ","),
 		"--state", strings.Join(slurmStates, ","),
 		"--starttime", start.Format(base.DatetimeLayout),
 		"--endtime", end.Format(base.DatetimeLayout),
-	}
+	)
 
 	// Run command as slurm user
 	if s.cmdExecMode == capabilityMode {
@@ -546,11 +717,8 @@ func (s *slurmScheduler) runSacctCmd(ctx context.Context, start, end time.Time)
 	return internal_osexec.ExecuteContext(ctx, sacctPath, args, env)
 }
 
-// Run sacctmgr command and return output.
-func (s *slurmScheduler) runSacctMgrCmd(ctx context.Context) ([]byte, error) {
-	// Use jobIDRaw that outputs the array jobs as regular job IDs instead of id_array format
-	args := []string{"--parsable2", "--noheader", "list", "associations", "format=Account,User"}
-
+// Run sacctmgr command with args and return output.
+func (s *slurmScheduler) runSacctMgrCmd(ctx context.Context, args []string) ([]byte, error) {
 	// sacct path
 	sacctMgrPath := filepath.Join(s.cluster.CLI.Path, "sacctmgr")
 
@@ -610,9 +778,20 @@ func executeInSecurityContext(
 
 // Run preflight checks on provided config.
 func preflightChecks(s *slurmScheduler) error {
-	// // Always prefer REST API mode if configured
-	// if clusterConfig.Web.URL != "" {
-	// 	return checkRESTAPI(clusterConfig, logger)
-	// }
+	// Decode extra_config when provided
+	s.config = &slurmConfig{APIVersion: defaultAPIVersion}
+	if !s.cluster.Extra.IsZero() {
+		if err := s.cluster.Extra.Decode(s.config); err != nil {
+			s.logger.Error("Failed to decode extra_config for SLURM cluster", "err", err)
+
+			return err
+		}
+	}
+
+	// Always prefer REST API mode if configured
+	if s.cluster.Web.URL != "" {
+		return preflightsREST(s)
+	}
+
 	return preflightsCLI(s)
 }
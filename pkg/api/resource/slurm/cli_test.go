@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -65,8 +66,80 @@ func TestParseSacctCmdOutput(t *testing.T) {
 	assert.InEpsilon(t, 120, float64(units[0].TotalTime["walltime"]), 0)
 }
 
+func TestParseSacctStepsCmdOutput(t *testing.T) {
+	sacctStepsCmdOutput := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T14:37:02+0100|2023-02-21T14:37:07+0100|NA|01:49:22|3000|0:0|RUNNING|billing=80,cpu=160,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr||
+1479763.0||||||||2023-02-21T15:05:00+0100|2023-02-21T15:05:00+0100|2023-02-21T15:10:00+0100|00:05:00|300|0:0|COMPLETED|cpu=160,gres/gpu=8,mem=320G,node=2|compute-0|python|||
+1479763.1||||||||2023-02-21T14:40:00+0100|2023-02-21T14:40:00+0100|2023-02-21T14:50:00+0100|00:10:00|600|0:0|COMPLETED|cpu=160,mem=320G,node=2|compute-0|python|||
+1481510|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T15:00:00+0100|2023-02-21T15:05:00+0100|2023-02-21T15:10:00+0100|00:05:00|300|0:0|COMPLETED|billing=2,cpu=2,mem=4M,node=1|compute-1|het|/home/usr|1481510|0
+1481511|part2|qos1|acc1|grp|1000|usr|1000|2023-02-21T15:00:00+0100|2023-02-21T15:05:00+0100|2023-02-21T15:10:00+0100|00:05:00|300|0:0|COMPLETED|billing=8,cpu=4,gres/gpu=2,mem=8M,node=1|compute-gpu-0|het|/home/usr|1481510|1
+1481511.0||||||||2023-02-21T15:05:00+0100|2023-02-21T15:05:00+0100|2023-02-21T15:08:00+0100|00:03:00|180|0:0|COMPLETED|cpu=4,gres/gpu=2,mem=8M,node=1|compute-gpu-0|train|||
+1490000.0||||||||2023-02-21T15:05:00+0100|2023-02-21T15:05:00+0100|2023-02-21T15:08:00+0100|00:03:00|180|0:0|COMPLETED|cpu=4,mem=8M,node=1|compute-1|orphan|||`
+
+	units, numJobs, numSteps := parseSacctStepsCmdOutput(sacctStepsCmdOutput, start, end)
+	require.Len(t, units, 5)
+	assert.Equal(t, 3, numJobs)
+	assert.Equal(t, 2, numSteps)
+
+	// Job without steps must be same as in jobs only mode
+	assert.Empty(t, units[0].ParentUUID)
+	assert.Equal(t, "1479763", units[0].UUID)
+	assert.InEpsilon(t, 900, float64(units[0].TotalTime["walltime"]), 0)
+
+	// Leader of heterogeneous job must account for all components
+	assert.Equal(t, "1481510", units[1].UUID)
+	assert.Empty(t, units[1].ParentUUID)
+	assert.Equal(t, int64(6), units[1].Allocation["cpus"])
+	assert.Equal(t, int64(2), units[1].Allocation["gpus"])
+	assert.Equal(t, int64(12*1024*1024), units[1].Allocation["mem"])
+	assert.InEpsilon(t, 1800, float64(units[1].TotalTime["alloc_cputime"]), 0)
+	assert.InEpsilon(t, 600, float64(units[1].TotalTime["alloc_gputime"]), 0)
+	assert.Equal(t, "1481510", units[1].Tags["het_job_id"])
+	assert.Equal(t, int64(0), units[1].Tags["het_job_offset"])
+
+	// Component of heterogeneous job
+	assert.Equal(t, "1481511", units[2].UUID)
+	assert.Equal(t, "1481510", units[2].ParentUUID)
+	assert.Equal(t, int64(1), units[2].Tags["het_job_offset"])
+
+	// Steps must inherit fields from their jobs. Step that finished before
+	// interval and step without a job must be ignored
+	assert.Equal(t, "1479763.0", units[3].UUID)
+	assert.Equal(t, "1479763", units[3].ParentUUID)
+	assert.Equal(t, "usr", units[3].User)
+	assert.Equal(t, "acc1", units[3].Project)
+	assert.Equal(t, "0", units[3].Tags["step"])
+	assert.Equal(t, int64(1000), units[3].Tags["uid"])
+	assert.Equal(t, "part1", units[3].Tags["partition"])
+	assert.InEpsilon(t, 300, float64(units[3].TotalTime["walltime"]), 0)
+
+	assert.Equal(t, "1481511.0", units[4].UUID)
+	assert.Equal(t, "1481511", units[4].ParentUUID)
+	assert.Equal(t, "part2", units[4].Tags["partition"])
+	assert.InEpsilon(t, 180, float64(units[4].TotalTime["walltime"]), 0)
+}
+
 func TestParseSacctMgrCmdOutput(t *testing.T) {
 	users, projects := parseSacctMgrCmdOutput(sacctMgrCmdOutput, current.Format(base.DatetimezoneLayout))
 	require.ElementsMatch(t, expectedUsers, users)
 	require.ElementsMatch(t, expectedProjects, projects)
 }
+
+func TestParseSacctMgrCoordCmdOutput(t *testing.T) {
+	coordinators := parseSacctMgrCoordCmdOutput(sacctMgrCoordCmdOutput)
+	assert.Equal(t, map[string][]string{"prj3": {"usr1"}, "prj4": {"usr3", "usr2"}}, coordinators)
+
+	// Coordinators must be sorted and set only on projects that have them
+	_, projects := parseSacctMgrCmdOutput(sacctMgrCmdOutput, current.Format(base.DatetimezoneLayout))
+	setCoordinators(projects, coordinators)
+
+	for _, project := range projects {
+		switch project.Name {
+		case "prj3":
+			assert.Equal(t, models.List{"usr1"}, project.Coordinators)
+		case "prj4":
+			assert.Equal(t, models.List{"usr2", "usr3"}, project.Coordinators)
+		default:
+			assert.Empty(t, project.Coordinators, project.Name)
+		}
+	}
+}
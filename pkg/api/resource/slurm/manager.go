@@ -6,6 +6,9 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
 	"sync"
 	"time"
 
@@ -23,7 +26,8 @@ const (
 
 // Fetch modes.
 const (
-	cliMode = "cli"
+	cliMode  = "cli"
+	restMode = "rest"
 )
 
 // Security contexts.
@@ -38,6 +42,27 @@ type slurmScheduler struct {
 	fetchMode        string // Whether to fetch from REST API or CLI commands
 	cmdExecMode      string // If sacct mode is chosen, the mode of executing command, ie, sudo or cap or native
 	securityContexts map[string]*security.SecurityContext
+	apiURL           *url.URL     // slurmrestd URL when REST API mode is chosen
+	client           *http.Client // HTTP client for slurmrestd
+	config           *slurmConfig
+}
+
+// slurmConfig is the extra config of SLURM cluster.
+type slurmConfig struct {
+	APIVersion   string `yaml:"api_version"`
+	IncludeSteps bool   `yaml:"include_steps"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *slurmConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = slurmConfig{
+		APIVersion: defaultAPIVersion,
+	}
+
+	type plain slurmConfig
+
+	return unmarshal((*plain)(c))
 }
 
 const slurmBatchScheduler = "slurm"
@@ -50,11 +75,13 @@ var (
 		"submit", "start", "end", "elapsed", "elapsedraw", "exitcode", "state",
 		"alloctres", "nodelist", "jobname", "workdir",
 	}
-	slurmStates = []string{
+	// Heterogeneous job fields are needed only when job steps are included
+	sacctStepFields = append(slices.Clone(sacctFields), "hetjobid", "hetjoboffset")
+	slurmStates     = []string{
 		"CANCELLED", "COMPLETED", "FAILED", "NODE_FAIL", "PREEMPTED", "TIMEOUT",
 		"RUNNING",
 	}
-	sacctFieldMap = make(map[string]int, len(sacctFields))
+	sacctFieldMap = make(map[string]int, len(sacctStepFields))
 )
 
 func init() {
@@ -62,7 +89,7 @@ func init() {
 	resource.Register(slurmBatchScheduler, New)
 
 	// Convert slice to map with index as value
-	for idx, field := range sacctFields {
+	for idx, field := range sacctStepFields {
 		sacctFieldMap[field] = idx
 	}
 }
@@ -105,6 +132,16 @@ func (s *slurmScheduler) FetchUnits(
 		return []models.ClusterUnits{{Cluster: s.cluster, Units: jobs}}, nil
 	}
 
+	if s.fetchMode == restMode {
+		if jobs, err = s.fetchFromREST(ctx, start, end); err != nil {
+			s.logger.Error("Failed to fetch jobs from slurmrestd", "cluster_id", s.cluster.ID, "err", err)
+
+			return nil, err
+		}
+
+		return []models.ClusterUnits{{Cluster: s.cluster, Units: jobs}}, nil
+	}
+
 	return nil, fmt.Errorf("unknown fetch mode for compute units SLURM cluster %s", s.cluster.ID)
 }
 
@@ -127,10 +164,24 @@ func (s *slurmScheduler) FetchUsersProjects(
 		}
 
 		return []models.ClusterUsers{
-				{Cluster: s.cluster, Users: users},
-			}, []models.ClusterProjects{
-				{Cluster: s.cluster, Projects: projects},
-			}, nil
+			{Cluster: s.cluster, Users: users},
+		}, []models.ClusterProjects{
+			{Cluster: s.cluster, Projects: projects},
+		}, nil
+	}
+
+	if s.fetchMode == restMode {
+		if users, projects, err = s.fetchAssocFromREST(ctx, current); err != nil {
+			s.logger.Error("Failed to fetch associations from slurmrestd", "cluster_id", s.cluster.ID, "err", err)
+
+			return nil, nil, err
+		}
+
+		return []models.ClusterUsers{
+			{Cluster: s.cluster, Users: users},
+		}, []models.ClusterProjects{
+			{Cluster: s.cluster, Projects: projects},
+		}, nil
 	}
 
 	return nil, nil, fmt.Errorf("unknown fetch mode for projects for SLURM cluster %s", s.cluster.ID)
@@ -147,6 +198,16 @@ func (s *slurmScheduler) fetchFromSacct(ctx context.Context, start time.Time, en
 	}
 
 	// Parse sacct output and create BatchJob structs slice
+	if s.config.IncludeSteps {
+		jobs, numJobs, numSteps := parseSacctStepsCmdOutput(string(sacctOutput), start, end)
+		s.logger.Info(
+			"SLURM jobs fetched", "cluster_id", s.cluster.ID, "start", start, "end", end,
+			"num_jobs", numJobs, "num_steps", numSteps,
+		)
+
+		return jobs, nil
+	}
+
 	jobs, numJobs := parseSacctCmdOutput(string(sacctOutput), start, end)
 	s.logger.Info("SLURM jobs fetched", "cluster_id", s.cluster.ID, "start", start, "end", end, "num_jobs", numJobs)
 
@@ -162,7 +223,7 @@ func (s *slurmScheduler) fetchFromSacctMgr(
 	currentTime := current.Format(base.DatetimeLayout)
 
 	// Execute sacctmgr command
-	sacctMgrOutput, err := s.runSacctMgrCmd(ctx)
+	sacctMgrOutput, err := s.runSacctMgrCmd(ctx, sacctMgrAssocArgs)
 	if err != nil {
 		s.logger.Error("Failed to run sacctmgr command", "cluster_id", s.cluster.ID, "err", err)
 
@@ -173,5 +234,16 @@ func (s *slurmScheduler) fetchFromSacctMgr(
 	users, projects := parseSacctMgrCmdOutput(string(sacctMgrOutput), currentTime)
 	s.logger.Info("SLURM user account data fetched", "cluster_id", s.cluster.ID, "num_users", len(users), "num_accounts", len(projects))
 
+	// Coordinators of accounts are not essential. Log failures and continue
+	// without them
+	sacctMgrOutput, err = s.runSacctMgrCmd(ctx, sacctMgrCoordArgs)
+	if err != nil {
+		s.logger.Warn("Failed to fetch SLURM account coordinators", "cluster_id", s.cluster.ID, "err", err)
+
+		return users, projects, nil
+	}
+
+	setCoordinators(projects, parseSacctMgrCoordCmdOutput(string(sacctMgrOutput)))
+
 	return users, projects, nil
 }
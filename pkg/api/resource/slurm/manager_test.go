@@ -12,7 +12,9 @@ import (
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -31,6 +33,11 @@ prj3|usr2
 prj4|
 prj4|usr2
 prj4|usr3`
+	sacctMgrCoordCmdOutput = `root|
+prj1|
+prj2|
+prj3|usr1
+prj4|usr3,usr2`
 	expectedBatchJobs = []models.Unit{
 		{
 			ID:              0,
@@ -193,3 +200,36 @@ printf """%s"""`, sacctMgrCmdOutput)
 		require.NoError(t, err)
 	}
 }
+
+func TestSLURMFetcherWithSteps(t *testing.T) {
+	// Write sacct executable that outputs steps only when -X flag is not passed
+	tmpDir := t.TempDir()
+	sacctPath := filepath.Join(tmpDir, "sacct")
+	sacctScript := `#!/bin/bash
+if [[ " $* " == *" -X "* ]]; then
+  exit 0
+fi
+printf """1481508|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T13:49:20+0100|2023-02-21T13:49:06+0100|2023-02-21T15:10:23+0100|00:08:17|4920|0:0|COMPLETED|billing=1,cpu=2,mem=4M,node=1|compute-[0-2]|test_script2|/home/usr||
+1481508.batch||||||||2023-02-21T13:49:06+0100|2023-02-21T13:49:06+0100|2023-02-21T15:10:23+0100|00:08:17|4920|0:0|COMPLETED|cpu=2,mem=4M,node=1|compute-0|batch|||"""`
+	os.WriteFile(sacctPath, []byte(sacctScript), 0o700) // #nosec
+
+	var extra yaml.Node
+	err := yaml.Unmarshal([]byte("include_steps: true"), &extra)
+	require.NoError(t, err)
+
+	cluster := models.Cluster{
+		ID:      "slurm-0",
+		Manager: "slurm",
+		CLI:     models.CLIConfig{Path: tmpDir},
+		Extra:   extra,
+	}
+
+	slurm, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	units, err := slurm.FetchUnits(context.Background(), start, end)
+	require.NoError(t, err)
+	require.Len(t, units[0].Units, 2)
+	assert.Equal(t, "1481508.batch", units[0].Units[1].UUID)
+	assert.Equal(t, "1481508", units[0].Units[1].ParentUUID)
+}
@@ -0,0 +1,440 @@
+package slurm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/helper"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	config_util "github.com/prometheus/common/config"
+)
+
+// Default slurmrestd API version.
+const defaultAPIVersion = "v0.0.41"
+
+// slurmrestd API versions that are supported.
+var supportedAPIVersions = []string{"v0.0.40", "v0.0.41", "v0.0.42"}
+
+// restNumber is a number returned by slurmrestd. Starting from v0.0.40
+// most of the numbers are returned as objects with set, infinite and number
+// fields. This type handles both plain numbers and objects.
+type restNumber int64
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *restNumber) UnmarshalJSON(data []byte) error {
+	var num int64
+	if err := json.Unmarshal(data, &num); err == nil {
+		*n = restNumber(num)
+
+		return nil
+	}
+
+	var obj struct {
+		Set      bool  `json:"set"`
+		Infinite bool  `json:"infinite"`
+		Number   int64 `json:"number"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	if obj.Set && !obj.Infinite {
+		*n = restNumber(obj.Number)
+	}
+
+	return nil
+}
+
+// restError is the error returned by slurmrestd.
+type restError struct {
+	Description string `json:"description"`
+	ErrorNumber int    `json:"error_number"`
+	Error       string `json:"error"`
+	Source      string `json:"source"`
+}
+
+// restTRES is a trackable resource of a job.
+type restTRES struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	ID    int64  `json:"id"`
+	Count int64  `json:"count"`
+}
+
+// restJob is the job returned by slurmdb jobs endpoint.
+type restJob struct {
+	Account          string `json:"account"`
+	Group            string `json:"group"`
+	JobID            int64  `json:"job_id"`
+	Name             string `json:"name"`
+	Nodes            string `json:"nodes"`
+	Partition        string `json:"partition"`
+	QOS              string `json:"qos"`
+	User             string `json:"user"`
+	WorkingDirectory string `json:"working_directory"`
+	State            struct {
+		Current []string `json:"current"`
+	} `json:"state"`
+	ExitCode struct {
+		ReturnCode restNumber `json:"return_code"`
+		Signal     struct {
+			ID restNumber `json:"id"`
+		} `json:"signal"`
+	} `json:"exit_code"`
+	Time struct {
+		Elapsed    restNumber `json:"elapsed"`
+		Submission restNumber `json:"submission"`
+		Start      restNumber `json:"start"`
+		End        restNumber `json:"end"`
+	} `json:"time"`
+	TRES struct {
+		Allocated []restTRES `json:"allocated"`
+	} `json:"tres"`
+}
+
+// restAssociation is the association returned by slurmdb associations endpoint.
+type restAssociation struct {
+	Account string `json:"account"`
+	Cluster string `json:"cluster"`
+	User    string `json:"user"`
+}
+
+// restAccount is the account returned by slurmdb accounts endpoint.
+type restAccount struct {
+	Name         string `json:"name"`
+	Coordinators []struct {
+		Name string `json:"name"`
+	} `json:"coordinators"`
+}
+
+type restJobsResponse struct {
+	Jobs   []restJob   `json:"jobs"`
+	Errors []restError `json:"errors"`
+}
+
+type restAssociationsResponse struct {
+	Associations []restAssociation `json:"associations"`
+	Errors       []restError       `json:"errors"`
+}
+
+type restAccountsResponse struct {
+	Accounts []restAccount `json:"accounts"`
+	Errors   []restError   `json:"errors"`
+}
+
+// Run preflights for REST API mode.
+func preflightsREST(slurm *slurmScheduler) error {
+	slurm.fetchMode = restMode
+	slurm.logger.Debug("Using SLURM REST API")
+
+	var err error
+
+	if slurm.apiURL, err = url.Parse(slurm.cluster.Web.URL); err != nil {
+		slurm.logger.Error("Failed to parse slurmrestd URL", "url", slurm.cluster.Web.URL, "err", err)
+
+		return err
+	}
+
+	// Job steps are available only from sacct
+	if slurm.config.IncludeSteps {
+		slurm.logger.Warn("Job steps are not supported in REST API mode. Only jobs will be fetched", "cluster_id", slurm.cluster.ID)
+
+		slurm.config.IncludeSteps = false
+	}
+
+	if !slices.Contains(supportedAPIVersions, slurm.config.APIVersion) {
+		slurm.logger.Error(
+			"Unsupported slurmrestd API version", "version", slurm.config.APIVersion,
+			"supported", strings.Join(supportedAPIVersions, ","),
+		)
+
+		return fmt.Errorf("unsupported slurmrestd api version %s", slurm.config.APIVersion)
+	}
+
+	// Make a HTTP client for slurmrestd from client config. JWT token
+	// must be set in the HTTP headers of the client config
+	if slurm.client, err = config_util.NewClientFromConfig(slurm.cluster.Web.HTTPClientConfig, "slurmrestd"); err != nil {
+		slurm.logger.Error("Failed to create HTTP client for slurmrestd", "err", err)
+
+		return err
+	}
+
+	return nil
+}
+
+// jobs endpoint.
+func (s *slurmScheduler) jobs() *url.URL {
+	return s.apiURL.JoinPath("/slurmdb", s.config.APIVersion, "jobs")
+}
+
+// associations endpoint.
+func (s *slurmScheduler) associations() *url.URL {
+	return s.apiURL.JoinPath("/slurmdb", s.config.APIVersion, "associations")
+}
+
+// accounts endpoint.
+func (s *slurmScheduler) accounts() *url.URL {
+	return s.apiURL.JoinPath("/slurmdb", s.config.APIVersion, "accounts")
+}
+
+// Get jobs from slurmrestd.
+func (s *slurmScheduler) fetchFromREST(ctx context.Context, start time.Time, end time.Time) ([]models.Unit, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.jobs().String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to fetch jobs from slurmrestd: %w", err)
+	}
+
+	// Use same filters as sacct command
+	q := req.URL.Query()
+	q.Add("start_time", strconv.FormatInt(start.Unix(), 10))
+	q.Add("end_time", strconv.FormatInt(end.Unix(), 10))
+	q.Add("state", strings.Join(slurmStates, ","))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := apiRequest[restJobsResponse](req, s.client)
+	if err != nil {
+		s.logger.Error("Failed to fetch jobs from slurmrestd", "cluster_id", s.cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	// Parse jobs and create units slice
+	jobs, numJobs := parseRESTJobs(resp.Jobs, start, end)
+	s.logger.Info("SLURM jobs fetched", "cluster_id", s.cluster.ID, "start", start, "end", end, "num_jobs", numJobs)
+
+	return jobs, nil
+}
+
+// Get user project association from slurmrestd.
+func (s *slurmScheduler) fetchAssocFromREST(
+	ctx context.Context,
+	current time.Time,
+) ([]models.User, []models.Project, error) {
+	// Get current time string
+	currentTime := current.Format(base.DatetimeLayout)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.associations().String(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request to fetch associations from slurmrestd: %w", err)
+	}
+
+	resp, err := apiRequest[restAssociationsResponse](req, s.client)
+	if err != nil {
+		s.logger.Error("Failed to fetch associations from slurmrestd", "cluster_id", s.cluster.ID, "err", err)
+
+		return nil, nil, err
+	}
+
+	// Parse associations the same way as sacctmgr output
+	users, projects := parseSacctMgrCmdOutput(restAssociationLines(resp.Associations), currentTime)
+	s.logger.Info("SLURM user account data fetched", "cluster_id", s.cluster.ID, "num_users", len(users), "num_accounts", len(projects))
+
+	// Coordinators of accounts are not essential. Log failures and continue
+	// without them
+	coordinators, err := s.fetchCoordFromREST(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to fetch SLURM account coordinators", "cluster_id", s.cluster.ID, "err", err)
+
+		return users, projects, nil
+	}
+
+	setCoordinators(projects, coordinators)
+
+	return users, projects, nil
+}
+
+// Get coordinators of accounts from slurmrestd.
+func (s *slurmScheduler) fetchCoordFromREST(ctx context.Context) (map[string][]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.accounts().String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to fetch accounts from slurmrestd: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Add("WithCoordinators", "true")
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := apiRequest[restAccountsResponse](req, s.client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse accounts the same way as sacctmgr output
+	return parseSacctMgrCoordCmdOutput(restAccountLines(resp.Accounts)), nil
+}
+
+// parseRESTJobs converts jobs returned by slurmrestd to fields of sacct output
+// and returns units. This ensures that units are same as the ones from sacct.
+func parseRESTJobs(restJobs []restJob, start time.Time, end time.Time) ([]models.Unit, int) {
+	jobs := make([]models.Unit, 0, len(restJobs))
+
+	// Cache of UIDs and GIDs. slurmrestd returns only names of user and group
+	uids := make(map[string]string)
+	gids := make(map[string]string)
+
+	for _, job := range restJobs {
+		if _, ok := uids[job.User]; !ok {
+			if u, err := user.Lookup(job.User); err == nil {
+				uids[job.User] = u.Uid
+			} else {
+				uids[job.User] = ""
+			}
+		}
+
+		if _, ok := gids[job.Group]; !ok {
+			if g, err := user.LookupGroup(job.Group); err == nil {
+				gids[job.Group] = g.Gid
+			} else {
+				gids[job.Group] = ""
+			}
+		}
+
+		components := make([]string, len(sacctFields))
+		components[sacctFieldMap["jobidraw"]] = strconv.FormatInt(job.JobID, 10)
+		components[sacctFieldMap["partition"]] = job.Partition
+		components[sacctFieldMap["qos"]] = job.QOS
+		components[sacctFieldMap["account"]] = job.Account
+		components[sacctFieldMap["group"]] = job.Group
+		components[sacctFieldMap["gid"]] = gids[job.Group]
+		components[sacctFieldMap["user"]] = job.User
+		components[sacctFieldMap["uid"]] = uids[job.User]
+		components[sacctFieldMap["submit"]] = restTime(job.Time.Submission)
+		components[sacctFieldMap["start"]] = restTime(job.Time.Start)
+		components[sacctFieldMap["end"]] = restTime(job.Time.End)
+		components[sacctFieldMap["elapsed"]] = helper.FormatElapsed(time.Duration(job.Time.Elapsed) * time.Second)
+		components[sacctFieldMap["elapsedraw"]] = strconv.FormatInt(int64(job.Time.Elapsed), 10)
+		components[sacctFieldMap["exitcode"]] = fmt.Sprintf("%d:%d", job.ExitCode.ReturnCode, job.ExitCode.Signal.ID)
+		components[sacctFieldMap["state"]] = strings.Join(job.State.Current, ",")
+		components[sacctFieldMap["alloctres"]] = restAllocTRES(job.TRES.Allocated)
+		components[sacctFieldMap["nodelist"]] = job.Nodes
+		components[sacctFieldMap["jobname"]] = job.Name
+		components[sacctFieldMap["workdir"]] = job.WorkingDirectory
+
+		if unit, ok := parseSacctJob(components, start, end); ok {
+			jobs = append(jobs, unit)
+		}
+	}
+
+	return jobs, len(jobs)
+}
+
+// restAssociationLines returns associations in the format of sacctmgr output.
+func restAssociationLines(assocs []restAssociation) string {
+	lines := make([]string, len(assocs))
+	for i, assoc := range assocs {
+		lines[i] = fmt.Sprintf("%s|%s", assoc.Account, assoc.User)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// restAccountLines returns accounts and their coordinators in the format of
+// sacctmgr output.
+func restAccountLines(accounts []restAccount) string {
+	lines := make([]string, len(accounts))
+	for i, account := range accounts {
+		coords := make([]string, len(account.Coordinators))
+		for j, coord := range account.Coordinators {
+			coords[j] = coord.Name
+		}
+
+		lines[i] = fmt.Sprintf("%s|%s", account.Name, strings.Join(coords, ","))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// restAllocTRES returns allocated TRES in the format of sacct alloctres field.
+func restAllocTRES(tres []restTRES) string {
+	elems := make([]string, len(tres))
+
+	for i, t := range tres {
+		name := t.Type
+		if t.Name != "" {
+			name = fmt.Sprintf("%s/%s", t.Type, t.Name)
+		}
+
+		// slurmrestd returns memory in MiB
+		if t.Type == "mem" {
+			elems[i] = fmt.Sprintf("%s=%dM", name, t.Count)
+		} else {
+			elems[i] = fmt.Sprintf("%s=%d", name, t.Count)
+		}
+	}
+
+	return strings.Join(elems, ",")
+}
+
+// restTime returns the unix timestamp in the format used by sacct.
+func restTime(ts restNumber) string {
+	if ts == 0 {
+		return "Unknown"
+	}
+
+	return time.Unix(int64(ts), 0).Format(base.DatetimezoneLayout)
+}
+
+// apiRequest makes the request using client and returns response.
+func apiRequest[T interface{ restErrors() []restError }](req *http.Request, client *http.Client) (T, error) {
+	// Add necessary headers
+	req.Header.Add("Accept", "application/json")
+
+	// Make request
+	resp, err := client.Do(req)
+	if err != nil {
+		return *new(T), err
+	}
+	defer resp.Body.Close()
+
+	// Check status code
+	if resp.StatusCode != http.StatusOK {
+		return *new(T), fmt.Errorf("request failed with status: %d", resp.StatusCode)
+	}
+
+	// Read response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return *new(T), err
+	}
+
+	// Unpack into data
+	var data T
+	if err = json.Unmarshal(body, &data); err != nil {
+		return *new(T), err
+	}
+
+	// Check for errors reported by slurmrestd
+	if restErrs := data.restErrors(); len(restErrs) > 0 {
+		errs := make([]error, len(restErrs))
+		for i, e := range restErrs {
+			errs[i] = fmt.Errorf("slurmrestd error %d: %s: %s", e.ErrorNumber, e.Error, e.Description)
+		}
+
+		return *new(T), errors.Join(errs...)
+	}
+
+	return data, nil
+}
+
+func (r restJobsResponse) restErrors() []restError {
+	return r.Errors
+}
+
+func (r restAssociationsResponse) restErrors() []restError {
+	return r.Errors
+}
+
+func (r restAccountsResponse) restErrors() []restError {
+	return r.Errors
+}
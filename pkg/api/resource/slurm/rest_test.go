@@ -0,0 +1,262 @@
+package slurm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const restToken = "slurmjwtsecret"
+
+func mockSlurmRESTServer() *httptest.Server {
+	// Start test server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Slurm-User-Token") != restToken {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		// Mimic errors returned by slurmrestd
+		if strings.Contains(r.URL.Path, "v0.0.42") {
+			w.Write([]byte(`{"jobs": [], "associations": [], "errors": [{"description": "Unable to query", "error_number": 9003, "error": "Unable to connect to database", "source": "slurmdb"}]}`))
+
+			return
+		}
+
+		var fileName string
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/jobs"):
+			// Check that filters are passed
+			if r.URL.Query().Get("start_time") == "" || r.URL.Query().Get("end_time") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			fileName = "jobs"
+		case strings.HasSuffix(r.URL.Path, "/associations"):
+			fileName = "associations"
+		case strings.HasSuffix(r.URL.Path, "/accounts"):
+			fileName = "accounts"
+		default:
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if data, err := os.ReadFile(fmt.Sprintf("../../testdata/slurmrestd/%s.json", fileName)); err == nil {
+			w.Write(data)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	return server
+}
+
+func mockRESTCluster(url string, token string, extraConfig string) (models.Cluster, error) {
+	var extra yaml.Node
+
+	if extraConfig != "" {
+		if err := yaml.Unmarshal([]byte(extraConfig), &extra); err != nil {
+			return models.Cluster{}, err
+		}
+	}
+
+	cluster := models.Cluster{
+		ID:      "slurm-0",
+		Manager: "slurm",
+		Extra:   extra,
+	}
+	cluster.Web.URL = url
+	cluster.Web.HTTPClientConfig.HTTPHeaders = &config_util.Headers{
+		Headers: map[string]config_util.Header{
+			"X-SLURM-USER-NAME":  {Values: []string{"ceems"}},
+			"X-SLURM-USER-TOKEN": {Secrets: []config_util.Secret{config_util.Secret(token)}},
+		},
+	}
+
+	return cluster, nil
+}
+
+// expectedRESTBatchJobs returns the units that slurmrestd must return for the
+// same jobs as in sacct output.
+func expectedRESTBatchJobs() []models.Unit {
+	// slurmrestd does not return UID and GID. They are looked up on the host
+	var uid, gid int64
+	if u, err := user.Lookup("usr"); err == nil {
+		uid, _ = strconv.ParseInt(u.Uid, 10, 64)
+	}
+
+	if g, err := user.LookupGroup("grp"); err == nil {
+		gid, _ = strconv.ParseInt(g.Gid, 10, 64)
+	}
+
+	units := make([]models.Unit, len(expectedBatchJobs))
+	for i, unit := range expectedBatchJobs {
+		unit.Tags = maps.Clone(unit.Tags)
+		unit.Tags["uid"] = uid
+		unit.Tags["gid"] = gid
+		units[i] = unit
+	}
+
+	// sacct outputs Unknown for end time of running jobs
+	units[0].EndedAt = "Unknown"
+
+	return units
+}
+
+func TestSLURMRESTFetcher(t *testing.T) {
+	// Setup mock slurmrestd server
+	server := mockSlurmRESTServer()
+	defer server.Close()
+
+	cluster, err := mockRESTCluster(server.URL, restToken, "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	slurm, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	assert.Equal(t, restMode, slurm.(*slurmScheduler).fetchMode)
+
+	units, err := slurm.FetchUnits(ctx, start, end)
+	require.NoError(t, err)
+	assert.Equal(t, expectedRESTBatchJobs(), units[0].Units)
+
+	// Users and projects must be same as the ones from sacctmgr
+	expectedUsers, expectedProjects := parseSacctMgrCmdOutput(sacctMgrCmdOutput, current.Format(base.DatetimeLayout))
+	setCoordinators(expectedProjects, parseSacctMgrCoordCmdOutput(sacctMgrCoordCmdOutput))
+
+	users, projects, err := slurm.FetchUsersProjects(ctx, current)
+	require.NoError(t, err)
+	assert.Equal(t, expectedUsers, users[0].Users)
+	assert.Equal(t, expectedProjects, projects[0].Projects)
+}
+
+func TestSLURMRESTFetcherExtraConfig(t *testing.T) {
+	// Setup mock slurmrestd server
+	server := mockSlurmRESTServer()
+	defer server.Close()
+
+	cluster, err := mockRESTCluster(server.URL, restToken, `
+---
+api_version: v0.0.40`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	slurm, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/slurmdb/v0.0.40/jobs", slurm.(*slurmScheduler).jobs().String())
+
+	units, err := slurm.FetchUnits(ctx, start, end)
+	require.NoError(t, err)
+	assert.Len(t, units[0].Units, 2)
+
+	// Unsupported API version
+	cluster, err = mockRESTCluster(server.URL, restToken, `
+---
+api_version: v0.0.38`)
+	require.NoError(t, err)
+
+	_, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Error(t, err)
+}
+
+func TestSLURMRESTFetcherFail(t *testing.T) {
+	// Setup mock slurmrestd server
+	server := mockSlurmRESTServer()
+
+	// Cluster with wrong token
+	cluster, err := mockRESTCluster(server.URL, "wrongtoken", "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	slurm, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, err = slurm.FetchUnits(ctx, start, end)
+	require.Error(t, err)
+
+	// Errors returned by slurmrestd
+	cluster, err = mockRESTCluster(server.URL, restToken, "api_version: v0.0.42")
+	require.NoError(t, err)
+
+	slurm, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, err = slurm.FetchUnits(ctx, start, end)
+	require.ErrorContains(t, err, "Unable to connect to database")
+
+	_, _, err = slurm.FetchUsersProjects(ctx, current)
+	require.Error(t, err)
+
+	// Stop test server to simulate when slurmrestd is offline
+	server.Close()
+
+	cluster, err = mockRESTCluster(server.URL, restToken, "")
+	require.NoError(t, err)
+
+	slurm, err = New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, _, err = slurm.FetchUsersProjects(ctx, current)
+	require.Error(t, err)
+}
+
+func TestRestNumber(t *testing.T) {
+	var v struct {
+		Plain    restNumber `json:"plain"`
+		Set      restNumber `json:"set"`
+		Unset    restNumber `json:"unset"`
+		Infinite restNumber `json:"infinite"`
+	}
+
+	err := json.Unmarshal([]byte(`{
+		"plain": 10,
+		"set": {"set": true, "infinite": false, "number": 20},
+		"unset": {"set": false, "infinite": false, "number": 30},
+		"infinite": {"set": true, "infinite": true, "number": 0}
+	}`), &v)
+	require.NoError(t, err)
+	assert.Equal(t, restNumber(10), v.Plain)
+	assert.Equal(t, restNumber(20), v.Set)
+	assert.Equal(t, restNumber(0), v.Unset)
+	assert.Equal(t, restNumber(0), v.Infinite)
+
+	err = json.Unmarshal([]byte(`{"plain": "invalid"}`), &v)
+	require.Error(t, err)
+}
+
+func TestRestAllocTRES(t *testing.T) {
+	tres := []restTRES{
+		{Type: "cpu", Count: 4},
+		{Type: "mem", Count: 2048},
+		{Type: "gres", Name: "gpu:a100", Count: 2},
+	}
+	assert.Equal(t, "cpu=4,mem=2048M,gres/gpu:a100=2", restAllocTRES(tres))
+	assert.Empty(t, restAllocTRES(nil))
+}
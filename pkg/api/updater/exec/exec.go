@@ -0,0 +1,371 @@
+// Package exec provides an updater that mutates compute units using an
+// external command
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	osexec "os/exec"
+	"os/user"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	internal_osexec "github.com/mahendrapaipuri/ceems/internal/osexec"
+	"github.com/mahendrapaipuri/ceems/internal/security"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
+	"github.com/prometheus/common/model"
+	"kernel.org/pub/linux/libs/security/libcap/cap"
+)
+
+// Name of the exec updater.
+const (
+	execUpdaterID = "exec"
+)
+
+// Name of the security context to execute commands.
+const (
+	execCmdCtx = "exec_updater_cmd"
+)
+
+// Defaults of exec updater.
+const (
+	defaultTimeout = model.Duration(30 * time.Second)
+	defaultRunAs   = "nobody"
+	defaultPath    = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// Required capabilities to execute commands as a different user.
+var requiredCaps = []string{"cap_setuid", "cap_setgid"}
+
+// Custom errors.
+var (
+	errNoCmd        = errors.New("path of command must be set in cli.path")
+	errTimeout      = errors.New("timeout must be more than 0")
+	errNoPrivileges = errors.New("current process does not have privileges to execute command as run_as user")
+	errUnitsAdded   = errors.New("command added units")
+	errUnitsRemoved = errors.New("command removed units")
+)
+
+// execConfig is the container for the configuration of exec updater.
+type execConfig struct {
+	Args    []string       `yaml:"args"`
+	Timeout model.Duration `yaml:"timeout"`
+	RunAs   string         `yaml:"run_as"`
+}
+
+// validate validates the config.
+func (c *execConfig) validate() error {
+	if c.Timeout <= 0 {
+		return errTimeout
+	}
+
+	return nil
+}
+
+// execUpdater pipes compute units as JSON to a command and reads back mutated
+// units from its stdout.
+type execUpdater struct {
+	logger      *slog.Logger
+	config      *execConfig
+	cmd         []string
+	env         []string
+	uid         int
+	gid         int
+	securityCtx *security.SecurityContext
+}
+
+// Register exec updater.
+func init() {
+	updater.Register(execUpdaterID, New)
+}
+
+// New creates a new exec updater.
+func New(instance updater.Instance, logger *slog.Logger) (updater.Updater, error) {
+	if instance.CLI.Path == "" {
+		logger.Error("Failed to setup exec updater", "id", instance.ID, "err", errNoCmd)
+
+		return nil, errNoCmd
+	}
+
+	// Make config from instances extra config
+	config := execConfig{
+		Timeout: defaultTimeout,
+	}
+	if !instance.Extra.IsZero() {
+		if err := instance.Extra.Decode(&config); err != nil {
+			logger.Error("Failed to setup exec updater", "id", instance.ID, "err", err)
+
+			return nil, err
+		}
+	}
+
+	// Validate config
+	if err := config.validate(); err != nil {
+		logger.Error("Failed to validate exec updater config", "instance_id", instance.ID, "err", err)
+
+		return nil, err
+	}
+
+	// Check if command exists
+	if _, err := os.Stat(instance.CLI.Path); err != nil {
+		logger.Error("Failed to find command of exec updater", "instance_id", instance.ID, "path", instance.CLI.Path, "err", err)
+
+		return nil, err
+	}
+
+	u := &execUpdater{
+		logger: logger.With("id", instance.ID),
+		config: &config,
+		cmd:    append([]string{instance.CLI.Path}, config.Args...),
+	}
+
+	// Command is executed only with configured env vars and a minimal PATH so
+	// that credentials in the environment of API server are not leaked
+	if _, ok := instance.CLI.EnvVars["PATH"]; !ok {
+		u.env = append(u.env, "PATH="+defaultPath)
+	}
+
+	for name, value := range instance.CLI.EnvVars {
+		u.env = append(u.env, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	if err := u.setupSecurityContext(); err != nil {
+		logger.Error("Failed to setup security context for exec updater", "instance_id", instance.ID, "err", err)
+
+		return nil, err
+	}
+
+	logger.Info("exec updater setup successful", "id", instance.ID, "run_as", config.RunAs)
+
+	return u, nil
+}
+
+// setupSecurityContext sets up a security context to execute command as
+// run_as user when current process has enough privileges. Commands are
+// executed as nobody by default in that case.
+func (u *execUpdater) setupSecurityContext() error {
+	currentUser, err := user.Current()
+	if err != nil {
+		return err
+	}
+
+	// Check if current capabilities have required caps
+	haveCaps := true
+
+	currentCaps := cap.GetProc().String()
+	for _, name := range requiredCaps {
+		if !strings.Contains(currentCaps, name) {
+			haveCaps = false
+
+			break
+		}
+	}
+
+	// Without privileges, commands can only be executed as current user
+	if currentUser.Uid != "0" && !haveCaps {
+		if u.config.RunAs != "" && u.config.RunAs != currentUser.Username {
+			return fmt.Errorf("%w: %s", errNoPrivileges, u.config.RunAs)
+		}
+
+		u.config.RunAs = currentUser.Username
+
+		return nil
+	}
+
+	if u.config.RunAs == "" {
+		u.config.RunAs = defaultRunAs
+	}
+
+	runAsUser, err := user.Lookup(u.config.RunAs)
+	if err != nil {
+		return err
+	}
+
+	if u.uid, err = strconv.Atoi(runAsUser.Uid); err != nil {
+		return err
+	}
+
+	if u.gid, err = strconv.Atoi(runAsUser.Gid); err != nil {
+		return err
+	}
+
+	var caps []cap.Value
+
+	for _, name := range requiredCaps {
+		value, err := cap.FromName(name)
+		if err != nil {
+			u.logger.Error("Error parsing capability", "name", name, "err", err)
+
+			continue
+		}
+
+		caps = append(caps, value)
+	}
+
+	u.securityCtx, err = security.NewSecurityContext(execCmdCtx, caps, security.ExecAsUser, u.logger)
+
+	return err
+}
+
+// Update pipes units of each cluster to command and updates them with the
+// units returned by the command. If command fails or returns invalid units,
+// units are returned unchanged.
+func (u *execUpdater) Update(
+	ctx context.Context,
+	startTime time.Time,
+	endTime time.Time,
+	units []models.ClusterUnits,
+) []models.ClusterUnits {
+	for i := range units {
+		if len(units[i].Units) == 0 {
+			continue
+		}
+
+		updatedUnits, err := u.update(ctx, units[i])
+		if err != nil {
+			u.logger.Error(
+				"Failed to update units with exec updater", "cluster_id", units[i].Cluster.ID,
+				"start", startTime, "end", endTime, "err", err,
+			)
+
+			continue
+		}
+
+		units[i].Units = updatedUnits
+	}
+
+	return units
+}
+
+// update executes command with units of a cluster and returns validated units.
+func (u *execUpdater) update(ctx context.Context, clusterUnits models.ClusterUnits) ([]models.Unit, error) {
+	stdin, err := json.Marshal([]models.ClusterUnits{clusterUnits})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode units: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(u.config.Timeout))
+	defer cancel()
+
+	stdout, err := u.execute(ctx, stdin)
+	if err != nil {
+		var exitErr *osexec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+
+		return nil, err
+	}
+
+	var updated []models.ClusterUnits
+
+	decoder := json.NewDecoder(bytes.NewReader(stdout))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&updated); err != nil {
+		return nil, fmt.Errorf("failed to decode units from command output: %w", err)
+	}
+
+	var updatedUnits []models.Unit
+	for _, cluster := range updated {
+		updatedUnits = append(updatedUnits, cluster.Units...)
+	}
+
+	return mergeUnits(clusterUnits.Units, updatedUnits)
+}
+
+// execute executes command with stdin and returns its stdout.
+func (u *execUpdater) execute(ctx context.Context, stdin []byte) ([]byte, error) {
+	if u.securityCtx == nil {
+		return internal_osexec.ExecuteContextWithStdin(ctx, u.cmd[0], u.cmd[1:], stdin, u.env)
+	}
+
+	dataPtr := &security.ExecSecurityCtxData{
+		Context: ctx,
+		Cmd:     u.cmd,
+		Environ: u.env,
+		Logger:  u.logger,
+		UID:     u.uid,
+		GID:     u.gid,
+		StdIn:   stdin,
+	}
+
+	if err := u.securityCtx.Exec(dataPtr); err != nil {
+		return nil, err
+	}
+
+	return dataPtr.StdOut, nil
+}
+
+// mergeUnits validates that updated units have the same UUIDs as the original
+// ones and returns updated units in the order of original units. Fields that
+// are internal to CEEMS and identifiers of cluster are preserved from original
+// units.
+func mergeUnits(units []models.Unit, updatedUnits []models.Unit) ([]models.Unit, error) {
+	updatedMap := make(map[string]models.Unit, len(updatedUnits))
+
+	for _, unit := range updatedUnits {
+		if _, ok := updatedMap[unit.UUID]; ok {
+			return nil, fmt.Errorf("%w: duplicate uuid %s", errUnitsAdded, unit.UUID)
+		}
+
+		updatedMap[unit.UUID] = unit
+	}
+
+	merged := make([]models.Unit, len(units))
+
+	for i, unit := range units {
+		updatedUnit, ok := updatedMap[unit.UUID]
+		if !ok {
+			return nil, fmt.Errorf("%w: uuid %s", errUnitsRemoved, unit.UUID)
+		}
+
+		delete(updatedMap, unit.UUID)
+
+		updatedUnit.ID = unit.ID
+		updatedUnit.ClusterID = unit.ClusterID
+		updatedUnit.ResourceManager = unit.ResourceManager
+		updatedUnit.Ignore = unit.Ignore
+		updatedUnit.NumUpdates = unit.NumUpdates
+		updatedUnit.LastUpdatedAt = unit.LastUpdatedAt
+		updatedUnit.Allocation = normaliseGeneric(updatedUnit.Allocation)
+		updatedUnit.Tags = normaliseGeneric(updatedUnit.Tags)
+
+		merged[i] = updatedUnit
+	}
+
+	if len(updatedMap) > 0 {
+		return nil, fmt.Errorf("%w: uuids %s", errUnitsAdded, strings.Join(slices.Sorted(maps.Keys(updatedMap)), ","))
+	}
+
+	return merged, nil
+}
+
+// normaliseGeneric converts JSON numbers in generic map into int64 when
+// possible and float64 otherwise as numbers in allocation and tags are
+// expected to be integers.
+func normaliseGeneric(g models.Generic) models.Generic {
+	for key, value := range g {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+
+		if v, err := number.Int64(); err == nil {
+			g[key] = v
+		} else if v, err := number.Float64(); err == nil {
+			g[key] = v
+		}
+	}
+
+	return g
+}
@@ -0,0 +1,204 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var testUnits = []models.Unit{
+	{
+		ID:              10,
+		ClusterID:       "slurm-0",
+		ResourceManager: "slurm",
+		UUID:            "1",
+		Project:         "prj1",
+		Allocation:      models.Allocation{"cpus": int64(2)},
+		TotalTime:       models.MetricMap{"walltime": 3600},
+		Tags:            models.Tag{"partition": "cpu"},
+		NumUpdates:      2,
+	},
+	{
+		ID:              11,
+		ClusterID:       "slurm-0",
+		ResourceManager: "slurm",
+		UUID:            "2",
+		Project:         "prj2",
+		Allocation:      models.Allocation{"cpus": int64(4)},
+		TotalTime:       models.MetricMap{"walltime": 1800},
+		Tags:            models.Tag{"partition": "gpu"},
+		NumUpdates:      1,
+	},
+}
+
+func mockInstance(t *testing.T, script string, extraConfig string) updater.Instance {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "update.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/bash\n"+script+"\n"), 0o700)) //nolint:gosec
+
+	var extra yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(extraConfig), &extra))
+
+	return updater.Instance{
+		ID:      "exec-0",
+		Updater: "exec",
+		CLI: models.CLIConfig{
+			Path:    path,
+			EnvVars: map[string]string{"OWNER": "team1"},
+		},
+		Extra: extra,
+	}
+}
+
+func mockClusterUnits() []models.ClusterUnits {
+	units := make([]models.Unit, len(testUnits))
+
+	for i, unit := range testUnits {
+		units[i] = unit
+		units[i].Tags = models.Tag{}
+
+		for k, v := range unit.Tags {
+			units[i].Tags[k] = v
+		}
+	}
+
+	return []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0", Manager: "slurm"},
+			Units:   units,
+		},
+	}
+}
+
+func TestExecUpdater(t *testing.T) {
+	currentUser, err := user.Current()
+	require.NoError(t, err)
+
+	// Add a tag to each unit, correct allocation of unit 2 and attempt to change
+	// internal fields which must be ignored
+	script := `sed -e "s/\"tags\":{/\"tags\":{\"owner\":\"${OWNER}\",/g" \
+  -e 's/"cpus":4/"cpus":8/' \
+  -e 's/"cluster_id":"slurm-0"/"cluster_id":"slurm-1"/g'`
+
+	instance := mockInstance(t, script, fmt.Sprintf("timeout: 5s\nrun_as: %s", currentUser.Username))
+
+	u, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	units := u.Update(context.Background(), time.Now(), time.Now(), mockClusterUnits())
+
+	expected := mockClusterUnits()[0].Units
+	expected[0].Tags["owner"] = "team1"
+	expected[1].Tags["owner"] = "team1"
+	expected[1].Allocation = models.Allocation{"cpus": int64(8)}
+
+	assert.Equal(t, expected, units[0].Units)
+}
+
+func TestExecUpdaterEnviron(t *testing.T) {
+	currentUser, err := user.Current()
+	require.NoError(t, err)
+
+	// Environment of API server must not be available to command
+	t.Setenv("CEEMS_SECRET", "secret")
+
+	script := `sed -e "s/\"tags\":{/\"tags\":{\"secret\":\"${CEEMS_SECRET}\",\"path\":\"${PATH//\//_}\",/g"`
+
+	instance := mockInstance(t, script, "run_as: "+currentUser.Username)
+
+	u, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	units := u.Update(context.Background(), time.Now(), time.Now(), mockClusterUnits())
+
+	for _, unit := range units[0].Units {
+		assert.Equal(t, "", unit.Tags["secret"])
+		assert.Equal(t, strings.ReplaceAll(defaultPath, "/", "_"), unit.Tags["path"])
+	}
+}
+
+func TestExecUpdaterInvalidOutput(t *testing.T) {
+	currentUser, err := user.Current()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		script string
+		config string
+	}{
+		{
+			name:   "command fails",
+			script: "echo 'project DB not reachable' >&2; exit 1",
+		},
+		{
+			name:   "invalid json",
+			script: "echo 'not json'",
+		},
+		{
+			name:   "units removed",
+			script: `sed 's/"uuid":"2"/"uuid":"1"/'`,
+		},
+		{
+			name:   "units added",
+			script: `sed 's/"uuid":"2"/"uuid":"3"/'`,
+		},
+		{
+			name:   "timeout",
+			script: "sleep 300",
+			config: "timeout: 100ms\n",
+		},
+	}
+
+	for _, test := range tests {
+		instance := mockInstance(t, test.script, fmt.Sprintf("%srun_as: %s", test.config, currentUser.Username))
+
+		u, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.NoError(t, err, test.name)
+
+		// Units must be returned unchanged
+		units := u.Update(context.Background(), time.Now(), time.Now(), mockClusterUnits())
+		assert.Equal(t, mockClusterUnits(), units, test.name)
+	}
+}
+
+func TestExecUpdaterConfig(t *testing.T) {
+	instance := mockInstance(t, "cat", "timeout: 0s")
+
+	// Missing command
+	_, err := New(updater.Instance{ID: "exec-0"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.ErrorIs(t, err, errNoCmd)
+
+	// Invalid timeout
+	_, err = New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.ErrorIs(t, err, errTimeout)
+
+	// Non existent command
+	instance.CLI.Path = filepath.Join(t.TempDir(), "missing")
+	_, err = New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Error(t, err)
+}
+
+func TestMergeUnits(t *testing.T) {
+	// Duplicate units must be rejected
+	_, err := mergeUnits(testUnits, []models.Unit{testUnits[0], testUnits[0], testUnits[1]})
+	require.ErrorIs(t, err, errUnitsAdded)
+
+	// Units can be returned in any order
+	units, err := mergeUnits(testUnits, []models.Unit{testUnits[1], testUnits[0]})
+	require.NoError(t, err)
+	assert.Equal(t, testUnits, units)
+}
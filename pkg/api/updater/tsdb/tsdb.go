@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"maps"
 	"math"
+	"regexp"
 	"strings"
 	"sync"
 	"text/template"
@@ -170,7 +171,7 @@ func (t *tsdbUpdater) fetchAggMetrics(
 
 	// Template data
 	tmplData := map[string]interface{}{
-		"UUIDs":                   strings.Join(uuids, "|"),
+		"UUIDs":                   uuidsRegex(uuids),
 		"ScrapeInterval":          settings.ScrapeInterval,
 		"ScrapeIntervalMilli":     settings.ScrapeInterval.Milliseconds(),
 		"EvaluationInterval":      settings.EvaluationInterval,
@@ -267,7 +268,10 @@ func (t *tsdbUpdater) update(
 		// We get the aggregate metrics of these "ignored" comput units as well but
 		// we will remove time series of metrics from TSDB as they might be not realiable
 		// for small durations
-		if units[i].EndedAtTS > 0 {
+		//
+		// Sub units like job steps are never ignored as their time series are
+		// part of parent unit's time series
+		if units[i].EndedAtTS > 0 && units[i].ParentUUID == "" {
 			if units[i].EndedAtTS-units[i].StartedAtTS < time.Duration(t.config.CutoffDuration).Milliseconds() {
 				ignoredUnits = append(ignoredUnits, uuid)
 				units[i].Ignore = 1
@@ -518,14 +522,25 @@ func (t *tsdbUpdater) deleteTimeSeries(
 	//
 	// Join them with | as delimiter. We will use regex match to match all series
 	// with the label uuid=~"$unitids"
-	allUUIDs := strings.Join(unitUUIDs, "|")
 	matchers := t.config.LabelsToDrop
-	matchers = append(matchers, fmt.Sprintf("{uuid=~\"%s\"}", allUUIDs))
+	matchers = append(matchers, fmt.Sprintf("{uuid=~\"%s\"}", uuidsRegex(unitUUIDs)))
 
 	// Make a API request to delete data of ignored units
 	return t.Delete(ctx, start, end, matchers)
 }
 
+// uuidsRegex returns a regex that matches any of uuids. UUIDs of sub units like
+// job steps, eg 123.0, contain regex meta characters and hence, they are escaped.
+// Backslashes are escaped again as regex is used in double quoted PromQL strings.
+func uuidsRegex(uuids []string) string {
+	quoted := make([]string, len(uuids))
+	for i, uuid := range uuids {
+		quoted[i] = strings.ReplaceAll(regexp.QuoteMeta(uuid), `\`, `\\`)
+	}
+
+	return strings.Join(quoted, "|")
+}
+
 // sanitizeValue verifies if value is either NaN/Inf/-Inf.
 // If value is any of these, zero will be returned. Returns 0 if value is negative.
 func sanitizeValue(val float64) models.JSONFloat {
@@ -8,6 +8,8 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -490,3 +492,137 @@ func TestTSDBUpdateFailNoTSDB(t *testing.T) {
 	updatedUnits := tsdb.Update(context.Background(), time.Now().Add(-5*time.Minute), time.Now(), units)
 	assert.Equal(t, expectedUnits, updatedUnits)
 }
+
+func TestTSDBUpdateSubUnits(t *testing.T) {
+	// Start test server
+	server := mockTSDBServer()
+	defer server.Close()
+
+	// Make mock instance config
+	instance, err := mockInstanceConfig(server.URL)
+	require.NoError(t, err)
+
+	// Current time
+	currTime := time.Now()
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{
+				ID:       "default",
+				Updaters: []string{"default"},
+			},
+			Units: []models.Unit{
+				{
+					UUID:        "1",
+					StartedAtTS: currTime.Add(-3 * time.Second).UnixMilli(),
+					EndedAtTS:   currTime.UnixMilli(),
+				},
+				{
+					UUID:        "1.0",
+					ParentUUID:  "1",
+					StartedAtTS: currTime.Add(-3 * time.Second).UnixMilli(),
+					EndedAtTS:   currTime.UnixMilli(),
+				},
+			},
+		},
+	}
+
+	tsdb, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// Short lived sub units must not be ignored
+	updatedUnits := tsdb.Update(context.Background(), time.Now().Add(-1*time.Minute), time.Now(), units)
+	assert.Equal(t, 1, updatedUnits[0].Units[0].Ignore)
+	assert.Equal(t, 0, updatedUnits[0].Units[1].Ignore)
+}
+
+func TestTSDBUpdateStepUnits(t *testing.T) {
+	var queries []string
+
+	var mu sync.Mutex
+
+	// Series of job steps have a step label along with uuid label
+	expected := tsdb.Response[any]{
+		Status: "success",
+		Data: map[string]interface{}{
+			"resultType": "vector",
+			"result": []interface{}{
+				map[string]interface{}{
+					"metric": map[string]string{"uuid": "1"},
+					"value":  []interface{}{12345, "1.1"},
+				},
+				map[string]interface{}{
+					"metric": map[string]string{"uuid": "1", "step": "0"},
+					"value":  []interface{}{12345, "3.3"},
+				},
+			},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/query") {
+			mu.Lock()
+			queries = append(queries, r.FormValue("query"))
+			mu.Unlock()
+		}
+
+		if err := json.NewEncoder(w).Encode(&expected); err != nil {
+			w.Write([]byte("KO"))
+		}
+	}))
+	defer server.Close()
+
+	config := `
+---
+queries:
+    avg_cpu_usage:
+      usage: avg by (uuid, step) (foo{uuid=~"{{.UUIDs}}"})`
+
+	var extraConfig yaml.Node
+
+	require.NoError(t, yaml.Unmarshal([]byte(config), &extraConfig))
+
+	instance := updater.Instance{
+		ID:      "default",
+		Updater: "tsdb",
+		Web: models.WebConfig{
+			URL: server.URL,
+		},
+		Extra: extraConfig,
+	}
+
+	// Current time
+	currTime := time.Now()
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{
+				ID:       "default",
+				Updaters: []string{"default"},
+			},
+			Units: []models.Unit{
+				{
+					UUID:        "1",
+					StartedAtTS: currTime.Add(-10 * time.Minute).UnixMilli(),
+					EndedAtTS:   currTime.UnixMilli(),
+				},
+				{
+					UUID:        "1.0",
+					ParentUUID:  "1",
+					StartedAtTS: currTime.Add(-10 * time.Minute).UnixMilli(),
+					EndedAtTS:   currTime.UnixMilli(),
+				},
+			},
+		},
+	}
+
+	tsdb, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	updatedUnits := tsdb.Update(context.Background(), time.Now().Add(-5*time.Minute), time.Now(), units)
+
+	// Step unit must get metrics of its own series
+	assert.Equal(t, models.MetricMap{"usage": 1.1}, updatedUnits[0].Units[0].AveCPUUsage)
+	assert.Equal(t, models.MetricMap{"usage": 3.3}, updatedUnits[0].Units[1].AveCPUUsage)
+
+	// UUIDs of step units must be escaped in the regex
+	require.NotEmpty(t, queries)
+	assert.Equal(t, `avg by (uuid, step) (foo{uuid=~"1|1\\.0"})`, queries[0])
+}
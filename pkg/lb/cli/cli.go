@@ -21,7 +21,9 @@ import (
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/mahendrapaipuri/ceems/internal/audit"
 	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/internal/oidc"
 	internal_runtime "github.com/mahendrapaipuri/ceems/internal/runtime"
 	"github.com/mahendrapaipuri/ceems/internal/security"
 	ceems_api "github.com/mahendrapaipuri/ceems/pkg/api/cli"
@@ -53,6 +55,8 @@ type CEEMSLBAppConfig struct {
 // SetDirectory joins any relative file paths with dir.
 func (c *CEEMSLBAppConfig) SetDirectory(dir string) {
 	c.Server.Web.HTTPClientConfig.SetDirectory(dir)
+	c.LB.OIDC.SetDirectory(dir)
+	c.LB.Audit.SetDirectory(dir)
 }
 
 // Validate valides the CEEMS LB config to check if backend servers have IDs set.
@@ -85,7 +89,13 @@ func (c *CEEMSLBAppConfig) Validate() error {
 		}
 	}
 
-	return nil
+	// Validate OIDC config
+	if err := c.LB.OIDC.Validate(); err != nil {
+		return err
+	}
+
+	// Validate audit config
+	return c.LB.Audit.Validate()
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -128,6 +138,8 @@ func (c *CEEMSLBAppConfig) UnmarshalYAML(unmarshal func(interface{}) error) erro
 type CEEMSLBConfig struct {
 	Backends []base.Backend `yaml:"backends"`
 	Strategy string         `yaml:"strategy"`
+	OIDC     oidc.Config    `yaml:"oidc"`
+	Audit    audit.Config   `yaml:"audit"`
 }
 
 // CEEMSLoadBalancer represents the `ceems_lb` cli.
@@ -236,6 +248,16 @@ func (lb *CEEMSLoadBalancer) Main() error {
 			ReadPaths: []string{webConfigFilePath, configFilePath},
 		}
 
+		// Audit log files are rotated and hence, their directory must be writable
+		if config.LB.Audit.Backend == audit.BackendFile {
+			auditDir := filepath.Dir(config.LB.Audit.File)
+			if err := os.MkdirAll(auditDir, 0o700); err != nil {
+				return fmt.Errorf("failed to create directory of audit log: %w", err)
+			}
+
+			securityCfg.ReadWritePaths = append(securityCfg.ReadWritePaths, auditDir)
+		}
+
 		// Drop all unnecessary privileges
 		if err := security.DropPrivileges(securityCfg); err != nil {
 			return err
@@ -265,6 +287,20 @@ func (lb *CEEMSLoadBalancer) Main() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Audit log is shared by all load balancers
+	var auditLogger *audit.Logger
+
+	if config.LB.Audit.Enabled() {
+		if auditLogger, err = frontend.NewAuditLogger(config.LB.Audit, config.Server, logger); err != nil {
+			logger.Error("Failed to setup audit log", "err", err)
+
+			return err
+		}
+
+		// Write pending audit records on exit
+		defer auditLogger.Close()
+	}
+
 	// Make manager and LB maps
 	managers := make(map[base.LBType]serverpool.Manager, 2)
 	lbs := make(map[base.LBType]frontend.LoadBalancer, 2)
@@ -286,6 +322,8 @@ func (lb *CEEMSLoadBalancer) Main() error {
 			WebSystemdSocket: *systemdSocket,
 			WebConfigFile:    webConfigFilePath,
 			APIServer:        config.Server,
+			OIDC:             config.LB.OIDC,
+			Audit:            auditLogger,
 			Manager:          managers[lbType],
 		}
 
@@ -0,0 +1,99 @@
+//go:build cgo
+// +build cgo
+
+package frontend
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/audit"
+	ceems_api_base "github.com/mahendrapaipuri/ceems/pkg/api/base"
+	ceems_api_cli "github.com/mahendrapaipuri/ceems/pkg/api/cli"
+	ceems_api "github.com/mahendrapaipuri/ceems/pkg/api/http"
+	"github.com/mahendrapaipuri/ceems/pkg/lb/base"
+)
+
+// Custom errors.
+var (
+	errAuditNoDB = errors.New("db audit backend needs data path of CEEMS API server")
+)
+
+// auditDB is an audit sink that owns its DB connection.
+type auditDB struct {
+	audit.Sink
+
+	db *sql.DB
+}
+
+// Close closes DB connection.
+func (a *auditDB) Close() error {
+	return a.db.Close()
+}
+
+// NewAuditLogger returns a new audit logger that is shared by all load balancers.
+// With db backend, records are written to CEEMS DB found in the data path of
+// CEEMS API server.
+func NewAuditLogger(c audit.Config, apiServer ceems_api_cli.CEEMSAPIServerConfig, logger *slog.Logger) (*audit.Logger, error) {
+	var sink audit.Sink
+
+	var err error
+
+	switch c.Backend {
+	case audit.BackendDB:
+		if apiServer.Data.Path == "" {
+			return nil, errAuditNoDB
+		}
+
+		dbAbsPath, err := filepath.Abs(filepath.Join(apiServer.Data.Path, ceems_api_base.CEEMSDBName))
+		if err != nil {
+			return nil, err
+		}
+
+		// Audit log is the only data written to DB by LB
+		db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?%s", dbAbsPath, "_mutex=no&_busy_timeout=5000"))
+		if err != nil {
+			return nil, err
+		}
+
+		sink = &auditDB{Sink: ceems_api.NewAuditDBSink(db, logger), db: db}
+	case audit.BackendFile:
+		if sink, err = audit.NewFileSink(c.File, c.MaxSize, c.MaxBackups); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, audit.ErrInvalidBackend
+	}
+
+	return audit.NewLogger(base.CEEMSLoadBalancerAppName, sink, logger), nil
+}
+
+// record adds the access decision of request served with status to audit log.
+func (amw *authenticationMiddleware) record(
+	w *audit.ResponseWriter,
+	r *http.Request,
+	start time.Time,
+	user string,
+	reqParams *ReqParams,
+) {
+	record := audit.NewRecord(r, start, w.Status())
+
+	// User will not be identified when authentication fails. Use the user
+	// sent in the request in that case
+	if record.User = user; user == "" {
+		record.User = r.Header.Get(grafanaUserHeader)
+	}
+
+	if reqParams.clusterID != "" {
+		record.ClusterIDs = audit.ToList([]string{reqParams.clusterID})
+	}
+
+	record.UUIDs = audit.ToList(reqParams.uuids)
+
+	amw.audit.Log(record)
+}
@@ -16,6 +16,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mahendrapaipuri/ceems/internal/audit"
+	"github.com/mahendrapaipuri/ceems/internal/oidc"
 	ceems_api_base "github.com/mahendrapaipuri/ceems/pkg/api/base"
 	ceems_api_cli "github.com/mahendrapaipuri/ceems/pkg/api/cli"
 	ceems_api_http "github.com/mahendrapaipuri/ceems/pkg/api/http"
@@ -29,6 +31,7 @@ import (
 // Custom errors.
 var (
 	ErrUnknownClusterID = errors.New("unknown cluster ID")
+	errNoUserHeader     = errors.New("no user header found")
 )
 
 // RetryContextKey is the key used to set context value for retry.
@@ -61,6 +64,8 @@ type Config struct {
 	WebSystemdSocket bool
 	WebConfigFile    string
 	APIServer        ceems_api_cli.CEEMSAPIServerConfig
+	OIDC             oidc.Config
+	Audit            *audit.Logger
 	Manager          serverpool.Manager
 }
 
@@ -150,8 +155,12 @@ func (lb *loadBalancer) ValidateClusterIDs(ctx context.Context) error {
 			return err
 		}
 
-		// Add necessary headers. Value of header is not important only its presence
-		req.Header.Add(ceemsUserHeader, "admin")
+		// CEEMS API server trusts the header only when its value is the shared
+		// secret. Without secret, request must be authenticated using the
+		// credentials in HTTP client config
+		if lb.amw.ceems.secret != "" {
+			req.Header.Add(ceemsUserHeader, lb.amw.ceems.secret)
+		}
 
 		// Make request
 		// If request failed, forbid the query. It can happen when CEEMS API server
@@ -386,6 +386,13 @@ func TestValidateClusterIDsWithAPIPass(t *testing.T) {
 	}
 
 	ceemsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Shared secret must be sent in CEEMS header
+		if r.Header.Get(ceemsUserHeader) != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
 		if err := json.NewEncoder(w).Encode(&expected); err != nil {
 			w.Write([]byte("KO"))
 		}
@@ -415,6 +422,7 @@ func TestValidateClusterIDsWithAPIPass(t *testing.T) {
 		Address: "localhost:9030", // dummy address
 	}
 	config.APIServer.Web.URL = ceemsServer.URL
+	config.APIServer.Web.LBSecret = "secret"
 
 	// New load balancer
 	lb, err := New(config)
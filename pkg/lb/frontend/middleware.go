@@ -18,7 +18,10 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/mahendrapaipuri/ceems/internal/audit"
+	"github.com/mahendrapaipuri/ceems/internal/oidc"
 	ceems_api_base "github.com/mahendrapaipuri/ceems/pkg/api/base"
 	ceems_api "github.com/mahendrapaipuri/ceems/pkg/api/http"
 	"github.com/mahendrapaipuri/ceems/pkg/lb/base"
@@ -82,6 +85,7 @@ type ceems struct {
 	db     *sql.DB
 	webURL *url.URL
 	client *http.Client
+	secret string // Shared secret sent in X-Ceems-User header to CEEMS API server
 }
 
 func (c *ceems) verifyEndpoint() *url.URL {
@@ -107,6 +111,8 @@ type authenticationMiddleware struct {
 	clusterIDs    []string
 	pathsACLRegex *regexp.Regexp
 	parseRequest  func(*ReqParams, *http.Request) error
+	verifier      *oidc.Verifier
+	audit         *audit.Logger
 }
 
 // newAuthMiddleware setups new auth middleware.
@@ -160,7 +166,16 @@ func newAuthMiddleware(c *Config) (*authenticationMiddleware, error) {
 			db:     db,
 			webURL: ceemsWebURL,
 			client: ceemsClient,
+			secret: string(c.APIServer.Web.LBSecret),
 		},
+		audit: c.Audit,
+	}
+
+	// Verify bearer tokens when OIDC authentication is enabled
+	if c.OIDC.Enabled() {
+		if amw.verifier, err = oidc.NewVerifier(c.OIDC, c.Logger); err != nil {
+			return nil, fmt.Errorf("failed to setup OIDC authentication: %w", err)
+		}
 	}
 
 	// Setup parsing functions based on LB type
@@ -232,15 +247,60 @@ func (amw *authenticationMiddleware) isUserUnit(
 	return true
 }
 
+// authenticate returns the user making the request and true if user is an
+// admin based on the groups in bearer token. Grafana user header is used
+// when OIDC is not enabled or when request does not have a bearer token and
+// header auth fallback is allowed.
+func (amw *authenticationMiddleware) authenticate(r *http.Request) (string, bool, error) {
+	if amw.verifier != nil {
+		if token, err := oidc.BearerToken(r); err == nil {
+			identity, err := amw.verifier.Verify(r.Context(), token)
+			if err != nil {
+				return "", false, fmt.Errorf("invalid bearer token: %w", err)
+			}
+
+			return identity.Username, identity.Admin, nil
+		}
+
+		if !amw.verifier.HeaderAuth() {
+			return "", false, oidc.ErrNoBearerToken
+		}
+	}
+
+	if loggedUser := r.Header.Get(grafanaUserHeader); loggedUser != "" {
+		return loggedUser, false, nil
+	}
+
+	return "", false, errNoUserHeader
+}
+
 // Middleware function, which will be called for each request.
 func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var loggedUser string
 
+		var isAdmin bool
+
+		// Only requests that go through access control are audited
+		var audited bool
+
 		reqParams := &ReqParams{}
 
 		var err error
 
+		// Record access decision in audit log once the request is served
+		if amw.audit != nil {
+			start := time.Now()
+			rw := audit.NewResponseWriter(w)
+			w = rw
+
+			defer func() {
+				if audited {
+					amw.record(rw, r, start, loggedUser, reqParams)
+				}
+			}()
+		}
+
 		// Get cluster id from X-Ceems-Cluster-Id header
 		// This is most important and request parameter that we need
 		// to proxy request. Rest of them are optional
@@ -248,6 +308,8 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 
 		// Verify clusterID is in list of valid cluster IDs
 		if !slices.Contains(amw.clusterIDs, reqParams.clusterID) {
+			audited = true
+
 			// Write an error and stop the handler chain
 			w.WriteHeader(http.StatusBadRequest)
 
@@ -275,6 +337,8 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 			goto end
 		}
 
+		audited = true
+
 		// Clone request, parse query params and set them in request context
 		// This will ensure we set query params in request's context always
 		err = amw.parseRequest(reqParams, r)
@@ -286,10 +350,10 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 		r.Header.Del(adminUserHeader)
 		r.Header.Del(loggedUserHeader)
 
-		// Check if username header is available
-		loggedUser = r.Header.Get(grafanaUserHeader)
-		if loggedUser == "" {
-			amw.logger.Error("Grafana user Header not found. Denying authentication")
+		// Identify user from bearer token or Grafana user header
+		loggedUser, isAdmin, err = amw.authenticate(r)
+		if err != nil {
+			amw.logger.Error("Failed to identify user. Denying authentication", "err", err)
 
 			// Write an error and stop the handler chain
 			w.WriteHeader(http.StatusUnauthorized)
@@ -297,7 +361,7 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 			response := ceems_api.Response[any]{
 				Status:    "error",
 				ErrorType: "unauthorized",
-				Error:     "no user header found",
+				Error:     "no user identified",
 			}
 			if err := json.NewEncoder(w).Encode(&response); err != nil {
 				amw.logger.Error("Failed to encode response", "err", err)
@@ -312,8 +376,9 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 		// Set logged user header
 		r.Header.Set(loggedUserHeader, loggedUser)
 
+		// Admin users identified from groups in bearer token can query all units.
 		// Check if user is querying for his/her own compute units by looking to DB
-		if !amw.isUserUnit(
+		if !isAdmin && !amw.isUserUnit(
 			r.Context(),
 			loggedUser,
 			[]string{reqParams.clusterID},
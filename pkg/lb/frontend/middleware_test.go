@@ -5,19 +5,30 @@ package frontend
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/mahendrapaipuri/ceems/internal/audit"
+	"github.com/mahendrapaipuri/ceems/internal/oidc"
+	ceems_api_cli "github.com/mahendrapaipuri/ceems/pkg/api/cli"
 	http_api "github.com/mahendrapaipuri/ceems/pkg/api/http"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -68,14 +79,15 @@ CREATE TABLE projects (
 	"id" integer not null primary key,
 	"cluster_id" text,
 	"name" text,
-	"users" text
+	"users" text,
+	"coordinators" text
 );
-INSERT INTO projects VALUES(1, 'rm-0', 'prj1', '["usr1","usr2"]');
-INSERT INTO projects VALUES(2, 'rm-0', 'prj2', '["usr2"]');
-INSERT INTO projects VALUES(3, 'rm-0', 'prj3', '["usr3"]');
-INSERT INTO projects VALUES(4, 'rm-1', 'prj1', '["usr1","usr2"]');
-INSERT INTO projects VALUES(5, 'rm-1', 'prj4', '["usr4"]');
-INSERT INTO projects VALUES(6, 'rm-1', 'prj5', '["usr5"]');
+INSERT INTO projects VALUES(1, 'rm-0', 'prj1', '["usr1","usr2"]', '[]');
+INSERT INTO projects VALUES(2, 'rm-0', 'prj2', '["usr2"]', '[]');
+INSERT INTO projects VALUES(3, 'rm-0', 'prj3', '["usr3"]', '["usr1"]');
+INSERT INTO projects VALUES(4, 'rm-1', 'prj1', '["usr1","usr2"]', '[]');
+INSERT INTO projects VALUES(5, 'rm-1', 'prj4', '["usr4"]', '[]');
+INSERT INTO projects VALUES(6, 'rm-1', 'prj5', '["usr5"]', '[]');
 CREATE TABLE users (
 	"id" integer not null primary key,
 	"cluster_id" text,
@@ -278,6 +290,22 @@ func TestMiddlewareWithDB(t *testing.T) {
 			header: true,
 			code:   200,
 		},
+		{
+			name:   "pass due to uuid from coordinated project",
+			req:    "/query?query=foo{uuid=\"1481510\"}&time=1735045414",
+			id:     "rm-0",
+			user:   "usr1",
+			header: true,
+			code:   200,
+		},
+		{
+			name:   "forbid due to uuid from uncoordinated project",
+			req:    "/query?query=foo{uuid=\"1481510\"}&time=1735045414",
+			id:     "rm-1",
+			user:   "usr1",
+			header: true,
+			code:   403,
+		},
 		{
 			name:   "forbid due to no uuid",
 			req:    "/query_range?query=foo{uuid=\"\"}",
@@ -325,3 +353,183 @@ func TestMiddlewareWithDB(t *testing.T) {
 		assert.Equal(t, test.code, resAPI.StatusCode, "%s with API", test.name)
 	}
 }
+
+func TestMiddlewareWithOIDC(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	db, err := setupTestDB(tmpDir)
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	// Write JWKS to a file
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "k1", Use: "sig"}}})
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(tmpDir, "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, jwks, 0o600))
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	verifier, err := oidc.NewVerifier(oidc.Config{
+		Issuer:           "https://idp.example.com",
+		JWKSFile:         jwksFile,
+		Audiences:        []string{"ceems"},
+		UsernameClaim:    "sub",
+		GroupsClaim:      "roles",
+		AdminGroups:      []string{"admin"},
+		HTTPClientConfig: config.DefaultHTTPClientConfig,
+	}, logger)
+	require.NoError(t, err)
+
+	amw := authenticationMiddleware{
+		logger:        logger,
+		clusterIDs:    []string{"rm-0", "rm-1"},
+		ceems:         ceems{db: db},
+		parseRequest:  parseTSDBRequest,
+		pathsACLRegex: regexpTSDBRestrictedPath,
+		verifier:      verifier,
+	}
+	handlerToTest := amw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: key},
+		(&jose.SignerOptions{}).WithHeader(jose.HeaderKey("kid"), "k1"),
+	)
+	require.NoError(t, err)
+
+	token := func(user string, roles ...string) string {
+		token, err := jwt.Signed(signer).Claims(map[string]interface{}{
+			"iss":   "https://idp.example.com",
+			"aud":   "ceems",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"sub":   user,
+			"roles": roles,
+		}).Serialize()
+		require.NoError(t, err)
+
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		req     string
+		headers map[string]string
+		code    int
+	}{
+		{
+			name:    "pass with token of owner",
+			req:     "/query?query=foo{uuid=\"1479763\"}&time=1735045414",
+			headers: map[string]string{"Authorization": "Bearer " + token("usr1")},
+			code:    200,
+		},
+		{
+			name:    "forbid with token of other user",
+			req:     "/query?query=foo{uuid=\"1479765\"}&time=1735045414",
+			headers: map[string]string{"Authorization": "Bearer " + token("usr1")},
+			code:    403,
+		},
+		{
+			name:    "pass with token of admin role",
+			req:     "/query?query=foo{uuid=\"1479765\"}&time=1735045414",
+			headers: map[string]string{"Authorization": "Bearer " + token("usr9", "admin")},
+			code:    200,
+		},
+		{
+			name:    "deny invalid token",
+			req:     "/query?query=foo{uuid=\"1479763\"}&time=1735045414",
+			headers: map[string]string{"Authorization": "Bearer foo.bar.baz", grafanaUserHeader: "usr1"},
+			code:    401,
+		},
+		{
+			name:    "deny header auth when it is disabled",
+			req:     "/query?query=foo{uuid=\"1479763\"}&time=1735045414",
+			headers: map[string]string{grafanaUserHeader: "usr1"},
+			code:    401,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, test.req, nil)
+		request.Header.Set(ceemsClusterIDHeader, "rm-0")
+
+		for k, v := range test.headers {
+			request.Header.Set(k, v)
+		}
+
+		responseRecorder := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(responseRecorder, request)
+
+		res := responseRecorder.Result()
+		res.Body.Close()
+		assert.Equal(t, test.code, res.StatusCode, test.name)
+	}
+}
+
+func TestMiddlewareAudit(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, err := setupTestDB(tmpDir)
+	require.NoError(t, err)
+
+	// DB backend needs CEEMS DB
+	_, err = NewAuditLogger(audit.Config{Backend: audit.BackendDB}, ceems_api_cli.CEEMSAPIServerConfig{}, logger)
+	require.ErrorIs(t, err, errAuditNoDB)
+
+	auditLogger, err := NewAuditLogger(
+		audit.Config{Backend: audit.BackendFile, File: filepath.Join(tmpDir, "audit.log"), MaxSize: 1},
+		ceems_api_cli.CEEMSAPIServerConfig{}, logger,
+	)
+	require.NoError(t, err)
+
+	amw := authenticationMiddleware{
+		logger:        logger,
+		clusterIDs:    []string{"rm-0", "rm-1"},
+		ceems:         ceems{db: db},
+		parseRequest:  parseTSDBRequest,
+		pathsACLRegex: regexpTSDBRestrictedPath,
+		audit:         auditLogger,
+	}
+	handler := amw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, test := range []struct {
+		req  string
+		id   string
+		code int
+	}{
+		{req: "/query?query=foo{uuid=\"1481510\"}&time=1735045414", id: "rm-1", code: 403},
+		{req: "/query?query=foo{uuid=\"1479763\"}&time=1735045414", id: "rm-0", code: 200},
+		{req: "/query?query=foo{uuid=\"1479763\"}&time=1735045414", id: "rm-2", code: 400},
+		{req: "/api/v1/status/buildinfo", id: "rm-0", code: 200},
+	} {
+		request := httptest.NewRequest(http.MethodGet, test.req, nil)
+		request.Header.Set(grafanaUserHeader, "usr1")
+		request.Header.Set(ceemsClusterIDHeader, test.id)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		require.Equal(t, test.code, w.Code, test.req)
+	}
+
+	// Flush pending records
+	require.NoError(t, auditLogger.Close())
+
+	records, err := auditLogger.Query(context.Background(), audit.Filter{To: time.Now().UnixMilli()})
+	require.NoError(t, err)
+
+	// Requests that are not access controlled are not audited
+	require.Len(t, records, 3)
+
+	assert.Equal(t, audit.DecisionError, records[0].Decision)
+	assert.Equal(t, audit.DecisionAllow, records[1].Decision)
+
+	// Denial must contain the queried UUIDs
+	assert.Equal(t, "usr1", records[2].User)
+	assert.Equal(t, "ceems_lb", records[2].Source)
+	assert.Equal(t, audit.DecisionDeny, records[2].Decision)
+	assert.Equal(t, "/query", records[2].Endpoint)
+	assert.Equal(t, models.List{"rm-1"}, records[2].ClusterIDs)
+	assert.Equal(t, models.List{"1481510"}, records[2].UUIDs)
+}
@@ -588,6 +588,14 @@ func (t *TSDB) Query(ctx context.Context, query string, queryTime time.Time) (Me
 					}
 				}
 
+				// Series of job steps have step label and they are identified
+				// by <uuid>.<step> which is the UUID of step unit
+				if step, exists := metric["step"]; exists {
+					if v, ok := step.(string); ok && v != "" {
+						uuid = fmt.Sprintf("%s.%s", uuid, v)
+					}
+				}
+
 				if val, exists := result["value"]; exists {
 					if values, ok = val.([]interface{}); ok {
 						if len(values) > 1 {
@@ -360,6 +360,15 @@ func TestTSDBQuerySuccess(t *testing.T) {
 						12345, "2.2",
 					},
 				},
+				map[string]interface{}{
+					"metric": map[string]string{
+						"uuid": "2",
+						"step": "0",
+					},
+					"value": []interface{}{
+						12345, "3.3",
+					},
+				},
 			},
 		},
 	}
@@ -377,7 +386,7 @@ func TestTSDBQuerySuccess(t *testing.T) {
 
 	m, err := tsdb.Query(context.Background(), "", time.Now())
 	require.NoError(t, err)
-	assert.Equal(t, Metric{"1": 1.1, "2": 2.2}, m)
+	assert.Equal(t, Metric{"1": 1.1, "2": 2.2, "2.0": 3.3}, m)
 }
 
 func TestTSDBQueryFail(t *testing.T) {
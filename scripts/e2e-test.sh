@@ -721,7 +721,7 @@ then
     get -H "X-Grafana-User: grafana" "127.0.0.1:${port}/api/${api_version}/users/admin" > "${fixture_output}"
   elif [ "${scenario}" = "api-cluster-admin-query" ]
   then
-    get -H "X-Ceems-User: usr1" "127.0.0.1:${port}/api/${api_version}/clusters/admin" > "${fixture_output}"
+    get -H "X-Ceems-User: e2e-secret" "127.0.0.1:${port}/api/${api_version}/clusters/admin" > "${fixture_output}"
   elif [ "${scenario}" = "api-uuid-query" ]
   then
     get -H "X-Grafana-User: usr2" "127.0.0.1:${port}/api/${api_version}/units?uuid=1481508&project=acc2&cluster_id=slurm-0" > "${fixture_output}"